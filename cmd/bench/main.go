@@ -0,0 +1,86 @@
+// Command bench runs the broadcast fanout benchmark outside of go test and
+// prints ns/op, B/op and allocs/op per client count and payload size.
+//
+//	go run ./cmd/bench -clients 10,100,1000 -payload 64,1024 -benchtime 2s -cpuprofile cpu.prof
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/YuarenArt/chatters/internal/bench"
+)
+
+func main() {
+	testing.Init()
+	clients := flag.String("clients", "1,10,100,1000", "comma-separated client counts")
+	payloads := flag.String("payload", "64,1024,16384", "comma-separated payload sizes in bytes")
+	benchtime := flag.String("benchtime", "1s", "run time per case, or Nx for N iterations")
+	cpuProfile := flag.String("cpuprofile", "", "write a CPU profile of all cases to this file")
+	memProfile := flag.String("memprofile", "", "write a heap profile after all cases to this file")
+	flag.Parse()
+
+	if err := flag.Set("test.benchtime", *benchtime); err != nil {
+		log.Fatalf("invalid -benchtime: %v", err)
+	}
+	clientCounts, err := parseSizes(*clients)
+	if err != nil {
+		log.Fatalf("invalid -clients: %v", err)
+	}
+	payloadSizes, err := parseSizes(*payloads)
+	if err != nil {
+		log.Fatalf("invalid -payload: %v", err)
+	}
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			log.Fatalf("create CPU profile: %v", err)
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			log.Fatalf("start CPU profile: %v", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	fmt.Printf("goos: %s goarch: %s cpus: %d\n", runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+	for _, c := range bench.Cases(clientCounts, payloadSizes) {
+		result := testing.Benchmark(func(b *testing.B) {
+			bench.Fanout(b, c)
+		})
+		fmt.Printf("BenchmarkFanout/%s\t%s\t%s\n", c.Name(), result.String(), result.MemString())
+	}
+
+	if *memProfile != "" {
+		f, err := os.Create(*memProfile)
+		if err != nil {
+			log.Fatalf("create heap profile: %v", err)
+		}
+		defer f.Close()
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			log.Fatalf("write heap profile: %v", err)
+		}
+	}
+}
+
+// parseSizes parses a comma-separated list of positive integers
+func parseSizes(list string) ([]int, error) {
+	var sizes []int
+	for _, field := range strings.Split(list, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("%q is not a positive integer", field)
+		}
+		sizes = append(sizes, n)
+	}
+	return sizes, nil
+}
@@ -7,9 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
-	"strconv"
 	"syscall"
-	"time"
 
 	_ "github.com/YuarenArt/chatters/docs"
 	"github.com/YuarenArt/chatters/internal/config"
@@ -27,9 +25,18 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	cfg := config.NewConfig()
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		panic("Failed to load config: " + err.Error())
+	}
+
+	logLevel, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		panic("Failed to parse log level: " + err.Error())
+	}
+	logging.SetLevel(logLevel)
 
-	if cfg.IsProfilingEnabled() {
+	if cfg.Profiling {
 		runtime.SetBlockProfileRate(1)
 		runtime.SetMutexProfileFraction(1)
 		runtime.MemProfileRate = 1
@@ -48,27 +55,161 @@ func main() {
 		panic("Failed to initialize logger: " + err.Error())
 	}
 
-	taskPoolSize, err := strconv.Atoi(cfg.TaskPoolSize)
+	shutdownTracing, err := server.SetupTracing(ctx, cfg)
 	if err != nil {
-		panic("Failed to parse task pool size: " + err.Error())
+		panic("Failed to set up tracing: " + err.Error())
 	}
-	taskPool, err := websocket.NewTaskPool(taskPoolSize)
+
+	taskPool, err := websocket.NewTaskPool(cfg.TaskPoolSize)
 	if err != nil {
 		logger.Error(ctx, "Failed to initialize task pool", "error", err.Error())
 		panic("Failed to initialize task pool: " + err.Error())
 	}
 	defer taskPool.Release()
 
+	if err := server.CheckRoomDefaults(cfg); err != nil {
+		panic("Failed to parse default room options: " + err.Error())
+	}
+	if err := server.CheckTokenKeys(cfg); err != nil {
+		panic("Failed to load host token keys: " + err.Error())
+	}
+	if _, err := cfg.Pepper(); err != nil {
+		panic("Failed to read password pepper: " + err.Error())
+	}
+	if err := server.CheckTLS(cfg); err != nil {
+		panic("Failed to load TLS configuration: " + err.Error())
+	}
+	if err := server.CheckTrustedProxies(cfg); err != nil {
+		panic("Failed to parse trusted proxies: " + err.Error())
+	}
+	if err := websocket.ValidateOrigins(cfg.AllowedOriginList()); err != nil {
+		panic("Failed to parse allowed origins: " + err.Error())
+	}
+	usernameScope, err := websocket.ParseUniqueScope(cfg.UsernameScope)
+	if err != nil {
+		panic("Failed to parse username scope: " + err.Error())
+	}
+
+	wireNaming, err := websocket.ParseWireNaming(cfg.WireNaming)
+	if err != nil {
+		panic("Failed to parse wire naming: " + err.Error())
+	}
+	branding := &websocket.Branding{
+		AppName:      cfg.AppName,
+		DefaultName:  cfg.DefaultUsername,
+		BotName:      cfg.BotName,
+		HostName:     cfg.HostName,
+		AutoModName:  cfg.AutoModName,
+		SystemPrefix: cfg.SystemPrefix,
+	}
+	if err := branding.Validate(); err != nil {
+		panic("Failed to parse branding: " + err.Error())
+	}
+	filterAction, err := websocket.ParseFilterAction(cfg.FilterAction)
+	if err != nil {
+		panic("Failed to parse content filter action: " + err.Error())
+	}
+	var filterWords []string
+	if cfg.FilterWordsFile != "" {
+		if filterWords, err = websocket.LoadWordList(cfg.FilterWordsFile); err != nil {
+			panic("Failed to load content filter words: " + err.Error())
+		}
+	}
+
 	hub := websocket.NewHub()
+	hub.Naming = wireNaming
+	hub.Branding = branding
+	occupancyInterval, occupancyRetention := cfg.OccupancySampling()
+	if occupancyInterval > 0 {
+		hub.Occupancy = websocket.NewMemoryOccupancyStore()
+	}
+	if cfg.RedisURL != "" {
+		broker, err := websocket.NewRedisBroker(ctx, cfg.RedisURL)
+		if err != nil {
+			logger.Error(ctx, "Failed to connect to Redis broker", "error", err.Error())
+			panic("Failed to connect to Redis broker: " + err.Error())
+		}
+		defer broker.Close()
+		hub.Broker = broker
+		logger.Info(ctx, "Using Redis broker for room broadcasts")
+
+		moderation, err := websocket.NewRedisModerationStore(ctx, cfg.RedisURL)
+		if err != nil {
+			logger.Error(ctx, "Failed to connect to Redis moderation store", "error", err.Error())
+			panic("Failed to connect to Redis moderation store: " + err.Error())
+		}
+		defer moderation.Close()
+		hub.Moderation = moderation
+	} else if cfg.ModerationDir != "" {
+		moderation, err := websocket.NewFileModerationStore(cfg.ModerationDir)
+		if err != nil {
+			panic("Failed to open moderation store: " + err.Error())
+		}
+		hub.Moderation = moderation
+	}
+	if cfg.UploadS3Bucket != "" {
+		uploads, err := websocket.NewS3UploadStorage(websocket.S3Config{
+			Endpoint:  cfg.UploadS3Endpoint,
+			Region:    cfg.UploadS3Region,
+			Bucket:    cfg.UploadS3Bucket,
+			AccessKey: cfg.UploadS3AccessKey,
+			SecretKey: cfg.UploadS3SecretKey,
+			PublicURL: cfg.UploadS3PublicURL,
+		})
+		if err != nil {
+			panic("Failed to configure S3 upload storage: " + err.Error())
+		}
+		hub.Uploads = uploads
+	} else if cfg.UploadDir != "" {
+		uploads, err := websocket.NewLocalUploadStorage(cfg.UploadDir, cfg.UploadBaseURL)
+		if err != nil {
+			panic("Failed to open upload directory: " + err.Error())
+		}
+		hub.Uploads = uploads
+	}
 	wsHandler := websocket.NewHandler(hub, taskPool)
+	wsHandler.Admission = websocket.NewAdmissionController(cfg.MaxConnections, cfg.RoomShare)
+	wsHandler.PerIP = websocket.NewIPConnectionLimiter(cfg.MaxConnsPerIP)
+	if cfg.Compression > 0 {
+		if err := wsHandler.EnableCompression(&websocket.Compression{Level: cfg.Compression, MinSize: cfg.CompressMin}); err != nil {
+			panic("Failed to configure WebSocket compression: " + err.Error())
+		}
+	}
+	wsHandler.Tickets = websocket.NewTicketStore(websocket.JoinTicketTTL, cfg.DerivedKey(config.KeyPurposeTickets))
+	wsHandler.States = websocket.NewStateTokens(cfg.DerivedKey(config.KeyPurposeState), websocket.ResumeStateTTL)
+	wsHandler.HandshakeTimeout = cfg.HandshakeWindow()
+	if usernameScope != websocket.UniqueNone {
+		wsHandler.Usernames = websocket.NewUsernameRegistry(usernameScope)
+	}
+	wsHandler.Filter = websocket.NewContentFilter(filterWords, filterAction)
+	wsHandler.UseMessageInterceptor(wsHandler.Filter.Interceptor())
+	if cfg.SchemaDir != "" {
+		schemas, err := websocket.LoadPayloadSchemas(cfg.SchemaDir)
+		if err != nil {
+			panic("Failed to load payload schemas: " + err.Error())
+		}
+		wsHandler.SignalingHandler.UseSchemas(schemas)
+		logger.Info(ctx, "Validating message payloads", "types", schemas.Types())
+	}
+
+	if secret, rotation := cfg.Pseudonyms(); rotation > 0 {
+		wsHandler.Pseudonyms = websocket.NewPseudonymizer(secret, rotation)
+	}
+	srv := server.NewServer(cfg.Addr(), *wsHandler, logger, cfg)
+	go hub.RunJanitor(ctx, cfg.RoomIdleWindow(), srv.Metrics)
+	go hub.RunCompactor(ctx, cfg.HistoryCompactInterval(), srv.Metrics)
+	go hub.RunOccupancySampler(ctx, occupancyInterval, occupancyRetention)
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go srv.WatchConfig(ctx, reload)
 
-	srv := server.NewServer(":"+cfg.Port, *wsHandler, logger, cfg)
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
 	serverErrCh := make(chan error, 1)
 	go func() {
-		logger.Info(ctx, "Starting server", "port", cfg.Port)
+		logger.Info(ctx, "Starting server", "addr", cfg.Addr())
 		if err := srv.Run(ctx); err != nil {
 			serverErrCh <- err
 		}
@@ -86,7 +227,7 @@ func main() {
 		}
 	}
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownWindow())
 	defer shutdownCancel()
 
 	logger.Info(ctx, "Shutting down server gracefully...")
@@ -94,6 +235,9 @@ func main() {
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		logger.Error(ctx, "Server forced to shutdown", "error", err.Error())
 	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error(ctx, "Failed to flush traces", "error", err.Error())
+	}
 
 	logger.Info(ctx, "Server exited successfully")
 }
@@ -15,32 +15,48 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/health": {
+        "/api/admin/bans": {
             "get": {
-                "description": "Returns server status",
+                "description": "Returns the bans set through the admin API that are in effect, oldest first (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "admin"
+                ],
+                "summary": "List bans of every room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    }
                 ],
-                "summary": "Health check",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/server.BansResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Admin API is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/api/rooms": {
+            },
             "post": {
-                "description": "Generates and creates a new room with a random ID. Optionally set a password for the room.",
+                "description": "Bans a username (case-insensitive) or an IP address from all rooms, hosts included, and closes matching connections. Omit duration to ban permanently (admin only).",
                 "consumes": [
                     "application/json"
                 ],
@@ -48,68 +64,104 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "rooms"
+                    "admin"
                 ],
-                "summary": "Create a new room",
+                "summary": "Ban user or IP from every room",
                 "parameters": [
                     {
-                        "description": "Room creation request with optional password",
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Ban target and duration",
                         "name": "request",
                         "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.CreateRoomRequest"
+                            "$ref": "#/definitions/server.BanRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Room created successfully with host token",
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/server.CreateRoomResponse"
+                            "$ref": "#/definitions/websocket.Ban"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Server error",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Admin API is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Too many bans",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorResponse"
                         }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
                     }
                 }
-            }
-        },
-        "/api/rooms/{room_id}": {
-            "get": {
-                "description": "Returns room information by ID",
-                "consumes": [
-                    "application/json"
-                ],
+            },
+            "delete": {
+                "description": "Lifts the ban on a username or an IP address set through the admin API (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "rooms"
+                    "admin"
                 ],
-                "summary": "Get room info",
+                "summary": "Lift a ban from every room",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Room ID",
-                        "name": "room_id",
-                        "in": "path",
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Banned username",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Banned IP address",
+                        "name": "ip",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/server.RoomResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
@@ -118,6 +170,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -125,43 +183,68 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Deletes a room (host only)",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/api/admin/connections": {
+            "get": {
+                "description": "Returns the live connections of all rooms, the longest connected first, optionally filtered (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "rooms"
+                    "admin"
                 ],
-                "summary": "Delete room",
+                "summary": "List connections",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username, case-insensitive",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client IP",
+                        "name": "ip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Room ID",
                         "name": "room_id",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Host JWT token",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "description": "Cursor from a previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/server.PageResponse-websocket_ConnectionInfo"
                         }
                     },
                     "400": {
@@ -177,7 +260,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Admin API is disabled",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorResponse"
                         }
@@ -185,9 +268,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/rooms/{room_id}/kick": {
+        "/api/admin/connections/disconnect": {
             "post": {
-                "description": "Removes a user from the room (host only)",
+                "description": "Closes the connections of a username, an IP or both in every room, hosts included. They may reconnect; ban them to keep them out (admin only)",
                 "consumes": [
                     "application/json"
                 ],
@@ -195,31 +278,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "rooms"
+                    "admin"
                 ],
-                "summary": "Kick user from room",
+                "summary": "Disconnect a user everywhere",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Room ID",
-                        "name": "room_id",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "type": "string",
-                        "description": "Host JWT token",
-                        "name": "Authorization",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
                         "in": "header",
                         "required": true
                     },
                     {
-                        "description": "Kick user request",
+                        "description": "Connections to close",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.KickUserRequest"
+                            "$ref": "#/definitions/server.AdminDisconnectMatchingRequest"
                         }
                     }
                 ],
@@ -227,10 +303,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/server.AdminDisconnectResponse"
                         }
                     },
                     "400": {
@@ -246,66 +319,51 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Admin API is disabled",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorResponse"
                         }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/rooms/{room_id}/password": {
-            "put": {
-                "description": "Changes the password of a room (host only)",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/admin/connections/{conn_id}": {
+            "get": {
+                "description": "Returns the room, username and IP of a live connection (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "rooms"
+                    "admin"
                 ],
-                "summary": "Change room password",
+                "summary": "Look up a connection",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Room ID",
-                        "name": "room_id",
+                        "type": "string",
+                        "description": "Connection ID",
+                        "name": "conn_id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Host JWT token",
-                        "name": "Authorization",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "description": "Change password request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/server.ChangePasswordRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/server.ErrorResponse"
+                            "$ref": "#/definitions/websocket.ConnectionInfo"
                         }
                     },
                     "401": {
@@ -321,53 +379,37 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/rooms/{room_id}/validate-password": {
-            "post": {
-                "description": "Validates password for password-protected room",
-                "consumes": [
-                    "application/json"
-                ],
+            },
+            "delete": {
+                "description": "Closes one live connection. The client may reconnect; ban it to keep it out (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "rooms"
+                    "admin"
                 ],
-                "summary": "Validate room password",
+                "summary": "Close a connection",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Room ID",
-                        "name": "room_id",
+                        "type": "string",
+                        "description": "Connection ID",
+                        "name": "conn_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Password validation request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/server.ValidatePasswordRequest"
-                        }
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "boolean"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/server.ErrorResponse"
+                            "$ref": "#/definitions/server.AdminDisconnectResponse"
                         }
                     },
                     "401": {
@@ -385,170 +427,6552 @@ const docTemplate = `{
                 }
             }
         },
-        "/ws/{room_id}": {
+        "/api/admin/rooms": {
             "get": {
-                "description": "Opens a WebSocket connection to the specified room. Optionally provide a username.",
+                "description": "Returns every room on this server, private ones included, ordered by room ID (admin only)",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "websocket"
+                    "admin"
                 ],
-                "summary": "Connect to WebSocket room",
+                "summary": "List all rooms",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Room ID (1-999999999)",
-                        "name": "room_id",
-                        "in": "path",
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Username for chat. If omitted, 'Anonymous' is used",
-                        "name": "username",
+                        "description": "Cursor from a previous page",
+                        "name": "cursor",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Room password if required",
-                        "name": "password",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Host token for room management privileges",
-                        "name": "host_token",
+                        "type": "integer",
+                        "description": "Page size (default 50, max 200)",
+                        "name": "limit",
                         "in": "query"
                     }
                 ],
                 "responses": {
-                    "101": {
-                        "description": "Switching Protocols (WebSocket upgraded)",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.PageResponse-websocket_RoomSummary"
                         }
                     },
                     "400": {
-                        "description": "Bad request or validation error",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/websocket.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Admin API is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/rooms/import": {
+            "post": {
+                "description": "Restores a room from the snapshot sent by another instance during a migration (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Import a migrated room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Room snapshot",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/websocket.RoomSnapshot"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.ImportRoomResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Admin API is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Room already exists",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Tenant room quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/rooms/{room_id}": {
+            "delete": {
+                "description": "Deletes a room and disconnects its clients without the host token (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Force-delete a room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
+                    }
+                }
+            }
+        },
+        "/api/admin/rooms/{room_id}/access-log": {
+            "get": {
+                "description": "Returns the most recent joins, leaves and kicks of the room, oldest first, with the\nconnection ID and address of each member (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Room access log with addresses",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.AccessLogResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/rooms/{room_id}/disconnect": {
+            "post": {
+                "description": "Closes the connections of the listed users, hosts included. Clients may reconnect; ban them to keep them out (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Disconnect clients",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Users to disconnect",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.AdminDisconnectRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.AdminDisconnectResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/rooms/{room_id}/messages/import": {
+            "post": {
+                "description": "Adds historical chat messages to the history of any room without broadcasting them (admin only).\nFollows the rules of the host import endpoint.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Import messages into any room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Messages to import, at most 1000",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.ImportMessagesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid messages",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Import rate exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/rooms/{room_id}/migrate": {
+            "post": {
+                "description": "Hands the room, its history and its recent broadcasts to another instance and tells clients to reconnect there (admin only).\nMessaging and joins are suspended during the handoff. Clients receive a \"migrate\" notification with the URL to reconnect to\nand are closed with code 4005; reconnecting with ?since= set to their last sequence replays what they missed.\nIf the target rejects the snapshot the room resumes on this instance. Targets must be listed in MIGRATION_TARGETS.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Migrate a room to another instance",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Target instance",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.MigrateRoomRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.MigrateRoomResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Target is not in MIGRATION_TARGETS",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Room is already migrating",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Target instance rejected the room",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/stats": {
+            "get": {
+                "description": "Returns server-wide room, client and connection counts (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Server statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.AdminStatsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Admin API is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/webhooks": {
+            "get": {
+                "description": "Returns the webhooks receiving server events, from the configuration and the admin API (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List webhooks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.WebhooksResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Admin API is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Sends room_created, room_deleted, user_joined, user_kicked and message_dropped events to url,\nor only the listed events. Deliveries are signed with the returned secret (admin only).\nThe URL must use https and resolve to a public address; redirects are not followed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Register webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook definition",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateWebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "URL is not https or names a private address",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Admin API is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Too many webhooks",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/webhooks/{webhook_id}": {
+            "delete": {
+                "description": "Stops deliveries to a webhook (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Remove webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/branding": {
+            "get": {
+                "description": "Returns the product name and the username given to clients that join without one",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Branding",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.BrandingResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/health": {
+            "get": {
+                "description": "Returns server status",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reports": {
+            "post": {
+                "description": "Logs a user complaint together with the connection ID from the welcome message,\nwhich is the request_id of the connection in the server logs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "support"
+                ],
+                "summary": "Report a bug",
+                "parameters": [
+                    {
+                        "description": "Bug report",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.BugReportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/server.BugReportResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms": {
+            "get": {
+                "description": "Returns the rooms whose creators opted into the public directory, ordered by room ID.\nPrivate rooms are never listed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "List public rooms",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Only public rooms are listed; false is rejected",
+                        "name": "public",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rooms carrying this tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 200)",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from a previous page, overrides page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.PageResponse-websocket_RoomSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Generates and creates a new room with a random ID. Optionally set a password and override the default room settings.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Create a new room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request without creating a duplicate room",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key, required when API keys are configured",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Room creation request with optional password and settings",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateRoomRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Room created successfully with host token",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateRoomResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or unknown API key",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Room quota or rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}": {
+            "get": {
+                "description": "Returns room information by ID. The ETag header carries the room settings version.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Get room info",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.RoomResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Room settings version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a room (host only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Delete room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/access-log": {
+            "get": {
+                "description": "Returns the most recent joins, leaves and kicks of the room, oldest first, with the\nconnection ID of each member (host only). Hosts are anonymous, so member addresses\nare left out; operators read them from the admin access log.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Room access log",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.AccessLogResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/automod": {
+            "get": {
+                "description": "Returns the auto-moderation rules of the room in evaluation order (host only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Get auto-moderation rules",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ModRulesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces the auto-moderation rules of the room (host only). Rules run in order on chat messages of members.\nA message matches a rule when all of its conditions hold: words, pattern, sender age and message rate.\nFlag rules record a host-only \"flagged\" event; the first matching delete, mute or kick rule drops the message,\nacts on the sender and records a host-only \"automod\" event. Hosts are never moderated.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Set auto-moderation rules",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Rules in evaluation order",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.SetModRulesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ModRulesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/bans": {
+            "get": {
+                "description": "Returns the bans currently in effect, oldest first (host only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "List bans",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.BansResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Bans a username or an IP address from the room and disconnects matching members (host only). Unlike a kick, banned users cannot rejoin until the ban expires or is lifted. Omit duration to ban permanently.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Ban user or IP",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Ban target and duration",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.BanRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.Ban"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Too many bans",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Lifts the ban on a username or an IP address (host only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Lift ban",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Banned username",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Banned IP address",
+                        "name": "ip",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/breakouts": {
+            "get": {
+                "description": "Returns the breakout rooms of the room with their online member counts (host only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "breakouts"
+                ],
+                "summary": "List breakout rooms",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Parent room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token of the parent room",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.BreakoutsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a private breakout room of the room with the same password and returns a host token for it (host only).\nBreakout rooms are deleted together with their parent room.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "breakouts"
+                ],
+                "summary": "Create breakout room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Parent room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token of the parent room",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Breakout room settings",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateBreakoutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateRoomResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Too many breakout rooms, or the room is a breakout room",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Room quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/breakouts/recall": {
+            "post": {
+                "description": "Sends every member of the room's breakout rooms a \"move\" message with a join ticket back to the room (host only).\nThe breakout rooms stay open until they are deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "breakouts"
+                ],
+                "summary": "Recall breakout rooms",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Parent room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token of the parent room",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.RecallBreakoutsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/breakouts/{breakout_id}/move": {
+            "post": {
+                "description": "Sends each listed member of the room a \"move\" message with a join ticket for the breakout room (host only).\nMembers leave and rejoin on their own; the ticket replaces the room password.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "breakouts"
+                ],
+                "summary": "Move users to breakout room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Parent room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Breakout room ID",
+                        "name": "breakout_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token of the parent room",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Users to move",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.MoveToBreakoutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.MoveToBreakoutResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/close": {
+            "post": {
+                "description": "Announces that the room closes after the given delay, repeats \"closing\" warnings to members as the deadline approaches, then sends \"closed\" and disconnects everyone gracefully (host only). Calling it again replaces the countdown.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Schedule room close",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delay before the room closes, e.g. 5m (default 0, max 24h)",
+                        "name": "in",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/server.CloseRoomResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/events": {
+            "get": {
+                "description": "Returns room events newer than since, oldest first: joins, leaves, chat messages,\nmoderation actions and settings changes, with the payload members received.\nBans and unbans are only listed for the host. Password-protected rooms require\nthe host token or the room password. Poll with since set to next_since.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Room event feed",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event already seen (default 0)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum events to return (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.EventsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/filter": {
+            "get": {
+                "description": "Returns the content filter override of the room (host only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Get room content filter",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.RoomContentFilter"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces the room's content filter action and extra blocked words (host only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Set room content filter",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Filter override",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.SetContentFilterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.RoomContentFilter"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/freeze": {
+            "post": {
+                "description": "Suspends all non-host messaging and joins (host only). Omit duration to freeze until unfrozen.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Freeze room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Optional freeze duration",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/server.FreezeRoomRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/hooks": {
+            "get": {
+                "description": "Returns the bot hooks registered in the room (host only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "hooks"
+                ],
+                "summary": "List bot hooks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/websocket.BotHook"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Forwards chat messages starting with prefix to url (host only). The bot replies via the hook reply endpoint using the returned token.\nThe URL must use https and resolve to a public address; redirects are not followed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "hooks"
+                ],
+                "summary": "Register bot hook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Hook definition",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateHookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateHookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid room ID, or the URL is not https or names a private address",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Room has too many hooks",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/hooks/{hook_id}": {
+            "delete": {
+                "description": "Unregisters a bot hook (host only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "hooks"
+                ],
+                "summary": "Remove bot hook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hook ID",
+                        "name": "hook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/hooks/{hook_id}/reply": {
+            "post": {
+                "description": "Posts a chat message to the room as the hook's bot. Authenticated with the token returned at registration.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "hooks"
+                ],
+                "summary": "Bot reply",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hook ID",
+                        "name": "hook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hook token",
+                        "name": "X-Hook-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Reply",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.HookReplyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/host/transfer": {
+            "post": {
+                "description": "Hands the caller's host privileges to a connected member (host only). The member receives a new host token over its WebSocket in an \"auth\" message; the caller's token stops working. Use the \"promote\" WebSocket message to add a co-host instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Transfer host role",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Member to hand the host role to",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.TransferHostRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Member is already a host",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/join-ticket": {
+            "post": {
+                "description": "Validates the room password and returns a short-lived single-use ticket to pass as the ticket query parameter of the WebSocket URL",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Issue join ticket",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Room password, if the room has one",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/server.JoinTicketRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.JoinTicketResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid password",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/kick": {
+            "post": {
+                "description": "Removes a user from the room (host only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Kick user from room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Kick user request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.KickUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/kick-bulk": {
+            "post": {
+                "description": "Atomically removes all listed users from the room (host only) and reports per-user results",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Kick several users from room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Users to kick",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.KickBulkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.KickBulkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/lock": {
+            "post": {
+                "description": "Turns away all new members, even with the room password, while current members keep chatting and may reconnect (host only).\nUnlike a freeze, messaging continues; unlike a password change, nobody can get in until the room is unlocked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Lock room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/members": {
+            "get": {
+                "description": "Returns clients currently connected to the room, ordered by join time.\nPassword-protected rooms require the host token or the room password.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "List room members",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from a previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.PageResponse-websocket_MemberInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/messages": {
+            "post": {
+                "description": "Broadcasts a chat message to the room as a bot, for integrations without a WebSocket connection.\nAuthenticated with the room's host token or, when API keys are configured, the API key owning the room.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Post message",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key owning the room",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Message",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.PostMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Tenant message quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/messages/import": {
+            "post": {
+                "description": "Adds historical chat messages, e.g. from another chat system, to the room history without broadcasting them (host only).\nMessages get new IDs in sending order and are merged with the stored ones by sending time; the history limit and retention apply.\nA room imports at most 5000 messages per minute.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Import messages",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Messages to import, at most 1000",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.ImportMessagesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid messages",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Import rate exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/messages/search": {
+            "get": {
+                "description": "Full-text search over stored chat messages. Every word of q must appear in the message text (case-insensitive).\nPassword-protected rooms require the host token or the room password.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Search room messages",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Words to search for",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only messages sent at or after this RFC 3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only messages sent at or before this RFC 3339 time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from a previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.PageResponse-websocket_StoredMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid time range",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/metadata": {
+            "get": {
+                "description": "Returns the application-defined key/value metadata of the room. Protected rooms require the host token or X-Room-Password.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Get room metadata",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/metadata/{key}": {
+            "put": {
+                "description": "Stores a value under key and notifies connected clients (host only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Set room metadata key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Metadata key (1-64 characters)",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Metadata value",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.SetMetadataRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Room has too many metadata keys",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes a metadata key and notifies connected clients (host only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Delete room metadata key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Metadata key",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/mute": {
+            "post": {
+                "description": "Drops the chat messages of a connected member until unmuted (host only). The member is told it is muted when it tries to chat.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Mute user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Member to mute",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.MuteUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Hosts cannot be muted",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/password": {
+            "put": {
+                "description": "Changes the password of a room (host only). Requires If-Match with the current settings ETag.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Change room password",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Settings ETag returned by room info",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Change password request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New room settings version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Settings were changed concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/password-attempts": {
+            "get": {
+                "description": "Returns failed join-password attempts for the room grouped by hashed source address (host only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Failed password attempts",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.PasswordAudit"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/refresh-token": {
+            "post": {
+                "description": "Exchanges a valid host token, or one that expired within the refresh grace window, for a new one valid for another 24 hours",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Refresh host token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.RefreshTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/settings": {
+            "patch": {
+                "description": "Partially updates room settings (host only). Requires If-Match with the current settings ETag.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Update room settings",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Settings ETag returned by room info",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Settings to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.UpdateRoomSettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.RoomSettingsResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New room settings version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Settings were changed concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/stats": {
+            "get": {
+                "description": "Returns live statistics of a room, including p50/p99 heartbeat RTT of connected clients,\nand its occupancy history sampled over time. The history stays available for a while\nafter the room ends, when only the occupancy is returned and ended is true.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Get room statistics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.RoomStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/unfreeze": {
+            "post": {
+                "description": "Resumes messaging and joins in a frozen room (host only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Unfreeze room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/unlock": {
+            "post": {
+                "description": "Lets new members join a locked room again (host only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Unlock room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/unmute": {
+            "post": {
+                "description": "Lets a muted member chat again (host only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Unmute user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Member to unmute",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.MuteUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/uploads": {
+            "post": {
+                "description": "Stores a file for the room and returns the URL to share it with an attachment message.\nThe type is detected from the content and must be one of UPLOAD_TYPES; files are deleted with the room.\nPassword-protected rooms require the room password or the host token.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Upload a file",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.Upload"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Room not found or uploads disabled",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Too many uploads in the room",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "File type not accepted",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/validate-password": {
+            "post": {
+                "description": "Validates password for password-protected room",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Validate room password",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Password validation request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidatePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/webhooks": {
+            "get": {
+                "description": "Returns the webhooks receiving the messages of the room (host only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "hooks"
+                ],
+                "summary": "List room webhooks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/websocket.RoomWebhook"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Sends every chat message of the room to url as a room_message event, signed with the returned secret\nlike server webhooks (host only). Up to 20 messages per second are delivered; the dropped field of\na delivery counts the messages skipped since the previous one. The URL must use https and resolve\nto a public address; redirects are not followed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "hooks"
+                ],
+                "summary": "Register room webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook definition",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateRoomWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateRoomWebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid room ID, or the URL is not https or names a private address",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Room has too many webhooks",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/server.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rooms/{room_id}/webhooks/{webhook_id}": {
+            "delete": {
+                "description": "Stops mirroring the messages of the room to a webhook (host only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "hooks"
+                ],
+                "summary": "Remove room webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host JWT token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/session": {
+            "get": {
+                "description": "Returns the anonymous user ID bound to the session cookie, issuing the cookie if needed,\nand the pseudonymous ID rooms use for bans and rate limits",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Current session",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.SessionResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/usage": {
+            "get": {
+                "description": "Returns rooms, connections and messages used by the API key and the quotas applied to it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "usage"
+                ],
+                "summary": "API key usage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.UsageResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API keys are not configured",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/usernames/suggest": {
+            "get": {
+                "description": "Returns random adjective-noun usernames that pass validation, are not blocked by the\nserver or room word filters and are not taken in the username uniqueness scope.\nNames are not reserved: joining with one may still fail if someone takes it first.\nFewer names than requested are returned when few remain free.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Suggest usernames",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "room_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Number of suggestions, 1 to 20",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.UsernameSuggestionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/socket.io/": {
+            "get": {
+                "description": "Engine.IO v4 compatible endpoint. Socket.IO events map to message types.\nSessions may open over HTTP long-polling (GET without sid), exchanging packets with GET\nand POST requests carrying the sid, but must upgrade to the websocket transport within\n30 seconds: members join the room once upgraded, and events sent while polling are delivered then.",
+                "tags": [
+                    "websocket"
+                ],
+                "summary": "Connect to a room using Socket.IO",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Engine.IO protocol version, must be 4",
+                        "name": "EIO",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transport, websocket or polling",
+                        "name": "transport",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session ID from the polling handshake, to poll or upgrade the session",
+                        "name": "sid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Room ID (1-999999999)",
+                        "name": "room_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username for chat. If omitted, 'Anonymous' is used",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Single-use join ticket from the join-ticket endpoint, required for password-protected rooms",
+                        "name": "ticket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host token for room management privileges",
+                        "name": "host_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols (WebSocket upgraded)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "200": {
+                        "description": "Engine.IO packets of the polling transport",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, validation error or unknown session",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid join ticket",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Banned from the room",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Room not found",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Engine.IO v4 compatible endpoint. Socket.IO events map to message types.\nSessions may open over HTTP long-polling (GET without sid), exchanging packets with GET\nand POST requests carrying the sid, but must upgrade to the websocket transport within\n30 seconds: members join the room once upgraded, and events sent while polling are delivered then.",
+                "tags": [
+                    "websocket"
+                ],
+                "summary": "Connect to a room using Socket.IO",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Engine.IO protocol version, must be 4",
+                        "name": "EIO",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transport, websocket or polling",
+                        "name": "transport",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session ID from the polling handshake, to poll or upgrade the session",
+                        "name": "sid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Room ID (1-999999999)",
+                        "name": "room_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username for chat. If omitted, 'Anonymous' is used",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Single-use join ticket from the join-ticket endpoint, required for password-protected rooms",
+                        "name": "ticket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host token for room management privileges",
+                        "name": "host_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols (WebSocket upgraded)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "200": {
+                        "description": "Engine.IO packets of the polling transport",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, validation error or unknown session",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid join ticket",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Banned from the room",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Room not found",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws/{room_id}": {
+            "get": {
+                "description": "Opens a WebSocket connection to the specified room. Optionally provide a username.",
+                "tags": [
+                    "websocket"
+                ],
+                "summary": "Connect to WebSocket room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID (1-999999999)",
+                        "name": "room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username for chat. If omitted, 'Anonymous' is used",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Single-use join ticket from the join-ticket endpoint, required for password-protected rooms",
+                        "name": "ticket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Host token for room management privileges",
+                        "name": "host_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resume token from the welcome message to reconnect within the grace period",
+                        "name": "resume",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Replay buffered broadcasts with a sequence number greater than this",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "State token from a state message to resume the same identity, e.g. after a server restart",
+                        "name": "state",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols (WebSocket upgraded)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request or validation error",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid join ticket",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Banned from the room",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Room not found",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Room is full or username is taken",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Room is frozen or locked",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Connection quota of the API key or the client address exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is at connection capacity",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "server.AccessLogResponse": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.AccessEntry"
+                    }
+                }
+            }
+        },
+        "server.AdminDisconnectMatchingRequest": {
+            "type": "object",
+            "properties": {
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "spammer1"
+                }
+            }
+        },
+        "server.AdminDisconnectRequest": {
+            "type": "object",
+            "required": [
+                "usernames"
+            ],
+            "properties": {
+                "usernames": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "john_doe"
+                    ]
+                }
+            }
+        },
+        "server.AdminDisconnectResponse": {
+            "type": "object",
+            "properties": {
+                "disconnected": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "server.AdminStatsResponse": {
+            "type": "object",
+            "properties": {
+                "clients": {
+                    "type": "integer",
+                    "example": 87
+                },
+                "connections": {
+                    "type": "integer",
+                    "example": 87
+                },
+                "goroutines": {
+                    "type": "integer",
+                    "example": 412
+                },
+                "public_rooms": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "rooms": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "started_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "uptime_seconds": {
+                    "type": "integer",
+                    "example": 3600
+                }
+            }
+        },
+        "server.BanRequest": {
+            "type": "object",
+            "properties": {
+                "duration_seconds": {
+                    "type": "integer",
+                    "maximum": 31536000,
+                    "minimum": 0,
+                    "example": 3600
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "spammer1"
+                }
+            }
+        },
+        "server.BansResponse": {
+            "type": "object",
+            "properties": {
+                "bans": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.Ban"
+                    }
+                }
+            }
+        },
+        "server.BrandingResponse": {
+            "type": "object",
+            "properties": {
+                "app_name": {
+                    "type": "string",
+                    "example": "Chatters"
+                },
+                "default_username": {
+                    "type": "string",
+                    "example": "Anonymous"
+                }
+            }
+        },
+        "server.BreakoutsResponse": {
+            "type": "object",
+            "properties": {
+                "breakouts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.BreakoutInfo"
+                    }
+                }
+            }
+        },
+        "server.BugReportRequest": {
+            "type": "object",
+            "required": [
+                "connection_id",
+                "description"
+            ],
+            "properties": {
+                "connection_id": {
+                    "type": "string",
+                    "example": "5c1e0b8f-4a55-9f59-3f0c-2b8d9a105a5e"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 2000,
+                    "example": "Messages stopped arriving after reconnecting"
+                },
+                "room_id": {
+                    "type": "integer",
+                    "maximum": 999999999,
+                    "minimum": 1,
+                    "example": 123456
+                }
+            }
+        },
+        "server.BugReportResponse": {
+            "type": "object",
+            "properties": {
+                "connection_id": {
+                    "type": "string",
+                    "example": "5c1e0b8f-4a55-9f59-3f0c-2b8d9a105a5e"
+                },
+                "report_id": {
+                    "type": "string",
+                    "example": "9a10b8f5-3f0c-2b8d-4a55-0b8f5a5e5c1e"
+                }
+            }
+        },
+        "server.ChangePasswordRequest": {
+            "type": "object",
+            "properties": {
+                "new_password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "example": "newpassword456"
+                }
+            }
+        },
+        "server.CloseRoomResponse": {
+            "type": "object",
+            "properties": {
+                "closes_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:05:00Z"
+                }
+            }
+        },
+        "server.CreateBreakoutRequest": {
+            "type": "object",
+            "properties": {
+                "max_clients": {
+                    "type": "integer",
+                    "maximum": 10000,
+                    "minimum": 0,
+                    "example": 8
+                },
+                "topic": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Group A"
+                }
+            }
+        },
+        "server.CreateHookRequest": {
+            "type": "object",
+            "required": [
+                "bot_name",
+                "prefix",
+                "url"
+            ],
+            "properties": {
+                "bot_name": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "WeatherBot"
+                },
+                "prefix": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "!weather"
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://bots.example.com/weather"
+                }
+            }
+        },
+        "server.CreateHookResponse": {
+            "type": "object",
+            "properties": {
+                "bot_name": {
+                    "type": "string",
+                    "example": "WeatherBot"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "!weather"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "9f59e3c1..."
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://bots.example.com/weather"
+                }
+            }
+        },
+        "server.CreateRoomRequest": {
+            "type": "object",
+            "properties": {
+                "history_limit": {
+                    "type": "integer",
+                    "maximum": 10000,
+                    "minimum": 0,
+                    "example": 500
+                },
+                "max_clients": {
+                    "type": "integer",
+                    "maximum": 10000,
+                    "minimum": 0,
+                    "example": 50
+                },
+                "ordered_delivery": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "example": "mypassword123"
+                },
+                "retention_seconds": {
+                    "type": "integer",
+                    "maximum": 2592000,
+                    "minimum": 0,
+                    "example": 86400
+                },
+                "slow_consumer": {
+                    "type": "string",
+                    "enum": [
+                        "disconnect",
+                        "drop_oldest",
+                        "buffer_to_disk"
+                    ],
+                    "example": "drop_oldest"
+                },
+                "slow_mode_seconds": {
+                    "type": "integer",
+                    "maximum": 3600,
+                    "minimum": 0,
+                    "example": 5
+                },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "golang"
+                    ]
+                },
+                "topic": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Weekly sync"
+                },
+                "visibility": {
+                    "type": "string",
+                    "enum": [
+                        "private",
+                        "public"
+                    ],
+                    "example": "public"
+                }
+            }
+        },
+        "server.CreateRoomResponse": {
+            "type": "object",
+            "properties": {
+                "host_token": {
+                    "type": "string"
+                },
+                "room_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.CreateRoomWebhookRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://archive.example.com/chatters"
+                }
+            }
+        },
+        "server.CreateRoomWebhookResponse": {
+            "type": "object",
+            "properties": {
+                "secret": {
+                    "type": "string",
+                    "example": "9c1d0f..."
+                },
+                "webhook": {
+                    "$ref": "#/definitions/websocket.RoomWebhook"
+                }
+            }
+        },
+        "server.CreateWebhookRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "maxItems": 5,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "user_kicked"
+                    ]
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://ops.example.com/chatters"
+                }
+            }
+        },
+        "server.CreateWebhookResponse": {
+            "type": "object",
+            "properties": {
+                "secret": {
+                    "type": "string",
+                    "example": "9c1d0f..."
+                },
+                "webhook": {
+                    "$ref": "#/definitions/server.Webhook"
+                }
+            }
+        },
+        "server.ErrorResponse": {
+            "description": "Code is the HTTP status, ErrorCode a stable machine-readable identifier",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "error": {
+                    "type": "string",
+                    "example": "room not found"
+                },
+                "error_code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/websocket.ErrorCode"
+                        }
+                    ],
+                    "example": "ROOM_NOT_FOUND"
+                }
+            }
+        },
+        "server.EventsResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.RoomEvent"
+                    }
+                },
+                "next_since": {
+                    "description": "NextSince is the since value for the next request",
+                    "type": "integer",
+                    "example": 17
+                },
+                "truncated": {
+                    "description": "Truncated is set when events after since were already dropped and state should be refetched",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "server.FreezeRoomRequest": {
+            "type": "object",
+            "properties": {
+                "duration_seconds": {
+                    "type": "integer",
+                    "maximum": 86400,
+                    "minimum": 0,
+                    "example": 300
+                }
+            }
+        },
+        "server.HookReplyRequest": {
+            "type": "object",
+            "required": [
+                "text"
+            ],
+            "properties": {
+                "text": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "example": "Berlin: 21°C, sunny"
+                }
+            }
+        },
+        "server.ImportMessagesRequest": {
+            "type": "object",
+            "required": [
+                "messages"
+            ],
+            "properties": {
+                "messages": {
+                    "type": "array",
+                    "maxItems": 1000,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/websocket.ImportedMessage"
+                    }
+                }
+            }
+        },
+        "server.ImportRoomResponse": {
+            "type": "object",
+            "properties": {
+                "last_seq": {
+                    "type": "integer",
+                    "example": 1024
+                },
+                "room_id": {
+                    "type": "integer",
+                    "example": 123456
+                }
+            }
+        },
+        "server.JoinTicketRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "example": "mypassword123"
+                }
+            }
+        },
+        "server.JoinTicketResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:30Z"
+                },
+                "ticket": {
+                    "type": "string",
+                    "example": "pQ3v0bX2..."
+                }
+            }
+        },
+        "server.KickBulkRequest": {
+            "type": "object",
+            "required": [
+                "usernames"
+            ],
+            "properties": {
+                "usernames": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "spammer1",
+                        "spammer2"
+                    ]
+                }
+            }
+        },
+        "server.KickBulkResponse": {
+            "type": "object",
+            "properties": {
+                "kicked": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.KickResultResponse"
+                    }
+                }
+            }
+        },
+        "server.KickResultResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "user not found in room"
+                },
+                "kicked": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "username": {
+                    "type": "string",
+                    "example": "spammer1"
+                }
+            }
+        },
+        "server.KickUserRequest": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "john_doe"
+                }
+            }
+        },
+        "server.MigrateRoomRequest": {
+            "type": "object",
+            "required": [
+                "target"
+            ],
+            "properties": {
+                "client_url": {
+                    "description": "ClientURL is the base URL clients reconnect to, Target if empty",
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://chat-2.example.com"
+                },
+                "target": {
+                    "description": "Target is the base URL the snapshot is sent to, one of MIGRATION_TARGETS",
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "http://chat-2.internal:8080"
+                }
+            }
+        },
+        "server.MigrateRoomResponse": {
+            "type": "object",
+            "properties": {
+                "client_url": {
+                    "type": "string",
+                    "example": "https://chat-2.example.com"
+                },
+                "last_seq": {
+                    "type": "integer",
+                    "example": 1024
+                },
+                "room_id": {
+                    "type": "integer",
+                    "example": 123456
+                }
+            }
+        },
+        "server.ModRulesResponse": {
+            "type": "object",
+            "properties": {
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.ModRule"
+                    }
+                }
+            }
+        },
+        "server.MoveResultResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "user not found in room"
+                },
+                "moved": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "username": {
+                    "type": "string",
+                    "example": "alice"
+                }
+            }
+        },
+        "server.MoveToBreakoutRequest": {
+            "type": "object",
+            "required": [
+                "usernames"
+            ],
+            "properties": {
+                "usernames": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "alice",
+                        "bob"
+                    ]
+                }
+            }
+        },
+        "server.MoveToBreakoutResponse": {
+            "type": "object",
+            "properties": {
+                "moved": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.MoveResultResponse"
+                    }
+                }
+            }
+        },
+        "server.MuteUserRequest": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "john_doe"
+                }
+            }
+        },
+        "server.OccupancyResponse": {
+            "type": "object",
+            "properties": {
+                "peak": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "samples": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.OccupancySample"
+                    }
+                }
+            }
+        },
+        "server.PageResponse-websocket_ConnectionInfo": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.ConnectionInfo"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "bzo1MA"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "server.PageResponse-websocket_MemberInfo": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.MemberInfo"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "bzo1MA"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "server.PageResponse-websocket_RoomSummary": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.RoomSummary"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "bzo1MA"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "server.PageResponse-websocket_StoredMessage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.StoredMessage"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "bzo1MA"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "server.PostMessageRequest": {
+            "type": "object",
+            "required": [
+                "text"
+            ],
+            "properties": {
+                "bot_name": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "StatusBot"
+                },
+                "text": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "example": "Maintenance starts in 10 minutes"
+                }
+            }
+        },
+        "server.RTTStatsResponse": {
+            "type": "object",
+            "properties": {
+                "p50_ms": {
+                    "type": "integer",
+                    "example": 35
+                },
+                "p99_ms": {
+                    "type": "integer",
+                    "example": 180
+                },
+                "samples": {
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "server.RecallBreakoutsResponse": {
+            "type": "object",
+            "properties": {
+                "recalled": {
+                    "type": "integer",
+                    "example": 6
+                }
+            }
+        },
+        "server.RefreshTokenResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-02T12:00:00Z"
+                },
+                "host_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.RoomResponse": {
+            "type": "object",
+            "properties": {
+                "client_count": {
+                    "type": "integer"
+                },
+                "closes_at": {
+                    "type": "string"
+                },
+                "has_password": {
+                    "type": "boolean"
+                },
+                "host_id": {
+                    "type": "string"
+                },
+                "host_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "locked": {
+                    "type": "boolean"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "ordered_delivery": {
+                    "type": "boolean"
+                },
+                "room_id": {
+                    "type": "integer"
+                },
+                "settings_version": {
+                    "type": "integer"
+                },
+                "slow_consumer": {
+                    "type": "string",
+                    "example": "disconnect"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "topic": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.RoomSettingsResponse": {
+            "type": "object",
+            "properties": {
+                "has_password": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "max_clients": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "retention_seconds": {
+                    "type": "integer",
+                    "example": 86400
+                },
+                "room_id": {
+                    "type": "integer",
+                    "example": 123456
+                },
+                "settings_version": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "slow_mode_seconds": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "topic": {
+                    "type": "string",
+                    "example": "Weekly sync"
+                },
+                "visibility": {
+                    "type": "string",
+                    "example": "public"
+                },
+                "welcome_message": {
+                    "type": "string",
+                    "example": "Welcome! Please read the pinned agenda."
+                }
+            }
+        },
+        "server.RoomStatsResponse": {
+            "type": "object",
+            "properties": {
+                "client_count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "ended": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "occupancy": {
+                    "$ref": "#/definitions/server.OccupancyResponse"
+                },
+                "room_id": {
+                    "type": "integer",
+                    "example": 123456
+                },
+                "rtt": {
+                    "$ref": "#/definitions/server.RTTStatsResponse"
+                }
+            }
+        },
+        "server.SessionResponse": {
+            "type": "object",
+            "properties": {
+                "pseudonym": {
+                    "description": "Pseudonym identifies the caller for bans and rate limits. It is derived\nfrom the session cookie, or from the caller's network and browser until the\ncookie is sent back, and changes every rotation period.",
+                    "type": "string",
+                    "example": "anon_Xr3kQ9vT2mLw8pZc1nYb0A"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "0b8f5a5e-5c1e-4a55-9f59-3f0c2b8d9a10"
+                }
+            }
+        },
+        "server.SetContentFilterRequest": {
+            "type": "object",
+            "required": [
+                "words"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "off",
+                        "flag",
+                        "mask",
+                        "reject"
+                    ],
+                    "example": "reject"
+                },
+                "words": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "spoiler"
+                    ]
+                }
+            }
+        },
+        "server.SetMetadataRequest": {
+            "type": "object",
+            "properties": {
+                "value": {
+                    "type": "string",
+                    "maxLength": 512,
+                    "example": "https://docs.example.com/agenda"
+                }
+            }
+        },
+        "server.SetModRulesRequest": {
+            "type": "object",
+            "properties": {
+                "rules": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "$ref": "#/definitions/websocket.ModRule"
+                    }
+                }
+            }
+        },
+        "server.TenantQuotaResponse": {
+            "type": "object",
+            "properties": {
+                "max_connections": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "max_messages": {
+                    "type": "integer",
+                    "example": 100000
+                },
+                "max_rooms": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "message_window_seconds": {
+                    "type": "integer",
+                    "example": 3600
+                }
+            }
+        },
+        "server.TransferHostRequest": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "john_doe"
+                }
+            }
+        },
+        "server.UpdateRoomSettingsRequest": {
+            "type": "object",
+            "properties": {
+                "max_clients": {
+                    "type": "integer",
+                    "maximum": 10000,
+                    "minimum": 0,
+                    "example": 50
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "example": "newpassword456"
+                },
+                "retention_seconds": {
+                    "type": "integer",
+                    "maximum": 2592000,
+                    "minimum": 0,
+                    "example": 86400
+                },
+                "slow_mode_seconds": {
+                    "type": "integer",
+                    "maximum": 3600,
+                    "minimum": 0,
+                    "example": 5
+                },
+                "topic": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Weekly sync"
+                },
+                "visibility": {
+                    "type": "string",
+                    "enum": [
+                        "private",
+                        "public"
+                    ],
+                    "example": "public"
+                },
+                "welcome_message": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "example": "Welcome! Please read the pinned agenda."
+                }
+            }
+        },
+        "server.UsageResponse": {
+            "type": "object",
+            "properties": {
+                "quota": {
+                    "$ref": "#/definitions/server.TenantQuotaResponse"
+                },
+                "usage": {
+                    "$ref": "#/definitions/websocket.TenantUsage"
+                }
+            }
+        },
+        "server.UsernameSuggestionsResponse": {
+            "type": "object",
+            "properties": {
+                "scope": {
+                    "description": "how widely usernames must be unique",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/websocket.UniqueScope"
+                        }
+                    ],
+                    "example": "room"
+                },
+                "suggestions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Brave-Otter",
+                        "Sunny-Maple"
+                    ]
+                }
+            }
+        },
+        "server.ValidatePasswordRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "example": "mypassword123"
+                }
+            }
+        },
+        "server.ValidationErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 422
+                },
+                "error": {
+                    "type": "string",
+                    "example": "validation failed"
+                },
+                "error_code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/websocket.ErrorCode"
+                        }
+                    ],
+                    "example": "VALIDATION_FAILED"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.ValidationError"
+                    }
+                }
+            }
+        },
+        "server.Webhook": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "events": {
+                    "description": "empty means every event",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "room_created"
+                    ]
+                },
+                "from_config": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://ops.example.com/chatters"
+                }
+            }
+        },
+        "server.WebhooksResponse": {
+            "type": "object",
+            "properties": {
+                "webhooks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.Webhook"
+                    }
+                }
+            }
+        },
+        "websocket.AccessEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "join"
+                },
+                "at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "by": {
+                    "description": "who kicked the member",
+                    "type": "string",
+                    "example": "host"
+                },
+                "connection_id": {
+                    "type": "string",
+                    "example": "3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                }
+            }
+        },
+        "websocket.Ban": {
+            "type": "object",
+            "properties": {
+                "banned_by": {
+                    "type": "string",
+                    "example": "host"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "expires_at": {
+                    "description": "nil for a permanent ban",
+                    "type": "string",
+                    "example": "2024-01-01T13:00:00Z"
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "pseudonyms": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     },
-                    "401": {
-                        "description": "Unauthorized - invalid password or host token",
-                        "schema": {
-                            "$ref": "#/definitions/websocket.ErrorResponse"
+                    "example": [
+                        "anon_Xr3kQ9vT2mLw8pZc1nYb0A"
+                    ]
+                },
+                "username": {
+                    "type": "string",
+                    "example": "spammer1"
+                }
+            }
+        },
+        "websocket.BotHook": {
+            "type": "object",
+            "properties": {
+                "bot_name": {
+                    "type": "string",
+                    "example": "WeatherBot"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "!weather"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://bots.example.com/weather"
+                }
+            }
+        },
+        "websocket.BreakoutInfo": {
+            "type": "object",
+            "properties": {
+                "online_count": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "room_id": {
+                    "type": "integer",
+                    "example": 654321
+                }
+            }
+        },
+        "websocket.ConnectionInfo": {
+            "type": "object",
+            "properties": {
+                "connection_id": {
+                    "type": "string",
+                    "example": "3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "is_host": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "joined_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "room_id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "session_id": {
+                    "type": "string",
+                    "example": "0b8f5a5e-5c1e-4a55-9f59-3f0c2b8d9a10"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                }
+            }
+        },
+        "websocket.ErrorCode": {
+            "type": "string",
+            "enum": [
+                "INVALID_REQUEST",
+                "VALIDATION_FAILED",
+                "INVALID_ROOM_ID",
+                "INVALID_USERNAME",
+                "INVALID_WEBHOOK_URL",
+                "NOT_FOUND",
+                "ROOM_NOT_FOUND",
+                "USER_NOT_FOUND",
+                "ALREADY_HOST",
+                "HOOK_NOT_FOUND",
+                "WEBHOOK_NOT_FOUND",
+                "METADATA_NOT_FOUND",
+                "CONNECTION_NOT_FOUND",
+                "INVALID_TOKEN",
+                "INVALID_PASSWORD",
+                "PASSWORD_REQUIRED",
+                "INVALID_TICKET",
+                "INVALID_API_KEY",
+                "INVALID_ADMIN_KEY",
+                "ROOM_FULL",
+                "USERNAME_TAKEN",
+                "BANNED",
+                "CANNOT_MUTE_HOST",
+                "BAN_NOT_FOUND",
+                "TOO_MANY_BANS",
+                "ROOM_FROZEN",
+                "ROOM_LOCKED",
+                "ROOM_MIGRATING",
+                "ROOM_EXISTS",
+                "MIGRATION_FAILED",
+                "TARGET_NOT_ALLOWED",
+                "TOO_MANY_HOOKS",
+                "TOO_MANY_WEBHOOKS",
+                "TOO_MANY_METADATA_KEYS",
+                "TOO_MANY_BREAKOUTS",
+                "TOO_MANY_UPLOADS",
+                "UNSUPPORTED_TYPE",
+                "BREAKOUT_NOT_FOUND",
+                "QUOTA_EXCEEDED",
+                "RATE_LIMITED",
+                "SERVER_BUSY",
+                "TOO_MANY_CONNECTIONS",
+                "PRECONDITION_REQUIRED",
+                "SETTINGS_CONFLICT",
+                "IDEMPOTENCY_CONFLICT",
+                "IDEMPOTENCY_KEY_REUSED",
+                "REQUEST_TOO_LARGE",
+                "FEATURE_DISABLED",
+                "INTERNAL_ERROR"
+            ],
+            "x-enum-varnames": [
+                "CodeInvalidRequest",
+                "CodeValidationFailed",
+                "CodeInvalidRoomID",
+                "CodeInvalidUsername",
+                "CodeInvalidWebhookURL",
+                "CodeNotFound",
+                "CodeRoomNotFound",
+                "CodeUserNotFound",
+                "CodeAlreadyHost",
+                "CodeHookNotFound",
+                "CodeWebhookNotFound",
+                "CodeMetadataNotFound",
+                "CodeConnectionNotFound",
+                "CodeInvalidToken",
+                "CodeInvalidPassword",
+                "CodePasswordRequired",
+                "CodeInvalidTicket",
+                "CodeInvalidAPIKey",
+                "CodeInvalidAdminKey",
+                "CodeRoomFull",
+                "CodeUsernameTaken",
+                "CodeBanned",
+                "CodeCannotMuteHost",
+                "CodeBanNotFound",
+                "CodeTooManyBans",
+                "CodeRoomFrozen",
+                "CodeRoomLocked",
+                "CodeRoomMigrating",
+                "CodeRoomExists",
+                "CodeMigrationFailed",
+                "CodeTargetNotAllowed",
+                "CodeTooManyHooks",
+                "CodeTooManyWebhooks",
+                "CodeTooManyMetadataKeys",
+                "CodeTooManyBreakouts",
+                "CodeTooManyUploads",
+                "CodeUnsupportedType",
+                "CodeBreakoutNotFound",
+                "CodeQuotaExceeded",
+                "CodeRateLimited",
+                "CodeServerBusy",
+                "CodeTooManyConnections",
+                "CodePreconditionRequired",
+                "CodeSettingsConflict",
+                "CodeIdempotencyConflict",
+                "CodeIdempotencyMismatch",
+                "CodeRequestTooLarge",
+                "CodeFeatureDisabled",
+                "CodeInternal"
+            ]
+        },
+        "websocket.ErrorResponse": {
+            "description": "Code is the HTTP status, ErrorCode a stable machine-readable identifier",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "error": {
+                    "type": "string",
+                    "example": "room not found"
+                },
+                "error_code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/websocket.ErrorCode"
                         }
-                    },
-                    "404": {
-                        "description": "Room not found",
-                        "schema": {
-                            "$ref": "#/definitions/websocket.ErrorResponse"
+                    ],
+                    "example": "ROOM_NOT_FOUND"
+                }
+            }
+        },
+        "websocket.FilterAction": {
+            "type": "string",
+            "enum": [
+                "",
+                "off",
+                "flag",
+                "mask",
+                "reject"
+            ],
+            "x-enum-comments": {
+                "FilterFlag": "deliver unchanged and record a flagged event for hosts",
+                "FilterInherit": "room only: use the server action",
+                "FilterMask": "replace blocked words with asterisks",
+                "FilterOff": "deliver unchanged",
+                "FilterReject": "drop the message and tell the sender"
+            },
+            "x-enum-descriptions": [
+                "room only: use the server action",
+                "deliver unchanged",
+                "deliver unchanged and record a flagged event for hosts",
+                "replace blocked words with asterisks",
+                "drop the message and tell the sender"
+            ],
+            "x-enum-varnames": [
+                "FilterInherit",
+                "FilterOff",
+                "FilterFlag",
+                "FilterMask",
+                "FilterReject"
+            ]
+        },
+        "websocket.ImportResult": {
+            "type": "object",
+            "properties": {
+                "dropped": {
+                    "description": "messages over the history limit or past retention",
+                    "type": "integer",
+                    "example": 2
+                },
+                "first_id": {
+                    "description": "ID of the oldest imported message",
+                    "type": "integer",
+                    "example": 43
+                },
+                "imported": {
+                    "description": "messages added to history",
+                    "type": "integer",
+                    "example": 998
+                },
+                "last_id": {
+                    "type": "integer",
+                    "example": 1042
+                }
+            }
+        },
+        "websocket.ImportedMessage": {
+            "type": "object",
+            "properties": {
+                "sent_at": {
+                    "type": "string",
+                    "example": "2023-06-01T09:30:00Z"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Hello from the old chat!"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                }
+            }
+        },
+        "websocket.MediaState": {
+            "description": "Sent by a client as media_state when its devices change",
+            "type": "object",
+            "properties": {
+                "camera_on": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "mic_muted": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "screen_sharing": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "websocket.MemberInfo": {
+            "type": "object",
+            "properties": {
+                "is_host": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "joined_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "media": {
+                    "$ref": "#/definitions/websocket.MediaState"
+                },
+                "muted": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "reconnecting": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "username": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                }
+            }
+        },
+        "websocket.ModAction": {
+            "type": "string",
+            "enum": [
+                "delete",
+                "mute",
+                "kick",
+                "flag"
+            ],
+            "x-enum-comments": {
+                "ModDelete": "drop the message and tell the sender",
+                "ModFlag": "deliver the message and record a flagged event for hosts",
+                "ModKick": "drop the message and kick the sender",
+                "ModMute": "drop the message and mute the sender"
+            },
+            "x-enum-descriptions": [
+                "drop the message and tell the sender",
+                "drop the message and mute the sender",
+                "drop the message and kick the sender",
+                "deliver the message and record a flagged event for hosts"
+            ],
+            "x-enum-varnames": [
+                "ModDelete",
+                "ModMute",
+                "ModKick",
+                "ModFlag"
+            ]
+        },
+        "websocket.ModRule": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/websocket.ModAction"
                         }
+                    ],
+                    "example": "delete"
+                },
+                "max_messages": {
+                    "description": "MaxMessages and WindowSeconds match senders that sent more than MaxMessages\nchat messages, this one included, within the last WindowSeconds",
+                    "type": "integer",
+                    "example": 5
+                },
+                "max_sender_age_seconds": {
+                    "description": "MaxSenderAgeSeconds matches senders that joined the room at most this long ago",
+                    "type": "integer",
+                    "example": 300
+                },
+                "name": {
+                    "type": "string",
+                    "example": "no links from newcomers"
+                },
+                "pattern": {
+                    "description": "regular expression the text must match",
+                    "type": "string",
+                    "example": "https?://"
+                },
+                "window_seconds": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "words": {
+                    "description": "the text contains one of the words, case-insensitively",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "$ref": "#/definitions/websocket.ErrorResponse"
-                        }
-                    }
+                    "example": [
+                        "casino"
+                    ]
                 }
             }
-        }
-    },
-    "definitions": {
-        "server.ChangePasswordRequest": {
+        },
+        "websocket.OccupancySample": {
             "type": "object",
             "properties": {
-                "new_password": {
+                "at": {
                     "type": "string",
-                    "example": "newpassword456"
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "clients": {
+                    "type": "integer",
+                    "example": 42
                 }
             }
         },
-        "server.CreateRoomRequest": {
+        "websocket.PasswordAttemptSource": {
             "type": "object",
             "properties": {
-                "password": {
+                "count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "last_attempt": {
                     "type": "string",
-                    "example": "mypassword123"
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "source_hash": {
+                    "type": "string",
+                    "example": "3f0c2b8d9a105c1e"
                 }
             }
         },
-        "server.CreateRoomResponse": {
+        "websocket.PasswordAudit": {
             "type": "object",
             "properties": {
-                "host_token": {
+                "failed_attempts": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "last_attempt": {
                     "type": "string"
                 },
-                "room_id": {
-                    "type": "integer"
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.PasswordAttemptSource"
+                    }
                 }
             }
         },
-        "server.ErrorResponse": {
+        "websocket.RoomContentFilter": {
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "integer"
+                "action": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/websocket.FilterAction"
+                        }
+                    ],
+                    "example": "reject"
                 },
-                "error": {
-                    "type": "string"
+                "words": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "spoiler"
+                    ]
                 }
             }
         },
-        "server.KickUserRequest": {
+        "websocket.RoomEvent": {
             "type": "object",
             "properties": {
-                "username": {
+                "at": {
                     "type": "string",
-                    "example": "john_doe"
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "data": {
+                    "type": "object"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 17
+                },
+                "type": {
+                    "type": "string",
+                    "example": "join"
                 }
             }
         },
-        "server.RoomResponse": {
+        "websocket.RoomSnapshot": {
+            "type": "object"
+        },
+        "websocket.RoomSummary": {
             "type": "object",
             "properties": {
                 "client_count": {
-                    "type": "integer"
+                    "type": "integer",
+                    "example": 12
                 },
                 "has_password": {
-                    "type": "boolean"
+                    "type": "boolean",
+                    "example": false
                 },
-                "host_id": {
-                    "type": "string"
+                "locked": {
+                    "type": "boolean",
+                    "example": false
                 },
                 "room_id": {
-                    "type": "integer"
+                    "type": "integer",
+                    "example": 42
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "golang"
+                    ]
+                },
+                "topic": {
+                    "type": "string",
+                    "example": "Weekly sync"
+                },
+                "visibility": {
+                    "type": "string",
+                    "example": "public"
                 }
             }
         },
-        "server.ValidatePasswordRequest": {
+        "websocket.RoomWebhook": {
             "type": "object",
             "properties": {
-                "password": {
+                "created_at": {
                     "type": "string",
-                    "example": "mypassword123"
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://archive.example.com/chatters"
                 }
             }
         },
-        "websocket.ErrorResponse": {
+        "websocket.StoredMessage": {
             "type": "object",
             "properties": {
-                "code": {
+                "edited_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:05:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "imported": {
+                    "description": "added through an import, the username is not verified",
+                    "type": "boolean",
+                    "example": false
+                },
+                "sent_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Hello, world!"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "JohnDoe"
+                }
+            }
+        },
+        "websocket.TenantUsage": {
+            "type": "object",
+            "properties": {
+                "connections": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "messages": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "rooms": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "window_start": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                }
+            }
+        },
+        "websocket.UniqueScope": {
+            "type": "string",
+            "enum": [
+                "none",
+                "room",
+                "tenant",
+                "global"
+            ],
+            "x-enum-comments": {
+                "UniqueGlobal": "reserved server-wide for the session that claimed it",
+                "UniqueNone": "duplicates are allowed",
+                "UniqueRoom": "unique among the members of a room",
+                "UniqueTenant": "unique across the rooms of an API key"
+            },
+            "x-enum-descriptions": [
+                "duplicates are allowed",
+                "unique among the members of a room",
+                "unique across the rooms of an API key",
+                "reserved server-wide for the session that claimed it"
+            ],
+            "x-enum-varnames": [
+                "UniqueNone",
+                "UniqueRoom",
+                "UniqueTenant",
+                "UniqueGlobal"
+            ]
+        },
+        "websocket.Upload": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "image/png"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "diagram.png"
+                },
+                "size": {
                     "type": "integer",
-                    "example": 400
+                    "example": 48213
+                },
+                "uploaded_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "/uploads/rooms/42/3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e.png"
+                }
+            }
+        },
+        "websocket.ValidationError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "username"
                 },
                 "message": {
                     "type": "string",
-                    "example": "Invalid request"
+                    "example": "username is too short"
                 }
             }
+        },
+        "websocket.Visibility": {
+            "type": "string",
+            "enum": [
+                "private",
+                "public"
+            ],
+            "x-enum-varnames": [
+                "VisibilityPrivate",
+                "VisibilityPublic"
+            ]
         }
     }
 }`
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "0.1.0",
+	Version:          "0.1.3",
 	Host:             "localhost:8080",
 	BasePath:         "/",
 	Schemes:          []string{},
@@ -0,0 +1,118 @@
+// Package bench holds the broadcast fanout benchmark shared by the Go
+// benchmarks in pkg/websocket/tests and the cmd/bench runner.
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+)
+
+// window is how many broadcasts may be in flight before the benchmark waits for
+// every client to catch up. It stays below the client queue size, so no client
+// is ever dropped for falling behind.
+const window = 128
+
+// queueSize is the Send buffer of benchmark clients, the same as real clients
+const queueSize = 256
+
+// flushPrefix marks the last broadcast of a batch among the other room messages
+var flushPrefix = []byte(`{"type":"flush"`)
+
+// Case is one benchmark configuration
+type Case struct {
+	Clients     int
+	PayloadSize int
+}
+
+// Name returns the sub-benchmark name of the case
+func (c Case) Name() string {
+	return fmt.Sprintf("clients=%d/payload=%d", c.Clients, c.PayloadSize)
+}
+
+// Cases returns every combination of client counts and payload sizes
+func Cases(clients, payloadSizes []int) []Case {
+	cases := make([]Case, 0, len(clients)*len(payloadSizes))
+	for _, n := range clients {
+		for _, size := range payloadSizes {
+			cases = append(cases, Case{Clients: n, PayloadSize: size})
+		}
+	}
+	return cases
+}
+
+// nopConn is a Conn that discards everything; benchmark clients are drained
+// straight from their Send queue
+type nopConn struct{}
+
+func (nopConn) SetReadLimit(int64)                        {}
+func (nopConn) SetPongHandler(func(string) error)         {}
+func (nopConn) SetReadDeadline(time.Time) error           { return nil }
+func (nopConn) SetWriteDeadline(time.Time) error          { return nil }
+func (nopConn) ReadMessage() (int, []byte, error)         { select {} }
+func (nopConn) WriteMessage(int, []byte) error            { return nil }
+func (nopConn) WriteControl(int, []byte, time.Time) error { return nil }
+func (nopConn) Close() error                              { return nil }
+
+// Fanout measures delivering one broadcast to every client of a room through
+// Room.Broadcast, the path chat messages take. Broadcasts are sent in batches
+// ending with a flush message; ns/op is the batch time until every client
+// received the flush, divided by the batch size. Allocations include the room
+// sequencing.
+func Fanout(b *testing.B, c Case) {
+	room := websocket.NewRoom(1, nil, websocket.WithHistoryLimit(0))
+	go room.Run()
+	defer room.StopRoom()
+
+	var caughtUp sync.WaitGroup
+	for i := 0; i < c.Clients; i++ {
+		client := &websocket.Client{
+			Conn:     nopConn{},
+			Send:     make(chan []byte, queueSize),
+			Room:     room,
+			Username: fmt.Sprintf("bench-%d", i),
+		}
+		go func() {
+			for msg := range client.Send {
+				if bytes.HasPrefix(msg, flushPrefix) {
+					caughtUp.Done()
+				}
+			}
+		}()
+		room.Register <- client
+	}
+	for room.GetClientCount() < c.Clients {
+		time.Sleep(time.Millisecond)
+	}
+
+	payload := json.RawMessage(`"` + strings.Repeat("x", c.PayloadSize) + `"`)
+	msg, err := json.Marshal(websocket.Message{Type: "bench", Data: payload})
+	if err != nil {
+		b.Fatal(err)
+	}
+	flush, err := json.Marshal(websocket.Message{Type: "flush", Data: payload})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(msg)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for sent := 0; sent < b.N; {
+		batch := min(window, b.N-sent)
+		sent += batch
+		caughtUp.Add(c.Clients)
+		for i := 1; i < batch; i++ {
+			room.Broadcast <- msg
+		}
+		room.Broadcast <- flush
+		caughtUp.Wait()
+	}
+	b.StopTimer()
+}
@@ -1,69 +1,555 @@
 package config
 
 import (
-	"flag"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"net"
 	"os"
-	"sync"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Config is the server configuration. Every option can be set in the config file,
+// by an environment variable or by a command-line flag; see Load.
 type Config struct {
-	Port         string
-	JWTSecret    string
-	TaskPoolSize string
-	Profiling    string
+	ConfigFile string
+	args       []string          // arguments Load was called with, read again by Reload
+	values     map[string]string // resolved option values by flag name
+
+	LogLevel       string
+	AllowedOrigins string
+	TrustedProxies string
+
+	Host           string
+	Port           int
+	JWTSecret      string
+	TaskPoolSize   int
+	Profiling      bool
+	IdempotencyTTL time.Duration
+	MaxConnections int
+	MaxConnsPerIP  int
+	Compression    int
+	CompressMin    int
+	SchemaDir      string
+	RoomShare      int
+	EngineIO       bool
+	WebTransport   bool
+	ReconnectGrace time.Duration
+	RoomMsgQuota   int
+	ClientLimit    int
+	ClientWindow   time.Duration
+	SendCredits    int
+	CreditWindow   time.Duration
+	PasswordAlert  int
+	AlertWebhook   string
+	RedisURL       string
+	ModerationDir  string
+	UploadDir      string
+	UploadBaseURL  string
+	UploadMaxBytes int
+	UploadTypes    string
+	RoomIdleTTL    time.Duration
+	CompactEvery   time.Duration
+
+	TLSCertFile      string
+	TLSKeyFile       string
+	AutocertDomains  string
+	AutocertCacheDir string
+	AutocertEmail    string
+	HTTPRedirectAddr string
+
+	APIRateLimit       float64
+	APIRateBurst       int
+	SensitiveRateLimit float64
+	SensitiveRateBurst int
+
+	OccupancyInterval  time.Duration
+	OccupancyRetention time.Duration
+
+	APIKeys             string
+	TenantMaxRooms      int
+	TenantMaxConns      int
+	TenantMaxMessages   int
+	TenantMessageWindow time.Duration
+
+	DefaultHistory    int
+	DefaultMaxClients int
+	DefaultSlowMode   time.Duration
+	DefaultRetention  time.Duration
+	DefaultVisibility string
+	DefaultSlowPolicy string
+	SpoolDir          string
+	SpoolBudget       int
+
+	UploadS3Endpoint  string
+	UploadS3Region    string
+	UploadS3Bucket    string
+	UploadS3AccessKey string
+	UploadS3SecretKey string
+	UploadS3PublicURL string
+
+	JWTPrivateKeyFile string
+	JWTPublicKeyFile  string
+	HostTokenGrace    time.Duration
+
+	PseudonymSecret   string
+	PseudonymRotation time.Duration
+
+	StaticDir   string
+	SPAFallback bool
+
+	HandshakeTimeout time.Duration
+
+	AdminKey         string
+	MigrationTargets string
+
+	UsernameScope string
+
+	WebhookURLs   string
+	WebhookSecret string
+	WebhookEvents string
+
+	PasswordPepper string
+	PepperFile     string
+
+	WireNaming string
+
+	AppName         string
+	DefaultUsername string
+	BotName         string
+	HostName        string
+	AutoModName     string
+	SystemPrefix    string
+
+	FilterWordsFile string
+	FilterAction    string
+
+	OTLPEndpoint string
+	ServiceName  string
+
+	ReadTimeout         time.Duration
+	ReadHeaderTimeout   time.Duration
+	WriteTimeout        time.Duration
+	IdleTimeout         time.Duration
+	HTTPShutdownTimeout time.Duration
+	ShutdownTimeout     time.Duration
 }
 
-var (
-	instance *Config
-	once     sync.Once
-	parsed   bool
-)
+// Load reads the configuration with the following priority:
+// 1. Command-line flag from args.
+// 2. Environment variable.
+// 3. Config file (-config or CONFIG_FILE), YAML or TOML with option names as keys.
+// 4. Default value.
+func Load(args []string) (*Config, error) {
+	c := &Config{}
+	l := newLoader("chatters")
+
+	l.string(&c.ConfigFile, "CONFIG_FILE", "config", "", "optional YAML (.yaml/.yml) or TOML (.toml) file of options keyed by flag name")
+
+	l.string(&c.LogLevel, "LOG_LEVEL", "log-level", "info", "minimum level of logged messages: debug, info, warn or error")
+	l.string(&c.TrustedProxies, "TRUSTED_PROXIES", "trusted-proxies", "", "comma-separated IPs or CIDRs of reverse proxies whose X-Forwarded-For header names the client IP (empty = none, the peer address is used)")
+	l.string(&c.AllowedOrigins, "ALLOWED_ORIGINS", "allowed-origins", "", "comma-separated browser origins allowed to call the API and connect over WebSocket besides the server's own, e.g. https://*.example.com (* = any)")
+
+	l.string(&c.Host, "HOST", "host", "", "HTTP server bind address (empty = all interfaces)")
+	l.int(&c.Port, "PORT", "port", 8080, "HTTP server port")
+
+	l.string(&c.TLSCertFile, "TLS_CERT_FILE", "tls-cert-file", "", "PEM certificate chain served over HTTPS/WSS, with TLS_KEY_FILE (empty = plain HTTP)")
+	l.string(&c.TLSKeyFile, "TLS_KEY_FILE", "tls-key-file", "", "PEM private key of TLS_CERT_FILE")
+	l.string(&c.AutocertDomains, "AUTOCERT_DOMAINS", "autocert-domains", "", "comma-separated domains to obtain Let's Encrypt certificates for instead of TLS_CERT_FILE (empty = disabled)")
+	l.string(&c.AutocertCacheDir, "AUTOCERT_CACHE_DIR", "autocert-cache-dir", "autocert", "directory caching Let's Encrypt certificates and the account key")
+	l.string(&c.AutocertEmail, "AUTOCERT_EMAIL", "autocert-email", "", "contact address registered with Let's Encrypt (empty = none)")
+	l.string(&c.HTTPRedirectAddr, "HTTP_REDIRECT_ADDR", "http-redirect-addr", "", "address of a plain HTTP listener redirecting to HTTPS, e.g. :80; answers ACME challenges in autocert mode (empty = none)")
+
+	l.string(&c.JWTSecret, "SECRET_KEY", "jwt-secret", "supersecret", "JWT secret key")
+	l.int(&c.TaskPoolSize, "TASK_POOL_SIZE", "task-pool-size", 10000, "size of task pool")
+	l.bool(&c.Profiling, "PROFILING", "profiling", false, "enable pprof profiling")
+	l.duration(&c.IdempotencyTTL, "IDEMPOTENCY_TTL", "idempotency-ttl", 24*time.Hour, "how long idempotent responses are kept")
+	l.int(&c.MaxConnections, "MAX_CONNECTIONS", "max-connections", 0, "max concurrent WebSocket connections (0 = unlimited)")
+	l.int(&c.MaxConnsPerIP, "MAX_CONNECTIONS_PER_IP", "max-connections-per-ip", 100, "max concurrent WebSocket connections per client IP (0 = unlimited)")
+	l.int(&c.Compression, "WS_COMPRESSION_LEVEL", "ws-compression-level", 0, "permessage-deflate level from 1 (fastest) to 9 (smallest) for clients that offer it (0 = disabled)")
+	l.int(&c.CompressMin, "WS_COMPRESSION_MIN_SIZE", "ws-compression-min-size", 256, "smallest WebSocket message in bytes that is compressed")
+	l.string(&c.SchemaDir, "WS_PAYLOAD_SCHEMA_DIR", "ws-payload-schema-dir", "", "directory of <message type>.json JSON Schemas validating the payload of inbound messages (empty = not validated)")
+	l.int(&c.RoomShare, "MAX_ROOM_CONNECTION_SHARE", "max-room-connection-share", 100, "max percent of connections a single room may hold")
+	l.bool(&c.EngineIO, "ENGINEIO_ENABLED", "engineio", false, "enable Socket.IO/Engine.IO compatibility endpoint")
+	l.bool(&c.WebTransport, "WEBTRANSPORT_ENABLED", "webtransport", false, "serve the experimental WebTransport endpoint /wt/{room_id} over HTTP/3 on the HTTPS port (UDP), requires TLS")
+	l.duration(&c.ReconnectGrace, "RECONNECT_GRACE", "reconnect-grace", 10*time.Second, "how long a dropped member may resume before leaving (0 = disabled)")
+	l.int(&c.RoomMsgQuota, "ROOM_MESSAGE_QUOTA", "room-message-quota", 0, "max broadcast messages per second per room (0 = unlimited)")
+	l.int(&c.ClientLimit, "CLIENT_RATE_LIMIT", "client-rate-limit", 0, "max messages a client may send per rate window (0 = unlimited)")
+	l.duration(&c.ClientWindow, "CLIENT_RATE_WINDOW", "client-rate-window", 10*time.Second, "window of the per-client message rate limit")
+	l.float(&c.APIRateLimit, "API_RATE_LIMIT", "api-rate-limit", 20, "requests per second each IP may make to /api (0 = unlimited)")
+	l.int(&c.APIRateBurst, "API_RATE_BURST", "api-rate-burst", 40, "requests an IP may make to /api in a burst")
+	l.float(&c.SensitiveRateLimit, "API_SENSITIVE_RATE_LIMIT", "api-sensitive-rate-limit", 0.2, "requests per second each IP may make to create rooms and check room passwords (0 = unlimited)")
+	l.int(&c.SensitiveRateBurst, "API_SENSITIVE_RATE_BURST", "api-sensitive-rate-burst", 5, "room creations and password checks an IP may make in a burst")
+	l.int(&c.SendCredits, "SEND_CREDITS", "send-credits", 0, "broadcast messages per credit window shared equally by the members of a room (0 = disabled)")
+	l.duration(&c.CreditWindow, "SEND_CREDIT_WINDOW", "send-credit-window", 10*time.Second, "how often members are granted new send credits")
+	l.int(&c.PasswordAlert, "PASSWORD_ALERT_THRESHOLD", "password-alert-threshold", 10, "failed room password attempts that trigger an alert (0 = disabled)")
+	l.duration(&c.RoomIdleTTL, "ROOM_IDLE_TTL", "room-idle-ttl", 30*time.Minute, "how long an empty room is kept before it is deleted (0 = forever)")
+	l.duration(&c.CompactEvery, "HISTORY_COMPACT_INTERVAL", "history-compact-interval", time.Minute, "how often expired history is removed from all rooms (0 = disabled)")
+	l.duration(&c.OccupancyInterval, "OCCUPANCY_SAMPLE_INTERVAL", "occupancy-sample-interval", time.Minute, "how often the client count of every room is recorded for its occupancy history (0 = disabled)")
+	l.duration(&c.OccupancyRetention, "OCCUPANCY_RETENTION", "occupancy-retention", 24*time.Hour, "how long the occupancy history of a room is kept after its last sample")
+	l.string(&c.RedisURL, "REDIS_URL", "redis-url", "", "Redis URL for the multi-node broadcast backplane (empty = in-process)")
+	l.string(&c.ModerationDir, "MODERATION_STORE_DIR", "moderation-store-dir", "", "directory persisting bans, mutes and hosts of rooms when REDIS_URL is not set (empty = kept in memory only)")
+	l.string(&c.UploadDir, "UPLOAD_DIR", "upload-dir", "", "directory storing files uploaded to rooms when no S3 bucket is set (empty = uploads disabled)")
+	l.string(&c.UploadBaseURL, "UPLOAD_BASE_URL", "upload-base-url", "/uploads/", "URL path or absolute URL files in UPLOAD_DIR are served at")
+	l.int(&c.UploadMaxBytes, "UPLOAD_MAX_BYTES", "upload-max-bytes", 8<<20, "max size of an uploaded file in bytes, within the 10MB request limit")
+	l.string(&c.UploadTypes, "UPLOAD_TYPES", "upload-types", "image/png,image/jpeg,image/gif,image/webp,application/pdf,text/plain", "comma-separated content types accepted for uploads")
+	l.string(&c.UploadS3Endpoint, "UPLOAD_S3_ENDPOINT", "upload-s3-endpoint", "", "endpoint of the S3-compatible service storing uploads")
+	l.string(&c.UploadS3Region, "UPLOAD_S3_REGION", "upload-s3-region", "us-east-1", "region of the upload bucket")
+	l.string(&c.UploadS3Bucket, "UPLOAD_S3_BUCKET", "upload-s3-bucket", "", "bucket storing uploads, used instead of UPLOAD_DIR when set")
+	l.string(&c.UploadS3AccessKey, "UPLOAD_S3_ACCESS_KEY", "upload-s3-access-key", "", "access key of the upload bucket")
+	l.string(&c.UploadS3SecretKey, "UPLOAD_S3_SECRET_KEY", "upload-s3-secret-key", "", "secret key of the upload bucket")
+	l.string(&c.UploadS3PublicURL, "UPLOAD_S3_PUBLIC_URL", "upload-s3-public-url", "", "base URL the upload bucket is served at, such as a CDN (empty = endpoint/bucket/)")
+	l.string(&c.AlertWebhook, "PASSWORD_ALERT_WEBHOOK", "password-alert-webhook", "", "URL notified with a POST when a password alert fires")
+
+	l.string(&c.APIKeys, "API_KEYS", "api-keys", "", "comma-separated API keys required to create rooms, each a tenant with its own quotas (empty = tenancy disabled)")
+	l.int(&c.TenantMaxRooms, "TENANT_MAX_ROOMS", "tenant-max-rooms", 0, "max live rooms per API key (0 = unlimited)")
+	l.int(&c.TenantMaxConns, "TENANT_MAX_CONNECTIONS", "tenant-max-connections", 0, "max WebSocket connections per API key (0 = unlimited)")
+	l.int(&c.TenantMaxMessages, "TENANT_MAX_MESSAGES", "tenant-max-messages", 0, "max messages per API key per message window (0 = unlimited)")
+	l.duration(&c.TenantMessageWindow, "TENANT_MESSAGE_WINDOW", "tenant-message-window", time.Hour, "window of the per API key message quota")
+
+	l.string(&c.JWTPrivateKeyFile, "JWT_PRIVATE_KEY_FILE", "jwt-private-key-file", "", "PEM RSA or Ed25519 private key signing host tokens (RS256/EdDSA instead of HS256)")
+	l.string(&c.JWTPublicKeyFile, "JWT_PUBLIC_KEY_FILE", "jwt-public-key-file", "", "PEM public key verifying host tokens (derived from the private key if empty)")
+	l.duration(&c.HostTokenGrace, "HOST_TOKEN_REFRESH_GRACE", "host-token-refresh-grace", time.Hour, "how long after expiry a host token can still be refreshed (0 = only unexpired tokens)")
+
+	l.string(&c.PseudonymSecret, "PSEUDONYM_SECRET", "pseudonym-secret", "", "secret keying the pseudonymous IDs of anonymous members (empty = derived from SECRET_KEY)")
+	l.duration(&c.PseudonymRotation, "PSEUDONYM_ROTATION", "pseudonym-rotation", 24*time.Hour, "how often pseudonymous IDs change; bans follow them while the member keeps returning (0 = disabled)")
+
+	l.string(&c.StaticDir, "STATIC_DIR", "static-dir", "web/static", "directory of the web client served at / and /static")
+	l.bool(&c.SPAFallback, "SPA_FALLBACK", "spa-fallback", true, "serve index.html for unknown non-API paths")
+
+	l.duration(&c.HandshakeTimeout, "WS_HANDSHAKE_TIMEOUT", "ws-handshake-timeout", 10*time.Second, "how long an upgraded WebSocket may stay silent before its first message or pong (0 = disabled)")
 
-// NewConfig загружает конфигурацию из окружения или флагов
-func NewConfig() *Config {
-	once.Do(func() {
-		instance = &Config{
-			Port:         configValue("PORT", "port", "8080", "HTTP server port"),
-			JWTSecret:    configValue("SECRET_KEY", "jwt-secret", "supersecret", "JWT secret key"),
-			TaskPoolSize: configValue("TASK_POOL_SIZE", "task-pool-size", "10000", "size of task pool"),
-			Profiling:    configValue("PROFILING", "profiling", "false", "enable pprof profiling (true/false)"),
+	l.string(&c.AdminKey, "ADMIN_API_KEY", "admin-api-key", "", "key required by the /api/admin endpoints (empty = admin API disabled)")
+	l.string(&c.MigrationTargets, "MIGRATION_TARGETS", "migration-targets", "", "comma-separated base URLs of the instances rooms may be migrated to (empty = migration disabled)")
+
+	l.string(&c.UsernameScope, "USERNAME_SCOPE", "username-scope", "none", "where usernames must be unique: none, room, tenant or global (reserved per session)")
+
+	l.string(&c.WebhookURLs, "WEBHOOK_URLS", "webhook-urls", "", "comma-separated URLs receiving room events as signed JSON POSTs")
+	l.string(&c.WebhookSecret, "WEBHOOK_SECRET", "webhook-secret", "", "HMAC secret signing deliveries to WEBHOOK_URLS (empty = unsigned)")
+	l.string(&c.WebhookEvents, "WEBHOOK_EVENTS", "webhook-events", "", "comma-separated events sent to WEBHOOK_URLS (empty = all)")
+
+	l.string(&c.PasswordPepper, "PASSWORD_PEPPER", "password-pepper", "", "secret mixed into room password hashes (empty = no pepper)")
+	l.string(&c.PepperFile, "PASSWORD_PEPPER_FILE", "password-pepper-file", "", "file holding the password pepper, e.g. a mounted secret (overrides PASSWORD_PEPPER)")
+
+	l.string(&c.WireNaming, "WIRE_NAMING", "wire-naming", "compat", "spelling of legacy camelCase message fields: legacy, compat (both) or snake")
+
+	l.string(&c.AppName, "APP_NAME", "app-name", "Chatters", "product name shown by the web client")
+	l.string(&c.DefaultUsername, "DEFAULT_USERNAME", "default-username", "Anonymous", "username of clients that join without one")
+	l.string(&c.BotName, "BOT_NAME", "bot-name", "Bot", "sender of injected messages that do not name one")
+	l.string(&c.HostName, "HOST_NAME", "host-name", "host", "moderator named when hosts kick, mute or ban through the REST API")
+	l.string(&c.AutoModName, "AUTOMOD_NAME", "automod-name", "automod", "moderator named in actions of auto-moderation rules")
+	l.string(&c.SystemPrefix, "SYSTEM_PREFIX", "system-prefix", "", "text prepended to system notifications: the room welcome message and error texts")
+
+	l.string(&c.FilterWordsFile, "CONTENT_FILTER_WORDS_FILE", "content-filter-words-file", "", "file of blocked words or phrases, one per line, checked in chat messages (empty = none)")
+	l.string(&c.FilterAction, "CONTENT_FILTER_ACTION", "content-filter-action", "mask", "what to do with chat messages containing blocked words: off, flag, mask or reject")
+
+	l.string(&c.OTLPEndpoint, "OTEL_EXPORTER_OTLP_ENDPOINT", "otlp-endpoint", "", "OTLP/HTTP collector receiving traces, e.g. http://localhost:4318 (empty = tracing disabled)")
+	l.string(&c.ServiceName, "OTEL_SERVICE_NAME", "otel-service-name", "chatters", "service name reported with traces")
+
+	l.duration(&c.ReadTimeout, "READ_TIMEOUT", "read-timeout", 10*time.Second, "max duration for reading an entire request")
+	l.duration(&c.ReadHeaderTimeout, "READ_HEADER_TIMEOUT", "read-header-timeout", 5*time.Second, "max duration for reading request headers")
+	l.duration(&c.WriteTimeout, "WRITE_TIMEOUT", "write-timeout", 20*time.Second, "max duration before timing out writes of a response")
+	l.duration(&c.IdleTimeout, "IDLE_TIMEOUT", "idle-timeout", 120*time.Second, "max time to wait for the next request on keep-alive connections")
+	l.duration(&c.HTTPShutdownTimeout, "HTTP_SHUTDOWN_TIMEOUT", "http-shutdown-timeout", 15*time.Second, "how long to wait for in-flight HTTP requests on shutdown")
+	l.int(&c.DefaultHistory, "DEFAULT_ROOM_HISTORY", "default-room-history", 1000, "chat messages kept per room unless set at creation")
+	l.int(&c.DefaultMaxClients, "DEFAULT_ROOM_MAX_CLIENTS", "default-room-max-clients", 0, "max clients per room unless set at creation (0 = unlimited)")
+	l.duration(&c.DefaultSlowMode, "DEFAULT_ROOM_SLOW_MODE", "default-room-slow-mode", 0, "slow mode interval for new rooms (0 = off)")
+	l.duration(&c.DefaultRetention, "DEFAULT_ROOM_RETENTION", "default-room-retention", 0, "message retention for new rooms (0 = keep until history limit)")
+	l.string(&c.DefaultVisibility, "DEFAULT_ROOM_VISIBILITY", "default-room-visibility", "private", "visibility of new rooms (private/public)")
+	l.string(&c.DefaultSlowPolicy, "DEFAULT_ROOM_SLOW_CONSUMER", "default-room-slow-consumer", "disconnect", "what new rooms do with clients whose send queue is full: disconnect, drop_oldest or buffer_to_disk")
+	l.string(&c.SpoolDir, "SLOW_CONSUMER_SPOOL_DIR", "slow-consumer-spool-dir", "", "directory of the buffer_to_disk spools of slow clients (empty = system temp dir)")
+	l.int(&c.SpoolBudget, "SLOW_CONSUMER_SPOOL_MAX_BYTES", "slow-consumer-spool-max-bytes", 256<<20, "bytes the buffer_to_disk spools of all clients may keep on disk together; clients over it are disconnected")
+
+	l.duration(&c.ShutdownTimeout, "SHUTDOWN_TIMEOUT", "shutdown-timeout", 30*time.Second, "how long to wait for rooms and workers to stop on shutdown")
+
+	if err := l.load(args, &c.ConfigFile); err != nil {
+		return nil, err
+	}
+	c.args = args
+	c.values = l.values()
+	return c, nil
+}
+
+// reloadable are the options a running server applies when the config is reloaded
+var reloadable = map[string]bool{
+	"log-level":                true,
+	"allowed-origins":          true,
+	"room-message-quota":       true,
+	"client-rate-limit":        true,
+	"client-rate-window":       true,
+	"default-room-max-clients": true,
+}
+
+// Reload reads the configuration again from the arguments of Load, the environment
+// and the config file, so edits to the file are picked up
+func (c *Config) Reload() (*Config, error) {
+	return Load(c.args)
+}
+
+// RestartRequired returns the names of the options that differ in next but only
+// take effect after a restart
+func (c *Config) RestartRequired(next *Config) []string {
+	var names []string
+	for name, value := range next.values {
+		if c.values[name] != value && !reloadable[name] {
+			names = append(names, name)
 		}
-	})
-	return instance
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Addr returns the address the HTTP server listens on
+func (c *Config) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// ServerTimeouts returns the HTTP server read, read-header, write and idle timeouts
+func (c *Config) ServerTimeouts() (read, readHeader, write, idle time.Duration) {
+	return positiveDuration(c.ReadTimeout, 10*time.Second),
+		positiveDuration(c.ReadHeaderTimeout, 5*time.Second),
+		positiveDuration(c.WriteTimeout, 20*time.Second),
+		positiveDuration(c.IdleTimeout, 120*time.Second)
+}
+
+// HTTPShutdownWindow returns how long in-flight HTTP requests may finish on shutdown
+func (c *Config) HTTPShutdownWindow() time.Duration {
+	return positiveDuration(c.HTTPShutdownTimeout, 15*time.Second)
 }
 
-// IsProfilingEnabled returns true if profiling is enabled in the config
-func (c *Config) IsProfilingEnabled() bool {
-	switch c.Profiling {
-	case "true", "1", "yes", "on":
-		return true
-	default:
-		return false
+// ShutdownWindow returns how long the whole graceful shutdown may take
+func (c *Config) ShutdownWindow() time.Duration {
+	return positiveDuration(c.ShutdownTimeout, 30*time.Second)
+}
+
+// RoomDefaults holds server-wide defaults for new rooms
+type RoomDefaults struct {
+	Visibility   string
+	HistoryLimit int
+	MaxClients   int
+	SlowMode     time.Duration
+	Retention    time.Duration
+	SlowConsumer string
+}
+
+// NewRoomDefaults returns the default room options. They are validated by the server,
+// since rooms would silently differ from what was configured.
+func (c *Config) NewRoomDefaults() RoomDefaults {
+	return RoomDefaults{
+		Visibility:   c.DefaultVisibility,
+		HistoryLimit: c.DefaultHistory,
+		MaxClients:   c.DefaultMaxClients,
+		SlowMode:     c.DefaultSlowMode,
+		Retention:    c.DefaultRetention,
+		SlowConsumer: c.DefaultSlowPolicy,
 	}
 }
 
-// configValue returns the value of a parameter based on the following priority:
-// 1. Environment variable.
-// 2. Command-line flag.
-// 3. Default value.
-func configValue(envVar, flagName, defaultValue, description string) string {
-	envValue := os.Getenv(envVar)
-	if envValue != "" {
-		return envValue
+// AllowedOriginList returns the cross-origin browsers allowed to use the server, empty for same-origin only
+func (c *Config) AllowedOriginList() []string {
+	return splitList(c.AllowedOrigins)
+}
+
+// TrustedProxyList returns the addresses of the reverse proxies trusted to report client IPs
+func (c *Config) TrustedProxyList() []string {
+	return splitList(c.TrustedProxies)
+}
+
+// AutocertDomainList returns the domains served with Let's Encrypt certificates, empty if autocert is disabled
+func (c *Config) AutocertDomainList() []string {
+	return splitList(c.AutocertDomains)
+}
+
+// TLSEnabled reports whether the server serves HTTPS, from certificate files or autocert
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" || c.TLSKeyFile != "" || len(c.AutocertDomainList()) > 0
+}
+
+// APIKeyList returns the configured API keys, empty if tenancy is disabled
+func (c *Config) APIKeyList() []string {
+	return splitList(c.APIKeys)
+}
+
+// AdminAPIKey returns the key guarding the admin API, empty if it is disabled
+func (c *Config) AdminAPIKey() string {
+	return strings.TrimSpace(c.AdminKey)
+}
+
+// MigrationTargetList returns the base URLs rooms may be migrated to
+func (c *Config) MigrationTargetList() []string {
+	targets := splitList(c.MigrationTargets)
+	for i, target := range targets {
+		targets[i] = strings.TrimRight(target, "/")
 	}
+	return targets
+}
+
+// WebhookURLList returns the webhook URLs from the configuration
+func (c *Config) WebhookURLList() []string {
+	return splitList(c.WebhookURLs)
+}
+
+// WebhookEventList returns the events sent to the configured webhooks, empty for all
+func (c *Config) WebhookEventList() []string {
+	return splitList(c.WebhookEvents)
+}
+
+// UploadTypeList returns the content types accepted for uploads
+func (c *Config) UploadTypeList() []string {
+	return splitList(c.UploadTypes)
+}
 
-	// Create command-line flag only once
-	if !parsed {
-		flag.String(flagName, defaultValue, description)
-		parsed = true
-		flag.Parse()
+// Pepper returns the room password pepper, read from PepperFile if it is set
+func (c *Config) Pepper() (string, error) {
+	if c.PepperFile == "" {
+		return c.PasswordPepper, nil
+	}
+	data, err := os.ReadFile(c.PepperFile)
+	if err != nil {
+		return "", err
 	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// TenantLimits returns the per API key room, connection and message quotas and the message window
+func (c *Config) TenantLimits() (rooms, connections, messages int, window time.Duration) {
+	return max(c.TenantMaxRooms, 0),
+		max(c.TenantMaxConns, 0),
+		max(c.TenantMaxMessages, 0),
+		positiveDuration(c.TenantMessageWindow, time.Hour)
+}
+
+// APIRateLimits returns the per-IP request rate and burst of /api, a zero rate if unlimited
+func (c *Config) APIRateLimits() (perSecond float64, burst int) {
+	return max(c.APIRateLimit, 0), max(c.APIRateBurst, 1)
+}
+
+// SensitiveRateLimits returns the per-IP rate and burst of room creation and password
+// checks, a zero rate if unlimited
+func (c *Config) SensitiveRateLimits() (perSecond float64, burst int) {
+	return max(c.SensitiveRateLimit, 0), max(c.SensitiveRateBurst, 1)
+}
+
+// IdempotencyWindow returns how long responses for Idempotency-Key requests are cached
+func (c *Config) IdempotencyWindow() time.Duration {
+	return positiveDuration(c.IdempotencyTTL, 24*time.Hour)
+}
+
+// SpoolBudgetBytes returns the bytes all slow-consumer spools may keep on disk
+func (c *Config) SpoolBudgetBytes() int64 {
+	return int64(max(c.SpoolBudget, 0))
+}
+
+// ReconnectGracePeriod returns how long a disconnected member keeps its place in the room
+func (c *Config) ReconnectGracePeriod() time.Duration {
+	return max(c.ReconnectGrace, 0)
+}
+
+// HostTokenRefreshGrace returns how long after expiry a host token may still be refreshed
+func (c *Config) HostTokenRefreshGrace() time.Duration {
+	return max(c.HostTokenGrace, 0)
+}
 
-	// Get the flag value
-	flagValue := flag.Lookup(flagName)
-	if flagValue != nil {
-		return flagValue.Value.String()
+// Purposes of the keys derived from SECRET_KEY
+const (
+	KeyPurposeSession    = "chatters session cookie"
+	KeyPurposeTickets    = "chatters join tickets"
+	KeyPurposeState      = "chatters resume state"
+	KeyPurposePseudonyms = "chatters pseudonyms"
+)
+
+// DerivedKey returns the key for purpose, derived from SECRET_KEY with HKDF-SHA256
+// so that a value signed for one purpose is never accepted for another. Host
+// tokens are signed with SECRET_KEY itself.
+func (c *Config) DerivedKey(purpose string) []byte {
+	// HKDF only fails for keys longer than 255 hashes
+	key, _ := hkdf.Key(sha256.New, []byte(c.JWTSecret), nil, purpose, sha256.Size)
+	return key
+}
+
+// Pseudonyms returns the secret and rotation period of pseudonymous member IDs,
+// a zero rotation if they are disabled
+func (c *Config) Pseudonyms() (secret []byte, rotation time.Duration) {
+	if c.PseudonymSecret != "" {
+		return []byte(c.PseudonymSecret), max(c.PseudonymRotation, 0)
 	}
+	return c.DerivedKey(KeyPurposePseudonyms), max(c.PseudonymRotation, 0)
+}
+
+// HandshakeWindow returns how long an upgraded WebSocket may stay silent, 0 if unlimited
+func (c *Config) HandshakeWindow() time.Duration {
+	return max(c.HandshakeTimeout, 0)
+}
+
+// RoomIdleWindow returns how long an empty room is kept, 0 if empty rooms are never deleted
+func (c *Config) RoomIdleWindow() time.Duration {
+	return max(c.RoomIdleTTL, 0)
+}
+
+// HistoryCompactInterval returns how often expired history is compacted, 0 if disabled
+func (c *Config) HistoryCompactInterval() time.Duration {
+	return max(c.CompactEvery, 0)
+}
+
+// OccupancySampling returns how often room occupancy is sampled, 0 if disabled,
+// and how long histories are kept
+func (c *Config) OccupancySampling() (interval, retention time.Duration) {
+	return max(c.OccupancyInterval, 0), positiveDuration(c.OccupancyRetention, 24*time.Hour)
+}
+
+// RoomMessageQuota returns the per-room broadcast budget in messages per second, 0 if unlimited
+func (c *Config) RoomMessageQuota() int {
+	return max(c.RoomMsgQuota, 0)
+}
+
+// ClientRateLimit returns how many messages a client may send per window, 0 if unlimited
+func (c *Config) ClientRateLimit() (int, time.Duration) {
+	return max(c.ClientLimit, 0), positiveDuration(c.ClientWindow, 10*time.Second)
+}
 
-	return defaultValue
+// SendCreditLimit returns the broadcast messages per window a room shares among its members, 0 if disabled
+func (c *Config) SendCreditLimit() (int, time.Duration) {
+	return max(c.SendCredits, 0), positiveDuration(c.CreditWindow, 10*time.Second)
+}
+
+// PasswordAlertLimit returns the failed password attempts per room that trigger an alert, 0 if disabled
+func (c *Config) PasswordAlertLimit() int {
+	return max(c.PasswordAlert, 0)
+}
+
+// TracesEndpoint returns the OTLP/HTTP URL spans are exported to, empty if tracing is disabled
+func (c *Config) TracesEndpoint() string {
+	if c.OTLPEndpoint == "" {
+		return ""
+	}
+	return strings.TrimSuffix(c.OTLPEndpoint, "/") + "/v1/traces"
+}
+
+// StaticRoot returns the directory the web client is served from
+func (c *Config) StaticRoot() string {
+	if c.StaticDir == "" {
+		return "web/static"
+	}
+	return c.StaticDir
+}
+
+// positiveDuration returns d, or def if d is not positive
+func positiveDuration(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+// splitList splits a comma-separated value, dropping empty items
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
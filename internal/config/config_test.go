@@ -0,0 +1,221 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile writes a config file named name to a temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// clearEnv unsets the variables a test relies on, an empty value is ignored by Load
+func clearEnv(t *testing.T, vars ...string) {
+	for _, v := range vars {
+		t.Setenv(v, "")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "chatters.yaml", "port: 9000\nlog-level: warn\n")
+	other := writeFile(t, "other.yaml", "port: 9300\n")
+
+	tests := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		wantPort   int
+		wantLevel  string
+		wantConfig string
+	}{
+		{name: "defaults", wantPort: 8080, wantLevel: "info"},
+		{name: "file", args: []string{"-config", file}, wantPort: 9000, wantLevel: "warn", wantConfig: file},
+		{name: "file from the environment", env: map[string]string{"CONFIG_FILE": file}, wantPort: 9000, wantLevel: "warn", wantConfig: file},
+		{name: "flag names another file", args: []string{"-config", other}, env: map[string]string{"CONFIG_FILE": file}, wantPort: 9300, wantLevel: "info", wantConfig: other},
+		{name: "environment over file", args: []string{"-config", file}, env: map[string]string{"PORT": "9100"}, wantPort: 9100, wantLevel: "warn", wantConfig: file},
+		{name: "flag over environment", args: []string{"-config", file, "-port", "9200"}, env: map[string]string{"PORT": "9100", "LOG_LEVEL": "error"}, wantPort: 9200, wantLevel: "error", wantConfig: file},
+		{name: "empty variables are ignored", env: map[string]string{"PORT": ""}, wantPort: 8080, wantLevel: "info"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t, "CONFIG_FILE", "PORT", "LOG_LEVEL")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := Load(tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPort, cfg.Port)
+			assert.Equal(t, tt.wantLevel, cfg.LogLevel)
+			assert.Equal(t, tt.wantConfig, cfg.ConfigFile)
+		})
+	}
+}
+
+func TestLoadConfigFileFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "chatters.yaml",
+			content: `port: 9000
+allowed_origins:
+  - https://a.example.com
+  - https://b.example.com
+engineio: true
+reconnect-grace: 30s
+api-rate-limit: 2.5
+system-prefix: "[system]"
+`,
+		},
+		{
+			name: "toml",
+			file: "chatters.toml",
+			content: `port = 9000
+allowed_origins = ["https://a.example.com", "https://b.example.com"]
+engineio = true
+reconnect-grace = "30s"
+api-rate-limit = 2.5
+system-prefix = "[system]"
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t, "CONFIG_FILE", "PORT", "ALLOWED_ORIGINS", "ENGINEIO_ENABLED", "RECONNECT_GRACE", "API_RATE_LIMIT", "SYSTEM_PREFIX")
+			cfg, err := Load([]string{"-config", writeFile(t, tt.file, tt.content)})
+			require.NoError(t, err)
+			assert.Equal(t, 9000, cfg.Port)
+			assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.AllowedOriginList())
+			assert.True(t, cfg.EngineIO)
+			assert.Equal(t, 30*time.Second, cfg.ReconnectGrace)
+			assert.Equal(t, 2.5, cfg.APIRateLimit)
+			assert.Equal(t, "[system]", cfg.SystemPrefix)
+		})
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{name: "unknown option", file: "c.yaml", content: "prot: 9000\n", want: `unknown option "prot"`},
+		{name: "config names no other file", file: "c.yaml", content: "config: other.yaml\n", want: `unknown option "config"`},
+		{name: "invalid value", file: "c.yaml", content: "port: http\n", want: `invalid value "http" for port`},
+		{name: "invalid duration", file: "c.toml", content: "reconnect-grace = \"soon\"\n", want: "invalid value"},
+		{name: "nested table", file: "c.toml", content: "[tls]\ncert = \"a.pem\"\n", want: "nested tables are not supported"},
+		{name: "malformed yaml", file: "c.yml", content: "port: [9000\n", want: "config file"},
+		{name: "unsupported format", file: "c.json", content: `{"port": 9000}`, want: `unsupported format ".json"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t, "CONFIG_FILE")
+			_, err := Load([]string{"-config", writeFile(t, tt.file, tt.content)})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		clearEnv(t, "CONFIG_FILE")
+		_, err := Load([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")})
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+	t.Run("invalid environment variable", func(t *testing.T) {
+		clearEnv(t, "CONFIG_FILE")
+		t.Setenv("PORT", "http")
+		_, err := Load(nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "environment variable PORT")
+	})
+}
+
+func TestBoolValues(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{value: "true", want: true},
+		{value: "1", want: true},
+		{value: "YES", want: true},
+		{value: " on ", want: true},
+		{value: "false"},
+		{value: "0"},
+		{value: "no"},
+		{value: "off"},
+		{value: "maybe", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			clearEnv(t, "CONFIG_FILE")
+			t.Setenv("SPA_FALLBACK", tt.value)
+			cfg, err := Load(nil)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "invalid boolean")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.SPAFallback)
+		})
+	}
+
+	t.Run("flags", func(t *testing.T) {
+		clearEnv(t, "CONFIG_FILE", "SPA_FALLBACK", "PROFILING")
+		cfg, err := Load([]string{"-profiling", "-spa-fallback=off"})
+		require.NoError(t, err)
+		assert.True(t, cfg.Profiling)
+		assert.False(t, cfg.SPAFallback)
+	})
+}
+
+func TestReload(t *testing.T) {
+	clearEnv(t, "CONFIG_FILE", "PORT", "LOG_LEVEL", "CLIENT_RATE_LIMIT", "ROOM_MESSAGE_QUOTA")
+	path := writeFile(t, "chatters.yaml", "log-level: info\nclient-rate-limit: 5\n")
+	cfg, err := Load([]string{"-config", path, "-room-message-quota", "10"})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("log-level: debug\nclient-rate-limit: 8\nport: 9000\nroom-message-quota: 20\n"), 0o600))
+	next, err := cfg.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, "debug", next.LogLevel)
+	assert.Equal(t, 8, next.ClientLimit)
+	assert.Equal(t, 9000, next.Port)
+	assert.Equal(t, 10, next.RoomMsgQuota, "flags still override the file")
+
+	assert.Equal(t, []string{"port"}, cfg.RestartRequired(next))
+	assert.Empty(t, next.RestartRequired(next))
+
+	t.Setenv("SECRET_KEY", "rotated")
+	next, err = cfg.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jwt-secret", "port"}, cfg.RestartRequired(next))
+
+	require.NoError(t, os.WriteFile(path, []byte("log-level: [debug\n"), 0o600))
+	_, err = cfg.Reload()
+	assert.Error(t, err)
+}
+
+func TestDerivedKey(t *testing.T) {
+	cfg := &Config{JWTSecret: "secret"}
+	session := cfg.DerivedKey(KeyPurposeSession)
+	assert.Len(t, session, 32)
+	assert.Equal(t, session, cfg.DerivedKey(KeyPurposeSession))
+	assert.NotEqual(t, session, cfg.DerivedKey(KeyPurposeTickets))
+	assert.NotEqual(t, cfg.DerivedKey(KeyPurposeTickets), cfg.DerivedKey(KeyPurposeState))
+	assert.NotEqual(t, session, (&Config{JWTSecret: "other"}).DerivedKey(KeyPurposeSession))
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// loader registers every option as a flag of fs and remembers the environment
+// variable each one is read from. Values from the config file and the environment
+// are applied through the flags, so all sources share the same parsing.
+type loader struct {
+	fs  *flag.FlagSet
+	env map[string]string // flag name -> environment variable
+}
+
+// newLoader creates a loader whose flags, like the standard command line, print
+// usage and exit on -help or invalid arguments
+func newLoader(name string) *loader {
+	return &loader{fs: flag.NewFlagSet(name, flag.ExitOnError), env: make(map[string]string)}
+}
+
+func (l *loader) string(p *string, envVar, name, def, usage string) {
+	l.fs.StringVar(p, name, def, usage)
+	l.env[name] = envVar
+}
+
+func (l *loader) int(p *int, envVar, name string, def int, usage string) {
+	l.fs.IntVar(p, name, def, usage)
+	l.env[name] = envVar
+}
+
+func (l *loader) float(p *float64, envVar, name string, def float64, usage string) {
+	l.fs.Float64Var(p, name, def, usage)
+	l.env[name] = envVar
+}
+
+func (l *loader) duration(p *time.Duration, envVar, name string, def time.Duration, usage string) {
+	l.fs.DurationVar(p, name, def, usage)
+	l.env[name] = envVar
+}
+
+func (l *loader) bool(p *bool, envVar, name string, def bool, usage string) {
+	*p = def
+	l.fs.Var((*boolValue)(p), name, usage)
+	l.env[name] = envVar
+}
+
+// load applies the config file, then the environment and then args, so that each
+// source overrides the ones before it. Options a flag was given for are not read
+// from the file or the environment.
+func (l *loader) load(args []string, configFile *string) error {
+	if err := l.fs.Parse(args); err != nil {
+		return err
+	}
+	fromFlags := make(map[string]bool)
+	l.fs.Visit(func(f *flag.Flag) { fromFlags[f.Name] = true })
+	if path := os.Getenv(l.env["config"]); path != "" && !fromFlags["config"] {
+		*configFile = path
+	}
+
+	if *configFile != "" {
+		values, err := readConfigFile(*configFile)
+		if err != nil {
+			return fmt.Errorf("config file %s: %w", *configFile, err)
+		}
+		for key, value := range values {
+			name := strings.ReplaceAll(strings.ToLower(key), "_", "-")
+			if _, ok := l.env[name]; !ok || name == "config" {
+				return fmt.Errorf("config file %s: unknown option %q", *configFile, key)
+			}
+			if fromFlags[name] {
+				continue
+			}
+			if err := l.fs.Set(name, value); err != nil {
+				return fmt.Errorf("config file %s: invalid value %q for %s: %w", *configFile, value, key, err)
+			}
+		}
+	}
+
+	for name, envVar := range l.env {
+		value, ok := os.LookupEnv(envVar)
+		if !ok || value == "" || fromFlags[name] || name == "config" {
+			continue
+		}
+		if err := l.fs.Set(name, value); err != nil {
+			return fmt.Errorf("environment variable %s: invalid value %q: %w", envVar, value, err)
+		}
+	}
+	return nil
+}
+
+// values returns the resolved value of every option, keyed by flag name
+func (l *loader) values() map[string]string {
+	values := make(map[string]string, len(l.env))
+	l.fs.VisitAll(func(f *flag.Flag) { values[f.Name] = f.Value.String() })
+	return values
+}
+
+// readConfigFile reads a flat YAML or TOML file, chosen by its extension, whose keys
+// are option names. Lists are joined with commas.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported format %q, use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		s, err := scalarString(value)
+		if err != nil {
+			return nil, fmt.Errorf("option %q: %w", key, err)
+		}
+		values[key] = s
+	}
+	return values, nil
+}
+
+// scalarString formats a decoded config file value as it would be written in a flag
+func scalarString(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, err := scalarString(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	case map[string]any:
+		return "", errors.New("nested tables are not supported")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// boolValue is a boolean flag that also accepts yes/no and on/off
+type boolValue bool
+
+func (b *boolValue) Set(s string) error {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "1", "yes", "on":
+		*b = true
+	case "false", "0", "no", "off", "":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %q", s)
+	}
+	return nil
+}
+
+func (b *boolValue) String() string {
+	if b == nil {
+		return "false"
+	}
+	return strconv.FormatBool(bool(*b))
+}
+
+func (b *boolValue) IsBoolFlag() bool { return true }
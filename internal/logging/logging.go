@@ -7,6 +7,9 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Level int
@@ -21,6 +24,30 @@ const (
 	loggerKey keyType = "logger"
 )
 
+// level is the minimum level of every logger, changed at runtime by SetLevel
+var level slog.LevelVar
+
+// SetLevel sets the minimum level of every logger created by this package
+func SetLevel(l Level) {
+	level.Set(slog.Level(l))
+}
+
+// ParseLevel parses debug, info, warn or error
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return Debug, nil
+	case "info", "":
+		return Info, nil
+	case "warn", "warning":
+		return Warn, nil
+	case "error":
+		return Error, nil
+	default:
+		return Info, fmt.Errorf("unknown log level %q, use debug, info, warn or error", s)
+	}
+}
+
 // Logger defines the interface for structured logging.
 type Logger interface {
 	Debug(ctx context.Context, msg string, keysAndValues ...interface{})
@@ -48,7 +75,7 @@ type SlogLogger struct {
 }
 
 func newSlogLogger(writer io.Writer) Logger {
-	handler := slog.NewJSONHandler(writer, &slog.HandlerOptions{})
+	handler := slog.NewJSONHandler(writer, &slog.HandlerOptions{Level: &level})
 	return &SlogLogger{
 		logger: slog.New(handler),
 	}
@@ -92,6 +119,9 @@ func (l *SlogLogger) Log(ctx context.Context, level Level, msg string, keysAndVa
 		if requestID, ok := ctx.Value("request_id").(string); ok {
 			keysAndValues = append(keysAndValues, "request_id", requestID)
 		}
+		if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+			keysAndValues = append(keysAndValues, "trace_id", span.TraceID().String(), "span_id", span.SpanID().String())
+		}
 		l.logger.Log(ctx, slog.Level(level), msg, keysAndValues...)
 	}
 }
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLogResponse lists the recent joins, leaves and kicks of a room
+type AccessLogResponse struct {
+	Entries []websocket.AccessEntry `json:"entries"`
+}
+
+// RoomAccessLog godoc
+// @Summary Room access log
+// @Description Returns the most recent joins, leaves and kicks of the room, oldest first, with the
+// @Description connection ID of each member (host only). Hosts are anonymous, so member addresses
+// @Description are left out; operators read them from the admin access log.
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Success 200 {object} AccessLogResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/access-log [get]
+func (s *Server) RoomAccessLog() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+		entries := room.AccessLog()
+		for i := range entries {
+			entries[i].IP = ""
+		}
+		c.JSON(http.StatusOK, AccessLogResponse{Entries: entries})
+	}
+}
+
+// AdminRoomAccessLog godoc
+// @Summary Room access log with addresses
+// @Description Returns the most recent joins, leaves and kicks of the room, oldest first, with the
+// @Description connection ID and address of each member (admin only)
+// @Tags admin
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} AccessLogResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/rooms/{room_id}/access-log [get]
+func (s *Server) AdminRoomAccessLog() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.adminRoom(c)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, AccessLogResponse{Entries: room.AccessLog()})
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/config"
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	gorillaWs "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLogRedactsAddressesForHosts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := websocket.NewHMACKeys("test-secret")
+	s := &Server{
+		Handler:   *websocket.NewHandler(websocket.NewHub(), nil),
+		Logger:    logging.NewLogger(),
+		Config:    &config.Config{AdminKey: "admin-key"},
+		TokenKeys: keys,
+	}
+	room, _ := s.Handler.Hub.CreateRoom(1, nil, websocket.WithHost("host-1"))
+	defer room.StopRoom()
+	token, err := keys.Sign(websocket.NewHostClaims(1, "host-1", time.Now()))
+	require.NoError(t, err)
+
+	engine := gin.New()
+	engine.GET("/api/ws/:room_id", s.Handler.HandleWebSocketWithKeys(keys))
+	engine.GET("/api/rooms/:room_id/access-log", s.RoomAccessLog())
+	engine.GET("/api/admin/rooms/:room_id/access-log", s.requireAdmin(), s.AdminRoomAccessLog())
+	server := httptest.NewServer(engine)
+	defer server.Close()
+	conn, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws/1?username=alice", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool { return len(room.AccessLog()) == 1 }, time.Second, 10*time.Millisecond)
+
+	accessLog := func(path, header, value string) []websocket.AccessEntry {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(header, value)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp AccessLogResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Entries, 1)
+		return resp.Entries
+	}
+	hostView := accessLog("/api/rooms/1/access-log", "Authorization", token)
+	assert.Equal(t, "alice", hostView[0].Username)
+	assert.Empty(t, hostView[0].IP)
+	adminView := accessLog("/api/admin/rooms/1/access-log", AdminKeyHeader, "admin-key")
+	assert.Equal(t, "127.0.0.1", adminView[0].IP)
+	assert.NotEmpty(t, room.AccessLog()[0].IP, "the room keeps the address")
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminKeyHeader carries the operator key of admin requests
+const AdminKeyHeader = "X-Admin-Key"
+
+// AdminDisconnectRequest lists the clients to disconnect from a room
+type AdminDisconnectRequest struct {
+	Usernames []string `json:"usernames" binding:"required,min=1,max=100,dive,required,max=50" example:"john_doe"`
+}
+
+// AdminDisconnectResponse reports how many connections were closed
+type AdminDisconnectResponse struct {
+	Disconnected int `json:"disconnected" example:"1"`
+}
+
+// AdminStatsResponse is a server-wide snapshot for operators
+type AdminStatsResponse struct {
+	StartedAt     time.Time `json:"started_at" example:"2024-01-01T12:00:00Z"`
+	UptimeSeconds int64     `json:"uptime_seconds" example:"3600"`
+	Rooms         int       `json:"rooms" example:"12"`
+	PublicRooms   int       `json:"public_rooms" example:"3"`
+	Clients       int       `json:"clients" example:"87"`
+	Connections   int       `json:"connections" example:"87"`
+	Goroutines    int       `json:"goroutines" example:"412"`
+}
+
+// requireAdmin guards the admin API. It answers 404 while no admin key is configured
+// and 401 when the request does not carry it.
+func (s *Server) requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminKey := s.Config.AdminAPIKey()
+		if adminKey == "" {
+			c.AbortWithStatusJSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "admin API is disabled",
+				ErrorCode: websocket.CodeFeatureDisabled,
+			})
+			return
+		}
+		key := c.GetHeader(AdminKeyHeader)
+		if key == "" || subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) != 1 {
+			s.Metrics.AuthFailed(websocket.AuthTransportREST, websocket.AuthFailureInvalidAdminKey)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
+				Code:      http.StatusUnauthorized,
+				Error:     "valid " + AdminKeyHeader + " header required",
+				ErrorCode: websocket.CodeInvalidAdminKey,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// adminRoom resolves the room of an admin request, writing an error response if it is missing
+func (s *Server) adminRoom(c *gin.Context) (*websocket.Room, bool) {
+	roomID, err := validateRoomID(c.Param("room_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Error:     "invalid room ID format",
+			ErrorCode: websocket.CodeInvalidRoomID,
+		})
+		return nil, false
+	}
+	room, exists := s.Handler.Hub.GetRoom(roomID)
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:      http.StatusNotFound,
+			Error:     "room not found",
+			ErrorCode: websocket.CodeRoomNotFound,
+		})
+		return nil, false
+	}
+	return room, true
+}
+
+// AdminRooms godoc
+// @Summary List all rooms
+// @Description Returns every room on this server, private ones included, ordered by room ID (admin only)
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param cursor query string false "Cursor from a previous page"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Success 200 {object} PageResponse[websocket.RoomSummary]
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Admin API is disabled"
+// @Router /api/admin/rooms [get]
+func (s *Server) AdminRooms() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		page, ok := pageFromQuery(c, s.Handler.Hub.ListRooms(websocket.RoomFilter{}))
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// AdminDeleteRoom godoc
+// @Summary Force-delete a room
+// @Description Deletes a room and disconnects its clients without the host token (admin only)
+// @Tags admin
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/rooms/{room_id} [delete]
+func (s *Server) AdminDeleteRoom() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.adminRoom(c)
+		if !ok {
+			return
+		}
+		if !s.Handler.Hub.DeleteRoom(room.ID) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
+			})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Warn, "Room force-deleted by admin",
+			"room_id", room.ID, "client_ip", c.ClientIP())
+
+		c.JSON(http.StatusOK, gin.H{"message": "room deleted successfully"})
+	}
+}
+
+// AdminDisconnect godoc
+// @Summary Disconnect clients
+// @Description Closes the connections of the listed users, hosts included. Clients may reconnect; ban them to keep them out (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param request body AdminDisconnectRequest true "Users to disconnect"
+// @Success 200 {object} AdminDisconnectResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/admin/rooms/{room_id}/disconnect [post]
+func (s *Server) AdminDisconnect() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.adminRoom(c)
+		if !ok {
+			return
+		}
+		var req AdminDisconnectRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		disconnected := room.Disconnect(req.Usernames)
+		s.Logger.Log(c.Request.Context(), logging.Warn, "Clients disconnected by admin",
+			"room_id", room.ID, "usernames", req.Usernames, "disconnected", disconnected)
+
+		c.JSON(http.StatusOK, AdminDisconnectResponse{Disconnected: disconnected})
+	}
+}
+
+// AdminStats godoc
+// @Summary Server statistics
+// @Description Returns server-wide room, client and connection counts (admin only)
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} AdminStatsResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Admin API is disabled"
+// @Router /api/admin/stats [get]
+func (s *Server) AdminStats() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		stats := AdminStatsResponse{
+			StartedAt:     s.startedAt,
+			UptimeSeconds: int64(time.Since(s.startedAt) / time.Second),
+			Connections:   s.Handler.Admission.Active(),
+			Goroutines:    runtime.NumGoroutine(),
+		}
+		for _, room := range s.Handler.Hub.ListRooms(websocket.RoomFilter{}) {
+			stats.Rooms++
+			stats.Clients += room.ClientCount
+			if room.Visibility == websocket.VisibilityPublic {
+				stats.PublicRooms++
+			}
+		}
+		c.JSON(http.StatusOK, stats)
+	}
+}
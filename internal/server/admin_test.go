@@ -0,0 +1,197 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/config"
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	gorillaWs "github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// adminServer returns a server with the admin API guarded by adminKey, and the
+// engine serving it with the WebSocket endpoint
+func adminServer(t *testing.T, adminKey string) (*Server, *gin.Engine) {
+	gin.SetMode(gin.TestMode)
+	pool, err := websocket.NewTaskPool(10)
+	require.NoError(t, err)
+	t.Cleanup(pool.Release)
+	keys := websocket.NewHMACKeys("test-secret")
+	s := &Server{
+		Handler:   *websocket.NewHandler(websocket.NewHub(), pool),
+		Logger:    logging.NewLogger(),
+		Config:    &config.Config{AdminKey: adminKey},
+		TokenKeys: keys,
+		Metrics: &Metrics{AuthFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: "test_auth_failures_total"}, []string{"transport", "reason"})},
+		startedAt: time.Now().Add(-time.Minute),
+	}
+	engine := gin.New()
+	engine.GET("/api/ws/:room_id", s.Handler.HandleWebSocketWithKeys(keys))
+	admin := engine.Group("/api/admin", s.requireAdmin())
+	admin.GET("/stats", s.AdminStats())
+	admin.GET("/rooms", s.AdminRooms())
+	admin.DELETE("/rooms/:room_id", s.AdminDeleteRoom())
+	admin.POST("/rooms/:room_id/disconnect", s.AdminDisconnect())
+	admin.GET("/bans", s.AdminListBans())
+	admin.POST("/bans", s.AdminCreateBan())
+	admin.DELETE("/bans", s.AdminDeleteBan())
+	return s, engine
+}
+
+// adminRequest serves a request to the admin API carrying key, if any
+func adminRequest(engine *gin.Engine, method, path, key string, body any) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(AdminKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequireAdmin(t *testing.T) {
+	t.Run("disabled without a configured key", func(t *testing.T) {
+		_, engine := adminServer(t, "")
+		w := adminRequest(engine, http.MethodGet, "/api/admin/stats", "anything", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), string(websocket.CodeFeatureDisabled))
+	})
+
+	s, engine := adminServer(t, "admin-key")
+	for _, key := range []string{"", "wrong-key", "admin-key-suffix"} {
+		w := adminRequest(engine, http.MethodGet, "/api/admin/stats", key, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "key %q", key)
+		assert.Contains(t, w.Body.String(), string(websocket.CodeInvalidAdminKey))
+	}
+	assert.Equal(t, 3.0, testutil.ToFloat64(s.Metrics.AuthFailures.WithLabelValues(
+		websocket.AuthTransportREST, websocket.AuthFailureInvalidAdminKey)))
+
+	w := adminRequest(engine, http.MethodGet, "/api/admin/stats", "admin-key", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAdminRoomsAndStats(t *testing.T) {
+	s, engine := adminServer(t, "admin-key")
+	private, _ := s.Handler.Hub.CreateRoom(1, nil)
+	defer private.StopRoom()
+	public, _ := s.Handler.Hub.CreateRoom(2, nil, websocket.WithVisibility(websocket.VisibilityPublic))
+	defer public.StopRoom()
+
+	w := adminRequest(engine, http.MethodGet, "/api/admin/rooms", "admin-key", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var page PageResponse[websocket.RoomSummary]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page.Items, 2, "private rooms are listed")
+	assert.Equal(t, websocket.ID(1), page.Items[0].ID)
+	assert.Equal(t, websocket.ID(2), page.Items[1].ID)
+
+	w = adminRequest(engine, http.MethodGet, "/api/admin/stats", "admin-key", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var stats AdminStatsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, 2, stats.Rooms)
+	assert.Equal(t, 1, stats.PublicRooms)
+	assert.GreaterOrEqual(t, stats.UptimeSeconds, int64(60))
+	assert.Positive(t, stats.Goroutines)
+}
+
+func TestAdminDeleteRoom(t *testing.T) {
+	s, engine := adminServer(t, "admin-key")
+	s.Handler.Hub.CreateRoom(1, nil, websocket.WithHost("host-1"))
+
+	w := adminRequest(engine, http.MethodDelete, "/api/admin/rooms/1", "admin-key", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, exists := s.Handler.Hub.GetRoom(1)
+	assert.False(t, exists)
+
+	w = adminRequest(engine, http.MethodDelete, "/api/admin/rooms/1", "admin-key", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), string(websocket.CodeRoomNotFound))
+
+	w = adminRequest(engine, http.MethodDelete, "/api/admin/rooms/abc", "admin-key", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), string(websocket.CodeInvalidRoomID))
+}
+
+func TestAdminDisconnect(t *testing.T) {
+	s, engine := adminServer(t, "admin-key")
+	room, _ := s.Handler.Hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	server := httptest.NewServer(engine)
+	defer server.Close()
+	conn, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws/1?username=alice", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool { return room.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	w := adminRequest(engine, http.MethodPost, "/api/admin/rooms/1/disconnect", "admin-key", AdminDisconnectRequest{})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "an empty list is rejected")
+
+	w = adminRequest(engine, http.MethodPost, "/api/admin/rooms/1/disconnect", "admin-key",
+		AdminDisconnectRequest{Usernames: []string{"alice", "nobody"}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp AdminDisconnectResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 1, resp.Disconnected)
+	assert.Eventually(t, func() bool { return room.GetClientCount() == 0 }, time.Second, 10*time.Millisecond)
+
+	w = adminRequest(engine, http.MethodPost, "/api/admin/rooms/2/disconnect", "admin-key",
+		AdminDisconnectRequest{Usernames: []string{"alice"}})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminBans(t *testing.T) {
+	s, engine := adminServer(t, "admin-key")
+	room, _ := s.Handler.Hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	server := httptest.NewServer(engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username=alice"
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool { return room.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	w := adminRequest(engine, http.MethodPost, "/api/admin/bans", "admin-key", BanRequest{})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "a target is required")
+
+	w = adminRequest(engine, http.MethodPost, "/api/admin/bans", "admin-key", BanRequest{Username: "alice", DurationSeconds: 3600})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Eventually(t, func() bool { return room.GetClientCount() == 0 }, time.Second, 10*time.Millisecond)
+	_, resp, err := gorillaWs.DefaultDialer.Dial(wsURL, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	w = adminRequest(engine, http.MethodGet, "/api/admin/bans", "admin-key", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var bans BansResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &bans))
+	require.Len(t, bans.Bans, 1)
+	assert.Equal(t, "alice", bans.Bans[0].Username)
+	assert.NotNil(t, bans.Bans[0].ExpiresAt)
+
+	w = adminRequest(engine, http.MethodDelete, "/api/admin/bans?username=alice", "admin-key", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = adminRequest(engine, http.MethodDelete, "/api/admin/bans?username=alice", "admin-key", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), string(websocket.CodeBanNotFound))
+	w = adminRequest(engine, http.MethodDelete, "/api/admin/bans", "admin-key", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordAlertPayload is posted to the password alert webhook
+type PasswordAlertPayload struct {
+	Event          string       `json:"event" example:"room.password_alert"`
+	FailedAttempts int          `json:"failed_attempts" example:"10"`
+	Sources        int          `json:"sources" example:"2"`
+	RoomID         websocket.ID `json:"room_id" example:"123456"`
+}
+
+// passwordAlert logs a password guessing alert and forwards it to the configured webhook
+func (s *Server) passwordAlert(room *websocket.Room, audit websocket.PasswordAudit) {
+	ctx := context.Background()
+	s.Logger.Log(ctx, logging.Warn, "Room password attempts threshold reached",
+		"room_id", room.ID, "failed_attempts", audit.FailedAttempts, "sources", len(audit.Sources))
+
+	url := s.Config.AlertWebhook
+	if url == "" {
+		return
+	}
+	body, err := json.Marshal(PasswordAlertPayload{
+		Event:          "room.password_alert",
+		RoomID:         room.ID,
+		FailedAttempts: audit.FailedAttempts,
+		Sources:        len(audit.Sources),
+	})
+	if err != nil {
+		return
+	}
+
+	s.postWebhook(url, body, true)
+}
+
+// PasswordAttempts godoc
+// @Summary Failed password attempts
+// @Description Returns failed join-password attempts for the room grouped by hashed source address (host only)
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Success 200 {object} websocket.PasswordAudit
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/password-attempts [get]
+func (s *Server) PasswordAttempts() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, room.PasswordAudit())
+	}
+}
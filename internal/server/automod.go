@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// SetModRulesRequest replaces the auto-moderation rules of a room. An empty list
+// turns auto-moderation off.
+type SetModRulesRequest struct {
+	Rules []websocket.ModRule `json:"rules" binding:"max=20"`
+}
+
+type ModRulesResponse struct {
+	Rules []websocket.ModRule `json:"rules"`
+}
+
+// RoomModRules godoc
+// @Summary Get auto-moderation rules
+// @Description Returns the auto-moderation rules of the room in evaluation order (host only)
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Success 200 {object} ModRulesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/automod [get]
+func (s *Server) RoomModRules() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, ModRulesResponse{Rules: room.ModRules()})
+	}
+}
+
+// SetRoomModRules godoc
+// @Summary Set auto-moderation rules
+// @Description Replaces the auto-moderation rules of the room (host only). Rules run in order on chat messages of members.
+// @Description A message matches a rule when all of its conditions hold: words, pattern, sender age and message rate.
+// @Description Flag rules record a host-only "flagged" event; the first matching delete, mute or kick rule drops the message,
+// @Description acts on the sender and records a host-only "automod" event. Hosts are never moderated.
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Param request body SetModRulesRequest true "Rules in evaluation order"
+// @Success 200 {object} ModRulesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/rooms/{room_id}/automod [put]
+func (s *Server) SetRoomModRules() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		var req SetModRulesRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+		if fieldErrs := websocket.ValidateModRules(req.Rules); len(fieldErrs) > 0 {
+			respondValidationErrors(c, fieldErrs)
+			return
+		}
+		if err := room.SetModRules(req.Rules); err != nil {
+			respondValidationErrors(c, []websocket.ValidationError{{Field: "rules", Message: err.Error()}})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Room auto-moderation rules updated",
+			"room_id", room.ID, "rules", len(req.Rules))
+
+		c.JSON(http.StatusOK, ModRulesResponse{Rules: room.ModRules()})
+	}
+}
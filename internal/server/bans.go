@@ -0,0 +1,249 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+type BanRequest struct {
+	Username        string `json:"username,omitempty" binding:"required_without=IP,excluded_with=IP,max=50" example:"spammer1"`
+	IP              string `json:"ip,omitempty" binding:"omitempty,ip" example:"203.0.113.7"`
+	DurationSeconds int    `json:"duration_seconds,omitempty" binding:"min=0,max=31536000" example:"3600"`
+}
+
+type BansResponse struct {
+	Bans []websocket.Ban `json:"bans"`
+}
+
+// CreateBan godoc
+// @Summary Ban user or IP
+// @Description Bans a username or an IP address from the room and disconnects matching members (host only). Unlike a kick, banned users cannot rejoin until the ban expires or is lifted. Omit duration to ban permanently.
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Param Idempotency-Key header string false "Key to safely retry the request"
+// @Param request body BanRequest true "Ban target and duration"
+// @Success 201 {object} websocket.Ban
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Too many bans"
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/rooms/{room_id}/bans [post]
+func (s *Server) CreateBan() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		var req BanRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		ban, err := room.Ban(req.Username, req.IP, time.Duration(req.DurationSeconds)*time.Second, s.Handler.Hub.Brand().HostName)
+		if errors.Is(err, websocket.ErrTooManyBans) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Code:      http.StatusConflict,
+				Error:     err.Error(),
+				ErrorCode: websocket.CodeTooManyBans,
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     err.Error(),
+				ErrorCode: websocket.CodeInvalidRequest,
+			})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Ban added",
+			"room_id", room.ID, "username", req.Username, "ip", req.IP, "duration_seconds", req.DurationSeconds)
+
+		c.JSON(http.StatusCreated, ban)
+	}
+}
+
+// DeleteBan godoc
+// @Summary Lift ban
+// @Description Lifts the ban on a username or an IP address (host only)
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Param username query string false "Banned username"
+// @Param ip query string false "Banned IP address"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/bans [delete]
+func (s *Server) DeleteBan() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		username, ip := c.Query("username"), c.Query("ip")
+		if (username == "") == (ip == "") {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     "exactly one of username or ip is required",
+				ErrorCode: websocket.CodeInvalidRequest,
+			})
+			return
+		}
+
+		if !room.Unban(username, ip) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "ban not found",
+				ErrorCode: websocket.CodeBanNotFound,
+			})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Ban lifted",
+			"room_id", room.ID, "username", username, "ip", ip)
+
+		c.JSON(http.StatusOK, gin.H{"message": "ban lifted"})
+	}
+}
+
+// ListBans godoc
+// @Summary List bans
+// @Description Returns the bans currently in effect, oldest first (host only)
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Success 200 {object} BansResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/bans [get]
+func (s *Server) ListBans() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, BansResponse{Bans: room.Bans()})
+	}
+}
+
+// AdminCreateBan godoc
+// @Summary Ban user or IP from every room
+// @Description Bans a username (case-insensitive) or an IP address from all rooms, hosts included, and closes matching connections. Omit duration to ban permanently (admin only).
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param request body BanRequest true "Ban target and duration"
+// @Success 201 {object} websocket.Ban
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Admin API is disabled"
+// @Failure 409 {object} ErrorResponse "Too many bans"
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/admin/bans [post]
+func (s *Server) AdminCreateBan() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req BanRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		ban, err := s.Handler.Hub.Ban(req.Username, req.IP, time.Duration(req.DurationSeconds)*time.Second, "")
+		if errors.Is(err, websocket.ErrTooManyHubBans) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Code:      http.StatusConflict,
+				Error:     err.Error(),
+				ErrorCode: websocket.CodeTooManyBans,
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     err.Error(),
+				ErrorCode: websocket.CodeInvalidRequest,
+			})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Warn, "Ban added to all rooms by admin",
+			"username", req.Username, "ip", req.IP, "duration_seconds", req.DurationSeconds)
+
+		c.JSON(http.StatusCreated, ban)
+	}
+}
+
+// AdminDeleteBan godoc
+// @Summary Lift a ban from every room
+// @Description Lifts the ban on a username or an IP address set through the admin API (admin only)
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param username query string false "Banned username"
+// @Param ip query string false "Banned IP address"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/bans [delete]
+func (s *Server) AdminDeleteBan() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		username, ip := c.Query("username"), c.Query("ip")
+		if (username == "") == (ip == "") {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     "exactly one of username or ip is required",
+				ErrorCode: websocket.CodeInvalidRequest,
+			})
+			return
+		}
+
+		if !s.Handler.Hub.Unban(username, ip) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "ban not found",
+				ErrorCode: websocket.CodeBanNotFound,
+			})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Warn, "Ban lifted from all rooms by admin",
+			"username", username, "ip", ip)
+
+		c.JSON(http.StatusOK, gin.H{"message": "ban lifted"})
+	}
+}
+
+// AdminListBans godoc
+// @Summary List bans of every room
+// @Description Returns the bans set through the admin API that are in effect, oldest first (admin only)
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} BansResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Admin API is disabled"
+// @Router /api/admin/bans [get]
+func (s *Server) AdminListBans() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, BansResponse{Bans: s.Handler.Hub.Bans()})
+	}
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BrandingResponse holds the user-visible names clients should display
+type BrandingResponse struct {
+	AppName         string `json:"app_name" example:"Chatters"`
+	DefaultUsername string `json:"default_username" example:"Anonymous"`
+}
+
+// Branding godoc
+// @Summary Branding
+// @Description Returns the product name and the username given to clients that join without one
+// @Tags session
+// @Produce json
+// @Success 200 {object} BrandingResponse
+// @Router /api/branding [get]
+func (s *Server) Branding() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		branding := s.Handler.Hub.Brand()
+		c.JSON(http.StatusOK, BrandingResponse{AppName: branding.AppName, DefaultUsername: branding.DefaultName})
+	}
+}
@@ -0,0 +1,282 @@
+package server
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CreateBreakoutRequest struct {
+	Topic      *string `json:"topic,omitempty" binding:"omitempty,max=200" example:"Group A"`
+	MaxClients *int    `json:"max_clients,omitempty" binding:"omitempty,min=0,max=10000" example:"8"`
+}
+
+type BreakoutsResponse struct {
+	Breakouts []websocket.BreakoutInfo `json:"breakouts"`
+}
+
+type MoveToBreakoutRequest struct {
+	Usernames []string `json:"usernames" binding:"required,min=1,max=100,unique,dive,required,max=50" example:"alice,bob"`
+}
+
+// MoveResultResponse is the outcome of moving a single user
+type MoveResultResponse struct {
+	Username string `json:"username" example:"alice"`
+	Error    string `json:"error,omitempty" example:"user not found in room"`
+	Moved    bool   `json:"moved" example:"true"`
+}
+
+type MoveToBreakoutResponse struct {
+	Results []MoveResultResponse `json:"results"`
+	Moved   int                  `json:"moved" example:"2"`
+}
+
+type RecallBreakoutsResponse struct {
+	Recalled int `json:"recalled" example:"6"`
+}
+
+// CreateBreakout godoc
+// @Summary Create breakout room
+// @Description Creates a private breakout room of the room with the same password and returns a host token for it (host only).
+// @Description Breakout rooms are deleted together with their parent room.
+// @Tags breakouts
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Parent room ID"
+// @Param Authorization header string true "Host JWT token of the parent room"
+// @Param request body CreateBreakoutRequest false "Breakout room settings"
+// @Success 201 {object} CreateRoomResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Too many breakout rooms, or the room is a breakout room"
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Failure 429 {object} ErrorResponse "Room quota exceeded"
+// @Router /api/rooms/{room_id}/breakouts [post]
+func (s *Server) CreateBreakout() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		parent, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		var req CreateBreakoutRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		private := string(websocket.VisibilityPrivate)
+		settingsOpts, fields, err := s.roomOptions(CreateRoomRequest{
+			Topic:      req.Topic,
+			Visibility: &private,
+			MaxClients: req.MaxClients,
+		})
+		if err != nil {
+			s.Logger.Log(ctx, logging.Error, "Invalid default room options", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:      http.StatusInternalServerError,
+				Error:     "invalid default room options",
+				ErrorCode: websocket.CodeInternal,
+			})
+			return
+		}
+		if len(fields) > 0 {
+			respondValidationErrors(c, fields)
+			return
+		}
+
+		hostID := uuid.New().String()
+		opts := append(settingsOpts, websocket.WithHost(hostID))
+		opts = append(opts, s.serverRoomOptions()...)
+
+		var breakout *websocket.Room
+		err = websocket.ErrRoomIDTaken
+		for i := 0; i < 100 && errors.Is(err, websocket.ErrRoomIDTaken); i++ {
+			roomID := websocket.ID(rand.Uint32())
+			if roomID < MinRoomID || roomID > MaxRoomID {
+				continue
+			}
+			breakout, err = s.Handler.Hub.CreateBreakout(parent, roomID, s.Metrics, opts...)
+		}
+		switch {
+		case errors.Is(err, websocket.ErrTooManyBreakouts):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Code:      http.StatusConflict,
+				Error:     err.Error(),
+				ErrorCode: websocket.CodeTooManyBreakouts,
+			})
+			return
+		case errors.Is(err, websocket.ErrNestedBreakout):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Code:      http.StatusConflict,
+				Error:     err.Error(),
+				ErrorCode: websocket.CodeInvalidRequest,
+			})
+			return
+		case errors.Is(err, websocket.ErrRoomQuota):
+			c.JSON(http.StatusTooManyRequests, ErrorResponse{
+				Code:      http.StatusTooManyRequests,
+				Error:     err.Error(),
+				ErrorCode: websocket.CodeQuotaExceeded,
+			})
+			return
+		case err != nil:
+			s.Logger.Log(ctx, logging.Error, "Failed to create breakout room", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:      http.StatusInternalServerError,
+				Error:     "failed to create breakout room",
+				ErrorCode: websocket.CodeInternal,
+			})
+			return
+		}
+
+		tokenString, err := s.TokenKeys.Sign(websocket.NewHostClaims(breakout.ID, hostID, time.Now()))
+		if err != nil {
+			s.Handler.Hub.DeleteRoom(breakout.ID)
+			s.Logger.Log(ctx, logging.Error, "Failed to sign JWT token", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:      http.StatusInternalServerError,
+				Error:     "failed to generate host token",
+				ErrorCode: websocket.CodeInternal,
+			})
+			return
+		}
+
+		s.Logger.Log(ctx, logging.Info, "Breakout room created",
+			"room_id", parent.ID, "breakout_id", breakout.ID)
+		c.JSON(http.StatusCreated, CreateRoomResponse{RoomID: breakout.ID, HostToken: tokenString})
+	}
+}
+
+// ListBreakouts godoc
+// @Summary List breakout rooms
+// @Description Returns the breakout rooms of the room with their online member counts (host only)
+// @Tags breakouts
+// @Produce json
+// @Param room_id path int true "Parent room ID"
+// @Param Authorization header string true "Host JWT token of the parent room"
+// @Success 200 {object} BreakoutsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/breakouts [get]
+func (s *Server) ListBreakouts() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		parent, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		resp := BreakoutsResponse{Breakouts: []websocket.BreakoutInfo{}}
+		for _, room := range s.Handler.Hub.Breakouts(parent.ID) {
+			resp.Breakouts = append(resp.Breakouts, websocket.BreakoutInfo{
+				RoomID:      room.ID,
+				OnlineCount: room.GetClientCount(),
+			})
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// MoveToBreakout godoc
+// @Summary Move users to breakout room
+// @Description Sends each listed member of the room a "move" message with a join ticket for the breakout room (host only).
+// @Description Members leave and rejoin on their own; the ticket replaces the room password.
+// @Tags breakouts
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Parent room ID"
+// @Param breakout_id path int true "Breakout room ID"
+// @Param Authorization header string true "Host JWT token of the parent room"
+// @Param request body MoveToBreakoutRequest true "Users to move"
+// @Success 200 {object} MoveToBreakoutResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/rooms/{room_id}/breakouts/{breakout_id}/move [post]
+func (s *Server) MoveToBreakout() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		parent, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		breakoutID, err := validateRoomID(c.Param("breakout_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     "invalid breakout room ID format",
+				ErrorCode: websocket.CodeInvalidRoomID,
+			})
+			return
+		}
+		if _, err := s.Handler.Hub.Breakout(parent.ID, breakoutID); err != nil {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     err.Error(),
+				ErrorCode: websocket.CodeBreakoutNotFound,
+			})
+			return
+		}
+
+		var req MoveToBreakoutRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		results := parent.MoveClients(req.Usernames, breakoutID, websocket.MoveToBreakout, s.Handler.Tickets)
+
+		resp := MoveToBreakoutResponse{Results: make([]MoveResultResponse, 0, len(results))}
+		for _, result := range results {
+			resp.Results = append(resp.Results, MoveResultResponse{
+				Username: result.Username,
+				Moved:    result.Moved,
+				Error:    result.Reason,
+			})
+			if result.Moved {
+				resp.Moved++
+			}
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Users moved to breakout room",
+			"room_id", parent.ID, "breakout_id", breakoutID, "moved", resp.Moved)
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// RecallBreakouts godoc
+// @Summary Recall breakout rooms
+// @Description Sends every member of the room's breakout rooms a "move" message with a join ticket back to the room (host only).
+// @Description The breakout rooms stay open until they are deleted.
+// @Tags breakouts
+// @Produce json
+// @Param room_id path int true "Parent room ID"
+// @Param Authorization header string true "Host JWT token of the parent room"
+// @Success 200 {object} RecallBreakoutsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/breakouts/recall [post]
+func (s *Server) RecallBreakouts() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		parent, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		recalled := s.Handler.Hub.RecallBreakouts(parent.ID, s.Handler.Tickets)
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Breakout rooms recalled",
+			"room_id", parent.ID, "recalled", recalled)
+		c.JSON(http.StatusOK, RecallBreakoutsResponse{Recalled: recalled})
+	}
+}
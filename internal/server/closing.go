@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// MaxCloseDelay is the longest countdown a host can schedule before a room closes
+const MaxCloseDelay = 24 * time.Hour
+
+type CloseRoomResponse struct {
+	ClosesAt time.Time `json:"closes_at" example:"2024-01-01T12:05:00Z"`
+}
+
+// closesAt returns when the room's scheduled close takes effect, nil if none is scheduled
+func closesAt(room *websocket.Room) *time.Time {
+	at := room.ClosesAt()
+	if at.IsZero() {
+		return nil
+	}
+	at = at.UTC()
+	return &at
+}
+
+// CloseRoom godoc
+// @Summary Schedule room close
+// @Description Announces that the room closes after the given delay, repeats "closing" warnings to members as the deadline approaches, then sends "closed" and disconnects everyone gracefully (host only). Calling it again replaces the countdown.
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param in query string false "Delay before the room closes, e.g. 5m (default 0, max 24h)"
+// @Param Authorization header string true "Host JWT token"
+// @Success 202 {object} CloseRoomResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/close [post]
+func (s *Server) CloseRoom() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		var delay time.Duration
+		if in := c.Query("in"); in != "" {
+			var err error
+			delay, err = time.ParseDuration(in)
+			if err != nil || delay < 0 || delay > MaxCloseDelay {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Code:      http.StatusBadRequest,
+					Error:     "in must be a duration between 0s and 24h",
+					ErrorCode: websocket.CodeInvalidRequest,
+				})
+				return
+			}
+		}
+
+		at, scheduled := s.Handler.Hub.ScheduleClose(room.ID, delay)
+		if !scheduled {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
+			})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Room close scheduled",
+			"room_id", room.ID, "in", delay.String())
+
+		c.JSON(http.StatusAccepted, CloseRoomResponse{ClosesAt: at.UTC()})
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminDisconnectMatchingRequest selects connections to close across all rooms
+type AdminDisconnectMatchingRequest struct {
+	Username string `json:"username" binding:"required_without=IP,max=50" example:"spammer1"`
+	IP       string `json:"ip" binding:"omitempty,ip" example:"203.0.113.7"`
+}
+
+// connectionFilter reads the connection filter from the query, writing a 400
+// response for an invalid room ID
+func connectionFilter(c *gin.Context) (websocket.ConnectionFilter, bool) {
+	filter := websocket.ConnectionFilter{
+		Username:  c.Query("username"),
+		IP:        c.Query("ip"),
+		SessionID: c.Query("session_id"),
+	}
+	if roomIDStr := c.Query("room_id"); roomIDStr != "" {
+		roomID, err := validateRoomID(roomIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     "invalid room ID format",
+				ErrorCode: websocket.CodeInvalidRoomID,
+			})
+			return filter, false
+		}
+		filter.RoomID = roomID
+	}
+	return filter, true
+}
+
+// AdminConnections godoc
+// @Summary List connections
+// @Description Returns the live connections of all rooms, the longest connected first, optionally filtered (admin only)
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param username query string false "Username, case-insensitive"
+// @Param ip query string false "Client IP"
+// @Param session_id query string false "Session ID"
+// @Param room_id query int false "Room ID"
+// @Param cursor query string false "Cursor from a previous page"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Success 200 {object} PageResponse[websocket.ConnectionInfo]
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Admin API is disabled"
+// @Router /api/admin/connections [get]
+func (s *Server) AdminConnections() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		filter, ok := connectionFilter(c)
+		if !ok {
+			return
+		}
+		page, ok := pageFromQuery(c, s.Handler.Hub.Connections.Find(filter))
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// AdminConnection godoc
+// @Summary Look up a connection
+// @Description Returns the room, username and IP of a live connection (admin only)
+// @Tags admin
+// @Produce json
+// @Param conn_id path string true "Connection ID"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} websocket.ConnectionInfo
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/connections/{conn_id} [get]
+func (s *Server) AdminConnection() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		info, ok := s.Handler.Hub.Connections.Lookup(c.Param("conn_id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "connection not found",
+				ErrorCode: websocket.CodeConnectionNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, info)
+	}
+}
+
+// AdminCloseConnection godoc
+// @Summary Close a connection
+// @Description Closes one live connection. The client may reconnect; ban it to keep it out (admin only)
+// @Tags admin
+// @Produce json
+// @Param conn_id path string true "Connection ID"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} AdminDisconnectResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/connections/{conn_id} [delete]
+func (s *Server) AdminCloseConnection() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		connID := c.Param("conn_id")
+		if !s.Handler.Hub.Connections.Disconnect(connID) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "connection not found",
+				ErrorCode: websocket.CodeConnectionNotFound,
+			})
+			return
+		}
+		s.Logger.Log(c.Request.Context(), logging.Warn, "Connection closed by admin",
+			"connection_id", connID, "client_ip", c.ClientIP())
+		c.JSON(http.StatusOK, AdminDisconnectResponse{Disconnected: 1})
+	}
+}
+
+// AdminDisconnectMatching godoc
+// @Summary Disconnect a user everywhere
+// @Description Closes the connections of a username, an IP or both in every room, hosts included. They may reconnect; ban them to keep them out (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param request body AdminDisconnectMatchingRequest true "Connections to close"
+// @Success 200 {object} AdminDisconnectResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Admin API is disabled"
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/admin/connections/disconnect [post]
+func (s *Server) AdminDisconnectMatching() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req AdminDisconnectMatchingRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		disconnected := s.Handler.Hub.Connections.DisconnectMatching(websocket.ConnectionFilter{
+			Username: req.Username,
+			IP:       req.IP,
+		})
+		s.Logger.Log(c.Request.Context(), logging.Warn, "Clients disconnected from all rooms by admin",
+			"username", req.Username, "ip", req.IP, "disconnected", disconnected)
+
+		c.JSON(http.StatusOK, AdminDisconnectResponse{Disconnected: disconnected})
+	}
+}
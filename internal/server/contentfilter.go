@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// SetContentFilterRequest replaces the content filter override of a room. An empty
+// action keeps the server action, words are blocked in addition to the server word list.
+type SetContentFilterRequest struct {
+	Action string   `json:"action" binding:"omitempty,oneof=off flag mask reject" example:"reject"`
+	Words  []string `json:"words" binding:"max=100,dive,required,max=50" example:"spoiler"`
+}
+
+// RoomContentFilter godoc
+// @Summary Get room content filter
+// @Description Returns the content filter override of the room (host only)
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Success 200 {object} websocket.RoomContentFilter
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/filter [get]
+func (s *Server) RoomContentFilter() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, room.ContentFilter())
+	}
+}
+
+// SetRoomContentFilter godoc
+// @Summary Set room content filter
+// @Description Replaces the room's content filter action and extra blocked words (host only)
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Param request body SetContentFilterRequest true "Filter override"
+// @Success 200 {object} websocket.RoomContentFilter
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/rooms/{room_id}/filter [put]
+func (s *Server) SetRoomContentFilter() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		var req SetContentFilterRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		filter := websocket.RoomContentFilter{Action: websocket.FilterAction(req.Action), Words: req.Words}
+		if err := room.SetContentFilter(filter); err != nil {
+			respondValidationErrors(c, []websocket.ValidationError{{Field: "words", Message: err.Error()}})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Room content filter updated",
+			"room_id", room.ID, "action", req.Action, "words", len(req.Words))
+
+		c.JSON(http.StatusOK, room.ContentFilter())
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// ListRooms godoc
+// @Summary List public rooms
+// @Description Returns the rooms whose creators opted into the public directory, ordered by room ID.
+// @Description Private rooms are never listed.
+// @Tags rooms
+// @Produce json
+// @Param public query bool false "Only public rooms are listed; false is rejected" default(true)
+// @Param tag query string false "Only rooms carrying this tag"
+// @Param page query int false "Page number, starting at 1"
+// @Param page_size query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "Cursor from a previous page, overrides page"
+// @Success 200 {object} PageResponse[websocket.RoomSummary]
+// @Failure 400 {object} ErrorResponse
+// @Router /api/rooms [get]
+func (s *Server) ListRooms() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if publicStr := c.Query("public"); publicStr != "" {
+			public, err := strconv.ParseBool(publicStr)
+			if err != nil || !public {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Code:      http.StatusBadRequest,
+					Error:     "only public rooms can be listed",
+					ErrorCode: websocket.CodeInvalidRequest,
+				})
+				return
+			}
+		}
+
+		rooms := s.Handler.Hub.ListRooms(websocket.RoomFilter{
+			Visibility: websocket.VisibilityPublic,
+			Tag:        c.Query("tag"),
+		})
+		page, ok := pageFromQuery(c, rooms)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errIfMatchMissing = errors.New("If-Match header is required")
+	errIfMatchInvalid = errors.New("invalid If-Match header")
+)
+
+// settingsETag formats a room settings version as a strong ETag value
+func settingsETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// parseIfMatch extracts the expected settings version from the If-Match header.
+// Both strong ("3") and weak (W/"3") forms are accepted.
+func parseIfMatch(c *gin.Context) (uint64, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" {
+		return 0, errIfMatchMissing
+	}
+	value = strings.TrimPrefix(value, "W/")
+	value = strings.Trim(value, `"`)
+
+	version, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errIfMatchInvalid
+	}
+	return version, nil
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// EventsResponse is a page of the room event feed
+type EventsResponse struct {
+	Events []websocket.RoomEvent `json:"events"`
+	// NextSince is the since value for the next request
+	NextSince uint64 `json:"next_since" example:"17"`
+	// Truncated is set when events after since were already dropped and state should be refetched
+	Truncated bool `json:"truncated" example:"false"`
+}
+
+// RoomEvents godoc
+// @Summary Room event feed
+// @Description Returns room events newer than since, oldest first: joins, leaves, chat messages,
+// @Description moderation actions and settings changes, with the payload members received.
+// @Description Bans and unbans are only listed for the host. Password-protected rooms require
+// @Description the host token or the room password. Poll with since set to next_since.
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param since query int false "ID of the last event already seen (default 0)"
+// @Param limit query int false "Maximum events to return (default 50, max 200)"
+// @Param Authorization header string false "Host JWT token"
+// @Param X-Room-Password header string false "Room password"
+// @Success 200 {object} EventsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/events [get]
+func (s *Server) RoomEvents() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireRoomReader(c)
+		if !ok {
+			return
+		}
+
+		var since uint64
+		if sinceStr := c.Query("since"); sinceStr != "" {
+			var err error
+			if since, err = strconv.ParseUint(sinceStr, 10, 64); err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Code:      http.StatusBadRequest,
+					Error:     "since must be a non-negative integer",
+					ErrorCode: websocket.CodeInvalidRequest,
+				})
+				return
+			}
+		}
+		req, err := parsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     err.Error(),
+				ErrorCode: websocket.CodeInvalidRequest,
+			})
+			return
+		}
+
+		isHost := false
+		if token := c.GetHeader("Authorization"); token != "" {
+			_, err := s.validateHostToken(token, c.Param("room_id"))
+			isHost = err == nil
+		}
+
+		events, next, truncated := room.EventsSince(since, req.Limit, isHost)
+		c.JSON(http.StatusOK, EventsResponse{Events: events, NextSince: next, Truncated: truncated})
+	}
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// RoomPasswordHeader lets room members read history of a password-protected room
+const RoomPasswordHeader = "X-Room-Password"
+
+// requireRoomReader resolves the room and checks the caller may read its messages:
+// anyone for open rooms, the host or holders of the room password otherwise.
+func (s *Server) requireRoomReader(c *gin.Context) (*websocket.Room, bool) {
+	roomIDStr := c.Param("room_id")
+	roomID, err := validateRoomID(roomIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Error:     "invalid room ID format",
+			ErrorCode: websocket.CodeInvalidRoomID,
+		})
+		return nil, false
+	}
+
+	room, exists := s.Handler.Hub.GetRoom(roomID)
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:      http.StatusNotFound,
+			Error:     "room not found",
+			ErrorCode: websocket.CodeRoomNotFound,
+		})
+		return nil, false
+	}
+
+	if !room.HasPassword() {
+		return room, true
+	}
+	if token := c.GetHeader("Authorization"); token != "" {
+		if _, err := s.validateHostToken(token, roomIDStr); err == nil {
+			return room, true
+		}
+	}
+	if password := c.GetHeader(RoomPasswordHeader); password != "" {
+		if room.CheckPassword(s.Passwords, password) {
+			return room, true
+		}
+		room.RecordFailedPassword(c.ClientIP())
+		s.Metrics.AuthFailed(websocket.AuthTransportREST, websocket.AuthFailureInvalidPassword)
+	}
+
+	c.JSON(http.StatusUnauthorized, ErrorResponse{
+		Code:      http.StatusUnauthorized,
+		Error:     "room password or host token required",
+		ErrorCode: websocket.CodePasswordRequired,
+	})
+	return nil, false
+}
+
+// parseTimeRange reads the optional RFC 3339 from/to query parameters
+func parseTimeRange(c *gin.Context) (from, to time.Time, fields []websocket.ValidationError) {
+	parse := func(name string) time.Time {
+		value := c.Query(name)
+		if value == "" {
+			return time.Time{}
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			fields = append(fields, websocket.ValidationError{Field: name, Message: name + " must be an RFC 3339 timestamp"})
+		}
+		return t
+	}
+	from, to = parse("from"), parse("to")
+	if len(fields) == 0 && !from.IsZero() && !to.IsZero() && to.Before(from) {
+		fields = append(fields, websocket.ValidationError{Field: "to", Message: "to must not be before from"})
+	}
+	return from, to, fields
+}
+
+// SearchMessages godoc
+// @Summary Search room messages
+// @Description Full-text search over stored chat messages. Every word of q must appear in the message text (case-insensitive).
+// @Description Password-protected rooms require the host token or the room password.
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param q query string false "Words to search for"
+// @Param from query string false "Only messages sent at or after this RFC 3339 time"
+// @Param to query string false "Only messages sent at or before this RFC 3339 time"
+// @Param cursor query string false "Cursor from a previous page"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param Authorization header string false "Host JWT token"
+// @Param X-Room-Password header string false "Room password"
+// @Success 200 {object} PageResponse[websocket.StoredMessage]
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid time range"
+// @Router /api/rooms/{room_id}/messages/search [get]
+func (s *Server) SearchMessages() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireRoomReader(c)
+		if !ok {
+			return
+		}
+
+		from, to, fields := parseTimeRange(c)
+		if len(fields) > 0 {
+			respondValidationErrors(c, fields)
+			return
+		}
+
+		page, ok := pageFromQuery(c, room.SearchMessages(c.Query("q"), from, to))
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// ImportMessagesRequest carries historical messages to add to a room history
+type ImportMessagesRequest struct {
+	Messages []websocket.ImportedMessage `json:"messages" binding:"required,min=1,max=1000"`
+}
+
+// ImportMessages godoc
+// @Summary Import messages
+// @Description Adds historical chat messages, e.g. from another chat system, to the room history without broadcasting them (host only).
+// @Description Messages get new IDs in sending order and are merged with the stored ones by sending time; the history limit and retention apply.
+// @Description A room imports at most 5000 messages per minute.
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Param request body ImportMessagesRequest true "Messages to import, at most 1000"
+// @Success 201 {object} websocket.ImportResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid messages"
+// @Failure 429 {object} ErrorResponse "Import rate exceeded"
+// @Router /api/rooms/{room_id}/messages/import [post]
+func (s *Server) ImportMessages() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+		s.importMessages(c, room)
+	}
+}
+
+// AdminImportMessages godoc
+// @Summary Import messages into any room
+// @Description Adds historical chat messages to the history of any room without broadcasting them (admin only).
+// @Description Follows the rules of the host import endpoint.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param request body ImportMessagesRequest true "Messages to import, at most 1000"
+// @Success 201 {object} websocket.ImportResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid messages"
+// @Failure 429 {object} ErrorResponse "Import rate exceeded"
+// @Router /api/admin/rooms/{room_id}/messages/import [post]
+func (s *Server) AdminImportMessages() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.adminRoom(c)
+		if !ok {
+			return
+		}
+		s.importMessages(c, room)
+	}
+}
+
+// importMessages validates and imports the messages of the request into room
+func (s *Server) importMessages(c *gin.Context, room *websocket.Room) {
+	var req ImportMessagesRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	if fieldErrs := websocket.ValidateImport(req.Messages, time.Now()); len(fieldErrs) > 0 {
+		respondValidationErrors(c, fieldErrs)
+		return
+	}
+
+	result, retryAfter, err := room.ImportMessages(req.Messages)
+	switch {
+	case errors.Is(err, websocket.ErrImportRateLimited):
+		c.Header("Retry-After", strconv.Itoa(max(int(math.Ceil(retryAfter.Seconds())), 1)))
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{
+			Code:      http.StatusTooManyRequests,
+			Error:     err.Error(),
+			ErrorCode: websocket.CodeRateLimited,
+		})
+		return
+	case err != nil:
+		respondValidationErrors(c, []websocket.ValidationError{{Field: "messages", Message: err.Error()}})
+		return
+	}
+
+	s.Logger.Log(c.Request.Context(), logging.Info, "Messages imported",
+		"room_id", room.ID, "imported", result.Imported, "dropped", result.Dropped)
+
+	c.JSON(http.StatusCreated, result)
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// HookTokenHeader authenticates bot replies sent to a hook
+const HookTokenHeader = "X-Hook-Token"
+
+type CreateHookRequest struct {
+	Prefix  string `json:"prefix" binding:"required,max=32" example:"!weather"`
+	URL     string `json:"url" binding:"required,url,max=2048" example:"https://bots.example.com/weather"`
+	BotName string `json:"bot_name" binding:"required,max=50" example:"WeatherBot"`
+}
+
+// CreateHookResponse is returned once on registration; the token is not shown again
+type CreateHookResponse struct {
+	websocket.BotHook
+	Token string `json:"token" example:"9f59e3c1..."`
+}
+
+type HookReplyRequest struct {
+	Text string `json:"text" binding:"required,max=1000" example:"Berlin: 21°C, sunny"`
+}
+
+// dispatchHook posts a bot hook event to the hook URL
+func (s *Server) dispatchHook(hook websocket.BotHook, event websocket.BotHookEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	s.postWebhook(hook.URL, body, false)
+}
+
+// CreateHook godoc
+// @Summary Register bot hook
+// @Description Forwards chat messages starting with prefix to url (host only). The bot replies via the hook reply endpoint using the returned token.
+// @Description The URL must use https and resolve to a public address; redirects are not followed.
+// @Tags hooks
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Param request body CreateHookRequest true "Hook definition"
+// @Success 201 {object} CreateHookResponse
+// @Failure 400 {object} ErrorResponse "Invalid room ID, or the URL is not https or names a private address"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Room has too many hooks"
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/rooms/{room_id}/hooks [post]
+func (s *Server) CreateHook() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		var req CreateHookRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		err := checkWebhookURL(req.URL)
+		var hook websocket.BotHook
+		var token string
+		if err == nil {
+			hook, token, err = room.AddHook(req.Prefix, req.URL, req.BotName)
+		}
+		if err != nil {
+			status, code := http.StatusInternalServerError, websocket.CodeInternal
+			switch {
+			case errors.Is(err, websocket.ErrTooManyHooks):
+				status, code = http.StatusConflict, websocket.CodeTooManyHooks
+			case errors.Is(err, websocket.ErrInsecureWebhookURL), errors.Is(err, ErrPrivateAddress):
+				status, code = http.StatusBadRequest, websocket.CodeInvalidWebhookURL
+			}
+			c.JSON(status, ErrorResponse{
+				Code:      status,
+				Error:     err.Error(),
+				ErrorCode: code,
+			})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Bot hook registered",
+			"room_id", room.ID, "hook_id", hook.ID, "prefix", hook.Prefix)
+
+		c.JSON(http.StatusCreated, CreateHookResponse{BotHook: hook, Token: token})
+	}
+}
+
+// ListHooks godoc
+// @Summary List bot hooks
+// @Description Returns the bot hooks registered in the room (host only)
+// @Tags hooks
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Success 200 {array} websocket.BotHook
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/hooks [get]
+func (s *Server) ListHooks() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, room.Hooks())
+	}
+}
+
+// DeleteHook godoc
+// @Summary Remove bot hook
+// @Description Unregisters a bot hook (host only)
+// @Tags hooks
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param hook_id path string true "Hook ID"
+// @Param Authorization header string true "Host JWT token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/hooks/{hook_id} [delete]
+func (s *Server) DeleteHook() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+		if !room.RemoveHook(c.Param("hook_id")) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     websocket.ErrHookNotFound.Error(),
+				ErrorCode: websocket.CodeHookNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "hook removed"})
+	}
+}
+
+// HookReply godoc
+// @Summary Bot reply
+// @Description Posts a chat message to the room as the hook's bot. Authenticated with the token returned at registration.
+// @Tags hooks
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param hook_id path string true "Hook ID"
+// @Param X-Hook-Token header string true "Hook token"
+// @Param request body HookReplyRequest true "Reply"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/rooms/{room_id}/hooks/{hook_id}/reply [post]
+func (s *Server) HookReply() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		roomID, err := validateRoomID(c.Param("room_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     "invalid room ID format",
+				ErrorCode: websocket.CodeInvalidRoomID,
+			})
+			return
+		}
+		room, exists := s.Handler.Hub.GetRoom(roomID)
+		if !exists {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
+			})
+			return
+		}
+
+		hook, err := room.AuthorizeHook(c.Param("hook_id"), c.GetHeader(HookTokenHeader))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Code:      http.StatusUnauthorized,
+				Error:     "invalid hook or token",
+				ErrorCode: websocket.CodeInvalidToken,
+			})
+			return
+		}
+
+		var req HookReplyRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		if err := room.PostBotMessage(hook.BotName, req.Text); err != nil {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": "reply posted"})
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+type TransferHostRequest struct {
+	Username string `json:"username" binding:"required,max=50" example:"john_doe"`
+}
+
+// TransferHost godoc
+// @Summary Transfer host role
+// @Description Hands the caller's host privileges to a connected member (host only). The member receives a new host token over its WebSocket in an "auth" message; the caller's token stops working. Use the "promote" WebSocket message to add a co-host instead.
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Param request body TransferHostRequest true "Member to hand the host role to"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Member is already a host"
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/rooms/{room_id}/host/transfer [post]
+func (s *Server) TransferHost() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		roomIDStr := c.Param("room_id")
+
+		roomID, err := validateRoomID(roomIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     "invalid room ID format",
+				ErrorCode: websocket.CodeInvalidRoomID,
+			})
+			return
+		}
+
+		claims, err := s.validateHostToken(c.GetHeader("Authorization"), roomIDStr)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Code:      http.StatusUnauthorized,
+				Error:     "unauthorized: " + err.Error(),
+				ErrorCode: websocket.CodeInvalidToken,
+			})
+			return
+		}
+
+		room, exists := s.Handler.Hub.GetRoom(roomID)
+		if !exists {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
+			})
+			return
+		}
+
+		var req TransferHostRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		hostID, _ := (*claims)["host_id"].(string)
+		switch err := room.TransferHost(hostID, req.Username); {
+		case errors.Is(err, websocket.ErrUserNotInRoom):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "user not found in room",
+				ErrorCode: websocket.CodeUserNotFound,
+			})
+			return
+		case errors.Is(err, websocket.ErrAlreadyHost):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Code:      http.StatusConflict,
+				Error:     "user is already a host",
+				ErrorCode: websocket.CodeAlreadyHost,
+			})
+			return
+		case err != nil:
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Code:      http.StatusUnauthorized,
+				Error:     "unauthorized: " + err.Error(),
+				ErrorCode: websocket.CodeInvalidToken,
+			})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Host role transferred",
+			"room_id", roomID, "username", req.Username)
+
+		c.JSON(http.StatusOK, gin.H{"message": "host role transferred"})
+	}
+}
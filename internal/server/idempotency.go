@@ -0,0 +1,244 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying a client-chosen idempotency key
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayHeader is set on responses served from the idempotency cache
+	IdempotentReplayHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	idempotencySweepPeriod  = time.Minute
+)
+
+// idempotentResponse is a cached response for a previously seen idempotency key
+type idempotentResponse struct {
+	header      http.Header
+	expiresAt   time.Time
+	body        []byte
+	requestHash []byte // SHA-256 of the request body the response belongs to
+	status      int
+	inFlight    bool
+}
+
+// IdempotencyStore keeps responses of mutating requests for a limited window
+// so retried requests with the same Idempotency-Key are not executed twice.
+type IdempotencyStore struct {
+	entries   map[string]*idempotentResponse
+	lastSweep time.Time
+	ttl       time.Duration
+	mu        sync.Mutex
+}
+
+// NewIdempotencyStore creates a store that remembers responses for ttl
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		entries:   make(map[string]*idempotentResponse),
+		lastSweep: time.Now(),
+		ttl:       ttl,
+	}
+}
+
+// begin reserves key for a new request. It returns the cached response if the key
+// was already completed, or inFlight=true if another request with the key is running.
+func (s *IdempotencyStore) begin(key string) (cached *idempotentResponse, inFlight bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expiresAt) {
+		if entry.inFlight {
+			return nil, true
+		}
+		return entry, false
+	}
+
+	s.entries[key] = &idempotentResponse{inFlight: true, expiresAt: now.Add(s.ttl)}
+	return nil, false
+}
+
+// complete stores the final response for key and the hash of the request body
+func (s *IdempotencyStore) complete(key string, requestHash []byte, status int, header http.Header, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = &idempotentResponse{
+		status:      status,
+		header:      header,
+		body:        body,
+		requestHash: requestHash,
+		expiresAt:   time.Now().Add(s.ttl),
+	}
+}
+
+// release forgets key so the request can be retried
+func (s *IdempotencyStore) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// sweep removes expired entries, at most once per idempotencySweepPeriod
+func (s *IdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < idempotencySweepPeriod {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// responseRecorder copies the response body while it is written to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// hashingBody hashes the request body as the handler reads it
+type hashingBody struct {
+	io.ReadCloser
+	hash hash.Hash
+}
+
+func (b *hashingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	return n, err
+}
+
+// sum hashes the part of the body the handler left unread and returns the hash
+func (b *hashingBody) sum() []byte {
+	_, _ = io.Copy(b.hash, b.ReadCloser)
+	return b.hash.Sum(nil)
+}
+
+// idempotencyCaller identifies who sent the request: the API key, the session
+// cookie or, for clients without either, the client IP. A key replayed by
+// another caller never reaches the cached response.
+func idempotencyCaller(c *gin.Context) string {
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + string(sum[:])
+	}
+	if sessionID := c.GetString(websocket.SessionIDKey); sessionID != "" {
+		return "session:" + sessionID
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// isMutatingMethod reports whether method changes server state
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// IdempotencyMiddleware replays the stored response for mutating requests that repeat
+// an Idempotency-Key within the store window. Keys are scoped to method, path, the
+// Authorization header and the caller, so a key seen by someone else cannot replay
+// their response. Reusing a key with a different body is answered with 422.
+// Server errors are not cached so that clients can safely retry them.
+func IdempotencyMiddleware(store *IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     "idempotency key is too long",
+				ErrorCode: websocket.CodeInvalidRequest,
+			})
+			c.Abort()
+			return
+		}
+
+		key := c.Request.Method + " " + c.Request.URL.Path + " " +
+			c.GetHeader("Authorization") + " " + idempotencyCaller(c) + " " + idempotencyKey
+
+		cached, inFlight := store.begin(key)
+		if inFlight {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Code:      http.StatusConflict,
+				Error:     "a request with this idempotency key is already in progress",
+				ErrorCode: websocket.CodeIdempotencyConflict,
+			})
+			c.Abort()
+			return
+		}
+		if cached != nil {
+			requestHash := sha256.New()
+			_, _ = io.Copy(requestHash, c.Request.Body)
+			if !bytes.Equal(requestHash.Sum(nil), cached.requestHash) {
+				c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+					Code:      http.StatusUnprocessableEntity,
+					Error:     "idempotency key was already used with a different request body",
+					ErrorCode: websocket.CodeIdempotencyMismatch,
+				})
+				c.Abort()
+				return
+			}
+			for name, values := range cached.header {
+				c.Writer.Header()[name] = values
+			}
+			c.Header(IdempotentReplayHeader, "true")
+			c.Data(cached.status, cached.header.Get("Content-Type"), cached.body)
+			c.Abort()
+			return
+		}
+
+		// Released unless a response is stored, also when the handler panics
+		stored := false
+		defer func() {
+			if !stored {
+				store.release(key)
+			}
+		}()
+
+		body := &hashingBody{ReadCloser: c.Request.Body, hash: sha256.New()}
+		c.Request.Body = body
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		store.complete(key, body.sum(), status, recorder.Header().Clone(), recorder.body.Bytes())
+		stored = true
+	}
+}
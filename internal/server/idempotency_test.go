@@ -0,0 +1,127 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// idempotencyEngine serves POST /api/rooms, counting executions. Requests with a
+// body of "fail" get a 500, those with a body of "panic" panic.
+func idempotencyEngine(calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(gin.Recovery(), SessionMiddleware([]byte("test-secret")))
+	api := engine.Group("/api", IdempotencyMiddleware(NewIdempotencyStore(time.Minute)))
+	api.POST("/rooms", func(c *gin.Context) {
+		*calls++
+		body, _ := c.GetRawData()
+		if string(body) == "panic" {
+			panic("handler failed")
+		}
+		if string(body) == "fail" {
+			c.String(http.StatusInternalServerError, "failed")
+			return
+		}
+		c.String(http.StatusCreated, "room "+strconv.Itoa(*calls))
+	})
+	return engine
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	post := func(engine *gin.Engine, key, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/rooms", strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, key)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	sessionCookie := func(w *httptest.ResponseRecorder) *http.Cookie {
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.Name == SessionCookieName {
+				return cookie
+			}
+		}
+		t.Fatal("no session cookie issued")
+		return nil
+	}
+
+	t.Run("replays for the same caller", func(t *testing.T) {
+		var calls int
+		engine := idempotencyEngine(&calls)
+		first := post(engine, "k1", `{"name":"a"}`, nil)
+		require.Equal(t, http.StatusCreated, first.Code)
+		replay := post(engine, "k1", `{"name":"a"}`, sessionCookie(first))
+		assert.Equal(t, http.StatusCreated, replay.Code)
+		assert.Equal(t, first.Body.String(), replay.Body.String())
+		assert.Equal(t, "true", replay.Header().Get(IdempotentReplayHeader))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("keys of other callers are not replayed", func(t *testing.T) {
+		var calls int
+		engine := idempotencyEngine(&calls)
+		owner := post(engine, "k1", `{"name":"a"}`, nil)
+		other := post(engine, "k1", `{"name":"a"}`, nil)
+		assert.Equal(t, http.StatusCreated, other.Code)
+		assert.NotEqual(t, owner.Body.String(), other.Body.String())
+		assert.Empty(t, other.Header().Get(IdempotentReplayHeader))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("rejects a reused key with another body", func(t *testing.T) {
+		var calls int
+		engine := idempotencyEngine(&calls)
+		first := post(engine, "k1", `{"name":"a"}`, nil)
+		reused := post(engine, "k1", `{"name":"b"}`, sessionCookie(first))
+		assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+		assert.Contains(t, reused.Body.String(), "IDEMPOTENCY_KEY_REUSED")
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("server errors are retried", func(t *testing.T) {
+		var calls int
+		engine := idempotencyEngine(&calls)
+		first := post(engine, "k1", "fail", nil)
+		require.Equal(t, http.StatusInternalServerError, first.Code)
+		retry := post(engine, "k1", "fail", sessionCookie(first))
+		assert.Equal(t, http.StatusInternalServerError, retry.Code)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("keys of panicking requests are released", func(t *testing.T) {
+		var calls int
+		engine := idempotencyEngine(&calls)
+		first := post(engine, "k1", "panic", nil)
+		require.Equal(t, http.StatusInternalServerError, first.Code)
+		retry := post(engine, "k1", "panic", sessionCookie(first))
+		assert.Equal(t, http.StatusInternalServerError, retry.Code, "the key is not left in progress")
+		assert.Equal(t, 2, calls)
+	})
+}
+
+func TestIdempotencyCallerFallsBackToIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	caller := func(remoteAddr, apiKey string) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/rooms", nil)
+		c.Request.RemoteAddr = remoteAddr
+		if apiKey != "" {
+			c.Request.Header.Set(APIKeyHeader, apiKey)
+		}
+		return idempotencyCaller(c)
+	}
+	assert.NotEqual(t, caller("10.0.0.1:1000", ""), caller("10.0.0.2:1000", ""))
+	assert.Equal(t, caller("10.0.0.1:1000", "key-1"), caller("10.0.0.2:1000", "key-1"))
+	assert.NotEqual(t, caller("10.0.0.1:1000", "key-1"), caller("10.0.0.1:1000", "key-2"))
+}
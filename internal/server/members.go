@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoomMembers godoc
+// @Summary List room members
+// @Description Returns clients currently connected to the room, ordered by join time.
+// @Description Password-protected rooms require the host token or the room password.
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param cursor query string false "Cursor from a previous page"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param Authorization header string false "Host JWT token"
+// @Param X-Room-Password header string false "Room password"
+// @Success 200 {object} PageResponse[websocket.MemberInfo]
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/members [get]
+func (s *Server) RoomMembers() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireRoomReader(c)
+		if !ok {
+			return
+		}
+
+		page, ok := pageFromQuery(c, room.ListClients())
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+type PostMessageRequest struct {
+	Text    string `json:"text" binding:"required,max=1000" example:"Maintenance starts in 10 minutes"`
+	BotName string `json:"bot_name,omitempty" binding:"omitempty,max=50" example:"StatusBot"`
+}
+
+// requireIntegrationRoom returns the room of the request if the caller holds its
+// host token or, with API keys configured, the API key the room belongs to
+func (s *Server) requireIntegrationRoom(c *gin.Context) (*websocket.Room, bool) {
+	key := c.GetHeader(APIKeyHeader)
+	if s.Tenants == nil || key == "" {
+		return s.requireHostRoom(c)
+	}
+
+	roomID, err := validateRoomID(c.Param("room_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Error:     "invalid room ID format",
+			ErrorCode: websocket.CodeInvalidRoomID,
+		})
+		return nil, false
+	}
+	if _, ok := s.requireTenant(c); !ok {
+		return nil, false
+	}
+	room, exists := s.Handler.Hub.GetRoom(roomID)
+	// A room of another tenant is reported as missing, not revealing it exists
+	if !exists || subtle.ConstantTimeCompare([]byte(room.Tenant()), []byte(key)) != 1 {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:      http.StatusNotFound,
+			Error:     "room not found",
+			ErrorCode: websocket.CodeRoomNotFound,
+		})
+		return nil, false
+	}
+	return room, true
+}
+
+// PostMessage godoc
+// @Summary Post message
+// @Description Broadcasts a chat message to the room as a bot, for integrations without a WebSocket connection.
+// @Description Authenticated with the room's host token or, when API keys are configured, the API key owning the room.
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string false "Host JWT token"
+// @Param X-API-Key header string false "API key owning the room"
+// @Param request body PostMessageRequest true "Message"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Failure 429 {object} ErrorResponse "Tenant message quota exceeded"
+// @Router /api/rooms/{room_id}/messages [post]
+func (s *Server) PostMessage() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireIntegrationRoom(c)
+		if !ok {
+			return
+		}
+
+		var req PostMessageRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+		if req.BotName == "" {
+			req.BotName = s.Handler.Hub.Brand().BotName
+		}
+
+		if err := room.InjectMessage(req.BotName, req.Text); err != nil {
+			status, code, message := http.StatusInternalServerError, websocket.CodeInternal, err.Error()
+			switch {
+			case errors.Is(err, websocket.ErrTenantQuota):
+				status, code = http.StatusTooManyRequests, websocket.CodeQuotaExceeded
+			case errors.Is(err, websocket.ErrRoomStopped):
+				// The room was deleted after it was looked up
+				status, code, message = http.StatusNotFound, websocket.CodeRoomNotFound, "room not found"
+			}
+			c.JSON(status, ErrorResponse{Code: status, Error: message, ErrorCode: code})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Message injected",
+			"room_id", room.ID, "bot_name", req.BotName)
+
+		c.JSON(http.StatusAccepted, gin.H{"message": "message posted"})
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostMessageToStoppedRoom(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := websocket.NewHMACKeys("test-secret")
+	s := &Server{
+		Handler:   *websocket.NewHandler(websocket.NewHub(), nil),
+		Logger:    logging.NewLogger(),
+		TokenKeys: keys,
+	}
+	room, _ := s.Handler.Hub.CreateRoom(1, nil, websocket.WithHost("host-1"))
+	token, err := keys.Sign(websocket.NewHostClaims(1, "host-1", time.Now()))
+	require.NoError(t, err)
+	engine := gin.New()
+	engine.POST("/api/rooms/:room_id/messages", s.PostMessage())
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/rooms/1/messages", strings.NewReader(`{"text":"hello"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	require.Equal(t, http.StatusAccepted, post().Code)
+
+	// A room stopped while still registered with the hub fails the request instead of hanging it
+	room.StopRoom()
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post() }()
+	select {
+	case w := <-done:
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), string(websocket.CodeRoomNotFound))
+	case <-time.After(time.Second):
+		t.Fatal("posting to a stopped room did not return")
+	}
+}
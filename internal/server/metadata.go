@@ -0,0 +1,121 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+type SetMetadataRequest struct {
+	Value string `json:"value" binding:"max=512" example:"https://docs.example.com/agenda"`
+}
+
+// RoomMetadata godoc
+// @Summary Get room metadata
+// @Description Returns the application-defined key/value metadata of the room. Protected rooms require the host token or X-Room-Password.
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string false "Host JWT token"
+// @Param X-Room-Password header string false "Room password"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/metadata [get]
+func (s *Server) RoomMetadata() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireRoomReader(c)
+		if !ok {
+			return
+		}
+		metadata := room.Metadata()
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+		c.JSON(http.StatusOK, metadata)
+	}
+}
+
+// SetRoomMetadata godoc
+// @Summary Set room metadata key
+// @Description Stores a value under key and notifies connected clients (host only)
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param key path string true "Metadata key (1-64 characters)"
+// @Param Authorization header string true "Host JWT token"
+// @Param request body SetMetadataRequest true "Metadata value"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Room has too many metadata keys"
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/rooms/{room_id}/metadata/{key} [put]
+func (s *Server) SetRoomMetadata() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		var req SetMetadataRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		key := c.Param("key")
+		if err := room.SetMetadata(key, req.Value); err != nil {
+			if errors.Is(err, websocket.ErrMetadataFull) {
+				c.JSON(http.StatusConflict, ErrorResponse{
+					Code:      http.StatusConflict,
+					Error:     err.Error(),
+					ErrorCode: websocket.CodeTooManyMetadataKeys,
+				})
+				return
+			}
+			respondValidationErrors(c, []websocket.ValidationError{{Field: "key", Message: err.Error()}})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Room metadata updated",
+			"room_id", room.ID, "key", key)
+		c.JSON(http.StatusOK, room.Metadata())
+	}
+}
+
+// DeleteRoomMetadata godoc
+// @Summary Delete room metadata key
+// @Description Removes a metadata key and notifies connected clients (host only)
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param key path string true "Metadata key"
+// @Param Authorization header string true "Host JWT token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/metadata/{key} [delete]
+func (s *Server) DeleteRoomMetadata() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+		if !room.DeleteMetadata(c.Param("key")) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "metadata key not found",
+				ErrorCode: websocket.CodeMetadataNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "metadata key removed"})
+	}
+}
@@ -1,27 +1,59 @@
 package server
 
 import (
+	"context"
 	"os"
 	"runtime"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/shirou/gopsutil/v3/process"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// MaxRoomMetricLabels bounds how many rooms get their own room label; the
+// broadcasts of any further rooms are reported under OtherRoomLabel
+const MaxRoomMetricLabels = 100
+
+// OtherRoomLabel is the room label shared by rooms over MaxRoomMetricLabels
+const OtherRoomLabel = "other"
+
+// TraceIDExemplar is the exemplar label holding the trace ID of an observation
+const TraceIDExemplar = "trace_id"
+
 // Metrics holds Prometheus metrics for HTTP and WebSocket monitoring
 type Metrics struct {
 	RequestDuration *prometheus.HistogramVec
 	RequestCounter  *prometheus.CounterVec
+	HTTPRateLimited *prometheus.CounterVec
 	WSConnections   prometheus.Gauge
 	WSMessages      *prometheus.CounterVec
+	WSRTT           prometheus.Histogram
+	ActiveRoomCount prometheus.Gauge
+	RoomClients     *prometheus.GaugeVec
+	ReapedRooms     prometheus.Counter
+	RateLimited     prometheus.Counter
+	PipelineStages  *prometheus.HistogramVec
+	BroadcastTime   *prometheus.HistogramVec
+	BroadcastFanout *prometheus.HistogramVec
+	ReclaimedMsgs   prometheus.Counter
+	ReclaimedBytes  prometheus.Counter
+	AuthFailures    *prometheus.CounterVec
+	ReapedConns     *prometheus.CounterVec
+	SlowConsumers   *prometheus.CounterVec
+	CompressedBytes *prometheus.CounterVec
+	ShedMessages    *prometheus.CounterVec
 	Goroutines      prometheus.Gauge
 	MemoryAlloc     prometheus.Gauge
 	HeapAlloc       prometheus.Gauge
 	CPUUsage        prometheus.Gauge
+	roomLabels      map[string]string // room ID to label, kept until the room is deleted
+	ownLabels       int
+	roomLabelsMu    sync.Mutex
 	stopChan        chan struct{}
 }
 
@@ -43,6 +75,13 @@ func NewMetrics() *Metrics {
 			},
 			[]string{"method", "path", "status"},
 		),
+		HTTPRateLimited: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_rate_limited_total",
+				Help: "Total number of HTTP requests rejected by a per-IP rate limit",
+			},
+			[]string{"limiter"},
+		),
 		WSConnections: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "ws_active_connections",
 			Help: "Number of active WebSocket connections",
@@ -54,6 +93,97 @@ func NewMetrics() *Metrics {
 			},
 			[]string{"direction"},
 		),
+		WSRTT: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "ws_rtt_seconds",
+			Help:    "Round-trip time of WebSocket ping/pong heartbeats",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 12),
+		}),
+		ActiveRoomCount: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ws_active_rooms",
+			Help: "Number of active rooms",
+		}),
+		RoomClients: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "clients_per_room",
+				Help: "Number of clients connected to a room",
+			},
+			[]string{"room"},
+		),
+		ReapedRooms: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ws_rooms_reaped_total",
+			Help: "Total number of idle rooms removed by the janitor",
+		}),
+		RateLimited: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ws_client_rate_limited_total",
+			Help: "Total number of messages dropped by the per-client rate limit",
+		}),
+		PipelineStages: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "ws_pipeline_stage_seconds",
+				Help:    "Duration of broadcast pipeline stages (enqueue, fanout, write)",
+				Buckets: prometheus.ExponentialBuckets(0.00001, 4, 10),
+			},
+			[]string{"stage"},
+		),
+		BroadcastTime: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "ws_broadcast_duration_seconds",
+				Help:    "Time to sequence a room broadcast and queue it to every client",
+				Buckets: prometheus.ExponentialBuckets(0.00001, 4, 10),
+			},
+			[]string{"room"},
+		),
+		BroadcastFanout: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "ws_broadcast_fanout_clients",
+				Help:    "Number of clients a room broadcast was queued to",
+				Buckets: prometheus.ExponentialBuckets(1, 2, 14),
+			},
+			[]string{"room"},
+		),
+		ReclaimedMsgs: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ws_history_reclaimed_messages_total",
+			Help: "Total number of expired history messages removed by compaction",
+		}),
+		ReclaimedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ws_history_reclaimed_bytes_total",
+			Help: "Approximate bytes of history released by compaction",
+		}),
+		AuthFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "auth_failures_total",
+				Help: "Total number of rejected credentials (host tokens, room passwords, join tickets, API keys)",
+			},
+			[]string{"transport", "reason"},
+		),
+		ReapedConns: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ws_connections_reaped_total",
+				Help: "Total number of WebSocket connections closed for missing the handshake or read deadline",
+			},
+			[]string{"reason"},
+		),
+		SlowConsumers: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ws_slow_consumer_total",
+				Help: "Total number of full WebSocket send queues by the slow-consumer policy that handled them, buffer_to_disk_failed if a spool could not take the client",
+			},
+			[]string{"policy"},
+		),
+		ShedMessages: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ws_messages_shed_total",
+				Help: "Total number of low-priority broadcasts skipped for congested clients, by priority",
+			},
+			[]string{"priority"},
+		),
+		CompressedBytes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ws_compression_bytes_total",
+				Help: "Bytes of WebSocket messages written with permessage-deflate, before and after compression",
+			},
+			[]string{"stage"},
+		),
 		Goroutines: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "goroutines",
 			Help: "Number of active goroutines",
@@ -70,7 +200,8 @@ func NewMetrics() *Metrics {
 			Name: "process_cpu_percent",
 			Help: "CPU usage of the process in percent",
 		}),
-		stopChan: make(chan struct{}),
+		roomLabels: make(map[string]string),
+		stopChan:   make(chan struct{}),
 	}
 
 	prometheus.MustRegister(
@@ -80,8 +211,24 @@ func NewMetrics() *Metrics {
 		m.CPUUsage,
 		m.RequestCounter,
 		m.RequestDuration,
+		m.HTTPRateLimited,
 		m.WSConnections,
 		m.WSMessages,
+		m.WSRTT,
+		m.ActiveRoomCount,
+		m.RoomClients,
+		m.ReapedRooms,
+		m.RateLimited,
+		m.PipelineStages,
+		m.BroadcastTime,
+		m.BroadcastFanout,
+		m.ReclaimedMsgs,
+		m.ReclaimedBytes,
+		m.AuthFailures,
+		m.ReapedConns,
+		m.SlowConsumers,
+		m.CompressedBytes,
+		m.ShedMessages,
 	)
 
 	go m.startRuntimeMetricsUpdater(5 * time.Second)
@@ -102,14 +249,27 @@ func (m *Metrics) PrometheusMiddleware() gin.HandlerFunc {
 			path = c.Request.URL.Path
 		}
 
-		m.RequestDuration.WithLabelValues(c.Request.Method, path, strconv.Itoa(status)).Observe(latency)
+		observeWithTrace(c.Request.Context(), m.RequestDuration.WithLabelValues(c.Request.Method, path, strconv.Itoa(status)), latency)
 		m.RequestCounter.WithLabelValues(c.Request.Method, path, strconv.Itoa(status)).Inc()
 	}
 }
 
-// MetricsHandler returns a handler for Prometheus metrics endpoint
+// observeWithTrace records v in o with the trace ID of the sampled span in ctx as
+// an exemplar, so dashboards can link an observation to its trace
+func observeWithTrace(ctx context.Context, o prometheus.Observer, v float64) {
+	span := trace.SpanContextFromContext(ctx)
+	if exemplars, ok := o.(prometheus.ExemplarObserver); ok && span.IsSampled() {
+		exemplars.ObserveWithExemplar(v, prometheus.Labels{TraceIDExemplar: span.TraceID().String()})
+		return
+	}
+	o.Observe(v)
+}
+
+// MetricsHandler returns a handler for Prometheus metrics endpoint. Exemplars are
+// only exposed to scrapers that negotiate the OpenMetrics format.
 func (m *Metrics) MetricsHandler() gin.HandlerFunc {
-	h := promhttp.Handler()
+	h := promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}))
 	return func(c *gin.Context) {
 		h.ServeHTTP(c.Writer, c.Request)
 	}
@@ -151,6 +311,139 @@ func (m *Metrics) DroppedMessage(roomID string, clientID string) {
 	m.WSMessages.WithLabelValues("dropped").Inc()
 }
 
+// RTTObserved records a WebSocket heartbeat round-trip time
+func (m *Metrics) RTTObserved(roomID string, rtt time.Duration) {
+	m.WSRTT.Observe(rtt.Seconds())
+}
+
+// ThrottledMessage increments the counter of messages rejected by a room broadcast quota
+func (m *Metrics) ThrottledMessage(roomID string) {
+	m.WSMessages.WithLabelValues("throttled").Inc()
+}
+
+// RateLimitedMessage increments the counter of messages dropped by the per-client rate limit
+func (m *Metrics) RateLimitedMessage(roomID string, clientID string) {
+	m.RateLimited.Inc()
+}
+
+// StageObserved records the duration of one broadcast pipeline stage
+func (m *Metrics) StageObserved(roomID string, stage string, d time.Duration) {
+	m.StageObservedContext(context.Background(), roomID, stage, d)
+}
+
+// StageObservedContext is StageObserved with the trace of ctx as exemplar
+func (m *Metrics) StageObservedContext(ctx context.Context, roomID string, stage string, d time.Duration) {
+	observeWithTrace(ctx, m.PipelineStages.WithLabelValues(stage), d.Seconds())
+}
+
+// BroadcastObserved records the duration and fan-out size of one room broadcast
+func (m *Metrics) BroadcastObserved(roomID string, recipients int, d time.Duration) {
+	m.BroadcastObservedContext(context.Background(), roomID, recipients, d)
+}
+
+// BroadcastObservedContext is BroadcastObserved with the trace of ctx as exemplar
+func (m *Metrics) BroadcastObservedContext(ctx context.Context, roomID string, recipients int, d time.Duration) {
+	room := m.roomLabel(roomID)
+	observeWithTrace(ctx, m.BroadcastTime.WithLabelValues(room), d.Seconds())
+	m.BroadcastFanout.WithLabelValues(room).Observe(float64(recipients))
+}
+
+// roomLabel returns the room label of roomID, keeping the label set bounded.
+// A room keeps its label until it is deleted, so gauges stay balanced.
+func (m *Metrics) roomLabel(roomID string) string {
+	m.roomLabelsMu.Lock()
+	defer m.roomLabelsMu.Unlock()
+	if label, ok := m.roomLabels[roomID]; ok {
+		return label
+	}
+	label := OtherRoomLabel
+	if m.ownLabels < MaxRoomMetricLabels {
+		label = roomID
+		m.ownLabels++
+	}
+	m.roomLabels[roomID] = label
+	return label
+}
+
+// MessageBroadcast counts a message broadcast to a room
+func (m *Metrics) MessageBroadcast(roomID string) {
+	m.WSMessages.WithLabelValues("broadcast").Inc()
+}
+
+// ClientJoined counts a connection registered in a room
+func (m *Metrics) ClientJoined(roomID string, clientID string) {
+	m.WSConnections.Inc()
+	m.RoomClients.WithLabelValues(m.roomLabel(roomID)).Inc()
+}
+
+// ClientLeft counts a connection removed from a room
+func (m *Metrics) ClientLeft(roomID string, clientID string) {
+	m.WSConnections.Dec()
+	m.RoomClients.WithLabelValues(m.roomLabel(roomID)).Dec()
+}
+
+// RoomCreated counts a new room. The janitor resets the count on every sweep, see ActiveRooms.
+func (m *Metrics) RoomCreated(roomID string) {
+	m.ActiveRoomCount.Inc()
+}
+
+// RoomDeleted counts a deleted room and frees its room label, so the label set tracks live rooms
+func (m *Metrics) RoomDeleted(roomID string) {
+	m.ActiveRoomCount.Dec()
+	m.roomLabelsMu.Lock()
+	defer m.roomLabelsMu.Unlock()
+	label, ok := m.roomLabels[roomID]
+	delete(m.roomLabels, roomID)
+	if ok && label == roomID {
+		m.ownLabels--
+		m.BroadcastTime.DeleteLabelValues(roomID)
+		m.BroadcastFanout.DeleteLabelValues(roomID)
+		m.RoomClients.DeleteLabelValues(roomID)
+	}
+}
+
+// ConnectionReaped counts a connection closed because it went silent
+func (m *Metrics) ConnectionReaped(roomID string, reason string) {
+	m.ReapedConns.WithLabelValues(reason).Inc()
+}
+
+// SlowConsumer counts a full send queue handled with policy
+func (m *Metrics) SlowConsumer(roomID string, policy string) {
+	m.SlowConsumers.WithLabelValues(policy).Inc()
+}
+
+// MessageShed counts a broadcast of priority skipped for a congested client
+func (m *Metrics) MessageShed(roomID string, priority string) {
+	m.ShedMessages.WithLabelValues(priority).Inc()
+}
+
+// MessageCompressed counts the bytes of a message written compressed
+func (m *Metrics) MessageCompressed(original, compressed int) {
+	m.CompressedBytes.WithLabelValues("original").Add(float64(original))
+	m.CompressedBytes.WithLabelValues("compressed").Add(float64(compressed))
+}
+
+// ActiveRooms sets the number of active rooms
+func (m *Metrics) ActiveRooms(count int) {
+	m.ActiveRoomCount.Set(float64(count))
+}
+
+// RoomReaped increments the counter of rooms removed for being idle
+func (m *Metrics) RoomReaped(roomID string) {
+	m.ReapedRooms.Inc()
+}
+
+// HistoryCompacted counts history reclaimed by the compactor
+func (m *Metrics) HistoryCompacted(roomID string, messages int, bytes int) {
+	m.ReclaimedMsgs.Add(float64(messages))
+	m.ReclaimedBytes.Add(float64(bytes))
+}
+
+// AuthFailed counts a rejected credential
+func (m *Metrics) AuthFailed(transport, reason string) {
+	m.AuthFailures.WithLabelValues(transport, reason).Inc()
+}
+
 // Stop stops runtime metrics updater
 func (m *Metrics) Stop() {
 	close(m.stopChan)
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// migrationTimeout bounds the handoff of a room snapshot to the target instance
+const migrationTimeout = 10 * time.Second
+
+// MigrateRoomRequest names the instance a room moves to. Both instances must share
+// the admin key and the token signing keys, so host tokens stay valid, and the
+// target must be listed in MIGRATION_TARGETS, as the admin key is sent to it.
+type MigrateRoomRequest struct {
+	// Target is the base URL the snapshot is sent to, one of MIGRATION_TARGETS
+	Target string `json:"target" binding:"required,url,max=2048" example:"http://chat-2.internal:8080"`
+	// ClientURL is the base URL clients reconnect to, Target if empty
+	ClientURL string `json:"client_url" binding:"omitempty,url,max=2048" example:"https://chat-2.example.com"`
+}
+
+// MigrateRoomResponse reports a completed migration
+type MigrateRoomResponse struct {
+	RoomID    websocket.ID `json:"room_id" example:"123456"`
+	ClientURL string       `json:"client_url" example:"https://chat-2.example.com"`
+	LastSeq   uint64       `json:"last_seq" example:"1024"`
+}
+
+// ImportRoomResponse reports a room restored from a snapshot
+type ImportRoomResponse struct {
+	RoomID  websocket.ID `json:"room_id" example:"123456"`
+	LastSeq uint64       `json:"last_seq" example:"1024"`
+}
+
+// AdminMigrateRoom godoc
+// @Summary Migrate a room to another instance
+// @Description Hands the room, its history and its recent broadcasts to another instance and tells clients to reconnect there (admin only).
+// @Description Messaging and joins are suspended during the handoff. Clients receive a "migrate" notification with the URL to reconnect to
+// @Description and are closed with code 4005; reconnecting with ?since= set to their last sequence replays what they missed.
+// @Description If the target rejects the snapshot the room resumes on this instance. Targets must be listed in MIGRATION_TARGETS.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param request body MigrateRoomRequest true "Target instance"
+// @Success 200 {object} MigrateRoomResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "Target is not in MIGRATION_TARGETS"
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Room is already migrating"
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Failure 502 {object} ErrorResponse "Target instance rejected the room"
+// @Router /api/admin/rooms/{room_id}/migrate [post]
+func (s *Server) AdminMigrateRoom() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.adminRoom(c)
+		if !ok {
+			return
+		}
+		var req MigrateRoomRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+		target := strings.TrimRight(req.Target, "/")
+		if !slices.Contains(s.Config.MigrationTargetList(), target) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Code:      http.StatusForbidden,
+				Error:     "target is not an allowed migration target",
+				ErrorCode: websocket.CodeTargetNotAllowed,
+			})
+			return
+		}
+		clientURL := strings.TrimRight(req.ClientURL, "/")
+		if clientURL == "" {
+			clientURL = target
+		}
+
+		snapshot, ok := room.BeginMigration()
+		if !ok {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Code:      http.StatusConflict,
+				Error:     "room is already migrating",
+				ErrorCode: websocket.CodeRoomMigrating,
+			})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), migrationTimeout)
+		defer cancel()
+		if err := s.sendSnapshot(ctx, target, snapshot); err != nil {
+			room.CancelMigration()
+			s.Logger.Log(c.Request.Context(), logging.Error, "Room migration failed",
+				"room_id", room.ID, "target", target, "error", err.Error())
+			c.JSON(http.StatusBadGateway, ErrorResponse{
+				Code:      http.StatusBadGateway,
+				Error:     "target instance rejected the room: " + err.Error(),
+				ErrorCode: websocket.CodeMigrationFailed,
+			})
+			return
+		}
+
+		s.Handler.Hub.CompleteMigration(room.ID, clientURL)
+		s.Logger.Log(c.Request.Context(), logging.Warn, "Room migrated by admin",
+			"room_id", room.ID, "target", target, "last_seq", snapshot.LastSeq, "client_ip", c.ClientIP())
+
+		c.JSON(http.StatusOK, MigrateRoomResponse{RoomID: room.ID, ClientURL: clientURL, LastSeq: snapshot.LastSeq})
+	}
+}
+
+// sendSnapshot posts snapshot to the import endpoint of target. Targets are
+// operator-configured, so private addresses are allowed, but redirects are not
+// followed: the admin key must only reach the listed instance.
+func (s *Server) sendSnapshot(ctx context.Context, target string, snapshot websocket.RoomSnapshot) error {
+	body, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target+"/api/admin/rooms/import", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(AdminKeyHeader, s.Config.AdminAPIKey())
+
+	resp, err := newOutboundClient(migrationTimeout, true).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusCreated {
+		return nil
+	}
+	var errResp ErrorResponse
+	if data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096)); json.Unmarshal(data, &errResp) == nil && errResp.Error != "" {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, errResp.Error)
+	}
+	return fmt.Errorf("status %d", resp.StatusCode)
+}
+
+// AdminImportRoom godoc
+// @Summary Import a migrated room
+// @Description Restores a room from the snapshot sent by another instance during a migration (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param request body websocket.RoomSnapshot true "Room snapshot"
+// @Success 201 {object} ImportRoomResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Admin API is disabled"
+// @Failure 409 {object} ErrorResponse "Room already exists"
+// @Failure 429 {object} ErrorResponse "Tenant room quota exceeded"
+// @Router /api/admin/rooms/import [post]
+func (s *Server) AdminImportRoom() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var snapshot websocket.RoomSnapshot
+		if !bindRequest(c, &snapshot) {
+			return
+		}
+		if snapshot.ID < MinRoomID || snapshot.ID > MaxRoomID {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     "invalid room ID",
+				ErrorCode: websocket.CodeInvalidRoomID,
+			})
+			return
+		}
+
+		opts := s.serverRoomOptions()
+		release := func() {}
+		if s.Tenants != nil && snapshot.Tenant != "" {
+			releaseRoom, ok := s.Tenants.AcquireRoom(snapshot.Tenant)
+			if !ok {
+				c.JSON(http.StatusTooManyRequests, ErrorResponse{
+					Code:      http.StatusTooManyRequests,
+					Error:     "room quota exceeded",
+					ErrorCode: websocket.CodeQuotaExceeded,
+				})
+				return
+			}
+			release = releaseRoom
+			opts = append(opts, websocket.WithTenant(s.Tenants, snapshot.Tenant, releaseRoom))
+		}
+
+		room, created := s.Handler.Hub.RestoreRoom(snapshot, s.Metrics, opts...)
+		if !created {
+			release()
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Code:      http.StatusConflict,
+				Error:     "room already exists",
+				ErrorCode: websocket.CodeRoomExists,
+			})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Room imported from another instance",
+			"room_id", room.ID, "last_seq", snapshot.LastSeq, "history", len(snapshot.History))
+
+		c.JSON(http.StatusCreated, ImportRoomResponse{RoomID: room.ID, LastSeq: room.LastSeq()})
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/config"
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	gorillaWs "github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// migrationServer returns a server with the admin API enabled and the room
+// migration routes, and the engine serving them
+func migrationServer(t *testing.T, cfg *config.Config) (*Server, *gin.Engine) {
+	pool, err := websocket.NewTaskPool(10)
+	require.NoError(t, err)
+	t.Cleanup(pool.Release)
+	keys := websocket.NewHMACKeys("test-secret")
+	s := &Server{
+		Handler:   *websocket.NewHandler(websocket.NewHub(), pool),
+		Logger:    logging.NewLogger(),
+		Config:    cfg,
+		TokenKeys: keys,
+		Metrics:   &Metrics{ActiveRoomCount: prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_active_rooms"})},
+		live:      new(atomic.Pointer[config.Config]),
+	}
+	s.live.Store(cfg)
+	engine := gin.New()
+	engine.GET("/api/ws/:room_id", s.Handler.HandleWebSocketWithKeys(keys))
+	admin := engine.Group("/api/admin", s.requireAdmin())
+	admin.POST("/rooms/:room_id/migrate", s.AdminMigrateRoom())
+	admin.POST("/rooms/import", s.AdminImportRoom())
+	return s, engine
+}
+
+func TestAdminMigrateRoom(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	targetServer, targetEngine := migrationServer(t, &config.Config{AdminKey: "admin-key", ReconnectGrace: time.Minute})
+	target := httptest.NewServer(targetEngine)
+	defer target.Close()
+	source, sourceEngine := migrationServer(t, &config.Config{AdminKey: "admin-key", MigrationTargets: target.URL + "/"})
+	sourceHTTP := httptest.NewServer(sourceEngine)
+	defer sourceHTTP.Close()
+
+	room, _ := source.Handler.Hub.CreateRoom(1, nil, websocket.WithHost("host-1"), websocket.WithReconnectGrace(time.Minute))
+	defer room.StopRoom()
+	conn, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(sourceHTTP.URL, "http")+"/api/ws/1?username=alice", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	var welcome websocket.WelcomeMessage
+	for welcome.ResumeToken == "" {
+		var msg websocket.Message
+		require.NoError(t, conn.ReadJSON(&msg))
+		if msg.Type == "welcome" {
+			require.NoError(t, json.Unmarshal(msg.Data, &welcome))
+		}
+	}
+	require.NoError(t, conn.WriteJSON(websocket.Message{Type: "raise_hand"}))
+	require.Eventually(t, func() bool { return len(room.HandQueue()) == 1 }, time.Second, 10*time.Millisecond)
+	hook, _, err := room.AddWebhook("https://hooks.example.com/chat")
+	require.NoError(t, err)
+	require.True(t, room.SetLocked(true))
+
+	migrate := func(target string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(MigrateRoomRequest{Target: target})
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/rooms/1/migrate", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(AdminKeyHeader, "admin-key")
+		w := httptest.NewRecorder()
+		sourceEngine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("targets outside the allowlist are refused", func(t *testing.T) {
+		w := migrate("http://attacker.example.com")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), string(websocket.CodeTargetNotAllowed))
+		assert.False(t, room.IsMigrating())
+		_, exists := source.Handler.Hub.GetRoom(1)
+		assert.True(t, exists)
+	})
+
+	t.Run("moves the room with its members", func(t *testing.T) {
+		w := migrate(target.URL)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		_, exists := source.Handler.Hub.GetRoom(1)
+		assert.False(t, exists)
+
+		restored, ok := targetServer.Handler.Hub.GetRoom(1)
+		require.True(t, ok)
+		defer restored.StopRoom()
+		assert.True(t, restored.IsLocked())
+		require.Len(t, restored.Webhooks(), 1)
+		assert.Equal(t, hook.ID, restored.Webhooks()[0].ID)
+		require.Len(t, restored.HandQueue(), 1)
+		assert.Equal(t, "alice", restored.HandQueue()[0].Username)
+		assert.True(t, restored.AdmitsJoin(welcome.ResumeToken), "migrated members may resume")
+		assert.False(t, restored.AdmitsJoin(""))
+	})
+}
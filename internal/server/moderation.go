@@ -0,0 +1,283 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+type KickBulkRequest struct {
+	Usernames []string `json:"usernames" binding:"required,min=1,max=100,unique,dive,required,max=50" example:"spammer1,spammer2"`
+}
+
+// KickResultResponse is the outcome of kicking a single user
+type KickResultResponse struct {
+	Username string `json:"username" example:"spammer1"`
+	Error    string `json:"error,omitempty" example:"user not found in room"`
+	Kicked   bool   `json:"kicked" example:"true"`
+}
+
+type KickBulkResponse struct {
+	Results []KickResultResponse `json:"results"`
+	Kicked  int                  `json:"kicked" example:"2"`
+}
+
+// KickBulk godoc
+// @Summary Kick several users from room
+// @Description Atomically removes all listed users from the room (host only) and reports per-user results
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Param Idempotency-Key header string false "Key to safely retry the request"
+// @Param request body KickBulkRequest true "Users to kick"
+// @Success 200 {object} KickBulkResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/rooms/{room_id}/kick-bulk [post]
+func (s *Server) KickBulk() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		var req KickBulkRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		results := room.KickClients(req.Usernames, s.Handler.Hub.Brand().HostName)
+
+		resp := KickBulkResponse{Results: make([]KickResultResponse, 0, len(results))}
+		for _, result := range results {
+			resp.Results = append(resp.Results, KickResultResponse{
+				Username: result.Username,
+				Kicked:   result.Kicked,
+				Error:    result.Reason,
+			})
+			if result.Kicked {
+				resp.Kicked++
+			}
+		}
+
+		s.Logger.Log(ctx, logging.Info, "Users kicked from room",
+			"room_id", room.ID, "requested", len(req.Usernames), "kicked", resp.Kicked)
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+type FreezeRoomRequest struct {
+	DurationSeconds int `json:"duration_seconds,omitempty" binding:"min=0,max=86400" example:"300"`
+}
+
+// FreezeRoom godoc
+// @Summary Freeze room
+// @Description Suspends all non-host messaging and joins (host only). Omit duration to freeze until unfrozen.
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Param request body FreezeRoomRequest false "Optional freeze duration"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/rooms/{room_id}/freeze [post]
+func (s *Server) FreezeRoom() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		var req FreezeRoomRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		room.Freeze(time.Duration(req.DurationSeconds) * time.Second)
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Room frozen",
+			"room_id", room.ID, "duration_seconds", req.DurationSeconds)
+
+		c.JSON(http.StatusOK, gin.H{"message": "room frozen"})
+	}
+}
+
+// UnfreezeRoom godoc
+// @Summary Unfreeze room
+// @Description Resumes messaging and joins in a frozen room (host only)
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/unfreeze [post]
+func (s *Server) UnfreezeRoom() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		room.Unfreeze()
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Room unfrozen", "room_id", room.ID)
+
+		c.JSON(http.StatusOK, gin.H{"message": "room unfrozen"})
+	}
+}
+
+// LockRoom godoc
+// @Summary Lock room
+// @Description Turns away all new members, even with the room password, while current members keep chatting and may reconnect (host only).
+// @Description Unlike a freeze, messaging continues; unlike a password change, nobody can get in until the room is unlocked.
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/lock [post]
+func (s *Server) LockRoom() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		if room.SetLocked(true) {
+			s.Logger.Log(c.Request.Context(), logging.Info, "Room locked", "room_id", room.ID)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "room locked"})
+	}
+}
+
+// UnlockRoom godoc
+// @Summary Unlock room
+// @Description Lets new members join a locked room again (host only)
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/unlock [post]
+func (s *Server) UnlockRoom() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		if room.SetLocked(false) {
+			s.Logger.Log(c.Request.Context(), logging.Info, "Room unlocked", "room_id", room.ID)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "room unlocked"})
+	}
+}
+
+type MuteUserRequest struct {
+	Username string `json:"username" binding:"required,max=50" example:"john_doe"`
+}
+
+// MuteUser godoc
+// @Summary Mute user
+// @Description Drops the chat messages of a connected member until unmuted (host only). The member is told it is muted when it tries to chat.
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Param request body MuteUserRequest true "Member to mute"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Hosts cannot be muted"
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/rooms/{room_id}/mute [post]
+func (s *Server) MuteUser() func(c *gin.Context) {
+	return s.setMuted(true)
+}
+
+// UnmuteUser godoc
+// @Summary Unmute user
+// @Description Lets a muted member chat again (host only)
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Param request body MuteUserRequest true "Member to unmute"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/rooms/{room_id}/unmute [post]
+func (s *Server) UnmuteUser() func(c *gin.Context) {
+	return s.setMuted(false)
+}
+
+func (s *Server) setMuted(muted bool) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		var req MuteUserRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		switch err := room.SetMuted(req.Username, muted, s.Handler.Hub.Brand().HostName); {
+		case errors.Is(err, websocket.ErrUserNotInRoom):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "user not found in room",
+				ErrorCode: websocket.CodeUserNotFound,
+			})
+			return
+		case errors.Is(err, websocket.ErrCannotMuteHost):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Code:      http.StatusConflict,
+				Error:     err.Error(),
+				ErrorCode: websocket.CodeCannotMuteHost,
+			})
+			return
+		}
+
+		message := "user unmuted"
+		if muted {
+			message = "user muted"
+		}
+		s.Logger.Log(c.Request.Context(), logging.Info, "Room member mute changed",
+			"room_id", room.ID, "username", req.Username, "muted", muted)
+
+		c.JSON(http.StatusOK, gin.H{"message": message})
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+)
+
+var (
+	ErrPrivateAddress = errors.New("webhook URL resolves to a private address")
+	errRedirect       = errors.New("redirects are not followed")
+)
+
+// sharedAddressSpace is 100.64.0.0/10, used for carrier-grade NAT and by some
+// clouds for their metadata endpoints
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddress reports whether ip is routable on the internet, so requests to it
+// can't reach the server's own network or cloud metadata endpoints
+func publicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() &&
+		!ip.IsPrivate() &&
+		!ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// checkWebhookURL validates a webhook URL registered through the API: it must use
+// https and must not name a private address. Host names are checked when dialing.
+func checkWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return websocket.ErrInsecureWebhookURL
+	}
+	if u.Scheme != "https" {
+		return websocket.ErrInsecureWebhookURL
+	}
+	if ip, err := netip.ParseAddr(u.Hostname()); err == nil && !publicAddress(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// refusePrivateAddresses is a net.Dialer Control function rejecting connections
+// to non-public addresses. It runs after name resolution, so a host name that
+// resolves to a private address, even after a DNS change, is refused too.
+func refusePrivateAddresses(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicAddress(addrPort.Addr()) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// newOutboundClient returns the client for requests to URLs supplied by API
+// callers. It only connects to public addresses and doesn't follow redirects,
+// returning the redirect response instead. Operator-configured endpoints use
+// allowPrivate, as they often live on the internal network.
+func newOutboundClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = refusePrivateAddresses
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
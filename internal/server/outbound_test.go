@@ -0,0 +1,70 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckWebhookURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{"https://hooks.example.com/chatters", nil},
+		{"https://203.0.113.7/chatters", nil},
+		{"http://hooks.example.com/chatters", websocket.ErrInsecureWebhookURL},
+		{"ftp://hooks.example.com/chatters", websocket.ErrInsecureWebhookURL},
+		{"https://127.0.0.1/chatters", ErrPrivateAddress},
+		{"https://[::1]/chatters", ErrPrivateAddress},
+		{"https://10.1.2.3/chatters", ErrPrivateAddress},
+		{"https://192.168.0.10/chatters", ErrPrivateAddress},
+		{"https://169.254.169.254/latest/meta-data", ErrPrivateAddress},
+		{"https://100.100.100.200/latest/meta-data", ErrPrivateAddress},
+		{"https://[::ffff:127.0.0.1]/chatters", ErrPrivateAddress},
+		{"https://0.0.0.0/chatters", ErrPrivateAddress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.ErrorIs(t, checkWebhookURL(tt.url), tt.want)
+		})
+	}
+}
+
+func TestOutboundClientRefusesPrivateAddresses(t *testing.T) {
+	var hits int
+	target := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { hits++ }))
+	defer target.Close()
+
+	_, err := newOutboundClient(time.Second, false).Post(target.URL, "application/json", nil)
+	assert.ErrorIs(t, err, ErrPrivateAddress)
+	assert.Zero(t, hits)
+
+	resp, err := newOutboundClient(time.Second, true).Post(target.URL, "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 1, hits)
+}
+
+func TestOutboundClientDoesNotFollowRedirects(t *testing.T) {
+	var followed bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			followed = true
+			return
+		}
+		http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+	}))
+	defer target.Close()
+
+	resp, err := newOutboundClient(time.Second, true).Post(target.URL, "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+	assert.False(t, followed)
+}
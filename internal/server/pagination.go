@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// Pagination defaults
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+
+	offsetCursorPrefix = "o:"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// PageResponse is the envelope shared by all list endpoints.
+// NextCursor is empty when there are no more items.
+type PageResponse[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty" example:"bzo1MA"`
+	Total      int    `json:"total" example:"120"`
+}
+
+// PageRequest holds the pagination query parameters of a list request
+type PageRequest struct {
+	Cursor string
+	Limit  int
+}
+
+// parsePageRequest reads cursor and limit query parameters, applying defaults and bounds.
+// page and page_size are accepted as numbered-page aliases; a cursor takes precedence over page.
+func parsePageRequest(c *gin.Context) (PageRequest, error) {
+	req := PageRequest{
+		Cursor: c.Query("cursor"),
+		Limit:  DefaultPageLimit,
+	}
+	limitField, limitStr := "limit", c.Query("limit")
+	if limitStr == "" {
+		limitField, limitStr = "page_size", c.Query("page_size")
+	}
+	if limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return req, &websocket.ValidationError{Field: limitField, Message: limitField + " must be a positive integer"}
+		}
+		req.Limit = min(limit, MaxPageLimit)
+	}
+	if pageStr := c.Query("page"); pageStr != "" && req.Cursor == "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			return req, &websocket.ValidationError{Field: "page", Message: "page must be a positive integer"}
+		}
+		if page > 1 {
+			req.Cursor = encodeOffsetCursor((page - 1) * req.Limit)
+		}
+	}
+	return req, nil
+}
+
+// encodeOffsetCursor returns an opaque cursor pointing at offset
+func encodeOffsetCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(offsetCursorPrefix + strconv.Itoa(offset)))
+}
+
+// decodeOffsetCursor parses a cursor produced by encodeOffsetCursor. Empty cursor means offset 0.
+func decodeOffsetCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), offsetCursorPrefix) {
+		return 0, errInvalidCursor
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), offsetCursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, errInvalidCursor
+	}
+	return offset, nil
+}
+
+// paginate returns the page of items selected by req using offset cursors
+func paginate[T any](items []T, req PageRequest) (PageResponse[T], error) {
+	offset, err := decodeOffsetCursor(req.Cursor)
+	if err != nil {
+		return PageResponse[T]{}, err
+	}
+
+	page := PageResponse[T]{Items: []T{}, Total: len(items)}
+	if offset >= len(items) {
+		return page, nil
+	}
+
+	end := min(offset+req.Limit, len(items))
+	page.Items = items[offset:end]
+	if end < len(items) {
+		page.NextCursor = encodeOffsetCursor(end)
+	}
+	return page, nil
+}
+
+// pageFromQuery paginates items using the request query parameters.
+// On invalid parameters a 400 response is written and ok is false.
+func pageFromQuery[T any](c *gin.Context, items []T) (page PageResponse[T], ok bool) {
+	req, err := parsePageRequest(c)
+	if err == nil {
+		page, err = paginate(items, req)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Error:     err.Error(),
+			ErrorCode: websocket.CodeInvalidRequest,
+		})
+		return page, false
+	}
+	return page, true
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// Names of the REST rate limiters, used as the limiter label of their metric
+const (
+	APILimiterName       = "api"
+	SensitiveLimiterName = "sensitive"
+)
+
+const rateLimitSweepPeriod = time.Minute
+
+// RateLimiter is a per-key token bucket refilling at rate tokens per second up to
+// burst. Buckets that refilled completely are forgotten, so memory is bounded by
+// the keys active within the last burst/rate seconds.
+type RateLimiter struct {
+	buckets   map[string]*websocket.TokenBucket
+	lastSweep time.Time
+	rate      float64
+	burst     int
+	mu        sync.Mutex
+}
+
+// NewRateLimiter creates a limiter allowing perSecond requests per key with bursts
+// of up to burst. It returns nil, which allows everything, if perSecond is zero.
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		buckets:   make(map[string]*websocket.TokenBucket),
+		lastSweep: time.Now(),
+		rate:      perSecond,
+		burst:     max(burst, 1),
+	}
+}
+
+// Allow consumes a token of key. When none is left it returns false and how long
+// to wait for the next one.
+func (l *RateLimiter) Allow(key string, now time.Time) (time.Duration, bool) {
+	if l == nil {
+		return 0, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = websocket.NewTokenBucket(l.rate, l.burst, now)
+		l.buckets[key] = b
+	}
+	return b.Take(now)
+}
+
+// sweep forgets the buckets that refilled completely, at most once per period
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepPeriod {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.Full(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimit rejects requests of client IPs over the limit of limiter with 429
+// and a Retry-After header in seconds
+func (s *Server) rateLimit(limiter *RateLimiter, name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		wait, ok := limiter.Allow(c.ClientIP(), time.Now())
+		if ok {
+			c.Next()
+			return
+		}
+		s.Metrics.HTTPRateLimited.WithLabelValues(name).Inc()
+		c.Header("Retry-After", strconv.Itoa(max(int(math.Ceil(wait.Seconds())), 1)))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorResponse{
+			Code:      http.StatusTooManyRequests,
+			Error:     "too many requests",
+			ErrorCode: websocket.CodeRateLimited,
+		})
+	}
+}
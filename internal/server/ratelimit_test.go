@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	assert.Nil(t, NewRateLimiter(0, 10))
+	var unlimited *RateLimiter
+	_, ok := unlimited.Allow("203.0.113.7", time.Now())
+	assert.True(t, ok)
+
+	now := time.Now()
+	limiter := NewRateLimiter(2, 3)
+	for i := 0; i < 3; i++ {
+		_, ok := limiter.Allow("203.0.113.7", now)
+		require.True(t, ok, "request %d of the burst", i)
+	}
+	wait, ok := limiter.Allow("203.0.113.7", now)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+	_, ok = limiter.Allow("203.0.113.8", now)
+	assert.True(t, ok, "other keys have buckets of their own")
+
+	_, ok = limiter.Allow("203.0.113.7", now.Add(500*time.Millisecond))
+	assert.True(t, ok, "a token refills after 1/rate seconds")
+
+	// Buckets that refilled completely are forgotten on the next sweep
+	limiter.Allow("203.0.113.9", now.Add(2*rateLimitSweepPeriod))
+	assert.Len(t, limiter.buckets, 1)
+}
+
+func TestRateLimitClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name           string
+		trustedProxies string
+		remoteAddr     string
+		limited        bool // whether changing X-Forwarded-For keeps the limit
+	}{
+		{"no trusted proxies", "", "10.0.0.1:1234", true},
+		{"untrusted peer", "192.0.2.0/24", "10.0.0.1:1234", true},
+		{"trusted proxy", "10.0.0.0/8", "10.0.0.1:1234", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{TrustedProxies: tt.trustedProxies}
+			require.NoError(t, CheckTrustedProxies(cfg))
+			engine, err := newEngine(cfg)
+			require.NoError(t, err)
+			s := &Server{Metrics: &Metrics{HTTPRateLimited: prometheus.NewCounterVec(
+				prometheus.CounterOpts{Name: "test_http_rate_limited_total"}, []string{"limiter"})}}
+			engine.GET("/", s.rateLimit(NewRateLimiter(1, 1), APILimiterName), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			request := func(forwardedFor string) int {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.RemoteAddr = tt.remoteAddr
+				req.Header.Set("X-Forwarded-For", forwardedFor)
+				w := httptest.NewRecorder()
+				engine.ServeHTTP(w, req)
+				return w.Code
+			}
+			require.Equal(t, http.StatusOK, request("203.0.113.1"))
+			status := request("203.0.113.2")
+			if tt.limited {
+				assert.Equal(t, http.StatusTooManyRequests, status)
+			} else {
+				assert.Equal(t, http.StatusOK, status)
+			}
+		})
+	}
+}
+
+func TestCheckTrustedProxies(t *testing.T) {
+	for list, valid := range map[string]bool{
+		"":                          true,
+		"10.0.0.1":                  true,
+		"10.0.0.0/8, 2001:db8::/32": true,
+		"proxy.internal":            false,
+		"10.0.0.0/33":               false,
+	} {
+		err := CheckTrustedProxies(&config.Config{TrustedProxies: list})
+		assert.Equal(t, valid, err == nil, "list %q", list)
+	}
+}
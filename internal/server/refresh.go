@@ -0,0 +1,132 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+type RefreshTokenResponse struct {
+	ExpiresAt time.Time `json:"expires_at" example:"2024-01-02T12:00:00Z"`
+	HostToken string    `json:"host_token"`
+}
+
+// refreshableHostClaims verifies a host token like validateHostToken, but also
+// accepts a token that expired less than grace ago. Any other validation error,
+// including a bad signature, rejects the token.
+func (s *Server) refreshableHostClaims(tokenString, roomIDStr string, grace time.Duration) (jwt.MapClaims, error) {
+	if tokenString == "" {
+		return nil, errHostTokenRequired
+	}
+
+	token, err := s.TokenKeys.Parse(tokenString)
+	if err != nil {
+		var verr *jwt.ValidationError
+		if !errors.As(err, &verr) || verr.Errors != jwt.ValidationErrorExpired {
+			return nil, errors.New("invalid token")
+		}
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, errors.New("token has no expiry")
+	}
+	if time.Since(time.Unix(int64(exp), 0)) > grace {
+		return nil, errors.New("token expired beyond refresh grace")
+	}
+
+	if err := checkHostClaims(claims, roomIDStr); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// RefreshHostToken godoc
+// @Summary Refresh host token
+// @Description Exchanges a valid host token, or one that expired within the refresh grace window, for a new one valid for another 24 hours
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host token"
+// @Success 200 {object} RefreshTokenResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/refresh-token [post]
+func (s *Server) RefreshHostToken() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		roomIDStr := c.Param("room_id")
+
+		roomID, err := validateRoomID(roomIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     "invalid room ID format",
+				ErrorCode: websocket.CodeInvalidRoomID,
+			})
+			return
+		}
+
+		claims, err := s.refreshableHostClaims(c.GetHeader("Authorization"), roomIDStr, s.Config.HostTokenRefreshGrace())
+		if err != nil {
+			s.hostAuthFailed(err)
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Code:      http.StatusUnauthorized,
+				Error:     "unauthorized: " + err.Error(),
+				ErrorCode: websocket.CodeInvalidToken,
+			})
+			return
+		}
+
+		room, exists := s.Handler.Hub.GetRoom(roomID)
+		if !exists {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
+			})
+			return
+		}
+
+		// A token for a host that has since been replaced must not mint new ones
+		hostID, _ := claims["host_id"].(string)
+		if !room.IsHostID(hostID) {
+			s.hostAuthFailed(websocket.ErrNotHost)
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Code:      http.StatusUnauthorized,
+				Error:     "unauthorized: token is not for the current host",
+				ErrorCode: websocket.CodeInvalidToken,
+			})
+			return
+		}
+
+		now := time.Now()
+		tokenString, err := s.TokenKeys.Sign(websocket.NewHostClaims(roomID, hostID, now))
+		if err != nil {
+			s.Logger.Log(ctx, logging.Error, "Failed to sign refreshed host token", "room_id", roomID, "error", err.Error())
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:      http.StatusInternalServerError,
+				Error:     "failed to generate host token",
+				ErrorCode: websocket.CodeInternal,
+			})
+			return
+		}
+
+		s.Logger.Log(ctx, logging.Info, "Host token refreshed", "room_id", roomID)
+		c.JSON(http.StatusOK, RefreshTokenResponse{
+			ExpiresAt: now.Add(websocket.HostTokenTTL).UTC(),
+			HostToken: tokenString,
+		})
+	}
+}
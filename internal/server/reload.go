@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/YuarenArt/chatters/internal/config"
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+)
+
+// liveConfig returns the latest loaded configuration. Options that can be reloaded
+// are read from it instead of Config, which keeps the startup values.
+func (s *Server) liveConfig() *config.Config {
+	return s.live.Load()
+}
+
+// WatchConfig reloads the configuration each time a signal arrives on reload,
+// typically SIGHUP, until ctx is done. A configuration that fails to load or
+// validate is logged and the current one kept.
+func (s *Server) WatchConfig(ctx context.Context, reload <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+			next, err := s.liveConfig().Reload()
+			if err == nil {
+				err = s.ApplyConfig(next)
+			}
+			if err != nil {
+				s.Logger.Log(ctx, logging.Error, "Config reload failed, keeping the current config", "error", err.Error())
+				continue
+			}
+			s.Logger.Log(ctx, logging.Info, "Config reloaded")
+		}
+	}
+}
+
+// ApplyConfig validates next and applies its runtime-tunable options: the log level,
+// allowed origins, rate limits, which running rooms switch to as well, and the
+// defaults of new rooms. Other changed options are logged as needing a restart.
+func (s *Server) ApplyConfig(next *config.Config) error {
+	level, err := logging.ParseLevel(next.LogLevel)
+	if err != nil {
+		return err
+	}
+	if err := CheckRoomDefaults(next); err != nil {
+		return fmt.Errorf("invalid room defaults: %w", err)
+	}
+	if err := websocket.ValidateOrigins(next.AllowedOriginList()); err != nil {
+		return err
+	}
+
+	current := s.live.Swap(next)
+	logging.SetLevel(level)
+	s.Handler.Origins.Set(next.AllowedOriginList())
+
+	limit, window := next.ClientRateLimit()
+	quota := next.RoomMessageQuota()
+	currentLimit, currentWindow := current.ClientRateLimit()
+	if limit != currentLimit || window != currentWindow || quota != current.RoomMessageQuota() {
+		s.Handler.Hub.Rooms.Range(func(_, value any) bool {
+			value.(*websocket.Room).SetRateLimits(limit, window, quota)
+			return true
+		})
+	}
+
+	if restart := current.RestartRequired(next); len(restart) > 0 {
+		s.Logger.Log(context.Background(), logging.Warn, "Changed options take effect after a restart", "options", restart)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/YuarenArt/chatters/internal/config"
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyConfig(t *testing.T) {
+	load := func(args ...string) *config.Config {
+		cfg, err := config.Load(args)
+		require.NoError(t, err)
+		return cfg
+	}
+	t.Setenv("CONFIG_FILE", "")
+	current := load()
+	live := new(atomic.Pointer[config.Config])
+	live.Store(current)
+	s := &Server{
+		Handler: *websocket.NewHandler(websocket.NewHub(), nil),
+		Logger:  logging.NewLogger(),
+		Config:  current,
+		live:    live,
+	}
+	defer logging.SetLevel(logging.Info)
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "unknown log level", args: []string{"-log-level", "verbose"}},
+		{name: "invalid room defaults", args: []string{"-default-room-visibility", "hidden"}},
+		{name: "invalid slow consumer policy", args: []string{"-default-room-slow-consumer", "wait"}},
+		{name: "invalid origin", args: []string{"-allowed-origins", "not an origin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, s.ApplyConfig(load(tt.args...)))
+			assert.Same(t, current, s.liveConfig(), "an invalid config is not applied")
+		})
+	}
+
+	next := load("-allowed-origins", "https://app.example.com", "-client-rate-limit", "5", "-port", "9000")
+	require.NoError(t, s.ApplyConfig(next))
+	assert.Same(t, next, s.liveConfig())
+	assert.Same(t, current, s.Config, "startup values are kept")
+	assert.True(t, s.Handler.Origins.Allows("https://app.example.com"))
+	assert.False(t, s.Handler.Origins.Allows("https://other.example.com"))
+}
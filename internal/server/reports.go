@@ -0,0 +1,53 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BugReportRequest is a user complaint about a WebSocket connection
+type BugReportRequest struct {
+	ConnectionID string `json:"connection_id" binding:"required,uuid" example:"5c1e0b8f-4a55-9f59-3f0c-2b8d9a105a5e"`
+	RoomID       int    `json:"room_id,omitempty" binding:"omitempty,min=1,max=999999999" example:"123456"`
+	Description  string `json:"description" binding:"required,max=2000" example:"Messages stopped arriving after reconnecting"`
+}
+
+// BugReportResponse identifies a stored bug report
+type BugReportResponse struct {
+	ReportID     string `json:"report_id" example:"9a10b8f5-3f0c-2b8d-4a55-0b8f5a5e5c1e"`
+	ConnectionID string `json:"connection_id" example:"5c1e0b8f-4a55-9f59-3f0c-2b8d9a105a5e"`
+}
+
+// BugReport godoc
+// @Summary Report a bug
+// @Description Logs a user complaint together with the connection ID from the welcome message,
+// @Description which is the request_id of the connection in the server logs
+// @Tags support
+// @Accept json
+// @Produce json
+// @Param request body BugReportRequest true "Bug report"
+// @Success 202 {object} BugReportResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/reports [post]
+func (s *Server) BugReport() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req BugReportRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		reportID := uuid.New().String()
+		s.Logger.Log(c.Request.Context(), logging.Warn, "Bug report",
+			"report_id", reportID,
+			"connection_id", req.ConnectionID,
+			"room_id", req.RoomID,
+			"description", req.Description,
+			"client_ip", c.ClientIP(),
+			"user_agent", c.Request.UserAgent())
+
+		c.JSON(http.StatusAccepted, BugReportResponse{ReportID: reportID, ConnectionID: req.ConnectionID})
+	}
+}
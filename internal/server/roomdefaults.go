@@ -0,0 +1,136 @@
+package server
+
+import (
+	"errors"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/config"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+)
+
+// validateHistoryLimit checks a room history size against its bounds
+func validateHistoryLimit(limit int) []websocket.ValidationError {
+	if limit < 0 || limit > websocket.MaxHistoryLimit {
+		return []websocket.ValidationError{{Field: "history_limit", Message: "history_limit is out of valid range"}}
+	}
+	return nil
+}
+
+// defaultRoomSettings returns the configured settings and history size for new rooms
+func defaultRoomSettings(cfg *config.Config) (websocket.RoomSettings, int, error) {
+	defaults := cfg.NewRoomDefaults()
+
+	settings := websocket.RoomSettings{
+		Visibility: websocket.Visibility(defaults.Visibility),
+		MaxClients: defaults.MaxClients,
+		SlowMode:   defaults.SlowMode,
+		Retention:  defaults.Retention,
+	}
+	fieldErrs := websocket.SettingsUpdate{
+		Visibility: &settings.Visibility,
+		MaxClients: &settings.MaxClients,
+		SlowMode:   &settings.SlowMode,
+		Retention:  &settings.Retention,
+	}.Validate()
+	fieldErrs = append(fieldErrs, validateHistoryLimit(defaults.HistoryLimit)...)
+	if len(fieldErrs) > 0 {
+		return settings, 0, errors.New("default room " + fieldErrs[0].Message)
+	}
+	return settings, defaults.HistoryLimit, nil
+}
+
+// CheckRoomDefaults validates the configured defaults for new rooms
+func CheckRoomDefaults(cfg *config.Config) error {
+	if _, _, err := defaultRoomSettings(cfg); err != nil {
+		return err
+	}
+	_, err := websocket.ParseSlowConsumerPolicy(cfg.NewRoomDefaults().SlowConsumer)
+	return err
+}
+
+// serverRoomOptions returns the limits and callbacks the server applies to every room it creates
+func (s *Server) serverRoomOptions() []websocket.RoomOption {
+	return []websocket.RoomOption{
+		websocket.WithReconnectGrace(s.Config.ReconnectGracePeriod()),
+		websocket.WithBroadcastQuota(s.liveConfig().RoomMessageQuota()),
+		websocket.WithClientRateLimit(s.liveConfig().ClientRateLimit()),
+		websocket.WithSendCredits(s.Config.SendCreditLimit()),
+		websocket.WithPasswordAlert(s.Config.PasswordAlertLimit(), s.passwordAlert),
+		websocket.WithHookDispatcher(s.dispatchHook),
+		websocket.WithRoomWebhookDispatcher(s.dispatchRoomWebhook),
+		websocket.WithSpoolDir(s.Config.SpoolDir),
+		websocket.WithSpoolBudget(s.spools),
+	}
+}
+
+// roomOptions merges the server defaults with the overrides of a create request.
+// Invalid overrides are returned as field errors.
+func (s *Server) roomOptions(req CreateRoomRequest) ([]websocket.RoomOption, []websocket.ValidationError, error) {
+	settings, historyLimit, err := defaultRoomSettings(s.liveConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var update websocket.SettingsUpdate
+	update.Topic = req.Topic
+	if req.Visibility != nil {
+		visibility := websocket.Visibility(*req.Visibility)
+		update.Visibility = &visibility
+	}
+	update.MaxClients = req.MaxClients
+	if req.SlowModeSeconds != nil {
+		slowMode := time.Duration(*req.SlowModeSeconds) * time.Second
+		update.SlowMode = &slowMode
+	}
+	if req.RetentionSeconds != nil {
+		retention := time.Duration(*req.RetentionSeconds) * time.Second
+		update.Retention = &retention
+	}
+
+	var fields []websocket.ValidationError
+	for _, fe := range update.Validate() {
+		fields = append(fields, websocket.ValidationError{Field: fe.Field, Message: fe.Message})
+	}
+	if req.HistoryLimit != nil {
+		fields = append(fields, validateHistoryLimit(*req.HistoryLimit)...)
+		historyLimit = *req.HistoryLimit
+	}
+	if len(fields) > 0 {
+		return nil, fields, nil
+	}
+
+	if update.Topic != nil {
+		settings.Topic = *update.Topic
+	}
+	if update.Visibility != nil {
+		settings.Visibility = *update.Visibility
+	}
+	if update.MaxClients != nil {
+		settings.MaxClients = *update.MaxClients
+	}
+	if update.SlowMode != nil {
+		settings.SlowMode = *update.SlowMode
+	}
+	if update.Retention != nil {
+		settings.Retention = *update.Retention
+	}
+
+	opts := []websocket.RoomOption{
+		websocket.WithSettings(settings),
+		websocket.WithHistoryLimit(historyLimit),
+		websocket.WithTags(req.Tags...),
+	}
+	if req.OrderedDelivery {
+		opts = append(opts, websocket.WithOrderedDelivery(websocket.ReplayBufferSize))
+	}
+	policy := s.liveConfig().NewRoomDefaults().SlowConsumer
+	if req.SlowConsumer != "" {
+		policy = req.SlowConsumer
+	}
+	slowConsumer, err := websocket.ParseSlowConsumerPolicy(policy)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts = append(opts, websocket.WithSlowConsumerPolicy(slowConsumer))
+	return opts, nil, nil
+}
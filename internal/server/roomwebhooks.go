@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// RoomMessageEventType is the X-Chatters-Event of room webhook deliveries
+const RoomMessageEventType = "room_message"
+
+// CreateRoomWebhookRequest registers a room webhook
+type CreateRoomWebhookRequest struct {
+	URL string `json:"url" binding:"required,url,max=2048" example:"https://archive.example.com/chatters"`
+}
+
+// CreateRoomWebhookResponse returns the webhook with the secret its deliveries are signed with
+type CreateRoomWebhookResponse struct {
+	Webhook websocket.RoomWebhook `json:"webhook"`
+	Secret  string                `json:"secret" example:"9c1d0f..."`
+}
+
+// dispatchRoomWebhook signs and posts a room message event, retrying like server webhooks
+func (s *Server) dispatchRoomWebhook(hook websocket.RoomWebhook, secret string, event websocket.RoomMessageEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	s.Webhooks.send(Webhook{ID: hook.ID, URL: hook.URL, secret: secret}, RoomMessageEventType, body)
+}
+
+// CreateRoomWebhook godoc
+// @Summary Register room webhook
+// @Description Sends every chat message of the room to url as a room_message event, signed with the returned secret
+// @Description like server webhooks (host only). Up to 20 messages per second are delivered; the dropped field of
+// @Description a delivery counts the messages skipped since the previous one. The URL must use https and resolve
+// @Description to a public address; redirects are not followed.
+// @Tags hooks
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Param request body CreateRoomWebhookRequest true "Webhook definition"
+// @Success 201 {object} CreateRoomWebhookResponse
+// @Failure 400 {object} ErrorResponse "Invalid room ID, or the URL is not https or names a private address"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Room has too many webhooks"
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/rooms/{room_id}/webhooks [post]
+func (s *Server) CreateRoomWebhook() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		var req CreateRoomWebhookRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		err := checkWebhookURL(req.URL)
+		var hook websocket.RoomWebhook
+		var secret string
+		if err == nil {
+			hook, secret, err = room.AddWebhook(req.URL)
+		}
+		if err != nil {
+			status, code := http.StatusInternalServerError, websocket.CodeInternal
+			switch {
+			case errors.Is(err, websocket.ErrTooManyRoomWebhooks):
+				status, code = http.StatusConflict, websocket.CodeTooManyWebhooks
+			case errors.Is(err, websocket.ErrInsecureWebhookURL), errors.Is(err, ErrPrivateAddress):
+				status, code = http.StatusBadRequest, websocket.CodeInvalidWebhookURL
+			}
+			c.JSON(status, ErrorResponse{Code: status, Error: err.Error(), ErrorCode: code})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Room webhook registered",
+			"room_id", room.ID, "webhook_id", hook.ID, "url", hook.URL)
+
+		c.JSON(http.StatusCreated, CreateRoomWebhookResponse{Webhook: hook, Secret: secret})
+	}
+}
+
+// ListRoomWebhooks godoc
+// @Summary List room webhooks
+// @Description Returns the webhooks receiving the messages of the room (host only)
+// @Tags hooks
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Success 200 {array} websocket.RoomWebhook
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/webhooks [get]
+func (s *Server) ListRoomWebhooks() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, room.Webhooks())
+	}
+}
+
+// DeleteRoomWebhook godoc
+// @Summary Remove room webhook
+// @Description Stops mirroring the messages of the room to a webhook (host only)
+// @Tags hooks
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param webhook_id path string true "Webhook ID"
+// @Param Authorization header string true "Host JWT token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/webhooks/{webhook_id} [delete]
+func (s *Server) DeleteRoomWebhook() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+		if !room.RemoveWebhook(c.Param("webhook_id")) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     websocket.ErrRoomWebhookNotFound.Error(),
+				ErrorCode: websocket.CodeWebhookNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "webhook removed"})
+	}
+}
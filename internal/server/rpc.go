@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+	chattersv1 "github.com/YuarenArt/chatters/pkg/rpc/chatters/v1"
+	"github.com/YuarenArt/chatters/pkg/rpc/chatters/v1/chattersv1connect"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// RPC headers browsers must be allowed to send and read for gRPC-Web and Connect
+const (
+	rpcAllowHeaders  = "Connect-Protocol-Version, Connect-Timeout-Ms, Grpc-Timeout, X-Grpc-Web, X-User-Agent"
+	rpcExposeHeaders = "Grpc-Status, Grpc-Message, Grpc-Status-Details-Bin"
+)
+
+// roomService implements the typed room API over the hub. The connect handler
+// serves it over gRPC, gRPC-Web and the Connect protocol, so browsers call it
+// without a proxy.
+type roomService struct {
+	hub *websocket.Hub
+}
+
+// registerRPC routes the RoomService procedures, rate limited as the REST API
+func (s *Server) registerRPC() {
+	path, handler := chattersv1connect.NewRoomServiceHandler(&roomService{hub: s.Handler.Hub})
+	rpc := s.Engine.Group(path, s.rateLimit(s.APILimiter, APILimiterName))
+	// GET serves the side-effect-free procedures to Connect clients using HTTP GET
+	rpc.POST("/*procedure", gin.WrapH(handler))
+	rpc.GET("/*procedure", gin.WrapH(handler))
+}
+
+// GetRoom returns the directory view of a room
+func (rs *roomService) GetRoom(_ context.Context, req *connect.Request[chattersv1.GetRoomRequest]) (*connect.Response[chattersv1.GetRoomResponse], error) {
+	roomID := websocket.ID(req.Msg.GetRoomId())
+	if roomID < MinRoomID || roomID > MaxRoomID {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("room ID out of valid range"))
+	}
+	room, exists := rs.hub.GetRoom(roomID)
+	if !exists {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("room not found"))
+	}
+	return connect.NewResponse(&chattersv1.GetRoomResponse{Room: roomMessage(room.Summary())}), nil
+}
+
+// ListRooms lists the public rooms, paginated with the cursors of the REST directory
+func (rs *roomService) ListRooms(_ context.Context, req *connect.Request[chattersv1.ListRoomsRequest]) (*connect.Response[chattersv1.ListRoomsResponse], error) {
+	pageReq := PageRequest{Cursor: req.Msg.GetCursor(), Limit: DefaultPageLimit}
+	if size := req.Msg.GetPageSize(); size < 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("page_size must be a positive integer"))
+	} else if size > 0 {
+		pageReq.Limit = min(int(size), MaxPageLimit)
+	}
+
+	rooms := rs.hub.ListRooms(websocket.RoomFilter{
+		Visibility: websocket.VisibilityPublic,
+		Tag:        req.Msg.GetTag(),
+	})
+	page, err := paginate(rooms, pageReq)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	resp := &chattersv1.ListRoomsResponse{
+		Rooms:      make([]*chattersv1.Room, 0, len(page.Items)),
+		NextCursor: page.NextCursor,
+		Total:      int32(page.Total),
+	}
+	for _, summary := range page.Items {
+		resp.Rooms = append(resp.Rooms, roomMessage(summary))
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// roomMessage converts a directory summary to its RPC message
+func roomMessage(summary websocket.RoomSummary) *chattersv1.Room {
+	return &chattersv1.Room{
+		RoomId:      uint32(summary.ID),
+		Topic:       summary.Topic,
+		Visibility:  string(summary.Visibility),
+		Tags:        summary.Tags,
+		ClientCount: int32(summary.ClientCount),
+		HasPassword: summary.HasPassword,
+		Locked:      summary.Locked,
+	}
+}
+
+var _ chattersv1connect.RoomServiceHandler = (*roomService)(nil)
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/YuarenArt/chatters/internal/config"
+	"github.com/YuarenArt/chatters/internal/logging"
+	chattersv1 "github.com/YuarenArt/chatters/pkg/rpc/chatters/v1"
+	"github.com/YuarenArt/chatters/pkg/rpc/chatters/v1/chattersv1connect"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoomService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hub := websocket.NewHub()
+	s := &Server{
+		Handler: *websocket.NewHandler(hub, nil),
+		Engine:  gin.New(),
+		Logger:  logging.NewLogger(),
+		Config:  &config.Config{},
+	}
+	s.registerRPC()
+	for id, opts := range map[websocket.ID][]websocket.RoomOption{
+		1: {websocket.WithVisibility(websocket.VisibilityPublic), websocket.WithTags("golang")},
+		2: {websocket.WithVisibility(websocket.VisibilityPublic)},
+		3: nil,
+	} {
+		room, _ := hub.CreateRoom(id, nil, opts...)
+		defer room.StopRoom()
+	}
+
+	srv := httptest.NewUnstartedServer(s.Engine)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	clients := map[string]chattersv1connect.RoomServiceClient{
+		"connect":  chattersv1connect.NewRoomServiceClient(srv.Client(), srv.URL),
+		"get":      chattersv1connect.NewRoomServiceClient(srv.Client(), srv.URL, connect.WithHTTPGet()),
+		"grpc":     chattersv1connect.NewRoomServiceClient(srv.Client(), srv.URL, connect.WithGRPC()),
+		"grpc-web": chattersv1connect.NewRoomServiceClient(srv.Client(), srv.URL, connect.WithGRPCWeb()),
+	}
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			room, err := client.GetRoom(ctx, connect.NewRequest(&chattersv1.GetRoomRequest{RoomId: 1}))
+			require.NoError(t, err)
+			assert.Equal(t, uint32(1), room.Msg.GetRoom().GetRoomId())
+			assert.Equal(t, []string{"golang"}, room.Msg.GetRoom().GetTags())
+
+			_, err = client.GetRoom(ctx, connect.NewRequest(&chattersv1.GetRoomRequest{RoomId: 999}))
+			assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+
+			first, err := client.ListRooms(ctx, connect.NewRequest(&chattersv1.ListRoomsRequest{PageSize: 1}))
+			require.NoError(t, err)
+			require.Len(t, first.Msg.GetRooms(), 1)
+			assert.Equal(t, int32(2), first.Msg.GetTotal(), "private rooms are not listed")
+			require.NotEmpty(t, first.Msg.GetNextCursor())
+
+			second, err := client.ListRooms(ctx, connect.NewRequest(&chattersv1.ListRoomsRequest{PageSize: 1, Cursor: first.Msg.GetNextCursor()}))
+			require.NoError(t, err)
+			require.Len(t, second.Msg.GetRooms(), 1)
+			assert.Equal(t, uint32(2), second.Msg.GetRooms()[0].GetRoomId())
+			assert.Empty(t, second.Msg.GetNextCursor())
+
+			_, err = client.ListRooms(ctx, connect.NewRequest(&chattersv1.ListRoomsRequest{Cursor: "bogus"}))
+			assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+		})
+	}
+}
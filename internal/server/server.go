@@ -3,10 +3,13 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/rand"
 	"net/http"
+	"net/netip"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/YuarenArt/chatters/internal/config"
@@ -15,9 +18,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
+	"github.com/quic-go/quic-go/http3"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type CreateRoomResponse struct {
@@ -26,25 +29,42 @@ type CreateRoomResponse struct {
 }
 
 type RoomResponse struct {
-	HostID      string       `json:"host_id,omitempty"`
-	ClientCount int          `json:"client_count"`
-	RoomID      websocket.ID `json:"room_id"`
-	HasPassword bool         `json:"has_password"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+	HostIDs         []string          `json:"host_ids,omitempty"`
+	ClosesAt        *time.Time        `json:"closes_at,omitempty"`
+	HostID          string            `json:"host_id,omitempty"`
+	Topic           string            `json:"topic,omitempty"`
+	SettingsVersion uint64            `json:"settings_version"`
+	ClientCount     int               `json:"client_count"`
+	RoomID          websocket.ID      `json:"room_id"`
+	HasPassword     bool              `json:"has_password"`
+	Locked          bool              `json:"locked"`
+	OrderedDelivery bool              `json:"ordered_delivery"`
+	SlowConsumer    string            `json:"slow_consumer" example:"disconnect"`
 }
 
-type ErrorResponse struct {
-	Error string `json:"error"`
-	Code  int    `json:"code"`
-}
+// ErrorResponse is the error body of every endpoint, shared with the WebSocket handler
+type ErrorResponse = websocket.ErrorResponse
 
 type Server struct {
-	Handler    websocket.Handler
-	Logger     logging.Logger
-	Engine     *gin.Engine
-	Metrics    *Metrics
-	Config     *config.Config
-	Addr       string
-	Middleware []gin.HandlerFunc
+	Handler     websocket.Handler
+	Logger      logging.Logger
+	Engine      *gin.Engine
+	Metrics     *Metrics
+	Config      *config.Config
+	Idempotency *IdempotencyStore
+	APILimiter  *RateLimiter             // nil when /api is not rate limited
+	Sensitive   *RateLimiter             // limits room creation and password checks, nil if unlimited
+	Tenants     *websocket.TenantTracker // nil when API keys are not configured
+	TokenKeys   *websocket.TokenKeys
+	Webhooks    *WebhookDispatcher
+	Passwords   *websocket.PasswordHasher
+	Addr        string
+	Middleware  []gin.HandlerFunc
+	spools      *websocket.SpoolBudget         // shared by the slow-consumer spools of all rooms
+	live        *atomic.Pointer[config.Config] // latest config, replaced by ApplyConfig
+	startedAt   time.Time
 }
 
 // Validation constants
@@ -53,31 +73,73 @@ const (
 	MinRoomID = 1         // Minimum room ID value
 )
 
-// hashPassword hashes a password using bcrypt with optimized cost for performance
-func hashPassword(password string) (string, error) {
-	// Using cost 4 for better performance during testing/development
-	// DefaultCost (10) is too expensive for high-load scenarios
-	const bcryptCost = 4
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
-	return string(bytes), err
+// CheckTrustedProxies reports a trusted proxy that is neither an IP nor a CIDR
+func CheckTrustedProxies(cfg *config.Config) error {
+	for _, proxy := range cfg.TrustedProxyList() {
+		if _, err := netip.ParsePrefix(proxy); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(proxy); err != nil {
+			return fmt.Errorf("invalid trusted proxy %q", proxy)
+		}
+	}
+	return nil
+}
+
+// newEngine creates the gin engine. Client IPs, which rate limits, connection
+// caps, bans and pseudonyms rely on, are only read from X-Forwarded-For when a
+// trusted proxy sent the request.
+func newEngine(cfg *config.Config) (*gin.Engine, error) {
+	engine := gin.New()
+	return engine, engine.SetTrustedProxies(cfg.TrustedProxyList())
+}
+
+// passwordHasher returns the room password hasher with the configured pepper.
+// A pepper file that cannot be read is reported at startup.
+func passwordHasher(cfg *config.Config) *websocket.PasswordHasher {
+	pepper, _ := cfg.Pepper()
+	return websocket.NewPasswordHasher(pepper)
 }
 
 func NewServer(addr string, handler websocket.Handler, serverLogger logging.Logger, cfg *config.Config) *Server {
 	apiLogger, _ := logging.NewFileLogger("logs/api.log", false)
 
-	engine := gin.New()
+	engine, err := newEngine(cfg)
+	if err != nil {
+		serverLogger.Log(context.Background(), logging.Error, "Trusted proxies not applied", "error", err.Error())
+	}
 	engine.Use(gin.Recovery())
+	engine.Use(TracingMiddleware())
 
 	metrics := NewMetrics()
 	engine.Use(metrics.PrometheusMiddleware())
+	handler.AuthMetrics = metrics
+	if handler.Compression != nil {
+		handler.Compression.Metrics = metrics
+	}
 
-	// Add CORS middleware
+	live := new(atomic.Pointer[config.Config])
+	live.Store(cfg)
+	origins := handler.Origins
+	origins.Set(cfg.AllowedOriginList())
+
+	// Add CORS middleware. A wildcard allowlist admits any origin without
+	// credentials; listed origins are echoed back and may send credentials.
 	engine.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
+		origin := c.GetHeader("Origin")
+		switch {
+		case origins.AllowsAny():
+			c.Header("Access-Control-Allow-Origin", "*")
+		case origin != "" && origins.Allows(origin):
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Allow-Credentials", "true")
+			c.Header("Vary", "Origin")
+		default:
+			c.Header("Vary", "Origin")
+		}
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, HEAD, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Length, Content-Type, Authorization")
-		c.Header("Access-Control-Expose-Headers", "Content-Length")
-		c.Header("Access-Control-Allow-Credentials", "true")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Length, Content-Type, Authorization, If-Match, "+IdempotencyKeyHeader+", "+RoomPasswordHeader+", "+APIKeyHeader+", "+AdminKeyHeader+", "+HookTokenHeader+", "+rpcAllowHeaders)
+		c.Header("Access-Control-Expose-Headers", "Content-Length, ETag, "+IdempotentReplayHeader+", "+rpcExposeHeaders)
 		c.Header("Access-Control-Max-Age", "43200") // 12 hours
 
 		if c.Request.Method == "OPTIONS" {
@@ -92,8 +154,9 @@ func NewServer(addr string, handler websocket.Handler, serverLogger logging.Logg
 	engine.Use(func(c *gin.Context) {
 		if c.Request.ContentLength > 10*1024*1024 { // 10MB limit
 			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
-				Code:  http.StatusRequestEntityTooLarge,
-				Error: "request too large",
+				Code:      http.StatusRequestEntityTooLarge,
+				Error:     "request too large",
+				ErrorCode: websocket.CodeRequestTooLarge,
 			})
 			c.Abort()
 			return
@@ -102,24 +165,41 @@ func NewServer(addr string, handler websocket.Handler, serverLogger logging.Logg
 	})
 
 	engine.Use(APILoggerMiddleware(apiLogger))
+	engine.Use(SessionMiddleware(cfg.DerivedKey(config.KeyPurposeSession)))
 	engine.GET("/metrics", metrics.MetricsHandler())
 
 	s := &Server{
-		Handler: handler,
-		Engine:  engine,
-		Addr:    addr,
-		Logger:  serverLogger,
-		Metrics: metrics,
-		Config:  cfg,
+		Handler:     handler,
+		Engine:      engine,
+		Addr:        addr,
+		Logger:      serverLogger,
+		Metrics:     metrics,
+		Config:      cfg,
+		Idempotency: NewIdempotencyStore(cfg.IdempotencyWindow()),
+		APILimiter:  NewRateLimiter(cfg.APIRateLimits()),
+		Sensitive:   NewRateLimiter(cfg.SensitiveRateLimits()),
+		TokenKeys:   tokenKeys(cfg),
+		Webhooks:    NewWebhookDispatcher(serverLogger),
+		Passwords:   passwordHasher(cfg),
+		spools:      websocket.NewSpoolBudget(cfg.SpoolBudgetBytes()),
+		live:        live,
+		startedAt:   time.Now(),
 	}
+	for _, url := range cfg.WebhookURLList() {
+		if _, _, err := s.Webhooks.Add(url, cfg.WebhookEventList(), cfg.WebhookSecret, true); err != nil {
+			serverLogger.Log(context.Background(), logging.Error, "Webhook not registered", "url", url, "error", err.Error())
+		}
+	}
+	if handler.Hub.Events == nil {
+		handler.Hub.Events = websocket.NewEventBus()
+	}
+	handler.Hub.Events.Subscribe(s.Webhooks.Publish)
+	rooms, connections, messages, window := cfg.TenantLimits()
+	s.Tenants = newTenantTracker(cfg.APIKeyList(), rooms, connections, messages, window)
 
 	s.registerRoutes()
 
-	engine.StaticFS("/static", http.Dir("web/static"))
-
-	engine.GET("/", func(c *gin.Context) {
-		c.File("web/static/index.html")
-	})
+	s.registerStatic()
 
 	return s
 }
@@ -133,16 +213,102 @@ func NewServer(addr string, handler websocket.Handler, serverLogger logging.Logg
 // @Router /api/health [get]
 func (s *Server) registerRoutes() {
 
-	s.Engine.GET("/ws/:room_id", s.Handler.HandleWebSocketWithJWT(s.Config.JWTSecret))
+	s.Engine.GET("/ws/:room_id", s.Handler.HandleWebSocketWithKeys(s.TokenKeys))
+	if s.Config.EngineIO {
+		engineIO := s.Handler.HandleEngineIOWithKeys(s.TokenKeys)
+		s.Engine.GET("/socket.io/", engineIO)
+		s.Engine.POST("/socket.io/", engineIO)
+	}
+	if s.Config.WebTransport {
+		s.Handler.EnableWebTransport(s.Engine)
+		s.Engine.Handle(http.MethodConnect, "/wt/:room_id", s.Handler.HandleWebTransportWithKeys(s.TokenKeys))
+	}
+	s.registerRPC()
 	api := s.Engine.Group("/api")
-
-	api.POST("/rooms", s.CreateRoom())
+	api.Use(s.rateLimit(s.APILimiter, APILimiterName))
+	api.Use(IdempotencyMiddleware(s.Idempotency))
+	sensitive := s.rateLimit(s.Sensitive, SensitiveLimiterName)
+
+	api.GET("/session", s.Session())
+	api.GET("/branding", s.Branding())
+	api.GET("/usage", s.Usage())
+	api.GET("/usernames/suggest", s.SuggestUsernames())
+	api.POST("/reports", s.BugReport())
+	api.GET("/rooms", s.ListRooms())
+	api.POST("/rooms", sensitive, s.CreateRoom())
 	api.GET("/rooms/:room_id", s.Room())
-	api.POST("/rooms/:room_id/validate-password", s.ValidatePassword())
+	api.GET("/rooms/:room_id/stats", s.RoomStats())
+	api.GET("/rooms/:room_id/members", s.RoomMembers())
+	api.GET("/rooms/:room_id/messages/search", s.SearchMessages())
+	api.GET("/rooms/:room_id/events", s.RoomEvents())
+	api.POST("/rooms/:room_id/validate-password", sensitive, s.ValidatePassword())
+	api.POST("/rooms/:room_id/join-ticket", s.JoinTicket())
+	api.POST("/rooms/:room_id/refresh-token", s.RefreshHostToken())
 	api.POST("/rooms/:room_id/kick", s.KickUser())
+	api.POST("/rooms/:room_id/kick-bulk", s.KickBulk())
+	api.POST("/rooms/:room_id/bans", s.CreateBan())
+	api.GET("/rooms/:room_id/bans", s.ListBans())
+	api.DELETE("/rooms/:room_id/bans", s.DeleteBan())
+	api.POST("/rooms/:room_id/mute", s.MuteUser())
+	api.POST("/rooms/:room_id/unmute", s.UnmuteUser())
+	api.POST("/rooms/:room_id/freeze", s.FreezeRoom())
+	api.POST("/rooms/:room_id/unfreeze", s.UnfreezeRoom())
+	api.POST("/rooms/:room_id/lock", s.LockRoom())
+	api.POST("/rooms/:room_id/unlock", s.UnlockRoom())
 	api.PUT("/rooms/:room_id/password", s.ChangePassword())
+	api.GET("/rooms/:room_id/password-attempts", s.PasswordAttempts())
+	api.GET("/rooms/:room_id/access-log", s.RoomAccessLog())
+	api.PATCH("/rooms/:room_id/settings", s.UpdateRoomSettings())
+	api.GET("/rooms/:room_id/filter", s.RoomContentFilter())
+	api.PUT("/rooms/:room_id/filter", s.SetRoomContentFilter())
+	api.GET("/rooms/:room_id/automod", s.RoomModRules())
+	api.PUT("/rooms/:room_id/automod", s.SetRoomModRules())
+	api.GET("/rooms/:room_id/metadata", s.RoomMetadata())
+	api.PUT("/rooms/:room_id/metadata/:key", s.SetRoomMetadata())
+	api.DELETE("/rooms/:room_id/metadata/:key", s.DeleteRoomMetadata())
+	api.POST("/rooms/:room_id/messages", s.PostMessage())
+	api.POST("/rooms/:room_id/messages/import", s.ImportMessages())
+	api.POST("/rooms/:room_id/uploads", sensitive, s.UploadFile())
+	api.POST("/rooms/:room_id/hooks", s.CreateHook())
+	api.GET("/rooms/:room_id/hooks", s.ListHooks())
+	api.DELETE("/rooms/:room_id/hooks/:hook_id", s.DeleteHook())
+	api.POST("/rooms/:room_id/hooks/:hook_id/reply", s.HookReply())
+	api.POST("/rooms/:room_id/webhooks", s.CreateRoomWebhook())
+	api.GET("/rooms/:room_id/webhooks", s.ListRoomWebhooks())
+	api.DELETE("/rooms/:room_id/webhooks/:webhook_id", s.DeleteRoomWebhook())
+	api.POST("/rooms/:room_id/host/transfer", s.TransferHost())
+	api.POST("/rooms/:room_id/breakouts", s.CreateBreakout())
+	api.GET("/rooms/:room_id/breakouts", s.ListBreakouts())
+	api.POST("/rooms/:room_id/breakouts/recall", s.RecallBreakouts())
+	api.POST("/rooms/:room_id/breakouts/:breakout_id/move", s.MoveToBreakout())
+	api.POST("/rooms/:room_id/close", s.CloseRoom())
 	api.DELETE("/rooms/:room_id", s.DeleteRoom())
 
+	if local, ok := s.Handler.Hub.Uploads.(*websocket.LocalUploadStorage); ok && local.BasePath() != "/" {
+		s.Engine.GET(local.BasePath()+"*filepath", gin.WrapH(local))
+		s.Engine.HEAD(local.BasePath()+"*filepath", gin.WrapH(local))
+	}
+
+	admin := api.Group("/admin", s.requireAdmin())
+	admin.GET("/stats", s.AdminStats())
+	admin.GET("/rooms", s.AdminRooms())
+	admin.DELETE("/rooms/:room_id", s.AdminDeleteRoom())
+	admin.POST("/rooms/:room_id/disconnect", s.AdminDisconnect())
+	admin.GET("/rooms/:room_id/access-log", s.AdminRoomAccessLog())
+	admin.GET("/connections", s.AdminConnections())
+	admin.POST("/connections/disconnect", s.AdminDisconnectMatching())
+	admin.GET("/connections/:conn_id", s.AdminConnection())
+	admin.DELETE("/connections/:conn_id", s.AdminCloseConnection())
+	admin.GET("/bans", s.AdminListBans())
+	admin.POST("/bans", s.AdminCreateBan())
+	admin.DELETE("/bans", s.AdminDeleteBan())
+	admin.POST("/rooms/:room_id/migrate", s.AdminMigrateRoom())
+	admin.POST("/rooms/import", s.AdminImportRoom())
+	admin.POST("/rooms/:room_id/messages/import", s.AdminImportMessages())
+	admin.GET("/webhooks", s.AdminWebhooks())
+	admin.POST("/webhooks", s.AdminCreateWebhook())
+	admin.DELETE("/webhooks/:webhook_id", s.AdminDeleteWebhook())
+
 	s.Engine.GET("/api/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 		s.Logger.Log(c.Request.Context(), logging.Debug, "Health check", "status", "ok")
@@ -155,35 +321,84 @@ func (s *Server) registerRoutes() {
 
 // Run starts HTTP server with graceful shutdown support.
 func (s *Server) Run(ctx context.Context) error {
-	s.Logger.Log(ctx, logging.Info, "Starting server", "addr", s.Addr)
-
+	tlsConfig, redirect, err := newTLSConfig(s.Config)
+	if err != nil {
+		return err
+	}
+	if s.Config.WebTransport && tlsConfig == nil {
+		return errors.New("WEBTRANSPORT_ENABLED requires TLS_CERT_FILE or AUTOCERT_DOMAINS")
+	}
+	s.Logger.Log(ctx, logging.Info, "Starting server", "addr", s.Addr, "tls", tlsConfig != nil)
+
+	readTimeout, readHeaderTimeout, writeTimeout, idleTimeout := s.Config.ServerTimeouts()
+	// Plaintext HTTP/2 lets native gRPC clients reach the RPC API without TLS
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
 	srv := &http.Server{
 		Addr:              s.Addr,
 		Handler:           s.Engine,
-		ReadTimeout:       10 * time.Second,
-		ReadHeaderTimeout: 5 * time.Second,
-		WriteTimeout:      20 * time.Second,
-		IdleTimeout:       120 * time.Second,
+		TLSConfig:         tlsConfig,
+		Protocols:         protocols,
+		ReadTimeout:       readTimeout,
+		ReadHeaderTimeout: readHeaderTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
 	}
+	servers := []*http.Server{srv}
 
-	errCh := make(chan error, 1)
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	errCh := make(chan error, 3)
+	serve := func(listen func() error) {
+		if err := listen(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			errCh <- err
 		}
-		close(errCh)
-	}()
+	}
+	if tlsConfig != nil {
+		// The certificates come from TLSConfig
+		go serve(func() error { return srv.ListenAndServeTLS("", "") })
+	} else {
+		go serve(srv.ListenAndServe)
+	}
+	if redirect != nil && s.Config.HTTPRedirectAddr != "" {
+		redirectSrv := &http.Server{
+			Addr:              s.Config.HTTPRedirectAddr,
+			Handler:           redirect,
+			ReadTimeout:       readTimeout,
+			ReadHeaderTimeout: readHeaderTimeout,
+			WriteTimeout:      writeTimeout,
+			IdleTimeout:       idleTimeout,
+		}
+		servers = append(servers, redirectSrv)
+		s.Logger.Log(ctx, logging.Info, "Redirecting HTTP to HTTPS", "addr", redirectSrv.Addr)
+		go serve(redirectSrv.ListenAndServe)
+	}
 
-	<-ctx.Done()
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	if wt := s.Handler.WebTransport; wt != nil {
+		wt.H3.Addr = s.Addr
+		wt.H3.TLSConfig = http3.ConfigureTLSConfig(tlsConfig)
+		s.Logger.Log(ctx, logging.Info, "Serving WebTransport over HTTP/3", "addr", s.Addr)
+		go serve(wt.ListenAndServe)
+		defer wt.Close()
+	}
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+	case serveErr = <-errCh:
+		s.Logger.Log(ctx, logging.Error, "HTTP server failed", "error", serveErr)
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.Config.HTTPShutdownWindow())
 	defer cancel()
 
 	s.Logger.Log(ctx, logging.Info, "Shutting down HTTP server gracefully")
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		s.Logger.Log(ctx, logging.Error, "HTTP shutdown error", "error", err)
-		return err
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			s.Logger.Log(ctx, logging.Error, "HTTP shutdown error", "error", err)
+			return err
+		}
 	}
-	return nil
+	return serveErr
 }
 
 func (s *Server) Use(ctx context.Context, mw ...gin.HandlerFunc) {
@@ -201,22 +416,12 @@ func validateRoomID(roomIDStr string) (websocket.ID, error) {
 
 	roomID := websocket.ID(roomIDUint)
 	if roomID < MinRoomID || roomID > MaxRoomID {
-		return 0, &ValidationError{Field: "room_id", Message: "room ID out of valid range"}
+		return 0, &websocket.ValidationError{Field: "room_id", Message: "room ID out of valid range"}
 	}
 
 	return roomID, nil
 }
 
-// ValidationError represents validation errors
-type ValidationError struct {
-	Field   string `json:"field"`
-	Message string `json:"message"`
-}
-
-func (e *ValidationError) Error() string {
-	return e.Message
-}
-
 func APILoggerMiddleware(logger logging.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := uuid.New().String()
@@ -247,32 +452,48 @@ func APILoggerMiddleware(logger logging.Logger) gin.HandlerFunc {
 	}
 }
 
+// CreateRoomRequest creates a room. Omitted settings take the server defaults.
 type CreateRoomRequest struct {
-	Password string `json:"password,omitempty" example:"mypassword123"`
+	Topic            *string `json:"topic,omitempty" binding:"omitempty,max=200" example:"Weekly sync"`
+	Visibility       *string `json:"visibility,omitempty" binding:"omitempty,oneof=private public" example:"public"`
+	SlowModeSeconds  *int    `json:"slow_mode_seconds,omitempty" binding:"omitempty,min=0,max=3600" example:"5"`
+	RetentionSeconds *int    `json:"retention_seconds,omitempty" binding:"omitempty,min=0,max=2592000" example:"86400"`
+	HistoryLimit     *int    `json:"history_limit,omitempty" binding:"omitempty,min=0,max=10000" example:"500"`
+	MaxClients       *int    `json:"max_clients,omitempty" binding:"omitempty,min=0,max=10000" example:"50"`
+	OrderedDelivery  bool    `json:"ordered_delivery,omitempty" example:"false"`
+	SlowConsumer     string  `json:"slow_consumer,omitempty" binding:"omitempty,oneof=disconnect drop_oldest buffer_to_disk" example:"drop_oldest"`
+	Password         string  `json:"password,omitempty" binding:"max=72" example:"mypassword123"`
+
+	Tags []string `json:"tags,omitempty" binding:"omitempty,max=10,dive,min=1,max=32" example:"golang"`
 }
 
 type ValidatePasswordRequest struct {
-	Password string `json:"password" example:"mypassword123"`
+	Password string `json:"password" binding:"max=72" example:"mypassword123"`
 }
 
 type KickUserRequest struct {
-	Username string `json:"username" example:"john_doe"`
+	Username string `json:"username" binding:"required,max=50" example:"john_doe"`
 }
 
 type ChangePasswordRequest struct {
-	NewPassword string `json:"new_password" example:"newpassword456"`
+	NewPassword string `json:"new_password" binding:"max=72" example:"newpassword456"`
 }
 
 // CreateRoom godoc
 // @Summary Create a new room
-// @Description Generates and creates a new room with a random ID. Optionally set a password for the room.
+// @Description Generates and creates a new room with a random ID. Optionally set a password and override the default room settings.
 // @Tags rooms
 // @Accept json
 // @Produce json
-// @Param request body CreateRoomRequest false "Room creation request with optional password"
+// @Param Idempotency-Key header string false "Key to safely retry the request without creating a duplicate room"
+// @Param X-API-Key header string false "API key, required when API keys are configured"
+// @Param request body CreateRoomRequest false "Room creation request with optional password and settings"
 // @Success 201 {object} CreateRoomResponse "Room created successfully with host token"
 // @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Missing or unknown API key"
+// @Failure 429 {object} ErrorResponse "Room quota or rate limit exceeded"
 // @Failure 500 {object} ErrorResponse "Server error"
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
 // @Router /api/rooms [post]
 func (s *Server) CreateRoom() func(c *gin.Context) {
 	return func(c *gin.Context) {
@@ -285,21 +506,48 @@ func (s *Server) CreateRoom() func(c *gin.Context) {
 			"user_agent", c.Request.UserAgent())
 
 		var req CreateRoomRequest
-		// Handle empty request body (optional password)
-		if c.Request.ContentLength > 0 {
-			if err := c.BindJSON(&req); err != nil {
-				c.JSON(http.StatusBadRequest, ErrorResponse{
-					Code:  http.StatusBadRequest,
-					Error: "invalid request body",
-				})
-				return
-			}
+		// Empty request body is allowed (optional password)
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		tenant, ok := s.requireTenant(c)
+		if !ok {
+			return
+		}
+
+		settingsOpts, fields, err := s.roomOptions(req)
+		if err != nil {
+			s.Logger.Log(ctx, logging.Error, "Invalid default room options", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:      http.StatusInternalServerError,
+				Error:     "invalid default room options",
+				ErrorCode: websocket.CodeInternal,
+			})
+			return
+		}
+		if len(fields) > 0 {
+			respondValidationErrors(c, fields)
+			return
+		}
+
+		releaseRoom, ok := s.Tenants.AcquireRoom(tenant)
+		if !ok {
+			c.JSON(http.StatusTooManyRequests, ErrorResponse{
+				Code:      http.StatusTooManyRequests,
+				Error:     "room quota exceeded",
+				ErrorCode: websocket.CodeQuotaExceeded,
+			})
+			return
+		}
+		if s.Tenants != nil {
+			settingsOpts = append(settingsOpts, websocket.WithTenant(s.Tenants, tenant, releaseRoom))
 		}
 
 		var roomID websocket.ID
 		var created bool
 		maxRetries := 100
-		var hostToken *jwt.Token
+		var hostClaims jwt.MapClaims
 
 		for i := 0; i < maxRetries; i++ {
 			roomID = websocket.ID(rand.Uint32())
@@ -310,24 +558,22 @@ func (s *Server) CreateRoom() func(c *gin.Context) {
 			// Generate host ID for the room creator
 			hostID := uuid.New().String()
 
-			hostToken = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-				"room_id": roomID,
-				"host_id": hostID,
-				"host":    true,
-				"exp":     time.Now().Add(24 * time.Hour).Unix(),
-			})
+			hostClaims = websocket.NewHostClaims(roomID, hostID, time.Now())
 
 			// Prepare room options
-			var opts []websocket.RoomOption
+			opts := append([]websocket.RoomOption{}, settingsOpts...)
 			opts = append(opts, websocket.WithHost(hostID))
+			opts = append(opts, s.serverRoomOptions()...)
 
 			if req.Password != "" {
-				hashedPassword, err := hashPassword(req.Password)
+				hashedPassword, err := s.Passwords.Hash(req.Password)
 				if err != nil {
+					releaseRoom()
 					s.Logger.Log(ctx, logging.Error, "Failed to hash password", "error", err.Error())
 					c.JSON(http.StatusInternalServerError, ErrorResponse{
-						Code:  http.StatusInternalServerError,
-						Error: "failed to process password",
+						Code:      http.StatusInternalServerError,
+						Error:     "failed to process password",
+						ErrorCode: websocket.CodeInternal,
 					})
 					return
 				}
@@ -341,22 +587,25 @@ func (s *Server) CreateRoom() func(c *gin.Context) {
 		}
 
 		if !created {
+			releaseRoom()
 			s.Logger.Log(ctx, logging.Error, "Failed to create room after retries",
 				"max_retries", maxRetries)
 			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Code:  http.StatusInternalServerError,
-				Error: "failed to create room after multiple attempts",
+				Code:      http.StatusInternalServerError,
+				Error:     "failed to create room after multiple attempts",
+				ErrorCode: websocket.CodeInternal,
 			})
 			return
 		}
 
 		// Sign the JWT token
-		tokenString, err := hostToken.SignedString([]byte(s.Config.JWTSecret))
+		tokenString, err := s.TokenKeys.Sign(hostClaims)
 		if err != nil {
 			s.Logger.Log(ctx, logging.Error, "Failed to sign JWT token", "error", err.Error())
 			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Code:  http.StatusInternalServerError,
-				Error: "failed to generate host token",
+				Code:      http.StatusInternalServerError,
+				Error:     "failed to generate host token",
+				ErrorCode: websocket.CodeInternal,
 			})
 			return
 		}
@@ -369,12 +618,13 @@ func (s *Server) CreateRoom() func(c *gin.Context) {
 
 // Room godoc
 // @Summary Get room info
-// @Description Returns room information by ID
+// @Description Returns room information by ID. The ETag header carries the room settings version.
 // @Tags rooms
 // @Accept json
 // @Produce json
 // @Param room_id path int true "Room ID"
 // @Success 200 {object} RoomResponse
+// @Header 200 {string} ETag "Room settings version"
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /api/rooms/{room_id} [get]
@@ -395,8 +645,9 @@ func (s *Server) Room() func(c *gin.Context) {
 			s.Logger.Log(ctx, logging.Warn, "Invalid room ID provided",
 				"room_id", roomIDStr, "error", err.Error())
 			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Code:  http.StatusBadRequest,
-				Error: "invalid room ID format or out of range",
+				Code:      http.StatusBadRequest,
+				Error:     "invalid room ID format or out of range",
+				ErrorCode: websocket.CodeInvalidRoomID,
 			})
 			return
 		}
@@ -406,19 +657,31 @@ func (s *Server) Room() func(c *gin.Context) {
 			s.Logger.Log(ctx, logging.Info, "Room not found",
 				"room_id", roomID, "requested_id", roomIDStr)
 			c.JSON(http.StatusNotFound, ErrorResponse{
-				Code:  http.StatusNotFound,
-				Error: "room not found",
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
 			})
 			return
 		}
 
 		s.Logger.Log(ctx, logging.Info, "Room info retrieved successfully",
 			"room_id", roomID, "client_count", room.GetClientCount())
+		version := room.SettingsVersion()
+		c.Header("ETag", settingsETag(version))
 		c.JSON(http.StatusOK, RoomResponse{
-			RoomID:      room.ID,
-			HasPassword: room.HasPassword(),
-			HostID:      room.GetHostID(),
-			ClientCount: room.GetClientCount(),
+			RoomID:          room.ID,
+			HasPassword:     room.HasPassword(),
+			Locked:          room.IsLocked(),
+			HostID:          room.GetHostID(),
+			HostIDs:         room.HostIDs(),
+			Topic:           room.Settings().Topic,
+			Tags:            room.Tags(),
+			ClientCount:     room.GetClientCount(),
+			SettingsVersion: version,
+			Metadata:        room.Metadata(),
+			OrderedDelivery: room.IsOrdered(),
+			SlowConsumer:    string(room.SlowConsumer()),
+			ClosesAt:        closesAt(room),
 		})
 	}
 }
@@ -433,7 +696,7 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		wg.Add(1)
 		go func(r *websocket.Room) {
 			defer wg.Done()
-			r.StopRoom()
+			r.Shutdown()
 		}(room)
 		return true
 	})
@@ -455,18 +718,25 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	return nil
 }
 
-// validateHostToken validates JWT token and checks if user is host
+// errHostTokenRequired is returned when a request carries no host token
+var errHostTokenRequired = errors.New("host token required")
+
+// validateHostToken validates JWT token and checks if user is host.
+// Rejected tokens are counted in the auth failure metric.
 func (s *Server) validateHostToken(tokenString, roomIDStr string) (*jwt.MapClaims, error) {
+	claims, err := s.parseHostToken(tokenString, roomIDStr)
+	if err != nil {
+		s.hostAuthFailed(err)
+	}
+	return claims, err
+}
+
+func (s *Server) parseHostToken(tokenString, roomIDStr string) (*jwt.MapClaims, error) {
 	if tokenString == "" {
-		return nil, errors.New("host token required")
+		return nil, errHostTokenRequired
 	}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
-		}
-		return []byte(s.Config.JWTSecret), nil
-	})
+	token, err := s.TokenKeys.Parse(tokenString)
 
 	if err != nil || !token.Valid {
 		return nil, errors.New("invalid token")
@@ -477,6 +747,22 @@ func (s *Server) validateHostToken(tokenString, roomIDStr string) (*jwt.MapClaim
 		return nil, errors.New("invalid token claims")
 	}
 
+	if err := checkHostClaims(claims, roomIDStr); err != nil {
+		return nil, err
+	}
+
+	// Tokens of hosts that handed their privileges over are no longer honoured
+	roomID, _ := validateRoomID(roomIDStr)
+	if room, ok := s.Handler.Hub.GetRoom(roomID); ok {
+		if hostID, _ := claims["host_id"].(string); !room.IsHostID(hostID) {
+			return nil, websocket.ErrNotHost
+		}
+	}
+	return &claims, nil
+}
+
+// checkHostClaims verifies that claims belong to a host token for the room
+func checkHostClaims(claims jwt.MapClaims, roomIDStr string) error {
 	// Verify room_id matches - convert to string for comparison
 	var tokenRoomID string
 	switch v := claims["room_id"].(type) {
@@ -485,19 +771,56 @@ func (s *Server) validateHostToken(tokenString, roomIDStr string) (*jwt.MapClaim
 	case string:
 		tokenRoomID = v
 	default:
-		return nil, errors.New("invalid room_id type in token")
+		return errors.New("invalid room_id type in token")
 	}
 
 	if tokenRoomID != roomIDStr {
-		return nil, errors.New("token room_id mismatch")
+		return errors.New("token room_id mismatch")
 	}
 
 	// Verify host claim
 	if host, ok := claims["host"].(bool); !ok || !host {
-		return nil, errors.New("not a host token")
+		return errors.New("not a host token")
 	}
 
-	return &claims, nil
+	return nil
+}
+
+// requireHostRoom resolves the room from the path and checks that the request carries
+// a valid host token for it. On failure the error response is written and ok is false.
+func (s *Server) requireHostRoom(c *gin.Context) (room *websocket.Room, ok bool) {
+	roomIDStr := c.Param("room_id")
+
+	roomID, err := validateRoomID(roomIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Error:     "invalid room ID format",
+			ErrorCode: websocket.CodeInvalidRoomID,
+		})
+		return nil, false
+	}
+
+	if _, err := s.validateHostToken(c.GetHeader("Authorization"), roomIDStr); err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Code:      http.StatusUnauthorized,
+			Error:     "unauthorized: " + err.Error(),
+			ErrorCode: websocket.CodeInvalidToken,
+		})
+		return nil, false
+	}
+
+	room, exists := s.Handler.Hub.GetRoom(roomID)
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:      http.StatusNotFound,
+			Error:     "room not found",
+			ErrorCode: websocket.CodeRoomNotFound,
+		})
+		return nil, false
+	}
+
+	return room, true
 }
 
 // ValidatePassword godoc
@@ -512,6 +835,8 @@ func (s *Server) validateHostToken(tokenString, roomIDStr string) (*jwt.MapClaim
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
 // @Router /api/rooms/{room_id}/validate-password [post]
 func (s *Server) ValidatePassword() func(c *gin.Context) {
 	return func(c *gin.Context) {
@@ -521,8 +846,9 @@ func (s *Server) ValidatePassword() func(c *gin.Context) {
 		roomID, err := validateRoomID(roomIDStr)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Code:  http.StatusBadRequest,
-				Error: "invalid room ID format",
+				Code:      http.StatusBadRequest,
+				Error:     "invalid room ID format",
+				ErrorCode: websocket.CodeInvalidRoomID,
 			})
 			return
 		}
@@ -530,22 +856,16 @@ func (s *Server) ValidatePassword() func(c *gin.Context) {
 		room, exists := s.Handler.Hub.GetRoom(roomID)
 		if !exists {
 			c.JSON(http.StatusNotFound, ErrorResponse{
-				Code:  http.StatusNotFound,
-				Error: "room not found",
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
 			})
 			return
 		}
 
 		var req ValidatePasswordRequest
-		// Handle empty request body
-		if c.Request.ContentLength > 0 {
-			if err := c.BindJSON(&req); err != nil {
-				c.JSON(http.StatusBadRequest, ErrorResponse{
-					Code:  http.StatusBadRequest,
-					Error: "invalid request body",
-				})
-				return
-			}
+		if !bindRequest(c, &req) {
+			return
 		}
 
 		if !room.HasPassword() {
@@ -553,8 +873,11 @@ func (s *Server) ValidatePassword() func(c *gin.Context) {
 			return
 		}
 
-		err = bcrypt.CompareHashAndPassword([]byte(room.HashedPassword), []byte(req.Password))
-		valid := err == nil
+		valid := room.CheckPassword(s.Passwords, req.Password)
+		if !valid && req.Password != "" {
+			room.RecordFailedPassword(c.ClientIP())
+			s.Metrics.AuthFailed(websocket.AuthTransportREST, websocket.AuthFailureInvalidPassword)
+		}
 
 		s.Logger.Log(ctx, logging.Info, "Password validation attempt",
 			"room_id", roomID, "valid", valid)
@@ -571,11 +894,13 @@ func (s *Server) ValidatePassword() func(c *gin.Context) {
 // @Produce json
 // @Param room_id path int true "Room ID"
 // @Param Authorization header string true "Host JWT token"
+// @Param Idempotency-Key header string false "Key to safely retry the request"
 // @Param request body KickUserRequest true "Kick user request"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
 // @Router /api/rooms/{room_id}/kick [post]
 func (s *Server) KickUser() func(c *gin.Context) {
 	return func(c *gin.Context) {
@@ -586,8 +911,9 @@ func (s *Server) KickUser() func(c *gin.Context) {
 		roomID, err := validateRoomID(roomIDStr)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Code:  http.StatusBadRequest,
-				Error: "invalid room ID format",
+				Code:      http.StatusBadRequest,
+				Error:     "invalid room ID format",
+				ErrorCode: websocket.CodeInvalidRoomID,
 			})
 			return
 		}
@@ -595,8 +921,9 @@ func (s *Server) KickUser() func(c *gin.Context) {
 		_, err = s.validateHostToken(hostToken, roomIDStr)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, ErrorResponse{
-				Code:  http.StatusUnauthorized,
-				Error: "unauthorized: " + err.Error(),
+				Code:      http.StatusUnauthorized,
+				Error:     "unauthorized: " + err.Error(),
+				ErrorCode: websocket.CodeInvalidToken,
 			})
 			return
 		}
@@ -604,35 +931,24 @@ func (s *Server) KickUser() func(c *gin.Context) {
 		room, exists := s.Handler.Hub.GetRoom(roomID)
 		if !exists {
 			c.JSON(http.StatusNotFound, ErrorResponse{
-				Code:  http.StatusNotFound,
-				Error: "room not found",
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
 			})
 			return
 		}
 
 		var req KickUserRequest
-		// Handle empty request body
-		if c.Request.ContentLength > 0 {
-			if err := c.BindJSON(&req); err != nil {
-				c.JSON(http.StatusBadRequest, ErrorResponse{
-					Code:  http.StatusBadRequest,
-					Error: "invalid request body",
-				})
-				return
-			}
-		} else {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Code:  http.StatusBadRequest,
-				Error: "username is required",
-			})
+		if !bindRequest(c, &req) {
 			return
 		}
 
 		kicked := room.KickClient(req.Username)
 		if !kicked {
 			c.JSON(http.StatusNotFound, ErrorResponse{
-				Code:  http.StatusNotFound,
-				Error: "user not found in room",
+				Code:      http.StatusNotFound,
+				Error:     "user not found in room",
+				ErrorCode: websocket.CodeUserNotFound,
 			})
 			return
 		}
@@ -646,17 +962,23 @@ func (s *Server) KickUser() func(c *gin.Context) {
 
 // ChangePassword godoc
 // @Summary Change room password
-// @Description Changes the password of a room (host only)
+// @Description Changes the password of a room (host only). Requires If-Match with the current settings ETag.
 // @Tags rooms
 // @Accept json
 // @Produce json
 // @Param room_id path int true "Room ID"
 // @Param Authorization header string true "Host JWT token"
+// @Param If-Match header string true "Settings ETag returned by room info"
+// @Param Idempotency-Key header string false "Key to safely retry the request"
 // @Param request body ChangePasswordRequest true "Change password request"
 // @Success 200 {object} map[string]string
+// @Header 200 {string} ETag "New room settings version"
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse "Settings were changed concurrently"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
 // @Router /api/rooms/{room_id}/password [put]
 func (s *Server) ChangePassword() func(c *gin.Context) {
 	return func(c *gin.Context) {
@@ -667,8 +989,9 @@ func (s *Server) ChangePassword() func(c *gin.Context) {
 		roomID, err := validateRoomID(roomIDStr)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Code:  http.StatusBadRequest,
-				Error: "invalid room ID format",
+				Code:      http.StatusBadRequest,
+				Error:     "invalid room ID format",
+				ErrorCode: websocket.CodeInvalidRoomID,
 			})
 			return
 		}
@@ -676,8 +999,9 @@ func (s *Server) ChangePassword() func(c *gin.Context) {
 		_, err = s.validateHostToken(hostToken, roomIDStr)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, ErrorResponse{
-				Code:  http.StatusUnauthorized,
-				Error: "unauthorized: " + err.Error(),
+				Code:      http.StatusUnauthorized,
+				Error:     "unauthorized: " + err.Error(),
+				ErrorCode: websocket.CodeInvalidToken,
 			})
 			return
 		}
@@ -685,37 +1009,57 @@ func (s *Server) ChangePassword() func(c *gin.Context) {
 		room, exists := s.Handler.Hub.GetRoom(roomID)
 		if !exists {
 			c.JSON(http.StatusNotFound, ErrorResponse{
-				Code:  http.StatusNotFound,
-				Error: "room not found",
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
 			})
 			return
 		}
 
-		var req ChangePasswordRequest
-		// Handle empty request body
-		if c.Request.ContentLength > 0 {
-			if err := c.BindJSON(&req); err != nil {
-				c.JSON(http.StatusBadRequest, ErrorResponse{
-					Code:  http.StatusBadRequest,
-					Error: "invalid request body",
-				})
-				return
+		expectedVersion, err := parseIfMatch(c)
+		if err != nil {
+			status, code := http.StatusBadRequest, websocket.CodeInvalidRequest
+			if errors.Is(err, errIfMatchMissing) {
+				status, code = http.StatusPreconditionRequired, websocket.CodePreconditionRequired
 			}
+			c.JSON(status, ErrorResponse{
+				Code:      status,
+				Error:     err.Error(),
+				ErrorCode: code,
+			})
+			return
+		}
+
+		var req ChangePasswordRequest
+		if !bindRequest(c, &req) {
+			return
 		}
 
 		var hashedPassword string
 		if req.NewPassword != "" {
-			hashedPassword, err = hashPassword(req.NewPassword)
+			hashedPassword, err = s.Passwords.Hash(req.NewPassword)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, ErrorResponse{
-					Code:  http.StatusInternalServerError,
-					Error: "failed to hash password",
+					Code:      http.StatusInternalServerError,
+					Error:     "failed to hash password",
+					ErrorCode: websocket.CodeInternal,
 				})
 				return
 			}
 		}
 
-		room.SetPassword(hashedPassword)
+		version, applied := room.SetPasswordIfVersion(hashedPassword, expectedVersion)
+		c.Header("ETag", settingsETag(version))
+		if !applied {
+			s.Logger.Log(ctx, logging.Info, "Room password change conflict",
+				"room_id", roomID, "expected_version", expectedVersion, "current_version", version)
+			c.JSON(http.StatusPreconditionFailed, ErrorResponse{
+				Code:      http.StatusPreconditionFailed,
+				Error:     "room settings were modified by another request",
+				ErrorCode: websocket.CodeSettingsConflict,
+			})
+			return
+		}
 
 		s.Logger.Log(ctx, logging.Info, "Room password changed",
 			"room_id", roomID, "has_password", req.NewPassword != "")
@@ -732,6 +1076,7 @@ func (s *Server) ChangePassword() func(c *gin.Context) {
 // @Produce json
 // @Param room_id path int true "Room ID"
 // @Param Authorization header string true "Host JWT token"
+// @Param Idempotency-Key header string false "Key to safely retry the request"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
@@ -746,8 +1091,9 @@ func (s *Server) DeleteRoom() func(c *gin.Context) {
 		roomID, err := validateRoomID(roomIDStr)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Code:  http.StatusBadRequest,
-				Error: "invalid room ID format",
+				Code:      http.StatusBadRequest,
+				Error:     "invalid room ID format",
+				ErrorCode: websocket.CodeInvalidRoomID,
 			})
 			return
 		}
@@ -755,8 +1101,9 @@ func (s *Server) DeleteRoom() func(c *gin.Context) {
 		_, err = s.validateHostToken(hostToken, roomIDStr)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, ErrorResponse{
-				Code:  http.StatusUnauthorized,
-				Error: "unauthorized: " + err.Error(),
+				Code:      http.StatusUnauthorized,
+				Error:     "unauthorized: " + err.Error(),
+				ErrorCode: websocket.CodeInvalidToken,
 			})
 			return
 		}
@@ -764,8 +1111,9 @@ func (s *Server) DeleteRoom() func(c *gin.Context) {
 		deleted := s.Handler.Hub.DeleteRoom(roomID)
 		if !deleted {
 			c.JSON(http.StatusNotFound, ErrorResponse{
-				Code:  http.StatusNotFound,
-				Error: "room not found",
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
 			})
 			return
 		}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// SessionCookieName is the cookie carrying the signed anonymous user ID
+	SessionCookieName = "chatters_session"
+
+	sessionCookieMaxAge = 365 * 24 * 60 * 60 // one year, in seconds
+)
+
+// SessionResponse describes the caller's anonymous session
+type SessionResponse struct {
+	UserID string `json:"user_id" example:"0b8f5a5e-5c1e-4a55-9f59-3f0c2b8d9a10"`
+	// Pseudonym identifies the caller for bans and rate limits. It is derived
+	// from the session cookie, or from the caller's network and browser until the
+	// cookie is sent back, and changes every rotation period.
+	Pseudonym string `json:"pseudonym,omitempty" example:"anon_Xr3kQ9vT2mLw8pZc1nYb0A"`
+}
+
+// signSession returns the cookie value for userID: "<id>.<hmac>"
+func signSession(userID string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(userID))
+	return userID + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseSessionCookie verifies the cookie signature and returns the user ID
+func parseSessionCookie(value string, secret []byte) (string, bool) {
+	userID, _, found := strings.Cut(value, ".")
+	if !found || userID == "" {
+		return "", false
+	}
+	if !hmac.Equal([]byte(signSession(userID, secret)), []byte(value)) {
+		return "", false
+	}
+	return userID, true
+}
+
+// SessionMiddleware issues a signed session cookie with a stable anonymous user ID
+// on first contact and exposes the ID to handlers under websocket.SessionIDKey.
+func SessionMiddleware(secret []byte) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cookie, err := c.Cookie(SessionCookieName); err == nil {
+			if userID, ok := parseSessionCookie(cookie, secret); ok {
+				c.Set(websocket.SessionIDKey, userID)
+				c.Set(websocket.SessionReturningKey, true)
+				c.Next()
+				return
+			}
+		}
+
+		userID := uuid.New().String()
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     SessionCookieName,
+			Value:    signSession(userID, secret),
+			Path:     "/",
+			MaxAge:   sessionCookieMaxAge,
+			HttpOnly: true,
+			Secure:   c.Request.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		c.Set(websocket.SessionIDKey, userID)
+		c.Next()
+	}
+}
+
+// Session godoc
+// @Summary Current session
+// @Description Returns the anonymous user ID bound to the session cookie, issuing the cookie if needed,
+// @Description and the pseudonymous ID rooms use for bans and rate limits
+// @Tags session
+// @Produce json
+// @Success 200 {object} SessionResponse
+// @Router /api/session [get]
+func (s *Server) Session() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		resp := SessionResponse{UserID: c.GetString(websocket.SessionIDKey)}
+		if s.Handler.Pseudonyms != nil {
+			var returning string
+			if c.GetBool(websocket.SessionReturningKey) {
+				returning = resp.UserID
+			}
+			resp.Pseudonym, _ = s.Handler.Pseudonyms.Pseudonyms(returning, c.Request, c.ClientIP(), time.Now())
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateRoomSettingsRequest is a partial update of room settings, omitted fields are left unchanged.
+// An empty password removes password protection.
+type UpdateRoomSettingsRequest struct {
+	Password         *string `json:"password,omitempty" binding:"omitempty,max=72" example:"newpassword456"`
+	Topic            *string `json:"topic,omitempty" binding:"omitempty,max=200" example:"Weekly sync"`
+	WelcomeMessage   *string `json:"welcome_message,omitempty" binding:"omitempty,max=1000" example:"Welcome! Please read the pinned agenda."`
+	Visibility       *string `json:"visibility,omitempty" binding:"omitempty,oneof=private public" example:"public"`
+	MaxClients       *int    `json:"max_clients,omitempty" binding:"omitempty,min=0,max=10000" example:"50"`
+	SlowModeSeconds  *int    `json:"slow_mode_seconds,omitempty" binding:"omitempty,min=0,max=3600" example:"5"`
+	RetentionSeconds *int    `json:"retention_seconds,omitempty" binding:"omitempty,min=0,max=2592000" example:"86400"`
+}
+
+// RoomSettingsResponse describes the current room settings
+type RoomSettingsResponse struct {
+	Topic            string       `json:"topic" example:"Weekly sync"`
+	WelcomeMessage   string       `json:"welcome_message" example:"Welcome! Please read the pinned agenda."`
+	Visibility       string       `json:"visibility" example:"public"`
+	SettingsVersion  uint64       `json:"settings_version" example:"3"`
+	MaxClients       int          `json:"max_clients" example:"50"`
+	SlowModeSeconds  int          `json:"slow_mode_seconds" example:"5"`
+	RetentionSeconds int          `json:"retention_seconds" example:"86400"`
+	RoomID           websocket.ID `json:"room_id" example:"123456"`
+	HasPassword      bool         `json:"has_password" example:"true"`
+}
+
+// toUpdate converts the request into a websocket.SettingsUpdate, leaving the password unhashed
+func (r UpdateRoomSettingsRequest) toUpdate() websocket.SettingsUpdate {
+	var update websocket.SettingsUpdate
+	update.Topic = r.Topic
+	update.Welcome = r.WelcomeMessage
+	if r.Visibility != nil {
+		visibility := websocket.Visibility(*r.Visibility)
+		update.Visibility = &visibility
+	}
+	update.MaxClients = r.MaxClients
+	if r.SlowModeSeconds != nil {
+		slowMode := time.Duration(*r.SlowModeSeconds) * time.Second
+		update.SlowMode = &slowMode
+	}
+	if r.RetentionSeconds != nil {
+		retention := time.Duration(*r.RetentionSeconds) * time.Second
+		update.Retention = &retention
+	}
+	return update
+}
+
+func newRoomSettingsResponse(room *websocket.Room, version uint64) RoomSettingsResponse {
+	settings := room.Settings()
+	return RoomSettingsResponse{
+		RoomID:           room.ID,
+		HasPassword:      room.HasPassword(),
+		Topic:            settings.Topic,
+		WelcomeMessage:   settings.Welcome,
+		Visibility:       string(settings.Visibility),
+		MaxClients:       settings.MaxClients,
+		SlowModeSeconds:  int(settings.SlowMode / time.Second),
+		RetentionSeconds: int(settings.Retention / time.Second),
+		SettingsVersion:  version,
+	}
+}
+
+// UpdateRoomSettings godoc
+// @Summary Update room settings
+// @Description Partially updates room settings (host only). Requires If-Match with the current settings ETag.
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param Authorization header string true "Host JWT token"
+// @Param If-Match header string true "Settings ETag returned by room info"
+// @Param Idempotency-Key header string false "Key to safely retry the request"
+// @Param request body UpdateRoomSettingsRequest true "Settings to change"
+// @Success 200 {object} RoomSettingsResponse
+// @Header 200 {string} ETag "New room settings version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Failure 412 {object} ErrorResponse "Settings were changed concurrently"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
+// @Router /api/rooms/{room_id}/settings [patch]
+func (s *Server) UpdateRoomSettings() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		room, ok := s.requireHostRoom(c)
+		if !ok {
+			return
+		}
+
+		expectedVersion, err := parseIfMatch(c)
+		if err != nil {
+			status, code := http.StatusBadRequest, websocket.CodeInvalidRequest
+			if errors.Is(err, errIfMatchMissing) {
+				status, code = http.StatusPreconditionRequired, websocket.CodePreconditionRequired
+			}
+			c.JSON(status, ErrorResponse{
+				Code:      status,
+				Error:     err.Error(),
+				ErrorCode: code,
+			})
+			return
+		}
+
+		var req UpdateRoomSettingsRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		update := req.toUpdate()
+		if fieldErrs := update.Validate(); len(fieldErrs) > 0 {
+			respondValidationErrors(c, fieldErrs)
+			return
+		}
+
+		if req.Password != nil {
+			var hashedPassword string
+			if *req.Password != "" {
+				hashedPassword, err = s.Passwords.Hash(*req.Password)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, ErrorResponse{
+						Code:      http.StatusInternalServerError,
+						Error:     "failed to hash password",
+						ErrorCode: websocket.CodeInternal,
+					})
+					return
+				}
+			}
+			update.HashedPassword = &hashedPassword
+		}
+
+		version, applied := room.UpdateSettings(update, expectedVersion)
+		c.Header("ETag", settingsETag(version))
+		if !applied {
+			s.Logger.Log(ctx, logging.Info, "Room settings update conflict",
+				"room_id", room.ID, "expected_version", expectedVersion, "current_version", version)
+			c.JSON(http.StatusPreconditionFailed, ErrorResponse{
+				Code:      http.StatusPreconditionFailed,
+				Error:     "room settings were modified by another request",
+				ErrorCode: websocket.CodeSettingsConflict,
+			})
+			return
+		}
+
+		s.Logger.Log(ctx, logging.Info, "Room settings updated",
+			"room_id", room.ID, "settings_version", version)
+
+		c.JSON(http.StatusOK, newRoomSettingsResponse(room, version))
+	}
+}
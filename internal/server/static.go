@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// apiPrefixes are paths owned by the server itself. Unknown paths below them
+// get a JSON 404 instead of the SPA index.
+var apiPrefixes = []string{"/api/", "/ws/", "/socket.io/", "/static/", "/swagger/", "/metrics"}
+
+// registerStatic serves the web client from the configured static root. With
+// the SPA fallback enabled, any other GET for a non-API path returns index.html
+// so client-side routes survive a page reload.
+func (s *Server) registerStatic() {
+	root := s.Config.StaticRoot()
+	index := filepath.Join(root, "index.html")
+
+	s.Engine.StaticFS("/static", http.Dir(root))
+	s.Engine.GET("/", func(c *gin.Context) {
+		c.File(index)
+	})
+
+	fallback := s.Config.SPAFallback
+	s.Engine.NoRoute(func(c *gin.Context) {
+		if fallback && isHistoryRoute(c.Request) {
+			c.File(index)
+			return
+		}
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:      http.StatusNotFound,
+			Error:     "not found",
+			ErrorCode: websocket.CodeNotFound,
+		})
+	})
+}
+
+// isHistoryRoute reports whether r looks like a client-side route: a GET or
+// HEAD outside the API prefixes whose last segment is not a file name.
+func isHistoryRoute(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	for _, prefix := range apiPrefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return false
+		}
+	}
+	return filepath.Ext(r.URL.Path) == ""
+}
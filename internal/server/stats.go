@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// RTTStatsResponse describes heartbeat round-trip times of a room's clients
+type RTTStatsResponse struct {
+	P50Ms   int64 `json:"p50_ms" example:"35"`
+	P99Ms   int64 `json:"p99_ms" example:"180"`
+	Samples int   `json:"samples" example:"12"`
+}
+
+// OccupancyResponse is the attendance curve of a room
+type OccupancyResponse struct {
+	Samples []websocket.OccupancySample `json:"samples"`
+	Peak    int                         `json:"peak" example:"42"`
+}
+
+// RoomStatsResponse contains live statistics of a room
+type RoomStatsResponse struct {
+	Occupancy   OccupancyResponse `json:"occupancy"`
+	RTT         RTTStatsResponse  `json:"rtt"`
+	ClientCount int               `json:"client_count" example:"12"`
+	RoomID      websocket.ID      `json:"room_id" example:"123456"`
+	Ended       bool              `json:"ended" example:"false"`
+}
+
+// RoomStats godoc
+// @Summary Get room statistics
+// @Description Returns live statistics of a room, including p50/p99 heartbeat RTT of connected clients,
+// @Description and its occupancy history sampled over time. The history stays available for a while
+// @Description after the room ends, when only the occupancy is returned and ended is true.
+// @Tags rooms
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Success 200 {object} RoomStatsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/stats [get]
+func (s *Server) RoomStats() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		roomID, err := validateRoomID(c.Param("room_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     "invalid room ID format",
+				ErrorCode: websocket.CodeInvalidRoomID,
+			})
+			return
+		}
+
+		history := s.Handler.Hub.OccupancyHistory(roomID)
+		occupancy := OccupancyResponse{Samples: []websocket.OccupancySample{}}
+		for _, sample := range history {
+			occupancy.Samples = append(occupancy.Samples, sample)
+			occupancy.Peak = max(occupancy.Peak, sample.Clients)
+		}
+
+		room, exists := s.Handler.Hub.GetRoom(roomID)
+		if !exists && len(history) > 0 {
+			c.JSON(http.StatusOK, RoomStatsResponse{RoomID: roomID, Ended: true, Occupancy: occupancy})
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
+			})
+			return
+		}
+
+		rtt := room.RTTStats()
+		c.JSON(http.StatusOK, RoomStatsResponse{
+			RoomID:      room.ID,
+			ClientCount: room.GetClientCount(),
+			Occupancy:   occupancy,
+			RTT: RTTStatsResponse{
+				P50Ms:   rtt.P50.Milliseconds(),
+				P99Ms:   rtt.P99.Milliseconds(),
+				Samples: rtt.Samples,
+			},
+		})
+	}
+}
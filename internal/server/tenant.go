@@ -0,0 +1,99 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader identifies the tenant of a request when API keys are configured
+const APIKeyHeader = "X-API-Key"
+
+// TenantQuotaResponse describes the limits applied to an API key, 0 means unlimited
+type TenantQuotaResponse struct {
+	MaxRooms             int `json:"max_rooms" example:"10"`
+	MaxConnections       int `json:"max_connections" example:"500"`
+	MaxMessages          int `json:"max_messages" example:"100000"`
+	MessageWindowSeconds int `json:"message_window_seconds" example:"3600"`
+}
+
+// UsageResponse reports the usage and quotas of an API key
+type UsageResponse struct {
+	Usage websocket.TenantUsage `json:"usage"`
+	Quota TenantQuotaResponse   `json:"quota"`
+}
+
+// newTenantTracker builds the tracker from config, nil when no API keys are configured
+func newTenantTracker(keys []string, rooms, connections, messages int, window time.Duration) *websocket.TenantTracker {
+	if len(keys) == 0 {
+		return nil
+	}
+	return websocket.NewTenantTracker(websocket.TenantQuota{
+		MaxRooms:       rooms,
+		MaxConnections: connections,
+		MaxMessages:    messages,
+		MessageWindow:  window,
+	})
+}
+
+// requireTenant returns the caller's API key. With tenancy disabled it returns "" and true.
+// An unknown or missing key yields a 401 response and false.
+func (s *Server) requireTenant(c *gin.Context) (string, bool) {
+	if s.Tenants == nil {
+		return "", true
+	}
+	key := c.GetHeader(APIKeyHeader)
+	for _, known := range s.Config.APIKeyList() {
+		if key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(known)) == 1 {
+			return key, true
+		}
+	}
+	s.Metrics.AuthFailed(websocket.AuthTransportREST, websocket.AuthFailureInvalidAPIKey)
+	c.JSON(http.StatusUnauthorized, ErrorResponse{
+		Code:      http.StatusUnauthorized,
+		Error:     "valid " + APIKeyHeader + " header required",
+		ErrorCode: websocket.CodeInvalidAPIKey,
+	})
+	return "", false
+}
+
+// Usage godoc
+// @Summary API key usage
+// @Description Returns rooms, connections and messages used by the API key and the quotas applied to it
+// @Tags usage
+// @Produce json
+// @Param X-API-Key header string true "API key"
+// @Success 200 {object} UsageResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "API keys are not configured"
+// @Router /api/usage [get]
+func (s *Server) Usage() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if s.Tenants == nil {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "usage tracking is disabled",
+				ErrorCode: websocket.CodeFeatureDisabled,
+			})
+			return
+		}
+		key, ok := s.requireTenant(c)
+		if !ok {
+			return
+		}
+
+		quota := s.Tenants.Quota()
+		c.JSON(http.StatusOK, UsageResponse{
+			Usage: s.Tenants.Usage(key),
+			Quota: TenantQuotaResponse{
+				MaxRooms:             quota.MaxRooms,
+				MaxConnections:       quota.MaxConnections,
+				MaxMessages:          quota.MaxMessages,
+				MessageWindowSeconds: int(quota.MessageWindow / time.Second),
+			},
+		})
+	}
+}
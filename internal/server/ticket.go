@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+type JoinTicketRequest struct {
+	Password string `json:"password,omitempty" binding:"max=72" example:"mypassword123"`
+}
+
+type JoinTicketResponse struct {
+	ExpiresAt time.Time `json:"expires_at" example:"2024-01-01T12:00:30Z"`
+	Ticket    string    `json:"ticket" example:"pQ3v0bX2..."`
+}
+
+// JoinTicket godoc
+// @Summary Issue join ticket
+// @Description Validates the room password and returns a short-lived single-use ticket to pass as the ticket query parameter of the WebSocket URL
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param request body JoinTicketRequest false "Room password, if the room has one"
+// @Success 201 {object} JoinTicketResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse "Invalid password"
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/rooms/{room_id}/join-ticket [post]
+func (s *Server) JoinTicket() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		roomID, err := validateRoomID(c.Param("room_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     "invalid room ID format",
+				ErrorCode: websocket.CodeInvalidRoomID,
+			})
+			return
+		}
+
+		room, exists := s.Handler.Hub.GetRoom(roomID)
+		if !exists {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
+			})
+			return
+		}
+
+		var req JoinTicketRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		if room.HasPassword() {
+			if !room.CheckPassword(s.Passwords, req.Password) {
+				if req.Password != "" {
+					room.RecordFailedPassword(c.ClientIP())
+				}
+				s.Metrics.AuthFailed(websocket.AuthTransportREST, websocket.AuthFailureInvalidPassword)
+				c.JSON(http.StatusUnauthorized, ErrorResponse{
+					Code:      http.StatusUnauthorized,
+					Error:     "invalid or missing password",
+					ErrorCode: websocket.CodeInvalidPassword,
+				})
+				return
+			}
+		}
+
+		ticket, expiresAt, err := s.Handler.Tickets.Issue(roomID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:      http.StatusInternalServerError,
+				Error:     "failed to issue join ticket",
+				ErrorCode: websocket.CodeInternal,
+			})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Debug, "Join ticket issued", "room_id", roomID)
+
+		c.JSON(http.StatusCreated, JoinTicketResponse{Ticket: ticket, ExpiresAt: expiresAt})
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/YuarenArt/chatters/internal/config"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// CheckTLS validates the TLS options and that the certificate files can be loaded
+func CheckTLS(cfg *config.Config) error {
+	_, _, err := newTLSConfig(cfg)
+	return err
+}
+
+// newTLSConfig returns the TLS configuration of the server and the handler of the
+// plain HTTP redirect listener, both nil if TLS is disabled. In autocert mode
+// certificates are obtained from Let's Encrypt on the first handshake of each
+// domain, and the redirect listener answers its HTTP-01 challenges.
+func newTLSConfig(cfg *config.Config) (*tls.Config, http.Handler, error) {
+	domains := cfg.AutocertDomainList()
+	switch {
+	case !cfg.TLSEnabled():
+		if cfg.HTTPRedirectAddr != "" {
+			return nil, nil, errors.New("HTTP_REDIRECT_ADDR requires TLS_CERT_FILE or AUTOCERT_DOMAINS")
+		}
+		return nil, nil, nil
+	case len(domains) > 0 && (cfg.TLSCertFile != "" || cfg.TLSKeyFile != ""):
+		return nil, nil, errors.New("AUTOCERT_DOMAINS and TLS_CERT_FILE are mutually exclusive")
+	case len(domains) == 0 && (cfg.TLSCertFile == "" || cfg.TLSKeyFile == ""):
+		return nil, nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	redirect := redirectToHTTPS(cfg.Port)
+	if len(domains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+			Email:      cfg.AutocertEmail,
+		}
+		tlsConfig := manager.TLSConfig()
+		tlsConfig.MinVersion = tls.VersionTLS12
+		return tlsConfig, manager.HTTPHandler(redirect), nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, redirect, nil
+}
+
+// redirectToHTTPS redirects requests to the same host and path on the HTTPS port
+func redirectToHTTPS(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/YuarenArt/chatters/internal/config"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/golang-jwt/jwt"
+)
+
+// CheckTokenKeys validates the configured host token keys and that they can issue tokens
+func CheckTokenKeys(cfg *config.Config) error {
+	keys, err := websocket.NewTokenKeys(cfg.JWTSecret, cfg.JWTPrivateKeyFile, cfg.JWTPublicKeyFile)
+	if err != nil {
+		return err
+	}
+	_, err = keys.Sign(jwt.MapClaims{})
+	return err
+}
+
+// tokenKeys returns the configured host token keys, falling back to the HMAC
+// secret if they cannot be loaded. CheckTokenKeys reports that case at startup.
+func tokenKeys(cfg *config.Config) *websocket.TokenKeys {
+	keys, err := websocket.NewTokenKeys(cfg.JWTSecret, cfg.JWTPrivateKeyFile, cfg.JWTPublicKeyFile)
+	if err != nil {
+		return websocket.NewHMACKeys(cfg.JWTSecret)
+	}
+	return keys
+}
+
+// hostAuthFailed counts a host token rejected by a REST endpoint
+func (s *Server) hostAuthFailed(err error) {
+	reason := websocket.AuthFailureInvalidToken
+	switch {
+	case errors.Is(err, errHostTokenRequired):
+		reason = websocket.AuthFailureMissingToken
+	case errors.Is(err, websocket.ErrNotHost):
+		reason = websocket.AuthFailureRevokedToken
+	}
+	s.Metrics.AuthFailed(websocket.AuthTransportREST, reason)
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// maxUploadName bounds the file name kept for an upload
+const maxUploadName = 255
+
+// uploadExtensions maps the default upload types to the extension of their stored files
+var uploadExtensions = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+}
+
+// UploadFile godoc
+// @Summary Upload a file
+// @Description Stores a file for the room and returns the URL to share it with an attachment message.
+// @Description The type is detected from the content and must be one of UPLOAD_TYPES; files are deleted with the room.
+// @Description Password-protected rooms require the room password or the host token.
+// @Tags rooms
+// @Accept mpfd
+// @Produce json
+// @Param room_id path int true "Room ID"
+// @Param X-Room-Password header string false "Room password"
+// @Param Authorization header string false "Host JWT token"
+// @Param file formData file true "File to upload"
+// @Success 201 {object} websocket.Upload
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Room not found or uploads disabled"
+// @Failure 409 {object} ErrorResponse "Too many uploads in the room"
+// @Failure 413 {object} ErrorResponse "File too large"
+// @Failure 415 {object} ErrorResponse "File type not accepted"
+// @Failure 500 {object} ErrorResponse
+// @Router /api/rooms/{room_id}/uploads [post]
+func (s *Server) UploadFile() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		storage := s.Handler.Hub.Uploads
+		if storage == nil {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "uploads are disabled",
+				ErrorCode: websocket.CodeFeatureDisabled,
+			})
+			return
+		}
+		room, ok := s.requireRoomReader(c)
+		if !ok {
+			return
+		}
+
+		maxBytes := int64(s.Config.UploadMaxBytes)
+		tooLarge := func() {
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+				Code:      http.StatusRequestEntityTooLarge,
+				Error:     fmt.Sprintf("files are limited to %d bytes", maxBytes),
+				ErrorCode: websocket.CodeRequestTooLarge,
+			})
+		}
+		// Leave room for the multipart framing around the file
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+64<<10)
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				tooLarge()
+				return
+			}
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     "multipart form with a file field required",
+				ErrorCode: websocket.CodeInvalidRequest,
+			})
+			return
+		}
+		defer file.Close()
+		if header.Size > maxBytes {
+			tooLarge()
+			return
+		}
+
+		sniff := make([]byte, 512)
+		n, err := io.ReadFull(file, sniff)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     "failed to read file",
+				ErrorCode: websocket.CodeInvalidRequest,
+			})
+			return
+		}
+		contentType, _, _ := mime.ParseMediaType(http.DetectContentType(sniff[:n]))
+		if !slices.Contains(s.Config.UploadTypeList(), contentType) {
+			c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+				Code:      http.StatusUnsupportedMediaType,
+				Error:     "file type " + contentType + " is not accepted",
+				ErrorCode: websocket.CodeUnsupportedType,
+			})
+			return
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:      http.StatusInternalServerError,
+				Error:     "failed to read file",
+				ErrorCode: websocket.CodeInternal,
+			})
+			return
+		}
+
+		// The slot is taken before the file is stored, so a full room gets no files
+		reservation, err := room.ReserveUpload()
+		if err != nil {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Code:      http.StatusConflict,
+				Error:     err.Error(),
+				ErrorCode: websocket.CodeTooManyUploads,
+			})
+			return
+		}
+		defer reservation.Release()
+
+		key, uploadID := websocket.NewUploadKey(room.ID, uploadExtension(contentType))
+		url, err := storage.Put(c.Request.Context(), key, contentType, file, header.Size)
+		if err != nil {
+			s.Logger.Log(c.Request.Context(), logging.Error, "Storing upload failed", "room_id", room.ID, "error", err.Error())
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:      http.StatusInternalServerError,
+				Error:     "failed to store file",
+				ErrorCode: websocket.CodeInternal,
+			})
+			return
+		}
+
+		// The multipart reader already strips directories from the file name
+		name := header.Filename
+		if name == "" || name == "." {
+			name = uploadID + uploadExtension(contentType)
+		}
+		if len(name) > maxUploadName {
+			name = name[len(name)-maxUploadName:]
+		}
+		upload := websocket.Upload{
+			UploadedAt:  time.Now().UTC(),
+			ID:          uploadID,
+			URL:         url,
+			Name:        name,
+			ContentType: contentType,
+			Size:        header.Size,
+		}
+		if err := reservation.Commit(upload); err != nil {
+			// The room closed while the file was stored, its uploads may be gone already
+			if err := storage.DeletePrefix(context.WithoutCancel(c.Request.Context()), key); err != nil {
+				s.Logger.Log(c.Request.Context(), logging.Error, "Deleting upload failed", "room_id", room.ID, "error", err.Error())
+			}
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
+			})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "File uploaded",
+			"room_id", room.ID, "upload_id", uploadID, "content_type", contentType, "size", header.Size)
+
+		c.JSON(http.StatusCreated, upload)
+	}
+}
+
+// uploadExtension returns the extension of stored files of contentType
+func uploadExtension(contentType string) string {
+	if ext, ok := uploadExtensions[contentType]; ok {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
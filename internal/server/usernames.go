@@ -0,0 +1,72 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// UsernameSuggestionsResponse lists usernames free to join a room with
+type UsernameSuggestionsResponse struct {
+	Scope       websocket.UniqueScope `json:"scope" example:"room"` // how widely usernames must be unique
+	Suggestions []string              `json:"suggestions" example:"Brave-Otter,Sunny-Maple"`
+}
+
+// SuggestUsernames godoc
+// @Summary Suggest usernames
+// @Description Returns random adjective-noun usernames that pass validation, are not blocked by the
+// @Description server or room word filters and are not taken in the username uniqueness scope.
+// @Description Names are not reserved: joining with one may still fail if someone takes it first.
+// @Description Fewer names than requested are returned when few remain free.
+// @Tags rooms
+// @Produce json
+// @Param room_id query int true "Room ID"
+// @Param count query int false "Number of suggestions, 1 to 20" default(5)
+// @Success 200 {object} UsernameSuggestionsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/usernames/suggest [get]
+func (s *Server) SuggestUsernames() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		roomID, err := validateRoomID(c.Query("room_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Error:     "invalid room ID format",
+				ErrorCode: websocket.CodeInvalidRoomID,
+			})
+			return
+		}
+		count := websocket.DefaultUsernameSuggestions
+		if countStr := c.Query("count"); countStr != "" {
+			count, err = strconv.Atoi(countStr)
+			if err != nil || count < 1 || count > websocket.MaxUsernameSuggestions {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Code:      http.StatusBadRequest,
+					Error:     "count must be between 1 and " + strconv.Itoa(websocket.MaxUsernameSuggestions),
+					ErrorCode: websocket.CodeInvalidRequest,
+				})
+				return
+			}
+		}
+
+		room, exists := s.Handler.Hub.GetRoom(roomID)
+		if !exists {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     "room not found",
+				ErrorCode: websocket.CodeRoomNotFound,
+			})
+			return
+		}
+
+		// Names the caller's own session holds count as free, it may join with them again
+		holder := c.GetString(websocket.SessionIDKey)
+		c.JSON(http.StatusOK, UsernameSuggestionsResponse{
+			Scope:       s.Handler.Usernames.Scope(),
+			Suggestions: websocket.SuggestUsernames(room, s.Handler.Usernames, s.Handler.Filter, holder, count),
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationErrorResponse is returned when one or more request fields are invalid
+type ValidationErrorResponse struct {
+	Error     string                      `json:"error" example:"validation failed"`
+	ErrorCode websocket.ErrorCode         `json:"error_code" example:"VALIDATION_FAILED"`
+	Fields    []websocket.ValidationError `json:"fields"`
+	Code      int                         `json:"code" example:"422"`
+}
+
+var registerTagNameOnce sync.Once
+
+// useJSONFieldNames makes validator report fields by their JSON names
+func useJSONFieldNames() {
+	registerTagNameOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" || name == "" {
+				return field.Name
+			}
+			return name
+		})
+	})
+}
+
+// bindRequest decodes the JSON body into req and validates its binding tags.
+// An empty body is validated as a zero value, so required fields are reported
+// instead of a decoding error. On failure the error response is written and
+// false is returned: 400 for malformed JSON, 422 with field errors otherwise.
+func bindRequest(c *gin.Context, req interface{}) bool {
+	useJSONFieldNames()
+
+	var err error
+	if c.Request.ContentLength == 0 {
+		err = binding.Validator.ValidateStruct(req)
+	} else {
+		err = c.ShouldBindJSON(req)
+	}
+	if err == nil {
+		return true
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Error:     "invalid request body",
+			ErrorCode: websocket.CodeInvalidRequest,
+		})
+		return false
+	}
+
+	fields := make([]websocket.ValidationError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, websocket.ValidationError{
+			Field:   fe.Field(),
+			Message: validationMessage(fe),
+		})
+	}
+	respondValidationErrors(c, fields)
+	return false
+}
+
+// respondValidationErrors writes a 422 response listing invalid fields
+func respondValidationErrors(c *gin.Context, fields []websocket.ValidationError) {
+	c.JSON(http.StatusUnprocessableEntity, ValidationErrorResponse{
+		Code:      http.StatusUnprocessableEntity,
+		Error:     "validation failed",
+		ErrorCode: websocket.CodeValidationFailed,
+		Fields:    fields,
+	})
+}
+
+// validationMessage converts a validator error into a human-readable message
+func validationMessage(fe validator.FieldError) string {
+	field := fe.Field()
+	switch fe.Tag() {
+	case "required":
+		return field + " is required"
+	case "max":
+		if fe.Kind() == reflect.String {
+			return field + " must be at most " + fe.Param() + " characters long"
+		}
+		return field + " must be at most " + fe.Param()
+	case "min":
+		if fe.Kind() == reflect.String {
+			return field + " must be at least " + fe.Param() + " characters long"
+		}
+		return field + " must be at least " + fe.Param()
+	case "unique":
+		return field + " must not contain duplicates"
+	case "required_without":
+		return field + " or " + strings.ToLower(fe.Param()) + " is required"
+	case "excluded_with":
+		return field + " cannot be combined with " + strings.ToLower(fe.Param())
+	case "ip":
+		return field + " must be a valid IP address"
+	case "oneof":
+		return field + " must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	default:
+		return field + " is invalid"
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+)
+
+// webhookTimeout bounds a single outgoing webhook delivery
+const webhookTimeout = 5 * time.Second
+
+// postWebhook POSTs a JSON body to url in the background, logging failures.
+// Unless the URL comes from the configuration, only public addresses are reached.
+func (s *Server) postWebhook(url string, body []byte, fromConfig bool) {
+	client := s.Webhooks.client
+	if fromConfig {
+		client = s.Webhooks.trusted
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			s.Logger.Log(ctx, logging.Error, "Invalid webhook URL", "url", url, "error", err.Error())
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			s.Logger.Log(ctx, logging.Error, "Webhook delivery failed", "url", url, "error", err.Error())
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusMultipleChoices {
+			s.Logger.Log(ctx, logging.Error, "Webhook rejected", "url", url, "status", resp.StatusCode)
+		}
+	}()
+}
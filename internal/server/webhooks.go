@@ -0,0 +1,347 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Headers of webhook deliveries. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the webhook secret, prefixed with "sha256=".
+const (
+	WebhookEventHeader     = "X-Chatters-Event"
+	WebhookDeliveryHeader  = "X-Chatters-Delivery"
+	WebhookTimestampHeader = "X-Chatters-Timestamp"
+	WebhookSignatureHeader = "X-Chatters-Signature"
+)
+
+// Webhook delivery limits
+const (
+	MaxWebhooks = 20
+
+	webhookAttempts      = 4              // first try plus retries
+	webhookRetryBase     = time.Second    // doubled after every failed attempt
+	maxWebhookDeliveries = 64             // concurrent deliveries, events beyond are dropped
+	webhookDeliveryLimit = webhookTimeout // per attempt
+)
+
+var (
+	ErrTooManyWebhooks  = errors.New("too many webhooks")
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	errWebhookRetryable = errors.New("webhook endpoint failed")
+)
+
+// Webhook is an endpoint receiving server events
+type Webhook struct {
+	CreatedAt  time.Time `json:"created_at" example:"2024-01-01T12:00:00Z"`
+	ID         string    `json:"id" example:"3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"`
+	URL        string    `json:"url" example:"https://ops.example.com/chatters"`
+	Events     []string  `json:"events,omitempty" example:"room_created"` // empty means every event
+	FromConfig bool      `json:"from_config" example:"false"`
+	secret     string
+}
+
+// CreateWebhookRequest registers a webhook
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048" example:"https://ops.example.com/chatters"`
+	Events []string `json:"events,omitempty" binding:"omitempty,max=5,dive,oneof=room_created room_deleted user_joined user_kicked message_dropped" example:"user_kicked"`
+}
+
+// CreateWebhookResponse returns the webhook with the secret its deliveries are signed with
+type CreateWebhookResponse struct {
+	Webhook Webhook `json:"webhook"`
+	Secret  string  `json:"secret" example:"9c1d0f..."`
+}
+
+// WebhooksResponse lists the registered webhooks
+type WebhooksResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// WebhookDispatcher signs and POSTs server events to the registered webhooks,
+// retrying failed deliveries with exponential backoff
+type WebhookDispatcher struct {
+	hooks     map[string]*Webhook
+	client    *http.Client // for webhooks registered through the API
+	trusted   *http.Client // for webhooks from the configuration
+	slots     chan struct{}
+	logger    logging.Logger
+	retryBase time.Duration
+	sleep     func(time.Duration) // waits out the backoff between attempts
+	mu        sync.RWMutex
+}
+
+// NewWebhookDispatcher creates a dispatcher without webhooks
+func NewWebhookDispatcher(logger logging.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		hooks:     make(map[string]*Webhook),
+		client:    newOutboundClient(webhookDeliveryLimit, false),
+		trusted:   newOutboundClient(webhookDeliveryLimit, true),
+		slots:     make(chan struct{}, maxWebhookDeliveries),
+		logger:    logger,
+		retryBase: webhookRetryBase,
+		sleep:     time.Sleep,
+	}
+}
+
+// SignWebhook returns the signature header value of a delivery
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Add registers url for events, every event if empty. An empty secret is replaced
+// by a random one unless the webhook comes from the configuration, whose
+// deliveries are then unsigned. Other webhooks must use https and are only
+// delivered to public addresses.
+func (d *WebhookDispatcher) Add(url string, events []string, secret string, fromConfig bool) (Webhook, string, error) {
+	if !fromConfig {
+		if err := checkWebhookURL(url); err != nil {
+			return Webhook{}, "", err
+		}
+	}
+	if secret == "" && !fromConfig {
+		secretBytes := make([]byte, 32)
+		if _, err := rand.Read(secretBytes); err != nil {
+			return Webhook{}, "", err
+		}
+		secret = hex.EncodeToString(secretBytes)
+	}
+	hook := &Webhook{
+		ID:         uuid.New().String(),
+		URL:        url,
+		Events:     slices.Clone(events),
+		FromConfig: fromConfig,
+		CreatedAt:  time.Now(),
+		secret:     secret,
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.hooks) >= MaxWebhooks {
+		return Webhook{}, "", ErrTooManyWebhooks
+	}
+	d.hooks[hook.ID] = hook
+	return *hook, secret, nil
+}
+
+// Remove unregisters a webhook and reports whether it existed
+func (d *WebhookDispatcher) Remove(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.hooks[id]
+	delete(d.hooks, id)
+	return ok
+}
+
+// List returns the webhooks, oldest first
+func (d *WebhookDispatcher) List() []Webhook {
+	d.mu.RLock()
+	hooks := make([]Webhook, 0, len(d.hooks))
+	for _, hook := range d.hooks {
+		hooks = append(hooks, *hook)
+	}
+	d.mu.RUnlock()
+
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].CreatedAt.Before(hooks[j].CreatedAt)
+	})
+	return hooks
+}
+
+// Publish delivers event to every webhook subscribed to its type. It never
+// blocks: deliveries run in the background and are dropped when too many are
+// already in flight.
+func (d *WebhookDispatcher) Publish(event websocket.ServerEvent) {
+	d.mu.RLock()
+	var targets []Webhook
+	for _, hook := range d.hooks {
+		if len(hook.Events) == 0 || slices.Contains(hook.Events, event.Type) {
+			targets = append(targets, *hook)
+		}
+	}
+	d.mu.RUnlock()
+	if len(targets) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	for _, hook := range targets {
+		d.send(hook, event.Type, body)
+	}
+}
+
+// send delivers body in the background unless too many deliveries are in flight
+func (d *WebhookDispatcher) send(hook Webhook, eventType string, body []byte) {
+	select {
+	case d.slots <- struct{}{}:
+	default:
+		d.logger.Log(context.Background(), logging.Warn, "Webhook delivery dropped, too many in flight",
+			"webhook_id", hook.ID, "event", eventType)
+		return
+	}
+	go func() {
+		defer func() { <-d.slots }()
+		d.deliver(hook, eventType, body)
+	}()
+}
+
+// deliver POSTs body to the webhook, retrying network errors, 429 and 5xx responses
+func (d *WebhookDispatcher) deliver(hook Webhook, eventType string, body []byte) {
+	deliveryID := uuid.New().String()
+	var err error
+	for attempt := 0; attempt < webhookAttempts; attempt++ {
+		if attempt > 0 {
+			d.sleep(d.retryBase << (attempt - 1))
+		}
+		if err = d.post(hook, eventType, deliveryID, body); !errors.Is(err, errWebhookRetryable) {
+			break
+		}
+	}
+	if err != nil {
+		d.logger.Log(context.Background(), logging.Error, "Webhook delivery failed",
+			"webhook_id", hook.ID, "url", hook.URL, "event", eventType, "error", err.Error())
+	}
+}
+
+// post makes a single delivery attempt
+func (d *WebhookDispatcher) post(hook Webhook, eventType, deliveryID string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, eventType)
+	req.Header.Set(WebhookDeliveryHeader, deliveryID)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	if hook.secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(hook.secret, timestamp, body))
+	}
+
+	client := d.client
+	if hook.FromConfig {
+		client = d.trusted
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Join(errWebhookRetryable, err)
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return errors.Join(errWebhookRetryable, errors.New("status "+resp.Status))
+	case resp.StatusCode >= http.StatusBadRequest:
+		return errors.New("rejected with status " + resp.Status)
+	case resp.StatusCode >= http.StatusMultipleChoices:
+		return errRedirect
+	}
+	return nil
+}
+
+// AdminWebhooks godoc
+// @Summary List webhooks
+// @Description Returns the webhooks receiving server events, from the configuration and the admin API (admin only)
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} WebhooksResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Admin API is disabled"
+// @Router /api/admin/webhooks [get]
+func (s *Server) AdminWebhooks() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, WebhooksResponse{Webhooks: s.Webhooks.List()})
+	}
+}
+
+// AdminCreateWebhook godoc
+// @Summary Register webhook
+// @Description Sends room_created, room_deleted, user_joined, user_kicked and message_dropped events to url,
+// @Description or only the listed events. Deliveries are signed with the returned secret (admin only).
+// @Description The URL must use https and resolve to a public address; redirects are not followed.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param request body CreateWebhookRequest true "Webhook definition"
+// @Success 201 {object} CreateWebhookResponse
+// @Failure 400 {object} ErrorResponse "URL is not https or names a private address"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Admin API is disabled"
+// @Failure 409 {object} ErrorResponse "Too many webhooks"
+// @Failure 422 {object} ValidationErrorResponse "Invalid fields"
+// @Router /api/admin/webhooks [post]
+func (s *Server) AdminCreateWebhook() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req CreateWebhookRequest
+		if !bindRequest(c, &req) {
+			return
+		}
+
+		hook, secret, err := s.Webhooks.Add(req.URL, req.Events, "", false)
+		if err != nil {
+			status, code := http.StatusInternalServerError, websocket.CodeInternal
+			switch {
+			case errors.Is(err, ErrTooManyWebhooks):
+				status, code = http.StatusConflict, websocket.CodeTooManyWebhooks
+			case errors.Is(err, websocket.ErrInsecureWebhookURL), errors.Is(err, ErrPrivateAddress):
+				status, code = http.StatusBadRequest, websocket.CodeInvalidWebhookURL
+			}
+			c.JSON(status, ErrorResponse{Code: status, Error: err.Error(), ErrorCode: code})
+			return
+		}
+
+		s.Logger.Log(c.Request.Context(), logging.Info, "Webhook registered",
+			"webhook_id", hook.ID, "url", hook.URL, "events", hook.Events)
+
+		c.JSON(http.StatusCreated, CreateWebhookResponse{Webhook: hook, Secret: secret})
+	}
+}
+
+// AdminDeleteWebhook godoc
+// @Summary Remove webhook
+// @Description Stops deliveries to a webhook (admin only)
+// @Tags admin
+// @Produce json
+// @Param webhook_id path string true "Webhook ID"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/webhooks/{webhook_id} [delete]
+func (s *Server) AdminDeleteWebhook() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if !s.Webhooks.Remove(c.Param("webhook_id")) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:      http.StatusNotFound,
+				Error:     ErrWebhookNotFound.Error(),
+				ErrorCode: websocket.CodeWebhookNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "webhook removed"})
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YuarenArt/chatters/internal/logging"
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookDeliveriesAreSignedAndRetried(t *testing.T) {
+	type delivery struct {
+		body      []byte
+		signature string
+		timestamp string
+	}
+	deliveries := make(chan delivery, 4)
+	var attempts int
+	var mu sync.Mutex
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		failing := attempts < 3
+		mu.Unlock()
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- delivery{body, r.Header.Get(WebhookSignatureHeader), r.Header.Get(WebhookTimestampHeader)}
+	}))
+	defer receiver.Close()
+
+	dispatcher := NewWebhookDispatcher(logging.NewLogger())
+	var backoff []time.Duration
+	dispatcher.sleep = func(d time.Duration) {
+		mu.Lock()
+		backoff = append(backoff, d)
+		mu.Unlock()
+	}
+	// The receiver listens on loopback, which only configured webhooks may reach
+	_, secret, err := dispatcher.Add(receiver.URL, []string{websocket.ServerEventUserKicked}, "test-secret", true)
+	require.NoError(t, err)
+	assert.Equal(t, "test-secret", secret)
+
+	dispatcher.Publish(websocket.ServerEvent{Type: websocket.ServerEventUserJoined, RoomID: 1})
+	dispatcher.Publish(websocket.ServerEvent{Type: websocket.ServerEventUserKicked, RoomID: 1, Username: "alice", By: "host"})
+
+	select {
+	case d := <-deliveries:
+		assert.Equal(t, SignWebhook(secret, d.timestamp, d.body), d.signature)
+		var event websocket.ServerEvent
+		require.NoError(t, json.Unmarshal(d.body, &event))
+		assert.Equal(t, websocket.ServerEventUserKicked, event.Type)
+		assert.Equal(t, "alice", event.Username)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 3, attempts, "only the subscribed event is delivered")
+	assert.Equal(t, []time.Duration{webhookRetryBase, 2 * webhookRetryBase}, backoff)
+}
+
+func TestWebhookDeliveryGivesUpOnClientErrors(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer receiver.Close()
+
+	dispatcher := NewWebhookDispatcher(logging.NewLogger())
+	dispatcher.sleep = func(time.Duration) { t.Error("a rejected delivery was retried") }
+	hook, _, err := dispatcher.Add(receiver.URL, nil, "", true)
+	require.NoError(t, err)
+
+	dispatcher.deliver(hook, websocket.ServerEventRoomCreated, []byte(`{}`))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,144 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: chatters/v1/rooms.proto
+
+package chattersv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	v1 "github.com/YuarenArt/chatters/pkg/rpc/chatters/v1"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// RoomServiceName is the fully-qualified name of the RoomService service.
+	RoomServiceName = "chatters.v1.RoomService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// RoomServiceGetRoomProcedure is the fully-qualified name of the RoomService's GetRoom RPC.
+	RoomServiceGetRoomProcedure = "/chatters.v1.RoomService/GetRoom"
+	// RoomServiceListRoomsProcedure is the fully-qualified name of the RoomService's ListRooms RPC.
+	RoomServiceListRoomsProcedure = "/chatters.v1.RoomService/ListRooms"
+)
+
+// RoomServiceClient is a client for the chatters.v1.RoomService service.
+type RoomServiceClient interface {
+	// GetRoom returns the directory view of a room
+	GetRoom(context.Context, *connect.Request[v1.GetRoomRequest]) (*connect.Response[v1.GetRoomResponse], error)
+	// ListRooms lists the public rooms, optionally by tag
+	ListRooms(context.Context, *connect.Request[v1.ListRoomsRequest]) (*connect.Response[v1.ListRoomsResponse], error)
+}
+
+// NewRoomServiceClient constructs a client for the chatters.v1.RoomService service. By default, it
+// uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewRoomServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) RoomServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	roomServiceMethods := v1.File_chatters_v1_rooms_proto.Services().ByName("RoomService").Methods()
+	return &roomServiceClient{
+		getRoom: connect.NewClient[v1.GetRoomRequest, v1.GetRoomResponse](
+			httpClient,
+			baseURL+RoomServiceGetRoomProcedure,
+			connect.WithSchema(roomServiceMethods.ByName("GetRoom")),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		listRooms: connect.NewClient[v1.ListRoomsRequest, v1.ListRoomsResponse](
+			httpClient,
+			baseURL+RoomServiceListRoomsProcedure,
+			connect.WithSchema(roomServiceMethods.ByName("ListRooms")),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// roomServiceClient implements RoomServiceClient.
+type roomServiceClient struct {
+	getRoom   *connect.Client[v1.GetRoomRequest, v1.GetRoomResponse]
+	listRooms *connect.Client[v1.ListRoomsRequest, v1.ListRoomsResponse]
+}
+
+// GetRoom calls chatters.v1.RoomService.GetRoom.
+func (c *roomServiceClient) GetRoom(ctx context.Context, req *connect.Request[v1.GetRoomRequest]) (*connect.Response[v1.GetRoomResponse], error) {
+	return c.getRoom.CallUnary(ctx, req)
+}
+
+// ListRooms calls chatters.v1.RoomService.ListRooms.
+func (c *roomServiceClient) ListRooms(ctx context.Context, req *connect.Request[v1.ListRoomsRequest]) (*connect.Response[v1.ListRoomsResponse], error) {
+	return c.listRooms.CallUnary(ctx, req)
+}
+
+// RoomServiceHandler is an implementation of the chatters.v1.RoomService service.
+type RoomServiceHandler interface {
+	// GetRoom returns the directory view of a room
+	GetRoom(context.Context, *connect.Request[v1.GetRoomRequest]) (*connect.Response[v1.GetRoomResponse], error)
+	// ListRooms lists the public rooms, optionally by tag
+	ListRooms(context.Context, *connect.Request[v1.ListRoomsRequest]) (*connect.Response[v1.ListRoomsResponse], error)
+}
+
+// NewRoomServiceHandler builds an HTTP handler from the service implementation. It returns the path
+// on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewRoomServiceHandler(svc RoomServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	roomServiceMethods := v1.File_chatters_v1_rooms_proto.Services().ByName("RoomService").Methods()
+	roomServiceGetRoomHandler := connect.NewUnaryHandler(
+		RoomServiceGetRoomProcedure,
+		svc.GetRoom,
+		connect.WithSchema(roomServiceMethods.ByName("GetRoom")),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	roomServiceListRoomsHandler := connect.NewUnaryHandler(
+		RoomServiceListRoomsProcedure,
+		svc.ListRooms,
+		connect.WithSchema(roomServiceMethods.ByName("ListRooms")),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/chatters.v1.RoomService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case RoomServiceGetRoomProcedure:
+			roomServiceGetRoomHandler.ServeHTTP(w, r)
+		case RoomServiceListRoomsProcedure:
+			roomServiceListRoomsHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedRoomServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedRoomServiceHandler struct{}
+
+func (UnimplementedRoomServiceHandler) GetRoom(context.Context, *connect.Request[v1.GetRoomRequest]) (*connect.Response[v1.GetRoomResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("chatters.v1.RoomService.GetRoom is not implemented"))
+}
+
+func (UnimplementedRoomServiceHandler) ListRooms(context.Context, *connect.Request[v1.ListRoomsRequest]) (*connect.Response[v1.ListRoomsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("chatters.v1.RoomService.ListRooms is not implemented"))
+}
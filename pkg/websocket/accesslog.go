@@ -0,0 +1,62 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+// AccessLogSize is how many access entries a room keeps
+const AccessLogSize = 500
+
+// Access log actions
+const (
+	AccessJoin  = "join"
+	AccessLeave = "leave"
+	AccessKick  = "kick"
+)
+
+// AccessEntry records a member joining, leaving or being kicked from a room
+type AccessEntry struct {
+	At       time.Time `json:"at" example:"2024-01-01T12:00:00Z"`
+	Action   string    `json:"action" example:"join"`
+	Username string    `json:"username" example:"JohnDoe"`
+	ConnID   string    `json:"connection_id,omitempty" example:"3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"`
+	IP       string    `json:"ip,omitempty" example:"203.0.113.7"`
+	By       string    `json:"by,omitempty" example:"host"` // who kicked the member
+}
+
+// accessLog keeps the most recent access entries of a room in memory
+type accessLog struct {
+	entries []AccessEntry
+	mu      sync.RWMutex
+}
+
+// logAccess appends an entry for client, dropping the oldest ones over AccessLogSize
+func (r *Room) logAccess(action string, client *Client, by string) {
+	r.appendAccess(AccessEntry{
+		Action:   action,
+		Username: client.Username,
+		ConnID:   client.ConnID,
+		IP:       client.remoteIP,
+		By:       by,
+	})
+}
+
+// appendAccess stamps and stores entry
+func (r *Room) appendAccess(entry AccessEntry) {
+	entry.At = time.Now()
+	l := &r.access
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+	if drop := len(l.entries) - AccessLogSize; drop > 0 {
+		l.entries = append(l.entries[:0:0], l.entries[drop:]...)
+	}
+}
+
+// AccessLog returns the recorded joins, leaves and kicks, oldest first
+func (r *Room) AccessLog() []AccessEntry {
+	r.access.mu.RLock()
+	defer r.access.mu.RUnlock()
+	return append([]AccessEntry{}, r.access.entries...)
+}
@@ -0,0 +1,74 @@
+package websocket
+
+import "sync"
+
+// AdmissionController limits the number of concurrent WebSocket connections
+// server-wide and per room, so the process degrades predictably under load.
+type AdmissionController struct {
+	perRoom        map[ID]int
+	maxConnections int
+	maxPerRoom     int
+	active         int
+	mu             sync.Mutex
+}
+
+// NewAdmissionController creates a controller allowing up to maxConnections connections in total.
+// roomSharePercent caps a single room to that percentage of maxConnections.
+// Zero values disable the respective limit.
+func NewAdmissionController(maxConnections, roomSharePercent int) *AdmissionController {
+	ac := &AdmissionController{
+		perRoom:        make(map[ID]int),
+		maxConnections: maxConnections,
+	}
+	if maxConnections > 0 && roomSharePercent > 0 && roomSharePercent < 100 {
+		ac.maxPerRoom = (maxConnections*roomSharePercent + 99) / 100
+	}
+	return ac
+}
+
+// Acquire reserves a connection slot in roomID. When ok is true the caller
+// must call release exactly once after the connection is closed.
+func (ac *AdmissionController) Acquire(roomID ID) (release func(), ok bool) {
+	if ac == nil {
+		return func() {}, true
+	}
+
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	if ac.maxConnections > 0 && ac.active >= ac.maxConnections {
+		return nil, false
+	}
+	if ac.maxPerRoom > 0 && ac.perRoom[roomID] >= ac.maxPerRoom {
+		return nil, false
+	}
+
+	ac.active++
+	ac.perRoom[roomID]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { ac.release(roomID) })
+	}, true
+}
+
+func (ac *AdmissionController) release(roomID ID) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	ac.active--
+	if ac.perRoom[roomID] <= 1 {
+		delete(ac.perRoom, roomID)
+	} else {
+		ac.perRoom[roomID]--
+	}
+}
+
+// Active returns the number of admitted connections
+func (ac *AdmissionController) Active() int {
+	if ac == nil {
+		return 0
+	}
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	return ac.active
+}
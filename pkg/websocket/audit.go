@@ -0,0 +1,129 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"time"
+)
+
+// maxAuditSources bounds how many distinct sources are tracked per room
+const maxAuditSources = 256
+
+// PasswordAttemptSource aggregates failed password attempts from one source
+type PasswordAttemptSource struct {
+	LastAttempt time.Time `json:"last_attempt" example:"2024-01-01T12:00:00Z"`
+	SourceHash  string    `json:"source_hash" example:"3f0c2b8d9a105c1e"`
+	Count       int       `json:"count" example:"3"`
+}
+
+// PasswordAudit summarizes failed join-password attempts in a room
+type PasswordAudit struct {
+	LastAttempt    time.Time               `json:"last_attempt,omitzero"`
+	Sources        []PasswordAttemptSource `json:"sources"`
+	FailedAttempts int                     `json:"failed_attempts" example:"12"`
+}
+
+// PasswordAlertFunc is called when failed attempts in a room reach the alert threshold
+type PasswordAlertFunc func(room *Room, audit PasswordAudit)
+
+type passwordAuditLog struct {
+	lastAttempt time.Time
+	sources     map[string]*PasswordAttemptSource
+	alert       PasswordAlertFunc
+	salt        []byte
+	failed      int
+	threshold   int
+}
+
+// WithPasswordAlert calls alert every time another threshold failed password attempts are recorded.
+// A non-positive threshold disables alerting.
+func WithPasswordAlert(threshold int, alert PasswordAlertFunc) RoomOption {
+	return func(r *Room) {
+		r.audit.threshold = threshold
+		r.audit.alert = alert
+	}
+}
+
+// hashSource hashes a client address with a per-room salt so hosts can tell sources apart without seeing IPs
+func (a *passwordAuditLog) hashSource(source string) string {
+	if a.salt == nil {
+		a.salt = make([]byte, 16)
+		_, _ = rand.Read(a.salt)
+	}
+	sum := sha256.Sum256(append(append([]byte{}, a.salt...), source...))
+	return hex.EncodeToString(sum[:8])
+}
+
+// RecordFailedPassword registers a failed join-password attempt from source (typically the client IP)
+func (r *Room) RecordFailedPassword(source string) {
+	now := time.Now()
+
+	r.mu.Lock()
+	a := &r.audit
+	if a.sources == nil {
+		a.sources = make(map[string]*PasswordAttemptSource)
+	}
+	a.failed++
+	a.lastAttempt = now
+	hash := a.hashSource(source)
+	entry, ok := a.sources[hash]
+	if !ok && len(a.sources) < maxAuditSources {
+		entry = &PasswordAttemptSource{SourceHash: hash}
+		a.sources[hash] = entry
+	}
+	if entry != nil {
+		entry.Count++
+		entry.LastAttempt = now
+	}
+	alert := a.alert
+	fire := alert != nil && a.threshold > 0 && a.failed%a.threshold == 0
+	r.mu.Unlock()
+
+	if !fire {
+		return
+	}
+	audit := r.PasswordAudit()
+	alert(r, audit)
+	r.broadcastToHosts("password_alert", audit)
+}
+
+// PasswordAudit returns failed password attempts, most active sources first
+func (r *Room) PasswordAudit() PasswordAudit {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	audit := PasswordAudit{
+		FailedAttempts: r.audit.failed,
+		LastAttempt:    r.audit.lastAttempt,
+		Sources:        make([]PasswordAttemptSource, 0, len(r.audit.sources)),
+	}
+	for _, source := range r.audit.sources {
+		audit.Sources = append(audit.Sources, *source)
+	}
+	sort.Slice(audit.Sources, func(i, j int) bool {
+		if audit.Sources[i].Count != audit.Sources[j].Count {
+			return audit.Sources[i].Count > audit.Sources[j].Count
+		}
+		return audit.Sources[i].SourceHash < audit.Sources[j].SourceHash
+	})
+	return audit
+}
+
+// broadcastToHosts sends a notification to connected hosts only
+func (r *Room) broadcastToHosts(msgType string, payload interface{}) {
+	msg := mustMarshal(Message{Type: msgType, Data: mustMarshal(payload)})
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for client := range r.Clients {
+		if !client.IsHost() {
+			continue
+		}
+		select {
+		case client.Send <- msg:
+		default:
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// ErrCodeAuthFailed is sent when an auth message carries an invalid host token
+const ErrCodeAuthFailed = "auth_failed"
+
+// Transports and reasons reported to AuthMetrics
+const (
+	AuthTransportREST = "rest"
+	AuthTransportWS   = "ws"
+
+	AuthFailureMissingToken    = "missing_token"
+	AuthFailureInvalidToken    = "invalid_token"
+	AuthFailureRevokedToken    = "revoked_token"
+	AuthFailureInvalidPassword = "invalid_password"
+	AuthFailureInvalidTicket   = "invalid_ticket"
+	AuthFailureTicketRequired  = "ticket_required"
+	AuthFailureInvalidState    = "invalid_state"
+	AuthFailureInvalidAPIKey   = "invalid_api_key"
+	AuthFailureInvalidAdminKey = "invalid_admin_key"
+)
+
+var errInvalidHostToken = errors.New("invalid host token")
+
+// AuthMetrics counts rejected credentials so brute-force attempts and
+// misconfigured clients can be alerted on
+type AuthMetrics interface {
+	AuthFailed(transport, reason string)
+}
+
+// authFailureReason maps a host token validation error to an AuthMetrics reason
+func authFailureReason(err error) string {
+	if errors.Is(err, ErrNotHost) {
+		return AuthFailureRevokedToken
+	}
+	return AuthFailureInvalidToken
+}
+
+// AuthMessage Sent by a connected client to upgrade to host privileges
+type AuthMessage struct {
+	HostToken string `json:"host_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// AuthResult Sent to the client after a successful auth message, and whenever its host
+// privileges are granted or revoked. HostToken is set when privileges are granted by another host.
+type AuthResult struct {
+	HostToken string `json:"host_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	IsHost    bool   `json:"is_host" example:"true"`
+}
+
+// handleAuthMessage validates a host token sent over the connection and grants host privileges
+func (c *Client) handleAuthMessage(message Message) {
+	var auth AuthMessage
+	if err := json.Unmarshal(message.Data, &auth); err != nil || auth.HostToken == "" {
+		if c.authMetrics != nil {
+			c.authMetrics.AuthFailed(AuthTransportWS, AuthFailureMissingToken)
+		}
+		c.sendError(ErrCodeAuthFailed, "host token is required")
+		return
+	}
+
+	hostID, err := validateHostToken(auth.HostToken, strconv.Itoa(int(c.Room.ID)), c.tokenKeys, c.Room)
+	if err != nil {
+		if c.authMetrics != nil {
+			c.authMetrics.AuthFailed(AuthTransportWS, authFailureReason(err))
+		}
+		c.sendError(ErrCodeAuthFailed, "invalid host token")
+		return
+	}
+
+	c.Room.mu.Lock()
+	c.hostID = hostID
+	c.isHost.Store(true)
+	c.Room.mu.Unlock()
+
+	c.trySend(mustMarshal(Message{Type: "auth", Data: mustMarshal(AuthResult{IsHost: true})}))
+}
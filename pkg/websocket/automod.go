@@ -0,0 +1,231 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ModAction is what an auto-moderation rule does with a chat message it matches
+type ModAction string
+
+const (
+	ModDelete ModAction = "delete" // drop the message and tell the sender
+	ModMute   ModAction = "mute"   // drop the message and mute the sender
+	ModKick   ModAction = "kick"   // drop the message and kick the sender
+	ModFlag   ModAction = "flag"   // deliver the message and record a flagged event for hosts
+)
+
+// Auto-moderation limits of a room
+const (
+	MaxModRules         = 20
+	MaxModRuleName      = 50
+	MaxModRuleWords     = 50
+	MaxModPatternLength = 200
+	MaxModSenderAge     = 24 * time.Hour
+	MaxModMessages      = 100
+	MaxModWindow        = time.Hour
+)
+
+// AutoModName is the built-in moderator named in mute and kick notifications of
+// rule actions, see Branding
+const AutoModName = "automod"
+
+// ErrCodeAutoModerated is sent to a member whose chat message was removed by a rule
+const ErrCodeAutoModerated = "auto_moderated"
+
+// EventAutoModerated is recorded in the event feed, for hosts only, when a rule
+// deletes a message, mutes or kicks its sender
+const EventAutoModerated = "automod"
+
+var ErrInvalidModRules = errors.New("invalid auto-moderation rules")
+
+// ModRule is a host-defined auto-moderation rule. A chat message matches when every
+// condition set on the rule holds; a rule must set at least one condition.
+type ModRule struct {
+	Name    string    `json:"name" example:"no links from newcomers"`
+	Action  ModAction `json:"action" example:"delete"`
+	Pattern string    `json:"pattern,omitempty" example:"https?://"` // regular expression the text must match
+	Words   []string  `json:"words,omitempty" example:"casino"`      // the text contains one of the words, case-insensitively
+	// MaxSenderAgeSeconds matches senders that joined the room at most this long ago
+	MaxSenderAgeSeconds int `json:"max_sender_age_seconds,omitempty" example:"300"`
+	// MaxMessages and WindowSeconds match senders that sent more than MaxMessages
+	// chat messages, this one included, within the last WindowSeconds
+	MaxMessages   int `json:"max_messages,omitempty" example:"5"`
+	WindowSeconds int `json:"window_seconds,omitempty" example:"10"`
+}
+
+// AutoModEvent is the host-only event recorded when a rule acts on a message
+type AutoModEvent struct {
+	Username string    `json:"username" example:"JohnDoe"`
+	Text     string    `json:"text" example:"cheap casino chips"`
+	Rule     string    `json:"rule" example:"no links from newcomers"`
+	Action   ModAction `json:"action" example:"delete"`
+}
+
+// modRule is a ModRule with its compiled patterns, guarded by Room.mu
+type modRule struct {
+	words   *wordMatcher
+	pattern *regexp.Regexp
+	ModRule
+}
+
+// ValidateModRules checks rules against the auto-moderation limits
+func ValidateModRules(rules []ModRule) []ValidationError {
+	if len(rules) > MaxModRules {
+		return []ValidationError{{Field: "rules", Message: fmt.Sprintf("at most %d rules are allowed", MaxModRules)}}
+	}
+	var errs []ValidationError
+	for i, rule := range rules {
+		field := func(name string) string { return fmt.Sprintf("rules[%d].%s", i, name) }
+		if name := strings.TrimSpace(rule.Name); name == "" || len(name) > MaxModRuleName {
+			errs = append(errs, ValidationError{Field: field("name"), Message: "name must be 1-50 characters"})
+		}
+		switch rule.Action {
+		case ModDelete, ModMute, ModKick, ModFlag:
+		default:
+			errs = append(errs, ValidationError{Field: field("action"), Message: "action must be delete, mute, kick or flag"})
+		}
+		if len(rule.Words) > MaxModRuleWords {
+			errs = append(errs, ValidationError{Field: field("words"), Message: "too many words"})
+		}
+		for _, word := range rule.Words {
+			if word = strings.TrimSpace(word); word == "" || len(word) > MaxFilterWordLength {
+				errs = append(errs, ValidationError{Field: field("words"), Message: "words must be 1-50 characters"})
+				break
+			}
+		}
+		if len(rule.Pattern) > MaxModPatternLength {
+			errs = append(errs, ValidationError{Field: field("pattern"), Message: "pattern is too long"})
+		} else if _, err := regexp.Compile(rule.Pattern); err != nil {
+			errs = append(errs, ValidationError{Field: field("pattern"), Message: "pattern is not a valid regular expression"})
+		}
+		if rule.MaxSenderAgeSeconds < 0 || time.Duration(rule.MaxSenderAgeSeconds)*time.Second > MaxModSenderAge {
+			errs = append(errs, ValidationError{Field: field("max_sender_age_seconds"), Message: "sender age is out of valid range"})
+		}
+		if rule.MaxMessages < 0 || rule.MaxMessages > MaxModMessages {
+			errs = append(errs, ValidationError{Field: field("max_messages"), Message: "max_messages is out of valid range"})
+		}
+		if rule.WindowSeconds < 0 || time.Duration(rule.WindowSeconds)*time.Second > MaxModWindow {
+			errs = append(errs, ValidationError{Field: field("window_seconds"), Message: "window is out of valid range"})
+		}
+		if (rule.MaxMessages > 0) != (rule.WindowSeconds > 0) {
+			errs = append(errs, ValidationError{Field: field("window_seconds"), Message: "max_messages and window_seconds must be set together"})
+		}
+		if len(rule.Words) == 0 && rule.Pattern == "" && rule.MaxSenderAgeSeconds == 0 && rule.MaxMessages == 0 {
+			errs = append(errs, ValidationError{Field: field("name"), Message: "rule has no conditions"})
+		}
+	}
+	return errs
+}
+
+// SetModRules replaces the room's auto-moderation rules. Rules are evaluated in
+// order on chat messages of members; hosts are never moderated.
+func (r *Room) SetModRules(rules []ModRule) error {
+	if len(ValidateModRules(rules)) > 0 {
+		return ErrInvalidModRules
+	}
+	compiled := make([]modRule, len(rules))
+	for i, rule := range rules {
+		words := make([]string, len(rule.Words))
+		for j, word := range rule.Words {
+			words[j] = strings.TrimSpace(word)
+		}
+		rule.Name = strings.TrimSpace(rule.Name)
+		rule.Words = words
+		compiled[i] = modRule{ModRule: rule, words: wordPattern(words)}
+		if rule.Pattern != "" {
+			compiled[i].pattern = regexp.MustCompile(rule.Pattern)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.modRules = compiled
+	return nil
+}
+
+// ModRules returns the room's auto-moderation rules
+func (r *Room) ModRules() []ModRule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rules := make([]ModRule, len(r.modRules))
+	for i, rule := range r.modRules {
+		rules[i] = rule.ModRule
+		rules[i].Words = append([]string{}, rule.Words...)
+	}
+	return rules
+}
+
+// matches reports whether text sent by a member who joined at joinedAt matches the rule.
+// recent holds the send times of the sender's chat messages, oldest first.
+func (m *modRule) matches(text string, joinedAt time.Time, recent []time.Time, now time.Time) bool {
+	if m.words != nil && !m.words.MatchString(text) {
+		return false
+	}
+	if m.pattern != nil && !m.pattern.MatchString(text) {
+		return false
+	}
+	if m.MaxSenderAgeSeconds > 0 && now.Sub(joinedAt) > time.Duration(m.MaxSenderAgeSeconds)*time.Second {
+		return false
+	}
+	if m.MaxMessages > 0 {
+		since := now.Add(-time.Duration(m.WindowSeconds) * time.Second)
+		sent := 0
+		for _, at := range recent {
+			if at.After(since) {
+				sent++
+			}
+		}
+		if sent <= m.MaxMessages {
+			return false
+		}
+	}
+	return true
+}
+
+// moderate runs the room's rules on a chat message of c and reports whether the
+// message may be delivered. Flag rules record the message and evaluation goes on;
+// the first other matching rule acts on the message and stops it.
+func (c *Client) moderate(text string, now time.Time) bool {
+	c.recentChats = append(c.recentChats, now)
+	if drop := len(c.recentChats) - (MaxModMessages + 1); drop > 0 {
+		c.recentChats = c.recentChats[drop:]
+	}
+	if c.IsHost() {
+		return true
+	}
+
+	r := c.Room
+	r.mu.RLock()
+	rules := r.modRules
+	joinedAt := c.joinedAt
+	r.mu.RUnlock()
+
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.matches(text, joinedAt, c.recentChats, now) {
+			continue
+		}
+		if rule.Action == ModFlag {
+			r.recordEvent(EventFlagged, FlaggedEvent{Username: c.Username, Text: text, Rule: rule.Name})
+			continue
+		}
+
+		log.Printf("Rule %q applied %s to chat message from %s in room %d", rule.Name, rule.Action, c.Username, r.ID)
+		r.recordEvent(EventAutoModerated, AutoModEvent{Username: c.Username, Text: text, Rule: rule.Name, Action: rule.Action})
+		switch rule.Action {
+		case ModDelete:
+			c.sendError(ErrCodeAutoModerated, fmt.Sprintf("message removed by rule %q", rule.Name))
+		case ModMute:
+			_ = r.SetMuted(c.Username, true, r.brand().AutoModName)
+		case ModKick:
+			r.KickClients([]string{c.Username}, r.brand().AutoModName)
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+package websocket
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"time"
+)
+
+// MaxBans limits the ban registry of a single room
+const MaxBans = 1000
+
+// maxBanPseudonyms bounds the pseudonyms a ban keeps, the oldest being dropped
+const maxBanPseudonyms = 8
+
+var (
+	ErrBanTargetRequired = errors.New("a username or IP address is required")
+	ErrTooManyBans       = errors.New("room has too many bans")
+)
+
+// Ban keeps a username or an IP address out of the room until it expires.
+// Pseudonyms holds the pseudonymous IDs of the members it disconnected, which
+// are kept out as well whatever username they come back with.
+type Ban struct {
+	CreatedAt  time.Time  `json:"created_at" example:"2024-01-01T12:00:00Z"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" example:"2024-01-01T13:00:00Z"` // nil for a permanent ban
+	Username   string     `json:"username,omitempty" example:"spammer1"`
+	IP         string     `json:"ip,omitempty" example:"203.0.113.7"`
+	BannedBy   string     `json:"banned_by,omitempty" example:"host"`
+	Pseudonyms []string   `json:"pseudonyms,omitempty" example:"anon_Xr3kQ9vT2mLw8pZc1nYb0A"`
+}
+
+func (b *Ban) expired(now time.Time) bool {
+	return b.ExpiresAt != nil && !now.Before(*b.ExpiresAt)
+}
+
+func banKey(username, ip string) string {
+	if username != "" {
+		return "user:" + username
+	}
+	return "ip:" + ip
+}
+
+// Ban bans username or ip, whichever is set, for duration (0 bans permanently)
+// and disconnects matching members. Hosts are neither disconnected nor kept out.
+// Banning the same target again replaces the previous ban.
+func (r *Room) Ban(username, ip string, duration time.Duration, bannedBy string) (Ban, error) {
+	if (username == "") == (ip == "") {
+		return Ban{}, ErrBanTargetRequired
+	}
+	now := time.Now()
+	ban := Ban{Username: username, IP: ip, BannedBy: bannedBy, CreatedAt: now}
+	if duration > 0 {
+		expiresAt := now.Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	var targets []string
+	r.mu.Lock()
+	r.pruneBans(now)
+	key := banKey(username, ip)
+	if _, exists := r.bans[key]; !exists && len(r.bans) >= MaxBans {
+		r.mu.Unlock()
+		return Ban{}, ErrTooManyBans
+	}
+	if r.bans == nil {
+		r.bans = make(map[string]*Ban)
+	}
+	for client := range r.Clients {
+		if client.IsHost() || !((username != "" && client.Username == username) || (ip != "" && client.remoteIP == ip)) {
+			continue
+		}
+		for _, pseudonym := range []string{client.pseudonym, client.fingerprint} {
+			if pseudonym != "" && !slices.Contains(ban.Pseudonyms, pseudonym) {
+				ban.Pseudonyms = append(ban.Pseudonyms, pseudonym)
+			}
+		}
+	}
+	ban.Pseudonyms = ban.Pseudonyms[max(len(ban.Pseudonyms)-maxBanPseudonyms, 0):]
+	r.bans[key] = &ban
+	for client := range r.Clients {
+		if (username != "" && client.Username == username) || (ip != "" && client.remoteIP == ip) ||
+			(client.pseudonym != "" && !client.IsHost() && slices.Contains(ban.Pseudonyms, client.pseudonym)) {
+			targets = append(targets, client.Username)
+		}
+	}
+	r.mu.Unlock()
+	r.recordEvent(EventBan, ban)
+	r.saveModeration()
+
+	if len(targets) > 0 {
+		r.KickClients(targets, bannedBy)
+	}
+	return ban, nil
+}
+
+// Unban lifts the ban on username or ip and reports whether one was in place
+func (r *Room) Unban(username, ip string) bool {
+	if (username == "") == (ip == "") {
+		return false
+	}
+	r.mu.Lock()
+	r.pruneBans(time.Now())
+	key := banKey(username, ip)
+	_, ok := r.bans[key]
+	delete(r.bans, key)
+	r.mu.Unlock()
+
+	if ok {
+		r.recordEvent(EventUnban, UnbanEvent{Username: username, IP: ip})
+		r.saveModeration()
+	}
+	return ok
+}
+
+// Bans returns the bans in effect, oldest first
+func (r *Room) Bans() []Ban {
+	r.mu.Lock()
+	r.pruneBans(time.Now())
+	bans := make([]Ban, 0, len(r.bans))
+	for _, ban := range r.bans {
+		bans = append(bans, *ban)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].CreatedAt.Before(bans[j].CreatedAt)
+	})
+	return bans
+}
+
+// IsBanned returns the ban that keeps username, ip or one of pseudonyms out of the
+// room, if any. The first pseudonym is the current one: a ban matching an older
+// one is renewed with it, so bans outlast pseudonym rotation while the banned
+// member keeps trying to return.
+func (r *Room) IsBanned(username, ip string, pseudonyms ...string) (Ban, bool) {
+	r.refreshModeration()
+	ban, renewed, ok := r.findBan(username, ip, pseudonyms)
+	if renewed {
+		r.saveModeration()
+	}
+	return ban, ok
+}
+
+// findBan returns the ban matching username, ip or pseudonyms and whether it was
+// renewed with the current pseudonym
+func (r *Room) findBan(username, ip string, pseudonyms []string) (Ban, bool, bool) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range []string{banKey(username, ""), banKey("", ip)} {
+		if ban, ok := r.bans[key]; ok && !ban.expired(now) {
+			return *ban, false, true
+		}
+	}
+	for _, ban := range r.bans {
+		if ban.expired(now) || !slices.ContainsFunc(pseudonyms, func(p string) bool {
+			return p != "" && slices.Contains(ban.Pseudonyms, p)
+		}) {
+			continue
+		}
+		renewed := false
+		if pseudonyms[0] != "" && !slices.Contains(ban.Pseudonyms, pseudonyms[0]) {
+			ban.Pseudonyms = append(ban.Pseudonyms, pseudonyms[0])
+			ban.Pseudonyms = ban.Pseudonyms[max(len(ban.Pseudonyms)-maxBanPseudonyms, 0):]
+			renewed = true
+		}
+		return *ban, renewed, true
+	}
+	return Ban{}, false, false
+}
+
+// pruneBans drops expired bans. Caller must hold r.mu.
+func (r *Room) pruneBans(now time.Time) {
+	for key, ban := range r.bans {
+		if ban.expired(now) {
+			delete(r.bans, key)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package websocket
+
+import (
+	"errors"
+
+	"github.com/gorilla/websocket"
+)
+
+// Kinds of binary messages, carried in their first byte. Clients send the kind
+// followed by the payload; the room relays it as a binary envelope naming the sender.
+const (
+	BinaryAudio     byte = 0x01 // voice snippet
+	BinaryFileChunk byte = 0x02 // chunk of a file
+)
+
+// ErrCodeInvalidBinary is sent to a client whose binary message has no known kind or payload
+const ErrCodeInvalidBinary = "invalid_binary"
+
+var errInvalidBinaryEnvelope = errors.New("invalid binary envelope")
+
+// isBinaryKind reports whether kind is a known binary message kind
+func isBinaryKind(kind byte) bool {
+	return kind == BinaryAudio || kind == BinaryFileChunk
+}
+
+// isBinary reports whether a queued message is a binary envelope. Other messages
+// are JSON and start with '{', which is never a binary kind.
+func isBinary(msg []byte) bool {
+	return len(msg) > 0 && isBinaryKind(msg[0])
+}
+
+// frameType returns the WebSocket frame type a queued message is written with
+func frameType(msg []byte) int {
+	if isBinary(msg) {
+		return websocket.BinaryMessage
+	}
+	return websocket.TextMessage
+}
+
+// binaryEnvelope frames a binary message for the room: the kind, the length of
+// the sender's username in one byte, the username and the payload
+func binaryEnvelope(kind byte, username string, payload []byte) []byte {
+	msg := make([]byte, 0, 2+len(username)+len(payload))
+	msg = append(msg, kind, byte(len(username)))
+	msg = append(msg, username...)
+	return append(msg, payload...)
+}
+
+// ParseBinaryEnvelope splits a binary message relayed by a room into its kind,
+// sender and payload
+func ParseBinaryEnvelope(msg []byte) (kind byte, username string, payload []byte, err error) {
+	if len(msg) < 2 || !isBinaryKind(msg[0]) || len(msg) < 2+int(msg[1]) {
+		return 0, "", nil, errInvalidBinaryEnvelope
+	}
+	end := 2 + int(msg[1])
+	return msg[0], string(msg[2:end]), msg[end:], nil
+}
+
+// handleBinaryMessage relays a binary message to the room. It follows the rules
+// of chat messages for mutes and broadcast quotas, but is not kept in history
+// and carries no sequence number, so it is not replayed on reconnect.
+func (c *Client) handleBinaryMessage(msg []byte) {
+	if len(msg) < 2 || !isBinaryKind(msg[0]) {
+		c.sendError(ErrCodeInvalidBinary, "binary messages start with a known kind followed by a payload")
+		return
+	}
+	if c.IsMuted() {
+		c.sendError(ErrCodeMuted, "you are muted by the host")
+		return
+	}
+	if !c.reserveBroadcast() {
+		return
+	}
+	c.Room.enqueue(c.spanContext(), binaryEnvelope(msg[0], c.Username, msg[1:]))
+}
@@ -0,0 +1,92 @@
+package websocket
+
+import (
+	"errors"
+	"strings"
+)
+
+// Default user-visible names, used unless the hub sets a Branding
+const (
+	DefaultAppName  = "Chatters"
+	DefaultBotName  = "Bot"
+	DefaultHostName = "host"
+)
+
+// Branding holds the user-visible names and texts the server generates, so
+// white-label deployments can change them without forking the code
+type Branding struct {
+	AppName      string // product name shown by clients
+	DefaultName  string // username of clients that join without one
+	BotName      string // sender of injected messages that do not name one
+	HostName     string // moderator named when hosts act through the REST API
+	AutoModName  string // moderator named in actions of auto-moderation rules
+	SystemPrefix string // prepended to the welcome and error texts the server sends, empty for none
+}
+
+// defaultBranding is used by hubs and rooms without a Branding
+var defaultBranding = Branding{
+	AppName:     DefaultAppName,
+	DefaultName: DefaultName,
+	BotName:     DefaultBotName,
+	HostName:    DefaultHostName,
+	AutoModName: AutoModName,
+}
+
+// DefaultBranding returns the built-in names
+func DefaultBranding() Branding {
+	return defaultBranding
+}
+
+// Validate checks that every name is set and could be shown as a username
+func (b Branding) Validate() error {
+	names := []struct{ field, name string }{
+		{"app name", b.AppName},
+		{"default username", b.DefaultName},
+		{"bot name", b.BotName},
+		{"host name", b.HostName},
+		{"automod name", b.AutoModName},
+	}
+	for _, n := range names {
+		if strings.TrimSpace(n.name) == "" {
+			return errors.New(n.field + " is empty")
+		}
+		if len(n.name) > MaxUsernameLength {
+			return errors.New(n.field + " is too long")
+		}
+		if strings.ContainsAny(n.name, "<>\"'&") {
+			return errors.New(n.field + " contains invalid characters")
+		}
+	}
+	if len(b.SystemPrefix) > MaxUsernameLength {
+		return errors.New("system prefix is too long")
+	}
+	return nil
+}
+
+// WithBranding sets the names the room uses in the notifications it generates
+func WithBranding(branding *Branding) RoomOption {
+	return func(r *Room) {
+		r.branding = branding
+	}
+}
+
+// brand returns the branding of the room
+func (r *Room) brand() *Branding {
+	if r.branding == nil {
+		return &defaultBranding
+	}
+	return r.branding
+}
+
+// systemText prefixes text generated by the server with the room's system prefix
+func (r *Room) systemText(text string) string {
+	return r.brand().SystemPrefix + text
+}
+
+// Brand returns the branding of the hub, the built-in names if none is set
+func (h *Hub) Brand() Branding {
+	if h.Branding == nil {
+		return defaultBranding
+	}
+	return *h.Branding
+}
@@ -0,0 +1,241 @@
+package websocket
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxBreakouts limits the breakout rooms of a single parent room
+const MaxBreakouts = 20
+
+// Reasons sent in move instructions
+const (
+	MoveToBreakout = "breakout"
+	MoveRecall     = "recall"
+)
+
+var (
+	ErrTooManyBreakouts = errors.New("room has too many breakout rooms")
+	ErrBreakoutNotFound = errors.New("breakout room not found")
+	ErrNestedBreakout   = errors.New("breakout rooms cannot have breakout rooms")
+	ErrRoomIDTaken      = errors.New("room ID is already in use")
+	ErrRoomQuota        = errors.New("room quota exceeded")
+)
+
+// MoveNotification Sent to a member that should leave its room and join RoomID,
+// redeeming Ticket in place of the room password
+type MoveNotification struct {
+	ExpiresAt time.Time `json:"expires_at" example:"2024-01-01T12:00:30Z"`
+	Ticket    string    `json:"ticket" example:"pQ3v0bX2..."`
+	Reason    string    `json:"reason" example:"breakout"`
+	RoomID    ID        `json:"room_id" example:"654321"`
+}
+
+// MoveResult reports whether a member was sent a move instruction
+type MoveResult struct {
+	Username string
+	Reason   string
+	Moved    bool
+}
+
+// BreakoutInfo describes a breakout room of a parent room
+type BreakoutInfo struct {
+	RoomID      ID  `json:"room_id" example:"654321"`
+	OnlineCount int `json:"online_count" example:"4"`
+}
+
+// breakoutLinks relates parent rooms to their breakout rooms
+type breakoutLinks struct {
+	children map[ID][]ID
+	parents  map[ID]ID
+	mu       sync.Mutex
+}
+
+// CreateBreakout creates room id as a breakout room of parent. The breakout room
+// shares the parent's password and counts against the parent's tenant quota.
+func (h *Hub) CreateBreakout(parent *Room, id ID, metrics MetricsNotifier, opts ...RoomOption) (*Room, error) {
+	h.breakouts.mu.Lock()
+	err := h.breakouts.check(parent.ID)
+	h.breakouts.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	parent.mu.RLock()
+	hashedPassword := parent.HashedPassword
+	parent.mu.RUnlock()
+	if hashedPassword != "" {
+		opts = append(opts, WithPassword(hashedPassword))
+	}
+	releaseRoom := func() {}
+	if parent.tenants != nil {
+		var ok bool
+		if releaseRoom, ok = parent.tenants.AcquireRoom(parent.tenant); !ok {
+			return nil, ErrRoomQuota
+		}
+		opts = append(opts, WithTenant(parent.tenants, parent.tenant, releaseRoom))
+	}
+
+	room, created := h.CreateRoom(id, metrics, opts...)
+	if !created {
+		releaseRoom()
+		return nil, ErrRoomIDTaken
+	}
+	if err := h.LinkBreakout(parent.ID, id); err != nil {
+		h.DeleteRoom(id)
+		return nil, err
+	}
+	return room, nil
+}
+
+// LinkBreakout makes child a breakout room of parent
+func (h *Hub) LinkBreakout(parent, child ID) error {
+	l := &h.breakouts
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.check(parent); err != nil {
+		return err
+	}
+	if l.children == nil {
+		l.children = make(map[ID][]ID)
+		l.parents = make(map[ID]ID)
+	}
+	l.children[parent] = append(l.children[parent], child)
+	l.parents[child] = parent
+	return nil
+}
+
+// check reports whether parent may have another breakout room. Caller must hold l.mu.
+func (l *breakoutLinks) check(parent ID) error {
+	if _, nested := l.parents[parent]; nested {
+		return ErrNestedBreakout
+	}
+	if len(l.children[parent]) >= MaxBreakouts {
+		return ErrTooManyBreakouts
+	}
+	return nil
+}
+
+// Breakouts returns the live breakout rooms of parent ordered by room ID
+func (h *Hub) Breakouts(parent ID) []*Room {
+	h.breakouts.mu.Lock()
+	ids := append([]ID{}, h.breakouts.children[parent]...)
+	h.breakouts.mu.Unlock()
+
+	rooms := make([]*Room, 0, len(ids))
+	for _, id := range ids {
+		if room, ok := h.GetRoom(id); ok {
+			rooms = append(rooms, room)
+		}
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].ID < rooms[j].ID })
+	return rooms
+}
+
+// Breakout returns breakout room id of parent, ErrBreakoutNotFound if id is not one
+func (h *Hub) Breakout(parent, id ID) (*Room, error) {
+	h.breakouts.mu.Lock()
+	parentID, linked := h.breakouts.parents[id]
+	h.breakouts.mu.Unlock()
+	room, ok := h.GetRoom(id)
+	if !linked || parentID != parent || !ok {
+		return nil, ErrBreakoutNotFound
+	}
+	return room, nil
+}
+
+// ParentRoom returns the ID of the room child is a breakout of
+func (h *Hub) ParentRoom(child ID) (ID, bool) {
+	h.breakouts.mu.Lock()
+	defer h.breakouts.mu.Unlock()
+	parent, ok := h.breakouts.parents[child]
+	return parent, ok
+}
+
+// unlinkRoom forgets the links of a removed room and returns its breakout rooms
+func (h *Hub) unlinkRoom(id ID) []ID {
+	l := &h.breakouts
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if parent, ok := l.parents[id]; ok {
+		delete(l.parents, id)
+		siblings := l.children[parent]
+		for i, sibling := range siblings {
+			if sibling == id {
+				l.children[parent] = append(siblings[:i:i], siblings[i+1:]...)
+				break
+			}
+		}
+	}
+	children := l.children[id]
+	delete(l.children, id)
+	for _, child := range children {
+		delete(l.parents, child)
+	}
+	return children
+}
+
+// roomRemoved ends the occupancy history of a removed room, unlinks it and
+// deletes its breakout rooms with it
+func (h *Hub) roomRemoved(id ID) {
+	h.occupancyEnded(id)
+	for _, child := range h.unlinkRoom(id) {
+		h.DeleteRoom(child)
+	}
+}
+
+// MoveClients instructs the listed members of r, or all of them if usernames is nil,
+// to join the room to with a join ticket issued for each of them. Results are
+// returned in input order, or per member when moving everyone.
+func (r *Room) MoveClients(usernames []string, to ID, reason string, tickets *TicketStore) []MoveResult {
+	r.mu.RLock()
+	var targets []*Client
+	for client := range r.Clients {
+		if usernames == nil || slices.Contains(usernames, client.Username) {
+			targets = append(targets, client)
+		}
+	}
+	r.mu.RUnlock()
+
+	moved := make(map[string]bool, len(targets))
+	var results []MoveResult
+	for _, client := range targets {
+		ticket, expiresAt, err := tickets.Issue(to)
+		if err != nil || !client.trySend(mustMarshal(Message{Type: "move", Data: mustMarshal(MoveNotification{
+			RoomID:    to,
+			Ticket:    ticket,
+			ExpiresAt: expiresAt,
+			Reason:    reason,
+		})})) {
+			continue
+		}
+		moved[client.Username] = true
+		if usernames == nil {
+			results = append(results, MoveResult{Username: client.Username, Moved: true})
+		}
+	}
+	if usernames == nil {
+		return results
+	}
+	for _, username := range usernames {
+		result := MoveResult{Username: username, Moved: moved[username]}
+		if !result.Moved {
+			result.Reason = "user not found in room"
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// RecallBreakouts instructs every member of the breakout rooms of parent to
+// return to it and returns how many were instructed
+func (h *Hub) RecallBreakouts(parent ID, tickets *TicketStore) int {
+	recalled := 0
+	for _, room := range h.Breakouts(parent) {
+		recalled += len(room.MoveClients(nil, parent, MoveRecall, tickets))
+	}
+	return recalled
+}
@@ -0,0 +1,58 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// brokerPublishTimeout bounds a single publish to the broker
+const brokerPublishTimeout = 5 * time.Second
+
+// Broker fans out room broadcasts between server instances hosting the same room ID.
+// Every published message is delivered to all subscribers of the room, including the publisher.
+// Sequenced messages take the next sequence number of the room, shared by all
+// instances, and are delivered in that order; other messages are delivered with seq 0.
+type Broker interface {
+	Publish(ctx context.Context, roomID ID, msg []byte, sequenced bool) error
+	Subscribe(roomID ID, deliver func(seq uint64, msg []byte)) (unsubscribe func(), err error)
+	Close() error
+}
+
+// WithBroker routes the room's broadcasts through broker instead of delivering them in-process
+func WithBroker(broker Broker) RoomOption {
+	return func(r *Room) {
+		r.broker = broker
+	}
+}
+
+// subscribe attaches the room to its broker, if any
+func (r *Room) subscribe() error {
+	if r.broker == nil {
+		return nil
+	}
+	unsubscribe, err := r.broker.Subscribe(r.ID, func(seq uint64, msg []byte) {
+		r.sendMessage(context.Background(), msg, seq)
+	})
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.unsubscribe = unsubscribe
+	r.mu.Unlock()
+	return nil
+}
+
+// publish delivers a broadcast through the broker, or directly when there is none
+func (r *Room) publish(ctx context.Context, msg []byte) {
+	if r.broker == nil {
+		r.sendMessage(ctx, msg, 0)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, brokerPublishTimeout)
+	defer cancel()
+	_, sequenced := envelopeOf(msg)
+	if err := r.broker.Publish(ctx, r.ID, msg, sequenced); err != nil {
+		log.Printf("Broker publish failed for room %d: %v", r.ID, err)
+	}
+}
@@ -0,0 +1,40 @@
+package websocket
+
+// ErrCodeRoomFull is sent when a connection is refused because the room is at capacity
+const ErrCodeRoomFull = "room_full"
+
+// WithMaxClients limits the room to n members. Hosts are always admitted. Zero means unlimited.
+func WithMaxClients(n int) RoomOption {
+	return func(r *Room) {
+		r.settings.MaxClients = n
+	}
+}
+
+// HasCapacity reports whether a non-host may join the room. Members within the
+// reconnect grace period keep their seat, so resuming with their token is always allowed.
+func (r *Room) HasCapacity(resumeToken string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.hasCapacity(resumeToken)
+}
+
+// hasCapacity is HasCapacity for callers already holding r.mu
+func (r *Room) hasCapacity(resumeToken string) bool {
+	if r.settings.MaxClients <= 0 {
+		return true
+	}
+	if _, ok := r.pending[resumeToken]; ok && resumeToken != "" {
+		return true
+	}
+	return len(r.Clients)+len(r.pending) < r.settings.MaxClients
+}
+
+// rejectFull tells a client that lost the race for the last seat that it cannot join,
+// then closes its Send channel so the write goroutine flushes the error and disconnects.
+func (c *Client) rejectFull() {
+	select {
+	case c.Send <- c.errorMessage(ErrorNotification{Code: ErrCodeRoomFull, Message: "room is full"}):
+	default:
+	}
+	c.closeSend()
+}
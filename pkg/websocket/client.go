@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -18,61 +20,152 @@ const (
 )
 
 type Client struct {
-	Conn      *websocket.Conn
-	Send      chan []byte
-	Room      *Room
-	Username  string
-	closeOnce sync.Once
-	IsHost    bool
+	Conn             Conn
+	Send             chan []byte
+	control          chan []byte // control broadcasts that found Send full, written first
+	Room             *Room
+	Username         string
+	SessionID        string
+	ConnID           string // server-assigned ID of the connection, logged with its events
+	remoteIP         string
+	pseudonym        string // pseudonymous ID, empty for hosts and if the handler issues none
+	fingerprint      string // fingerprint ID, recorded in bans next to pseudonym
+	resumeToken      string
+	resumeState      *ResumeState // set when the client resumed with a state token
+	states           *StateTokens
+	lastChatAt       time.Time
+	joinedAt         time.Time  // guarded by Room.mu
+	tokenKeys        *TokenKeys // verifies host tokens sent in auth messages
+	authMetrics      AuthMetrics
+	handshakeTimeout time.Duration
+	limiter          *tokenBucket
+	spool            *diskSpool    // broadcasts waiting for room in Send, guarded by Room.seqMu
+	compression      *Compression  // nil unless the client negotiated permessage-deflate
+	wire             *countingConn // network connection under Conn, set with compression
+	limiterVersion   uint64
+	credits          creditBalance
+	release          func()
+	releaseName      func() // frees the username claim, replaced on rename
+	usernames        *UsernameRegistry
+	handler          *SignalingHandler // routes read messages, defaultSignaling if nil
+	receivedAt       time.Time         // arrival of the message being handled, read goroutine only
+	recentChats      []time.Time       // send times of the latest chat messages, read goroutine only
+	rtt              atomic.Int64
+	pingsPending     atomic.Int32  // pings sent since the last pong
+	pings            pendingPings  // nonces of the pings awaiting a pong
+	quality          atomic.Int32  // index in qualityLevels of the last rating
+	lastAck          atomic.Uint64 // last broadcast sequence the client acknowledged
+	closeOnce        sync.Once
+	sendClosed       atomic.Bool
+	closeCode        atomic.Int32 // close code the connection is closed with by closeWith, 0 if none
+	hostID           string       // host ID the client holds privileges with, guarded by Room.mu
+	media            MediaState   // guarded by Room.mu
+	isHost           atomic.Bool  // updated live when hosts are promoted or transferred
+	muted            atomic.Bool
+	departed         bool       // guarded by Room.mu
+	span             trace.Span // lifetime of the connection, nil if it is not traced
+}
+
+// IsHost reports whether the client currently has host privileges
+func (c *Client) IsHost() bool {
+	return c.isHost.Load()
+}
+
+// signaling returns the router of the client's messages
+func (c *Client) signaling() *SignalingHandler {
+	if c.handler != nil {
+		return c.handler
+	}
+	return defaultSignaling
 }
 
 // Read reads messages from WebSocket connection
 func (c *Client) Read() {
+	var readErr error
+	reaped := false
 	defer func() {
+		c.endConnectionSpan(readErr)
+		if reaped {
+			c.closeWith(CloseIdleTimeout)
+		}
 		c.Room.Unregister <- c
 		c.Conn.Close()
+		if c.release != nil {
+			c.release()
+		}
+		if c.releaseName != nil {
+			c.releaseName()
+		}
 	}()
 
 	c.Conn.SetReadLimit(MaxMessageSize)
 
-	c.Conn.SetPongHandler(func(string) error {
+	// Until the first message or pong the client only gets the handshake timeout
+	handshakeDone := false
+	c.Conn.SetReadDeadline(c.initialReadDeadline(time.Now()))
+	c.Conn.SetPongHandler(func(appData string) error {
+		handshakeDone = true
 		c.Conn.SetReadDeadline(time.Now().Add(readDeadline))
+		c.handlePong(appData)
 		return nil
 	})
 	go c.startPing()
 
 	for {
-		c.Conn.SetReadDeadline(time.Now().Add(readDeadline))
-		_, msg, err := c.Conn.ReadMessage()
+		frame, msg, err := c.Conn.ReadMessage()
 		if err != nil {
+			readErr = err
+			reaped = c.reportReaped(err, handshakeDone)
 			break
 		}
+		receivedAt := time.Now()
+		handshakeDone = true
+		c.Conn.SetReadDeadline(receivedAt.Add(readDeadline))
 
-		var message Message
-		if err := json.Unmarshal(msg, &message); err != nil {
+		if !c.allowMessage(receivedAt) {
 			continue
 		}
 
-		switch message.Type {
-		case "chat":
-			c.handleChatMessage(message)
-		case "kick":
-			if !c.IsHost {
-				log.Printf("Non-host %s attempted to send kick message", c.Username)
-				continue
+		if frame == websocket.BinaryMessage {
+			if c.admitMessage("binary") {
+				c.receivedAt = receivedAt
+				c.handleBinaryMessage(msg)
 			}
-			var kick KickMessage
-			if err := json.Unmarshal(message.Data, &kick); err != nil {
-				log.Printf("Failed to unmarshal kick message: %v", err)
-				continue
+			continue
+		}
+
+		var message Message
+		if err := json.Unmarshal(msg, &message); err != nil {
+			if c.signaling().payloadSchemas() != nil {
+				c.rejectPayload("", []ValidationError{{Field: "$", Message: err.Error()}})
 			}
-			c.handleKickMessage(kick)
-		default:
-			c.Room.Broadcast <- msg
+			continue
 		}
+		if !c.admitMessage(message.Type) {
+			continue
+		}
+		c.receivedAt = receivedAt
+		c.signaling().Handle(c, message)
 	}
 }
 
+// admitMessage reports whether a message of msgType may be handled while the room
+// is frozen or migrating, telling the client why it was rejected otherwise
+func (c *Client) admitMessage(msgType string) bool {
+	if !c.IsHost() && c.Room.IsFrozen() && !isControlMessage(msgType) {
+		c.traceMessage("message.rejected", msgType)
+		c.sendError(ErrCodeRoomFrozen, "room is frozen by the host")
+		return false
+	}
+	if c.Room.IsMigrating() && !isControlMessage(msgType) {
+		c.traceMessage("message.rejected", msgType)
+		c.sendError(ErrCodeRoomMigrating, "room is moving to another server")
+		return false
+	}
+	c.traceMessage("message", msgType)
+	return true
+}
+
 func (c *Client) handleChatMessage(message Message) {
 	var chat ChatMessage
 	if err := json.Unmarshal(message.Data, &chat); err != nil {
@@ -84,7 +177,37 @@ func (c *Client) handleChatMessage(message Message) {
 		return
 	}
 
+	if c.IsMuted() {
+		c.sendError(ErrCodeMuted, "you are muted by the host")
+		return
+	}
+
+	if !c.moderate(chat.Text, time.Now()) {
+		return
+	}
+
+	// Commands such as /me broadcast too, so slow mode applies to them as well
+	if slowMode := c.Room.Settings().SlowMode; slowMode > 0 && !c.IsHost() {
+		if time.Since(c.lastChatAt) < slowMode {
+			log.Printf("Slow mode: dropping chat message from %s in room %d", c.Username, c.Room.ID)
+			return
+		}
+	}
+
+	commands := c.signaling().Commands
+	if commands.Dispatch(c, chat.Text) {
+		return
+	}
+	chat.Text = commands.Unescape(chat.Text)
+
+	if !c.reserveBroadcast() {
+		return
+	}
+	c.lastChatAt = time.Now()
+
 	chat.Username = c.Username
+	chat.Bot = false
+	chat.ID = c.Room.recordChat(chat.Username, c.authorID(), chat.Text).ID
 
 	log.Printf("hat message created: %+v", chat)
 
@@ -92,8 +215,9 @@ func (c *Client) handleChatMessage(message Message) {
 	message.Data = chatData
 
 	if newMsg, err := json.Marshal(message); err == nil {
-		c.Room.Broadcast <- newMsg
+		c.Room.enqueue(c.spanContext(), newMsg)
 	}
+	c.Room.dispatchHooks(chat.Username, chat.Text)
 }
 
 func (c *Client) handleKickMessage(kick KickMessage) {
@@ -115,41 +239,56 @@ func (c *Client) handleKickMessage(kick KickMessage) {
 		log.Printf("Target user %s not found in room %d", kick.TargetUsername, c.Room.ID)
 		return
 	}
+	// Co-hosts can't remove each other or the creator
+	if target.IsHost() && !c.isCreator() {
+		c.sendError(ErrCodeKickDenied, "only the room creator may kick hosts")
+		return
+	}
 
-	target.closeOnce.Do(func() {
-		close(target.Send)
-	})
-	target.Conn.Close()
+	c.Room.mu.Lock()
+	target.closeSendWith(CloseKicked)
+	c.Room.mu.Unlock()
+	target.closeWith(CloseKicked)
+	c.Room.logAccess(AccessKick, target, c.Username)
 	c.Room.Unregister <- target
 
-	notification := KickNotification{
+	c.Room.broadcastNotification("kick", KickNotification{
 		TargetUsername: kick.TargetUsername,
 		KickedBy:       c.Username,
-	}
-	notificationData, _ := json.Marshal(notification)
-	broadcastMsg := Message{
-		Type: "kick",
-		Data: notificationData,
-	}
-	broadcastData, _ := json.Marshal(broadcastMsg)
-	c.Room.Broadcast <- broadcastData
+	})
 
+	c.Room.emit(ServerEventUserKicked, kick.TargetUsername, c.Username)
 	log.Printf("User %s kicked by %s in room %d", kick.TargetUsername, c.Username, c.Room.ID)
 }
 
 // Write writes messages to WebSocket connection
 func (c *Client) Write() {
 	defer func() {
-		c.closeOnce.Do(func() {
-			close(c.Send)
-		})
-		c.Conn.Close()
+		// Under the room lock, as trySend only checks membership before sending
+		c.Room.mu.Lock()
+		c.closeSend()
+		c.Room.mu.Unlock()
+		if c.closeCode.Load() == 0 {
+			c.Conn.Close()
+		}
 	}()
 
-	for msg := range c.Send {
+	lastQualityCheck := time.Now()
+	for {
+		msg, ok := c.nextMessage()
+		if !ok {
+			return
+		}
+		if now := time.Now(); now.Sub(lastQualityCheck) >= qualityCheckInterval {
+			c.checkQuality()
+			lastQualityCheck = now
+		}
 		c.Conn.SetWriteDeadline(time.Now().Add(writeDeadline))
-		if err := c.Conn.WriteMessage(websocket.TextMessage, msg); err != nil {
-			log.Printf("Write failed for client %s: %v", c.Username, err)
+		done := c.Room.traceStage(c.spanContext(), StageWrite)
+		err := c.writeMessage(msg)
+		done()
+		if err != nil {
+			log.Printf("Write failed for client %s (conn %s): %v", c.Username, c.ConnID, err)
 			c.Room.Unregister <- c
 			return
 		}
@@ -160,12 +299,14 @@ func (c *Client) startPing() {
 	ticker := time.NewTicker(pingPeriod)
 	defer ticker.Stop()
 
+	// A pong to the first ping completes the handshake of a client that has nothing to send yet
+	if c.handshakeTimeout > 0 && !c.ping() {
+		return
+	}
 	for {
 		select {
 		case <-ticker.C:
-			if err := c.Conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(10*time.Second)); err != nil {
-				log.Printf("Ping failed for client %s: %v", c.Username, err)
-				c.Room.Unregister <- c
+			if !c.ping() {
 				return
 			}
 		case <-c.Room.Stop:
@@ -174,11 +315,58 @@ func (c *Client) startPing() {
 	}
 }
 
-func (c *Client) isClosed() bool {
+// ping sends a heartbeat and unregisters the client if it cannot be written
+func (c *Client) ping() bool {
+	c.pingsPending.Add(1)
+	if err := c.Conn.WriteControl(websocket.PingMessage, c.pings.add(time.Now()), time.Now().Add(10*time.Second)); err != nil {
+		log.Printf("Ping failed for client %s (conn %s): %v", c.Username, c.ConnID, err)
+		c.Room.Unregister <- c
+		return false
+	}
+	c.checkQuality()
+	return true
+}
+
+// trySend queues msg for this client without blocking.
+// The room lock guarantees Send is not closed while the client is registered.
+func (c *Client) trySend(msg []byte) bool {
+	if msg == nil {
+		return false
+	}
+	c.Room.mu.RLock()
+	defer c.Room.mu.RUnlock()
+	if !c.Room.Clients[c] {
+		return false
+	}
 	select {
-	case _, ok := <-c.Send:
-		return !ok
+	case c.Send <- msg:
+		return true
 	default:
 		return false
 	}
 }
+
+// isControlMessage reports whether msgType is a connection-level message
+// that is processed regardless of room moderation state
+func isControlMessage(msgType string) bool {
+	switch msgType {
+	case "ping", "time", "auth", "ack", "read", "preferences", "resend":
+		return true
+	default:
+		return false
+	}
+}
+
+// closeSend closes the send channel once, stopping the write loop
+func (c *Client) closeSend() {
+	c.closeOnce.Do(func() {
+		c.sendClosed.Store(true)
+		close(c.Send)
+	})
+}
+
+// isClosed reports whether the send channel was closed. It must not receive from
+// the channel, which would drop a queued message.
+func (c *Client) isClosed() bool {
+	return c.sendClosed.Load()
+}
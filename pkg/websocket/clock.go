@@ -0,0 +1,31 @@
+package websocket
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TimeSyncPayload Clock synchronization payload
+// @Description Clients send "time" with their local send time and get back server receive/send timestamps
+// @Description (unix milliseconds). Clock offset = ((server_receive - client_time) + (server_send - client_receive)) / 2.
+type TimeSyncPayload struct {
+	ClientTime        int64 `json:"client_time" example:"1718000000000"`
+	ServerReceiveTime int64 `json:"server_receive_time,omitempty" example:"1718000000021"`
+	ServerSendTime    int64 `json:"server_send_time,omitempty" example:"1718000000022"`
+}
+
+// handleTimeMessage answers a clock-sync request to the sender only
+func (c *Client) handleTimeMessage(message Message, receivedAt time.Time) {
+	var req TimeSyncPayload
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return
+	}
+
+	resp := TimeSyncPayload{
+		ClientTime:        req.ClientTime,
+		ServerReceiveTime: receivedAt.UnixMilli(),
+		ServerSendTime:    time.Now().UnixMilli(),
+	}
+	data, _ := json.Marshal(resp)
+	c.trySend(mustMarshal(Message{Type: "time", Data: data}))
+}
@@ -0,0 +1,68 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Close codes sent in the close frame when the server ends a connection. They use the
+// 4000-4999 range RFC 6455 reserves for applications, so clients can tell why they
+// were disconnected and whether reconnecting makes sense.
+const (
+	CloseKicked          = 4000 // removed from the room by a host or a ban
+	CloseRoomDeleted     = 4001 // the room was deleted or closed
+	CloseServerShutdown  = 4002 // the server is shutting down
+	CloseIdleTimeout     = 4003 // nothing was read from the client in time
+	ClosePolicyViolation = 4004 // disconnected by an admin or for falling behind on messages
+	CloseRoomMigrated    = 4005 // the room moved to another instance, reconnect there
+)
+
+// closeReasons are the reasons sent with each close code
+var closeReasons = map[int]string{
+	CloseKicked:          "kicked",
+	CloseRoomDeleted:     "room_deleted",
+	CloseServerShutdown:  "server_shutdown",
+	CloseIdleTimeout:     "idle_timeout",
+	ClosePolicyViolation: "policy_violation",
+	CloseRoomMigrated:    "room_migrated",
+}
+
+// CloseReason returns the reason sent with code, empty for codes not sent by the server
+func CloseReason(code int) string {
+	return closeReasons[code]
+}
+
+// closeFrameTimeout bounds how long writing a close frame may block
+const closeFrameTimeout = time.Second
+
+// closeWith sends a close frame with code and its reason, then closes the connection.
+// Messages still queued for the client are dropped. It may block up to
+// closeFrameTimeout, so it must not be called while holding the room lock.
+func (c *Client) closeWith(code int) {
+	c.closeCode.Store(int32(code))
+	frame := websocket.FormatCloseMessage(code, closeReasons[code])
+	c.Conn.WriteControl(websocket.CloseMessage, frame, time.Now().Add(closeFrameTimeout))
+	c.Conn.Close()
+}
+
+// closeSendWith closes the send channel of a client whose connection is about to be
+// closed with code, so that the write loop leaves closing it to closeWith
+func (c *Client) closeSendWith(code int) {
+	c.closeCode.Store(int32(code))
+	c.closeSend()
+}
+
+// closeAll closes the connections of clients with code in parallel and waits until done
+func closeAll(clients []*Client, code int) {
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.closeWith(code)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,139 @@
+package websocket
+
+import "time"
+
+// closeWarnings are the remaining times at which a scheduled close is announced
+// again, on top of the announcement when it is scheduled
+var closeWarnings = []time.Duration{
+	10 * time.Minute,
+	5 * time.Minute,
+	time.Minute,
+	30 * time.Second,
+	10 * time.Second,
+}
+
+// Graceful close timing: how long a closing room waits for send queues to drain,
+// and how long it then lets the last dequeued message reach the socket
+const (
+	closeDrainTimeout = 2 * time.Second
+	closeSettle       = 100 * time.Millisecond
+)
+
+// ScheduleClose announces that the room closes after delay, repeats the warning as
+// the deadline approaches and then closes the room gracefully and removes it from
+// the hub. Scheduling again replaces the previous countdown.
+func (h *Hub) ScheduleClose(id ID, delay time.Duration) (time.Time, bool) {
+	room, ok := h.GetRoom(id)
+	if !ok {
+		return time.Time{}, false
+	}
+	closesAt := time.Now().Add(delay)
+
+	cancel := make(chan struct{})
+	room.mu.Lock()
+	if room.closeCancel != nil {
+		close(room.closeCancel)
+	}
+	room.closeCancel = cancel
+	room.closesAt = closesAt
+	room.mu.Unlock()
+
+	go room.countdownClose(closesAt, cancel, func() {
+		if room.closeGracefully() && h.Rooms.CompareAndDelete(id, room) {
+			room.deleteModeration()
+			h.deleteUploads(id)
+			h.roomRemoved(id)
+		}
+	})
+	return closesAt, true
+}
+
+// ClosesAt returns when a scheduled close takes effect, zero if none is scheduled
+func (r *Room) ClosesAt() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.closesAt
+}
+
+// countdownClose broadcasts "closing" warnings until closesAt and then runs
+// closeRoom, unless cancel is closed or the room stops first
+func (r *Room) countdownClose(closesAt time.Time, cancel <-chan struct{}, closeRoom func()) {
+	r.announceClose(closesAt)
+
+	for _, warning := range closeWarnings {
+		at := closesAt.Add(-warning)
+		if !time.Now().Before(at) {
+			continue
+		}
+		if !r.waitClose(at, cancel) {
+			return
+		}
+		r.announceClose(closesAt)
+	}
+
+	if !r.waitClose(closesAt, cancel) {
+		return
+	}
+	closeRoom()
+}
+
+// waitClose sleeps until t and reports whether the countdown should continue
+func (r *Room) waitClose(t time.Time, cancel <-chan struct{}) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-cancel:
+		return false
+	case <-r.Stop:
+		return false
+	}
+}
+
+func (r *Room) announceClose(closesAt time.Time) {
+	left := time.Until(closesAt).Round(time.Second)
+	r.broadcastNotification("closing", ClosingNotification{
+		ClosesAt:    closesAt.UnixMilli(),
+		SecondsLeft: int(max(left, 0) / time.Second),
+	})
+}
+
+// closeGracefully tells every client the room is closed, gives their queued
+// messages a moment to be written and stops the room, closing connections with
+// CloseRoomDeleted.
+// It returns false if the room was already stopped.
+func (r *Room) closeGracefully() bool {
+	select {
+	case <-r.Stop:
+		return false
+	default:
+	}
+
+	r.broadcastNotification("closed", ClosingNotification{ClosesAt: time.Now().UnixMilli()})
+	r.drainSendQueues()
+	r.StopRoom()
+	return true
+}
+
+// drainSendQueues waits up to closeDrainTimeout for every client to write its
+// queued messages, then lets the last ones reach the socket
+func (r *Room) drainSendQueues() {
+	deadline := time.Now().Add(closeDrainTimeout)
+	for time.Now().Before(deadline) && !r.sendQueuesEmpty() {
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(closeSettle)
+}
+
+// sendQueuesEmpty reports whether every client has written all queued messages
+func (r *Room) sendQueuesEmpty() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for client := range r.Clients {
+		if len(client.Send) > 0 {
+			return false
+		}
+	}
+	return true
+}
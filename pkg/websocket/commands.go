@@ -0,0 +1,248 @@
+package websocket
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error codes sent in reply to slash commands
+const (
+	ErrCodeUnknownCommand = "unknown_command"
+	ErrCodeCommandDenied  = "command_denied"
+	ErrCodeCommandFailed  = "command_failed"
+)
+
+// CommandPermission controls who may run a command
+type CommandPermission int
+
+const (
+	PermissionEveryone CommandPermission = iota
+	PermissionHost
+)
+
+var (
+	ErrCommandExists      = errors.New("command is already registered")
+	ErrInvalidCommandName = errors.New("command name must be 1-32 lowercase letters, digits, - or _")
+)
+
+var commandNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Command is a parsed slash command. "/kick alice spamming" has the name
+// "kick", the args ["alice", "spamming"] and the rest "alice spamming".
+type Command struct {
+	Name string
+	Rest string // text after the name with its spacing kept
+	Args []string
+}
+
+// CommandFunc runs a command. A returned error is sent to the caller.
+type CommandFunc func(c *Client, cmd Command) error
+
+// CommandSpec registers a command
+type CommandSpec struct {
+	Run         CommandFunc
+	Name        string
+	Usage       string
+	Description string
+	Permission  CommandPermission
+}
+
+// CommandInfo describes a command in the help reply
+type CommandInfo struct {
+	Name        string `json:"name" example:"kick"`
+	Usage       string `json:"usage" example:"/kick <username>"`
+	Description string `json:"description" example:"Remove a member from the room"`
+	HostOnly    bool   `json:"host_only" example:"true"`
+}
+
+// CommandsNotification Sent to a client in reply to /help
+type CommandsNotification struct {
+	Commands []CommandInfo `json:"commands"`
+}
+
+// ActionMessage Sent to clients for /me, e.g. "JohnDoe waves"
+type ActionMessage struct {
+	Text     string `json:"text" example:"waves"`
+	Username string `json:"username" example:"JohnDoe"`
+	ID       uint64 `json:"id,omitempty" example:"42"`
+}
+
+// CommandRouter parses chat messages starting with "/" and runs the matching
+// registered command. A nil router treats every message as chat.
+type CommandRouter struct {
+	commands map[string]CommandSpec
+	mu       sync.RWMutex
+}
+
+// NewCommandRouter creates a router with the built-in commands
+func NewCommandRouter() *CommandRouter {
+	r := &CommandRouter{commands: make(map[string]CommandSpec)}
+	registerDefaultCommands(r)
+	return r
+}
+
+// Register adds a command. Names are case-insensitive and cannot be registered twice.
+func (r *CommandRouter) Register(spec CommandSpec) error {
+	spec.Name = strings.ToLower(spec.Name)
+	if !commandNamePattern.MatchString(spec.Name) || spec.Run == nil {
+		return ErrInvalidCommandName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.commands[spec.Name]; exists {
+		return ErrCommandExists
+	}
+	r.commands[spec.Name] = spec
+	return nil
+}
+
+// Commands lists the commands available to hosts or other members, sorted by name
+func (r *CommandRouter) Commands(host bool) []CommandInfo {
+	r.mu.RLock()
+	commands := make([]CommandInfo, 0, len(r.commands))
+	for _, spec := range r.commands {
+		if spec.Permission == PermissionHost && !host {
+			continue
+		}
+		commands = append(commands, CommandInfo{
+			Name:        spec.Name,
+			Usage:       spec.Usage,
+			Description: spec.Description,
+			HostOnly:    spec.Permission == PermissionHost,
+		})
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	return commands
+}
+
+// ParseCommand parses text as a slash command. Text not starting with a single
+// "/" is chat; "//" escapes a message that should start with a slash.
+func ParseCommand(text string) (Command, bool) {
+	if !strings.HasPrefix(text, "/") || strings.HasPrefix(text, "//") {
+		return Command{}, false
+	}
+	name, rest, _ := strings.Cut(text[1:], " ")
+	if name == "" {
+		return Command{}, false
+	}
+	rest = strings.TrimSpace(rest)
+	return Command{Name: strings.ToLower(name), Rest: rest, Args: strings.Fields(rest)}, true
+}
+
+// Unescape strips the leading "/" of chat text escaped with "//". A nil router
+// returns text as is, as it treats every message as chat.
+func (r *CommandRouter) Unescape(text string) string {
+	if r == nil || !strings.HasPrefix(text, "//") {
+		return text
+	}
+	return text[1:]
+}
+
+// Dispatch runs text as a command of c and reports whether it was one. Unknown
+// commands, missing permissions and command errors are reported to c.
+func (r *CommandRouter) Dispatch(c *Client, text string) bool {
+	if r == nil {
+		return false
+	}
+	cmd, ok := ParseCommand(text)
+	if !ok {
+		return false
+	}
+
+	r.mu.RLock()
+	spec, exists := r.commands[cmd.Name]
+	r.mu.RUnlock()
+	switch {
+	case !exists:
+		c.sendError(ErrCodeUnknownCommand, "unknown command /"+cmd.Name+", see /help")
+	case spec.Permission == PermissionHost && !c.IsHost():
+		c.sendError(ErrCodeCommandDenied, "/"+cmd.Name+" is only available to hosts")
+	default:
+		if err := spec.Run(c, cmd); err != nil {
+			c.sendError(ErrCodeCommandFailed, err.Error())
+		}
+	}
+	return true
+}
+
+// registerDefaultCommands registers /help, /me, /topic and /kick
+func registerDefaultCommands(r *CommandRouter) {
+	r.Register(CommandSpec{
+		Name:        "help",
+		Usage:       "/help",
+		Description: "List the available commands",
+		Run: func(c *Client, _ Command) error {
+			c.trySend(mustMarshal(Message{Type: "commands", Data: mustMarshal(CommandsNotification{
+				Commands: r.Commands(c.IsHost()),
+			})}))
+			return nil
+		},
+	})
+	r.Register(CommandSpec{
+		Name:        "me",
+		Usage:       "/me <action>",
+		Description: "Describe what you are doing",
+		Run: func(c *Client, cmd Command) error {
+			if cmd.Rest == "" {
+				return errors.New("usage: /me <action>")
+			}
+			if len(cmd.Rest) > MaxTextLength {
+				return errors.New("action is too long")
+			}
+			if !c.reserveBroadcast() {
+				return nil
+			}
+			c.lastChatAt = time.Now()
+			stored := c.Room.recordChat(c.Username, c.authorID(), "* "+c.Username+" "+cmd.Rest)
+			c.Room.enqueue(c.spanContext(), mustMarshal(Message{Type: "action", Data: mustMarshal(ActionMessage{
+				Text:     cmd.Rest,
+				Username: c.Username,
+				ID:       stored.ID,
+			})}))
+			return nil
+		},
+	})
+	r.Register(CommandSpec{
+		Name:        "topic",
+		Usage:       "/topic <topic>",
+		Description: "Change the room topic",
+		Permission:  PermissionHost,
+		Run: func(c *Client, cmd Command) error {
+			update := SettingsUpdate{Topic: &cmd.Rest}
+			if errs := update.Validate(); len(errs) > 0 {
+				return errors.New(errs[0].Message)
+			}
+			if _, ok := c.Room.UpdateSettings(update, c.Room.SettingsVersion()); !ok {
+				return errors.New("room settings changed concurrently, try again")
+			}
+			return nil
+		},
+	})
+	r.Register(CommandSpec{
+		Name:        "kick",
+		Usage:       "/kick <username>",
+		Description: "Remove a member from the room",
+		Permission:  PermissionHost,
+		Run: func(c *Client, cmd Command) error {
+			if len(cmd.Args) != 1 {
+				return errors.New("usage: /kick <username>")
+			}
+			if cmd.Args[0] == c.Username {
+				return errors.New("you cannot kick yourself")
+			}
+			if result := c.Room.KickClients(cmd.Args, c.Username)[0]; !result.Kicked {
+				return errors.New(result.Reason)
+			}
+			return nil
+		},
+	})
+}
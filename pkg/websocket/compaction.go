@@ -0,0 +1,64 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+)
+
+// CompactionMetrics receives the history space reclaimed by the hub compactor
+type CompactionMetrics interface {
+	HistoryCompacted(roomID string, messages int, bytes int)
+}
+
+// CompactHistory drops stored messages past the room retention and returns how many
+// messages and approximate bytes were reclaimed
+func (r *Room) CompactHistory(now time.Time) (int, int) {
+	retention := r.Settings().Retention
+
+	r.history.mu.Lock()
+	defer r.history.mu.Unlock()
+	return r.history.trim(now, retention)
+}
+
+// RunCompactor enforces history retention in every room each interval until ctx is done,
+// so rooms that stop receiving messages still release expired ones.
+// A non-positive interval disables compaction.
+func (h *Hub) RunCompactor(ctx context.Context, interval time.Duration, metrics CompactionMetrics) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			h.compactRooms(now, metrics)
+		}
+	}
+}
+
+// compactRooms compacts the history of every room and logs the total reclaimed
+func (h *Hub) compactRooms(now time.Time, metrics CompactionMetrics) {
+	totalMessages, totalBytes := 0, 0
+	h.Rooms.Range(func(_, value any) bool {
+		room := value.(*Room)
+		messages, bytes := room.CompactHistory(now)
+		if messages == 0 {
+			return true
+		}
+		totalMessages += messages
+		totalBytes += bytes
+		if metrics != nil {
+			metrics.HistoryCompacted(strconv.Itoa(int(room.ID)), messages, bytes)
+		}
+		return true
+	})
+	if totalMessages > 0 {
+		log.Printf("Compactor reclaimed %d messages (~%d bytes) of expired history", totalMessages, totalBytes)
+	}
+}
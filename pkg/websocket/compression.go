@@ -0,0 +1,109 @@
+package websocket
+
+import (
+	"bufio"
+	"compress/flate"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultCompressionMinSize is the smallest message compressed by default; the
+// deflate overhead outweighs the savings below it
+const DefaultCompressionMinSize = 256
+
+// CompressionMetrics receives the size of every message written compressed
+type CompressionMetrics interface {
+	MessageCompressed(original, compressed int)
+}
+
+// Compression configures permessage-deflate on WebSocket connections. It is only
+// used with clients that offer the extension; others are written uncompressed.
+type Compression struct {
+	Level   int                // flate level from 1 (fastest) to 9 (smallest)
+	MinSize int                // messages shorter than this many bytes are not compressed
+	Metrics CompressionMetrics // optional, compares bytes before and after compression
+}
+
+// Validate checks the compression level and threshold
+func (c *Compression) Validate() error {
+	if c.Level < flate.BestSpeed || c.Level > flate.BestCompression {
+		return errors.New("compression level must be between 1 and 9")
+	}
+	if c.MinSize < 0 {
+		return errors.New("compression min size must not be negative")
+	}
+	return nil
+}
+
+// EnableCompression negotiates permessage-deflate with clients that offer it
+func (h *Handler) EnableCompression(compression *Compression) error {
+	if err := compression.Validate(); err != nil {
+		return err
+	}
+	h.Compression = compression
+	h.Upgrader.EnableCompression = true
+	return nil
+}
+
+// offersCompression reports whether the client offered permessage-deflate, which
+// the upgrader accepts whenever compression is enabled
+func offersCompression(r *http.Request) bool {
+	for _, ext := range r.Header.Values("Sec-WebSocket-Extensions") {
+		if strings.Contains(ext, "permessage-deflate") {
+			return true
+		}
+	}
+	return false
+}
+
+// countingConn counts the bytes written to the network connection
+type countingConn struct {
+	net.Conn
+	written atomic.Int64
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.written.Add(int64(n))
+	return n, err
+}
+
+// countingWriter hands the upgrader a countingConn when it hijacks the connection
+type countingWriter struct {
+	gin.ResponseWriter
+	conn *countingConn
+}
+
+func (w *countingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := w.ResponseWriter.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	w.conn = &countingConn{Conn: conn}
+	return w.conn, brw, nil
+}
+
+// compressor is implemented by connections that can toggle compression per message
+type compressor interface {
+	EnableWriteCompression(enable bool)
+}
+
+// writeCompressed writes msg, compressed if it reaches the threshold, and reports
+// its size on the wire, frame header included, to the metrics
+func (c *Client) writeCompressed(msg []byte) error {
+	compress := len(msg) >= c.compression.MinSize
+	if conn, ok := c.Conn.(compressor); ok {
+		conn.EnableWriteCompression(compress)
+	}
+	before := c.wire.written.Load()
+	err := c.writeFrame(msg)
+	if compress && err == nil && c.compression.Metrics != nil {
+		c.compression.Metrics.MessageCompressed(len(msg), int(c.wire.written.Load()-before))
+	}
+	return err
+}
@@ -0,0 +1,17 @@
+package websocket
+
+import "time"
+
+// Conn is the transport a Client reads from and writes to.
+// It is satisfied by *websocket.Conn from gorilla and by protocol adapters
+// such as the Engine.IO compatibility layer and the WebTransport session adapter.
+type Conn interface {
+	SetReadLimit(limit int64)
+	SetPongHandler(h func(appData string) error)
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	WriteControl(messageType int, data []byte, deadline time.Time) error
+	Close() error
+}
@@ -0,0 +1,170 @@
+package websocket
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConnectionInfo Describes one live connection registered with the hub
+type ConnectionInfo struct {
+	JoinedAt  time.Time `json:"joined_at" example:"2024-01-01T12:00:00Z"`
+	ConnID    string    `json:"connection_id" example:"3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"`
+	Username  string    `json:"username" example:"JohnDoe"`
+	SessionID string    `json:"session_id,omitempty" example:"0b8f5a5e-5c1e-4a55-9f59-3f0c2b8d9a10"`
+	IP        string    `json:"ip" example:"203.0.113.7"`
+	RoomID    ID        `json:"room_id" example:"42"`
+	IsHost    bool      `json:"is_host" example:"false"`
+}
+
+// ConnectionFilter selects connections by their fields. Empty fields match any
+// connection; usernames are compared case-insensitively.
+type ConnectionFilter struct {
+	Username  string
+	IP        string
+	SessionID string
+	RoomID    ID
+}
+
+// matches reports whether info is selected by the filter
+func (f ConnectionFilter) matches(info ConnectionInfo) bool {
+	return (f.Username == "" || strings.EqualFold(f.Username, info.Username)) &&
+		(f.IP == "" || f.IP == info.IP) &&
+		(f.SessionID == "" || f.SessionID == info.SessionID) &&
+		(f.RoomID == 0 || f.RoomID == info.RoomID)
+}
+
+// ConnectionRegistry indexes the connections of every room of a hub, so a
+// connection can be found and closed without scanning the rooms. Rooms add
+// their clients when they join and remove them when they leave.
+type ConnectionRegistry struct {
+	clients map[*Client]struct{}
+	byID    map[string]*Client // clients with a connection ID
+	mu      sync.RWMutex
+}
+
+// NewConnectionRegistry creates an empty registry
+func NewConnectionRegistry() *ConnectionRegistry {
+	return &ConnectionRegistry{
+		clients: make(map[*Client]struct{}),
+		byID:    make(map[string]*Client),
+	}
+}
+
+// WithConnectionRegistry registers the room's clients with connections
+func WithConnectionRegistry(connections *ConnectionRegistry) RoomOption {
+	return func(r *Room) {
+		r.connections = connections
+	}
+}
+
+// add registers client. Caller holds client.Room.mu.
+func (cr *ConnectionRegistry) add(client *Client) {
+	if cr == nil {
+		return
+	}
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.clients[client] = struct{}{}
+	if client.ConnID != "" {
+		cr.byID[client.ConnID] = client
+	}
+}
+
+// remove drops client. Caller holds client.Room.mu.
+func (cr *ConnectionRegistry) remove(client *Client) {
+	if cr == nil {
+		return
+	}
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	delete(cr.clients, client)
+	if cr.byID[client.ConnID] == client {
+		delete(cr.byID, client.ConnID)
+	}
+}
+
+// Len returns the number of registered connections
+func (cr *ConnectionRegistry) Len() int {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+	return len(cr.clients)
+}
+
+// Lookup returns the connection with connID
+func (cr *ConnectionRegistry) Lookup(connID string) (ConnectionInfo, bool) {
+	cr.mu.RLock()
+	client, ok := cr.byID[connID]
+	cr.mu.RUnlock()
+	if !ok {
+		return ConnectionInfo{}, false
+	}
+	return client.connectionInfo(), true
+}
+
+// Find returns the connections selected by filter, the longest connected first
+func (cr *ConnectionRegistry) Find(filter ConnectionFilter) []ConnectionInfo {
+	var infos []ConnectionInfo
+	for _, client := range cr.find(filter) {
+		infos = append(infos, client.connectionInfo())
+	}
+	slices.SortFunc(infos, func(a, b ConnectionInfo) int { return a.JoinedAt.Compare(b.JoinedAt) })
+	return infos
+}
+
+// Disconnect closes the connection with connID, reporting whether it was registered
+func (cr *ConnectionRegistry) Disconnect(connID string) bool {
+	cr.mu.RLock()
+	client, ok := cr.byID[connID]
+	cr.mu.RUnlock()
+	if ok {
+		client.closeWith(ClosePolicyViolation)
+	}
+	return ok
+}
+
+// DisconnectMatching closes the connections selected by filter across all rooms
+// and returns how many were closed. A filter without fields closes nothing.
+func (cr *ConnectionRegistry) DisconnectMatching(filter ConnectionFilter) int {
+	if filter == (ConnectionFilter{}) {
+		return 0
+	}
+	targets := cr.find(filter)
+	closeAll(targets, ClosePolicyViolation)
+	return len(targets)
+}
+
+// find returns the clients selected by filter. Client fields are read after the
+// registry lock is released, rooms take it while holding their own lock.
+func (cr *ConnectionRegistry) find(filter ConnectionFilter) []*Client {
+	cr.mu.RLock()
+	clients := make([]*Client, 0, len(cr.clients))
+	for client := range cr.clients {
+		clients = append(clients, client)
+	}
+	cr.mu.RUnlock()
+
+	var selected []*Client
+	for _, client := range clients {
+		if filter.matches(client.connectionInfo()) {
+			selected = append(selected, client)
+		}
+	}
+	return selected
+}
+
+// connectionInfo describes the client's connection
+func (c *Client) connectionInfo() ConnectionInfo {
+	c.Room.mu.RLock()
+	defer c.Room.mu.RUnlock()
+	return ConnectionInfo{
+		ConnID:    c.ConnID,
+		RoomID:    c.Room.ID,
+		Username:  c.Username,
+		SessionID: c.SessionID,
+		IP:        c.remoteIP,
+		IsHost:    c.IsHost(),
+		JoinedAt:  c.joinedAt,
+	}
+}
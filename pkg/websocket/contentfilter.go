@@ -0,0 +1,263 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FilterAction is what the content filter does with a chat message containing a blocked word
+type FilterAction string
+
+const (
+	FilterInherit FilterAction = ""       // room only: use the server action
+	FilterOff     FilterAction = "off"    // deliver unchanged
+	FilterFlag    FilterAction = "flag"   // deliver unchanged and record a flagged event for hosts
+	FilterMask    FilterAction = "mask"   // replace blocked words with asterisks
+	FilterReject  FilterAction = "reject" // drop the message and tell the sender
+)
+
+// Content filter limits of a room
+const (
+	MaxRoomFilterWords  = 100
+	MaxFilterWordLength = 50
+)
+
+// EventFlagged is recorded in the event feed, for hosts only, when a message is flagged
+const EventFlagged = "flagged"
+
+var ErrInvalidFilterWords = errors.New("filter words must be 1-50 characters, at most 100 words")
+
+// FlaggedEvent is the host-only event recorded for a flagged message
+type FlaggedEvent struct {
+	Username string   `json:"username" example:"JohnDoe"`
+	Text     string   `json:"text" example:"some darn message"`
+	Words    []string `json:"words,omitempty" example:"darn"`
+	Rule     string   `json:"rule,omitempty" example:"no links from newcomers"` // auto-moderation rule that flagged it
+}
+
+// RoomContentFilter is the content filter override of a room
+type RoomContentFilter struct {
+	Action FilterAction `json:"action" example:"reject"`
+	Words  []string     `json:"words" example:"spoiler"`
+}
+
+// roomContentFilter is RoomContentFilter with its compiled word pattern, guarded by Room.mu
+type roomContentFilter struct {
+	pattern *wordMatcher
+	RoomContentFilter
+}
+
+// ParseFilterAction returns the action called s. Empty is FilterInherit.
+func ParseFilterAction(s string) (FilterAction, error) {
+	switch action := FilterAction(strings.ToLower(strings.TrimSpace(s))); action {
+	case FilterInherit, FilterOff, FilterFlag, FilterMask, FilterReject:
+		return action, nil
+	}
+	return "", errors.New("filter action must be off, flag, mask or reject")
+}
+
+// LoadWordList reads one blocked word or phrase per line. Blank lines and
+// lines starting with # are skipped.
+func LoadWordList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			words = append(words, line)
+		}
+	}
+	return words, scanner.Err()
+}
+
+// wordPattern matches any of words as whole words, case-insensitively. It is nil without words.
+func wordPattern(words []string) *wordMatcher {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	// Alternatives match leftmost-first, the longest words go first so they win
+	sort.SliceStable(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	return &wordMatcher{re: regexp.MustCompile(`(?i)(?:` + strings.Join(quoted, "|") + `)`)}
+}
+
+// wordMatcher finds the words of a word list in text. RE2's \b only knows ASCII
+// word characters, so whole words are told apart by the Unicode letters, digits
+// and underscores around them instead.
+type wordMatcher struct {
+	re *regexp.Regexp
+}
+
+// MatchString reports whether s contains one of the words
+func (m *wordMatcher) MatchString(s string) bool {
+	return len(m.FindAllStringIndex(s, 1)) > 0
+}
+
+// FindAllStringIndex returns the positions of at most n words in s, all of them if n < 0
+func (m *wordMatcher) FindAllStringIndex(s string, n int) [][]int {
+	var matches [][]int
+	for pos := 0; pos < len(s) && (n < 0 || len(matches) < n); {
+		loc := m.re.FindStringIndex(s[pos:])
+		if loc == nil {
+			break
+		}
+		start, end := pos+loc[0], pos+loc[1]
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if (start == 0 || !isWordRune(before)) && (end == len(s) || !isWordRune(after)) {
+			matches = append(matches, []int{start, end})
+			pos = end
+			continue
+		}
+		// Not a whole word, a match may still start at the next character
+		_, size := utf8.DecodeRuneInString(s[start:])
+		pos = start + size
+	}
+	return matches
+}
+
+// isWordRune reports whether r is part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_'
+}
+
+// ContentFilter checks chat messages against a server word list and the
+// words and action a room's host configured
+type ContentFilter struct {
+	pattern *wordMatcher
+	action  FilterAction
+}
+
+// NewContentFilter creates a filter applying action to messages containing one of words
+func NewContentFilter(words []string, action FilterAction) *ContentFilter {
+	if action == FilterInherit {
+		action = FilterMask
+	}
+	return &ContentFilter{pattern: wordPattern(words), action: action}
+}
+
+// Interceptor returns the message interceptor applying the filter to chat messages
+func (f *ContentFilter) Interceptor() MessageInterceptor {
+	return func(c *Client, msg *Message) (*Message, error) {
+		if msg.Type != "chat" && msg.Type != "edit" {
+			return msg, nil
+		}
+		var chat ChatMessage
+		if err := json.Unmarshal(msg.Data, &chat); err != nil {
+			return msg, nil
+		}
+
+		action, roomPattern := c.Room.contentFilter()
+		if action == FilterInherit {
+			action = f.action
+		}
+		if action == FilterOff {
+			return msg, nil
+		}
+		var matches [][]int
+		for _, pattern := range []*wordMatcher{f.pattern, roomPattern} {
+			if pattern != nil {
+				matches = append(matches, pattern.FindAllStringIndex(chat.Text, -1)...)
+			}
+		}
+		if len(matches) == 0 {
+			return msg, nil
+		}
+
+		switch action {
+		case FilterReject:
+			return nil, errors.New("message contains blocked words")
+		case FilterFlag:
+			words := make([]string, len(matches))
+			for i, m := range matches {
+				words[i] = chat.Text[m[0]:m[1]]
+			}
+			log.Printf("Flagged chat message from %s in room %d", c.Username, c.Room.ID)
+			c.Room.recordEvent(EventFlagged, FlaggedEvent{Username: c.Username, Text: chat.Text, Words: words})
+			return msg, nil
+		}
+		chat.Text = maskMatches(chat.Text, matches)
+		data, err := json.Marshal(chat)
+		if err != nil {
+			return nil, err
+		}
+		msg.Data = data
+		return msg, nil
+	}
+}
+
+// maskMatches replaces every matched range of text with one asterisk per
+// character. Server and room matches may overlap.
+func maskMatches(text string, matches [][]int) string {
+	sort.Slice(matches, func(i, j int) bool { return matches[i][0] < matches[j][0] })
+	var out strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m[0] < last {
+			m[0] = last
+		}
+		if m[0] >= m[1] {
+			continue
+		}
+		out.WriteString(text[last:m[0]])
+		out.WriteString(strings.Repeat("*", utf8.RuneCountInString(text[m[0]:m[1]])))
+		last = m[1]
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+// ContentFilter returns the room's filter override
+func (r *Room) ContentFilter() RoomContentFilter {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return RoomContentFilter{Action: r.filter.Action, Words: append([]string{}, r.filter.Words...)}
+}
+
+// SetContentFilter replaces the room's filter override. Words are matched as
+// whole words, case-insensitively, in addition to the server word list.
+func (r *Room) SetContentFilter(filter RoomContentFilter) error {
+	if len(filter.Words) > MaxRoomFilterWords {
+		return ErrInvalidFilterWords
+	}
+	words := make([]string, 0, len(filter.Words))
+	for _, word := range filter.Words {
+		word = strings.TrimSpace(word)
+		if word == "" || len(word) > MaxFilterWordLength {
+			return ErrInvalidFilterWords
+		}
+		words = append(words, word)
+	}
+	pattern := wordPattern(words)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.filter = roomContentFilter{RoomContentFilter: RoomContentFilter{Action: filter.Action, Words: words}, pattern: pattern}
+	return nil
+}
+
+// contentFilter returns the room's action override and word pattern
+func (r *Room) contentFilter() (FilterAction, *wordMatcher) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.filter.Action, r.filter.pattern
+}
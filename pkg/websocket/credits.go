@@ -0,0 +1,112 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+// ErrCodeCreditsExhausted is sent when a client sends a broadcast without send credits left
+const ErrCodeCreditsExhausted = "send_credits_exhausted"
+
+// CreditGrant Sent to a client when it joins and whenever a new credit window begins.
+// Each broadcast message (chat or action) spends one credit; messages sent without
+// credits are rejected until ResetAt.
+type CreditGrant struct {
+	ResetAt  time.Time `json:"reset_at" example:"2024-01-01T12:00:10Z"`
+	Credits  int       `json:"credits" example:"5"`
+	WindowMs int64     `json:"window_ms" example:"10000"`
+}
+
+// sendCredits is a room's budget of broadcasts per window, split equally among its members
+type sendCredits struct {
+	perWindow int
+	window    time.Duration
+}
+
+// creditBalance is the credits a client has left in its current window
+type creditBalance struct {
+	windowStart time.Time
+	remaining   int
+	mu          sync.Mutex
+}
+
+// WithSendCredits shares perWindow broadcast messages per window equally among the
+// members of the room, so every member gets the same say however large the room grows.
+// Each member is granted at least one credit per window. Zero disables credits.
+func WithSendCredits(perWindow int, window time.Duration) RoomOption {
+	return func(r *Room) {
+		if perWindow > 0 && window > 0 {
+			r.credits = sendCredits{perWindow: perWindow, window: window}
+		}
+	}
+}
+
+// creditShare returns the credits each member is granted per window, 0 if credits are disabled
+func (r *Room) creditShare() int {
+	if r.credits.perWindow == 0 {
+		return 0
+	}
+	r.mu.RLock()
+	members := len(r.Clients)
+	r.mu.RUnlock()
+	return max(1, r.credits.perWindow/max(1, members))
+}
+
+// grantCredits starts a new credit window for the client and tells it its share
+func (c *Client) grantCredits(now time.Time) {
+	share := c.Room.creditShare()
+	if share == 0 {
+		return
+	}
+	c.credits.mu.Lock()
+	c.credits.windowStart = now
+	c.credits.remaining = share
+	c.credits.mu.Unlock()
+
+	c.trySend(mustMarshal(Message{Type: "credits", Data: mustMarshal(CreditGrant{
+		Credits:  share,
+		WindowMs: c.Room.credits.window.Milliseconds(),
+		ResetAt:  now.Add(c.Room.credits.window),
+	})}))
+}
+
+// hasCredit reports whether the client has a send credit left, starting a new
+// window when the current one is over. A client without credits is told when to
+// retry.
+func (c *Client) hasCredit(now time.Time) bool {
+	if c.Room.credits.perWindow == 0 {
+		return true
+	}
+	window := c.Room.credits.window
+
+	c.credits.mu.Lock()
+	expired := !now.Before(c.credits.windowStart.Add(window))
+	c.credits.mu.Unlock()
+	if expired {
+		c.grantCredits(now)
+	}
+
+	c.credits.mu.Lock()
+	ok := c.credits.remaining > 0
+	retryAfter := c.credits.windowStart.Add(window).Sub(now)
+	c.credits.mu.Unlock()
+
+	if !ok {
+		c.sendErrorNotification(ErrorNotification{
+			Code:         ErrCodeCreditsExhausted,
+			Message:      "no send credits left in this window, wait for the next grant",
+			RetryAfterMs: retryAfter.Milliseconds() + 1,
+		})
+	}
+	return ok
+}
+
+// spendCredit consumes a send credit checked with hasCredit
+func (c *Client) spendCredit() {
+	if c.Room.credits.perWindow == 0 {
+		return
+	}
+	c.credits.mu.Lock()
+	c.credits.remaining = max(c.credits.remaining-1, 0)
+	c.credits.mu.Unlock()
+}
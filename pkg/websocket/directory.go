@@ -0,0 +1,106 @@
+package websocket
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// Tag limits
+const (
+	MaxRoomTags  = 10
+	MaxTagLength = 32
+)
+
+// RoomFilter selects the rooms returned by Hub.ListRooms.
+// Zero fields match every room.
+type RoomFilter struct {
+	Visibility Visibility
+	Tag        string
+}
+
+// RoomSummary is a point-in-time view of a room for directory listings
+type RoomSummary struct {
+	Topic       string     `json:"topic,omitempty" example:"Weekly sync"`
+	Visibility  Visibility `json:"visibility" swaggertype:"string" example:"public"`
+	Tags        []string   `json:"tags,omitempty" example:"golang"`
+	ClientCount int        `json:"client_count" example:"12"`
+	ID          ID         `json:"room_id" example:"42"`
+	HasPassword bool       `json:"has_password" example:"false"`
+	Locked      bool       `json:"locked" example:"false"`
+}
+
+// WithVisibility sets whether the room is listed in the public directory.
+// It must come after WithSettings, which replaces the whole settings.
+func WithVisibility(v Visibility) RoomOption {
+	return func(r *Room) {
+		r.settings.Visibility = v
+	}
+}
+
+// WithTags sets the directory tags of the room. Tags are lowercased and deduplicated.
+func WithTags(tags ...string) RoomOption {
+	return func(r *Room) {
+		r.tags = normalizeTags(tags)
+	}
+}
+
+// Tags returns a copy of the room tags
+func (r *Room) Tags() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.tags)
+}
+
+// Summary returns the directory view of the room
+func (r *Room) Summary() RoomSummary {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return RoomSummary{
+		ID:          r.ID,
+		Topic:       r.settings.Topic,
+		Visibility:  r.settings.Visibility,
+		Tags:        slices.Clone(r.tags),
+		ClientCount: len(r.Clients),
+		HasPassword: r.HashedPassword != "",
+		Locked:      r.locked,
+	}
+}
+
+// matches reports whether the summary passes filter
+func (s RoomSummary) matches(filter RoomFilter) bool {
+	if filter.Visibility != "" && s.Visibility != filter.Visibility {
+		return false
+	}
+	if filter.Tag != "" && !slices.Contains(s.Tags, strings.ToLower(filter.Tag)) {
+		return false
+	}
+	return true
+}
+
+// ListRooms returns the rooms matching filter ordered by room ID
+func (h *Hub) ListRooms(filter RoomFilter) []RoomSummary {
+	var rooms []RoomSummary
+	h.Rooms.Range(func(_, value any) bool {
+		if summary := value.(*Room).Summary(); summary.matches(filter) {
+			rooms = append(rooms, summary)
+		}
+		return true
+	})
+	slices.SortFunc(rooms, func(a, b RoomSummary) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return rooms
+}
+
+// normalizeTags lowercases tags, dropping blanks and duplicates
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,151 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrCodeEditFailed is sent when a message cannot be edited or deleted
+const ErrCodeEditFailed = "edit_failed"
+
+var (
+	// ErrMessageNotFound is returned for messages that are not in the room history
+	ErrMessageNotFound = errors.New("message not found in room history")
+	// ErrNotMessageAuthor is returned when a member changes a message sent by another
+	// session, even under the same username
+	ErrNotMessageAuthor = errors.New("only the author or a host can change this message")
+)
+
+// EditMessage Payload replacing the text of a chat message
+type EditMessage struct {
+	Text string `json:"text" example:"Hello, world! (fixed)"`
+	ID   uint64 `json:"id" example:"42"`
+}
+
+// DeleteMessage Payload removing a chat message
+type DeleteMessage struct {
+	ID uint64 `json:"id" example:"42"`
+}
+
+// MessageEditedNotification Broadcast when a chat message was edited
+type MessageEditedNotification struct {
+	EditedAt time.Time `json:"edited_at" example:"2024-01-01T12:05:00Z"`
+	Username string    `json:"username" example:"JohnDoe"` // author of the message
+	EditedBy string    `json:"edited_by" example:"JohnDoe"`
+	Text     string    `json:"text" example:"Hello, world! (fixed)"`
+	ID       uint64    `json:"id" example:"42"`
+}
+
+// MessageDeletedNotification Broadcast when a chat message was deleted
+type MessageDeletedNotification struct {
+	Username  string `json:"username" example:"JohnDoe"` // author of the message
+	DeletedBy string `json:"deleted_by" example:"host"`
+	ID        uint64 `json:"id" example:"42"`
+}
+
+// change applies fn to stored message id if author may change it: the identity
+// that sent it, or anyone when asHost is set. Bot messages have no author and only
+// hosts change them. Messages past retention count as gone.
+func (h *messageHistory) change(id uint64, author string, asHost bool, now time.Time, retention time.Duration, fn func(i int)) (StoredMessage, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i := slices.IndexFunc(h.messages, func(msg StoredMessage) bool { return msg.ID == id })
+	if i < 0 || retention > 0 && h.messages[i].SentAt.Before(now.Add(-retention)) {
+		return StoredMessage{}, ErrMessageNotFound
+	}
+	msg := h.messages[i]
+	if !asHost && (msg.author == "" || msg.author != author) {
+		return StoredMessage{}, ErrNotMessageAuthor
+	}
+	fn(i)
+	return msg, nil
+}
+
+// EditMessage replaces the text of message id in the history and broadcasts the
+// change made by editor. Members may edit the messages their author identity sent,
+// hosts any message.
+func (r *Room) EditMessage(id uint64, editor, author, text string, asHost bool) error {
+	now := time.Now().UTC()
+	msg, err := r.history.change(id, author, asHost, now, r.Settings().Retention, func(i int) {
+		r.history.messages[i].Text = text
+		r.history.messages[i].EditedAt = now
+	})
+	if err != nil {
+		return err
+	}
+	r.broadcastNotification("edit", MessageEditedNotification{
+		ID:       id,
+		Username: msg.Username,
+		Text:     text,
+		EditedBy: editor,
+		EditedAt: now,
+	})
+	return nil
+}
+
+// DeleteMessage removes message id from the history and broadcasts the removal by
+// editor. Members may delete the messages their author identity sent, hosts any message.
+func (r *Room) DeleteMessage(id uint64, editor, author string, asHost bool) error {
+	msg, err := r.history.change(id, author, asHost, time.Now(), r.Settings().Retention, func(i int) {
+		r.history.messages = slices.Delete(r.history.messages, i, i+1)
+	})
+	if err != nil {
+		return err
+	}
+	r.broadcastNotification("delete", MessageDeletedNotification{
+		ID:        id,
+		Username:  msg.Username,
+		DeletedBy: editor,
+	})
+	return nil
+}
+
+// authorID identifies the client as the author of its messages: its session, which
+// is bound to a signed cookie, or the connection when it has none. Usernames are not
+// unique by default and cannot be used.
+func (c *Client) authorID() string {
+	if c.SessionID != "" {
+		return "s:" + c.SessionID
+	}
+	return "c:" + c.ConnID
+}
+
+// handleEditMessage lets a member edit one of their messages, or a host any message.
+// The new text goes through the same checks as a chat message.
+func (c *Client) handleEditMessage(message Message) {
+	var edit EditMessage
+	if err := json.Unmarshal(message.Data, &edit); err != nil || edit.ID == 0 || edit.Text == "" || len(edit.Text) > MaxTextLength {
+		c.sendError(ErrCodeEditFailed, "edit needs a message id and a text of at most 1000 characters")
+		return
+	}
+	if c.IsMuted() {
+		c.sendError(ErrCodeMuted, "you are muted by the host")
+		return
+	}
+	if !c.moderate(edit.Text, time.Now()) {
+		return
+	}
+	if !c.reserveBroadcast() {
+		return
+	}
+	if err := c.Room.EditMessage(edit.ID, c.Username, c.authorID(), edit.Text, c.IsHost()); err != nil {
+		c.sendError(ErrCodeEditFailed, err.Error())
+	}
+}
+
+// handleDeleteMessage lets a member delete one of their messages, or a host any message
+func (c *Client) handleDeleteMessage(message Message) {
+	var del DeleteMessage
+	if err := json.Unmarshal(message.Data, &del); err != nil || del.ID == 0 {
+		c.sendError(ErrCodeEditFailed, "delete needs a message id")
+		return
+	}
+	if !c.reserveBroadcast() {
+		return
+	}
+	if err := c.Room.DeleteMessage(del.ID, c.Username, c.authorID(), c.IsHost()); err != nil {
+		c.sendError(ErrCodeEditFailed, err.Error())
+	}
+}
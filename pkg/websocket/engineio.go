@@ -0,0 +1,256 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// Engine.IO v4 packet types
+const (
+	eioOpen    = '0'
+	eioClose   = '1'
+	eioPing    = '2'
+	eioPong    = '3'
+	eioMessage = '4'
+	eioUpgrade = '5'
+	eioNoop    = '6'
+)
+
+// Socket.IO v5 packet types
+const (
+	sioConnect    = '0'
+	sioDisconnect = '1'
+	sioEvent      = '2'
+)
+
+// EngineIOProtocol is the only Engine.IO protocol revision supported
+const EngineIOProtocol = "4"
+
+var errEngineIOClosed = errors.New("engine.io session closed by client")
+
+// engineIOHandshake is the payload of the Engine.IO open packet
+type engineIOHandshake struct {
+	SID          string   `json:"sid"`
+	Upgrades     []string `json:"upgrades"`
+	PingInterval int64    `json:"pingInterval"`
+	PingTimeout  int64    `json:"pingTimeout"`
+	MaxPayload   int64    `json:"maxPayload"`
+}
+
+// engineIOConn adapts a WebSocket connection speaking Engine.IO/Socket.IO framing
+// to the Conn interface. Socket.IO events are translated to and from Message
+// envelopes, with the event name used as the message type.
+type engineIOConn struct {
+	*websocket.Conn
+	pongHandler func(string) error
+	sid         string
+	pending     [][]byte     // events received over polling before the upgrade, read first
+	lastPing    atomic.Value // []byte payload of the last ping, echoed to the pong handler
+	writeMu     sync.Mutex
+}
+
+// newEngineIOConn wraps conn and sends the Engine.IO open packet
+func newEngineIOConn(conn *websocket.Conn) (*engineIOConn, error) {
+	c := &engineIOConn{Conn: conn, sid: uuid.New().String()}
+	open, err := engineIOOpenPacket(c.sid, []string{})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.writeRaw(open); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// engineIOOpenPacket returns the Engine.IO open packet of session sid
+func engineIOOpenPacket(sid string, upgrades []string) ([]byte, error) {
+	handshake, err := json.Marshal(engineIOHandshake{
+		SID:          sid,
+		Upgrades:     upgrades,
+		PingInterval: pingPeriod.Milliseconds(),
+		PingTimeout:  (readDeadline - pingPeriod).Milliseconds(),
+		MaxPayload:   MaxMessageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{eioOpen}, handshake...), nil
+}
+
+func (c *engineIOConn) SetPongHandler(h func(appData string) error) {
+	c.pongHandler = h
+	c.Conn.SetPongHandler(h)
+}
+
+// ReadMessage returns the next Socket.IO event as a JSON Message,
+// handling Engine.IO and Socket.IO control packets internally.
+func (c *engineIOConn) ReadMessage() (int, []byte, error) {
+	if len(c.pending) > 0 {
+		msg := c.pending[0]
+		c.pending = c.pending[1:]
+		return websocket.TextMessage, msg, nil
+	}
+	for {
+		_, data, err := c.Conn.ReadMessage()
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		switch data[0] {
+		case eioPing:
+			if err := c.writeRaw(append([]byte{eioPong}, data[1:]...)); err != nil {
+				return 0, nil, err
+			}
+		case eioPong:
+			// Socket.IO clients answer pings without a payload, so the last ping
+			// payload is echoed to keep RTT measurement working.
+			appData := string(data[1:])
+			if last, ok := c.lastPing.Load().([]byte); ok && appData == "" {
+				appData = string(last)
+			}
+			if c.pongHandler != nil {
+				if err := c.pongHandler(appData); err != nil {
+					return 0, nil, err
+				}
+			}
+		case eioClose:
+			return 0, nil, errEngineIOClosed
+		case eioMessage:
+			msg, err := c.handleSocketIOPacket(data[1:])
+			if err != nil {
+				return 0, nil, err
+			}
+			if msg != nil {
+				return websocket.TextMessage, msg, nil
+			}
+		}
+	}
+}
+
+// handleSocketIOPacket processes a Socket.IO packet and returns a Message
+// for events, or nil for packets handled internally.
+func (c *engineIOConn) handleSocketIOPacket(packet []byte) ([]byte, error) {
+	msg, reply, err := socketIOPacket(c.sid, packet)
+	if err != nil || reply == nil {
+		return msg, err
+	}
+	return msg, c.writeRaw(reply)
+}
+
+// socketIOPacket parses a Socket.IO packet of session sid. It returns a Message
+// for events, and the Engine.IO packet to answer with, if any.
+func socketIOPacket(sid string, packet []byte) (msg, reply []byte, err error) {
+	if len(packet) == 0 {
+		return nil, nil, nil
+	}
+
+	switch packet[0] {
+	case sioConnect:
+		payload, _ := json.Marshal(map[string]string{"sid": sid})
+		return nil, append([]byte{eioMessage, sioConnect}, payload...), nil
+	case sioDisconnect:
+		return nil, nil, errEngineIOClosed
+	case sioEvent:
+		body := packet[1:]
+		// Skip an optional namespace ("/chat,") and ack id
+		if len(body) > 0 && body[0] == '/' {
+			if i := bytes.IndexByte(body, ','); i >= 0 {
+				body = body[i+1:]
+			}
+		}
+		for len(body) > 0 && body[0] >= '0' && body[0] <= '9' {
+			body = body[1:]
+		}
+
+		var args []json.RawMessage
+		if err := json.Unmarshal(body, &args); err != nil || len(args) == 0 {
+			return nil, nil, nil
+		}
+		var msgType string
+		if err := json.Unmarshal(args[0], &msgType); err != nil {
+			return nil, nil, nil
+		}
+		msg := Message{Type: msgType}
+		if len(args) > 1 {
+			msg.Data = args[1]
+		}
+		data, err := json.Marshal(msg)
+		return data, nil, err
+	default:
+		return nil, nil, nil
+	}
+}
+
+// WriteMessage sends a Message envelope as a Socket.IO event. Binary messages
+// are not relayed to Socket.IO clients and are skipped.
+func (c *engineIOConn) WriteMessage(messageType int, data []byte) error {
+	if messageType == websocket.BinaryMessage {
+		return nil
+	}
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Type == "" {
+		msg = Message{Type: "message", Data: data}
+	}
+	if msg.Data == nil {
+		msg.Data = json.RawMessage("null")
+	}
+
+	event, err := json.Marshal([]json.RawMessage{mustMarshal(msg.Type), msg.Data})
+	if err != nil {
+		return err
+	}
+	return c.writeRaw(append([]byte{eioMessage, sioEvent}, event...))
+}
+
+// WriteControl maps WebSocket pings to Engine.IO ping packets
+func (c *engineIOConn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	switch messageType {
+	case websocket.PingMessage:
+		c.lastPing.Store(data)
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
+		c.Conn.SetWriteDeadline(deadline)
+		return c.Conn.WriteMessage(websocket.TextMessage, []byte{eioPing})
+	case websocket.CloseMessage:
+		if err := c.writeRaw([]byte{eioClose}); err != nil {
+			return err
+		}
+	}
+	return c.Conn.WriteControl(messageType, data, deadline)
+}
+
+// SetWriteDeadline serializes with pings, which set their own deadline
+func (c *engineIOConn) SetWriteDeadline(t time.Time) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.Conn.SetWriteDeadline(t)
+}
+
+// writeRaw writes a text frame, serializing concurrent writers
+func (c *engineIOConn) writeRaw(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.Conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// engineIOQueryError validates Engine.IO query parameters
+func engineIOQueryError(eio, transport string) error {
+	if eio != EngineIOProtocol {
+		return &ValidationError{Field: "EIO", Message: "unsupported Engine.IO protocol version " + strconv.Quote(eio)}
+	}
+	if transport != "websocket" && transport != "polling" {
+		return &ValidationError{Field: "transport", Message: "only the websocket and polling transports are supported"}
+	}
+	return nil
+}
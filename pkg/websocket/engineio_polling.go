@@ -0,0 +1,308 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// EngineIOUpgradeTimeout is how long a session opened over HTTP long-polling may
+// take to upgrade to WebSocket before it is closed
+const EngineIOUpgradeTimeout = 30 * time.Second
+
+const (
+	// engineIOPollWait is how long a poll waits for packets before it returns a
+	// ping, below the default write timeout of the HTTP server
+	engineIOPollWait = 15 * time.Second
+	// maxEngineIOPendingEvents caps the events a client may send before upgrading
+	maxEngineIOPendingEvents = 32
+	// engineIORecordSeparator separates the packets of a long-polling payload
+	engineIORecordSeparator = '\x1e'
+	engineIOContentType     = "text/plain; charset=UTF-8"
+)
+
+var (
+	errEngineIOUnknownSession = errors.New("unknown engine.io session")
+	errEngineIOTooManyEvents  = errors.New("too many events sent before the websocket upgrade")
+	errEngineIOUpgrade        = errors.New("engine.io upgrade handshake failed")
+)
+
+// engineIOPollingSession is an Engine.IO session opened over HTTP long-polling.
+// Socket.IO clients start with polling and upgrade to WebSocket right after the
+// handshake; they join the room once upgraded. Events sent before the upgrade
+// are kept and dispatched after the join.
+type engineIOPollingSession struct {
+	sid       string
+	outbox    [][]byte // packets waiting for the next poll
+	events    [][]byte // Message envelopes received before the upgrade
+	wake      chan struct{}
+	final     byte // packet answering polls once closed: noop after an upgrade, close otherwise
+	closed    bool
+	upgrading bool
+	mu        sync.Mutex
+}
+
+// engineIOSessions holds the polling sessions waiting for their upgrade
+type engineIOSessions struct {
+	sessions map[string]*engineIOPollingSession
+	mu       sync.Mutex
+}
+
+func newEngineIOSessions() *engineIOSessions {
+	return &engineIOSessions{sessions: make(map[string]*engineIOPollingSession)}
+}
+
+// open creates a polling session, closed unless it upgrades within EngineIOUpgradeTimeout
+func (s *engineIOSessions) open() *engineIOPollingSession {
+	session := &engineIOPollingSession{sid: uuid.New().String(), wake: make(chan struct{}, 1)}
+	s.mu.Lock()
+	s.sessions[session.sid] = session
+	s.mu.Unlock()
+	time.AfterFunc(EngineIOUpgradeTimeout, func() {
+		s.take(session.sid)
+		session.close(eioClose)
+	})
+	return session
+}
+
+// get returns the session with sid, or nil
+func (s *engineIOSessions) get(sid string) *engineIOPollingSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[sid]
+}
+
+// take removes and returns the session with sid, or nil
+func (s *engineIOSessions) take(sid string) *engineIOPollingSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session := s.sessions[sid]
+	delete(s.sessions, sid)
+	return session
+}
+
+// claim reserves the session for an upgrade. Only the first call succeeds.
+func (s *engineIOPollingSession) claim() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.upgrading || s.closed {
+		return false
+	}
+	s.upgrading = true
+	return true
+}
+
+// push queues a packet for the next poll
+func (s *engineIOPollingSession) push(packet []byte) {
+	s.mu.Lock()
+	s.outbox = append(s.outbox, packet)
+	s.mu.Unlock()
+	s.signal()
+}
+
+// signal wakes a pending poll
+func (s *engineIOPollingSession) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// close ends polling; pending and later polls are answered with final. Only the
+// first call has an effect.
+func (s *engineIOPollingSession) close(final byte) {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		s.final = final
+	}
+	s.mu.Unlock()
+	s.signal()
+}
+
+// drain returns and clears the queued packets
+func (s *engineIOPollingSession) drain() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	packets := s.outbox
+	s.outbox = nil
+	return packets
+}
+
+// poll waits for queued packets and returns them as a long-polling payload. It
+// returns a ping after engineIOPollWait, and nil if ctx ends first.
+func (s *engineIOPollingSession) poll(ctx context.Context) []byte {
+	timer := time.NewTimer(engineIOPollWait)
+	defer timer.Stop()
+	for {
+		s.mu.Lock()
+		packets, closed, final := s.outbox, s.closed, s.final
+		s.outbox = nil
+		s.mu.Unlock()
+		switch {
+		case len(packets) > 0:
+			return bytes.Join(packets, []byte{engineIORecordSeparator})
+		case closed:
+			return []byte{final}
+		}
+
+		select {
+		case <-s.wake:
+		case <-timer.C:
+			return []byte{eioPing}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// receive handles the packets of a long-polling payload sent by the client
+func (s *engineIOPollingSession) receive(payload []byte) error {
+	for _, packet := range bytes.Split(payload, []byte{engineIORecordSeparator}) {
+		if len(packet) == 0 {
+			continue
+		}
+		switch packet[0] {
+		case eioPing:
+			s.push(append([]byte{eioPong}, packet[1:]...))
+		case eioClose:
+			return errEngineIOClosed
+		case eioMessage:
+			msg, reply, err := socketIOPacket(s.sid, packet[1:])
+			if err != nil {
+				return err
+			}
+			if reply != nil {
+				s.push(reply)
+			}
+			if msg != nil {
+				s.mu.Lock()
+				full := len(s.events) >= maxEngineIOPendingEvents
+				if !full {
+					s.events = append(s.events, msg)
+				}
+				s.mu.Unlock()
+				if full {
+					return errEngineIOTooManyEvents
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// upgradeEngineIOSession completes the upgrade of a polling session to conn: the
+// client probes the connection, the pending poll is released and the client
+// confirms the upgrade. Packets not polled yet are sent over conn.
+func upgradeEngineIOSession(conn *websocket.Conn, session *engineIOPollingSession) (*engineIOConn, error) {
+	c := &engineIOConn{Conn: conn, sid: session.sid}
+	conn.SetReadDeadline(time.Now().Add(EngineIOUpgradeTimeout))
+	if err := expectEngineIOPacket(conn, string(eioPing)+"probe"); err != nil {
+		return nil, err
+	}
+	if err := c.writeRaw([]byte(string(eioPong) + "probe")); err != nil {
+		return nil, err
+	}
+	// A noop ends the pending poll so the client can pause polling
+	session.close(eioNoop)
+	if err := expectEngineIOPacket(conn, string(eioUpgrade)); err != nil {
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	for _, packet := range session.drain() {
+		if err := c.writeRaw(packet); err != nil {
+			return nil, err
+		}
+	}
+	session.mu.Lock()
+	c.pending = session.events
+	session.events = nil
+	session.mu.Unlock()
+	return c, nil
+}
+
+// expectEngineIOPacket reads the next frame of conn and checks it is packet
+func expectEngineIOPacket(conn *websocket.Conn, packet string) error {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	if string(data) != packet {
+		return errEngineIOUpgrade
+	}
+	return nil
+}
+
+// serveEngineIOPolling serves the long-polling transport: a GET without sid opens
+// a session in the room, a GET with sid polls the packets of the session and a
+// POST delivers packets from the client
+func (h *Handler) serveEngineIOPolling(c *gin.Context) {
+	sid := c.Query("sid")
+	if sid == "" {
+		if c.Request.Method != http.MethodGet {
+			engineIOUnknownSession(c)
+			return
+		}
+		if _, ok := h.lookupRoom(c, c.Query("room_id")); !ok {
+			return
+		}
+		session := h.engineIO.open()
+		open, err := engineIOOpenPacket(session.sid, []string{"websocket"})
+		if err != nil {
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		c.Data(http.StatusOK, engineIOContentType, open)
+		return
+	}
+
+	session := h.engineIO.get(sid)
+	if session == nil {
+		engineIOUnknownSession(c)
+		return
+	}
+	if c.Request.Method == http.MethodGet {
+		if payload := session.poll(c.Request.Context()); payload != nil {
+			c.Data(http.StatusOK, engineIOContentType, payload)
+		}
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxMessageSize))
+	if err == nil {
+		err = session.receive(payload)
+	}
+	switch {
+	case errors.Is(err, errEngineIOClosed):
+		h.engineIO.take(sid)
+		session.close(eioClose)
+	case err != nil:
+		h.engineIO.take(sid)
+		session.close(eioClose)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Error:     err.Error(),
+			ErrorCode: CodeInvalidRequest,
+		})
+		return
+	}
+	c.Data(http.StatusOK, engineIOContentType, []byte("ok"))
+}
+
+// engineIOUnknownSession rejects a request for a session that does not exist
+func engineIOUnknownSession(c *gin.Context) {
+	c.JSON(http.StatusBadRequest, ErrorResponse{
+		Code:      http.StatusBadRequest,
+		Error:     errEngineIOUnknownSession.Error(),
+		ErrorCode: CodeInvalidRequest,
+	})
+}
@@ -0,0 +1,58 @@
+package websocket
+
+// ErrorCode identifies the cause of an ErrorResponse. Values are stable, so clients
+// can branch on them instead of parsing messages.
+type ErrorCode string
+
+// Error codes returned in ErrorResponse
+const (
+	CodeInvalidRequest       ErrorCode = "INVALID_REQUEST"
+	CodeValidationFailed     ErrorCode = "VALIDATION_FAILED"
+	CodeInvalidRoomID        ErrorCode = "INVALID_ROOM_ID"
+	CodeInvalidUsername      ErrorCode = "INVALID_USERNAME"
+	CodeInvalidWebhookURL    ErrorCode = "INVALID_WEBHOOK_URL"
+	CodeNotFound             ErrorCode = "NOT_FOUND"
+	CodeRoomNotFound         ErrorCode = "ROOM_NOT_FOUND"
+	CodeUserNotFound         ErrorCode = "USER_NOT_FOUND"
+	CodeAlreadyHost          ErrorCode = "ALREADY_HOST"
+	CodeHookNotFound         ErrorCode = "HOOK_NOT_FOUND"
+	CodeWebhookNotFound      ErrorCode = "WEBHOOK_NOT_FOUND"
+	CodeMetadataNotFound     ErrorCode = "METADATA_NOT_FOUND"
+	CodeConnectionNotFound   ErrorCode = "CONNECTION_NOT_FOUND"
+	CodeInvalidToken         ErrorCode = "INVALID_TOKEN"
+	CodeInvalidPassword      ErrorCode = "INVALID_PASSWORD"
+	CodePasswordRequired     ErrorCode = "PASSWORD_REQUIRED"
+	CodeInvalidTicket        ErrorCode = "INVALID_TICKET"
+	CodeInvalidAPIKey        ErrorCode = "INVALID_API_KEY"
+	CodeInvalidAdminKey      ErrorCode = "INVALID_ADMIN_KEY"
+	CodeRoomFull             ErrorCode = "ROOM_FULL"
+	CodeUsernameTaken        ErrorCode = "USERNAME_TAKEN"
+	CodeBanned               ErrorCode = "BANNED"
+	CodeCannotMuteHost       ErrorCode = "CANNOT_MUTE_HOST"
+	CodeBanNotFound          ErrorCode = "BAN_NOT_FOUND"
+	CodeTooManyBans          ErrorCode = "TOO_MANY_BANS"
+	CodeRoomFrozen           ErrorCode = "ROOM_FROZEN"
+	CodeRoomLocked           ErrorCode = "ROOM_LOCKED"
+	CodeRoomMigrating        ErrorCode = "ROOM_MIGRATING"
+	CodeRoomExists           ErrorCode = "ROOM_EXISTS"
+	CodeMigrationFailed      ErrorCode = "MIGRATION_FAILED"
+	CodeTargetNotAllowed     ErrorCode = "TARGET_NOT_ALLOWED"
+	CodeTooManyHooks         ErrorCode = "TOO_MANY_HOOKS"
+	CodeTooManyWebhooks      ErrorCode = "TOO_MANY_WEBHOOKS"
+	CodeTooManyMetadataKeys  ErrorCode = "TOO_MANY_METADATA_KEYS"
+	CodeTooManyBreakouts     ErrorCode = "TOO_MANY_BREAKOUTS"
+	CodeTooManyUploads       ErrorCode = "TOO_MANY_UPLOADS"
+	CodeUnsupportedType      ErrorCode = "UNSUPPORTED_TYPE"
+	CodeBreakoutNotFound     ErrorCode = "BREAKOUT_NOT_FOUND"
+	CodeQuotaExceeded        ErrorCode = "QUOTA_EXCEEDED"
+	CodeRateLimited          ErrorCode = "RATE_LIMITED"
+	CodeServerBusy           ErrorCode = "SERVER_BUSY"
+	CodeTooManyConnections   ErrorCode = "TOO_MANY_CONNECTIONS"
+	CodePreconditionRequired ErrorCode = "PRECONDITION_REQUIRED"
+	CodeSettingsConflict     ErrorCode = "SETTINGS_CONFLICT"
+	CodeIdempotencyConflict  ErrorCode = "IDEMPOTENCY_CONFLICT"
+	CodeIdempotencyMismatch  ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	CodeRequestTooLarge      ErrorCode = "REQUEST_TOO_LARGE"
+	CodeFeatureDisabled      ErrorCode = "FEATURE_DISABLED"
+	CodeInternal             ErrorCode = "INTERNAL_ERROR"
+)
@@ -0,0 +1,79 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+// Server event types published on the EventBus
+const (
+	ServerEventRoomCreated    = "room_created"
+	ServerEventRoomDeleted    = "room_deleted"
+	ServerEventUserJoined     = "user_joined"
+	ServerEventUserKicked     = "user_kicked"
+	ServerEventMessageDropped = "message_dropped"
+)
+
+// ServerEventTypes lists every server event type
+var ServerEventTypes = []string{
+	ServerEventRoomCreated,
+	ServerEventRoomDeleted,
+	ServerEventUserJoined,
+	ServerEventUserKicked,
+	ServerEventMessageDropped,
+}
+
+// ServerEvent is a room lifecycle or membership event for integrations
+type ServerEvent struct {
+	At       time.Time `json:"at" example:"2024-01-01T12:00:00Z"`
+	Type     string    `json:"type" example:"user_joined"`
+	Username string    `json:"username,omitempty" example:"JohnDoe"`
+	By       string    `json:"by,omitempty" example:"host"`
+	RoomID   ID        `json:"room_id" example:"123456"`
+}
+
+// EventBus fans server events out to subscribers. Subscribers are called
+// synchronously from room goroutines and must not block.
+type EventBus struct {
+	subscribers []func(ServerEvent)
+	mu          sync.RWMutex
+}
+
+// NewEventBus creates an event bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers fn for every published event
+func (b *EventBus) Subscribe(fn func(ServerEvent)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// Publish delivers event to the subscribers. A nil bus discards it.
+func (b *EventBus) Publish(event ServerEvent) {
+	if b == nil {
+		return
+	}
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, fn := range b.subscribers {
+		fn(event)
+	}
+}
+
+// WithEventBus publishes the room's server events on bus
+func WithEventBus(bus *EventBus) RoomOption {
+	return func(r *Room) {
+		r.bus = bus
+	}
+}
+
+// emit publishes a server event of the room
+func (r *Room) emit(eventType, username, by string) {
+	r.bus.Publish(ServerEvent{Type: eventType, RoomID: r.ID, Username: username, By: by})
+}
@@ -0,0 +1,124 @@
+package websocket
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// EventLogSize is how many events a room keeps for the event feed
+const EventLogSize = 1000
+
+// Event types recorded besides the room notifications
+const (
+	EventMessage = "message"
+	EventBan     = "ban"
+	EventUnban   = "unban"
+)
+
+// feedNotifications are the room notifications recorded in the event feed:
+// membership, moderation, message changes and settings. Presence updates such as
+// hand_queue or media_state are left out, so they can't push those out of the log.
+var feedNotifications = map[string]bool{
+	"join":     true,
+	"leave":    true,
+	"rename":   true,
+	"host":     true,
+	"kick":     true,
+	"mute":     true,
+	"freeze":   true,
+	"unfreeze": true,
+	"lock":     true,
+	"unlock":   true,
+	"edit":     true,
+	"delete":   true,
+	"settings": true,
+	"metadata": true,
+	"closing":  true,
+	"closed":   true,
+}
+
+// hostOnlyEvents are not broadcast to members, so only hosts may read them
+var hostOnlyEvents = map[string]bool{
+	EventBan:           true,
+	EventUnban:         true,
+	EventFlagged:       true,
+	EventAutoModerated: true,
+}
+
+// RoomEvent is an entry of the room event feed. Data holds the payload
+// members received over WebSocket for the same event.
+type RoomEvent struct {
+	At   time.Time       `json:"at" example:"2024-01-01T12:00:00Z"`
+	Type string          `json:"type" example:"join"`
+	Data json.RawMessage `json:"data" swaggertype:"object"`
+	ID   uint64          `json:"id" example:"17"`
+}
+
+// UnbanEvent is the payload of an unban event
+type UnbanEvent struct {
+	Username string `json:"username,omitempty" example:"spammer"`
+	IP       string `json:"ip,omitempty" example:"203.0.113.7"`
+}
+
+// eventLog keeps the most recent events of a room in memory
+type eventLog struct {
+	events []RoomEvent
+	nextID uint64
+	mu     sync.RWMutex
+}
+
+// append stores an event, dropping the oldest ones over EventLogSize
+func (l *eventLog) append(eventType string, data json.RawMessage, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextID++
+	l.events = append(l.events, RoomEvent{ID: l.nextID, Type: eventType, Data: data, At: now})
+	if drop := len(l.events) - EventLogSize; drop > 0 {
+		l.events = append(l.events[:0:0], l.events[drop:]...)
+	}
+}
+
+// recordEvent appends an event with the JSON encoding of payload to the feed
+func (r *Room) recordEvent(eventType string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	r.events.append(eventType, data, time.Now())
+}
+
+// EventsSince returns up to limit events newer than since, oldest first, and the
+// since value to resume from. Host-only events are skipped unless includeHostOnly
+// is set, and messages older than the room retention are left out. truncated reports
+// that events after since were already dropped, so the caller should resync its state.
+func (r *Room) EventsSince(since uint64, limit int, includeHostOnly bool) (events []RoomEvent, next uint64, truncated bool) {
+	cutoff := time.Time{}
+	if retention := r.Settings().Retention; retention > 0 {
+		cutoff = time.Now().Add(-retention)
+	}
+
+	r.events.mu.RLock()
+	defer r.events.mu.RUnlock()
+	events, next = []RoomEvent{}, since
+	if len(r.events.events) > 0 && r.events.events[0].ID > since+1 {
+		truncated = true
+	}
+	for _, event := range r.events.events {
+		if len(events) >= limit {
+			break
+		}
+		if event.ID <= since {
+			continue
+		}
+		next = event.ID
+		if hostOnlyEvents[event.Type] && !includeHostOnly {
+			continue
+		}
+		if event.Type == EventMessage && event.At.Before(cutoff) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, next, truncated
+}
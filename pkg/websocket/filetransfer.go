@@ -0,0 +1,213 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrCodeFileTransfer is sent when a file transfer message cannot be brokered
+const ErrCodeFileTransfer = "file_transfer_failed"
+
+// File transfer limits
+const (
+	MaxFileNameLength     = 255
+	MaxPendingFileOffers  = 5 // unanswered offers a member may have open
+	FileOfferTTL          = time.Minute
+	maxFileTransferSignal = 64 * 1024
+)
+
+var (
+	ErrInvalidFileOffer     = errors.New("file offer needs a recipient, a name of at most 255 characters and a positive size")
+	ErrTooManyFileOffers    = errors.New("too many unanswered file offers")
+	ErrFileTransferNotFound = errors.New("file transfer not found")
+)
+
+// FileOfferMessage Payload of a member offering a file to another member.
+// The file itself is sent over a WebRTC data channel, never through the server.
+type FileOfferMessage struct {
+	To   string `json:"to" example:"JaneDoe"`
+	Name string `json:"name" example:"slides.pdf"`
+	Mime string `json:"mime,omitempty" example:"application/pdf"`
+	Size int64  `json:"size" example:"1048576"`
+}
+
+// FileOfferNotification Sent to the recipient of a file offer, and to its sender as
+// "file-offered" with the transfer ID assigned by the server
+type FileOfferNotification struct {
+	TransferID string `json:"transfer_id" example:"3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"`
+	From       string `json:"from" example:"JohnDoe"`
+	To         string `json:"to" example:"JaneDoe"`
+	Name       string `json:"name" example:"slides.pdf"`
+	Mime       string `json:"mime,omitempty" example:"application/pdf"`
+	Size       int64  `json:"size" example:"1048576"`
+}
+
+// FileTransferMessage Payload of file-accept, file-reject, file-cancel, file-signal and
+// file-progress messages. Signal carries the data-channel SDP or ICE candidate,
+// Bytes the amount received so far.
+type FileTransferMessage struct {
+	TransferID string          `json:"transfer_id" example:"3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"`
+	From       string          `json:"from,omitempty" example:"JaneDoe"` // set by the server when relayed
+	Signal     json.RawMessage `json:"signal,omitempty" swaggertype:"object"`
+	Bytes      int64           `json:"bytes,omitempty" example:"524288"`
+}
+
+// fileTransfer is a file offer between two members, guarded by Room.mu
+type fileTransfer struct {
+	offeredAt time.Time
+	sender    *Client
+	recipient *Client
+	size      int64
+	accepted  bool
+}
+
+// peer returns the other party of the transfer, nil if c is not a party
+func (t *fileTransfer) peer(c *Client) *Client {
+	switch c {
+	case t.sender:
+		return t.recipient
+	case t.recipient:
+		return t.sender
+	}
+	return nil
+}
+
+// registerFileTransferSignaling registers the messages brokering peer-to-peer file
+// transfers: the server relays the offer, consent and the data-channel signaling of
+// an accepted transfer between its two parties only
+func registerFileTransferSignaling(s *SignalingHandler) {
+	s.Register("file-offer", (*Client).handleFileOffer)
+	s.Register("file-accept", (*Client).handleFileTransferMessage)
+	s.Register("file-reject", (*Client).handleFileTransferMessage)
+	s.Register("file-cancel", (*Client).handleFileTransferMessage)
+	s.Register("file-signal", (*Client).handleFileTransferMessage)
+	s.Register("file-progress", (*Client).handleFileTransferMessage)
+}
+
+// handleFileOffer forwards a file offer to its recipient
+func (c *Client) handleFileOffer(msg Message) {
+	var offer FileOfferMessage
+	if err := json.Unmarshal(msg.Data, &offer); err != nil {
+		c.sendError(ErrCodeFileTransfer, ErrInvalidFileOffer.Error())
+		return
+	}
+	notification, recipient, err := c.Room.offerFile(c, offer)
+	if err != nil {
+		c.sendError(ErrCodeFileTransfer, err.Error())
+		return
+	}
+	recipient.trySend(mustMarshal(Message{Type: "file-offer", Data: mustMarshal(notification)}))
+	c.trySend(mustMarshal(Message{Type: "file-offered", Data: mustMarshal(notification)}))
+}
+
+// offerFile records a file offer from sender and returns it with its recipient
+func (r *Room) offerFile(sender *Client, offer FileOfferMessage) (FileOfferNotification, *Client, error) {
+	if offer.To == "" || offer.To == sender.Username || offer.Name == "" ||
+		len(offer.Name) > MaxFileNameLength || offer.Size <= 0 {
+		return FileOfferNotification{}, nil, ErrInvalidFileOffer
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneFileTransfers(now)
+	var recipient *Client
+	for client := range r.Clients {
+		if client.Username == offer.To {
+			recipient = client
+			break
+		}
+	}
+	if recipient == nil {
+		return FileOfferNotification{}, nil, ErrUserNotInRoom
+	}
+	pending := 0
+	for _, transfer := range r.transfers {
+		if transfer.sender == sender && !transfer.accepted {
+			pending++
+		}
+	}
+	if pending >= MaxPendingFileOffers {
+		return FileOfferNotification{}, nil, ErrTooManyFileOffers
+	}
+
+	id := uuid.New().String()
+	if r.transfers == nil {
+		r.transfers = make(map[string]*fileTransfer)
+	}
+	r.transfers[id] = &fileTransfer{sender: sender, recipient: recipient, size: offer.Size, offeredAt: now}
+	return FileOfferNotification{
+		TransferID: id,
+		From:       sender.Username,
+		To:         recipient.Username,
+		Name:       offer.Name,
+		Mime:       offer.Mime,
+		Size:       offer.Size,
+	}, recipient, nil
+}
+
+// handleFileTransferMessage relays a message about an existing transfer to its other party
+func (c *Client) handleFileTransferMessage(msg Message) {
+	var transferMsg FileTransferMessage
+	if err := json.Unmarshal(msg.Data, &transferMsg); err != nil || len(transferMsg.Signal) > maxFileTransferSignal {
+		c.sendError(ErrCodeFileTransfer, "invalid file transfer message")
+		return
+	}
+	peer, err := c.Room.advanceFileTransfer(c, msg.Type, transferMsg)
+	if err != nil {
+		c.sendError(ErrCodeFileTransfer, err.Error())
+		return
+	}
+	transferMsg.From = c.Username
+	peer.trySend(mustMarshal(Message{Type: msg.Type, Data: mustMarshal(transferMsg)}))
+}
+
+// advanceFileTransfer applies a transfer message of c and returns the member to relay it to.
+// Only the recipient may accept or reject an offer, and signaling and progress are
+// only relayed once it was accepted.
+func (r *Room) advanceFileTransfer(c *Client, msgType string, msg FileTransferMessage) (*Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneFileTransfers(time.Now())
+	transfer, ok := r.transfers[msg.TransferID]
+	if !ok || transfer.peer(c) == nil {
+		return nil, ErrFileTransferNotFound
+	}
+	peer := transfer.peer(c)
+
+	switch msgType {
+	case "file-accept", "file-reject":
+		if c != transfer.recipient || transfer.accepted {
+			return nil, ErrFileTransferNotFound
+		}
+		if msgType == "file-accept" {
+			transfer.accepted = true
+		} else {
+			delete(r.transfers, msg.TransferID)
+		}
+	case "file-cancel":
+		delete(r.transfers, msg.TransferID)
+	case "file-signal", "file-progress":
+		if !transfer.accepted {
+			return nil, errors.New("file transfer was not accepted")
+		}
+		if msgType == "file-progress" && msg.Bytes >= transfer.size {
+			delete(r.transfers, msg.TransferID)
+		}
+	}
+	return peer, nil
+}
+
+// pruneFileTransfers drops unanswered offers older than FileOfferTTL and transfers
+// whose parties left. Caller must hold r.mu.
+func (r *Room) pruneFileTransfers(now time.Time) {
+	for id, transfer := range r.transfers {
+		expired := !transfer.accepted && now.Sub(transfer.offeredAt) > FileOfferTTL
+		if expired || !r.Clients[transfer.sender] || !r.Clients[transfer.recipient] {
+			delete(r.transfers, id)
+		}
+	}
+}
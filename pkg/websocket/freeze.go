@@ -0,0 +1,109 @@
+package websocket
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Error codes sent in ErrorNotification
+const (
+	ErrCodeRoomFrozen = "room_frozen"
+)
+
+// Freeze suspends messaging and joins for everyone except hosts.
+// A zero duration freezes the room until Unfreeze is called.
+func (r *Room) Freeze(duration time.Duration) {
+	r.mu.Lock()
+	r.frozen = true
+	r.frozenUntil = time.Time{}
+	r.freezeGen++
+	if r.unfreezeTimer != nil {
+		r.unfreezeTimer.Stop()
+		r.unfreezeTimer = nil
+	}
+	if duration > 0 {
+		gen := r.freezeGen
+		r.frozenUntil = time.Now().Add(duration)
+		r.unfreezeTimer = time.AfterFunc(duration, func() { r.expireFreeze(gen) })
+	}
+	notification := FreezeNotification{Frozen: true}
+	if !r.frozenUntil.IsZero() {
+		notification.Until = r.frozenUntil.UnixMilli()
+	}
+	r.mu.Unlock()
+
+	r.broadcastNotification("freeze", notification)
+}
+
+// Unfreeze lifts a freeze. It is a no-op if the room is not frozen.
+func (r *Room) Unfreeze() {
+	r.mu.Lock()
+	lifted := r.liftFreeze()
+	r.mu.Unlock()
+	if lifted {
+		r.broadcastNotification("unfreeze", FreezeNotification{Frozen: false})
+	}
+}
+
+// expireFreeze lifts the timed freeze of generation gen. A timer that fired while
+// the room was frozen or unfrozen again leaves the newer state alone.
+func (r *Room) expireFreeze(gen uint64) {
+	r.mu.Lock()
+	lifted := r.freezeGen == gen && r.liftFreeze()
+	r.mu.Unlock()
+	if lifted {
+		r.broadcastNotification("unfreeze", FreezeNotification{Frozen: false})
+	}
+}
+
+// liftFreeze clears the freeze and reports whether the room was frozen. Caller
+// holds r.mu.
+func (r *Room) liftFreeze() bool {
+	if !r.frozen {
+		return false
+	}
+	r.frozen = false
+	r.frozenUntil = time.Time{}
+	r.freezeGen++
+	if r.unfreezeTimer != nil {
+		r.unfreezeTimer.Stop()
+		r.unfreezeTimer = nil
+	}
+	return true
+}
+
+// IsFrozen reports whether non-host messaging and joins are suspended
+func (r *Room) IsFrozen() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.frozen
+}
+
+// handleFreezeMessage lets a host freeze the room over WebSocket
+func (c *Client) handleFreezeMessage(message Message) {
+	var freeze FreezeMessage
+	if len(message.Data) > 0 {
+		if err := json.Unmarshal(message.Data, &freeze); err != nil {
+			return
+		}
+	}
+	if freeze.DurationSeconds < 0 {
+		return
+	}
+	c.Room.Freeze(time.Duration(freeze.DurationSeconds) * time.Second)
+}
+
+// sendError delivers a structured error message to this client only
+func (c *Client) sendError(code, message string) {
+	c.sendErrorNotification(ErrorNotification{Code: code, Message: message})
+}
+
+func (c *Client) sendErrorNotification(notification ErrorNotification) {
+	c.trySend(c.errorMessage(notification))
+}
+
+// errorMessage returns notification as a message, its text marked as a system one
+func (c *Client) errorMessage(notification ErrorNotification) []byte {
+	notification.Message = c.Room.systemText(notification.Message)
+	return mustMarshal(Message{Type: "error", Data: mustMarshal(notification)})
+}
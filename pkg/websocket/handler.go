@@ -5,19 +5,25 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/quic-go/webtransport-go"
 )
 
 const (
 	bufferSize = 256
 
+	// admissionRetryAfter is the Retry-After value (seconds) sent when the server is saturated
+	admissionRetryAfter = "5"
+
 	MaxUsernameLength = 50
 	MinUsernameLength = 4
 
+	// DefaultName is the built-in username of clients that join without one, see Branding
 	DefaultName = "Anonymous"
 )
 
@@ -25,26 +31,47 @@ type Handler struct {
 	Hub              *Hub
 	Pool             *TaskPool
 	SignalingHandler *SignalingHandler
+	Admission        *AdmissionController
+	PerIP            *IPConnectionLimiter // optional, caps the connections of each client IP
+	Tickets          *TicketStore
+	AuthMetrics      AuthMetrics          // optional, counts rejected credentials
+	Usernames        *UsernameRegistry    // optional, nil allows duplicate usernames
+	HandshakeTimeout time.Duration        // how long an upgraded client may stay silent, 0 disables the limit
+	Origins          *OriginAllowlist     // cross-origin browsers allowed to connect, checked by Upgrader
+	Pseudonyms       *Pseudonymizer       // optional, identifies anonymous members for bans and rate limits
+	Compression      *Compression         // optional, set with EnableCompression
+	States           *StateTokens         // optional, issues state tokens to resume after a restart
+	Filter           *ContentFilter       // optional, server word list also kept out of suggested usernames
+	WebTransport     *webtransport.Server // optional, set with EnableWebTransport
 	Upgrader         websocket.Upgrader
+	engineIO         *engineIOSessions // Engine.IO sessions polling until their upgrade
 }
 
+// NewHandler creates a handler that only accepts browsers from the server's own
+// origin until other origins are added to Origins
 func NewHandler(hub *Hub, pool *TaskPool) *Handler {
+	origins := NewOriginAllowlist(nil)
 	return &Handler{
-		Hub:  hub,
-		Pool: pool,
+		Hub:     hub,
+		Pool:    pool,
+		Origins: origins,
 		Upgrader: websocket.Upgrader{
 			ReadBufferSize:  4096,
 			WriteBufferSize: 4096,
-			CheckOrigin:     func(r *http.Request) bool { return true },
+			CheckOrigin:     origins.CheckOrigin,
 		},
 		SignalingHandler: NewSignalingHandler(),
+		Tickets:          NewTicketStore(JoinTicketTTL, nil),
+		States:           NewStateTokens(nil, ResumeStateTTL),
+		HandshakeTimeout: DefaultHandshakeTimeout,
+		engineIO:         newEngineIOSessions(),
 	}
 }
 
-// checkPasswordHash compares a password with its hash
-func checkPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+// UseMessageInterceptor runs interceptor on every inbound WebSocket message before
+// dispatch, e.g. to filter, audit or enrich messages
+func (h *Handler) UseMessageInterceptor(interceptor MessageInterceptor) {
+	h.SignalingHandler.Use(interceptor)
 }
 
 // validateUsername validates username format and length
@@ -74,8 +101,8 @@ func validateRoomID(roomIDStr string) (ID, error) {
 	return roomID, nil
 }
 
-// processUsername validates and returns username or default
-func processUsername(username string) (string, error) {
+// processUsername validates and returns username or defaultName if it is empty
+func processUsername(username, defaultName string) (string, error) {
 	username = strings.TrimSpace(username)
 	if username != "" {
 		if err := validateUsername(username); err != nil {
@@ -83,66 +110,81 @@ func processUsername(username string) (string, error) {
 		}
 		return username, nil
 	}
-	return DefaultName, nil
+	return defaultName, nil
 }
 
-// validateRoomPassword checks if provided password matches room password
-func validateRoomPassword(room *Room, providedPassword string) error {
-	if room.HashedPassword == "" {
-		return nil
-	}
-	if providedPassword == "" || !checkPasswordHash(providedPassword, room.HashedPassword) {
-		return &ValidationError{Field: "password", Message: "invalid or missing password"}
+// validateHostToken validates JWT token and returns the host ID it grants in the room.
+// A well-formed token for a host that no longer holds privileges yields ErrNotHost.
+func validateHostToken(hostToken, roomIDStr string, keys *TokenKeys, room *Room) (string, error) {
+	if hostToken == "" || keys == nil {
+		return "", errInvalidHostToken
 	}
-	return nil
-}
-
-// validateHostToken validates JWT token and checks if user is host
-func validateHostToken(hostToken, roomIDStr, jwtSecret string, room *Room) (bool, error) {
-	if hostToken == "" {
-		return false, nil
-	}
-	token, err := jwt.Parse(hostToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method")
-		}
-		return []byte(jwtSecret), nil
-	})
+	token, err := keys.Parse(hostToken)
 	if err != nil || !token.Valid {
-		return false, nil
+		return "", errInvalidHostToken
 	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return false, nil
+		return "", errInvalidHostToken
 	}
 	if roomIDStr != fmt.Sprintf("%v", claims["room_id"]) || claims["host"] != true {
-		return false, nil
+		return "", errInvalidHostToken
+	}
+	hostID, ok := claims["host_id"].(string)
+	if !ok {
+		return "", errInvalidHostToken
+	}
+	if !room.IsHostID(hostID) {
+		return "", ErrNotHost
+	}
+	return hostID, nil
+}
+
+// authFailed reports a rejected credential on the WebSocket join path
+func (h *Handler) authFailed(reason string) {
+	if h.AuthMetrics != nil {
+		h.AuthMetrics.AuthFailed(AuthTransportWS, reason)
 	}
-	hostIDClaim, exists := claims["host_id"]
-	if !exists {
-		return false, nil
+}
+
+// upgradeConnection upgrades HTTP connection to WebSocket. When the client
+// negotiated compression it also returns the network connection, counting the
+// bytes written, and nil otherwise.
+func (h *Handler) upgradeConnection(c *gin.Context) (*websocket.Conn, *countingConn, error) {
+	if h.Compression == nil || !offersCompression(c.Request) {
+		conn, err := h.Upgrader.Upgrade(c.Writer, c.Request, upgradeHeader(c))
+		return conn, nil, err
 	}
-	hostIDStr, ok := hostIDClaim.(string)
-	if !ok || hostIDStr != room.HostID {
-		return false, nil
+	w := &countingWriter{ResponseWriter: c.Writer}
+	conn, err := h.Upgrader.Upgrade(w, c.Request, upgradeHeader(c))
+	if err != nil {
+		return nil, nil, err
 	}
-	return true, nil
+	conn.SetCompressionLevel(h.Compression.Level)
+	return conn, w.conn, nil
 }
 
-// upgradeConnection upgrades HTTP connection to WebSocket
-func (h *Handler) upgradeConnection(c *gin.Context) (*websocket.Conn, error) {
-	return h.Upgrader.Upgrade(c.Writer, c.Request, nil)
+// upgradeUncompressed upgrades HTTP connection to WebSocket without negotiating
+// compression, for transports that frame messages themselves and so bypass the
+// compression metrics and MinSize
+func (h *Handler) upgradeUncompressed(c *gin.Context) (*websocket.Conn, error) {
+	upgrader := h.Upgrader
+	upgrader.EnableCompression = false
+	return upgrader.Upgrade(c.Writer, c.Request, upgradeHeader(c))
 }
 
 // createClient creates a new WebSocket client
-func createClient(conn *websocket.Conn, room *Room, username string, isHost bool) *Client {
-	return &Client{
+func createClient(conn Conn, room *Room, username, hostID string) *Client {
+	client := &Client{
 		Conn:     conn,
 		Send:     make(chan []byte, bufferSize),
+		control:  make(chan []byte, controlQueueSize),
 		Room:     room,
 		Username: username,
-		IsHost:   isHost,
+		hostID:   hostID,
 	}
+	client.isHost.Store(hostID != "")
+	return client
 }
 
 // startClientTasks starts read and write tasks for the client
@@ -162,10 +204,15 @@ func (h *Handler) startClientTasks(client *Client) error {
 	return nil
 }
 
-// HandleWebSocketWithJWT creates a handler function with JWT secret
+// HandleWebSocketWithJWT creates a handler function verifying HS256 host tokens with jwtSecret
 func (h *Handler) HandleWebSocketWithJWT(jwtSecret string) gin.HandlerFunc {
+	return h.HandleWebSocketWithKeys(NewHMACKeys(jwtSecret))
+}
+
+// HandleWebSocketWithKeys creates a handler function verifying host tokens with keys
+func (h *Handler) HandleWebSocketWithKeys(keys *TokenKeys) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		h.handleWebSocket(c, jwtSecret)
+		h.handleWebSocket(c, keys)
 	}
 }
 
@@ -175,71 +222,391 @@ func (h *Handler) HandleWebSocketWithJWT(jwtSecret string) gin.HandlerFunc {
 // @Tags websocket
 // @Param room_id path int true "Room ID (1-999999999)"
 // @Param username query string false "Username for chat. If omitted, 'Anonymous' is used"
-// @Param password query string false "Room password if required"
+// @Param ticket query string false "Single-use join ticket from the join-ticket endpoint, required for password-protected rooms"
 // @Param host_token query string false "Host token for room management privileges"
+// @Param resume query string false "Resume token from the welcome message to reconnect within the grace period"
+// @Param since query int false "Replay buffered broadcasts with a sequence number greater than this"
+// @Param state query string false "State token from a state message to resume the same identity, e.g. after a server restart"
 // @Success 101 {string} string "Switching Protocols (WebSocket upgraded)"
 // @Failure 400 {object} ErrorResponse "Bad request or validation error"
-// @Failure 401 {object} ErrorResponse "Unauthorized - invalid password or host token"
+// @Failure 401 {object} ErrorResponse "Unauthorized - invalid join ticket"
+// @Failure 403 {object} ErrorResponse "Banned from the room"
 // @Failure 404 {object} ErrorResponse "Room not found"
+// @Failure 409 {object} ErrorResponse "Room is full or username is taken"
+// @Failure 429 {object} ErrorResponse "Connection quota of the API key or the client address exceeded"
+// @Failure 423 {object} ErrorResponse "Room is frozen or locked"
 // @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Server is at connection capacity"
 // @Router /ws/{room_id} [get]
-func (h *Handler) handleWebSocket(c *gin.Context, jwtSecret string) {
-	roomIDStr := c.Param("room_id")
+func (h *Handler) handleWebSocket(c *gin.Context, keys *TokenKeys) {
+	h.serveRoom(c, c.Param("room_id"), keys, h.upgradeWebSocket)
+}
+
+// HandleEngineIOWithJWT creates a handler speaking Engine.IO/Socket.IO framing
+// over the WebSocket transport, so Socket.IO frontends can join rooms.
+func (h *Handler) HandleEngineIOWithJWT(jwtSecret string) gin.HandlerFunc {
+	return h.HandleEngineIOWithKeys(NewHMACKeys(jwtSecret))
+}
 
+// HandleEngineIOWithKeys is HandleEngineIOWithJWT verifying host tokens with keys
+func (h *Handler) HandleEngineIOWithKeys(keys *TokenKeys) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.handleEngineIO(c, keys)
+	}
+}
+
+// HandleEngineIO godoc
+// @Summary Connect to a room using Socket.IO
+// @Description Engine.IO v4 compatible endpoint. Socket.IO events map to message types.
+// @Description Sessions may open over HTTP long-polling (GET without sid), exchanging packets with GET
+// @Description and POST requests carrying the sid, but must upgrade to the websocket transport within
+// @Description 30 seconds: members join the room once upgraded, and events sent while polling are delivered then.
+// @Tags websocket
+// @Param EIO query string true "Engine.IO protocol version, must be 4"
+// @Param transport query string true "Transport, websocket or polling"
+// @Param sid query string false "Session ID from the polling handshake, to poll or upgrade the session"
+// @Param room_id query int true "Room ID (1-999999999)"
+// @Param username query string false "Username for chat. If omitted, 'Anonymous' is used"
+// @Param ticket query string false "Single-use join ticket from the join-ticket endpoint, required for password-protected rooms"
+// @Param host_token query string false "Host token for room management privileges"
+// @Success 101 {string} string "Switching Protocols (WebSocket upgraded)"
+// @Success 200 {string} string "Engine.IO packets of the polling transport"
+// @Failure 400 {object} ErrorResponse "Bad request, validation error or unknown session"
+// @Failure 401 {object} ErrorResponse "Unauthorized - invalid join ticket"
+// @Failure 403 {object} ErrorResponse "Banned from the room"
+// @Failure 404 {object} ErrorResponse "Room not found"
+// @Router /socket.io/ [get]
+// @Router /socket.io/ [post]
+func (h *Handler) handleEngineIO(c *gin.Context, keys *TokenKeys) {
+	if err := engineIOQueryError(c.Query("EIO"), c.Query("transport")); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Error:     err.Error(),
+			ErrorCode: CodeInvalidRequest,
+		})
+		return
+	}
+	if c.Query("transport") == "polling" {
+		h.serveEngineIOPolling(c)
+		return
+	}
+
+	// A sid upgrades a session opened over polling
+	var session *engineIOPollingSession
+	if sid := c.Query("sid"); sid != "" {
+		if session = h.engineIO.get(sid); session == nil || !session.claim() {
+			engineIOUnknownSession(c)
+			return
+		}
+		// The session keeps polling until the upgrade completes or the join fails
+		defer func() {
+			h.engineIO.take(sid)
+			session.close(eioClose)
+		}()
+	}
+
+	h.serveRoom(c, c.Query("room_id"), keys, func(c *gin.Context) (Conn, *countingConn, error) {
+		conn, err := h.upgradeUncompressed(c)
+		if err != nil {
+			return nil, nil, err
+		}
+		var eioConn *engineIOConn
+		if session != nil {
+			eioConn, err = upgradeEngineIOSession(conn, session)
+		} else {
+			eioConn, err = newEngineIOConn(conn)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		return eioConn, nil, nil
+	})
+}
+
+// lookupRoom returns the room a join request targets. On an invalid or unknown
+// room the error response is written and ok is false.
+func (h *Handler) lookupRoom(c *gin.Context, roomIDStr string) (room *Room, ok bool) {
 	roomID, err := validateRoomID(roomIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":  http.StatusBadRequest,
-			"error": err.Error(),
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Error:     err.Error(),
+			ErrorCode: CodeInvalidRoomID,
 		})
-		return
+		return nil, false
 	}
 
-	room, ok := h.Hub.GetRoom(roomID)
+	room, ok = h.Hub.GetRoom(roomID)
 	if !ok {
-		c.JSON(http.StatusNotFound, gin.H{
-			"code":  http.StatusNotFound,
-			"error": "room not found",
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:      http.StatusNotFound,
+			Error:     "room not found",
+			ErrorCode: CodeRoomNotFound,
 		})
+	}
+	return room, ok
+}
+
+// transportUpgrader upgrades an authorized join request to the connection of the
+// client. wire counts the bytes written when the connection compresses messages.
+type transportUpgrader func(c *gin.Context) (conn Conn, wire *countingConn, err error)
+
+// upgradeWebSocket is the transportUpgrader of the WebSocket endpoint
+func (h *Handler) upgradeWebSocket(c *gin.Context) (Conn, *countingConn, error) {
+	conn, wire, err := h.upgradeConnection(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, wire, nil
+}
+
+// serveRoom authorizes the join request, upgrades the connection with upgrade and
+// registers the client
+func (h *Handler) serveRoom(c *gin.Context, roomIDStr string, keys *TokenKeys, upgrade transportUpgrader) {
+	room, ok := h.lookupRoom(c, roomIDStr)
+	if !ok {
 		return
 	}
 
-	username, err := processUsername(c.Query("username"))
+	// A state token resumes the identity it was issued to, in place of username and
+	// session, and stands in for the password only while the room and its password
+	// are the ones it was issued with. Bans are checked as for any join below.
+	sessionID := c.GetString(SessionIDKey)
+	var resumeState *ResumeState
+	if token := c.Query("state"); token != "" && h.States != nil {
+		state, err := h.States.Verify(token, time.Now())
+		if err != nil || !room.admitsState(state) {
+			h.authFailed(AuthFailureInvalidState)
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Code:      http.StatusUnauthorized,
+				Error:     ErrInvalidStateToken.Error(),
+				ErrorCode: CodeInvalidToken,
+			})
+			return
+		}
+		resumeState = &state
+		sessionID = state.SessionID
+	}
+
+	username, err := processUsername(c.Query("username"), room.brand().DefaultName)
+	if resumeState != nil {
+		username, err = resumeState.Username, nil
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":  http.StatusBadRequest,
-			"error": err.Error(),
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Error:     err.Error(),
+			ErrorCode: CodeInvalidUsername,
+		})
+		return
+	}
+	if _, banned := h.Hub.IsBanned(username, c.ClientIP()); banned {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Code:      http.StatusForbidden,
+			Error:     "you are banned from this server",
+			ErrorCode: CodeBanned,
+		})
+		return
+	}
+
+	if ticket := c.Query("ticket"); ticket != "" {
+		if !h.Tickets.Redeem(ticket, room.ID) {
+			h.authFailed(AuthFailureInvalidTicket)
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Code:      http.StatusUnauthorized,
+				Error:     "invalid or expired join ticket",
+				ErrorCode: CodeInvalidTicket,
+			})
+			return
+		}
+	} else if room.HasPassword() && resumeState == nil {
+		h.authFailed(AuthFailureTicketRequired)
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Code:      http.StatusUnauthorized,
+			Error:     "join ticket required for password-protected room",
+			ErrorCode: CodePasswordRequired,
+		})
+		return
+	}
+
+	hostToken := c.Query("host_token")
+	hostID, err := validateHostToken(hostToken, roomIDStr, keys, room)
+	if err != nil && hostToken != "" {
+		// The join continues without host privileges
+		h.authFailed(authFailureReason(err))
+	}
+	isHost := err == nil
+
+	// Hosts answer for their room and are never identified by a pseudonym
+	var pseudonym, previousPseudonym, fingerprint string
+	if h.Pseudonyms != nil && !isHost {
+		var returning string
+		if c.GetBool(SessionReturningKey) || resumeState != nil {
+			returning = sessionID
+		}
+		now := time.Now()
+		pseudonym, previousPseudonym = h.Pseudonyms.Pseudonyms(returning, c.Request, c.ClientIP(), now)
+		fingerprint = h.Pseudonyms.Fingerprint(c.Request, c.ClientIP(), now)
+	}
+	if !isHost {
+		if _, banned := room.IsBanned(username, c.ClientIP(), pseudonym, previousPseudonym); banned {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Code:      http.StatusForbidden,
+				Error:     "you are banned from this room",
+				ErrorCode: CodeBanned,
+			})
+			return
+		}
+	}
+
+	if !isHost && room.IsFrozen() {
+		c.JSON(http.StatusLocked, ErrorResponse{
+			Code:      http.StatusLocked,
+			Error:     "room is frozen",
+			ErrorCode: CodeRoomFrozen,
 		})
 		return
 	}
 
-	if err := validateRoomPassword(room, c.Query("password")); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":  http.StatusUnauthorized,
-			"error": err.Error(),
+	resumeToken := c.Query("resume")
+	if resumeToken == "" && resumeState != nil {
+		resumeToken = room.pendingToken(username, sessionID)
+	}
+	if !isHost && !room.AdmitsJoin(resumeToken) {
+		c.JSON(http.StatusLocked, ErrorResponse{
+			Code:      http.StatusLocked,
+			Error:     "room is locked",
+			ErrorCode: CodeRoomLocked,
 		})
 		return
 	}
 
-	isHost, err := validateHostToken(c.Query("host_token"), roomIDStr, jwtSecret, room)
+	if room.IsMigrating() {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Code:      http.StatusServiceUnavailable,
+			Error:     "room is moving to another server",
+			ErrorCode: CodeRoomMigrating,
+		})
+		return
+	}
+
+	if !isHost && !room.HasCapacity(resumeToken) {
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:      http.StatusConflict,
+			Error:     "room is full",
+			ErrorCode: CodeRoomFull,
+		})
+		return
+	}
+
+	releaseName, err := h.Usernames.Claim(room, username, sessionID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":  http.StatusUnauthorized,
-			"error": "invalid host token",
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:      http.StatusConflict,
+			Error:     "username is already taken",
+			ErrorCode: CodeUsernameTaken,
+		})
+		return
+	}
+
+	releaseIP, ok := h.PerIP.Acquire(c.ClientIP())
+	if !ok {
+		releaseName()
+		c.Header("Retry-After", admissionRetryAfter)
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{
+			Code:      http.StatusTooManyRequests,
+			Error:     "too many connections from your address",
+			ErrorCode: CodeTooManyConnections,
+		})
+		return
+	}
+
+	release, admitted := h.Admission.Acquire(room.ID)
+	if !admitted {
+		releaseIP()
+		releaseName()
+		c.Header("Retry-After", admissionRetryAfter)
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Code:      http.StatusServiceUnavailable,
+			Error:     "server is at connection capacity, retry later",
+			ErrorCode: CodeServerBusy,
 		})
 		return
 	}
 
-	conn, err := h.upgradeConnection(c)
+	releaseTenant, ok := room.acquireTenantConnection()
+	if !ok {
+		release()
+		releaseIP()
+		releaseName()
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{
+			Code:      http.StatusTooManyRequests,
+			Error:     "connection quota exceeded",
+			ErrorCode: CodeQuotaExceeded,
+		})
+		return
+	}
+	releaseAdmission := release
+	release = func() {
+		releaseAdmission()
+		releaseTenant()
+		releaseIP()
+	}
+	closeEarly := func() {
+		release()
+		releaseName()
+	}
+
+	conn, wire, err := upgrade(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":  http.StatusInternalServerError,
-			"error": "failed to upgrade websocket connection",
+		closeEarly()
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Error:     "failed to upgrade websocket connection",
+			ErrorCode: CodeInternal,
 		})
 		return
 	}
 
-	client := createClient(conn, room, username, isHost)
+	client := createClient(conn, room, username, hostID)
+	client.release = release
+	client.releaseName = releaseName
+	client.usernames = h.Usernames
+	client.handler = h.SignalingHandler
+	if wire != nil {
+		client.compression = h.Compression
+		client.wire = wire
+	}
+	client.SessionID = sessionID
+	client.ConnID = connectionID(c)
+	client.remoteIP = c.ClientIP()
+	client.pseudonym = pseudonym
+	client.fingerprint = fingerprint
+	client.tokenKeys = keys
+	client.authMetrics = h.AuthMetrics
+	client.handshakeTimeout = h.HandshakeTimeout
+	client.resumeToken = resumeToken
+	client.resumeState = resumeState
+	client.states = h.States
+	client.startConnectionSpan(c.Request.Context())
+	if since, err := strconv.ParseUint(c.Query("since"), 10, 64); err == nil {
+		client.lastAck.Store(since)
+	}
 	room.Register <- client
-	h.startClientTasks(client)
+	if err := h.startClientTasks(client); err != nil {
+		// Without a read task nothing unregisters the client or frees its slots
+		room.Unregister <- client
+		conn.Close()
+		closeEarly()
+	}
+}
+
+// connectionID returns the ID of the upgrade request assigned by the API logger,
+// so the connection's events match the request in the access log
+func connectionID(c *gin.Context) string {
+	if id, ok := c.Request.Context().Value("request_id").(string); ok && id != "" {
+		return id
+	}
+	return uuid.New().String()
 }
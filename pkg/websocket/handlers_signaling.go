@@ -1,19 +1,8 @@
 package websocket
 
-import "encoding/json"
-
+// RegisterDefaultSignaling relays WebRTC signaling to the other members only,
+// instead of broadcasting it to the whole room with a sequence number
 func RegisterDefaultSignaling(sh *SignalingHandler) {
-
-	sh.Register("chat", func(c *Client, msg Message) {
-		var chat ChatMessage
-		if err := json.Unmarshal(msg.Data, &chat); err != nil {
-			return
-		}
-		chat.Username = c.Username
-		msg.Data, _ = json.Marshal(chat)
-		c.Room.Broadcast <- mustMarshal(msg)
-	})
-
 	// WebRTC offer
 	sh.Register("offer", func(c *Client, msg Message) {
 		c.Room.sendExcept(c, mustMarshal(msg))
@@ -0,0 +1,213 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxRaisedHands bounds the raised-hand queue of a room
+const MaxRaisedHands = 500
+
+// ErrCodeHandNotRaised is sent to a host calling on a member whose hand is not raised
+const ErrCodeHandNotRaised = "hand_not_raised"
+
+// ErrHandNotRaised is returned when calling on a member whose hand is not raised
+var ErrHandNotRaised = errors.New("hand is not raised")
+
+// RaisedHand is an entry of the raised-hand queue. Hands belong to members, not
+// usernames, so members sharing a name, such as the default one, queue separately.
+type RaisedHand struct {
+	RaisedAt time.Time `json:"raised_at" example:"2024-01-01T12:00:00Z"`
+	ID       string    `json:"id" example:"6f1c7a52-3c1e-4f0b-9a55-0b8f5a5e5c1e"`
+	Username string    `json:"username" example:"JohnDoe"`
+	member   string    // authorID of the member who raised it
+}
+
+// HandMessage Payload of lower_hand and call_on. Members lower their own hand with
+// an empty payload; hosts may pick any hand by its id or, less precisely, by the
+// username of the member who raised it, and call_on without either calls on the
+// first hand in the queue.
+type HandMessage struct {
+	ID       string `json:"id,omitempty" example:"6f1c7a52-3c1e-4f0b-9a55-0b8f5a5e5c1e"`
+	Username string `json:"username,omitempty" example:"JohnDoe"`
+}
+
+// HandQueueNotification Sent to clients as hand_queue when the raised-hand queue
+// changes, and to joining clients while hands are raised. Queue is in raising order.
+type HandQueueNotification struct {
+	Queue []RaisedHand `json:"queue"`
+}
+
+// CalledOnNotification Sent to clients as called_on when a host gives the floor to a member
+type CalledOnNotification struct {
+	ID       string `json:"id" example:"6f1c7a52-3c1e-4f0b-9a55-0b8f5a5e5c1e"`
+	Username string `json:"username" example:"JohnDoe"`
+	By       string `json:"by" example:"HostUser"`
+}
+
+// RaiseHand appends the hand of client to the raised-hand queue unless it is
+// already in it
+func (r *Room) RaiseHand(client *Client) {
+	member := client.authorID()
+	r.mu.Lock()
+	if slices.ContainsFunc(r.hands, func(h RaisedHand) bool { return h.member == member }) ||
+		len(r.hands) >= MaxRaisedHands {
+		r.mu.Unlock()
+		return
+	}
+	r.hands = append(r.hands, RaisedHand{
+		ID:       uuid.New().String(),
+		Username: client.Username,
+		RaisedAt: time.Now(),
+		member:   member,
+	})
+	r.mu.Unlock()
+	r.broadcastHandQueue()
+}
+
+// LowerHand removes the hand with id from the raised-hand queue and reports whether it was in it
+func (r *Room) LowerHand(id string) bool {
+	return r.lowerHand(func(h RaisedHand) bool { return h.ID == id })
+}
+
+// lowerHand removes the first hand selected by match from the queue
+func (r *Room) lowerHand(match func(RaisedHand) bool) bool {
+	r.mu.Lock()
+	i := slices.IndexFunc(r.hands, match)
+	if i >= 0 {
+		r.hands = slices.Delete(r.hands, i, i+1)
+	}
+	r.mu.Unlock()
+	if i < 0 {
+		return false
+	}
+	r.broadcastHandQueue()
+	return true
+}
+
+// CallOn gives the floor to the member whose hand has id, or to the first raised
+// hand if id is empty, removing the hand from the queue. It returns that hand.
+func (r *Room) CallOn(id, by string) (RaisedHand, error) {
+	if id == "" {
+		return r.callOn(func(RaisedHand) bool { return true }, by)
+	}
+	return r.callOn(func(h RaisedHand) bool { return h.ID == id }, by)
+}
+
+// callOn gives the floor to the first hand selected by match
+func (r *Room) callOn(match func(RaisedHand) bool, by string) (RaisedHand, error) {
+	r.mu.Lock()
+	i := slices.IndexFunc(r.hands, match)
+	if i < 0 {
+		r.mu.Unlock()
+		return RaisedHand{}, ErrHandNotRaised
+	}
+	hand := r.hands[i]
+	r.hands = slices.Delete(r.hands, i, i+1)
+	r.mu.Unlock()
+
+	r.broadcastNotification("called_on", CalledOnNotification{ID: hand.ID, Username: hand.Username, By: by})
+	r.broadcastHandQueue()
+	return hand, nil
+}
+
+// ClearHands empties the raised-hand queue
+func (r *Room) ClearHands() {
+	r.mu.Lock()
+	cleared := len(r.hands) > 0
+	r.hands = nil
+	r.mu.Unlock()
+	if cleared {
+		r.broadcastHandQueue()
+	}
+}
+
+// HandQueue returns the raised hands in raising order
+func (r *Room) HandQueue() []RaisedHand {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.hands)
+}
+
+// broadcastHandQueue announces the current raised-hand queue
+func (r *Room) broadcastHandQueue() {
+	r.broadcastNotification("hand_queue", HandQueueNotification{Queue: r.HandQueue()})
+}
+
+// sendHandQueue tells a joining client which hands are raised
+func (c *Client) sendHandQueue() {
+	if queue := c.Room.HandQueue(); len(queue) > 0 {
+		c.trySend(mustMarshal(Message{Type: "hand_queue", Data: mustMarshal(HandQueueNotification{Queue: queue})}))
+	}
+}
+
+// handLeft lowers the hand of a member that left, unless it is still connected elsewhere
+func (r *Room) handLeft(member string) {
+	r.mu.RLock()
+	for client := range r.Clients {
+		if client.authorID() == member {
+			r.mu.RUnlock()
+			return
+		}
+	}
+	r.mu.RUnlock()
+	r.lowerHand(func(h RaisedHand) bool { return h.member == member })
+}
+
+// moveHand hands the queue entry of a member resuming under a new connection
+// over to it. Caller must hold r.mu.
+func (r *Room) moveHand(from, to string) {
+	if i := slices.IndexFunc(r.hands, func(h RaisedHand) bool { return h.member == from }); i >= 0 {
+		r.hands[i].member = to
+	}
+}
+
+// renameHand keeps the place in the queue of a member that changed its username
+func (r *Room) renameHand(member, to string) {
+	r.mu.Lock()
+	i := slices.IndexFunc(r.hands, func(h RaisedHand) bool { return h.member == member })
+	if i >= 0 {
+		r.hands[i].Username = to
+	}
+	r.mu.Unlock()
+	if i >= 0 {
+		r.broadcastHandQueue()
+	}
+}
+
+// handleLowerHandMessage lowers the sender's hand, or the named hand for hosts
+func (c *Client) handleLowerHandMessage(message Message) {
+	var hand HandMessage
+	_ = json.Unmarshal(message.Data, &hand)
+	if !c.IsHost() || hand.ID == "" && hand.Username == "" {
+		member := c.authorID()
+		c.Room.lowerHand(func(h RaisedHand) bool { return h.member == member })
+		return
+	}
+	c.Room.lowerHand(hand.matches)
+}
+
+// handleCallOnMessage lets a host call on the named hand or the first raised hand
+func (c *Client) handleCallOnMessage(message Message) {
+	var hand HandMessage
+	_ = json.Unmarshal(message.Data, &hand)
+	if _, err := c.Room.callOn(hand.matches, c.Username); err != nil {
+		c.sendError(ErrCodeHandNotRaised, err.Error())
+	}
+}
+
+// matches reports whether the payload picks h: by id, by username, or the first
+// hand if it names neither
+func (m HandMessage) matches(h RaisedHand) bool {
+	switch {
+	case m.ID != "":
+		return h.ID == m.ID
+	case m.Username != "":
+		return h.Username == m.Username
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package websocket
+
+import (
+	"errors"
+	"log"
+	"net"
+	"strconv"
+	"time"
+)
+
+// DefaultHandshakeTimeout is how long an upgraded connection may stay silent
+// before its first message or pong
+const DefaultHandshakeTimeout = 10 * time.Second
+
+// Reasons reported to MetricsNotifier.ConnectionReaped
+const (
+	ReapHandshakeTimeout = "handshake_timeout" // upgraded but never sent a message or answered a ping
+	ReapReadTimeout      = "read_timeout"      // stopped sending and answering pings, e.g. a half-open TCP connection
+)
+
+// initialReadDeadline returns the deadline for the first frame of the connection
+func (c *Client) initialReadDeadline(now time.Time) time.Time {
+	if c.handshakeTimeout > 0 {
+		return now.Add(c.handshakeTimeout)
+	}
+	return now.Add(readDeadline)
+}
+
+// reportReaped logs and counts a connection dropped because a read deadline expired
+// and reports whether it was. Other read errors are ordinary disconnects and are not reported.
+func (c *Client) reportReaped(err error, handshakeDone bool) bool {
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		return false
+	}
+	reason := ReapReadTimeout
+	if !handshakeDone {
+		reason = ReapHandshakeTimeout
+	}
+	log.Printf("Reaping client %s (conn %s) in room %d: %s", c.Username, c.ConnID, c.Room.ID, reason)
+	if c.Room.Metrics != nil {
+		c.Room.Metrics.ConnectionReaped(strconv.Itoa(int(c.Room.ID)), reason)
+	}
+	return true
+}
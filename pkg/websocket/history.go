@@ -0,0 +1,154 @@
+package websocket
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// History limits
+const (
+	DefaultHistoryLimit = 1000  // chat messages a room keeps by default
+	MaxHistoryLimit     = 10000 // upper bound for a configured history size
+)
+
+// StoredMessage is a chat message kept in room history
+type StoredMessage struct {
+	SentAt   time.Time `json:"sent_at" example:"2024-01-01T12:00:00Z"`
+	EditedAt time.Time `json:"edited_at,omitzero" example:"2024-01-01T12:05:00Z"`
+	Username string    `json:"username" example:"JohnDoe"`
+	Text     string    `json:"text" example:"Hello, world!"`
+	ID       uint64    `json:"id" example:"42"`
+	Imported bool      `json:"imported,omitempty" example:"false"` // added through an import, the username is not verified
+	author   string    // identity allowed to change the message, empty for bot messages
+}
+
+// messageHistory keeps the most recent chat messages of a room in memory
+type messageHistory struct {
+	messages []StoredMessage
+	limit    int
+	nextID   uint64
+	mu       sync.RWMutex
+}
+
+// WithHistoryLimit sets how many chat messages the room keeps. Zero disables history.
+func WithHistoryLimit(limit int) RoomOption {
+	return func(r *Room) {
+		r.history.limit = max(limit, 0)
+	}
+}
+
+// append stores a message, dropping the oldest ones over the limit or older than retention
+func (h *messageHistory) append(username, author, text string, now time.Time, retention time.Duration) StoredMessage {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	msg := StoredMessage{ID: h.nextID, Username: username, Text: text, SentAt: now, author: author}
+	if h.limit == 0 {
+		return msg
+	}
+
+	h.messages = append(h.messages, msg)
+	h.trim(now, retention)
+	return msg
+}
+
+// trim drops the oldest messages over the limit or older than retention and returns
+// how many messages and approximate bytes were released. Caller must hold h.mu.
+func (h *messageHistory) trim(now time.Time, retention time.Duration) (int, int) {
+	drop := max(len(h.messages)-h.limit, 0)
+	if retention > 0 {
+		cutoff := now.Add(-retention)
+		for drop < len(h.messages) && h.messages[drop].SentAt.Before(cutoff) {
+			drop++
+		}
+	}
+	if drop == 0 {
+		return 0, 0
+	}
+	bytes := 0
+	for _, msg := range h.messages[:drop] {
+		bytes += msg.size()
+	}
+	h.messages = append(h.messages[:0:0], h.messages[drop:]...)
+	return drop, bytes
+}
+
+// retainedFrom returns the lowest ID of the messages still kept. Messages with
+// lower IDs were trimmed; it returns 1 if history is disabled, so nothing counts
+// as trimmed. Imports merge by send time, so the first message need not have the
+// lowest ID.
+func (h *messageHistory) retainedFrom() uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.limit == 0 {
+		return 1
+	}
+	lowest := h.nextID + 1
+	for _, msg := range h.messages {
+		lowest = min(lowest, msg.ID)
+	}
+	return lowest
+}
+
+// storedMessageOverhead approximates the fixed size of a StoredMessage:
+// a time.Time, two string headers and the ID
+const storedMessageOverhead = 64
+
+// size approximates the memory held by the message
+func (m StoredMessage) size() int {
+	return storedMessageOverhead + len(m.Username) + len(m.Text)
+}
+
+// recordChat stores a chat message of author in the room history and event feed
+func (r *Room) recordChat(username, author, text string) StoredMessage {
+	msg := r.history.append(username, author, text, time.Now(), r.Settings().Retention)
+	r.recordEvent(EventMessage, msg)
+	r.mirrorMessage(msg)
+	return msg
+}
+
+// History returns stored messages sent within [from, to], oldest first. Zero bounds are open.
+func (r *Room) History(from, to time.Time) []StoredMessage {
+	return r.SearchMessages("", from, to)
+}
+
+// SearchMessages returns stored messages within [from, to] whose text contains
+// every whitespace-separated term of query, case-insensitively. Zero bounds are open.
+func (r *Room) SearchMessages(query string, from, to time.Time) []StoredMessage {
+	terms := strings.Fields(strings.ToLower(query))
+	cutoff := time.Time{}
+	if retention := r.Settings().Retention; retention > 0 {
+		cutoff = time.Now().Add(-retention)
+	}
+
+	r.history.mu.RLock()
+	defer r.history.mu.RUnlock()
+
+	results := make([]StoredMessage, 0)
+	for _, msg := range r.history.messages {
+		if msg.SentAt.Before(cutoff) ||
+			(!from.IsZero() && msg.SentAt.Before(from)) ||
+			(!to.IsZero() && msg.SentAt.After(to)) {
+			continue
+		}
+		if matchesTerms(msg.Text, terms) {
+			results = append(results, msg)
+		}
+	}
+	return results
+}
+
+func matchesTerms(text string, terms []string) bool {
+	if len(terms) == 0 {
+		return true
+	}
+	text = strings.ToLower(text)
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
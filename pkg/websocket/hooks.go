@@ -0,0 +1,178 @@
+package websocket
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxRoomHooks is the maximum number of bot hooks per room
+const MaxRoomHooks = 10
+
+var (
+	ErrTooManyHooks = errors.New("room has too many hooks")
+	ErrHookNotFound = errors.New("hook not found")
+	ErrRoomStopped  = errors.New("room is closed")
+)
+
+// BotHook forwards chat messages starting with Prefix to an external HTTP endpoint
+type BotHook struct {
+	CreatedAt time.Time `json:"created_at" example:"2024-01-01T12:00:00Z"`
+	ID        string    `json:"id" example:"3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"`
+	Prefix    string    `json:"prefix" example:"!weather"`
+	URL       string    `json:"url" example:"https://bots.example.com/weather"`
+	BotName   string    `json:"bot_name" example:"WeatherBot"`
+	token     string
+}
+
+// BotHookEvent is delivered to a hook when a chat message matches its prefix
+type BotHookEvent struct {
+	SentAt   time.Time `json:"sent_at" example:"2024-01-01T12:00:00Z"`
+	HookID   string    `json:"hook_id" example:"3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"`
+	Username string    `json:"username" example:"JohnDoe"`
+	Text     string    `json:"text" example:"!weather Berlin"`
+	Args     string    `json:"args" example:"Berlin"`
+	RoomID   ID        `json:"room_id" example:"123456"`
+}
+
+// HookDispatcher delivers hook events, typically over HTTP. It must not block.
+type HookDispatcher func(hook BotHook, event BotHookEvent)
+
+// WithHookDispatcher sets how bot hook events leave the room
+func WithHookDispatcher(dispatch HookDispatcher) RoomOption {
+	return func(r *Room) {
+		r.dispatchHook = dispatch
+	}
+}
+
+// AddHook registers a bot hook and returns it with the secret token the bot uses
+// to reply. Events are only posted over https.
+func (r *Room) AddHook(prefix, rawURL, botName string) (BotHook, string, error) {
+	if u, err := url.Parse(rawURL); err != nil || u.Scheme != "https" {
+		return BotHook{}, "", ErrInsecureWebhookURL
+	}
+	tokenBytes := make([]byte, 24)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return BotHook{}, "", err
+	}
+	hook := &BotHook{
+		ID:        uuid.New().String(),
+		Prefix:    prefix,
+		URL:       rawURL,
+		BotName:   botName,
+		CreatedAt: time.Now(),
+		token:     hex.EncodeToString(tokenBytes),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.hooks) >= MaxRoomHooks {
+		return BotHook{}, "", ErrTooManyHooks
+	}
+	if r.hooks == nil {
+		r.hooks = make(map[string]*BotHook)
+	}
+	r.hooks[hook.ID] = hook
+	return *hook, hook.token, nil
+}
+
+// RemoveHook unregisters a bot hook
+func (r *Room) RemoveHook(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.hooks[id]; !ok {
+		return false
+	}
+	delete(r.hooks, id)
+	return true
+}
+
+// Hooks returns the registered bot hooks, oldest first
+func (r *Room) Hooks() []BotHook {
+	r.mu.RLock()
+	hooks := make([]BotHook, 0, len(r.hooks))
+	for _, hook := range r.hooks {
+		hooks = append(hooks, *hook)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].CreatedAt.Before(hooks[j].CreatedAt) })
+	return hooks
+}
+
+// AuthorizeHook returns the hook if token is its reply token
+func (r *Room) AuthorizeHook(id, token string) (BotHook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	hook, ok := r.hooks[id]
+	if !ok || subtle.ConstantTimeCompare([]byte(hook.token), []byte(token)) != 1 {
+		return BotHook{}, ErrHookNotFound
+	}
+	return *hook, nil
+}
+
+// PostBotMessage broadcasts a chat message on behalf of a bot. It fails with
+// ErrRoomStopped instead of waiting on a room that was stopped.
+func (r *Room) PostBotMessage(botName, text string) error {
+	select {
+	case <-r.Stop:
+		return ErrRoomStopped
+	default:
+	}
+	stored := r.recordChat(botName, "", text)
+	if !r.enqueue(context.Background(), mustMarshal(Message{Type: "chat", Data: mustMarshal(ChatMessage{
+		Text:     text,
+		Username: botName,
+		Bot:      true,
+		ID:       stored.ID,
+	})})) {
+		return ErrRoomStopped
+	}
+	return nil
+}
+
+// InjectMessage posts a bot message sent through the REST API. Unlike hook
+// replies it counts against the tenant message quota. It fails with
+// ErrRoomStopped if the room was stopped.
+func (r *Room) InjectMessage(botName, text string) error {
+	if !r.allowTenantMessage() {
+		return ErrTenantQuota
+	}
+	return r.PostBotMessage(botName, text)
+}
+
+// dispatchHooks forwards a chat message to every hook whose prefix it starts with
+func (r *Room) dispatchHooks(username, text string) {
+	r.mu.RLock()
+	dispatch := r.dispatchHook
+	var matched []BotHook
+	for _, hook := range r.hooks {
+		if strings.HasPrefix(text, hook.Prefix) {
+			matched = append(matched, *hook)
+		}
+	}
+	r.mu.RUnlock()
+	if dispatch == nil {
+		return
+	}
+
+	now := time.Now()
+	for _, hook := range matched {
+		dispatch(hook, BotHookEvent{
+			HookID:   hook.ID,
+			RoomID:   r.ID,
+			Username: username,
+			Text:     text,
+			Args:     strings.TrimSpace(strings.TrimPrefix(text, hook.Prefix)),
+			SentAt:   now,
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+// HostTokenTTL is how long a host token is valid after it is issued
+const HostTokenTTL = 24 * time.Hour
+
+// Error codes sent in ErrorNotification
+const (
+	ErrCodePromoteFailed = "promote_failed"
+	ErrCodeKickDenied    = "kick_denied"
+)
+
+var (
+	ErrUserNotInRoom = errors.New("user is not connected to the room")
+	ErrAlreadyHost   = errors.New("user is already a host")
+	ErrNotHost       = errors.New("host privileges were revoked")
+)
+
+// PromoteMessage Sent by a host to share host privileges with another member
+type PromoteMessage struct {
+	Username string `json:"username" example:"JohnDoe"`
+}
+
+// HostNotification Sent to clients when a member becomes a host
+type HostNotification struct {
+	Username    string `json:"username" example:"JohnDoe"`
+	By          string `json:"by,omitempty" example:"HostUser"`
+	Transferred bool   `json:"transferred" example:"false"` // the previous host gave up its privileges
+}
+
+// NewHostClaims returns the claims of a host token for hostID in the room, valid for HostTokenTTL from now
+func NewHostClaims(roomID ID, hostID string, now time.Time) jwt.MapClaims {
+	return jwt.MapClaims{
+		"room_id": roomID,
+		"host_id": hostID,
+		"host":    true,
+		"exp":     now.Add(HostTokenTTL).Unix(),
+	}
+}
+
+// GetHostID returns the ID of the room's first host, usually its creator
+func (r *Room) GetHostID() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.hostIDs) == 0 {
+		return ""
+	}
+	return r.hostIDs[0]
+}
+
+// isCreator reports whether the client holds the oldest host ID of the room: the
+// creator's, or the oldest remaining one once the creator handed its privileges over
+func (c *Client) isCreator() bool {
+	c.Room.mu.RLock()
+	defer c.Room.mu.RUnlock()
+	return c.hostID != "" && len(c.Room.hostIDs) > 0 && c.Room.hostIDs[0] == c.hostID
+}
+
+// HostIDs returns the IDs of all hosts of the room
+func (r *Room) HostIDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.hostIDs)
+}
+
+// IsHostID reports whether hostID currently holds host privileges in the room
+func (r *Room) IsHostID(hostID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return hostID != "" && slices.Contains(r.hostIDs, hostID)
+}
+
+// Promote makes the connected member username a co-host next to the existing hosts
+func (r *Room) Promote(username, by string) error {
+	r.mu.Lock()
+	hostID, granted, err := r.grantHost(username)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	r.saveModeration()
+	r.notifyHostChange(hostID, granted, nil, HostNotification{Username: username, By: by})
+	return nil
+}
+
+// TransferHost hands the privileges of fromHostID over to the connected member
+// username. Clients that were host through fromHostID lose their privileges.
+func (r *Room) TransferHost(fromHostID, username string) error {
+	r.mu.Lock()
+	if !slices.Contains(r.hostIDs, fromHostID) {
+		r.mu.Unlock()
+		return ErrNotHost
+	}
+	hostID, granted, err := r.grantHost(username)
+	if err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	r.hostIDs = slices.DeleteFunc(r.hostIDs, func(id string) bool { return id == fromHostID })
+	var revoked []*Client
+	for client := range r.Clients {
+		if client.hostID == fromHostID {
+			client.hostID = ""
+			client.isHost.Store(false)
+			revoked = append(revoked, client)
+		}
+	}
+	r.mu.Unlock()
+
+	r.saveModeration()
+	r.notifyHostChange(hostID, granted, revoked, HostNotification{Username: username, Transferred: true})
+	return nil
+}
+
+// grantHost gives host privileges under a new host ID to the clients of username.
+// Caller must hold r.mu.
+func (r *Room) grantHost(username string) (string, []*Client, error) {
+	var targets []*Client
+	for client := range r.Clients {
+		if client.Username != username {
+			continue
+		}
+		if client.IsHost() {
+			return "", nil, ErrAlreadyHost
+		}
+		targets = append(targets, client)
+	}
+	if len(targets) == 0 {
+		return "", nil, ErrUserNotInRoom
+	}
+
+	hostID := uuid.New().String()
+	r.hostIDs = append(r.hostIDs, hostID)
+	for _, client := range targets {
+		client.hostID = hostID
+		client.isHost.Store(true)
+	}
+	r.touch()
+	return hostID, targets, nil
+}
+
+// notifyHostChange sends new hosts a token for hostID, tells revoked clients they
+// are no longer host and announces the change to the room
+func (r *Room) notifyHostChange(hostID string, granted, revoked []*Client, notification HostNotification) {
+	for _, client := range granted {
+		result := AuthResult{IsHost: true}
+		if client.tokenKeys != nil {
+			result.HostToken, _ = client.tokenKeys.Sign(NewHostClaims(r.ID, hostID, time.Now()))
+		}
+		client.trySend(mustMarshal(Message{Type: "auth", Data: mustMarshal(result)}))
+	}
+	for _, client := range revoked {
+		client.trySend(mustMarshal(Message{Type: "auth", Data: mustMarshal(AuthResult{IsHost: false})}))
+	}
+	r.broadcastNotification("host", notification)
+}
+
+// handlePromoteMessage lets a host share its privileges with another member
+func (c *Client) handlePromoteMessage(message Message) {
+	var promote PromoteMessage
+	if err := json.Unmarshal(message.Data, &promote); err != nil || promote.Username == "" {
+		c.sendError(ErrCodePromoteFailed, "username is required")
+		return
+	}
+	if err := c.Room.Promote(promote.Username, c.Username); err != nil {
+		c.sendError(ErrCodePromoteFailed, err.Error())
+	}
+}
@@ -1,14 +1,34 @@
 package websocket
 
-import "sync"
+import (
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
 
 type Hub struct {
-	Rooms *sync.Map // Rooms map[ID]*Room
+	Rooms  *sync.Map  // Rooms map[ID]*Room
+	Broker Broker     // optional, nil delivers broadcasts in-process
+	Events *EventBus  // optional, receives room lifecycle and membership events
+	Naming WireNaming // field naming of room notifications, compat if empty
+
+	Branding *Branding // optional, user-visible names, the built-in ones if nil
+
+	Occupancy  OccupancyStore  // optional, keeps occupancy samples of rooms
+	Moderation ModerationStore // optional, persists bans, mutes and hosts of rooms
+	Uploads    UploadStorage   // optional, keeps files uploaded to rooms
+
+	Connections *ConnectionRegistry // connections of all rooms of the hub
+
+	breakouts breakoutLinks
+	bans      hubBans // bans that apply to every room, see Hub.Ban
 }
 
 func NewHub() *Hub {
 	return &Hub{
-		Rooms: &sync.Map{},
+		Rooms:       &sync.Map{},
+		Connections: NewConnectionRegistry(),
 	}
 }
 
@@ -21,16 +41,65 @@ func (h *Hub) GetRoom(id ID) (*Room, bool) {
 }
 
 func (h *Hub) CreateRoom(id ID, metrics MetricsNotifier, opts ...RoomOption) (*Room, bool) {
+	if h.Broker != nil {
+		opts = append([]RoomOption{WithBroker(h.Broker)}, opts...)
+	}
+	if h.Events != nil {
+		opts = append([]RoomOption{WithEventBus(h.Events)}, opts...)
+	}
+	if h.Naming != "" {
+		opts = append([]RoomOption{WithWireNaming(h.Naming)}, opts...)
+	}
+	if h.Branding != nil {
+		opts = append([]RoomOption{WithBranding(h.Branding)}, opts...)
+	}
+	if h.Moderation != nil {
+		opts = append([]RoomOption{WithModerationStore(h.Moderation)}, opts...)
+	}
+	if h.Uploads != nil {
+		opts = append([]RoomOption{WithUploadStorage(h.Uploads)}, opts...)
+	}
+	if h.Connections != nil {
+		opts = append([]RoomOption{WithConnectionRegistry(h.Connections)}, opts...)
+	}
 	room := NewRoom(id, metrics, opts...)
+	// Subscribed before the room is stored, so no join sees the broker dropped
+	if err := room.subscribe(); err != nil {
+		log.Printf("Broker subscribe failed for room %d, delivering locally: %v", id, err)
+		room.broker = nil
+	}
 	_, loaded := h.Rooms.LoadOrStore(id, room)
 	if loaded {
+		if room.unsubscribe != nil {
+			room.unsubscribe()
+		}
 		return nil, false
 	}
+	// A restored room brings its state along, any other one resumes the stored state
+	if room.restored {
+		room.saveModeration()
+	} else {
+		room.loadModeration()
+	}
 	go room.Run()
+	if metrics != nil {
+		metrics.RoomCreated(strconv.Itoa(int(id)))
+	}
+	if h.Occupancy != nil {
+		h.Occupancy.Record(id, OccupancySample{At: time.Now(), Clients: 0})
+	}
+	room.emit(ServerEventRoomCreated, "", "")
 	return room, true
 }
 
+// DeleteRoom removes the room and stops it, disconnecting its clients
 func (h *Hub) DeleteRoom(id ID) bool {
-	_, existed := h.Rooms.LoadAndDelete(id)
+	room, existed := h.Rooms.LoadAndDelete(id)
+	if existed {
+		room.(*Room).StopRoom()
+		room.(*Room).deleteModeration()
+		h.deleteUploads(id)
+		h.roomRemoved(id)
+	}
 	return existed
 }
@@ -0,0 +1,110 @@
+package websocket
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxHubBans limits the bans that apply to every room of a hub
+const MaxHubBans = 10000
+
+var ErrTooManyHubBans = errors.New("server has too many bans")
+
+// hubBans keeps usernames and IP addresses out of every room of a hub, hosts
+// included. Usernames are compared case-insensitively, as connection filters do.
+type hubBans struct {
+	bans map[string]*Ban
+	mu   sync.Mutex
+}
+
+func hubBanKey(username, ip string) string {
+	return banKey(strings.ToLower(username), ip)
+}
+
+// Ban bans username or ip, whichever is set, from every room for duration
+// (0 bans permanently) and closes the matching connections. Banning the same
+// target again replaces the previous ban.
+func (h *Hub) Ban(username, ip string, duration time.Duration, bannedBy string) (Ban, error) {
+	if (username == "") == (ip == "") {
+		return Ban{}, ErrBanTargetRequired
+	}
+	now := time.Now()
+	ban := Ban{Username: username, IP: ip, BannedBy: bannedBy, CreatedAt: now}
+	if duration > 0 {
+		expiresAt := now.Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	h.bans.mu.Lock()
+	h.bans.prune(now)
+	key := hubBanKey(username, ip)
+	if _, exists := h.bans.bans[key]; !exists && len(h.bans.bans) >= MaxHubBans {
+		h.bans.mu.Unlock()
+		return Ban{}, ErrTooManyHubBans
+	}
+	if h.bans.bans == nil {
+		h.bans.bans = make(map[string]*Ban)
+	}
+	h.bans.bans[key] = &ban
+	h.bans.mu.Unlock()
+
+	if h.Connections != nil {
+		h.Connections.DisconnectMatching(ConnectionFilter{Username: username, IP: ip})
+	}
+	return ban, nil
+}
+
+// Unban lifts the hub ban on username or ip and reports whether one was in place
+func (h *Hub) Unban(username, ip string) bool {
+	if (username == "") == (ip == "") {
+		return false
+	}
+	h.bans.mu.Lock()
+	defer h.bans.mu.Unlock()
+	h.bans.prune(time.Now())
+	key := hubBanKey(username, ip)
+	_, ok := h.bans.bans[key]
+	delete(h.bans.bans, key)
+	return ok
+}
+
+// Bans returns the hub bans in effect, oldest first
+func (h *Hub) Bans() []Ban {
+	h.bans.mu.Lock()
+	h.bans.prune(time.Now())
+	bans := make([]Ban, 0, len(h.bans.bans))
+	for _, ban := range h.bans.bans {
+		bans = append(bans, *ban)
+	}
+	h.bans.mu.Unlock()
+
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].CreatedAt.Before(bans[j].CreatedAt)
+	})
+	return bans
+}
+
+// IsBanned returns the hub ban that keeps username or ip out of every room, if any
+func (h *Hub) IsBanned(username, ip string) (Ban, bool) {
+	now := time.Now()
+	h.bans.mu.Lock()
+	defer h.bans.mu.Unlock()
+	for _, key := range []string{hubBanKey(username, ""), hubBanKey("", ip)} {
+		if ban, ok := h.bans.bans[key]; ok && !ban.expired(now) {
+			return *ban, true
+		}
+	}
+	return Ban{}, false
+}
+
+// prune drops expired bans. Caller must hold b.mu.
+func (b *hubBans) prune(now time.Time) {
+	for key, ban := range b.bans {
+		if ban.expired(now) {
+			delete(b.bans, key)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Message import limits
+const (
+	MaxImportBatch  = 1000 // messages accepted by one import
+	ImportRateLimit = 5000 // messages a room imports per importWindow
+	importWindow    = time.Minute
+	// importClockSkew tolerates senders whose clock runs slightly ahead of the server
+	importClockSkew = time.Minute
+)
+
+var (
+	// ErrInvalidImport is returned for messages that do not pass ValidateImport
+	ErrInvalidImport = errors.New("invalid imported messages")
+	// ErrImportRateLimited is returned when an import exceeds the import rate of the room
+	ErrImportRateLimited = errors.New("message import rate exceeded")
+)
+
+// ImportedMessage is a chat message from another chat system to add to room history
+type ImportedMessage struct {
+	SentAt   time.Time `json:"sent_at" example:"2023-06-01T09:30:00Z"`
+	Username string    `json:"username" example:"JohnDoe"`
+	Text     string    `json:"text" example:"Hello from the old chat!"`
+}
+
+// ImportResult reports the outcome of a message import
+type ImportResult struct {
+	Imported int    `json:"imported" example:"998"`          // messages added to history
+	Dropped  int    `json:"dropped" example:"2"`             // messages over the history limit or past retention
+	FirstID  uint64 `json:"first_id,omitempty" example:"43"` // ID of the oldest imported message
+	LastID   uint64 `json:"last_id,omitempty" example:"1042"`
+}
+
+// ValidateImport checks messages against the import limits at now
+func ValidateImport(messages []ImportedMessage, now time.Time) []ValidationError {
+	if len(messages) == 0 {
+		return []ValidationError{{Field: "messages", Message: "at least one message is required"}}
+	}
+	if len(messages) > MaxImportBatch {
+		return []ValidationError{{Field: "messages", Message: fmt.Sprintf("at most %d messages are imported at once", MaxImportBatch)}}
+	}
+	var errs []ValidationError
+	for i, msg := range messages {
+		field := func(name string) string { return fmt.Sprintf("messages[%d].%s", i, name) }
+		if err := validateUsername(msg.Username); err != nil {
+			errs = append(errs, ValidationError{Field: field("username"), Message: err.Error()})
+		}
+		if strings.TrimSpace(msg.Text) == "" || len(msg.Text) > MaxTextLength {
+			errs = append(errs, ValidationError{Field: field("text"), Message: fmt.Sprintf("text must be 1-%d characters", MaxTextLength)})
+		}
+		if msg.SentAt.IsZero() {
+			errs = append(errs, ValidationError{Field: field("sent_at"), Message: "sent_at is required"})
+		} else if msg.SentAt.After(now.Add(importClockSkew)) {
+			errs = append(errs, ValidationError{Field: field("sent_at"), Message: "sent_at must not be in the future"})
+		}
+	}
+	return errs
+}
+
+// ImportMessages adds messages to the room history without broadcasting them.
+// They get new IDs in the order they were sent and are merged with the stored
+// messages by sending time; the history limit and retention then apply as usual.
+// Their usernames are taken as given, so they are stored marked as imported.
+// Imports share a budget of ImportRateLimit messages per minute per room; over it
+// ErrImportRateLimited is returned with how long to wait.
+func (r *Room) ImportMessages(messages []ImportedMessage) (ImportResult, time.Duration, error) {
+	now := r.now()
+	if len(ValidateImport(messages, now)) > 0 {
+		return ImportResult{}, 0, ErrInvalidImport
+	}
+
+	r.mu.Lock()
+	if r.imports == nil {
+		r.imports = newTokenBucket(ImportRateLimit, importWindow)
+		r.imports.last = now
+	}
+	limiter := r.imports
+	r.mu.Unlock()
+	if retryAfter, ok := limiter.takeN(now, len(messages)); !ok {
+		return ImportResult{}, retryAfter, ErrImportRateLimited
+	}
+
+	imported := slices.Clone(messages)
+	slices.SortStableFunc(imported, func(a, b ImportedMessage) int { return a.SentAt.Compare(b.SentAt) })
+	retention := r.Settings().Retention
+
+	r.history.mu.Lock()
+	defer r.history.mu.Unlock()
+
+	result := ImportResult{FirstID: r.history.nextID + 1}
+	stored := make([]StoredMessage, 0, len(imported))
+	for _, msg := range imported {
+		r.history.nextID++
+		stored = append(stored, StoredMessage{
+			ID:       r.history.nextID,
+			Username: msg.Username,
+			Text:     msg.Text,
+			SentAt:   msg.SentAt.UTC(),
+			Imported: true,
+		})
+	}
+	result.LastID = r.history.nextID
+	if r.history.limit == 0 {
+		result.Dropped = len(stored)
+		return result, 0, nil
+	}
+
+	merged := make([]StoredMessage, 0, len(r.history.messages)+len(stored))
+	i, j := 0, 0
+	for i < len(r.history.messages) || j < len(stored) {
+		if j == len(stored) || i < len(r.history.messages) && !stored[j].SentAt.Before(r.history.messages[i].SentAt) {
+			merged = append(merged, r.history.messages[i])
+			i++
+		} else {
+			merged = append(merged, stored[j])
+			j++
+		}
+	}
+	r.history.messages = merged
+	r.history.trim(now, retention)
+	for _, msg := range r.history.messages {
+		if msg.ID >= result.FirstID {
+			result.Imported++
+		}
+	}
+	result.Dropped = len(stored) - result.Imported
+	return result, 0, nil
+}
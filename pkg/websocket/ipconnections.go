@@ -0,0 +1,60 @@
+package websocket
+
+import "sync"
+
+// IPConnectionLimiter caps the concurrent WebSocket connections of each client IP,
+// so a single client cannot exhaust the task pool and memory by opening sockets
+type IPConnectionLimiter struct {
+	active map[string]int
+	max    int
+	mu     sync.Mutex
+}
+
+// NewIPConnectionLimiter creates a limiter allowing up to max connections per IP.
+// It returns nil, which allows any number, if max is zero.
+func NewIPConnectionLimiter(max int) *IPConnectionLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &IPConnectionLimiter{active: make(map[string]int), max: max}
+}
+
+// Acquire reserves a connection of ip. When ok is true the caller must call
+// release exactly once after the connection is closed.
+func (l *IPConnectionLimiter) Acquire(ip string) (release func(), ok bool) {
+	if l == nil {
+		return func() {}, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active[ip] >= l.max {
+		return nil, false
+	}
+	l.active[ip]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { l.release(ip) })
+	}, true
+}
+
+func (l *IPConnectionLimiter) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active[ip] <= 1 {
+		delete(l.active, ip)
+	} else {
+		l.active[ip]--
+	}
+}
+
+// Active returns the number of open connections of ip
+func (l *IPConnectionLimiter) Active(ip string) int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active[ip]
+}
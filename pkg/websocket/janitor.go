@@ -0,0 +1,82 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Janitor sweep interval bounds
+const (
+	minJanitorInterval = time.Second
+	maxJanitorInterval = time.Minute
+)
+
+// JanitorMetrics receives room lifecycle metrics from the hub janitor
+type JanitorMetrics interface {
+	ActiveRooms(count int)
+	RoomReaped(roomID string)
+}
+
+// touch records activity in the room. Caller must hold r.mu.
+func (r *Room) touch() {
+	r.lastActivity = time.Now()
+}
+
+// idleFor reports how long the room has had no clients and no activity.
+// It returns false while clients are connected or may still resume.
+func (r *Room) idleFor(now time.Time) (time.Duration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.Clients) > 0 || len(r.pending) > 0 {
+		return 0, false
+	}
+	return now.Sub(r.lastActivity), true
+}
+
+// RunJanitor periodically stops and deletes rooms that have been empty for idleTTL,
+// until ctx is done. A non-positive idleTTL only reports the active room count.
+func (h *Hub) RunJanitor(ctx context.Context, idleTTL time.Duration, metrics JanitorMetrics) {
+	interval := maxJanitorInterval
+	if idleTTL > 0 {
+		interval = min(max(idleTTL/4, minJanitorInterval), maxJanitorInterval)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			h.reapIdleRooms(now, idleTTL, metrics)
+		}
+	}
+}
+
+// reapIdleRooms removes rooms idle for at least idleTTL and reports the remaining room count
+func (h *Hub) reapIdleRooms(now time.Time, idleTTL time.Duration, metrics JanitorMetrics) {
+	active := 0
+	h.Rooms.Range(func(key, value any) bool {
+		room := value.(*Room)
+		if idle, empty := room.idleFor(now); idleTTL > 0 && empty && idle >= idleTTL {
+			if h.Rooms.CompareAndDelete(key, room) {
+				room.StopRoom()
+				room.deleteModeration()
+				h.deleteUploads(room.ID)
+				h.roomRemoved(room.ID)
+				log.Printf("Janitor reaped room %d after %s idle", room.ID, idle.Truncate(time.Second))
+				if metrics != nil {
+					metrics.RoomReaped(strconv.Itoa(int(room.ID)))
+				}
+			}
+			return true
+		}
+		active++
+		return true
+	})
+	if metrics != nil {
+		metrics.ActiveRooms(active)
+	}
+}
@@ -0,0 +1,60 @@
+package websocket
+
+// ErrCodeRoomLocked is sent when a connection is refused because the room was
+// locked while it was being admitted
+const ErrCodeRoomLocked = "room_locked"
+
+// SetLocked locks or unlocks the room. A locked room turns away every new member,
+// even with the password or a join ticket, while current members keep chatting
+// and may resume their session. Hosts can always join. It reports whether the
+// lock changed.
+func (r *Room) SetLocked(locked bool) bool {
+	r.mu.Lock()
+	if r.locked == locked {
+		r.mu.Unlock()
+		return false
+	}
+	r.locked = locked
+	r.mu.Unlock()
+
+	msgType := "unlock"
+	if locked {
+		msgType = "lock"
+	}
+	r.broadcastNotification(msgType, LockNotification{Locked: locked})
+	return true
+}
+
+// IsLocked reports whether the room turns away new members
+func (r *Room) IsLocked() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.locked
+}
+
+// AdmitsJoin reports whether a member may join despite the lock: always while the
+// room is unlocked, and only to resume a pending session of a current member otherwise
+func (r *Room) AdmitsJoin(resumeToken string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.admitsJoin(resumeToken)
+}
+
+// admitsJoin is AdmitsJoin for callers already holding r.mu
+func (r *Room) admitsJoin(resumeToken string) bool {
+	if !r.locked {
+		return true
+	}
+	_, pending := r.pending[resumeToken]
+	return pending && resumeToken != ""
+}
+
+// rejectLocked tells a client whose join raced the lock that it cannot join, then
+// closes its Send channel so the write goroutine flushes the error and disconnects
+func (c *Client) rejectLocked() {
+	select {
+	case c.Send <- c.errorMessage(ErrorNotification{Code: ErrCodeRoomLocked, Message: "room is locked"}):
+	default:
+	}
+	c.closeSend()
+}
@@ -0,0 +1,41 @@
+package websocket
+
+import "encoding/json"
+
+// MediaState Camera, microphone and screen-share state a member reports for call UIs
+// @Description Sent by a client as media_state when its devices change
+type MediaState struct {
+	CameraOn      bool `json:"camera_on" example:"true"`
+	MicMuted      bool `json:"mic_muted" example:"false"`
+	ScreenSharing bool `json:"screen_sharing" example:"false"`
+}
+
+// MediaStateNotification Sent to clients when a member's media state changes
+type MediaStateNotification struct {
+	Username string `json:"username" example:"JohnDoe"`
+	MediaState
+}
+
+// MediaState returns the media state the client last reported
+func (c *Client) MediaState() MediaState {
+	c.Room.mu.RLock()
+	defer c.Room.mu.RUnlock()
+	return c.media
+}
+
+// handleMediaStateMessage stores the client's media state and announces changes to the room
+func (c *Client) handleMediaStateMessage(msg Message) {
+	var state MediaState
+	if err := json.Unmarshal(msg.Data, &state); err != nil {
+		return
+	}
+
+	c.Room.mu.Lock()
+	changed := c.media != state
+	c.media = state
+	c.Room.mu.Unlock()
+
+	if changed {
+		c.Room.broadcastNotification("media_state", MediaStateNotification{Username: c.Username, MediaState: state})
+	}
+}
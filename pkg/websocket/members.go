@@ -0,0 +1,65 @@
+package websocket
+
+import (
+	"sort"
+	"time"
+)
+
+// MemberInfo describes a client connected to a room, or one whose connection
+// dropped and that may still resume it within the reconnect grace period
+type MemberInfo struct {
+	JoinedAt     time.Time  `json:"joined_at" example:"2024-01-01T12:00:00Z"`
+	Username     string     `json:"username" example:"JohnDoe"`
+	IsHost       bool       `json:"is_host" example:"false"`
+	Muted        bool       `json:"muted,omitempty" example:"false"`
+	Reconnecting bool       `json:"reconnecting,omitempty" example:"false"`
+	Media        MediaState `json:"media"`
+}
+
+// MembersMessage Sent to a joining client with everyone currently in the room and the room metadata
+type MembersMessage struct {
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Members  []MemberInfo      `json:"members"`
+}
+
+// ListClients returns the connected clients and the members in their reconnect
+// grace period, ordered by join time
+func (r *Room) ListClients() []MemberInfo {
+	r.mu.RLock()
+	members := make([]MemberInfo, 0, len(r.Clients)+len(r.pending))
+	for client := range r.Clients {
+		members = append(members, MemberInfo{
+			Username: client.Username,
+			IsHost:   client.IsHost(),
+			Muted:    client.IsMuted(),
+			JoinedAt: client.joinedAt,
+			Media:    client.media,
+		})
+	}
+	for _, pending := range r.pending {
+		members = append(members, MemberInfo{
+			Username:     pending.username,
+			IsHost:       pending.isHost,
+			Muted:        r.muted[pending.username],
+			JoinedAt:     pending.joinedAt,
+			Reconnecting: true,
+		})
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(members, func(i, j int) bool {
+		if !members[i].JoinedAt.Equal(members[j].JoinedAt) {
+			return members[i].JoinedAt.Before(members[j].JoinedAt)
+		}
+		return members[i].Username < members[j].Username
+	})
+	return members
+}
+
+// sendMembers sends the current member list to the client
+func (c *Client) sendMembers() {
+	c.trySend(mustMarshal(Message{Type: "members", Data: mustMarshal(MembersMessage{
+		Members:  c.Room.ListClients(),
+		Metadata: c.Room.Metadata(),
+	})}))
+}
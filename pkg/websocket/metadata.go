@@ -0,0 +1,70 @@
+package websocket
+
+import (
+	"errors"
+	"maps"
+)
+
+// Metadata limits
+const (
+	MaxMetadataKeys        = 20
+	MaxMetadataKeyLength   = 64
+	MaxMetadataValueLength = 512
+)
+
+var (
+	ErrMetadataFull        = errors.New("room has too many metadata keys")
+	ErrMetadataKeyInvalid  = errors.New("metadata key must be 1-64 characters")
+	ErrMetadataValueTooBig = errors.New("metadata value is too long")
+)
+
+// MetadataNotification Sent to clients when the room metadata changes
+type MetadataNotification struct {
+	Metadata map[string]string `json:"metadata"`
+}
+
+// Metadata returns a copy of the application-defined key/value metadata of the room
+func (r *Room) Metadata() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return maps.Clone(r.metadata)
+}
+
+// SetMetadata stores value under key and notifies connected clients
+func (r *Room) SetMetadata(key, value string) error {
+	if key == "" || len(key) > MaxMetadataKeyLength {
+		return ErrMetadataKeyInvalid
+	}
+	if len(value) > MaxMetadataValueLength {
+		return ErrMetadataValueTooBig
+	}
+
+	r.mu.Lock()
+	if _, exists := r.metadata[key]; !exists && len(r.metadata) >= MaxMetadataKeys {
+		r.mu.Unlock()
+		return ErrMetadataFull
+	}
+	if r.metadata == nil {
+		r.metadata = make(map[string]string)
+	}
+	r.metadata[key] = value
+	metadata := maps.Clone(r.metadata)
+	r.mu.Unlock()
+
+	r.broadcastNotification("metadata", MetadataNotification{Metadata: metadata})
+	return nil
+}
+
+// DeleteMetadata removes key and notifies connected clients. It reports whether the key existed.
+func (r *Room) DeleteMetadata(key string) bool {
+	r.mu.Lock()
+	_, exists := r.metadata[key]
+	delete(r.metadata, key)
+	metadata := maps.Clone(r.metadata)
+	r.mu.Unlock()
+
+	if exists {
+		r.broadcastNotification("metadata", MetadataNotification{Metadata: metadata})
+	}
+	return exists
+}
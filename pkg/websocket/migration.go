@@ -0,0 +1,334 @@
+package websocket
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"time"
+)
+
+// ErrCodeRoomMigrating is sent when a message arrives while the room moves to another instance
+const ErrCodeRoomMigrating = "room_migrating"
+
+// RoomSnapshot is the state a room hands to another instance when it migrates:
+// its settings, hosts and moderation state, the chat history and the recent
+// broadcasts with their sequence numbers, so reconnecting clients can resume
+// with ?since= on the new instance without gaps. Uploads are handed off when
+// their storage is shared by the instances.
+type RoomSnapshot struct {
+	ID             ID                `json:"id" example:"123456"`
+	Settings       RoomSettings      `json:"settings"`
+	HashedPassword string            `json:"hashed_password,omitempty"`
+	Instance       string            `json:"instance,omitempty"`     // random ID of the room, binds its state tokens
+	PasswordGen    uint64            `json:"password_gen,omitempty"` // password changes so far
+	HostIDs        []string          `json:"host_ids"`
+	Tenant         string            `json:"tenant,omitempty"`
+	Tags           []string          `json:"tags,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	Bans           []Ban             `json:"bans,omitempty"`
+	Muted          []string          `json:"muted,omitempty"`
+	ContentFilter  RoomContentFilter `json:"content_filter"`
+	ModRules       []ModRule         `json:"mod_rules,omitempty"`
+	Uploads        []Upload          `json:"uploads,omitempty"`
+	History        []StoredMessage   `json:"history,omitempty"`
+	HistoryLimit   int               `json:"history_limit" example:"100"`
+	LastMessageID  uint64            `json:"last_message_id" example:"42"`
+	// Authors maps history message IDs to the identity allowed to change them
+	Authors map[uint64]string `json:"authors,omitempty"`
+	// Replay holds the buffered broadcasts, oldest first, the last one with sequence LastSeq
+	Replay     []json.RawMessage `json:"replay,omitempty" swaggertype:"array,object"`
+	ReplaySize int               `json:"replay_size" example:"256"`
+	LastSeq    uint64            `json:"last_seq" example:"1024"`
+	Ordered    bool              `json:"ordered"`
+	Locked     bool              `json:"locked"` // the room turns away new members
+	// SlowConsumer is the slow-consumer policy of the room
+	SlowConsumer SlowConsumerPolicy `json:"slow_consumer,omitempty" example:"disconnect"`
+	// Webhooks are the room webhooks with the secrets their deliveries are signed with
+	Webhooks []WebhookSnapshot `json:"webhooks,omitempty"`
+	// Hands is the raised-hand queue in raising order
+	Hands []HandSnapshot `json:"hands,omitempty"`
+	// Pending lists the members that may resume on the target: those within their
+	// reconnect grace period and those connected when the room moved
+	Pending []PendingSnapshot `json:"pending,omitempty"`
+}
+
+// WebhookSnapshot is a room webhook carried by a RoomSnapshot
+type WebhookSnapshot struct {
+	RoomWebhook
+	Secret string `json:"secret"`
+}
+
+// HandSnapshot is a raised hand carried by a RoomSnapshot
+type HandSnapshot struct {
+	RaisedHand
+	Member string `json:"member"`
+}
+
+// PendingSnapshot is a member carried by a RoomSnapshot that may resume its
+// session with ResumeToken
+type PendingSnapshot struct {
+	JoinedAt    time.Time `json:"joined_at" example:"2024-01-01T12:00:00Z"`
+	ResumeToken string    `json:"resume_token"`
+	Username    string    `json:"username" example:"JohnDoe"`
+	SessionID   string    `json:"session_id,omitempty"`
+	Member      string    `json:"member"`
+	LastAck     uint64    `json:"last_ack"`
+}
+
+// MigrateNotification Sent to clients when the room moves to another instance.
+// Clients reconnect to URL with ?since= set to the last sequence they received.
+type MigrateNotification struct {
+	URL     string `json:"url" example:"https://chat-2.example.com"`
+	RoomID  ID     `json:"room_id" example:"123456"`
+	LastSeq uint64 `json:"last_seq" example:"1024"`
+}
+
+// BeginMigration suspends messaging and joins and returns a snapshot of the room.
+// Broadcasts sequenced after the snapshot are not handed off, so messages from
+// clients are rejected until the migration completes or is cancelled. It returns
+// false if the room is already migrating or stopped.
+func (r *Room) BeginMigration() (RoomSnapshot, bool) {
+	r.seqMu.Lock()
+	r.mu.Lock()
+	stopped := false
+	select {
+	case <-r.Stop:
+		stopped = true
+	default:
+	}
+	if r.migrating || stopped {
+		r.mu.Unlock()
+		r.seqMu.Unlock()
+		return RoomSnapshot{}, false
+	}
+	r.migrating = true
+	snapshot := RoomSnapshot{
+		ID:             r.ID,
+		Settings:       r.settings,
+		HashedPassword: r.HashedPassword,
+		Instance:       r.instance,
+		PasswordGen:    r.passwordGen,
+		HostIDs:        slices.Clone(r.hostIDs),
+		Tenant:         r.tenant,
+		Tags:           slices.Clone(r.tags),
+		Metadata:       maps.Clone(r.metadata),
+		ReplaySize:     len(r.replay.messages),
+		LastSeq:        r.replay.next,
+		Ordered:        r.ordered,
+		Locked:         r.locked,
+		SlowConsumer:   r.slowConsumer,
+		Uploads:        r.uploadsSnapshot(),
+	}
+	size := uint64(len(r.replay.messages))
+	for seq := r.replay.oldest(); seq <= r.replay.next; seq++ {
+		snapshot.Replay = append(snapshot.Replay, r.replay.messages[seq%size])
+	}
+	for _, hook := range r.webhooks {
+		snapshot.Webhooks = append(snapshot.Webhooks, WebhookSnapshot{RoomWebhook: hook.RoomWebhook, Secret: hook.secret})
+	}
+	for _, hand := range r.hands {
+		snapshot.Hands = append(snapshot.Hands, HandSnapshot{RaisedHand: hand, Member: hand.member})
+	}
+	for token, pending := range r.pending {
+		snapshot.Pending = append(snapshot.Pending, PendingSnapshot{
+			ResumeToken: token,
+			Username:    pending.username,
+			SessionID:   pending.sessionID,
+			Member:      pending.member,
+			JoinedAt:    pending.joinedAt,
+			LastAck:     pending.lastAck,
+		})
+	}
+	for client := range r.Clients {
+		if client.resumeToken == "" {
+			continue
+		}
+		snapshot.Pending = append(snapshot.Pending, PendingSnapshot{
+			ResumeToken: client.resumeToken,
+			Username:    client.Username,
+			SessionID:   client.SessionID,
+			Member:      client.authorID(),
+			JoinedAt:    client.joinedAt,
+			LastAck:     client.lastAck.Load(),
+		})
+	}
+	r.mu.Unlock()
+	r.seqMu.Unlock()
+
+	snapshot.Bans = r.Bans()
+	snapshot.Muted = r.moderationState().Muted
+	snapshot.ContentFilter = r.ContentFilter()
+	snapshot.ModRules = r.ModRules()
+
+	r.history.mu.RLock()
+	snapshot.History = slices.Clone(r.history.messages)
+	for _, msg := range r.history.messages {
+		if msg.author == "" {
+			continue
+		}
+		if snapshot.Authors == nil {
+			snapshot.Authors = make(map[uint64]string)
+		}
+		snapshot.Authors[msg.ID] = msg.author
+	}
+	snapshot.HistoryLimit = r.history.limit
+	snapshot.LastMessageID = r.history.nextID
+	r.history.mu.RUnlock()
+
+	// The target stores the moderation state it restores from the snapshot
+	r.deleteModeration()
+	return snapshot, true
+}
+
+// CancelMigration resumes a room whose migration failed, storing its
+// moderation state again
+func (r *Room) CancelMigration() {
+	r.mu.Lock()
+	r.migrating = false
+	r.mu.Unlock()
+	r.saveModeration()
+}
+
+// IsMigrating reports whether the room is being handed to another instance
+func (r *Room) IsMigrating() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.migrating
+}
+
+// WithSnapshot restores the state of a room migrated from another instance. It
+// must be the last option, so the snapshot overrides the defaults of this instance.
+func WithSnapshot(snapshot RoomSnapshot) RoomOption {
+	return func(r *Room) {
+		r.restored = true
+		r.settings = snapshot.Settings
+		r.HashedPassword = snapshot.HashedPassword
+		if snapshot.Instance != "" {
+			r.instance = snapshot.Instance
+		}
+		r.passwordGen = snapshot.PasswordGen
+		r.hostIDs = slices.Clone(snapshot.HostIDs)
+		r.tags = slices.Clone(snapshot.Tags)
+		r.metadata = maps.Clone(snapshot.Metadata)
+		r.ordered = r.ordered || snapshot.Ordered
+		r.locked = snapshot.Locked
+		if snapshot.SlowConsumer != "" {
+			r.slowConsumer = snapshot.SlowConsumer
+		}
+
+		now := time.Now()
+		for _, ban := range snapshot.Bans {
+			if ban.expired(now) {
+				continue
+			}
+			if r.bans == nil {
+				r.bans = make(map[string]*Ban)
+			}
+			r.bans[banKey(ban.Username, ban.IP)] = &ban
+		}
+		for _, username := range snapshot.Muted {
+			if r.muted == nil {
+				r.muted = make(map[string]bool)
+			}
+			r.muted[username] = true
+		}
+		for _, upload := range snapshot.Uploads {
+			r.addUploadLocked(upload)
+		}
+		for _, hook := range snapshot.Webhooks {
+			if r.webhooks == nil {
+				r.webhooks = make(map[string]*roomWebhook)
+			}
+			r.webhooks[hook.ID] = &roomWebhook{
+				RoomWebhook: hook.RoomWebhook,
+				secret:      hook.Secret,
+				limiter:     newTokenBucket(RoomWebhookRate, time.Second),
+			}
+		}
+		for _, hand := range snapshot.Hands {
+			hand.member = hand.Member
+			r.hands = append(r.hands, hand.RaisedHand)
+		}
+		// Members get a full grace period on this instance to reconnect
+		if r.reconnectGrace > 0 {
+			for _, pending := range snapshot.Pending {
+				token := pending.ResumeToken
+				r.pending[token] = &pendingMember{
+					username:  pending.Username,
+					sessionID: pending.SessionID,
+					member:    pending.Member,
+					joinedAt:  pending.JoinedAt,
+					lastAck:   pending.LastAck,
+					timer:     time.AfterFunc(r.reconnectGrace, func() { r.expirePending(token) }),
+				}
+			}
+		}
+		// Both were validated by the instance the room comes from
+		_ = r.SetContentFilter(snapshot.ContentFilter)
+		_ = r.SetModRules(snapshot.ModRules)
+
+		r.history.limit = max(snapshot.HistoryLimit, 0)
+		r.history.nextID = snapshot.LastMessageID
+		r.history.messages = slices.Clone(snapshot.History)
+		for i := range r.history.messages {
+			r.history.messages[i].author = snapshot.Authors[r.history.messages[i].ID]
+		}
+		r.history.trim(now, r.settings.Retention)
+
+		if snapshot.ReplaySize > 0 {
+			r.replay = newReplayBuffer(snapshot.ReplaySize)
+		}
+		r.replay.next = snapshot.LastSeq
+		size := uint64(len(r.replay.messages))
+		first := snapshot.LastSeq + 1 - uint64(len(snapshot.Replay))
+		if first > 1 {
+			r.replay.floor = first
+		}
+		for i, msg := range snapshot.Replay {
+			if seq := first + uint64(i); seq+size > snapshot.LastSeq {
+				r.replay.messages[seq%size] = msg
+			}
+		}
+	}
+}
+
+// RestoreRoom creates a room from a snapshot taken on another instance. It returns
+// false if a room with the same ID already exists.
+func (h *Hub) RestoreRoom(snapshot RoomSnapshot, metrics MetricsNotifier, opts ...RoomOption) (*Room, bool) {
+	opts = append(slices.Clone(opts), WithSnapshot(snapshot))
+	return h.CreateRoom(snapshot.ID, metrics, opts...)
+}
+
+// CompleteMigration tells the clients of a migrating room to reconnect to url,
+// closes their connections with CloseRoomMigrated and removes the room. It
+// returns false if the room does not exist or is not migrating.
+func (h *Hub) CompleteMigration(id ID, url string) bool {
+	room, ok := h.GetRoom(id)
+	if !ok || !room.IsMigrating() {
+		return false
+	}
+
+	// Not sequenced: the broadcasts of the room continue on the new instance
+	notification := mustMarshal(Message{Type: "migrate", Data: mustMarshal(MigrateNotification{
+		URL:     url,
+		RoomID:  id,
+		LastSeq: room.LastSeq(),
+	})})
+	room.mu.RLock()
+	clients := slices.Collect(maps.Keys(room.Clients))
+	room.mu.RUnlock()
+	for _, client := range clients {
+		client.trySend(notification)
+	}
+	room.drainSendQueues()
+
+	room.stop(CloseRoomMigrated)
+	if h.Rooms.CompareAndDelete(id, room) {
+		h.roomRemoved(id)
+	}
+	// Uploads in storage of this instance didn't move with the room
+	if !room.sharedUploads {
+		h.deleteUploads(id)
+	}
+	return true
+}
@@ -0,0 +1,261 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Moderation store timing
+const (
+	// moderationStoreTimeout bounds a single load or save of the moderation state
+	moderationStoreTimeout = 5 * time.Second
+	// moderationRefreshInterval is how often joins reload the state another instance may have changed
+	moderationRefreshInterval = time.Second
+)
+
+// ModerationState is the moderation state of a room that outlives its connections
+type ModerationState struct {
+	Bans    []Ban    `json:"bans,omitempty"`
+	Muted   []string `json:"muted,omitempty"` // usernames of muted members
+	HostIDs []string `json:"host_ids,omitempty"`
+}
+
+// ModerationStore persists the bans, mutes and hosts of rooms, so they survive
+// restarts and are shared by the instances hosting the same room. Implementations
+// must be safe for concurrent use.
+type ModerationStore interface {
+	// Load returns the state of room id, false if none is stored
+	Load(ctx context.Context, id ID) (ModerationState, bool, error)
+	// Save replaces the state of room id
+	Save(ctx context.Context, id ID, state ModerationState) error
+	// Delete drops the state of room id
+	Delete(ctx context.Context, id ID) error
+}
+
+// MemoryModerationStore is a ModerationStore keeping the state of rooms in memory,
+// which does not survive restarts
+type MemoryModerationStore struct {
+	rooms map[ID]ModerationState
+	mu    sync.Mutex
+}
+
+func NewMemoryModerationStore() *MemoryModerationStore {
+	return &MemoryModerationStore{rooms: make(map[ID]ModerationState)}
+}
+
+func (s *MemoryModerationStore) Load(_ context.Context, id ID) (ModerationState, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.rooms[id]
+	return state, ok, nil
+}
+
+func (s *MemoryModerationStore) Save(_ context.Context, id ID, state ModerationState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rooms[id] = state
+	return nil
+}
+
+func (s *MemoryModerationStore) Delete(_ context.Context, id ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.rooms, id)
+	return nil
+}
+
+// FileModerationStore is a ModerationStore keeping the state of every room in a
+// JSON file of its own in a directory
+type FileModerationStore struct {
+	dir string
+}
+
+// NewFileModerationStore stores the state of rooms in dir, creating it if needed
+func NewFileModerationStore(dir string) (*FileModerationStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileModerationStore{dir: dir}, nil
+}
+
+func (s *FileModerationStore) path(id ID) string {
+	return filepath.Join(s.dir, strconv.FormatUint(uint64(id), 10)+".json")
+}
+
+func (s *FileModerationStore) Load(_ context.Context, id ID) (ModerationState, bool, error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ModerationState{}, false, nil
+	}
+	if err != nil {
+		return ModerationState{}, false, err
+	}
+	var state ModerationState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return ModerationState{}, false, err
+	}
+	return state, true, nil
+}
+
+// Save writes the state to a temporary file and renames it, so a crash never
+// leaves a partial state behind
+func (s *FileModerationStore) Save(_ context.Context, id ID, state ModerationState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, "moderation-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(id))
+}
+
+func (s *FileModerationStore) Delete(_ context.Context, id ID) error {
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// WithModerationStore persists the bans, mutes and hosts of the room in store
+func WithModerationStore(store ModerationStore) RoomOption {
+	return func(r *Room) {
+		r.moderation = store
+	}
+}
+
+// moderationState returns the bans in effect, the muted members and the hosts
+func (r *Room) moderationState() ModerationState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneBans(time.Now())
+	state := ModerationState{HostIDs: slices.Clone(r.hostIDs)}
+	for _, ban := range r.bans {
+		state.Bans = append(state.Bans, *ban)
+	}
+	slices.SortFunc(state.Bans, func(a, b Ban) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	state.Muted = slices.Sorted(maps.Keys(r.muted))
+	return state
+}
+
+// saveModeration writes the moderation state to the store, if any. Failures are
+// logged: the state in memory stays authoritative for this instance.
+func (r *Room) saveModeration() {
+	if r.moderation == nil {
+		return
+	}
+	r.moderationMu.Lock()
+	defer r.moderationMu.Unlock()
+	r.saveModerationLocked()
+}
+
+// saveModerationLocked writes the moderation state. Caller must hold r.moderationMu.
+func (r *Room) saveModerationLocked() {
+	ctx, cancel := context.WithTimeout(context.Background(), moderationStoreTimeout)
+	defer cancel()
+	if err := r.moderation.Save(ctx, r.ID, r.moderationState()); err != nil {
+		log.Printf("Saving moderation state of room %d failed: %v", r.ID, err)
+	}
+	r.moderationAt = time.Now()
+}
+
+// loadModeration resumes the stored bans and mutes of a new room, if any, and
+// stores its state. Stored hosts are never taken over: they were granted by an
+// earlier room with this ID, whose host tokens must not work in this one.
+func (r *Room) loadModeration() {
+	if r.moderation == nil {
+		return
+	}
+	r.moderationMu.Lock()
+	defer r.moderationMu.Unlock()
+	r.loadModerationLocked(true)
+	r.saveModerationLocked()
+}
+
+// deleteModeration drops the stored state of a room that is gone from this
+// instance: deleted, reaped, closed or migrated away. Only rooms stopped by a
+// shutdown keep it, so their bans hold after a restart.
+func (r *Room) deleteModeration() {
+	if r.moderation == nil {
+		return
+	}
+	r.moderationMu.Lock()
+	defer r.moderationMu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), moderationStoreTimeout)
+	defer cancel()
+	if err := r.moderation.Delete(ctx, r.ID); err != nil {
+		log.Printf("Deleting moderation state of room %d failed: %v", r.ID, err)
+	}
+}
+
+// refreshModeration reloads the stored state unless it was loaded or saved within
+// moderationRefreshInterval, so joins see bans made on other instances
+func (r *Room) refreshModeration() {
+	if r.moderation == nil {
+		return
+	}
+	r.moderationMu.Lock()
+	defer r.moderationMu.Unlock()
+	if time.Since(r.moderationAt) < moderationRefreshInterval {
+		return
+	}
+	r.loadModerationLocked(false)
+}
+
+// loadModerationLocked applies the stored state, leaving the hosts alone for a
+// fresh room. Connected clients are muted and unmuted to match it; host changes
+// apply to clients when they next authenticate. Caller must hold r.moderationMu.
+func (r *Room) loadModerationLocked(fresh bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), moderationStoreTimeout)
+	defer cancel()
+	state, ok, err := r.moderation.Load(ctx, r.ID)
+	if err != nil {
+		log.Printf("Loading moderation state of room %d failed: %v", r.ID, err)
+		return
+	}
+	r.moderationAt = time.Now()
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bans = make(map[string]*Ban, len(state.Bans))
+	for _, ban := range state.Bans {
+		if !ban.expired(now) {
+			r.bans[banKey(ban.Username, ban.IP)] = &ban
+		}
+	}
+	r.muted = make(map[string]bool, len(state.Muted))
+	for _, username := range state.Muted {
+		r.muted[username] = true
+	}
+	for client := range r.Clients {
+		if !client.IsHost() {
+			client.muted.Store(r.muted[client.Username])
+		}
+	}
+	if !fresh && len(state.HostIDs) > 0 {
+		r.hostIDs = slices.Clone(state.HostIDs)
+	}
+}
@@ -0,0 +1,84 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Error codes sent in ErrorNotification
+const (
+	ErrCodeMuted      = "muted"       // a muted member's chat message was dropped
+	ErrCodeMuteFailed = "mute_failed" // a host's mute or unmute was refused
+)
+
+// ErrCannotMuteHost is returned when muting a member with host privileges
+var ErrCannotMuteHost = errors.New("host cannot be muted")
+
+// MuteMessage Payload for a host muting or unmuting a member over WebSocket
+type MuteMessage struct {
+	Username string `json:"username" example:"JohnDoe"`
+}
+
+// MuteNotification Sent to clients when a member is muted or unmuted
+type MuteNotification struct {
+	Username string `json:"username" example:"JohnDoe"`
+	By       string `json:"by,omitempty" example:"HostUser"`
+	Muted    bool   `json:"muted" example:"true"`
+}
+
+// IsMuted reports whether the client's chat messages are dropped
+func (c *Client) IsMuted() bool {
+	return c.muted.Load()
+}
+
+// SetMuted mutes or unmutes the connected member username and announces the change.
+// The mute applies to the username, so it outlasts reconnects. Hosts cannot be muted.
+func (r *Room) SetMuted(username string, muted bool, by string) error {
+	found := false
+	r.mu.Lock()
+	for client := range r.Clients {
+		if client.Username != username {
+			continue
+		}
+		if muted && client.IsHost() {
+			r.mu.Unlock()
+			return ErrCannotMuteHost
+		}
+		found = true
+	}
+	if found {
+		for client := range r.Clients {
+			if client.Username == username {
+				client.muted.Store(muted)
+			}
+		}
+		if muted {
+			if r.muted == nil {
+				r.muted = make(map[string]bool)
+			}
+			r.muted[username] = true
+		} else {
+			delete(r.muted, username)
+		}
+	}
+	r.mu.Unlock()
+
+	if !found {
+		return ErrUserNotInRoom
+	}
+	r.saveModeration()
+	r.broadcastNotification("mute", MuteNotification{Username: username, By: by, Muted: muted})
+	return nil
+}
+
+// handleMuteMessage lets a host mute or unmute a member over WebSocket
+func (c *Client) handleMuteMessage(message Message) {
+	var mute MuteMessage
+	if err := json.Unmarshal(message.Data, &mute); err != nil || mute.Username == "" {
+		c.sendError(ErrCodeMuteFailed, "username is required")
+		return
+	}
+	if err := c.Room.SetMuted(mute.Username, message.Type == "mute", c.Username); err != nil {
+		c.sendError(ErrCodeMuteFailed, err.Error())
+	}
+}
@@ -0,0 +1,84 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// WireNaming selects the spelling of fields that predate the snake_case
+// convention, so frontends can migrate before the old names are dropped
+type WireNaming string
+
+const (
+	NamingLegacy WireNaming = "legacy" // only the old camelCase names
+	NamingCompat WireNaming = "compat" // old and snake_case names side by side
+	NamingSnake  WireNaming = "snake"  // only snake_case names
+)
+
+// ParseWireNaming returns the naming mode called s
+func ParseWireNaming(s string) (WireNaming, error) {
+	switch naming := WireNaming(strings.ToLower(strings.TrimSpace(s))); naming {
+	case NamingLegacy, NamingCompat, NamingSnake:
+		return naming, nil
+	}
+	return "", errors.New("wire naming must be legacy, compat or snake")
+}
+
+// WithWireNaming sets the field naming of the room's notifications
+func WithWireNaming(naming WireNaming) RoomOption {
+	return func(r *Room) {
+		r.naming = naming
+	}
+}
+
+// presenceWire is the wire form of join and leave notifications.
+// onlineCount is the legacy spelling of online_count.
+type presenceWire struct {
+	Username          string `json:"username"`
+	OnlineCountLegacy *int   `json:"onlineCount,omitempty"`
+	OnlineCount       *int   `json:"online_count,omitempty"`
+}
+
+func marshalPresence(username string, onlineCount int, naming WireNaming) ([]byte, error) {
+	wire := presenceWire{Username: username}
+	if naming != NamingSnake {
+		wire.OnlineCountLegacy = &onlineCount
+	}
+	if naming != NamingLegacy {
+		wire.OnlineCount = &onlineCount
+	}
+	return json.Marshal(wire)
+}
+
+func unmarshalPresence(data []byte) (string, int, error) {
+	var wire presenceWire
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return "", 0, err
+	}
+	switch {
+	case wire.OnlineCount != nil:
+		return wire.Username, *wire.OnlineCount, nil
+	case wire.OnlineCountLegacy != nil:
+		return wire.Username, *wire.OnlineCountLegacy, nil
+	}
+	return wire.Username, 0, nil
+}
+
+func (n JoinNotification) MarshalJSON() ([]byte, error) {
+	return marshalPresence(n.Username, n.OnlineCount, n.naming)
+}
+
+func (n *JoinNotification) UnmarshalJSON(data []byte) (err error) {
+	n.Username, n.OnlineCount, err = unmarshalPresence(data)
+	return err
+}
+
+func (n LeaveNotification) MarshalJSON() ([]byte, error) {
+	return marshalPresence(n.Username, n.OnlineCount, n.naming)
+}
+
+func (n *LeaveNotification) UnmarshalJSON(data []byte) (err error) {
+	n.Username, n.OnlineCount, err = unmarshalPresence(data)
+	return err
+}
@@ -0,0 +1,137 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Occupancy history limits of the in-memory store
+const (
+	// MaxOccupancySamples is how many samples are kept per room, a day at one sample a minute
+	MaxOccupancySamples = 1440
+	// DefaultOccupancyRetention is how long the history of a room is kept after its last sample
+	DefaultOccupancyRetention = 24 * time.Hour
+)
+
+// OccupancySample is the number of clients connected to a room at a point in time
+type OccupancySample struct {
+	At      time.Time `json:"at" example:"2024-01-01T12:00:00Z"`
+	Clients int       `json:"clients" example:"42"`
+}
+
+// OccupancyStore keeps the occupancy history of rooms, including rooms that ended,
+// so attendance can be reviewed after an event. Implementations must be safe for
+// concurrent use.
+type OccupancyStore interface {
+	// Record appends a sample to the history of room id
+	Record(id ID, sample OccupancySample)
+	// History returns the samples of room id, oldest first
+	History(id ID) []OccupancySample
+	// Prune drops the history of rooms without samples since before
+	Prune(before time.Time)
+}
+
+// MemoryOccupancyStore is an OccupancyStore keeping the latest MaxOccupancySamples
+// samples of every room in memory
+type MemoryOccupancyStore struct {
+	rooms map[ID]*occupancyRing
+	mu    sync.Mutex
+}
+
+// occupancyRing holds the latest samples of a room, overwriting the oldest
+type occupancyRing struct {
+	samples []OccupancySample
+	next    int
+}
+
+func NewMemoryOccupancyStore() *MemoryOccupancyStore {
+	return &MemoryOccupancyStore{rooms: make(map[ID]*occupancyRing)}
+}
+
+func (s *MemoryOccupancyStore) Record(id ID, sample OccupancySample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ring, ok := s.rooms[id]
+	if !ok {
+		ring = &occupancyRing{}
+		s.rooms[id] = ring
+	}
+	if len(ring.samples) < MaxOccupancySamples {
+		ring.samples = append(ring.samples, sample)
+		return
+	}
+	ring.samples[ring.next] = sample
+	ring.next = (ring.next + 1) % MaxOccupancySamples
+}
+
+func (s *MemoryOccupancyStore) History(id ID) []OccupancySample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ring, ok := s.rooms[id]
+	if !ok {
+		return nil
+	}
+	return append(append([]OccupancySample{}, ring.samples[ring.next:]...), ring.samples[:ring.next]...)
+}
+
+func (s *MemoryOccupancyStore) Prune(before time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, ring := range s.rooms {
+		last := ring.samples[(ring.next+len(ring.samples)-1)%len(ring.samples)]
+		if last.At.Before(before) {
+			delete(s.rooms, id)
+		}
+	}
+}
+
+// RunOccupancySampler records the client count of every room each interval until
+// ctx is done, and drops histories older than retention. It does nothing without
+// an occupancy store or with a non-positive interval.
+func (h *Hub) RunOccupancySampler(ctx context.Context, interval, retention time.Duration) {
+	if h.Occupancy == nil || interval <= 0 {
+		return
+	}
+	if retention <= 0 {
+		retention = DefaultOccupancyRetention
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			h.sampleOccupancy(now)
+			h.Occupancy.Prune(now.Add(-retention))
+		}
+	}
+}
+
+// sampleOccupancy records the current client count of every room
+func (h *Hub) sampleOccupancy(now time.Time) {
+	h.Rooms.Range(func(_, value any) bool {
+		room := value.(*Room)
+		h.Occupancy.Record(room.ID, OccupancySample{At: now, Clients: room.GetClientCount()})
+		return true
+	})
+}
+
+// OccupancyHistory returns the occupancy samples of room id, oldest first. The
+// history outlives the room until it is pruned.
+func (h *Hub) OccupancyHistory(id ID) []OccupancySample {
+	if h.Occupancy == nil {
+		return nil
+	}
+	return h.Occupancy.History(id)
+}
+
+// occupancyEnded closes the history of a removed room with an empty sample,
+// so attendance curves end when the room did
+func (h *Hub) occupancyEnded(id ID) {
+	if h.Occupancy != nil {
+		h.Occupancy.Record(id, OccupancySample{At: time.Now(), Clients: 0})
+	}
+}
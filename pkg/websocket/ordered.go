@@ -0,0 +1,59 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ErrCodeReplayUnavailable is sent when a resend asks for broadcasts no longer buffered
+const ErrCodeReplayUnavailable = "replay_unavailable"
+
+// ResendRequest Sent by clients to request retransmission of broadcasts From..To inclusive
+type ResendRequest struct {
+	From uint64 `json:"from" example:"40"`
+	To   uint64 `json:"to" example:"42"`
+}
+
+// WithOrderedDelivery enables strict ordering: every broadcast, including room
+// notifications, is delivered in sequence order without gaps. A client whose queue
+// is full, live or while replaying, is disconnected instead of skipped, so it can
+// resume and replay what it missed; a client resuming after broadcasts left the
+// buffer gets ErrCodeReplayUnavailable naming them. Presence updates are not
+// coalesced and the drop-oldest policy does not apply. bufferSize sets how many
+// broadcasts are kept for replay and resend.
+func WithOrderedDelivery(bufferSize int) RoomOption {
+	return func(r *Room) {
+		r.ordered = true
+		if bufferSize > 0 {
+			r.replay = newReplayBuffer(bufferSize)
+		}
+	}
+}
+
+// IsOrdered reports whether the room uses strict ordered delivery
+func (r *Room) IsOrdered() bool {
+	return r.ordered
+}
+
+// handleResendMessage retransmits a range of buffered broadcasts to the client
+func (c *Client) handleResendMessage(message Message) {
+	var req ResendRequest
+	if err := json.Unmarshal(message.Data, &req); err != nil || req.From == 0 || req.From > req.To {
+		c.sendError(ErrCodeReplayUnavailable, "invalid resend range")
+		return
+	}
+
+	r := c.Room
+	r.seqMu.Lock()
+	if oldest := r.replay.oldest(); req.From < oldest || req.To > r.replay.next {
+		r.seqMu.Unlock()
+		c.sendError(ErrCodeReplayUnavailable,
+			fmt.Sprintf("only broadcasts %d..%d are available", oldest, r.replay.next))
+		return
+	}
+	replayed := r.replayRange(c, req.From, req.To)
+	r.seqMu.Unlock()
+	if !replayed {
+		r.dropClients([]*Client{c})
+	}
+}
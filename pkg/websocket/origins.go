@@ -0,0 +1,93 @@
+package websocket
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// OriginAllowlist decides which browser origins may open WebSocket connections and
+// call the API. Entries are exact origins such as https://chat.example.com, origins
+// with a wildcard subdomain such as https://*.example.com, or "*" for any origin.
+// Requests from the server's own origin are always allowed. It is safe for
+// concurrent use and may be replaced at runtime with Set.
+type OriginAllowlist struct {
+	entries atomic.Pointer[[]string]
+}
+
+// NewOriginAllowlist creates an allowlist of origins, see ValidateOrigins
+func NewOriginAllowlist(origins []string) *OriginAllowlist {
+	a := &OriginAllowlist{}
+	a.Set(origins)
+	return a
+}
+
+// Set replaces the allowed origins
+func (a *OriginAllowlist) Set(origins []string) {
+	entries := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		entries = append(entries, strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/")))
+	}
+	a.entries.Store(&entries)
+}
+
+// AllowsAny reports whether every origin is allowed
+func (a *OriginAllowlist) AllowsAny() bool {
+	for _, entry := range *a.entries.Load() {
+		if entry == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows reports whether origin matches an entry of the allowlist
+func (a *OriginAllowlist) Allows(origin string) bool {
+	origin = strings.ToLower(origin)
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return false
+	}
+	for _, entry := range *a.entries.Load() {
+		if entry == "*" || entry == origin {
+			return true
+		}
+		scheme, host, ok := strings.Cut(entry, "://*.")
+		if ok && scheme == u.Scheme && strings.HasSuffix(u.Host, "."+host) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOrigin is an Upgrader.CheckOrigin accepting requests without an Origin
+// header, which do not come from browsers, same-origin requests and allowed origins
+func (a *OriginAllowlist) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return a.Allows(origin)
+}
+
+// ValidateOrigins checks that every entry is "*" or a scheme://host[:port] origin
+// whose host may start with "*." to match its subdomains
+func ValidateOrigins(origins []string) error {
+	for _, origin := range origins {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(strings.Replace(origin, "://*.", "://", 1))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			u.Path != "" || u.RawQuery != "" || u.User != nil || strings.Contains(u.Host, "*") {
+			return fmt.Errorf("invalid origin %q, use scheme://host[:port] with an optional *. before the host", origin)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package websocket
+
+import (
+	"context"
+	"runtime/trace"
+	"strconv"
+	"time"
+)
+
+// Stages of the broadcast pipeline reported to MetricsNotifier.StageObserved
+const (
+	// StageEnqueue is the time a sender waits for the room Run loop to accept a broadcast
+	StageEnqueue = "enqueue"
+	// StageFanout is the time spent queueing a broadcast to every client of the room
+	StageFanout = "fanout"
+	// StageWrite is the time a client write goroutine spends writing one message to the network
+	StageWrite = "write"
+)
+
+// traceStage starts timing a pipeline stage and returns the function that ends it.
+// Each stage is also a runtime/trace region, visible in `go tool trace` when tracing is on.
+// ctx carries the span the stage belongs to, if any.
+func (r *Room) traceStage(ctx context.Context, stage string) func() {
+	region := trace.StartRegion(ctx, "ws."+stage)
+	start := time.Now()
+	return func() {
+		region.End()
+		if r.Metrics == nil {
+			return
+		}
+		if metrics, ok := r.Metrics.(ContextMetricsNotifier); ok {
+			metrics.StageObservedContext(ctx, strconv.Itoa(int(r.ID)), stage, time.Since(start))
+		} else {
+			r.Metrics.StageObserved(strconv.Itoa(int(r.ID)), stage, time.Since(start))
+		}
+	}
+}
+
+// broadcastObserved reports the duration and fan-out size of a broadcast to Metrics
+func (r *Room) broadcastObserved(ctx context.Context, roomID string, recipients int, d time.Duration) {
+	if metrics, ok := r.Metrics.(ContextMetricsNotifier); ok {
+		metrics.BroadcastObservedContext(ctx, roomID, recipients, d)
+		return
+	}
+	r.Metrics.BroadcastObserved(roomID, recipients, d)
+}
+
+// broadcast is a message handed to the Run loop by enqueue
+type broadcast struct {
+	ctx context.Context
+	msg []byte
+}
+
+// enqueue hands msg to the Run loop for broadcasting, recording how long it
+// waited. ctx carries the span of the connection msg came from, the fan-out is
+// traced as its child. It reports false without waiting further once the room
+// is stopped.
+func (r *Room) enqueue(ctx context.Context, msg []byte) bool {
+	done := r.traceStage(ctx, StageEnqueue)
+	defer done()
+	select {
+	case r.broadcasts <- broadcast{ctx: ctx, msg: msg}:
+		return true
+	case <-r.Stop:
+		return false
+	}
+}
@@ -0,0 +1,117 @@
+package websocket
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+)
+
+// Preference limits
+const (
+	MaxMutedUsers        = 100
+	MaxStoredPreferences = 1000 // members per room whose preferences are kept
+)
+
+// Error codes sent in reply to preferences messages
+const (
+	ErrCodeInvalidPreferences = "invalid_preferences"
+	ErrCodeSessionRequired    = "session_required"
+)
+
+// Notification levels a member may choose
+const (
+	NotifyAll      = "all"
+	NotifyMentions = "mentions"
+	NotifyNone     = "none"
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// MemberPreferences Personal settings of a member, kept by the room across reconnects
+// @Description Sent by a client to replace its preferences and to the client on join
+type MemberPreferences struct {
+	Color             string   `json:"color,omitempty" example:"#ff8800"`
+	NotificationLevel string   `json:"notification_level,omitempty" example:"mentions"`
+	MutedUsers        []string `json:"muted_users,omitempty"`
+	// QualityAlerts sends hosts "member_quality" when the connection quality of a member changes
+	QualityAlerts bool `json:"quality_alerts,omitempty" example:"true"`
+}
+
+// Validate checks the preferences against their limits
+func (p MemberPreferences) Validate() *ValidationError {
+	if p.Color != "" && !colorPattern.MatchString(p.Color) {
+		return &ValidationError{Field: "color", Message: "color must be a #rrggbb hex value"}
+	}
+	switch p.NotificationLevel {
+	case "", NotifyAll, NotifyMentions, NotifyNone:
+	default:
+		return &ValidationError{Field: "notification_level", Message: "notification_level must be all, mentions or none"}
+	}
+	if len(p.MutedUsers) > MaxMutedUsers {
+		return &ValidationError{Field: "muted_users", Message: "too many muted users"}
+	}
+	for _, username := range p.MutedUsers {
+		if len(username) > MaxUsernameLength {
+			return &ValidationError{Field: "muted_users", Message: "muted username is too long"}
+		}
+	}
+	return nil
+}
+
+// Preferences returns the stored preferences of the member with the given session ID
+func (r *Room) Preferences(sessionID string) (MemberPreferences, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	prefs, ok := r.preferences[sessionID]
+	prefs.MutedUsers = slices.Clone(prefs.MutedUsers)
+	return prefs, ok
+}
+
+// SetPreferences replaces the preferences of the member with the given session ID
+func (r *Room) SetPreferences(sessionID string, prefs MemberPreferences) error {
+	if err := prefs.Validate(); err != nil {
+		return err
+	}
+	prefs.MutedUsers = slices.Clone(prefs.MutedUsers)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.preferences[sessionID]; !exists && len(r.preferences) >= MaxStoredPreferences {
+		return &ValidationError{Field: "session", Message: "room stores too many member preferences"}
+	}
+	if r.preferences == nil {
+		r.preferences = make(map[string]MemberPreferences)
+	}
+	r.preferences[sessionID] = prefs
+	return nil
+}
+
+// handlePreferencesMessage stores the preferences sent by the client and echoes them back
+func (c *Client) handlePreferencesMessage(message Message) {
+	if c.SessionID == "" {
+		c.sendError(ErrCodeSessionRequired, "preferences require a session cookie")
+		return
+	}
+	var prefs MemberPreferences
+	if err := json.Unmarshal(message.Data, &prefs); err != nil {
+		c.sendError(ErrCodeInvalidPreferences, "invalid preferences payload")
+		return
+	}
+	if err := c.Room.SetPreferences(c.SessionID, prefs); err != nil {
+		c.sendError(ErrCodeInvalidPreferences, err.Error())
+		return
+	}
+	c.sendPreferences()
+}
+
+// sendPreferences syncs the stored preferences to the client, if it has any
+func (c *Client) sendPreferences() {
+	if c.SessionID == "" {
+		return
+	}
+	prefs, ok := c.Room.Preferences(c.SessionID)
+	if !ok {
+		return
+	}
+	c.trySend(mustMarshal(Message{Type: "preferences", Data: mustMarshal(prefs)}))
+}
@@ -0,0 +1,93 @@
+package websocket
+
+import (
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// preparedWriter is implemented by transports that can write a message framed
+// once for every connection, such as *websocket.Conn from gorilla
+type preparedWriter interface {
+	WritePreparedMessage(pm *websocket.PreparedMessage) error
+}
+
+// preparedBroadcast is a broadcast and its prepared frames
+type preparedBroadcast struct {
+	msg []byte
+	pm  *websocket.PreparedMessage
+}
+
+// preparedCache keeps the prepared frames of the latest bufferSize broadcasts,
+// so the write loops of a room frame each broadcast once instead of once per
+// client. Broadcasts are found by their backing array, which the cache keeps
+// alive, so a queued message is matched only with the bytes it was prepared from.
+// Clients that fell further behind write their messages unprepared.
+type preparedCache struct {
+	mu      sync.RWMutex
+	entries map[*byte]preparedBroadcast
+	ring    [bufferSize]*byte
+	next    int
+}
+
+// add prepares msg for writing to many connections
+func (p *preparedCache) add(msg []byte) {
+	if len(msg) == 0 {
+		return
+	}
+	pm, err := websocket.NewPreparedMessage(frameType(msg), msg)
+	if err != nil {
+		return
+	}
+	key := &msg[0]
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.entries == nil {
+		p.entries = make(map[*byte]preparedBroadcast, len(p.ring))
+	}
+	if old := p.ring[p.next]; old != nil {
+		delete(p.entries, old)
+	}
+	p.ring[p.next] = key
+	p.entries[key] = preparedBroadcast{msg: msg, pm: pm}
+	p.next = (p.next + 1) % len(p.ring)
+}
+
+// get returns the prepared frames of msg, nil if it was not prepared or was evicted
+func (p *preparedCache) get(msg []byte) *websocket.PreparedMessage {
+	if len(msg) == 0 {
+		return nil
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	entry, ok := p.entries[&msg[0]]
+	if !ok || len(entry.msg) != len(msg) {
+		return nil
+	}
+	return entry.pm
+}
+
+// writeMessage writes msg to the connection, compressed when the client
+// negotiated permessage-deflate
+func (c *Client) writeMessage(msg []byte) error {
+	if c.compression != nil {
+		return c.writeCompressed(msg)
+	}
+	return c.writeFrame(msg)
+}
+
+// writeFrame writes msg to the connection, reusing the frames prepared for
+// the room broadcast it belongs to when the transport supports it
+func (c *Client) writeFrame(msg []byte) error {
+	// Engine.IO connections embed a gorilla connection but frame messages themselves
+	if _, framed := c.Conn.(*engineIOConn); framed {
+		return c.Conn.WriteMessage(frameType(msg), msg)
+	}
+	if w, ok := c.Conn.(preparedWriter); ok {
+		if pm := c.Room.prepared.get(msg); pm != nil {
+			return w.WritePreparedMessage(pm)
+		}
+	}
+	return c.Conn.WriteMessage(frameType(msg), msg)
+}
@@ -0,0 +1,96 @@
+package websocket
+
+import "strconv"
+
+// Priority ranks broadcasts for delivery to clients whose send queue is under
+// pressure: presence updates are shed first, control messages always get through.
+// Only updates that a later one supersedes are shed; membership changes such as
+// join and leave are control messages, a missed one would leave the roster wrong.
+type Priority int
+
+const (
+	// PriorityPresence covers member presence updates, which later ones supersede
+	PriorityPresence Priority = iota
+	// PriorityChat covers chat and binary messages
+	PriorityChat
+	// PriorityControl covers system and moderation messages
+	PriorityControl
+)
+
+// controlQueueSize bounds the control messages queued past a full send queue
+const controlQueueSize = 16
+
+// String returns the name of p, used as a metrics label
+func (p Priority) String() string {
+	switch p {
+	case PriorityPresence:
+		return "presence"
+	case PriorityChat:
+		return "chat"
+	default:
+		return "control"
+	}
+}
+
+// priorityOf returns the priority of broadcasts of msgType
+func priorityOf(msgType string) Priority {
+	switch msgType {
+	case "media_state", "member_quality", "hand_queue", "read":
+		return PriorityPresence
+	case "chat", "message", "action", "attachment", "edit", "delete":
+		return PriorityChat
+	default:
+		return PriorityControl
+	}
+}
+
+// congested reports whether the send queue of the client is at least half full
+func (c *Client) congested() bool {
+	return cap(c.Send) > 0 && len(c.Send) >= cap(c.Send)/2
+}
+
+// deliverPriority queues a broadcast of priority p for client. Presence updates are
+// shed while the client is congested, and control messages that find the send queue
+// full are queued on the control lane, which the write loop drains first. Ordered
+// rooms deliver every broadcast in sequence instead. It returns false if the client
+// could not take msg. The caller holds seqMu and the read lock of the room.
+func (r *Room) deliverPriority(client *Client, msg []byte, p Priority) bool {
+	if !r.ordered && p == PriorityPresence && client.spool == nil && client.congested() {
+		r.messageShed(p)
+		return true
+	}
+	if r.deliver(client, msg) {
+		return true
+	}
+	if !r.ordered && p == PriorityControl && client.control != nil && !client.isClosed() {
+		select {
+		case client.control <- msg:
+			return true
+		default:
+		}
+	}
+	return false
+}
+
+// messageShed reports a broadcast of priority p skipped for a congested client
+func (r *Room) messageShed(p Priority) {
+	if r.Metrics != nil {
+		r.Metrics.MessageShed(strconv.Itoa(int(r.ID)), p.String())
+	}
+}
+
+// nextMessage waits for the next message to write, control messages that
+// overflowed Send first. It returns false once Send is closed.
+func (c *Client) nextMessage() ([]byte, bool) {
+	select {
+	case msg := <-c.control:
+		return msg, true
+	default:
+	}
+	select {
+	case msg := <-c.control:
+		return msg, true
+	case msg, ok := <-c.Send:
+		return msg, ok
+	}
+}
@@ -0,0 +1,79 @@
+package websocket
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// pseudonymPrefix marks IDs issued by a Pseudonymizer
+const pseudonymPrefix = "anon_"
+
+// Pseudonymizer issues anonymous users a pseudonymous ID. Members returning with
+// the signed session cookie the server issued them are identified by it. Others
+// fall back to a fingerprint of their connection: the network of their IP address
+// (/24 for IPv4, /48 for IPv6), their User-Agent and their Accept-Language. The
+// fingerprint is client-controlled and shared by everyone behind the same NAT with
+// the same browser, so it only stands in until the cookie comes back. Both are
+// keyed with a salt that is itself an HMAC of the period number, so IDs cannot be
+// forged or reversed without the secret, stay stable within a rotation period and
+// cannot be linked across periods.
+type Pseudonymizer struct {
+	secret   []byte
+	rotation time.Duration
+}
+
+// NewPseudonymizer creates a pseudonymizer whose IDs change every rotation
+func NewPseudonymizer(secret []byte, rotation time.Duration) *Pseudonymizer {
+	return &Pseudonymizer{secret: secret, rotation: rotation}
+}
+
+// Pseudonyms returns the ID of the requester in the current rotation period and in
+// the previous one, so bans issued shortly before a rotation still match. sessionID
+// is the session the request proved with its cookie, empty to use the fingerprint.
+func (p *Pseudonymizer) Pseudonyms(sessionID string, r *http.Request, ip string, now time.Time) (current, previous string) {
+	period := now.UnixNano() / int64(p.rotation)
+	identity := "session\n" + sessionID
+	if sessionID == "" {
+		identity = p.fingerprint(r, ip)
+	}
+	return p.pseudonym(period, identity), p.pseudonym(period-1, identity)
+}
+
+// Fingerprint returns the current fingerprint ID of the requester. Bans record it
+// next to the session-based ID, so dropping the cookie does not get a member back in.
+func (p *Pseudonymizer) Fingerprint(r *http.Request, ip string, now time.Time) string {
+	return p.pseudonym(now.UnixNano()/int64(p.rotation), p.fingerprint(r, ip))
+}
+
+// fingerprint describes the requester's network and browser
+func (p *Pseudonymizer) fingerprint(r *http.Request, ip string) string {
+	return "fingerprint\n" + fingerprintNetwork(ip) + "\n" + r.UserAgent() + "\n" + r.Header.Get("Accept-Language")
+}
+
+// pseudonym keys fingerprint with the salt of period
+func (p *Pseudonymizer) pseudonym(period int64, fingerprint string) string {
+	salt := hmac.New(sha256.New, p.secret)
+	salt.Write([]byte("pseudonym-salt:" + strconv.FormatInt(period, 10)))
+	mac := hmac.New(sha256.New, salt.Sum(nil))
+	mac.Write([]byte(fingerprint))
+	return pseudonymPrefix + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// fingerprintNetwork returns the network of ip that stays the same when a
+// household or a mobile client gets a new address from its provider
+func fingerprintNetwork(ip string) string {
+	parsed := net.ParseIP(ip)
+	switch {
+	case parsed == nil:
+		return ip
+	case parsed.To4() != nil:
+		return parsed.Mask(net.CIDRMask(24, 32)).String()
+	default:
+		return parsed.Mask(net.CIDRMask(48, 128)).String()
+	}
+}
@@ -0,0 +1,111 @@
+package websocket
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Connection quality levels sent in "quality" notifications
+const (
+	QualityGood = "good"
+	QualityFair = "fair"
+	QualityPoor = "poor"
+)
+
+// qualityLevels are indexed by the level stored in Client.quality, good being the zero value
+var qualityLevels = []string{QualityGood, QualityFair, QualityPoor}
+
+// qualityCheckInterval is how often the write loop re-rates a busy connection
+const qualityCheckInterval = time.Second
+
+// QualityNotification Sent to a client as "quality" when the quality of its connection
+// changes level, and as "member_quality" to hosts that enabled quality_alerts
+type QualityNotification struct {
+	Username      string `json:"username,omitempty" example:"JohnDoe"` // member_quality only
+	Level         string `json:"level" example:"fair"`
+	Score         int    `json:"score" example:"65"`
+	RTTMs         int64  `json:"rtt_ms" example:"420"`
+	MissedPongs   int    `json:"missed_pongs" example:"1"`
+	QueuedPercent int    `json:"queued_percent" example:"40"` // how full the send queue is
+}
+
+// qualityScore rates a connection from 0 to 100 by its round-trip time, the pings
+// it left unanswered and how full its send queue is
+func qualityScore(rtt time.Duration, missedPongs int, queued float64) int {
+	score := 100
+	switch {
+	case rtt >= time.Second:
+		score -= 50
+	case rtt >= 500*time.Millisecond:
+		score -= 30
+	case rtt >= 250*time.Millisecond:
+		score -= 15
+	}
+	score -= 25 * min(missedPongs, 4)
+	switch {
+	case queued >= 0.75:
+		score -= 40
+	case queued >= 0.5:
+		score -= 20
+	case queued >= 0.25:
+		score -= 10
+	}
+	return max(score, 0)
+}
+
+// qualityLevel returns the index in qualityLevels of score
+func qualityLevel(score int) int {
+	switch {
+	case score >= 70:
+		return 0
+	case score >= 40:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// checkQuality rates the connection and, when its level changed, tells the client
+// and the hosts that enabled quality alerts
+func (c *Client) checkQuality() {
+	queued := 0.0
+	if size := cap(c.Send); size > 0 {
+		queued = float64(len(c.Send)) / float64(size)
+	}
+	missed := int(max(c.pingsPending.Load()-1, 0))
+	rtt := c.RTT()
+	score := qualityScore(rtt, missed, queued)
+	level := qualityLevel(score)
+	if int(c.quality.Swap(int32(level))) == level {
+		return
+	}
+
+	notification := QualityNotification{
+		Level:         qualityLevels[level],
+		Score:         score,
+		RTTMs:         rtt.Milliseconds(),
+		MissedPongs:   missed,
+		QueuedPercent: int(queued * 100),
+	}
+	data, _ := json.Marshal(notification)
+	c.trySend(mustMarshal(Message{Type: "quality", Data: data}))
+
+	notification.Username = c.Username
+	msg := mustMarshal(Message{Type: "member_quality", Data: mustMarshal(notification)})
+	for _, host := range c.Room.qualityAlertHosts(c) {
+		host.trySend(msg)
+	}
+}
+
+// qualityAlertHosts returns the hosts other than client that enabled quality alerts
+func (r *Room) qualityAlertHosts(client *Client) []*Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var hosts []*Client
+	for other := range r.Clients {
+		if other != client && other.IsHost() && other.SessionID != "" && r.preferences[other.SessionID].QualityAlerts {
+			hosts = append(hosts, other)
+		}
+	}
+	return hosts
+}
@@ -0,0 +1,130 @@
+package websocket
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCodeRoomRateLimited is sent when the room broadcast budget is exhausted
+const ErrCodeRoomRateLimited = "room_rate_limited"
+
+// tokenBucket allows up to limit messages per window, refilling continuously
+type tokenBucket struct {
+	last   time.Time
+	rate   float64
+	burst  float64
+	tokens float64
+	mu     sync.Mutex
+}
+
+func newTokenBucket(limit int, window time.Duration) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(limit) / window.Seconds(),
+		burst:  float64(limit),
+		tokens: float64(limit),
+		last:   time.Now(),
+	}
+}
+
+// take consumes one token. When none is left it returns false and how long to wait for the next one.
+func (q *tokenBucket) take(now time.Time) (time.Duration, bool) {
+	return q.takeN(now, 1)
+}
+
+// takeN consumes n tokens at once. When fewer are left it consumes none and returns
+// false and how long to wait until there are enough.
+func (q *tokenBucket) takeN(now time.Time, n int) (time.Duration, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.tokens = min(q.burst, q.tokens+now.Sub(q.last).Seconds()*q.rate)
+	q.last = now
+	if q.tokens >= float64(n) {
+		q.tokens -= float64(n)
+		return 0, true
+	}
+	return time.Duration((float64(n) - q.tokens) / q.rate * float64(time.Second)), false
+}
+
+// full reports whether the bucket refilled completely by now
+func (q *tokenBucket) full(now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.tokens+now.Sub(q.last).Seconds()*q.rate >= q.burst
+}
+
+// TokenBucket is a token bucket for callers outside the package, refilling at a
+// constant rate up to its burst. It is safe for concurrent use.
+type TokenBucket struct {
+	bucket tokenBucket
+}
+
+// NewTokenBucket creates a full bucket refilling perSecond tokens per second up to burst
+func NewTokenBucket(perSecond float64, burst int, now time.Time) *TokenBucket {
+	return &TokenBucket{bucket: tokenBucket{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}}
+}
+
+// Take consumes one token. When none is left it returns false and how long to wait for the next one.
+func (b *TokenBucket) Take(now time.Time) (time.Duration, bool) {
+	return b.bucket.take(now)
+}
+
+// Full reports whether the bucket refilled completely by now
+func (b *TokenBucket) Full(now time.Time) bool {
+	return b.bucket.full(now)
+}
+
+// WithBroadcastQuota limits the room to perSecond broadcast messages per second,
+// shared by all of its clients. Zero means unlimited.
+func WithBroadcastQuota(perSecond int) RoomOption {
+	return func(r *Room) {
+		if perSecond > 0 {
+			r.quota = newTokenBucket(perSecond, time.Second)
+		}
+	}
+}
+
+// allowBroadcast reserves room budget for one broadcast message
+func (r *Room) allowBroadcast() (time.Duration, bool) {
+	r.mu.RLock()
+	quota := r.quota
+	r.mu.RUnlock()
+	if quota == nil {
+		return 0, true
+	}
+	retryAfter, ok := quota.take(time.Now())
+	if !ok && r.Metrics != nil {
+		r.Metrics.ThrottledMessage(strconv.Itoa(int(r.ID)))
+	}
+	return retryAfter, ok
+}
+
+// reserveBroadcast checks the client's send credits and the room budget and tells the
+// client when to retry if either is exhausted. The credit is only spent once the
+// deployment and room quotas admitted the message.
+func (c *Client) reserveBroadcast() bool {
+	if !c.hasCredit(time.Now()) {
+		return false
+	}
+	if !c.Room.allowTenantMessage() {
+		c.sendError(ErrCodeTenantQuota, "message quota of this deployment is exhausted")
+		return false
+	}
+	retryAfter, ok := c.Room.allowBroadcast()
+	if !ok {
+		c.sendErrorNotification(ErrorNotification{
+			Code:         ErrCodeRoomRateLimited,
+			Message:      "room message rate exceeded, retry later",
+			RetryAfterMs: retryAfter.Milliseconds() + 1,
+		})
+		return false
+	}
+	c.spendCredit()
+	return true
+}
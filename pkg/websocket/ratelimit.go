@@ -0,0 +1,96 @@
+package websocket
+
+import (
+	"strconv"
+	"time"
+)
+
+// ErrCodeRateLimited is sent when a client exceeds its own message rate
+const ErrCodeRateLimited = "rate_limited"
+
+// WithClientRateLimit limits every client of the room to limit messages per window.
+// Excess messages are dropped and the sender is told when to retry. Zero means unlimited.
+func WithClientRateLimit(limit int, window time.Duration) RoomOption {
+	return func(r *Room) {
+		if limit > 0 && window > 0 {
+			r.clientLimit = limit
+			r.clientWindow = window
+		}
+	}
+}
+
+// SetRateLimits replaces the per-client message rate limit and the broadcast quota
+// of a running room, zero disabling either. Clients switch to the new limit with
+// their next message, starting with a full budget.
+func (r *Room) SetRateLimits(clientLimit int, clientWindow time.Duration, broadcastPerSecond int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clientLimit, r.clientWindow, r.quota = 0, 0, nil
+	WithClientRateLimit(clientLimit, clientWindow)(r)
+	WithBroadcastQuota(broadcastPerSecond)(r)
+	r.limitsVersion++
+}
+
+// clientRateLimit returns the per-client limit and window and the version of the room limits
+func (r *Room) clientRateLimit() (int, time.Duration, uint64) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.clientLimit, r.clientWindow, r.limitsVersion
+}
+
+// sharedLimiter returns the message budget shared by the clients with pseudonym,
+// so reconnecting or joining under several usernames does not reset it. Budgets
+// that refilled completely are dropped when a new one is created.
+func (r *Room) sharedLimiter(pseudonym string, limit int, window time.Duration, version uint64, now time.Time) *tokenBucket {
+	r.limitersMu.Lock()
+	defer r.limitersMu.Unlock()
+	if r.limitersVersion != version {
+		r.limiters, r.limitersVersion = nil, version
+	}
+	if limiter, ok := r.limiters[pseudonym]; ok {
+		return limiter
+	}
+	for key, limiter := range r.limiters {
+		if limiter.full(now) {
+			delete(r.limiters, key)
+		}
+	}
+	if r.limiters == nil {
+		r.limiters = make(map[string]*tokenBucket)
+	}
+	limiter := newTokenBucket(limit, window)
+	r.limiters[pseudonym] = limiter
+	return limiter
+}
+
+// allowMessage consumes one message from the client's budget, or the budget of its
+// pseudonym, replying with
+// a rate_limited error when it is exhausted. Only called from Read.
+func (c *Client) allowMessage(now time.Time) bool {
+	limit, window, version := c.Room.clientRateLimit()
+	if limit == 0 {
+		return true
+	}
+	limiter := c.limiter
+	if c.pseudonym != "" && !c.IsHost() {
+		limiter = c.Room.sharedLimiter(c.pseudonym, limit, window, version, now)
+	} else if c.limiter == nil || c.limiterVersion != version {
+		c.limiter = newTokenBucket(limit, window)
+		c.limiterVersion = version
+		limiter = c.limiter
+	}
+
+	retryAfter, ok := limiter.take(now)
+	if ok {
+		return true
+	}
+	if c.Room.Metrics != nil {
+		c.Room.Metrics.RateLimitedMessage(strconv.Itoa(int(c.Room.ID)), c.Username)
+	}
+	c.sendErrorNotification(ErrorNotification{
+		Code:         ErrCodeRateLimited,
+		Message:      "message rate exceeded, retry later",
+		RetryAfterMs: retryAfter.Milliseconds() + 1,
+	})
+	return false
+}
@@ -0,0 +1,141 @@
+package websocket
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ReadStateInterval is how often a room broadcasts the read positions that changed
+const ReadStateInterval = time.Second
+
+// ReadMessage Sent by a client with the sequence number of the last broadcast it displayed
+type ReadMessage struct {
+	Seq uint64 `json:"seq" example:"42"`
+}
+
+// ReadStateNotification Sent to clients as "read" with the read positions of members,
+// keyed by username. Broadcasts only carry the positions that changed since the
+// previous one; joining clients receive all of them.
+type ReadStateNotification struct {
+	Positions map[string]uint64 `json:"positions"`
+}
+
+// readReceipts keeps the read positions of the members of a room and the ones
+// not broadcast yet. A pending flush is scheduled while changed is not empty.
+type readReceipts struct {
+	positions map[string]uint64
+	changed   map[string]uint64
+	flush     *time.Timer
+	mu        sync.Mutex
+}
+
+// MarkRead moves the read position of username to seq, clamped to the latest
+// broadcast. Positions only move forward; the change is broadcast with the next
+// read state delta.
+func (r *Room) MarkRead(username string, seq uint64) {
+	seq = min(seq, r.LastSeq())
+	reads := &r.reads
+	reads.mu.Lock()
+	defer reads.mu.Unlock()
+	if seq == 0 || seq <= reads.positions[username] {
+		return
+	}
+	if reads.positions == nil {
+		reads.positions = make(map[string]uint64)
+		reads.changed = make(map[string]uint64)
+	}
+	reads.positions[username] = seq
+	reads.changed[username] = seq
+	if reads.flush == nil {
+		reads.flush = time.AfterFunc(ReadStateInterval, r.flushReads)
+	}
+}
+
+// ReadPositions returns the read positions of the members, keyed by username
+func (r *Room) ReadPositions() map[string]uint64 {
+	r.reads.mu.Lock()
+	defer r.reads.mu.Unlock()
+	return maps.Clone(r.reads.positions)
+}
+
+// flushReads sends the read positions that changed since the last flush to every
+// client. Deltas are not sequenced and stay out of the replay buffer and event
+// log: a client reporting the last seq it displayed would otherwise keep moving
+// its own position. Clients with a full queue miss a delta, the next one or the
+// state sent on joining catches them up.
+func (r *Room) flushReads() {
+	r.reads.mu.Lock()
+	changed := r.reads.changed
+	r.reads.changed = make(map[string]uint64)
+	r.reads.flush = nil
+	r.reads.mu.Unlock()
+
+	select {
+	case <-r.Stop:
+		return
+	default:
+	}
+	if len(changed) == 0 {
+		return
+	}
+	msg := mustMarshal(Message{Type: "read", Data: mustMarshal(ReadStateNotification{Positions: changed})})
+	r.mu.RLock()
+	clients := slices.Collect(maps.Keys(r.Clients))
+	r.mu.RUnlock()
+	for _, client := range clients {
+		client.trySend(msg)
+	}
+}
+
+// sendReadState tells a joining client how far members have read
+func (c *Client) sendReadState() {
+	if positions := c.Room.ReadPositions(); len(positions) > 0 {
+		c.trySend(mustMarshal(Message{Type: "read", Data: mustMarshal(ReadStateNotification{Positions: positions})}))
+	}
+}
+
+// readLeft drops the read position of a member that left, unless it is still
+// connected elsewhere. Clients learn of it from the leave notification.
+func (r *Room) readLeft(username string) {
+	r.mu.RLock()
+	for client := range r.Clients {
+		if client.Username == username {
+			r.mu.RUnlock()
+			return
+		}
+	}
+	r.mu.RUnlock()
+	r.reads.mu.Lock()
+	delete(r.reads.positions, username)
+	delete(r.reads.changed, username)
+	r.reads.mu.Unlock()
+}
+
+// renameRead keeps the read position of a member that changed its username
+func (r *Room) renameRead(from, to string) {
+	r.reads.mu.Lock()
+	defer r.reads.mu.Unlock()
+	seq, ok := r.reads.positions[from]
+	if !ok {
+		return
+	}
+	delete(r.reads.positions, from)
+	delete(r.reads.changed, from)
+	r.reads.positions[to] = max(seq, r.reads.positions[to])
+	r.reads.changed[to] = r.reads.positions[to]
+	if r.reads.flush == nil {
+		r.reads.flush = time.AfterFunc(ReadStateInterval, r.flushReads)
+	}
+}
+
+// handleReadMessage records the last broadcast the client displayed
+func (c *Client) handleReadMessage(message Message) {
+	var read ReadMessage
+	if err := json.Unmarshal(message.Data, &read); err != nil {
+		return
+	}
+	c.Room.MarkRead(c.Username, read.Seq)
+}
@@ -0,0 +1,136 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// pendingMember is a member whose connection dropped and who may still resume
+type pendingMember struct {
+	joinedAt  time.Time
+	timer     *time.Timer
+	username  string
+	sessionID string
+	member    string // authorID of the dropped connection
+	lastAck   uint64
+	isHost    bool
+}
+
+// WelcomeMessage Sent only to the joining client after registration
+// @Description Contains the resume token used to reconnect within the grace period without a leave/join
+type WelcomeMessage struct {
+	Username     string `json:"username" example:"JohnDoe"`
+	ResumeToken  string `json:"resume_token" example:"0b8f5a5e-5c1e-4a55-9f59-3f0c2b8d9a10"`
+	ConnectionID string `json:"connection_id" example:"5c1e0b8f-4a55-9f59-3f0c-2b8d9a105a5e"` // quote in bug reports to find the server logs
+	Resumed      bool   `json:"resumed" example:"false"`
+	IsHost       bool   `json:"is_host" example:"false"`
+}
+
+// ReconnectNotification Sent to clients when a member's connection drops or is resumed
+type ReconnectNotification struct {
+	Username string `json:"username" example:"JohnDoe"`
+}
+
+// WithReconnectGrace keeps a member for d after its connection drops before
+// announcing the leave, so it can resume with its token. Zero disables the grace period.
+func WithReconnectGrace(d time.Duration) RoomOption {
+	return func(r *Room) {
+		r.reconnectGrace = d
+	}
+}
+
+// resumePending claims the pending membership matching the client's resume token.
+// Caller must hold r.mu.
+func (r *Room) resumePending(client *Client) bool {
+	if client.resumeToken == "" {
+		return false
+	}
+	pending, ok := r.pending[client.resumeToken]
+	if !ok || pending.username != client.Username || pending.sessionID != client.SessionID {
+		return false
+	}
+	pending.timer.Stop()
+	delete(r.pending, client.resumeToken)
+	client.joinedAt = pending.joinedAt
+	r.moveHand(pending.member, client.authorID())
+	if client.lastAck.Load() == 0 {
+		client.lastAck.Store(pending.lastAck)
+	}
+	return true
+}
+
+// holdPending starts the grace period for a dropped client. Caller must hold r.mu.
+func (r *Room) holdPending(client *Client) bool {
+	if r.reconnectGrace <= 0 || client.resumeToken == "" {
+		return false
+	}
+	token := client.resumeToken
+	r.pending[token] = &pendingMember{
+		username:  client.Username,
+		sessionID: client.SessionID,
+		member:    client.authorID(),
+		joinedAt:  client.joinedAt,
+		lastAck:   client.lastAck.Load(),
+		isHost:    client.IsHost(),
+		timer:     time.AfterFunc(r.reconnectGrace, func() { r.expirePending(token) }),
+	}
+	return true
+}
+
+// expirePending announces the leave of a member that did not resume in time
+func (r *Room) expirePending(token string) {
+	r.mu.Lock()
+	pending, ok := r.pending[token]
+	if ok {
+		delete(r.pending, token)
+		r.touch()
+	}
+	r.mu.Unlock()
+
+	if ok {
+		r.appendAccess(AccessEntry{Action: AccessLeave, Username: pending.username})
+		r.broadcastNotification("leave", LeaveNotification{
+			Username:    pending.username,
+			OnlineCount: r.GetClientCount(),
+			naming:      r.naming,
+		})
+		r.handLeft(pending.member)
+		r.readLeft(pending.username)
+	}
+}
+
+// stopPending cancels all grace timers. Caller must hold r.mu.
+func (r *Room) stopPending() {
+	for token, pending := range r.pending {
+		pending.timer.Stop()
+		delete(r.pending, token)
+	}
+}
+
+// sendWelcome tells the client its resume token and connection ID
+func (c *Client) sendWelcome(resumed bool) {
+	c.trySend(mustMarshal(Message{Type: "welcome", Data: mustMarshal(WelcomeMessage{
+		Username:     c.Username,
+		ResumeToken:  c.resumeToken,
+		ConnectionID: c.ConnID,
+		Resumed:      resumed,
+		IsHost:       c.IsHost(),
+	})}))
+}
+
+// sendRoomWelcome shows the welcome message set by the host, if any, to the client only
+func (c *Client) sendRoomWelcome() {
+	welcome := c.Room.Settings().Welcome
+	if welcome == "" {
+		return
+	}
+	c.trySend(mustMarshal(Message{Type: "system", Data: mustMarshal(SystemNotification{
+		Text: c.Room.systemText(welcome),
+		Kind: "welcome",
+	})}))
+}
+
+func newResumeToken() string {
+	return uuid.New().String()
+}
@@ -0,0 +1,188 @@
+package websocket
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Resume state limits
+const (
+	ResumeStateTTL   = 24 * time.Hour // how long a state token may be used to resume
+	MaxResumeReplay  = 500            // history messages replayed on a state resume
+	stateTokenDomain = "resume-state."
+)
+
+// ErrInvalidStateToken is returned for state tokens that are malformed, forged or expired
+var ErrInvalidStateToken = errors.New("invalid or expired state token")
+
+// ResumeState is the client state a state token carries, enough to resume into
+// the same identity on any instance sharing the signing key, even after a restart
+// that restored the room from a snapshot. The token is bound to the room it was
+// issued in and to its password, so a later room with the same ID or a password
+// change invalidates it.
+type ResumeState struct {
+	ExpiresAt   time.Time `json:"exp"`
+	Username    string    `json:"u"`
+	SessionID   string    `json:"s,omitempty"`
+	Instance    string    `json:"i"`
+	PasswordGen uint64    `json:"pg,omitempty"`
+	RoomID      ID        `json:"r"`
+	Cursor      uint64    `json:"c,omitempty"` // ID of the last history message the client has
+}
+
+// StateMessage Sent to the client with a fresh state token after it joins and on request
+// @Description Pass the token as the state query parameter to resume after a server restart
+type StateMessage struct {
+	ExpiresAt  time.Time `json:"expires_at" example:"2024-01-02T12:00:00Z"`
+	StateToken string    `json:"state_token" example:"eyJleHAiOi....3q2-1BrD"`
+	Cursor     uint64    `json:"cursor" example:"42"`
+}
+
+// StateRequest Payload of a client asking for a fresh state token
+type StateRequest struct {
+	Cursor uint64 `json:"cursor" example:"42"` // ID of the last history message the client has
+}
+
+// HistoryReplay Sent to a client resuming with a state token, the history messages after its cursor
+type HistoryReplay struct {
+	Messages  []StoredMessage `json:"messages"`
+	Truncated bool            `json:"truncated,omitempty" example:"false"` // older messages past MaxResumeReplay were left out
+}
+
+// StateTokens signs and verifies state tokens. A token is "<payload>.<hmac>" where the
+// payload is the JSON encoded ResumeState, so the server keeps no session in memory.
+type StateTokens struct {
+	key []byte
+	ttl time.Duration
+}
+
+// NewStateTokens creates tokens valid for ttl signed with key. A nil key is replaced
+// by a random one, so tokens only verify until this instance restarts.
+func NewStateTokens(key []byte, ttl time.Duration) *StateTokens {
+	if key == nil {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	return &StateTokens{key: key, ttl: ttl}
+}
+
+// Issue signs state, setting its expiry
+func (t *StateTokens) Issue(state ResumeState, now time.Time) (string, ResumeState) {
+	state.ExpiresAt = now.Add(t.ttl).Truncate(time.Second).UTC()
+	encoded := base64.RawURLEncoding.EncodeToString(mustMarshal(state))
+	return encoded + "." + t.sign(encoded), state
+}
+
+// Verify returns the state of token if it is authentic and not expired
+func (t *StateTokens) Verify(token string, now time.Time) (ResumeState, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(t.sign(encoded)), []byte(signature)) {
+		return ResumeState{}, ErrInvalidStateToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ResumeState{}, ErrInvalidStateToken
+	}
+	var state ResumeState
+	if err := json.Unmarshal(payload, &state); err != nil || !now.Before(state.ExpiresAt) {
+		return ResumeState{}, ErrInvalidStateToken
+	}
+	return state, nil
+}
+
+// sign keeps state tokens apart from join tickets signed with the same key
+func (t *StateTokens) sign(encoded string) string {
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write([]byte(stateTokenDomain + encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// admitsState reports whether state was issued in this room with its current password
+func (r *Room) admitsState(state ResumeState) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return state.RoomID == r.ID && state.Instance == r.instance && state.PasswordGen == r.passwordGen
+}
+
+// pendingToken returns the resume token of the pending membership of username in
+// session, if any
+func (r *Room) pendingToken(username, sessionID string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for token, pending := range r.pending {
+		if pending.username == username && pending.sessionID == sessionID {
+			return token
+		}
+	}
+	return ""
+}
+
+// lastMessageID returns the ID of the newest history message
+func (r *Room) lastMessageID() uint64 {
+	r.history.mu.RLock()
+	defer r.history.mu.RUnlock()
+	return r.history.nextID
+}
+
+// sendState sends the client a fresh state token with cursor, clamped to the newest
+// history message
+func (c *Client) sendState(cursor uint64) {
+	if c.states == nil {
+		return
+	}
+	cursor = min(cursor, c.Room.lastMessageID())
+	c.Room.mu.RLock()
+	instance, passwordGen := c.Room.instance, c.Room.passwordGen
+	c.Room.mu.RUnlock()
+	token, state := c.states.Issue(ResumeState{
+		RoomID:      c.Room.ID,
+		Instance:    instance,
+		PasswordGen: passwordGen,
+		Username:    c.Username,
+		SessionID:   c.SessionID,
+		Cursor:      cursor,
+	}, time.Now())
+	c.trySend(mustMarshal(Message{Type: "state", Data: mustMarshal(StateMessage{
+		StateToken: token,
+		Cursor:     cursor,
+		ExpiresAt:  state.ExpiresAt,
+	})}))
+}
+
+// handleStateMessage refreshes the state token of the client. The cursor is the
+// client's to choose: it only decides which history is replayed on resume.
+func (c *Client) handleStateMessage(message Message) {
+	var req StateRequest
+	if len(message.Data) > 0 {
+		if err := json.Unmarshal(message.Data, &req); err != nil {
+			return
+		}
+	}
+	c.sendState(req.Cursor)
+}
+
+// replayHistory sends the client the history messages after cursor, at most
+// MaxResumeReplay of the newest ones
+func (c *Client) replayHistory(cursor uint64) {
+	var messages []StoredMessage
+	for _, msg := range c.Room.History(time.Time{}, time.Time{}) {
+		if msg.ID > cursor {
+			messages = append(messages, msg)
+		}
+	}
+	replay := HistoryReplay{Messages: messages}
+	if len(messages) > MaxResumeReplay {
+		replay.Messages = messages[len(messages)-MaxResumeReplay:]
+		replay.Truncated = true
+	}
+	if replay.Messages == nil {
+		replay.Messages = []StoredMessage{}
+	}
+	c.trySend(mustMarshal(Message{Type: "history", Data: mustMarshal(replay)}))
+}
@@ -1,43 +1,145 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
+	"slices"
 	"strconv"
 	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type ID uint32
 
 type MetricsNotifier interface {
 	DroppedMessage(roomID string, clientID string)
+	RTTObserved(roomID string, rtt time.Duration)
+	ThrottledMessage(roomID string)
+	RateLimitedMessage(roomID string, clientID string)
+	StageObserved(roomID string, stage string, d time.Duration)
+	ConnectionReaped(roomID string, reason string)
+	SlowConsumer(roomID string, policy string)
+	MessageShed(roomID string, priority string)
+	BroadcastObserved(roomID string, recipients int, d time.Duration)
+	MessageBroadcast(roomID string)
+	ClientJoined(roomID string, clientID string)
+	ClientLeft(roomID string, clientID string)
+	RoomCreated(roomID string)
+	RoomDeleted(roomID string)
+}
+
+// ContextMetricsNotifier is implemented by a MetricsNotifier that links pipeline
+// timings to the trace they were taken in, for example with histogram exemplars.
+// Rooms call it instead of StageObserved and BroadcastObserved.
+type ContextMetricsNotifier interface {
+	StageObservedContext(ctx context.Context, roomID string, stage string, d time.Duration)
+	BroadcastObservedContext(ctx context.Context, roomID string, recipients int, d time.Duration)
 }
 
 // RoomOption represents a functional option for configuring a Room.
 type RoomOption func(*Room)
 
 type Room struct {
-	Metrics        MetricsNotifier
-	Clients        map[*Client]bool
-	Register       chan *Client
-	Unregister     chan *Client
-	Broadcast      chan []byte
-	Stop           chan struct{}
-	HostID         string
-	HashedPassword string
-	mu             sync.RWMutex
-	stopOnce       sync.Once
-	ID             ID
+	Metrics         MetricsNotifier
+	Clients         map[*Client]bool
+	Register        chan *Client
+	Unregister      chan *Client
+	Broadcast       chan []byte
+	broadcasts      chan broadcast // from enqueue, with the context of the message behind them
+	Stop            chan struct{}
+	HashedPassword  string
+	mu              sync.RWMutex
+	seqMu           sync.Mutex // serializes sequencing and delivery of broadcasts; taken before mu
+	replay          replayBuffer
+	prepared        preparedCache // frames of the latest broadcasts, shared by the write loops
+	stopOnce        sync.Once
+	settings        RoomSettings
+	pending         map[string]*pendingMember
+	quota           *tokenBucket
+	imports         *tokenBucket // budget of imported history messages, created on first import
+	credits         sendCredits
+	clientLimit     int
+	clientWindow    time.Duration
+	audit           passwordAuditLog
+	history         messageHistory
+	broker          Broker
+	bus             *EventBus
+	connections     *ConnectionRegistry // nil if the room is not part of a hub
+	naming          WireNaming
+	branding        *Branding // nil for the built-in names
+	slowConsumer    SlowConsumerPolicy
+	spoolDir        string
+	spoolBudget     *SpoolBudget
+	filter          roomContentFilter
+	modRules        []modRule
+	unsubscribe     func()
+	lastActivity    time.Time
+	clock           func() time.Time // nil for time.Now
+	tenants         *TenantTracker
+	hooks           map[string]*BotHook
+	hands           []RaisedHand // raised-hand queue in raising order
+	reads           readReceipts
+	webhooks        map[string]*roomWebhook
+	hostIDs         []string // hosts of the room, the creator first
+	bans            map[string]*Ban
+	muted           map[string]bool // usernames of muted members
+	events          eventLog
+	access          accessLog
+	transfers       map[string]*fileTransfer
+	uploads         map[string]Upload // uploads available for attachments, keyed by URL
+	pendingUploads  int               // reserved upload slots whose files are being stored
+	sharedUploads   bool              // files are kept in storage every instance reaches
+	tags            []string
+	metadata        map[string]string
+	preferences     map[string]MemberPreferences // keyed by session ID
+	dispatchHook    HookDispatcher
+	dispatchWebhook RoomWebhookDispatcher
+	releaseTenant   func()
+	tenant          string
+	frozenUntil     time.Time
+	unfreezeTimer   *time.Timer
+	freezeGen       uint64 // bumped by every freeze and unfreeze, so stale timers do nothing
+	closesAt        time.Time
+	closeCancel     chan struct{} // closed to abandon a scheduled close
+	reconnectGrace  time.Duration
+	settingsVersion uint64
+	limitsVersion   uint64                  // incremented when SetRateLimits replaces the rate limits
+	limiters        map[string]*tokenBucket // message budgets shared by pseudonym
+	limitersVersion uint64                  // limitsVersion the budgets were created with
+	limitersMu      sync.Mutex
+	moderation      ModerationStore // nil if the moderation state is not persisted
+	moderationMu    sync.Mutex      // serializes loads and saves of the moderation state
+	moderationAt    time.Time       // last load or save, guarded by moderationMu
+	restored        bool            // created from a snapshot of another instance
+	instance        string          // random ID of this room, kept across migrations but not shared with later rooms of the same ID
+	passwordGen     uint64          // incremented whenever the password changes
+	frozen          bool
+	locked          bool // no new members may join
+	migrating       bool
+	ordered         bool
+	ID              ID
 }
 
 func NewRoom(id ID, metrics MetricsNotifier, opts ...RoomOption) *Room {
 	room := &Room{
-		ID:         id,
-		Clients:    make(map[*Client]bool, 50),
-		Register:   make(chan *Client, 100),
-		Unregister: make(chan *Client, 100),
-		Broadcast:  make(chan []byte, 100),
-		Stop:       make(chan struct{}, 1),
-		Metrics:    metrics,
+		ID:           id,
+		Clients:      make(map[*Client]bool, 50),
+		Register:     make(chan *Client, 100),
+		Unregister:   make(chan *Client, 100),
+		Broadcast:    make(chan []byte, 100),
+		broadcasts:   make(chan broadcast, 100),
+		Stop:         make(chan struct{}, 1),
+		Metrics:      metrics,
+		pending:      make(map[string]*pendingMember),
+		settings:     RoomSettings{Visibility: VisibilityPrivate},
+		history:      messageHistory{limit: DefaultHistoryLimit},
+		lastActivity: time.Now(),
+		replay:       newReplayBuffer(ReplayBufferSize),
+		naming:       NamingCompat,
+		instance:     uuid.New().String(),
 	}
 
 	for _, opt := range opts {
@@ -47,10 +149,10 @@ func NewRoom(id ID, metrics MetricsNotifier, opts ...RoomOption) *Room {
 	return room
 }
 
-// WithHost sets the HostID of the room.
+// WithHost adds hostID to the hosts of the room.
 func WithHost(hostID string) RoomOption {
 	return func(r *Room) {
-		r.HostID = hostID
+		r.hostIDs = append(r.hostIDs, hostID)
 	}
 }
 
@@ -61,6 +163,21 @@ func WithPassword(hashedPassword string) RoomOption {
 	}
 }
 
+// WithClock makes the room read the time of imports from now instead of time.Now
+func WithClock(now func() time.Time) RoomOption {
+	return func(r *Room) {
+		r.clock = now
+	}
+}
+
+// now returns the current time of the room's clock
+func (r *Room) now() time.Time {
+	if r.clock == nil {
+		return time.Now()
+	}
+	return r.clock()
+}
+
 func (r *Room) Run() {
 	for {
 		select {
@@ -69,7 +186,9 @@ func (r *Room) Run() {
 		case client := <-r.Unregister:
 			r.removeClient(client)
 		case msg := <-r.Broadcast:
-			r.sendMessage(msg)
+			r.publish(context.Background(), msg)
+		case b := <-r.broadcasts:
+			r.publish(b.ctx, b.msg)
 		case <-r.Stop:
 			return
 		}
@@ -77,59 +196,181 @@ func (r *Room) Run() {
 }
 
 func (r *Room) addClient(client *Client) {
+	r.mu.Lock()
+	if !client.IsHost() && !r.hasCapacity(client.resumeToken) {
+		client.departed = true
+		r.mu.Unlock()
+		client.rejectFull()
+		return
+	}
+	// The handler checked the lock before upgrading; the room may have locked since
+	if !client.IsHost() && !r.admitsJoin(client.resumeToken) {
+		client.departed = true
+		r.mu.Unlock()
+		client.rejectLocked()
+		return
+	}
+	resumable := r.reconnectGrace > 0
+	resumed := resumable && r.resumePending(client)
+	if resumable && !resumed {
+		client.resumeToken = newResumeToken()
+	}
+	if !resumed {
+		client.joinedAt = time.Now()
+	}
+	r.mu.Unlock()
+
+	// Register and replay under seqMu so no broadcast is missed or delivered twice
+	r.seqMu.Lock()
 	r.mu.Lock()
 	r.Clients[client] = true
+	r.connections.add(client)
+	if r.muted[client.Username] && !client.IsHost() {
+		client.muted.Store(true)
+	}
+	r.touch()
 	r.mu.Unlock()
-	r.broadcastJoinNotification(client)
+	if r.Metrics != nil {
+		r.Metrics.ClientJoined(strconv.Itoa(int(r.ID)), client.Username)
+	}
+	replayed := true
+	if since := client.lastAck.Load(); resumed || since > 0 {
+		replayed = r.replaySince(client, since)
+	}
+	r.seqMu.Unlock()
+	if !replayed {
+		// The client resumes again from its last ack rather than skip broadcasts
+		r.dropClients([]*Client{client})
+		return
+	}
+
+	if resumable {
+		client.sendWelcome(resumed)
+	}
+	if client.resumeState != nil {
+		client.replayHistory(client.resumeState.Cursor)
+	}
+	client.sendState(r.lastMessageID())
+	client.grantCredits(time.Now())
+	if resumed {
+		r.broadcastNotification("reconnected", ReconnectNotification{Username: client.Username})
+	} else {
+		r.logAccess(AccessJoin, client, "")
+		r.broadcastJoinNotification(client)
+		r.emit(ServerEventUserJoined, client.Username, "")
+		client.sendRoomWelcome()
+	}
+	client.sendMembers()
+	client.sendPreferences()
+	client.sendHandQueue()
+	client.sendReadState()
 }
 
+// removeClient unregisters the client and announces its leave once.
+// If the connection dropped while the client was still a member, the leave
+// is delayed by the reconnect grace period.
 func (r *Room) removeClient(client *Client) {
 	r.mu.Lock()
-	if _, ok := r.Clients[client]; ok {
+	_, registered := r.Clients[client]
+	if registered {
 		delete(r.Clients, client)
-		client.closeOnce.Do(func() {
-			close(client.Send)
-		})
+		client.closeSend()
+		r.clientLeft(client)
 	}
+	firstRemoval := !client.departed
+	client.departed = true
+	r.touch()
+	held := registered && firstRemoval && r.holdPending(client)
 	r.mu.Unlock()
+
+	if !firstRemoval {
+		return
+	}
+	if held {
+		r.broadcastNotification("reconnecting", ReconnectNotification{Username: client.Username})
+		return
+	}
+	r.logAccess(AccessLeave, client, "")
 	r.broadcastLeaveNotification(client)
+	r.handLeft(client.authorID())
+	r.readLeft(client.Username)
 }
 
-func (r *Room) sendMessage(msg []byte) {
-	r.mu.RLock()
-	clients := make([]*Client, 0, len(r.Clients))
-	for client := range r.Clients {
-		clients = append(clients, client)
-	}
-	r.mu.RUnlock()
+// sendMessage delivers msg to all clients. Sends are non-blocking and done under
+// the read lock so that a concurrent removal cannot close Send mid-delivery.
+// Congested clients are served by priority, see deliverPriority, and clients with
+// a full queue are handled by the slow-consumer policy of the room.
+// The message is framed once for all write loops, see preparedCache. ctx carries
+// the span of the connection the message came from, if any. seq is the sequence
+// number assigned by the broker, zero to assign the next one.
+func (r *Room) sendMessage(ctx context.Context, msg []byte, seq uint64) {
+	ctx, span := r.startFanoutSpan(ctx)
+	defer span.End()
+	defer r.traceStage(ctx, StageFanout)()
+	start := time.Now()
 
+	r.seqMu.Lock()
+	msg, priority := r.stamp(msg, seq)
+	if msg == nil {
+		r.seqMu.Unlock()
+		return
+	}
 	var dropped []*Client
-	for _, client := range clients {
-		if client.isClosed() {
-			dropped = append(dropped, client)
+	recipients := 0
+	r.mu.RLock()
+	if len(r.Clients) > 1 {
+		r.prepared.add(msg)
+	}
+	for client := range r.Clients {
+		if r.deliverPriority(client, msg, priority) {
+			recipients++
 			continue
 		}
-		select {
-		case client.Send <- msg:
-		default:
-			dropped = append(dropped, client)
+		if !client.isClosed() {
+			r.slowConsumerHandled(SlowConsumerDisconnect)
 		}
+		dropped = append(dropped, client)
 	}
+	r.mu.RUnlock()
+	r.seqMu.Unlock()
 
-	if len(dropped) > 0 {
-		r.mu.Lock()
-		for _, client := range dropped {
-			if _, ok := r.Clients[client]; ok {
-				delete(r.Clients, client)
-				client.closeOnce.Do(func() {
-					close(client.Send)
-				})
-				if r.Metrics != nil {
-					r.Metrics.DroppedMessage(strconv.Itoa(int(r.ID)), client.Username)
-				}
+	span.SetAttributes(attribute.Int("chatters.recipients", recipients))
+	if r.Metrics != nil {
+		roomID := strconv.Itoa(int(r.ID))
+		r.Metrics.MessageBroadcast(roomID)
+		r.broadcastObserved(ctx, roomID, recipients, time.Since(start))
+	}
+	r.dropClients(dropped)
+}
+
+// dropClients disconnects clients that could not keep up with broadcasts.
+// Their connections are closed with ClosePolicyViolation in the background.
+func (r *Room) dropClients(dropped []*Client) {
+	if len(dropped) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, client := range dropped {
+		if _, ok := r.Clients[client]; ok {
+			delete(r.Clients, client)
+			client.closeSendWith(ClosePolicyViolation)
+			go client.closeWith(ClosePolicyViolation)
+			r.clientLeft(client)
+			if r.Metrics != nil {
+				r.Metrics.DroppedMessage(strconv.Itoa(int(r.ID)), client.Username)
 			}
+			r.emit(ServerEventMessageDropped, client.Username, "")
 		}
-		r.mu.Unlock()
+	}
+}
+
+// clientLeft reports a client removed from r.Clients to the metrics and the
+// connection registry
+func (r *Room) clientLeft(client *Client) {
+	r.connections.remove(client)
+	if r.Metrics != nil {
+		r.Metrics.ClientLeft(strconv.Itoa(int(r.ID)), client.Username)
 	}
 }
 
@@ -137,6 +378,7 @@ func (r *Room) broadcastJoinNotification(client *Client) {
 	r.broadcastNotification("join", JoinNotification{
 		Username:    client.Username,
 		OnlineCount: r.GetClientCount(),
+		naming:      r.naming,
 	})
 }
 
@@ -144,40 +386,93 @@ func (r *Room) broadcastLeaveNotification(client *Client) {
 	r.broadcastNotification("leave", LeaveNotification{
 		Username:    client.Username,
 		OnlineCount: r.GetClientCount(),
+		naming:      r.naming,
 	})
 }
 
+// broadcastNotification sends a room notification to all clients. Clients with a full
+// queue miss it, unless the room uses ordered delivery or another slow-consumer policy
+// than disconnect, which then applies. Rooms on a broker publish it like other
+// broadcasts, so it takes the same sequence number on every instance.
 func (r *Room) broadcastNotification(msgType string, payload interface{}) {
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return
 	}
-	msg := Message{Type: msgType, Data: data}
-	msgBytes, _ := json.Marshal(msg)
-
+	if feedNotifications[msgType] {
+		r.events.append(msgType, data, time.Now())
+	}
+	if r.broker != nil {
+		r.publish(context.Background(), mustMarshal(Message{Type: msgType, Data: data}))
+		return
+	}
+	r.seqMu.Lock()
+	msgBytes := r.stampEnvelope(Message{Type: msgType, Data: data}, 0)
+	if msgBytes == nil {
+		r.seqMu.Unlock()
+		return
+	}
+	priority := priorityOf(msgType)
+	var dropped []*Client
 	r.mu.RLock()
-	defer r.mu.RUnlock()
+	if len(r.Clients) > 1 {
+		r.prepared.add(msgBytes)
+	}
 	for client := range r.Clients {
-		select {
-		case client.Send <- msgBytes:
-		default:
+		if r.deliverPriority(client, msgBytes, priority) || !r.ordered && r.SlowConsumer() == SlowConsumerDisconnect {
+			continue
+		}
+		if !client.isClosed() {
+			r.slowConsumerHandled(SlowConsumerDisconnect)
 		}
+		dropped = append(dropped, client)
 	}
+	r.mu.RUnlock()
+	r.seqMu.Unlock()
+
+	r.dropClients(dropped)
 }
 
+// StopRoom stops the room and closes every connection with CloseRoomDeleted
 func (r *Room) StopRoom() {
+	r.stop(CloseRoomDeleted)
+}
+
+// Shutdown stops the room and closes every connection with CloseServerShutdown
+func (r *Room) Shutdown() {
+	r.stop(CloseServerShutdown)
+}
+
+// stop stops the room once. Connections are closed with code after the room lock
+// is released, and stop returns when their close frames are written.
+func (r *Room) stop(code int) {
+	var clients []*Client
 	r.stopOnce.Do(func() {
 		close(r.Stop)
 		r.mu.Lock()
 		defer r.mu.Unlock()
+		if r.unfreezeTimer != nil {
+			r.unfreezeTimer.Stop()
+		}
+		r.stopPending()
+		if r.unsubscribe != nil {
+			r.unsubscribe()
+		}
+		if r.releaseTenant != nil {
+			r.releaseTenant()
+		}
 		for client := range r.Clients {
-			client.closeOnce.Do(func() {
-				close(client.Send)
-			})
-			client.Conn.Close()
+			client.closeSendWith(code)
+			r.clientLeft(client)
+			clients = append(clients, client)
 		}
 		r.Clients = make(map[*Client]bool)
+		if r.Metrics != nil {
+			r.Metrics.RoomDeleted(strconv.Itoa(int(r.ID)))
+		}
+		r.emit(ServerEventRoomDeleted, "", "")
 	})
+	closeAll(clients, code)
 }
 
 func (r *Room) GetClientCount() int {
@@ -193,66 +488,162 @@ func (r *Room) HasPassword() bool {
 	return r.HashedPassword != ""
 }
 
-// GetHostID returns the host ID of the room
-func (r *Room) GetHostID() string {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	return r.HostID
-}
-
 // SetPassword updates the room's hashed password
 func (r *Room) SetPassword(hashedPassword string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if r.HashedPassword != hashedPassword {
+		r.passwordGen++
+	}
 	r.HashedPassword = hashedPassword
+	r.settingsVersion++
+}
+
+// SettingsVersion returns the current version of the room settings.
+// It is incremented on every settings change and used for optimistic concurrency.
+func (r *Room) SettingsVersion() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.settingsVersion
+}
+
+// SetPasswordIfVersion updates the password only if the settings version still
+// equals expected. It returns the resulting version and whether the update was applied.
+func (r *Room) SetPasswordIfVersion(hashedPassword string, expected uint64) (uint64, bool) {
+	return r.UpdateSettings(SettingsUpdate{HashedPassword: &hashedPassword}, expected)
+}
+
+// Settings returns a copy of the current room settings
+func (r *Room) Settings() RoomSettings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.settings
+}
+
+// UpdateSettings atomically applies update if the settings version still equals expected
+// and notifies connected clients. It returns the resulting version and whether the
+// update was applied.
+func (r *Room) UpdateSettings(update SettingsUpdate, expected uint64) (uint64, bool) {
+	r.mu.Lock()
+	if r.settingsVersion != expected {
+		version := r.settingsVersion
+		r.mu.Unlock()
+		return version, false
+	}
+	update.apply(r)
+	r.settingsVersion++
+	version, settings := r.settingsVersion, r.settings
+	r.mu.Unlock()
+
+	r.broadcastNotification("settings", settings.notification())
+	return version, true
 }
 
-// KickClient removes a client from the room by username
+// KickClient removes a client from the room by username and closes its
+// connection with CloseKicked
 func (r *Room) KickClient(username string) bool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	for client := range r.Clients {
 		if client.Username == username {
-			go func(c *Client) { r.Unregister <- c }(client)
+			go func(c *Client) {
+				c.closeWith(CloseKicked)
+				r.Unregister <- c
+			}(client)
 			return true
 		}
 	}
 	return false
 }
 
-// sendExcept sends message to all clients except the sender.
-// It copies client pointers under lock, then sends outside the lock.
-func (r *Room) sendExcept(sender *Client, msg []byte) {
-	r.mu.RLock()
-	clients := make([]*Client, 0, len(r.Clients))
+// KickResult reports the outcome of kicking a single username
+type KickResult struct {
+	Username string
+	Reason   string
+	Kicked   bool
+}
+
+// KickClients atomically removes every client whose username is listed and
+// notifies the room. Hosts are never kicked. Results are returned in input order.
+func (r *Room) KickClients(usernames []string, kickedBy string) []KickResult {
+	results := make([]KickResult, len(usernames))
+	index := make(map[string]int, len(usernames))
+	for i, username := range usernames {
+		results[i] = KickResult{Username: username, Reason: "user not found in room"}
+		index[username] = i
+	}
+
+	var removed []*Client
+	r.mu.Lock()
 	for client := range r.Clients {
-		if client == sender {
+		i, ok := index[client.Username]
+		if !ok {
+			continue
+		}
+		if client.IsHost() {
+			results[i].Reason = "host cannot be kicked"
 			continue
 		}
-		clients = append(clients, client)
+		delete(r.Clients, client)
+		client.closeSendWith(CloseKicked)
+		r.clientLeft(client)
+		removed = append(removed, client)
+		results[i].Kicked = true
+		results[i].Reason = ""
+	}
+	r.mu.Unlock()
+
+	for _, client := range removed {
+		r.logAccess(AccessKick, client, kickedBy)
+	}
+	closeAll(removed, CloseKicked)
+	for i := range results {
+		if results[i].Kicked {
+			r.broadcastNotification("kick", KickNotification{
+				TargetUsername: results[i].Username,
+				KickedBy:       kickedBy,
+			})
+			r.emit(ServerEventUserKicked, results[i].Username, kickedBy)
+		}
+	}
+	return results
+}
+
+// Disconnect closes the connections of every client whose username is listed,
+// hosts included, with ClosePolicyViolation and returns how many were closed.
+// Unlike a kick it is not announced; members see the usual leave, and the
+// clients may reconnect.
+func (r *Room) Disconnect(usernames []string) int {
+	r.mu.RLock()
+	var targets []*Client
+	for client := range r.Clients {
+		if slices.Contains(usernames, client.Username) {
+			targets = append(targets, client)
+		}
 	}
 	r.mu.RUnlock()
 
+	closeAll(targets, ClosePolicyViolation)
+	return len(targets)
+}
+
+// sendExcept sends message to all clients except the sender. It sends under
+// the room lock, which keeps the Send channels of registered clients open.
+func (r *Room) sendExcept(sender *Client, msg []byte) {
 	var dropped []*Client
-	for _, client := range clients {
+	r.mu.RLock()
+	for client := range r.Clients {
+		if client == sender || client.isClosed() {
+			continue
+		}
 		select {
 		case client.Send <- msg:
 		default:
 			dropped = append(dropped, client)
 		}
 	}
+	r.mu.RUnlock()
 
-	if len(dropped) > 0 {
-		r.mu.Lock()
-		for _, client := range dropped {
-			if _, ok := r.Clients[client]; ok {
-				delete(r.Clients, client)
-				client.closeOnce.Do(func() {
-					close(client.Send)
-				})
-			}
-		}
-		r.mu.Unlock()
-	}
+	r.dropClients(dropped)
 }
@@ -0,0 +1,152 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Room webhook limits
+const (
+	MaxRoomWebhooks = 3
+	// RoomWebhookRate is how many messages per second a room webhook receives.
+	// Messages beyond it are skipped and counted in the next delivery.
+	RoomWebhookRate = 20
+)
+
+var (
+	ErrTooManyRoomWebhooks = errors.New("room has too many webhooks")
+	ErrRoomWebhookNotFound = errors.New("webhook not found")
+	ErrInsecureWebhookURL  = errors.New("webhook URL must use https")
+)
+
+// RoomWebhook mirrors every chat message of a room to an external endpoint,
+// e.g. for logging or compliance archives
+type RoomWebhook struct {
+	CreatedAt time.Time `json:"created_at" example:"2024-01-01T12:00:00Z"`
+	ID        string    `json:"id" example:"3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"`
+	URL       string    `json:"url" example:"https://archive.example.com/chatters"`
+}
+
+// RoomMessageEvent is delivered to the room webhooks for every chat message
+type RoomMessageEvent struct {
+	SentAt    time.Time `json:"sent_at" example:"2024-01-01T12:00:00Z"`
+	WebhookID string    `json:"webhook_id" example:"3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"`
+	Username  string    `json:"username" example:"JohnDoe"`
+	Text      string    `json:"text" example:"Hello, world!"`
+	RoomID    ID        `json:"room_id" example:"123456"`
+	MessageID uint64    `json:"message_id" example:"42"`
+	// Dropped counts the messages skipped by the rate limit since the previous delivery
+	Dropped uint64 `json:"dropped,omitempty" example:"0"`
+}
+
+// RoomWebhookDispatcher delivers room message events signed with secret. It must not block.
+type RoomWebhookDispatcher func(hook RoomWebhook, secret string, event RoomMessageEvent)
+
+// roomWebhook is a registered webhook with its signing secret and delivery budget
+type roomWebhook struct {
+	RoomWebhook
+	secret  string
+	limiter *tokenBucket
+	dropped atomic.Uint64
+}
+
+// WithRoomWebhookDispatcher sets how room message events leave the room
+func WithRoomWebhookDispatcher(dispatch RoomWebhookDispatcher) RoomOption {
+	return func(r *Room) {
+		r.dispatchWebhook = dispatch
+	}
+}
+
+// AddWebhook registers url to receive every chat message of the room and returns
+// it with the secret its deliveries are signed with. Messages only leave over https.
+func (r *Room) AddWebhook(rawURL string) (RoomWebhook, string, error) {
+	if u, err := url.Parse(rawURL); err != nil || u.Scheme != "https" {
+		return RoomWebhook{}, "", ErrInsecureWebhookURL
+	}
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return RoomWebhook{}, "", err
+	}
+	hook := &roomWebhook{
+		RoomWebhook: RoomWebhook{
+			ID:        uuid.New().String(),
+			URL:       rawURL,
+			CreatedAt: time.Now(),
+		},
+		secret:  hex.EncodeToString(secretBytes),
+		limiter: newTokenBucket(RoomWebhookRate, time.Second),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.webhooks) >= MaxRoomWebhooks {
+		return RoomWebhook{}, "", ErrTooManyRoomWebhooks
+	}
+	if r.webhooks == nil {
+		r.webhooks = make(map[string]*roomWebhook)
+	}
+	r.webhooks[hook.ID] = hook
+	return hook.RoomWebhook, hook.secret, nil
+}
+
+// RemoveWebhook unregisters a room webhook
+func (r *Room) RemoveWebhook(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.webhooks[id]; !ok {
+		return false
+	}
+	delete(r.webhooks, id)
+	return true
+}
+
+// Webhooks returns the registered room webhooks, oldest first
+func (r *Room) Webhooks() []RoomWebhook {
+	r.mu.RLock()
+	hooks := make([]RoomWebhook, 0, len(r.webhooks))
+	for _, hook := range r.webhooks {
+		hooks = append(hooks, hook.RoomWebhook)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].CreatedAt.Before(hooks[j].CreatedAt) })
+	return hooks
+}
+
+// mirrorMessage delivers a chat message to every room webhook with budget left
+func (r *Room) mirrorMessage(msg StoredMessage) {
+	r.mu.RLock()
+	dispatch := r.dispatchWebhook
+	hooks := make([]*roomWebhook, 0, len(r.webhooks))
+	for _, hook := range r.webhooks {
+		hooks = append(hooks, hook)
+	}
+	r.mu.RUnlock()
+	if dispatch == nil {
+		return
+	}
+
+	now := time.Now()
+	for _, hook := range hooks {
+		if _, ok := hook.limiter.take(now); !ok {
+			hook.dropped.Add(1)
+			continue
+		}
+		dispatch(hook.RoomWebhook, hook.secret, RoomMessageEvent{
+			WebhookID: hook.ID,
+			RoomID:    r.ID,
+			MessageID: msg.ID,
+			Username:  msg.Username,
+			Text:      msg.Text,
+			SentAt:    msg.SentAt,
+			Dropped:   hook.dropped.Swap(0),
+		})
+	}
+}
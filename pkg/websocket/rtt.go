@@ -0,0 +1,135 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RTTStats summarizes round-trip times of the clients in a room
+type RTTStats struct {
+	P50     time.Duration
+	P99     time.Duration
+	Samples int
+}
+
+// PingPayload App-level ping/pong payload
+// @Description Clients send "ping" with an arbitrary timestamp and receive it back in "pong"
+type PingPayload struct {
+	ClientTime int64 `json:"client_time,omitempty" example:"1718000000000"`
+	ServerTime int64 `json:"server_time,omitempty" example:"1718000000042"`
+	RTTMs      int64 `json:"rtt_ms,omitempty" example:"42"`
+}
+
+// maxPendingPings bounds the pings awaiting a pong; the oldest is forgotten first
+const maxPendingPings = 8
+
+// pendingPings maps the random nonces of unanswered pings to when they were sent,
+// so round-trip times only come from pongs echoing a ping the server sent
+type pendingPings struct {
+	sent map[string]time.Time
+	mu   sync.Mutex
+}
+
+// add returns the payload of a ping sent at now
+func (p *pendingPings) add(now time.Time) []byte {
+	var nonce [8]byte
+	_, _ = rand.Read(nonce[:])
+	key := hex.EncodeToString(nonce[:])
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sent == nil {
+		p.sent = make(map[string]time.Time, maxPendingPings)
+	}
+	if len(p.sent) >= maxPendingPings {
+		oldest := ""
+		for k, sentAt := range p.sent {
+			if oldest == "" || sentAt.Before(p.sent[oldest]) {
+				oldest = k
+			}
+		}
+		delete(p.sent, oldest)
+	}
+	p.sent[key] = now
+	return []byte(key)
+}
+
+// take forgets the ping with payload nonce and returns when it was sent
+func (p *pendingPings) take(nonce string) (time.Time, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sentAt, ok := p.sent[nonce]
+	delete(p.sent, nonce)
+	return sentAt, ok
+}
+
+// handlePong records the round-trip time from a pong echoing a pending ping and
+// re-rates the connection quality. Pongs to unknown pings are ignored.
+func (c *Client) handlePong(appData string) {
+	sentAt, ok := c.pings.take(appData)
+	if !ok {
+		return
+	}
+	rtt := time.Since(sentAt)
+	c.rtt.Store(int64(rtt))
+	c.pingsPending.Store(0)
+	if c.Room.Metrics != nil {
+		c.Room.Metrics.RTTObserved(strconv.Itoa(int(c.Room.ID)), rtt)
+	}
+	c.checkQuality()
+}
+
+// RTT returns the last measured round-trip time, or zero if unknown
+func (c *Client) RTT() time.Duration {
+	return time.Duration(c.rtt.Load())
+}
+
+// handlePingMessage answers an app-level ping with a pong to the sender only
+func (c *Client) handlePingMessage(message Message) {
+	var ping PingPayload
+	_ = json.Unmarshal(message.Data, &ping)
+
+	pong := PingPayload{
+		ClientTime: ping.ClientTime,
+		ServerTime: time.Now().UnixMilli(),
+		RTTMs:      c.RTT().Milliseconds(),
+	}
+	data, _ := json.Marshal(pong)
+	c.trySend(mustMarshal(Message{Type: "pong", Data: data}))
+}
+
+// RTTStats returns p50/p99 of the last measured RTT of every client in the room
+func (r *Room) RTTStats() RTTStats {
+	r.mu.RLock()
+	samples := make([]time.Duration, 0, len(r.Clients))
+	for client := range r.Clients {
+		if rtt := client.RTT(); rtt > 0 {
+			samples = append(samples, rtt)
+		}
+	}
+	r.mu.RUnlock()
+
+	if len(samples) == 0 {
+		return RTTStats{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return RTTStats{
+		P50:     percentile(samples, 50),
+		P99:     percentile(samples, 99),
+		Samples: len(samples),
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted samples
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
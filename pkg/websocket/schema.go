@@ -0,0 +1,343 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrCodeInvalidPayload is sent when a message is not JSON or its payload does not
+// match the schema of its type. The error lists the offending fields.
+const ErrCodeInvalidPayload = "invalid_payload"
+
+// maxSchemaErrors bounds the field errors reported for one message
+const maxSchemaErrors = 10
+
+// JSONSchema is a compiled JSON Schema. The commonly used subset of the draft 2020-12
+// vocabulary is supported: type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, minLength, maxLength, pattern,
+// minimum and maximum, along with the annotations in schemaAnnotations. Schemas
+// using any other keyword are rejected rather than accepting what they would forbid.
+type JSONSchema struct {
+	Types                []string               `json:"-"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Const                any                    `json:"const,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	pattern              *regexp.Regexp
+	unsupported          []string // keywords outside the supported subset, sorted
+}
+
+var schemaTypes = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+// schemaKeywords are the validation keywords JSONSchema implements
+var schemaKeywords = []string{
+	"type", "enum", "const", "properties", "required", "additionalProperties", "items",
+	"minItems", "maxItems", "minLength", "maxLength", "pattern", "minimum", "maximum",
+}
+
+// schemaAnnotations are keywords that don't affect validation and are accepted as is
+var schemaAnnotations = []string{"$schema", "$id", "$comment", "title", "description", "default", "examples"}
+
+// ParseJSONSchema compiles the schema in data
+func ParseJSONSchema(data []byte) (*JSONSchema, error) {
+	var schema JSONSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid JSON Schema: %w", err)
+	}
+	if err := schema.compile("$"); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// UnmarshalJSON reads type as either a single type name or a list of them
+func (s *JSONSchema) UnmarshalJSON(data []byte) error {
+	type plain JSONSchema
+	var raw struct {
+		plain
+		Type json.RawMessage `json:"type"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*s = JSONSchema(raw.plain)
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return err
+	}
+	for keyword := range keywords {
+		if !slices.Contains(schemaKeywords, keyword) && !slices.Contains(schemaAnnotations, keyword) {
+			s.unsupported = append(s.unsupported, keyword)
+		}
+	}
+	slices.Sort(s.unsupported)
+	if len(raw.Type) == 0 {
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(raw.Type, &single); err == nil {
+		s.Types = []string{single}
+		return nil
+	}
+	return json.Unmarshal(raw.Type, &s.Types)
+}
+
+// compile checks the keywords and compiles patterns, path naming the subschema in errors
+func (s *JSONSchema) compile(path string) error {
+	if len(s.unsupported) > 0 {
+		return fmt.Errorf("%s: unsupported keyword %q", path, s.unsupported[0])
+	}
+	for _, t := range s.Types {
+		if !slices.Contains(schemaTypes, t) {
+			return fmt.Errorf("%s: unknown type %q", path, t)
+		}
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %w", path, err)
+		}
+		s.pattern = re
+	}
+	for name, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("%s.%s: schema must be an object", path, name)
+		}
+		if err := property.compile(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(path + "[]")
+	}
+	return nil
+}
+
+// Validate checks the JSON document data against the schema. Fields of the errors
+// are paths below root, such as "data.text" or "data.tags[2]".
+func (s *JSONSchema) Validate(root string, data []byte) []ValidationError {
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte("null")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return []ValidationError{{Field: root, Message: "must be valid JSON"}}
+	}
+	var errs []ValidationError
+	s.validate(root, value, &errs)
+	return errs
+}
+
+func (s *JSONSchema) validate(path string, value any, errs *[]ValidationError) {
+	if len(*errs) >= maxSchemaErrors {
+		return
+	}
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, ValidationError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if len(s.Types) > 0 && !slices.ContainsFunc(s.Types, func(t string) bool { return hasSchemaType(value, t) }) {
+		fail("must be of type %s", strings.Join(s.Types, " or "))
+		return
+	}
+	if s.Const != nil && !jsonEqual(value, s.Const) {
+		fail("must be %v", s.Const)
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(allowed any) bool { return jsonEqual(value, allowed) }) {
+		fail("must be one of %v", s.Enum)
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("must match %s", s.Pattern)
+		}
+	case json.Number:
+		n, _ := v.Float64()
+		if s.Minimum != nil && n < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			fail("must be at most %v", *s.Maximum)
+		}
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, ValidationError{Field: path + "." + name, Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			if property, ok := s.Properties[name]; ok {
+				property.validate(path+"."+name, v[name], errs)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*errs = append(*errs, ValidationError{Field: path + "." + name, Message: "is not allowed"})
+			}
+		}
+	}
+}
+
+// hasSchemaType reports whether a value decoded with UseNumber is of JSON Schema type t
+func hasSchemaType(value any, t string) bool {
+	switch v := value.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		n, err := v.Float64()
+		return t == "integer" && err == nil && n == math.Trunc(n)
+	}
+	return false
+}
+
+// jsonEqual compares a decoded value with a schema constant by their JSON encoding
+func jsonEqual(value, constant any) bool {
+	a, errA := json.Marshal(value)
+	b, errB := json.Marshal(constant)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+// PayloadSchemas holds the JSON Schemas validating the payload of inbound messages
+// by type. Messages of types without a schema are not validated.
+type PayloadSchemas struct {
+	schemas map[string]*JSONSchema
+	mu      sync.RWMutex
+}
+
+// NewPayloadSchemas returns an empty schema registry
+func NewPayloadSchemas() *PayloadSchemas {
+	return &PayloadSchemas{schemas: make(map[string]*JSONSchema)}
+}
+
+// LoadPayloadSchemas reads every <message type>.json file in dir as the schema of
+// the payload of that message type
+func LoadPayloadSchemas(dir string) (*PayloadSchemas, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	schemas := NewPayloadSchemas()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := ParseJSONSchema(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+		schemas.Register(strings.TrimSuffix(filepath.Base(file), ".json"), schema)
+	}
+	return schemas, nil
+}
+
+// Register sets the schema of the payload of msgType, replacing any previous one
+func (p *PayloadSchemas) Register(msgType string, schema *JSONSchema) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.schemas[msgType] = schema
+}
+
+// Types returns the message types with a schema, sorted
+func (p *PayloadSchemas) Types() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	types := make([]string, 0, len(p.schemas))
+	for msgType := range p.schemas {
+		types = append(types, msgType)
+	}
+	slices.Sort(types)
+	return types
+}
+
+// Validate checks the payload of msg against the schema of its type
+func (p *PayloadSchemas) Validate(msg Message) []ValidationError {
+	p.mu.RLock()
+	schema, ok := p.schemas[msg.Type]
+	p.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return schema.Validate("data", msg.Data)
+}
+
+// UseSchemas validates the payload of every message against schemas before the
+// interceptors run. Messages that fail, and messages that are not JSON at all, are
+// answered with an invalid_payload error listing the offending fields.
+func (s *SignalingHandler) UseSchemas(schemas *PayloadSchemas) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schemas = schemas
+}
+
+// payloadSchemas returns the schemas set with UseSchemas, nil if payloads are not validated
+func (s *SignalingHandler) payloadSchemas() *PayloadSchemas {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.schemas
+}
+
+// rejectPayload tells the client its message was dropped for the field errors
+func (c *Client) rejectPayload(msgType string, fields []ValidationError) {
+	message := "invalid payload for message type " + msgType
+	if msgType == "" {
+		message = "message must be a JSON object with a type"
+	}
+	c.sendErrorNotification(ErrorNotification{
+		Code:    ErrCodeInvalidPayload,
+		Message: message,
+		Fields:  fields,
+	})
+}
@@ -0,0 +1,156 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ReplayBufferSize is how many recent broadcasts a room keeps for reconnecting clients
+const ReplayBufferSize = 256
+
+// AckMessage Sent by clients to acknowledge every broadcast up to Seq
+type AckMessage struct {
+	Seq uint64 `json:"seq" example:"42"`
+}
+
+// replayBuffer is a ring of the most recent sequenced broadcasts of a room,
+// the message with sequence s stored at s % len(messages). Guarded by Room.seqMu.
+type replayBuffer struct {
+	messages [][]byte
+	next     uint64 // last assigned sequence number
+	floor    uint64 // first sequence number held after a gap, 0 if none
+}
+
+func newReplayBuffer(size int) replayBuffer {
+	return replayBuffer{messages: make([][]byte, max(size, 1))}
+}
+
+// oldest returns the lowest sequence number still held by the buffer
+func (b *replayBuffer) oldest() uint64 {
+	size := uint64(len(b.messages))
+	if b.next < size {
+		return max(1, b.floor)
+	}
+	return max(b.next-size+1, b.floor)
+}
+
+// trimmedFromHistory reports whether msg is a chat, action or edit broadcast of
+// a history message with an ID below retained, which has since been trimmed for
+// retention or the history limit
+func trimmedFromHistory(msg []byte, retained uint64) bool {
+	envelope, ok := envelopeOf(msg)
+	if retained <= 1 || !ok {
+		return false
+	}
+	switch envelope.Type {
+	case "chat", "action", "edit":
+	default:
+		return false
+	}
+	var ref struct {
+		ID uint64 `json:"id"`
+	}
+	return json.Unmarshal(envelope.Data, &ref) == nil && ref.ID != 0 && ref.ID < retained
+}
+
+// envelopeOf decodes a broadcast envelope. Messages that are not valid envelopes,
+// such as binary ones, are not sequenced.
+func envelopeOf(msg []byte) (Message, bool) {
+	var envelope Message
+	if isBinary(msg) || json.Unmarshal(msg, &envelope) != nil {
+		return Message{}, false
+	}
+	return envelope, true
+}
+
+// stamp assigns sequence number seq, or the next one if zero, to an encoded
+// broadcast envelope and returns it with the delivery priority of its type.
+// Messages that are not envelopes are returned unchanged. It returns nil for a
+// broker delivery at or before the latest sequence number, which was already
+// delivered. Caller must hold r.seqMu.
+func (r *Room) stamp(msg []byte, seq uint64) ([]byte, Priority) {
+	envelope, ok := envelopeOf(msg)
+	if !ok {
+		if isBinary(msg) {
+			return msg, PriorityChat
+		}
+		return msg, PriorityControl
+	}
+	priority := priorityOf(envelope.Type)
+	if seq != 0 && seq <= r.replay.next {
+		return nil, priority
+	}
+	if stamped := r.stampEnvelope(envelope, seq); stamped != nil {
+		return stamped, priority
+	}
+	return msg, priority
+}
+
+// stampEnvelope assigns sequence number seq, or the next one if zero, to envelope,
+// keeps it for replay and returns its encoding. Rooms on a broker take seq from
+// the broker, so every instance numbers broadcasts alike; broadcasts skipped
+// before the room subscribed are not replayed. Caller must hold r.seqMu.
+func (r *Room) stampEnvelope(envelope Message, seq uint64) []byte {
+	if seq == 0 {
+		seq = r.replay.next + 1
+	}
+	envelope.Seq = seq
+	stamped := mustMarshal(envelope)
+	if stamped == nil {
+		return nil
+	}
+	if seq > r.replay.next+1 {
+		r.replay.floor = seq
+	}
+	r.replay.next = seq
+	r.replay.messages[seq%uint64(len(r.replay.messages))] = stamped
+	return stamped
+}
+
+// replayRange sends the client the buffered broadcasts from..to, clamped to what
+// the buffer still holds, skipping messages trimmed from history since. In
+// ordered rooms it stops and returns false when the client cannot take one, so
+// the caller drops the client instead of leaving a gap. Caller must hold r.seqMu.
+func (r *Room) replayRange(client *Client, from, to uint64) bool {
+	size := uint64(len(r.replay.messages))
+	retained := r.history.retainedFrom()
+	for seq := max(from, r.replay.oldest()); seq <= min(to, r.replay.next); seq++ {
+		msg := r.replay.messages[seq%size]
+		if trimmedFromHistory(msg, retained) {
+			continue
+		}
+		if !client.trySend(msg) && r.ordered {
+			return false
+		}
+	}
+	return true
+}
+
+// replaySince sends the client every buffered broadcast after seq. In ordered
+// rooms a client that missed broadcasts no longer buffered is told so with
+// ErrCodeReplayUnavailable first. Caller must hold r.seqMu.
+func (r *Room) replaySince(client *Client, seq uint64) bool {
+	if oldest := r.replay.oldest(); r.ordered && seq+1 < oldest && seq < r.replay.next {
+		client.sendError(ErrCodeReplayUnavailable,
+			fmt.Sprintf("broadcasts %d..%d are no longer available", seq+1, oldest-1))
+	}
+	return r.replayRange(client, seq+1, r.replay.next)
+}
+
+// LastSeq returns the sequence number of the latest broadcast of the room
+func (r *Room) LastSeq() uint64 {
+	r.seqMu.Lock()
+	defer r.seqMu.Unlock()
+	return r.replay.next
+}
+
+// handleAckMessage records the last broadcast the client has seen
+func (c *Client) handleAckMessage(message Message) {
+	var ack AckMessage
+	if err := json.Unmarshal(message.Data, &ack); err != nil {
+		return
+	}
+	if ack.Seq > c.lastAck.Load() {
+		c.lastAck.Store(ack.Seq)
+	}
+}
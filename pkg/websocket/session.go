@@ -0,0 +1,26 @@
+package websocket
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gin context keys of the anonymous session
+const (
+	// SessionIDKey holds the anonymous session user ID
+	SessionIDKey = "session_id"
+	// SessionReturningKey is true when the request presented a valid session
+	// cookie, false when the session was issued with it
+	SessionReturningKey = "session_returning"
+)
+
+// upgradeHeader carries cookies set earlier in the request chain into the upgrade response,
+// since the WebSocket handshake bypasses the regular response writer headers.
+func upgradeHeader(c *gin.Context) http.Header {
+	cookies := c.Writer.Header().Values("Set-Cookie")
+	if len(cookies) == 0 {
+		return nil
+	}
+	return http.Header{"Set-Cookie": cookies}
+}
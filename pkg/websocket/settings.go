@@ -0,0 +1,125 @@
+package websocket
+
+import "time"
+
+// Visibility controls whether a room may be discovered by other users
+type Visibility string
+
+const (
+	VisibilityPrivate Visibility = "private"
+	VisibilityPublic  Visibility = "public"
+)
+
+// Settings limits
+const (
+	MaxTopicLength   = 200
+	MaxWelcomeLength = 1000
+	MaxRoomClients   = 10000
+	MaxSlowMode      = 1 * time.Hour
+	MaxRetention     = 30 * 24 * time.Hour
+)
+
+// RoomSettings holds host-configurable room settings.
+// Zero values mean "no limit" for MaxClients, SlowMode and Retention.
+type RoomSettings struct {
+	Topic      string
+	Welcome    string // shown only to each joining client, empty for none
+	Visibility Visibility
+	MaxClients int
+	SlowMode   time.Duration
+	Retention  time.Duration
+}
+
+// SettingsUpdate is a partial settings change, nil fields are left untouched
+type SettingsUpdate struct {
+	HashedPassword *string
+	Topic          *string
+	Welcome        *string
+	Visibility     *Visibility
+	MaxClients     *int
+	SlowMode       *time.Duration
+	Retention      *time.Duration
+}
+
+// SettingsNotification Sent to clients when the room settings change
+type SettingsNotification struct {
+	Topic           string `json:"topic" example:"Weekly sync"`
+	Visibility      string `json:"visibility" example:"private"`
+	MaxClients      int    `json:"max_clients" example:"50"`
+	SlowModeSeconds int    `json:"slow_mode_seconds" example:"5"`
+}
+
+// WithSettings sets the initial room settings
+func WithSettings(settings RoomSettings) RoomOption {
+	return func(r *Room) {
+		r.settings = settings
+	}
+}
+
+// IsValid reports whether v is a known visibility value
+func (v Visibility) IsValid() bool {
+	return v == VisibilityPrivate || v == VisibilityPublic
+}
+
+// Validate checks the update against settings limits and returns
+// one ValidationError per invalid field.
+func (u SettingsUpdate) Validate() []ValidationError {
+	var errs []ValidationError
+	if u.Topic != nil && len(*u.Topic) > MaxTopicLength {
+		errs = append(errs, ValidationError{Field: "topic", Message: "topic is too long"})
+	}
+	if u.Welcome != nil && len(*u.Welcome) > MaxWelcomeLength {
+		errs = append(errs, ValidationError{Field: "welcome_message", Message: "welcome message is too long"})
+	}
+	if u.Visibility != nil && !u.Visibility.IsValid() {
+		errs = append(errs, ValidationError{Field: "visibility", Message: "visibility must be private or public"})
+	}
+	if u.MaxClients != nil && (*u.MaxClients < 0 || *u.MaxClients > MaxRoomClients) {
+		errs = append(errs, ValidationError{Field: "max_clients", Message: "max_clients is out of valid range"})
+	}
+	if u.SlowMode != nil && (*u.SlowMode < 0 || *u.SlowMode > MaxSlowMode) {
+		errs = append(errs, ValidationError{Field: "slow_mode_seconds", Message: "slow mode is out of valid range"})
+	}
+	if u.Retention != nil && (*u.Retention < 0 || *u.Retention > MaxRetention) {
+		errs = append(errs, ValidationError{Field: "retention_seconds", Message: "retention is out of valid range"})
+	}
+	return errs
+}
+
+// apply copies the non-nil fields of u into the room. Caller must hold r.mu.
+func (u SettingsUpdate) apply(r *Room) {
+	if u.HashedPassword != nil {
+		if r.HashedPassword != *u.HashedPassword {
+			r.passwordGen++
+		}
+		r.HashedPassword = *u.HashedPassword
+	}
+	if u.Topic != nil {
+		r.settings.Topic = *u.Topic
+	}
+	if u.Welcome != nil {
+		r.settings.Welcome = *u.Welcome
+	}
+	if u.Visibility != nil {
+		r.settings.Visibility = *u.Visibility
+	}
+	if u.MaxClients != nil {
+		r.settings.MaxClients = *u.MaxClients
+	}
+	if u.SlowMode != nil {
+		r.settings.SlowMode = *u.SlowMode
+	}
+	if u.Retention != nil {
+		r.settings.Retention = *u.Retention
+	}
+}
+
+// notification builds the client-facing view of the settings
+func (s RoomSettings) notification() SettingsNotification {
+	return SettingsNotification{
+		Topic:           s.Topic,
+		Visibility:      string(s.Visibility),
+		MaxClients:      s.MaxClients,
+		SlowModeSeconds: int(s.SlowMode / time.Second),
+	}
+}
@@ -3,35 +3,183 @@ package websocket
 import (
 	"encoding/json"
 	"log"
+	"strconv"
+	"sync"
 )
 
 // HandlerFunc processes a signaling message
 type HandlerFunc func(c *Client, msg Message)
 
-// SignalingHandler routes messages by type
+// ErrCodeMessageRejected is sent when an interceptor rejects a message or the
+// message uses a type reserved for the server
+const ErrCodeMessageRejected = "message_rejected"
+
+// serverTypes are the types of the notifications the server sends. A client
+// message of one of them is never broadcast, so members cannot forge
+// notifications; a registered handler still receives it.
+var serverTypes = map[string]bool{
+	"action": true, "called_on": true, "closed": true, "closing": true, "commands": true,
+	"credits": true, "error": true, "freeze": true, "unfreeze": true, "hand_queue": true,
+	"history": true, "host": true, "join": true, "leave": true, "kick": true, "lock": true, "unlock": true,
+	"member_quality": true, "members": true, "metadata": true, "migrate": true, "move": true,
+	"mute": true, "unmute": true, "pong": true, "quality": true, "read": true, "reconnected": true,
+	"reconnecting": true, "settings": true, "system": true, "welcome": true,
+}
+
+// MessageInterceptor inspects, rewrites or rejects an inbound message before it
+// is dispatched. Returning a nil message drops it silently; an error drops it
+// and is sent to the client.
+type MessageInterceptor func(c *Client, msg *Message) (*Message, error)
+
+// SignalingHandler routes the messages read from clients by type. It starts
+// with the built-in message types; Register adds custom types or replaces
+// built-in ones. Messages of other unregistered types are broadcast to the
+// room, except those of the server's notification types, which are rejected.
+// Chat messages starting with "/" are run by Commands.
 type SignalingHandler struct {
-	handlers map[string]HandlerFunc
+	handlers     map[string]HandlerFunc
+	interceptors []MessageInterceptor
+	schemas      *PayloadSchemas // nil if payloads are not validated
+	Commands     *CommandRouter
+	mu           sync.RWMutex
+}
+
+// defaultSignaling routes messages of clients created without a handler. It is
+// set in init, as the built-in handlers refer back to it.
+var defaultSignaling *SignalingHandler
+
+func init() {
+	defaultSignaling = NewSignalingHandler()
 }
 
 func NewSignalingHandler() *SignalingHandler {
-	return &SignalingHandler{
+	s := &SignalingHandler{
 		handlers: make(map[string]HandlerFunc),
+		Commands: NewCommandRouter(),
 	}
+	registerBuiltinSignaling(s)
+	return s
 }
 
 // Register new handler for message type
 func (s *SignalingHandler) Register(msgType string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.handlers[msgType] = fn
 }
 
+// RegisterCommand adds a slash command
+func (s *SignalingHandler) RegisterCommand(spec CommandSpec) error {
+	return s.Commands.Register(spec)
+}
+
+// Use appends an interceptor run on every message, in the order they were added
+func (s *SignalingHandler) Use(interceptor MessageInterceptor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interceptors = append(s.interceptors, interceptor)
+}
+
 // Handle incoming message
 func (s *SignalingHandler) Handle(c *Client, msg Message) {
-	if fn, ok := s.handlers[msg.Type]; ok {
+	s.mu.RLock()
+	interceptors := s.interceptors
+	schemas := s.schemas
+	s.mu.RUnlock()
+	if schemas != nil {
+		if fields := schemas.Validate(msg); len(fields) > 0 {
+			c.rejectPayload(msg.Type, fields)
+			return
+		}
+	}
+	for _, intercept := range interceptors {
+		next, err := intercept(c, &msg)
+		if err != nil {
+			c.sendError(ErrCodeMessageRejected, err.Error())
+			return
+		}
+		if next == nil {
+			return
+		}
+		msg = *next
+	}
+
+	s.mu.RLock()
+	fn, ok := s.handlers[msg.Type]
+	s.mu.RUnlock()
+	if ok {
 		fn(c, msg)
-	} else {
-		// default: broadcast raw message
-		c.Room.Broadcast <- mustMarshal(msg)
+		return
 	}
+	if serverTypes[msg.Type] {
+		c.sendError(ErrCodeMessageRejected, "message type "+strconv.Quote(msg.Type)+" is reserved for the server")
+		return
+	}
+	// default: broadcast raw message
+	if c.reserveBroadcast() {
+		c.Room.enqueue(c.spanContext(), mustMarshal(msg))
+	}
+}
+
+// hostOnly drops messages of clients without host privileges
+func hostOnly(fn HandlerFunc) HandlerFunc {
+	return func(c *Client, msg Message) {
+		if !c.IsHost() {
+			log.Printf("Non-host %s attempted to send %s message", c.Username, msg.Type)
+			return
+		}
+		fn(c, msg)
+	}
+}
+
+// registerBuiltinSignaling registers the message types every client understands
+func registerBuiltinSignaling(s *SignalingHandler) {
+	s.Register("chat", (*Client).handleChatMessage)
+	s.Register("attachment", (*Client).handleAttachmentMessage)
+	s.Register("ping", (*Client).handlePingMessage)
+	s.Register("time", func(c *Client, msg Message) {
+		c.handleTimeMessage(msg, c.receivedAt)
+	})
+	s.Register("auth", (*Client).handleAuthMessage)
+	s.Register("ack", (*Client).handleAckMessage)
+	s.Register("read", (*Client).handleReadMessage)
+	s.Register("state", (*Client).handleStateMessage)
+	s.Register("preferences", (*Client).handlePreferencesMessage)
+	s.Register("resend", (*Client).handleResendMessage)
+	s.Register("rename", (*Client).handleRenameMessage)
+	s.Register("media_state", (*Client).handleMediaStateMessage)
+	s.Register("raise_hand", func(c *Client, _ Message) {
+		c.Room.RaiseHand(c)
+	})
+	s.Register("lower_hand", (*Client).handleLowerHandMessage)
+	s.Register("kick", hostOnly(func(c *Client, msg Message) {
+		var kick KickMessage
+		if err := json.Unmarshal(msg.Data, &kick); err != nil {
+			log.Printf("Failed to unmarshal kick message: %v", err)
+			return
+		}
+		c.handleKickMessage(kick)
+	}))
+	s.Register("mute", hostOnly((*Client).handleMuteMessage))
+	s.Register("unmute", hostOnly((*Client).handleMuteMessage))
+	s.Register("promote", hostOnly((*Client).handlePromoteMessage))
+	s.Register("freeze", hostOnly((*Client).handleFreezeMessage))
+	s.Register("unfreeze", hostOnly(func(c *Client, _ Message) {
+		c.Room.Unfreeze()
+	}))
+	s.Register("edit", (*Client).handleEditMessage)
+	s.Register("delete", (*Client).handleDeleteMessage)
+	s.Register("lock", hostOnly(func(c *Client, _ Message) {
+		c.Room.SetLocked(true)
+	}))
+	s.Register("unlock", hostOnly(func(c *Client, _ Message) {
+		c.Room.SetLocked(false)
+	}))
+	s.Register("call_on", hostOnly((*Client).handleCallOnMessage))
+	s.Register("clear_hands", hostOnly(func(c *Client, _ Message) {
+		c.Room.ClearHands()
+	}))
+	registerFileTransferSignaling(s)
 }
 
 func mustMarshal(v interface{}) []byte {
@@ -0,0 +1,302 @@
+package websocket
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SlowConsumerPolicy decides what happens to a client whose send queue is full
+// when a broadcast is delivered
+type SlowConsumerPolicy string
+
+const (
+	// SlowConsumerDisconnect closes the connection with ClosePolicyViolation
+	SlowConsumerDisconnect SlowConsumerPolicy = "disconnect"
+	// SlowConsumerDropOldest discards the oldest queued message to make room.
+	// Ordered rooms disconnect instead, since it would leave a gap.
+	SlowConsumerDropOldest SlowConsumerPolicy = "drop_oldest"
+	// SlowConsumerBufferToDisk spools broadcasts to a temporary file until the
+	// client catches up, disconnecting it if the spool outgrows MaxSpoolBytes or
+	// the spool budget of the room runs out
+	SlowConsumerBufferToDisk SlowConsumerPolicy = "buffer_to_disk"
+)
+
+// SpoolFailed is reported to MetricsNotifier.SlowConsumer when a client could
+// not be spooled to disk, because the spool could not be created or the spool
+// budget ran out. The client is disconnected instead.
+const SpoolFailed = "buffer_to_disk_failed"
+
+// Spool limits
+const (
+	MaxSpoolBytes      = 16 << 20  // broadcasts spooled to disk for one client
+	DefaultSpoolBudget = 256 << 20 // spooled by all clients of rooms without a budget of their own
+)
+
+// spoolPollInterval is how often a spool retries a client whose send queue is still full
+const spoolPollInterval = 10 * time.Millisecond
+
+var (
+	errSpoolDrained = errors.New("spool drained")
+	errSpoolFull    = errors.New("spool full")
+)
+
+// ParseSlowConsumerPolicy returns the policy named s, SlowConsumerDisconnect if s is empty
+func ParseSlowConsumerPolicy(s string) (SlowConsumerPolicy, error) {
+	switch policy := SlowConsumerPolicy(s); policy {
+	case "":
+		return SlowConsumerDisconnect, nil
+	case SlowConsumerDisconnect, SlowConsumerDropOldest, SlowConsumerBufferToDisk:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown slow consumer policy %q, use disconnect, drop_oldest or buffer_to_disk", s)
+	}
+}
+
+// WithSlowConsumerPolicy sets what happens to clients that cannot keep up with broadcasts
+func WithSlowConsumerPolicy(policy SlowConsumerPolicy) RoomOption {
+	return func(r *Room) {
+		r.slowConsumer = policy
+	}
+}
+
+// SpoolBudget bounds the bytes the spools of all clients keep on disk together,
+// so many slow clients can't fill the disk. Spools give their bytes back when
+// they are deleted.
+type SpoolBudget struct {
+	limit int64
+	used  atomic.Int64
+}
+
+// NewSpoolBudget creates a budget of limit bytes
+func NewSpoolBudget(limit int64) *SpoolBudget {
+	return &SpoolBudget{limit: limit}
+}
+
+// defaultSpoolBudget is shared by the rooms created without WithSpoolBudget
+var defaultSpoolBudget = NewSpoolBudget(DefaultSpoolBudget)
+
+// Used returns the bytes spooled under the budget
+func (b *SpoolBudget) Used() int64 {
+	return b.used.Load()
+}
+
+// take claims n bytes, reporting false if they would exceed the limit
+func (b *SpoolBudget) take(n int64) bool {
+	for {
+		used := b.used.Load()
+		if used+n > b.limit {
+			return false
+		}
+		if b.used.CompareAndSwap(used, used+n) {
+			return true
+		}
+	}
+}
+
+// give returns n bytes to the budget
+func (b *SpoolBudget) give(n int64) {
+	b.used.Add(-n)
+}
+
+// WithSpoolBudget makes the spools of the room's clients share budget, the
+// process-wide one of DefaultSpoolBudget bytes if nil
+func WithSpoolBudget(budget *SpoolBudget) RoomOption {
+	return func(r *Room) {
+		r.spoolBudget = budget
+	}
+}
+
+// WithSpoolDir sets the directory of the spools of SlowConsumerBufferToDisk,
+// the system temporary directory if empty
+func WithSpoolDir(dir string) RoomOption {
+	return func(r *Room) {
+		r.spoolDir = dir
+	}
+}
+
+// SlowConsumer returns the slow-consumer policy of the room
+func (r *Room) SlowConsumer() SlowConsumerPolicy {
+	if r.slowConsumer == "" {
+		return SlowConsumerDisconnect
+	}
+	return r.slowConsumer
+}
+
+// deliver queues a sequenced broadcast for client, applying the slow-consumer
+// policy when its queue is full. It returns false if the client could not take
+// msg. The caller holds seqMu and the read lock of the room.
+func (r *Room) deliver(client *Client, msg []byte) bool {
+	if client.isClosed() {
+		return false
+	}
+	if client.spool != nil {
+		switch err := client.spool.append(msg); err {
+		case nil:
+			return true
+		case errSpoolDrained:
+			client.spool = nil
+		default:
+			return false
+		}
+	}
+	select {
+	case client.Send <- msg:
+		return true
+	default:
+	}
+
+	switch r.SlowConsumer() {
+	case SlowConsumerDropOldest:
+		if r.ordered {
+			break
+		}
+		select {
+		case <-client.Send:
+		default:
+		}
+		select {
+		case client.Send <- msg:
+			r.slowConsumerHandled(SlowConsumerDropOldest)
+			return true
+		default:
+		}
+	case SlowConsumerBufferToDisk:
+		budget := r.spoolBudget
+		if budget == nil {
+			budget = defaultSpoolBudget
+		}
+		spool, err := newDiskSpool(r.spoolDir, budget)
+		if err != nil {
+			r.reportSlowConsumer(SpoolFailed)
+			break
+		}
+		if spool.append(msg) != nil {
+			spool.remove()
+			r.reportSlowConsumer(SpoolFailed)
+			break
+		}
+		client.spool = spool
+		go client.drainSpool(spool)
+		r.slowConsumerHandled(SlowConsumerBufferToDisk)
+		return true
+	}
+	return false
+}
+
+// slowConsumerHandled reports a full send queue handled with policy to the metrics
+func (r *Room) slowConsumerHandled(policy SlowConsumerPolicy) {
+	r.reportSlowConsumer(string(policy))
+}
+
+// reportSlowConsumer reports what became of a full send queue to the metrics
+func (r *Room) reportSlowConsumer(outcome string) {
+	if r.Metrics != nil {
+		r.Metrics.SlowConsumer(strconv.Itoa(int(r.ID)), outcome)
+	}
+}
+
+// diskSpool buffers the broadcasts of a client in a temporary file of
+// length-prefixed records, oldest first
+type diskSpool struct {
+	mu      sync.Mutex
+	file    *os.File
+	budget  *SpoolBudget // holds the written bytes until the spool is removed
+	written int64        // end of the last record
+	read    int64        // start of the next record to hand to the send queue
+	done    bool         // drained or abandoned, later broadcasts bypass it
+}
+
+// newDiskSpool creates an empty spool in dir, taking its bytes from budget
+func newDiskSpool(dir string, budget *SpoolBudget) (*diskSpool, error) {
+	file, err := os.CreateTemp(dir, "chatters-spool-*")
+	if err != nil {
+		return nil, err
+	}
+	return &diskSpool{file: file, budget: budget}, nil
+}
+
+// append adds msg to the spool. It returns errSpoolDrained once the spool has
+// been drained, so msg goes to the send queue, and errSpoolFull if msg would
+// grow the spool over MaxSpoolBytes or exceed the budget.
+func (s *diskSpool) append(msg []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return errSpoolDrained
+	}
+	size := int64(4 + len(msg))
+	if s.written+size > MaxSpoolBytes || !s.budget.take(size) {
+		return errSpoolFull
+	}
+	record := binary.BigEndian.AppendUint32(make([]byte, 0, size), uint32(len(msg)))
+	if _, err := s.file.WriteAt(append(record, msg...), s.written); err != nil {
+		s.budget.give(size)
+		return err
+	}
+	s.written += size
+	return nil
+}
+
+// next returns the oldest spooled record. When none is left it marks the spool
+// drained and returns false.
+func (s *diskSpool) next() ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return nil, false
+	}
+	if s.read == s.written {
+		s.done = true
+		return nil, false
+	}
+	var header [4]byte
+	if _, err := s.file.ReadAt(header[:], s.read); err != nil {
+		s.done = true
+		return nil, false
+	}
+	msg := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := s.file.ReadAt(msg, s.read+4); err != nil {
+		s.done = true
+		return nil, false
+	}
+	s.read += int64(4 + len(msg))
+	return msg, true
+}
+
+// remove marks the spool done, deletes its file and gives its bytes back to the budget
+func (s *diskSpool) remove() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return
+	}
+	s.done = true
+	s.file.Close()
+	os.Remove(s.file.Name())
+	s.file = nil
+	s.budget.give(s.written)
+}
+
+// drainSpool moves the spooled broadcasts to the send queue as it empties, then
+// deletes the spool. It gives up when the client is disconnected.
+func (c *Client) drainSpool(s *diskSpool) {
+	defer s.remove()
+	for {
+		msg, ok := s.next()
+		if !ok {
+			return
+		}
+		for !c.trySend(msg) {
+			if c.isClosed() {
+				return
+			}
+			time.Sleep(spoolPollInterval)
+		}
+	}
+}
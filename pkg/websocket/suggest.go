@@ -0,0 +1,96 @@
+package websocket
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Username suggestion limits
+const (
+	DefaultUsernameSuggestions = 5
+	MaxUsernameSuggestions     = 20
+	suggestAttemptsPerName     = 20 // candidates tried per requested suggestion
+)
+
+// Word lists of suggested usernames. Both are kept free of anything a word
+// filter would reasonably block, the filters are still checked.
+var (
+	suggestAdjectives = []string{
+		"Amber", "Bold", "Brave", "Breezy", "Bright", "Calm", "Clever", "Cosmic",
+		"Crisp", "Curious", "Dapper", "Eager", "Fluffy", "Gentle", "Glad", "Golden",
+		"Happy", "Humble", "Jolly", "Keen", "Kind", "Lively", "Lucky", "Mellow",
+		"Merry", "Mighty", "Nimble", "Noble", "Polite", "Proud", "Quick", "Quiet",
+		"Rapid", "Rosy", "Shiny", "Silent", "Silver", "Sleepy", "Snowy", "Sunny",
+		"Swift", "Tidy", "Vivid", "Warm", "Wise", "Witty", "Zany", "Zesty",
+	}
+	suggestNouns = []string{
+		"Badger", "Beacon", "Bison", "Comet", "Cedar", "Dolphin", "Falcon", "Fern",
+		"Finch", "Fox", "Gecko", "Glacier", "Harbor", "Heron", "Koala", "Lantern",
+		"Lark", "Lemur", "Lynx", "Maple", "Meadow", "Meteor", "Otter", "Owl",
+		"Panda", "Pebble", "Pine", "Puffin", "Quokka", "Raven", "Ripple", "River",
+		"Robin", "Sparrow", "Spruce", "Summit", "Thistle", "Tiger", "Walrus", "Willow",
+		"Wombat", "Wren", "Yak", "Zebra",
+	}
+)
+
+// Blocks reports whether text contains a word blocked by the server list or the
+// room's own words. A nil filter only checks the room's words.
+func (f *ContentFilter) Blocks(room *Room, text string) bool {
+	_, roomPattern := room.contentFilter()
+	patterns := []*wordMatcher{roomPattern}
+	if f != nil {
+		patterns = append(patterns, f.pattern)
+	}
+	for _, pattern := range patterns {
+		if pattern != nil && pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Available reports whether holder could claim username in room right now. The
+// name is not reserved, a later Claim may still fail.
+func (u *UsernameRegistry) Available(room *Room, username, holder string) bool {
+	key, enforced := u.key(room, username)
+	if !enforced {
+		return true
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	claim := u.claims[key]
+	return claim == nil || claim.holder == holder || claim.refs == 0 && u.expired(claim, time.Now())
+}
+
+// SuggestUsernames returns up to count distinct random adjective-noun usernames that
+// pass validation, are not banned or blocked by filter in room and are free for
+// holder in the scope of registry. Fewer names are returned if few remain.
+func SuggestUsernames(room *Room, registry *UsernameRegistry, filter *ContentFilter, holder string, count int) []string {
+	count = min(max(count, 1), MaxUsernameSuggestions)
+	seen := make(map[string]bool)
+	names := make([]string, 0, count)
+	for attempt := 0; attempt < count*suggestAttemptsPerName && len(names) < count; attempt++ {
+		name := suggestAdjectives[rand.IntN(len(suggestAdjectives))] + "-" + suggestNouns[rand.IntN(len(suggestNouns))]
+		// Once the plain pairs keep colliding, tell names apart with a number
+		if attempt >= count*suggestAttemptsPerName/2 {
+			name += "-" + strconv.Itoa(10+rand.IntN(90))
+		}
+		if seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		if validateUsername(name) != nil || filter.Blocks(room, name) {
+			continue
+		}
+		if _, banned := room.IsBanned(name, ""); banned {
+			continue
+		}
+		if !registry.Available(room, name, holder) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
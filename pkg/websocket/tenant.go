@@ -0,0 +1,164 @@
+package websocket
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCodeTenantQuota is sent when a message exceeds the tenant message quota
+const ErrCodeTenantQuota = "tenant_quota_exceeded"
+
+var ErrTenantQuota = errors.New("tenant message quota exceeded")
+
+// TenantQuota limits what a single API key may use. Zero values disable the respective limit.
+type TenantQuota struct {
+	MaxRooms       int
+	MaxConnections int
+	MaxMessages    int           // messages per MessageWindow
+	MessageWindow  time.Duration // defaults to one hour
+}
+
+// TenantUsage reports the current usage of an API key
+type TenantUsage struct {
+	WindowStart time.Time `json:"window_start" example:"2024-01-01T12:00:00Z"`
+	Rooms       int       `json:"rooms" example:"3"`
+	Connections int       `json:"connections" example:"42"`
+	Messages    int       `json:"messages" example:"1200"`
+}
+
+// TenantTracker tracks and enforces per-key usage quotas.
+// A nil tracker allows everything.
+type TenantTracker struct {
+	usage map[string]*TenantUsage
+	quota TenantQuota
+	mu    sync.Mutex
+}
+
+// NewTenantTracker creates a tracker applying quota to every key
+func NewTenantTracker(quota TenantQuota) *TenantTracker {
+	if quota.MessageWindow <= 0 {
+		quota.MessageWindow = time.Hour
+	}
+	return &TenantTracker{
+		usage: make(map[string]*TenantUsage),
+		quota: quota,
+	}
+}
+
+// Quota returns the limits applied to every key
+func (t *TenantTracker) Quota() TenantQuota {
+	return t.quota
+}
+
+// entry returns the usage of key, creating it if needed. Caller must hold t.mu.
+func (t *TenantTracker) entry(key string) *TenantUsage {
+	u, ok := t.usage[key]
+	if !ok {
+		u = &TenantUsage{WindowStart: time.Now()}
+		t.usage[key] = u
+	}
+	return u
+}
+
+// AcquireRoom reserves a room for key. When ok is true, release must be called once the room is gone.
+func (t *TenantTracker) AcquireRoom(key string) (release func(), ok bool) {
+	if t == nil {
+		return func() {}, true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.entry(key)
+	if t.quota.MaxRooms > 0 && u.Rooms >= t.quota.MaxRooms {
+		return nil, false
+	}
+	u.Rooms++
+	return t.releaser(func() { t.entry(key).Rooms-- }), true
+}
+
+// AcquireConnection reserves a connection for key. When ok is true, release must be called once it closes.
+func (t *TenantTracker) AcquireConnection(key string) (release func(), ok bool) {
+	if t == nil {
+		return func() {}, true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.entry(key)
+	if t.quota.MaxConnections > 0 && u.Connections >= t.quota.MaxConnections {
+		return nil, false
+	}
+	u.Connections++
+	return t.releaser(func() { t.entry(key).Connections-- }), true
+}
+
+// releaser wraps fn so it runs once under t.mu
+func (t *TenantTracker) releaser(fn func()) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			fn()
+		})
+	}
+}
+
+// AllowMessage counts a message for key and reports whether it is within the quota
+func (t *TenantTracker) AllowMessage(key string) bool {
+	if t == nil {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.entry(key)
+	if now := time.Now(); now.Sub(u.WindowStart) >= t.quota.MessageWindow {
+		u.WindowStart = now
+		u.Messages = 0
+	}
+	if t.quota.MaxMessages > 0 && u.Messages >= t.quota.MaxMessages {
+		return false
+	}
+	u.Messages++
+	return true
+}
+
+// Usage returns the current usage of key
+func (t *TenantTracker) Usage(key string) TenantUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return *t.entry(key)
+}
+
+// WithTenant attributes the room and its connections and messages to key.
+// release is called when the room stops, typically to return a room reservation.
+func WithTenant(tracker *TenantTracker, key string, release func()) RoomOption {
+	return func(r *Room) {
+		r.tenants = tracker
+		r.tenant = key
+		r.releaseTenant = release
+	}
+}
+
+// acquireTenantConnection reserves a connection against the room's tenant quota
+func (r *Room) acquireTenantConnection() (func(), bool) {
+	if r.tenants == nil {
+		return func() {}, true
+	}
+	return r.tenants.AcquireConnection(r.tenant)
+}
+
+// allowTenantMessage counts a message against the room's tenant quota
+func (r *Room) allowTenantMessage() bool {
+	if r.tenants == nil {
+		return true
+	}
+	return r.tenants.AllowMessage(r.tenant)
+}
+
+// Tenant returns the API key the room is attributed to, empty without tenancy
+func (r *Room) Tenant() string {
+	return r.tenant
+}
@@ -0,0 +1,55 @@
+package websocket_test
+
+import (
+	"testing"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/stretchr/testify/suite"
+)
+
+type AdmissionTestSuite struct {
+	suite.Suite
+}
+
+func (s *AdmissionTestSuite) TestGlobalLimit() {
+	ac := websocket.NewAdmissionController(2, 0)
+
+	release1, ok := ac.Acquire(1)
+	s.True(ok)
+	_, ok = ac.Acquire(2)
+	s.True(ok)
+	_, ok = ac.Acquire(3)
+	s.False(ok)
+
+	release1()
+	release1()
+	s.Equal(1, ac.Active())
+
+	_, ok = ac.Acquire(3)
+	s.True(ok)
+}
+
+func (s *AdmissionTestSuite) TestRoomShare() {
+	ac := websocket.NewAdmissionController(10, 20)
+
+	for i := 0; i < 2; i++ {
+		_, ok := ac.Acquire(1)
+		s.True(ok)
+	}
+	_, ok := ac.Acquire(1)
+	s.False(ok)
+
+	_, ok = ac.Acquire(2)
+	s.True(ok)
+}
+
+func (s *AdmissionTestSuite) TestNilControllerAdmitsAll() {
+	var ac *websocket.AdmissionController
+	release, ok := ac.Acquire(1)
+	s.True(ok)
+	release()
+}
+
+func TestAdmissionTestSuite(t *testing.T) {
+	suite.Run(t, new(AdmissionTestSuite))
+}
@@ -2,6 +2,7 @@ package websocket_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -129,8 +130,11 @@ func (s *ClientTestSuite) TestReadChatMessage() {
 func (s *ClientTestSuite) TestWriteMessage() {
 	msg := []byte(`{"type":"test","data":"testdata"}`)
 
-	_, _, err := s.wsConn.ReadMessage()
-	s.NoError(err)
+	// join notification and member list
+	for i := 0; i < 2; i++ {
+		_, _, err := s.wsConn.ReadMessage()
+		s.NoError(err)
+	}
 
 	s.client.Send <- msg
 
@@ -139,6 +143,389 @@ func (s *ClientTestSuite) TestWriteMessage() {
 	s.Equal(msg, received)
 }
 
+func (s *ClientTestSuite) TestAppLevelPing() {
+	msgBytes := []byte(`{"type":"ping","data":{"client_time":123}}`)
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msgBytes))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		_ = s.wsConn.SetReadDeadline(deadline)
+		_, raw, err := s.wsConn.ReadMessage()
+		s.Require().NoError(err)
+
+		var received websocket.Message
+		if err := json.Unmarshal(raw, &received); err != nil || received.Type != "pong" {
+			continue
+		}
+		var pong websocket.PingPayload
+		s.NoError(json.Unmarshal(received.Data, &pong))
+		s.Equal(int64(123), pong.ClientTime)
+		s.NotZero(pong.ServerTime)
+		return
+	}
+	s.Fail("Timeout waiting for pong")
+}
+
+func (s *ClientTestSuite) TestTimeSync() {
+	msgBytes := []byte(`{"type":"time","data":{"client_time":1000}}`)
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msgBytes))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		_ = s.wsConn.SetReadDeadline(deadline)
+		_, raw, err := s.wsConn.ReadMessage()
+		s.Require().NoError(err)
+
+		var received websocket.Message
+		if err := json.Unmarshal(raw, &received); err != nil || received.Type != "time" {
+			continue
+		}
+		var payload websocket.TimeSyncPayload
+		s.NoError(json.Unmarshal(received.Data, &payload))
+		s.Equal(int64(1000), payload.ClientTime)
+		s.NotZero(payload.ServerReceiveTime)
+		s.GreaterOrEqual(payload.ServerSendTime, payload.ServerReceiveTime)
+		return
+	}
+	s.Fail("Timeout waiting for time response")
+}
+
+func (s *ClientTestSuite) TestFrozenRoomRejectsChat() {
+	s.room.Freeze(0)
+	s.True(s.room.IsFrozen())
+
+	msgBytes := []byte(`{"type":"chat","data":{"text":"Hello"}}`)
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msgBytes))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		_ = s.wsConn.SetReadDeadline(deadline)
+		_, raw, err := s.wsConn.ReadMessage()
+		s.Require().NoError(err)
+
+		var received websocket.Message
+		s.Require().NoError(json.Unmarshal(raw, &received))
+		s.NotEqual("chat", received.Type)
+		if received.Type != "error" {
+			continue
+		}
+		var notification websocket.ErrorNotification
+		s.NoError(json.Unmarshal(received.Data, &notification))
+		s.Equal(websocket.ErrCodeRoomFrozen, notification.Code)
+
+		s.room.Unfreeze()
+		s.False(s.room.IsFrozen())
+		return
+	}
+	s.Fail("Timeout waiting for room_frozen error")
+}
+
+func (s *ClientTestSuite) TestExpiredFreezeKeepsLaterFreeze() {
+	s.room.Freeze(50 * time.Millisecond)
+	s.room.Freeze(0)
+	time.Sleep(150 * time.Millisecond)
+	s.True(s.room.IsFrozen(), "the first freeze's timer lifted the indefinite one")
+
+	s.room.Freeze(50 * time.Millisecond)
+	s.Eventually(func() bool { return !s.room.IsFrozen() }, time.Second, 10*time.Millisecond)
+}
+
+func (s *ClientTestSuite) TestMutedClientIsNotified() {
+	s.ErrorIs(s.room.SetMuted("nobody", true, "host"), websocket.ErrUserNotInRoom)
+	s.NoError(s.room.SetMuted("testuser", true, "host"))
+	var muted websocket.MuteNotification
+	s.NoError(json.Unmarshal(s.readType("mute").Data, &muted))
+	s.Equal(websocket.MuteNotification{Username: "testuser", By: "host", Muted: true}, muted)
+	s.True(s.room.ListClients()[0].Muted)
+
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"hello"}}`)))
+	var notification websocket.ErrorNotification
+	s.NoError(json.Unmarshal(s.readType("error").Data, &notification))
+	s.Equal(websocket.ErrCodeMuted, notification.Code)
+
+	s.NoError(s.room.SetMuted("testuser", false, "host"))
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"hello again"}}`)))
+	s.readType("chat")
+}
+
+func (s *ClientTestSuite) TestBroadcastQuotaRejectsExcess() {
+	websocket.WithBroadcastQuota(1)(s.room)
+
+	msgBytes := []byte(`{"type":"chat","data":{"text":"Hello"}}`)
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msgBytes))
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msgBytes))
+
+	chats := 0
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		_ = s.wsConn.SetReadDeadline(deadline)
+		_, raw, err := s.wsConn.ReadMessage()
+		s.Require().NoError(err)
+
+		var received websocket.Message
+		s.Require().NoError(json.Unmarshal(raw, &received))
+		if received.Type == "chat" {
+			chats++
+			continue
+		}
+		if received.Type != "error" {
+			continue
+		}
+		var notification websocket.ErrorNotification
+		s.NoError(json.Unmarshal(received.Data, &notification))
+		s.Equal(websocket.ErrCodeRoomRateLimited, notification.Code)
+		s.Positive(notification.RetryAfterMs)
+		s.LessOrEqual(chats, 1)
+		return
+	}
+	s.Fail("Timeout waiting for room_rate_limited error")
+}
+
+func (s *ClientTestSuite) TestClientRateLimitDropsExcess() {
+	websocket.WithClientRateLimit(2, 10*time.Second)(s.room)
+
+	msgBytes := []byte(`{"type":"chat","data":{"text":"Hello"}}`)
+	for i := 0; i < 3; i++ {
+		s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msgBytes))
+	}
+
+	chats := 0
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		_ = s.wsConn.SetReadDeadline(deadline)
+		_, raw, err := s.wsConn.ReadMessage()
+		s.Require().NoError(err)
+
+		var received websocket.Message
+		s.Require().NoError(json.Unmarshal(raw, &received))
+		if received.Type == "chat" {
+			chats++
+			continue
+		}
+		if received.Type != "error" {
+			continue
+		}
+		var notification websocket.ErrorNotification
+		s.NoError(json.Unmarshal(received.Data, &notification))
+		s.Equal(websocket.ErrCodeRateLimited, notification.Code)
+		s.Positive(notification.RetryAfterMs)
+		s.LessOrEqual(chats, 2)
+		return
+	}
+	s.Fail("Timeout waiting for rate_limited error")
+}
+
+func (s *ClientTestSuite) TestSetRateLimitsAppliesToConnectedClients() {
+	msgBytes := []byte(`{"type":"chat","data":{"text":"Hello"}}`)
+	s.room.SetRateLimits(1, 10*time.Second, 0)
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msgBytes))
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msgBytes))
+
+	var notification websocket.ErrorNotification
+	s.NoError(json.Unmarshal(s.readType("error").Data, &notification))
+	s.Equal(websocket.ErrCodeRateLimited, notification.Code)
+
+	s.room.SetRateLimits(0, 0, 0)
+	for i := 0; i < 3; i++ {
+		s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msgBytes))
+	}
+	for i := 0; i < 3; i++ {
+		s.readType("chat")
+	}
+}
+
+func (s *ClientTestSuite) TestBurstOfChatsIsDeliveredInOrder() {
+	for _, text := range []string{"one", "two", "three"} {
+		s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"`+text+`"}}`)))
+	}
+
+	var texts []string
+	deadline := time.Now().Add(2 * time.Second)
+	for len(texts) < 3 {
+		_ = s.wsConn.SetReadDeadline(deadline)
+		_, raw, err := s.wsConn.ReadMessage()
+		s.Require().NoError(err)
+
+		var received websocket.Message
+		s.Require().NoError(json.Unmarshal(raw, &received))
+		if received.Type != "chat" {
+			continue
+		}
+		var chat websocket.ChatMessage
+		s.NoError(json.Unmarshal(received.Data, &chat))
+		texts = append(texts, chat.Text)
+	}
+	s.Equal([]string{"one", "two", "three"}, texts)
+}
+
+func (s *ClientTestSuite) TestRejectedBroadcastKeepsCredit() {
+	websocket.WithSendCredits(2, time.Minute)(s.room)
+	websocket.WithBroadcastQuota(1)(s.room)
+
+	msgBytes := []byte(`{"type":"chat","data":{"text":"Hello"}}`)
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msgBytes))
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msgBytes))
+	// The echo of the first message may arrive after the error of the second
+	chats := 0
+	var notification websocket.ErrorNotification
+	s.NoError(s.wsConn.SetReadDeadline(time.Now().Add(2 * time.Second)))
+	for chats == 0 || notification.Code == "" {
+		var msg websocket.Message
+		s.Require().NoError(s.wsConn.ReadJSON(&msg))
+		switch msg.Type {
+		case "chat":
+			chats++
+		case "error":
+			s.NoError(json.Unmarshal(msg.Data, &notification))
+		}
+	}
+	s.Equal(1, chats)
+	s.Equal(websocket.ErrCodeRoomRateLimited, notification.Code)
+
+	// The rate-limited message did not spend the second credit
+	time.Sleep(time.Duration(notification.RetryAfterMs) * time.Millisecond)
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msgBytes))
+	s.NoError(s.wsConn.SetReadDeadline(time.Now().Add(2 * time.Second)))
+	for {
+		var msg websocket.Message
+		s.Require().NoError(s.wsConn.ReadJSON(&msg))
+		if msg.Type == "chat" || msg.Type == "error" {
+			s.Equal("chat", msg.Type, string(msg.Data))
+			return
+		}
+	}
+}
+
+func (s *ClientTestSuite) TestSendCreditsRejectExcess() {
+	websocket.WithSendCredits(2, time.Minute)(s.room)
+
+	msgBytes := []byte(`{"type":"chat","data":{"text":"Hello"}}`)
+	for i := 0; i < 3; i++ {
+		s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msgBytes))
+	}
+
+	chats := 0
+	var grant websocket.CreditGrant
+	var notification websocket.ErrorNotification
+	deadline := time.Now().Add(2 * time.Second)
+	for chats < 2 || notification.Code == "" {
+		_ = s.wsConn.SetReadDeadline(deadline)
+		_, raw, err := s.wsConn.ReadMessage()
+		s.Require().NoError(err)
+
+		var received websocket.Message
+		s.Require().NoError(json.Unmarshal(raw, &received))
+		switch received.Type {
+		case "chat":
+			chats++
+		case "credits":
+			s.NoError(json.Unmarshal(received.Data, &grant))
+		case "error":
+			s.NoError(json.Unmarshal(received.Data, &notification))
+		}
+	}
+	s.Equal(websocket.ErrCodeCreditsExhausted, notification.Code)
+	s.Positive(notification.RetryAfterMs)
+	s.Equal(2, grant.Credits)
+	s.Equal(int64(60000), grant.WindowMs)
+
+	_ = s.wsConn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	for {
+		_, raw, err := s.wsConn.ReadMessage()
+		if err != nil {
+			break
+		}
+		s.NotContains(string(raw), `"type":"chat"`)
+	}
+}
+
+func (s *ClientTestSuite) TestResendRange() {
+	var seqs []uint64
+	for _, text := range []string{"one", "two"} {
+		s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"`+text+`"}}`)))
+		seqs = append(seqs, s.readType("chat").Seq)
+	}
+	s.Equal(seqs[0]+1, seqs[1])
+
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(fmt.Sprintf(`{"type":"resend","data":{"from":%d,"to":%d}}`, seqs[0], seqs[1]))))
+	s.Equal(seqs[0], s.readType("chat").Seq)
+	s.Equal(seqs[1], s.readType("chat").Seq)
+
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(fmt.Sprintf(`{"type":"resend","data":{"from":%d,"to":%d}}`, seqs[1], seqs[1]+5))))
+	var notification websocket.ErrorNotification
+	s.NoError(json.Unmarshal(s.readType("error").Data, &notification))
+	s.Equal(websocket.ErrCodeReplayUnavailable, notification.Code)
+}
+
+// readType reads messages until one of msgType arrives
+func (s *ClientTestSuite) readType(msgType string) websocket.Message {
+	s.NoError(s.wsConn.SetReadDeadline(time.Now().Add(2 * time.Second)))
+	for {
+		_, raw, err := s.wsConn.ReadMessage()
+		s.Require().NoError(err)
+
+		var msg websocket.Message
+		s.Require().NoError(json.Unmarshal(raw, &msg))
+		if msg.Type == msgType {
+			return msg
+		}
+	}
+}
+
+func (s *ClientTestSuite) TestRaiseHandQueue() {
+	readQueue := func() []websocket.RaisedHand {
+		var queue websocket.HandQueueNotification
+		s.Require().NoError(json.Unmarshal(s.readType("hand_queue").Data, &queue))
+		return queue.Queue
+	}
+
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"raise_hand"}`)))
+	queue := readQueue()
+	s.Require().Len(queue, 1)
+	s.Equal("testuser", queue[0].Username)
+	s.NotEmpty(queue[0].ID)
+
+	// Members sharing a username raise hands of their own
+	namesake := &websocket.Client{Send: make(chan []byte, 16), Room: s.room, Username: "testuser", ConnID: "namesake"}
+	s.room.RaiseHand(namesake)
+	queue = readQueue()
+	s.Require().Len(queue, 2)
+	s.Equal("testuser", queue[1].Username)
+	s.NotEqual(queue[0].ID, queue[1].ID)
+	s.room.RaiseHand(namesake)
+	s.Len(s.room.HandQueue(), 2)
+
+	// Only hosts may call on members
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"call_on"}`)))
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"ping"}`)))
+	s.readType("pong")
+	pending := s.room.HandQueue()
+	s.Require().Len(pending, 2)
+	s.Equal(queue[0].ID, pending[0].ID)
+
+	called, err := s.room.CallOn("", "HostUser")
+	s.Require().NoError(err)
+	s.Equal(queue[0].ID, called.ID)
+	var calledOn websocket.CalledOnNotification
+	s.Require().NoError(json.Unmarshal(s.readType("called_on").Data, &calledOn))
+	s.Equal(websocket.CalledOnNotification{ID: called.ID, Username: "testuser", By: "HostUser"}, calledOn)
+	s.Equal(queue[1].ID, readQueue()[0].ID)
+
+	// Members lower their own hand whatever hand they name
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"raise_hand"}`)))
+	s.Len(readQueue(), 2)
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"lower_hand","data":{"id":"`+queue[1].ID+`"}}`)))
+	lowered := readQueue()
+	s.Require().Len(lowered, 1)
+	s.Equal(queue[1].ID, lowered[0].ID)
+
+	_, err = s.room.CallOn(called.ID, "HostUser")
+	s.ErrorIs(err, websocket.ErrHandNotRaised)
+	s.room.ClearHands()
+	s.Empty(readQueue())
+	s.Empty(s.room.HandQueue())
+}
+
 func TestClientTestSuite(t *testing.T) {
 	suite.Run(t, new(ClientTestSuite))
 }
@@ -0,0 +1,207 @@
+package websocket_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	gorillaWs "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/suite"
+)
+
+type EngineIOTestSuite struct {
+	suite.Suite
+	handler *websocket.Handler
+	hub     *websocket.Hub
+	pool    *websocket.TaskPool
+	server  *httptest.Server
+}
+
+func (s *EngineIOTestSuite) SetupTest() {
+	s.hub = websocket.NewHub()
+	var err error
+	s.pool, err = websocket.NewTaskPool(10)
+	s.NoError(err)
+	s.handler = websocket.NewHandler(s.hub, s.pool)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engineIO := s.handler.HandleEngineIOWithJWT("test-secret")
+	engine.GET("/socket.io/", engineIO)
+	engine.POST("/socket.io/", engineIO)
+	s.server = httptest.NewServer(engine)
+	s.hub.CreateRoom(1, nil)
+}
+
+func (s *EngineIOTestSuite) TearDownTest() {
+	if room, ok := s.hub.GetRoom(1); ok {
+		room.StopRoom()
+	}
+	s.server.Close()
+	s.pool.Release()
+}
+
+func (s *EngineIOTestSuite) dial(query string) (*gorillaWs.Conn, error) {
+	wsURL := "ws" + strings.TrimPrefix(s.server.URL, "http") + "/socket.io/?" + query
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL, nil)
+	return conn, err
+}
+
+// readUntil reads frames until one starts with prefix
+func (s *EngineIOTestSuite) readUntil(conn *gorillaWs.Conn, prefix string) string {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		_ = conn.SetReadDeadline(deadline)
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			s.FailNow("read failed", err.Error())
+		}
+		if strings.HasPrefix(string(data), prefix) {
+			return string(data)
+		}
+	}
+	s.FailNow("timeout waiting for packet " + prefix)
+	return ""
+}
+
+// poll sends a long-polling request and returns the status and body
+func (s *EngineIOTestSuite) poll(method, query, body string) (int, string) {
+	req, err := http.NewRequest(method, s.server.URL+"/socket.io/?"+query, strings.NewReader(body))
+	s.Require().NoError(err)
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	s.Require().NoError(err)
+	return resp.StatusCode, string(data)
+}
+
+func (s *EngineIOTestSuite) TestPollingUpgradesToWebSocket() {
+	status, open := s.poll(http.MethodGet, "EIO=4&transport=polling&room_id=1&username=testuser", "")
+	s.Require().Equal(http.StatusOK, status)
+	s.Require().True(strings.HasPrefix(open, "0"))
+	var handshake struct {
+		SID      string   `json:"sid"`
+		Upgrades []string `json:"upgrades"`
+	}
+	s.Require().NoError(json.Unmarshal([]byte(open[1:]), &handshake))
+	s.Equal([]string{"websocket"}, handshake.Upgrades)
+	query := "EIO=4&room_id=1&username=testuser&sid=" + handshake.SID
+
+	// Connect over polling and send an event before upgrading
+	status, _ = s.poll(http.MethodPost, query+"&transport=polling", "40\x1e"+`42["chat",{"text":"Early"}]`)
+	s.Require().Equal(http.StatusOK, status)
+	_, connected := s.poll(http.MethodGet, query+"&transport=polling", "")
+	s.True(strings.HasPrefix(connected, "40"), connected)
+
+	pollDone := make(chan string, 1)
+	go func() {
+		_, payload := s.poll(http.MethodGet, query+"&transport=polling", "")
+		pollDone <- payload
+	}()
+
+	conn, err := s.dial(query + "&transport=websocket")
+	s.Require().NoError(err)
+	defer conn.Close()
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte("2probe")))
+	s.Equal("3probe", s.readUntil(conn, "3"))
+	select {
+	case payload := <-pollDone:
+		s.Equal("6", payload, "the pending poll ends with a noop")
+	case <-time.After(2 * time.Second):
+		s.FailNow("pending poll not released")
+	}
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte("5")))
+
+	event := s.readUntil(conn, `42["chat"`)
+	var args []json.RawMessage
+	s.NoError(json.Unmarshal([]byte(event[2:]), &args))
+	s.Require().Len(args, 2)
+	var chat websocket.ChatMessage
+	s.NoError(json.Unmarshal(args[1], &chat))
+	s.Equal("Early", chat.Text)
+
+	status, _ = s.poll(http.MethodGet, query+"&transport=polling", "")
+	s.Equal(http.StatusBadRequest, status, "the session no longer polls")
+}
+
+func (s *EngineIOTestSuite) TestPollingRejectsUnknownRoomAndSession() {
+	status, _ := s.poll(http.MethodGet, "EIO=4&transport=polling&room_id=999", "")
+	s.Equal(http.StatusNotFound, status)
+	status, _ = s.poll(http.MethodGet, "EIO=4&transport=polling&room_id=1&sid=unknown", "")
+	s.Equal(http.StatusBadRequest, status)
+	_, err := s.dial("EIO=4&transport=websocket&room_id=1&sid=unknown")
+	s.Error(err)
+}
+
+func (s *EngineIOTestSuite) TestHandshakeAndChatEvent() {
+	conn, err := s.dial("EIO=4&transport=websocket&room_id=1&username=testuser")
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	open := s.readUntil(conn, "0")
+	var handshake map[string]interface{}
+	s.NoError(json.Unmarshal([]byte(open[1:]), &handshake))
+	s.NotEmpty(handshake["sid"])
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte("40")))
+	s.readUntil(conn, "40")
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`42["chat",{"text":"Hello"}]`)))
+	event := s.readUntil(conn, `42["chat"`)
+
+	var args []json.RawMessage
+	s.NoError(json.Unmarshal([]byte(event[2:]), &args))
+	s.Require().Len(args, 2)
+	var chat websocket.ChatMessage
+	s.NoError(json.Unmarshal(args[1], &chat))
+	s.Equal("Hello", chat.Text)
+	s.Equal("testuser", chat.Username)
+}
+
+func (s *EngineIOTestSuite) TestBroadcastsKeepEngineIOFraming() {
+	var conns []*gorillaWs.Conn
+	for _, username := range []string{"alice", "robert"} {
+		conn, err := s.dial("EIO=4&transport=websocket&room_id=1&username=" + username)
+		s.Require().NoError(err)
+		defer conn.Close()
+		s.readUntil(conn, "0")
+		s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte("40")))
+		s.readUntil(conn, "40")
+		conns = append(conns, conn)
+	}
+	room, _ := s.hub.GetRoom(1)
+	s.Require().Eventually(func() bool { return room.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	// Broadcasts to several clients are prepared once, which must not bypass the framing
+	s.Require().NoError(room.PostBotMessage("WeatherBot", "sunny"))
+	for _, conn := range conns {
+		event := s.readUntil(conn, `42["chat"`)
+		var args []json.RawMessage
+		s.NoError(json.Unmarshal([]byte(event[2:]), &args))
+		s.Require().Len(args, 2)
+		var chat websocket.ChatMessage
+		s.NoError(json.Unmarshal(args[1], &chat))
+		s.Equal("sunny", chat.Text)
+	}
+}
+
+func (s *EngineIOTestSuite) TestCompressionIsNotNegotiated() {
+	s.Require().NoError(s.handler.EnableCompression(&websocket.Compression{Level: 6}))
+	dialer := gorillaWs.Dialer{EnableCompression: true}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(s.server.URL, "http")+"/socket.io/?EIO=4&transport=websocket&room_id=1&username=testuser", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+	s.Empty(resp.Header.Get("Sec-WebSocket-Extensions"), "Engine.IO frames bypass the compression settings")
+	s.readUntil(conn, "0")
+}
+
+func TestEngineIOTestSuite(t *testing.T) {
+	suite.Run(t, new(EngineIOTestSuite))
+}
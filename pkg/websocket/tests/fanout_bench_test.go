@@ -0,0 +1,17 @@
+package websocket_test
+
+import (
+	"testing"
+
+	"github.com/YuarenArt/chatters/internal/bench"
+)
+
+// BenchmarkFanout tracks broadcast cost as rooms and messages grow.
+// Run with: go test -run '^$' -bench Fanout ./pkg/websocket/tests
+func BenchmarkFanout(b *testing.B) {
+	for _, c := range bench.Cases([]int{1, 10, 100, 1000}, []int{64, 1024, 16384}) {
+		b.Run(c.Name(), func(b *testing.B) {
+			bench.Fanout(b, c)
+		})
+	}
+}
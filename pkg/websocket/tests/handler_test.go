@@ -1,16 +1,34 @@
 package websocket_test
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/YuarenArt/chatters/pkg/websocket"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
 	gorillaWs "github.com/gorilla/websocket"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 type HandlerTestSuite struct {
@@ -50,7 +68,12 @@ func (s *HandlerTestSuite) TestHandleWebSocketRoomNotFound() {
 	s.engine.ServeHTTP(w, req)
 
 	s.Equal(http.StatusNotFound, w.Code)
-	s.Contains(w.Body.String(), "room not found")
+
+	var resp websocket.ErrorResponse
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	s.Equal("room not found", resp.Error)
+	s.Equal(websocket.CodeRoomNotFound, resp.ErrorCode)
+	s.Equal(http.StatusNotFound, resp.Code)
 }
 
 func (s *HandlerTestSuite) TestHandleWebSocketValidUsername() {
@@ -70,6 +93,2183 @@ func (s *HandlerTestSuite) TestHandleWebSocketValidUsername() {
 	s.Equal(1, room.GetClientCount())
 }
 
+func (s *HandlerTestSuite) TestFailedTaskStartFreesSlots() {
+	pool, err := websocket.NewTaskPool(10)
+	s.Require().NoError(err)
+	pool.Release()
+	handler := websocket.NewHandler(s.hub, pool)
+	handler.Admission = websocket.NewAdmissionController(10, 0)
+	handler.PerIP = websocket.NewIPConnectionLimiter(10)
+	engine := gin.New()
+	engine.GET("/api/ws/:room_id", handler.HandleWebSocketWithJWT("test-secret"))
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	server := httptest.NewServer(engine)
+	defer server.Close()
+	conn, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws/1?username=alice", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	s.Eventually(func() bool {
+		return handler.Admission.Active() == 0 && handler.PerIP.Active("127.0.0.1") == 0 && room.GetClientCount() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *HandlerTestSuite) TestBrandingDefaultUsername() {
+	branding := websocket.DefaultBranding()
+	branding.DefaultName = "Guest"
+	s.Require().NoError(branding.Validate())
+	s.hub.Branding = &branding
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1"
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL, nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+	s.Equal("Guest", room.ListClients()[0].Username)
+
+	branding.BotName = "<b>"
+	s.Error(branding.Validate())
+}
+
+func (s *HandlerTestSuite) TestBrandingSystemPrefix() {
+	branding := websocket.DefaultBranding()
+	branding.SystemPrefix = "[system] "
+	s.hub.Branding = &branding
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	welcome := "Read the agenda"
+	room.UpdateSettings(websocket.SettingsUpdate{Welcome: &welcome}, room.SettingsVersion())
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username=alice"
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL, nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	var system websocket.SystemNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "system").Data, &system))
+	s.Equal("[system] Read the agenda", system.Text)
+
+	room.Freeze(0)
+	s.Require().NoError(conn.WriteJSON(websocket.Message{Type: "chat", Data: json.RawMessage(`{"text":"hello"}`)}))
+	var notification websocket.ErrorNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "error").Data, &notification))
+	s.Equal(websocket.ErrCodeRoomFrozen, notification.Code)
+	s.True(strings.HasPrefix(notification.Message, "[system] "), notification.Message)
+}
+
+func (s *HandlerTestSuite) TestBanFollowsPseudonym() {
+	s.handler.Pseudonyms = websocket.NewPseudonymizer([]byte("secret"), time.Hour)
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	dial := func(username, userAgent string) (*gorillaWs.Conn, *http.Response, error) {
+		return gorillaWs.DefaultDialer.Dial(wsURL+username, http.Header{"User-Agent": {userAgent}})
+	}
+
+	conn, _, err := dial("spammer", "Browser/1.0")
+	s.Require().NoError(err)
+	defer conn.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	ban, err := room.Ban("spammer", "", 0, "host")
+	s.Require().NoError(err)
+	s.Len(ban.Pseudonyms, 1)
+
+	_, resp, err := dial("newname", "Browser/1.0")
+	s.Require().Error(err)
+	s.Equal(http.StatusForbidden, resp.StatusCode)
+
+	other, _, err := dial("newname", "OtherBrowser/2.0")
+	s.Require().NoError(err)
+	other.Close()
+
+	// The ID of the previous period still matches after a rotation
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	now := time.Now()
+	current, _ := s.handler.Pseudonyms.Pseudonyms("", req, "203.0.113.7", now)
+	next, previous := s.handler.Pseudonyms.Pseudonyms("", req, "203.0.113.99", now.Add(time.Hour))
+	s.Equal(current, previous)
+	s.NotEqual(current, next)
+}
+
+func (s *HandlerTestSuite) TestBanFollowsSessionPseudonym() {
+	s.handler.Pseudonyms = websocket.NewPseudonymizer([]byte("secret"), time.Hour)
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithHost("host-1"))
+	defer room.StopRoom()
+
+	// Stands in for the server's session middleware: the "session" cookie is trusted as is
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		if session, err := c.Cookie("session"); err == nil {
+			c.Set(websocket.SessionIDKey, session)
+			c.Set(websocket.SessionReturningKey, true)
+		}
+	})
+	engine.GET("/api/ws/:room_id", s.handler.HandleWebSocketWithJWT("test-secret"))
+	server := httptest.NewServer(engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	dial := func(query, session, userAgent string) (*gorillaWs.Conn, *http.Response, error) {
+		header := http.Header{"User-Agent": {userAgent}}
+		if session != "" {
+			header.Set("Cookie", "session="+session)
+		}
+		return gorillaWs.DefaultDialer.Dial(wsURL+query, header)
+	}
+	hostToken, err := websocket.NewHMACKeys("test-secret").Sign(websocket.NewHostClaims(1, "host-1", time.Now()))
+	s.Require().NoError(err)
+
+	spammer, _, err := dial("spammer", "session-1", "Browser/1.0")
+	s.Require().NoError(err)
+	defer spammer.Close()
+	// The host shares the spammer's network and browser
+	host, _, err := dial("hostname&host_token="+hostToken, "session-2", "Browser/1.0")
+	s.Require().NoError(err)
+	defer host.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	ban, err := room.Ban("spammer", "", 0, "host")
+	s.Require().NoError(err)
+	// The session and fingerprint IDs of the spammer, none of the host
+	s.Len(ban.Pseudonyms, 2)
+	s.Never(func() bool { return room.GetClientCount() == 0 }, 200*time.Millisecond, 20*time.Millisecond)
+	s.Equal(1, room.GetClientCount())
+
+	// The session follows the spammer to another browser
+	_, resp, err := dial("newname", "session-1", "OtherBrowser/2.0")
+	s.Require().Error(err)
+	s.Equal(http.StatusForbidden, resp.StatusCode)
+	// Dropping the cookie falls back to the fingerprint
+	_, resp, err = dial("newname", "", "Browser/1.0")
+	s.Require().Error(err)
+	s.Equal(http.StatusForbidden, resp.StatusCode)
+	// Another member behind the same network with the same browser has a session of its own
+	neighbour, _, err := dial("neighbour", "session-3", "Browser/1.0")
+	s.Require().NoError(err)
+	neighbour.Close()
+}
+
+func (s *HandlerTestSuite) TestOriginAllowlist() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	s.Require().NoError(websocket.ValidateOrigins([]string{"https://app.example.com", "https://*.example.org"}))
+	s.Error(websocket.ValidateOrigins([]string{"https://example.com/path"}))
+	s.handler.Origins.Set([]string{"https://app.example.com", "https://*.example.org"})
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+
+	dial := func(username, origin string) (*http.Response, error) {
+		conn, resp, err := gorillaWs.DefaultDialer.Dial(wsURL+username, http.Header{"Origin": {origin}})
+		if err == nil {
+			conn.Close()
+		}
+		return resp, err
+	}
+
+	_, err := dial("listed", "https://app.example.com")
+	s.NoError(err)
+	_, err = dial("subdomain", "https://chat.eu.example.org")
+	s.NoError(err)
+	_, err = dial("sameorigin", server.URL)
+	s.NoError(err)
+
+	for _, origin := range []string{"https://evil.com", "https://example.org", "http://chat.example.org", "https://app.example.com.evil.com"} {
+		resp, err := dial("blocked", origin)
+		s.Error(err, origin)
+		s.Require().NotNil(resp, origin)
+		s.Equal(http.StatusForbidden, resp.StatusCode, origin)
+	}
+}
+
+func (s *HandlerTestSuite) TestJoinRefusedWhenRoomFull() {
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithMaxClients(1))
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"first", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	_, resp, err := gorillaWs.DefaultDialer.Dial(wsURL+"second", nil)
+	s.Error(err)
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusConflict, resp.StatusCode)
+	s.Equal(1, room.GetClientCount())
+}
+
+func (s *HandlerTestSuite) TestJoinRefusedOverPerIPLimit() {
+	s.handler.PerIP = websocket.NewIPConnectionLimiter(1)
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"first", nil)
+	s.Require().NoError(err)
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	_, resp, err := gorillaWs.DefaultDialer.Dial(wsURL+"second", nil)
+	s.Error(err)
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusTooManyRequests, resp.StatusCode)
+	s.NotEmpty(resp.Header.Get("Retry-After"))
+
+	// Closing the connection frees the slot of the address
+	conn.Close()
+	s.Eventually(func() bool { return s.handler.PerIP.Active("127.0.0.1") == 0 }, 2*time.Second, 10*time.Millisecond)
+	conn, _, err = gorillaWs.DefaultDialer.Dial(wsURL+"second", nil)
+	s.Require().NoError(err)
+	conn.Close()
+}
+
+// readWelcome reads messages until the welcome message and returns it
+func (s *HandlerTestSuite) readWelcome(conn *gorillaWs.Conn) websocket.WelcomeMessage {
+	s.NoError(conn.SetReadDeadline(time.Now().Add(2 * time.Second)))
+	for {
+		_, raw, err := conn.ReadMessage()
+		s.Require().NoError(err)
+
+		var msg websocket.Message
+		s.Require().NoError(json.Unmarshal(raw, &msg))
+		if msg.Type != "welcome" {
+			continue
+		}
+
+		var welcome websocket.WelcomeMessage
+		s.Require().NoError(json.Unmarshal(msg.Data, &welcome))
+		return welcome
+	}
+}
+
+func (s *HandlerTestSuite) TestResumeWithinReconnectGrace() {
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithReconnectGrace(time.Second))
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username=testuser"
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL, nil)
+	s.Require().NoError(err)
+	welcome := s.readWelcome(conn)
+	s.NotEmpty(welcome.ResumeToken)
+	s.NotEmpty(welcome.ConnectionID)
+	s.False(welcome.Resumed)
+	conn.Close()
+
+	s.Eventually(func() bool { return room.GetClientCount() == 0 }, time.Second, 10*time.Millisecond)
+
+	conn, _, err = gorillaWs.DefaultDialer.Dial(wsURL+"&resume="+welcome.ResumeToken, nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	resumed := s.readWelcome(conn)
+	s.True(resumed.Resumed)
+	s.Equal(welcome.ResumeToken, resumed.ResumeToken)
+	s.NotEqual(welcome.ConnectionID, resumed.ConnectionID)
+	s.Equal(1, room.GetClientCount())
+}
+
+func (s *HandlerTestSuite) TestRosterListsReconnectingMembers() {
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithReconnectGrace(time.Second))
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	dropped, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"alice", nil)
+	s.Require().NoError(err)
+	s.readWelcome(dropped)
+	dropped.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 0 }, time.Second, 10*time.Millisecond)
+
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"robert", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+	var roster websocket.MembersMessage
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "members").Data, &roster))
+	s.Require().Len(roster.Members, 2)
+	s.Equal("alice", roster.Members[0].Username)
+	s.True(roster.Members[0].Reconnecting)
+	s.Equal("robert", roster.Members[1].Username)
+	s.False(roster.Members[1].Reconnecting)
+}
+
+func (s *HandlerTestSuite) TestResumeReplaysUnacknowledgedBroadcasts() {
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithReconnectGrace(time.Second))
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username=alice"
+
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL, nil)
+	s.Require().NoError(err)
+	welcome := s.readWelcome(conn)
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"first"}}`)))
+	first := s.readMessageOfType(conn, "chat")
+	s.Positive(first.Seq)
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"ack","data":{"seq":`+strconv.FormatUint(first.Seq, 10)+`}}`)))
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"second"}}`)))
+	second := s.readMessageOfType(conn, "chat")
+	s.Greater(second.Seq, first.Seq)
+	conn.Close()
+
+	s.Eventually(func() bool { return room.GetClientCount() == 0 }, time.Second, 10*time.Millisecond)
+	room.PostBotMessage("bot", "missed")
+
+	resumed, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"&resume="+welcome.ResumeToken, nil)
+	s.Require().NoError(err)
+	defer resumed.Close()
+
+	var texts []string
+	for len(texts) < 2 {
+		var chat websocket.ChatMessage
+		s.NoError(json.Unmarshal(s.readMessageOfType(resumed, "chat").Data, &chat))
+		texts = append(texts, chat.Text)
+	}
+	s.Equal([]string{"second", "missed"}, texts)
+}
+
+func (s *HandlerTestSuite) TestResumeRequiresSameSession() {
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithReconnectGrace(time.Second))
+	defer room.StopRoom()
+
+	engine := gin.New()
+	engine.GET("/api/ws/:room_id", func(c *gin.Context) {
+		c.Set(websocket.SessionIDKey, c.Query("session"))
+		c.Next()
+	}, s.handler.HandleWebSocketWithJWT("test-secret"))
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username=testuser"
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"&session=first", nil)
+	s.Require().NoError(err)
+	welcome := s.readWelcome(conn)
+	conn.Close()
+
+	s.Eventually(func() bool { return room.GetClientCount() == 0 }, time.Second, 10*time.Millisecond)
+
+	conn, _, err = gorillaWs.DefaultDialer.Dial(wsURL+"&session=second&resume="+welcome.ResumeToken, nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	other := s.readWelcome(conn)
+	s.False(other.Resumed)
+	s.NotEqual(welcome.ResumeToken, other.ResumeToken)
+}
+
+func (s *HandlerTestSuite) TestPreferencesSurviveReconnect() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	engine := gin.New()
+	engine.GET("/api/ws/:room_id", func(c *gin.Context) {
+		c.Set(websocket.SessionIDKey, c.Query("session"))
+		c.Next()
+	}, s.handler.HandleWebSocketWithJWT("test-secret"))
+	server := httptest.NewServer(engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username=alice&session="
+
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"s1", nil)
+	s.Require().NoError(err)
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"preferences","data":{"color":"purple"}}`)))
+	var failure websocket.ErrorNotification
+	s.NoError(json.Unmarshal(s.readMessageOfType(conn, "error").Data, &failure))
+	s.Equal(websocket.ErrCodeInvalidPreferences, failure.Code)
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"preferences","data":{"color":"#ff8800","notification_level":"mentions","muted_users":["bob"]}}`)))
+	s.readMessageOfType(conn, "preferences")
+	conn.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 0 }, time.Second, 10*time.Millisecond)
+
+	conn, _, err = gorillaWs.DefaultDialer.Dial(wsURL+"s1", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	var prefs websocket.MemberPreferences
+	s.NoError(json.Unmarshal(s.readMessageOfType(conn, "preferences").Data, &prefs))
+	s.Equal(websocket.MemberPreferences{
+		Color:             "#ff8800",
+		NotificationLevel: websocket.NotifyMentions,
+		MutedUsers:        []string{"bob"},
+	}, prefs)
+
+	_, ok := room.Preferences("s2")
+	s.False(ok)
+}
+
+func (s *HandlerTestSuite) TestJoinWithTicket() {
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithPassword("not-a-real-hash"))
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username=testuser"
+
+	_, resp, err := gorillaWs.DefaultDialer.Dial(wsURL, nil)
+	s.Error(err)
+	s.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	ticket, expiresAt, err := s.handler.Tickets.Issue(1)
+	s.Require().NoError(err)
+	s.True(expiresAt.After(time.Now()))
+
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"&ticket="+ticket, nil)
+	s.Require().NoError(err)
+	conn.Close()
+
+	_, resp, err = gorillaWs.DefaultDialer.Dial(wsURL+"&ticket="+ticket, nil)
+	s.Error(err)
+	s.Equal(http.StatusUnauthorized, resp.StatusCode)
+}
+
+func (s *HandlerTestSuite) TestTicketBoundToRoomAndExpiry() {
+	store := websocket.NewTicketStore(20*time.Millisecond, []byte("ticket-key"))
+
+	ticket, _, err := store.Issue(1)
+	s.Require().NoError(err)
+	s.False(store.Redeem(ticket, 2))
+	s.False(store.Redeem(ticket, 1), "ticket is consumed by a failed redemption")
+
+	ticket, _, err = store.Issue(1)
+	s.Require().NoError(err)
+	s.False(websocket.NewTicketStore(time.Minute, []byte("other-key")).Redeem(ticket, 1), "signature must match the key")
+	tampered := []byte(ticket)
+	tampered[0] ^= 1
+	s.False(store.Redeem(string(tampered), 1), "tampered ticket")
+	s.True(store.Redeem(ticket, 1))
+
+	ticket, _, err = store.Issue(1)
+	s.Require().NoError(err)
+	time.Sleep(30 * time.Millisecond)
+	s.False(store.Redeem(ticket, 1))
+}
+
+// readMessageOfType reads messages until one of msgType arrives
+func (s *HandlerTestSuite) readMessageOfType(conn *gorillaWs.Conn, msgType string) websocket.Message {
+	s.NoError(conn.SetReadDeadline(time.Now().Add(2 * time.Second)))
+	for {
+		_, raw, err := conn.ReadMessage()
+		s.Require().NoError(err)
+
+		var msg websocket.Message
+		s.Require().NoError(json.Unmarshal(raw, &msg))
+		if msg.Type == msgType {
+			return msg
+		}
+	}
+}
+
+func (s *HandlerTestSuite) TestAuthMessageGrantsHost() {
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithHost("host-1"))
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username=testuser"
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL, nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"auth","data":{"host_token":"bogus"}}`)))
+	var failure websocket.ErrorNotification
+	s.NoError(json.Unmarshal(s.readMessageOfType(conn, "error").Data, &failure))
+	s.Equal(websocket.ErrCodeAuthFailed, failure.Code)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"room_id": 1,
+		"host_id": "host-1",
+		"host":    true,
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("test-secret"))
+	s.Require().NoError(err)
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"auth","data":{"host_token":"`+token+`"}}`)))
+	var result websocket.AuthResult
+	s.NoError(json.Unmarshal(s.readMessageOfType(conn, "auth").Data, &result))
+	s.True(result.IsHost)
+
+	members := room.ListClients()
+	s.Require().Len(members, 1)
+	s.True(members[0].IsHost)
+}
+
+func (s *HandlerTestSuite) TestRefusedMuteIsReported() {
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithHost("host-1"))
+	defer room.StopRoom()
+
+	token, err := websocket.NewHMACKeys("test-secret").Sign(websocket.NewHostClaims(1, "host-1", time.Now()))
+	s.Require().NoError(err)
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	host, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws/1?username=alice&host_token="+token, nil)
+	s.Require().NoError(err)
+	defer host.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	for _, target := range []string{"nobody", "alice"} {
+		s.NoError(host.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"mute","data":{"username":"`+target+`"}}`)))
+		var refused websocket.ErrorNotification
+		s.NoError(json.Unmarshal(s.readMessageOfType(host, "error").Data, &refused))
+		s.Equal(websocket.ErrCodeMuteFailed, refused.Code, target)
+	}
+}
+
+func (s *HandlerTestSuite) TestPromoteAndTransferHost() {
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithHost("host-1"))
+	defer room.StopRoom()
+
+	token, err := websocket.NewHMACKeys("test-secret").Sign(websocket.NewHostClaims(1, "host-1", time.Now()))
+	s.Require().NoError(err)
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+
+	alice, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"alice&host_token="+token, nil)
+	s.Require().NoError(err)
+	defer alice.Close()
+	bob, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"bobby", nil)
+	s.Require().NoError(err)
+	defer bob.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	s.NoError(alice.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"promote","data":{"username":"bobby"}}`)))
+	var granted websocket.AuthResult
+	s.NoError(json.Unmarshal(s.readMessageOfType(bob, "auth").Data, &granted))
+	s.True(granted.IsHost)
+	s.Require().NotEmpty(granted.HostToken)
+	var promoted websocket.HostNotification
+	s.NoError(json.Unmarshal(s.readMessageOfType(alice, "host").Data, &promoted))
+	s.Equal(websocket.HostNotification{Username: "bobby", By: "alice"}, promoted)
+	s.Len(room.HostIDs(), 2)
+
+	// Co-hosts can't kick the creator
+	s.NoError(bob.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"kick","data":{"target_username":"alice"}}`)))
+	var denied websocket.ErrorNotification
+	s.NoError(json.Unmarshal(s.readMessageOfType(bob, "error").Data, &denied))
+	s.Equal(websocket.ErrCodeKickDenied, denied.Code)
+	s.Equal(2, room.GetClientCount())
+
+	s.ErrorIs(room.TransferHost("host-1", "bobby"), websocket.ErrAlreadyHost)
+	s.ErrorIs(room.TransferHost("host-1", "carolyn"), websocket.ErrUserNotInRoom)
+
+	carol, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"carolyn", nil)
+	s.Require().NoError(err)
+	defer carol.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 3 }, time.Second, 10*time.Millisecond)
+
+	s.NoError(room.TransferHost("host-1", "carolyn"))
+	var revoked websocket.AuthResult
+	s.NoError(json.Unmarshal(s.readMessageOfType(alice, "auth").Data, &revoked))
+	s.False(revoked.IsHost)
+	s.False(room.IsHostID("host-1"))
+	s.ErrorIs(room.TransferHost("host-1", "alice"), websocket.ErrNotHost)
+
+	hosts := map[string]bool{}
+	for _, member := range room.ListClients() {
+		hosts[member.Username] = member.IsHost
+	}
+	s.Equal(map[string]bool{"alice": false, "bobby": true, "carolyn": true}, hosts)
+
+	// With the creator's host ID gone, the oldest remaining host may kick the others
+	s.NoError(bob.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"kick","data":{"target_username":"carolyn"}}`)))
+	s.Eventually(func() bool { return room.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+	s.Eventually(func() bool {
+		events, _, _ := room.EventsSince(0, websocket.EventLogSize, false)
+		return slices.ContainsFunc(events, func(event websocket.RoomEvent) bool {
+			return event.Type == "kick" && strings.Contains(string(event.Data), `"carolyn"`)
+		})
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *HandlerTestSuite) TestBannedUserCannotRejoin() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"spammer", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	_, err = room.Ban("", "", time.Minute, "host")
+	s.ErrorIs(err, websocket.ErrBanTargetRequired)
+	ban, err := room.Ban("spammer", "", time.Minute, "host")
+	s.Require().NoError(err)
+	s.NotNil(ban.ExpiresAt)
+	s.Eventually(func() bool { return room.GetClientCount() == 0 }, time.Second, 10*time.Millisecond)
+
+	_, resp, err := gorillaWs.DefaultDialer.Dial(wsURL+"spammer", nil)
+	s.Require().Error(err)
+	s.Equal(http.StatusForbidden, resp.StatusCode)
+
+	_, err = room.Ban("", "127.0.0.1", 0, "host")
+	s.Require().NoError(err)
+	_, resp, err = gorillaWs.DefaultDialer.Dial(wsURL+"newcomer", nil)
+	s.Require().Error(err)
+	s.Equal(http.StatusForbidden, resp.StatusCode)
+	s.Len(room.Bans(), 2)
+
+	s.True(room.Unban("", "127.0.0.1"))
+	s.True(room.Unban("spammer", ""))
+	s.False(room.Unban("spammer", ""))
+	conn2, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"spammer", nil)
+	s.Require().NoError(err)
+	conn2.Close()
+}
+
+func (s *HandlerTestSuite) TestUsernameUniqueInRoom() {
+	s.handler.Usernames = websocket.NewUsernameRegistry(websocket.UniqueRoom)
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	other, _ := s.hub.CreateRoom(2, nil)
+	defer other.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	baseURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/"
+
+	bobby, _, err := gorillaWs.DefaultDialer.Dial(baseURL+"1?username=bobby", nil)
+	s.Require().NoError(err)
+	_, resp, err := gorillaWs.DefaultDialer.Dial(baseURL+"1?username=Bobby", nil)
+	s.Require().Error(err)
+	s.Equal(http.StatusConflict, resp.StatusCode)
+	elsewhere, _, err := gorillaWs.DefaultDialer.Dial(baseURL+"2?username=bobby", nil)
+	s.Require().NoError(err)
+	elsewhere.Close()
+
+	carolyn, _, err := gorillaWs.DefaultDialer.Dial(baseURL+"1?username=carolyn", nil)
+	s.Require().NoError(err)
+	defer carolyn.Close()
+	s.Require().NoError(carolyn.WriteJSON(map[string]any{"type": "rename", "data": map[string]string{"username": "BOBBY"}}))
+	var notification websocket.ErrorNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(carolyn, "error").Data, &notification))
+	s.Equal(websocket.ErrCodeUsernameTaken, notification.Code)
+
+	s.Require().NoError(carolyn.WriteJSON(map[string]any{"type": "rename", "data": map[string]string{"username": "caroline"}}))
+	var renamed websocket.RenameNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(carolyn, "rename").Data, &renamed))
+	s.Equal(websocket.RenameNotification{From: "carolyn", To: "caroline"}, renamed)
+
+	bobby.Close()
+	s.Eventually(func() bool {
+		conn, _, err := gorillaWs.DefaultDialer.Dial(baseURL+"1?username=carolyn", nil)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, time.Second, 20*time.Millisecond, "the old name is free after a rename")
+	s.Eventually(func() bool {
+		conn, _, err := gorillaWs.DefaultDialer.Dial(baseURL+"1?username=bobby", nil)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, time.Second, 20*time.Millisecond, "the name is free once its holder left")
+}
+
+func (s *HandlerTestSuite) TestGlobalUsernameReservedForSession() {
+	registry := websocket.NewUsernameRegistry(websocket.UniqueGlobal)
+	room := websocket.NewRoom(1, nil)
+
+	release, err := registry.Claim(room, "reserved", "session-a")
+	s.Require().NoError(err)
+	again, err := registry.Claim(websocket.NewRoom(2, nil), "Reserved", "session-a")
+	s.Require().NoError(err, "the holder may use its name in several rooms")
+	release()
+	again()
+
+	_, err = registry.Claim(room, "reserved", "session-b")
+	s.ErrorIs(err, websocket.ErrUsernameTaken, "the name stays reserved after its holder left")
+	_, err = registry.Claim(room, websocket.DefaultName, "session-b")
+	s.NoError(err)
+
+	scope, err := websocket.ParseUniqueScope(" Tenant ")
+	s.NoError(err)
+	s.Equal(websocket.UniqueTenant, scope)
+	_, err = websocket.ParseUniqueScope("planet")
+	s.Error(err)
+}
+
+type fakeAuthMetrics struct {
+	mu       sync.Mutex
+	failures []string
+}
+
+func (m *fakeAuthMetrics) AuthFailed(transport, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures = append(m.failures, transport+":"+reason)
+}
+
+func (m *fakeAuthMetrics) recorded() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.failures...)
+}
+
+func (s *HandlerTestSuite) TestAuthFailuresAreCounted() {
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithHost("host-1"))
+	defer room.StopRoom()
+	metrics := &fakeAuthMetrics{}
+	s.handler.AuthMetrics = metrics
+	s.handler.Tickets = websocket.NewTicketStore(time.Minute, []byte("ticket-key"))
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username=testuser"
+
+	revoked, err := websocket.NewHMACKeys("test-secret").Sign(websocket.NewHostClaims(1, "host-0", time.Now()))
+	s.Require().NoError(err)
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"&host_token="+revoked, nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"auth","data":{"host_token":"bogus"}}`)))
+	s.readMessageOfType(conn, "error")
+
+	_, _, err = gorillaWs.DefaultDialer.Dial(wsURL+"&ticket=bogus", nil)
+	s.Error(err)
+
+	s.Equal([]string{"ws:revoked_token", "ws:invalid_token", "ws:invalid_ticket"}, metrics.recorded())
+}
+
+// reapMetrics records reaped connections and ignores everything else
+type reapMetrics struct {
+	mu      sync.Mutex
+	reasons []string
+}
+
+func (m *reapMetrics) DroppedMessage(string, string)                {}
+func (m *reapMetrics) RTTObserved(string, time.Duration)            {}
+func (m *reapMetrics) ThrottledMessage(string)                      {}
+func (m *reapMetrics) RateLimitedMessage(string, string)            {}
+func (m *reapMetrics) StageObserved(string, string, time.Duration)  {}
+func (m *reapMetrics) BroadcastObserved(string, int, time.Duration) {}
+func (m *reapMetrics) MessageBroadcast(string)                      {}
+func (m *reapMetrics) ClientJoined(string, string)                  {}
+func (m *reapMetrics) ClientLeft(string, string)                    {}
+func (m *reapMetrics) RoomCreated(string)                           {}
+func (m *reapMetrics) RoomDeleted(string)                           {}
+func (m *reapMetrics) SlowConsumer(string, string)                  {}
+func (m *reapMetrics) MessageShed(string, string)                   {}
+func (m *reapMetrics) ConnectionReaped(roomID string, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reasons = append(m.reasons, reason)
+}
+
+func (m *reapMetrics) recorded() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.reasons...)
+}
+
+func (s *HandlerTestSuite) TestSilentConnectionReapedAfterHandshakeTimeout() {
+	metrics := &reapMetrics{}
+	room, _ := s.hub.CreateRoom(1, metrics)
+	defer room.StopRoom()
+	s.handler.HandshakeTimeout = 150 * time.Millisecond
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+
+	// Never reads, so the server's ping is not answered
+	silent, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"silent", nil)
+	s.Require().NoError(err)
+	defer silent.Close()
+	talker, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"talker", nil)
+	s.Require().NoError(err)
+	defer talker.Close()
+	s.NoError(talker.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"ping","data":{}}`)))
+	s.readMessageOfType(talker, "pong")
+
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, 2*time.Second, 10*time.Millisecond)
+	s.Equal("talker", room.ListClients()[0].Username)
+	s.Equal([]string{websocket.ReapHandshakeTimeout}, metrics.recorded())
+}
+
+func (s *HandlerTestSuite) TestAsymmetricTokenKeys() {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	s.Require().NoError(err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	s.Require().NoError(err)
+	keyFile := filepath.Join(s.T().TempDir(), "host.pem")
+	s.Require().NoError(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	keys, err := websocket.NewTokenKeys("test-secret", keyFile, "")
+	s.Require().NoError(err)
+	s.Equal("EdDSA", keys.Algorithm())
+
+	token, err := keys.Sign(jwt.MapClaims{"room_id": 1, "host_id": "host-1", "host": true})
+	s.Require().NoError(err)
+	_, err = keys.Parse(token)
+	s.NoError(err)
+
+	hmacToken, err := websocket.NewHMACKeys("test-secret").Sign(jwt.MapClaims{"room_id": 1})
+	s.Require().NoError(err)
+	_, err = keys.Parse(hmacToken)
+	s.Error(err, "HS256 tokens must not verify against an asymmetric key")
+
+	_, err = websocket.NewTokenKeys("test-secret", filepath.Join(s.T().TempDir(), "missing.pem"), "")
+	s.Error(err)
+
+	writePublicKey := func(name string, key ed25519.PublicKey) string {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		s.Require().NoError(err)
+		file := filepath.Join(s.T().TempDir(), name)
+		s.Require().NoError(os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+		return file
+	}
+	_, err = websocket.NewTokenKeys("test-secret", keyFile, writePublicKey("host.pub", privateKey.Public().(ed25519.PublicKey)))
+	s.NoError(err)
+	otherKey, _, err := ed25519.GenerateKey(nil)
+	s.Require().NoError(err)
+	_, err = websocket.NewTokenKeys("test-secret", keyFile, writePublicKey("other.pub", otherKey))
+	s.ErrorIs(err, websocket.ErrTokenKeyMismatch)
+}
+
+func (s *HandlerTestSuite) TestScheduledCloseCountsDown() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	conn, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws/1?username=alice", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	closesAt, ok := s.hub.ScheduleClose(1, 300*time.Millisecond)
+	s.Require().True(ok)
+	s.Equal(closesAt, room.ClosesAt())
+
+	var warning websocket.ClosingNotification
+	s.NoError(json.Unmarshal(s.readMessageOfType(conn, "closing").Data, &warning))
+	s.Equal(closesAt.UnixMilli(), warning.ClosesAt)
+	s.readMessageOfType(conn, "closed")
+
+	_, _, err = conn.ReadMessage()
+	s.True(gorillaWs.IsCloseError(err, websocket.CloseRoomDeleted), "expected a room deleted close frame, got %v", err)
+	_, exists := s.hub.GetRoom(1)
+	s.False(exists)
+}
+
 func TestHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(HandlerTestSuite))
 }
+
+func (s *HandlerTestSuite) TestSlashCommands() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	s.Require().NoError(s.handler.SignalingHandler.RegisterCommand(websocket.CommandSpec{
+		Name: "roll",
+		Run: func(c *websocket.Client, cmd websocket.Command) error {
+			return errors.New("rolled " + strings.Join(cmd.Args, ","))
+		},
+	}))
+	s.ErrorIs(s.handler.SignalingHandler.RegisterCommand(websocket.CommandSpec{
+		Name: "ROLL",
+		Run:  func(*websocket.Client, websocket.Command) error { return nil },
+	}), websocket.ErrCommandExists)
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	conn, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws/1?username=alice", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	send := func(text string) {
+		data, _ := json.Marshal(websocket.ChatMessage{Text: text})
+		msg, _ := json.Marshal(websocket.Message{Type: "chat", Data: data})
+		s.Require().NoError(conn.WriteMessage(gorillaWs.TextMessage, msg))
+	}
+	readError := func() websocket.ErrorNotification {
+		var notification websocket.ErrorNotification
+		s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "error").Data, &notification))
+		return notification
+	}
+
+	send("/help")
+	var help websocket.CommandsNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "commands").Data, &help))
+	var names []string
+	for _, command := range help.Commands {
+		names = append(names, command.Name)
+	}
+	s.Equal([]string{"help", "me", "roll"}, names)
+
+	send("/kick bob")
+	s.Equal(websocket.ErrCodeCommandDenied, readError().Code)
+
+	send("/nope")
+	s.Equal(websocket.ErrCodeUnknownCommand, readError().Code)
+
+	send("/roll 1 6")
+	notification := readError()
+	s.Equal(websocket.ErrCodeCommandFailed, notification.Code)
+	s.Equal("rolled 1,6", notification.Message)
+
+	send("/me  waves")
+	var action websocket.ActionMessage
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "action").Data, &action))
+	s.NotZero(action.ID)
+	s.Equal(websocket.ActionMessage{Text: "waves", Username: "alice", ID: action.ID}, action)
+
+	send("//not a command")
+	var chat websocket.ChatMessage
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "chat").Data, &chat))
+	s.Equal("/not a command", chat.Text)
+
+	// Slow mode applies to commands that broadcast
+	slowMode := time.Minute
+	_, ok := room.UpdateSettings(websocket.SettingsUpdate{SlowMode: &slowMode}, room.SettingsVersion())
+	s.Require().True(ok)
+	send("/me  waves again")
+	s.Never(func() bool {
+		return len(room.History(time.Time{}, time.Time{})) > 2
+	}, 200*time.Millisecond, 10*time.Millisecond)
+}
+
+func (s *HandlerTestSuite) TestCustomMessageTypesAreDispatched() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	received := make(chan websocket.Message, 1)
+	s.handler.SignalingHandler.Register("typing", func(c *websocket.Client, msg websocket.Message) {
+		received <- msg
+	})
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	conn, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws/1?username=alice", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"kick","data":{"target_username":"alice"}}`)))
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"typing","data":{"active":true}}`)))
+	select {
+	case msg := <-received:
+		s.JSONEq(`{"active":true}`, string(msg.Data))
+	case <-time.After(2 * time.Second):
+		s.Fail("custom handler was not called")
+	}
+	// The kick of a non-host was dropped by the built-in handler
+	s.Equal(1, room.GetClientCount())
+}
+
+func (s *HandlerTestSuite) TestMessageInterceptors() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	s.handler.UseMessageInterceptor(func(c *websocket.Client, msg *websocket.Message) (*websocket.Message, error) {
+		if msg.Type == "typing" {
+			return nil, nil
+		}
+		if strings.Contains(string(msg.Data), "darn") {
+			return nil, errors.New("watch your language")
+		}
+		return msg, nil
+	})
+	s.handler.UseMessageInterceptor(func(c *websocket.Client, msg *websocket.Message) (*websocket.Message, error) {
+		if msg.Type != "chat" {
+			return msg, nil
+		}
+		var chat websocket.ChatMessage
+		if err := json.Unmarshal(msg.Data, &chat); err != nil {
+			return nil, err
+		}
+		chat.Text = strings.ToUpper(chat.Text)
+		msg.Data, _ = json.Marshal(chat)
+		return msg, nil
+	})
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	conn, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws/1?username=alice", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"typing","data":{}}`)))
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"darn it"}}`)))
+	var rejected websocket.ErrorNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "error").Data, &rejected))
+	s.Equal(websocket.ErrCodeMessageRejected, rejected.Code)
+	s.Equal("watch your language", rejected.Message)
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"hello"}}`)))
+	var chat websocket.ChatMessage
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "chat").Data, &chat))
+	s.Equal("HELLO", chat.Text)
+	s.Len(room.History(time.Time{}, time.Time{}), 1)
+}
+
+func (s *HandlerTestSuite) TestServerTypesAreNotRelayed() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	baseURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	victim, _, err := gorillaWs.DefaultDialer.Dial(baseURL+"robert", nil)
+	s.Require().NoError(err)
+	defer victim.Close()
+	s.readMessageOfType(victim, "members")
+	forger, _, err := gorillaWs.DefaultDialer.Dial(baseURL+"mallory", nil)
+	s.Require().NoError(err)
+	defer forger.Close()
+	s.readMessageOfType(forger, "members")
+
+	forged := []string{"welcome", "members", "host", "settings", "closing", "move", "migrate", "system", "error"}
+	for _, msgType := range forged {
+		s.NoError(forger.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"`+msgType+`","data":{"url":"https://evil.example"}}`)))
+		var rejected websocket.ErrorNotification
+		s.Require().NoError(json.Unmarshal(s.readMessageOfType(forger, "error").Data, &rejected))
+		s.Equal(websocket.ErrCodeMessageRejected, rejected.Code)
+		s.Contains(rejected.Message, msgType)
+	}
+
+	// Other unregistered types are still relayed, after everything sent before them
+	s.NoError(forger.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"typing","data":{}}`)))
+	s.NoError(victim.SetReadDeadline(time.Now().Add(2 * time.Second)))
+	for {
+		var msg websocket.Message
+		s.Require().NoError(victim.ReadJSON(&msg))
+		if msg.Type == "typing" {
+			break
+		}
+		s.NotContains(forged, msg.Type, "a member relayed a server notification")
+	}
+}
+
+func (s *HandlerTestSuite) TestContentFilter() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	s.handler.UseMessageInterceptor(websocket.NewContentFilter([]string{"darn", "блин", "@ss"}, websocket.FilterMask).Interceptor())
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	conn, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws/1?username=alice", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"Darn it, darned spoiler"}}`)))
+	var chat websocket.ChatMessage
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "chat").Data, &chat))
+	s.Equal("**** it, darned spoiler", chat.Text)
+
+	// Words are told apart by Unicode letters, not only ASCII ones
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"Блин, блин блинчик @ss class"}}`)))
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "chat").Data, &chat))
+	s.Equal("****, **** блинчик *** class", chat.Text)
+
+	s.ErrorIs(room.SetContentFilter(websocket.RoomContentFilter{Words: []string{" "}}), websocket.ErrInvalidFilterWords)
+	s.Require().NoError(room.SetContentFilter(websocket.RoomContentFilter{Action: websocket.FilterReject, Words: []string{"spoiler"}}))
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"big spoiler"}}`)))
+	var rejected websocket.ErrorNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "error").Data, &rejected))
+	s.Equal(websocket.ErrCodeMessageRejected, rejected.Code)
+
+	s.Require().NoError(room.SetContentFilter(websocket.RoomContentFilter{Action: websocket.FilterFlag}))
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"darn"}}`)))
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "chat").Data, &chat))
+	s.Equal("darn", chat.Text)
+	events, _, _ := room.EventsSince(0, websocket.EventLogSize, true)
+	var flagged []websocket.RoomEvent
+	for _, event := range events {
+		if event.Type == websocket.EventFlagged {
+			flagged = append(flagged, event)
+		}
+	}
+	s.Len(flagged, 1)
+	events, _, _ = room.EventsSince(0, websocket.EventLogSize, false)
+	for _, event := range events {
+		s.NotEqual(websocket.EventFlagged, event.Type)
+	}
+}
+
+func (s *HandlerTestSuite) TestAutoModRules() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	s.ErrorIs(room.SetModRules([]websocket.ModRule{{Name: "empty", Action: websocket.ModDelete}}), websocket.ErrInvalidModRules)
+	s.Len(websocket.ValidateModRules([]websocket.ModRule{{Name: "bad", Action: "ban", Pattern: "("}}), 2)
+	s.Require().NoError(room.SetModRules([]websocket.ModRule{
+		{Name: "links", Action: websocket.ModFlag, Pattern: `https?://`},
+		{Name: "casino", Action: websocket.ModDelete, Words: []string{"casino"}},
+		{Name: "flood", Action: websocket.ModMute, MaxMessages: 3, WindowSeconds: 60},
+	}))
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	conn, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws/1?username=alice", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"see http://example.com"}}`)))
+	var chat websocket.ChatMessage
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "chat").Data, &chat))
+	s.Equal("see http://example.com", chat.Text)
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"Casino night"}}`)))
+	var removed websocket.ErrorNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "error").Data, &removed))
+	s.Equal(websocket.ErrCodeAutoModerated, removed.Code)
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"again"}}`)))
+	s.readMessageOfType(conn, "chat")
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"and again"}}`)))
+	var mute websocket.MuteNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "mute").Data, &mute))
+	s.Equal("alice", mute.Username)
+	s.Equal(websocket.AutoModName, mute.By)
+
+	counts := map[string]int{}
+	events, _, _ := room.EventsSince(0, websocket.EventLogSize, true)
+	for _, event := range events {
+		counts[event.Type]++
+	}
+	s.Equal(1, counts[websocket.EventFlagged])
+	s.Equal(2, counts[websocket.EventAutoModerated])
+	s.Len(room.ModRules(), 3)
+}
+
+func (s *HandlerTestSuite) TestAccessLog() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	alice, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"alice", nil)
+	s.Require().NoError(err)
+	defer alice.Close()
+	robert, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"robert", nil)
+	s.Require().NoError(err)
+	defer robert.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	room.KickClients([]string{"robert"}, "host")
+	s.Eventually(func() bool { return len(room.AccessLog()) == 4 }, time.Second, 10*time.Millisecond)
+
+	entries := room.AccessLog()
+	var actions []string
+	for _, entry := range entries {
+		actions = append(actions, entry.Action+" "+entry.Username)
+	}
+	s.ElementsMatch([]string{"join alice", "join robert"}, actions[:2])
+	s.Equal([]string{"kick robert", "leave robert"}, actions[2:])
+	s.Equal("host", entries[2].By)
+	s.NotEmpty(entries[2].ConnID)
+}
+
+// broadcastMetrics records the fan-out of every room broadcast
+type broadcastMetrics struct {
+	reapMetrics
+	fanouts []int
+}
+
+func (m *broadcastMetrics) BroadcastObserved(roomID string, recipients int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fanouts = append(m.fanouts, recipients)
+}
+
+func (m *broadcastMetrics) observed() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]int(nil), m.fanouts...)
+}
+
+func (s *HandlerTestSuite) TestBroadcastMetrics() {
+	metrics := &broadcastMetrics{}
+	room, _ := s.hub.CreateRoom(1, metrics)
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	for _, username := range []string{"alice", "robert"} {
+		conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL+username, nil)
+		s.Require().NoError(err)
+		defer conn.Close()
+	}
+	s.Eventually(func() bool { return room.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	room.PostBotMessage("WeatherBot", "sunny")
+	s.Eventually(func() bool { return len(metrics.observed()) == 1 }, time.Second, 10*time.Millisecond)
+	s.Equal([]int{2}, metrics.observed())
+}
+
+func (s *HandlerTestSuite) TestPreparedBroadcastsReachEveryClient() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	var conns []*gorillaWs.Conn
+	for _, username := range []string{"alice", "robert", "carol"} {
+		conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL+username, nil)
+		s.Require().NoError(err)
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+	s.Eventually(func() bool { return room.GetClientCount() == 3 }, time.Second, 10*time.Millisecond)
+
+	prepared := &preparedConn{fakeConn: newFakeConn()}
+	client := &websocket.Client{Conn: prepared, Send: make(chan []byte, 256), Room: room, Username: "dave"}
+	go client.Write()
+	room.Register <- client
+	s.Eventually(func() bool { return room.GetClientCount() == 4 }, time.Second, 10*time.Millisecond)
+
+	// More broadcasts than the prepared frames kept, so evicted ones are reused too
+	for i := 0; i < 300; i++ {
+		room.PostBotMessage("WeatherBot", "forecast "+strconv.Itoa(i))
+		first := s.readMessageOfType(conns[0], "chat")
+		for _, conn := range conns[1:] {
+			s.Equal(first, s.readMessageOfType(conn, "chat"))
+		}
+	}
+	s.Eventually(func() bool { return prepared.preparedWrites() >= 300 }, time.Second, 10*time.Millisecond)
+	s.Empty(prepared.unpreparedChats(), "broadcasts are written from their prepared frames")
+}
+
+// preparedConn is a fakeConn that counts the messages written from prepared frames
+type preparedConn struct {
+	*fakeConn
+	mu         sync.Mutex
+	prepared   int
+	unprepared []string
+}
+
+func (c *preparedConn) WritePreparedMessage(*gorillaWs.PreparedMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prepared++
+	return nil
+}
+
+func (c *preparedConn) WriteMessage(_ int, data []byte) error {
+	var msg websocket.Message
+	if json.Unmarshal(data, &msg) == nil && msg.Type == "chat" {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.unprepared = append(c.unprepared, string(data))
+	}
+	return nil
+}
+
+func (c *preparedConn) preparedWrites() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.prepared
+}
+
+func (c *preparedConn) unpreparedChats() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.unprepared
+}
+
+type compressionMetrics struct {
+	mu                   sync.Mutex
+	original, compressed int
+}
+
+func (m *compressionMetrics) MessageCompressed(original, compressed int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.original += original
+	m.compressed += compressed
+}
+
+func (m *compressionMetrics) totals() (int, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.original, m.compressed
+}
+
+func (s *HandlerTestSuite) TestCompression() {
+	metrics := &compressionMetrics{}
+	s.Error(s.handler.EnableCompression(&websocket.Compression{Level: 10}))
+	s.Require().NoError(s.handler.EnableCompression(&websocket.Compression{Level: 6, MinSize: 256, Metrics: metrics}))
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	dialer := gorillaWs.Dialer{EnableCompression: true}
+	alice, resp, err := dialer.Dial(wsURL+"alice", nil)
+	s.Require().NoError(err)
+	defer alice.Close()
+	s.Contains(resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+	robert, resp, err := gorillaWs.DefaultDialer.Dial(wsURL+"robert", nil)
+	s.Require().NoError(err)
+	defer robert.Close()
+	s.Empty(resp.Header.Get("Sec-WebSocket-Extensions"))
+	s.Eventually(func() bool { return room.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	// Short messages stay below the threshold
+	room.PostBotMessage("WeatherBot", "sunny")
+	s.readMessageOfType(alice, "chat")
+	s.readMessageOfType(robert, "chat")
+	original, _ := metrics.totals()
+	s.Zero(original)
+
+	forecast := strings.Repeat("sunny with a light breeze, ", 100)
+	room.PostBotMessage("WeatherBot", forecast)
+	s.Equal(s.readMessageOfType(alice, "chat"), s.readMessageOfType(robert, "chat"))
+	original, compressed := metrics.totals()
+	s.Greater(original, len(forecast))
+	s.Positive(compressed)
+	s.Less(compressed, original/4)
+}
+
+// readBinary returns the next binary message on conn, skipping text messages
+func (s *HandlerTestSuite) readBinary(conn *gorillaWs.Conn) []byte {
+	s.NoError(conn.SetReadDeadline(time.Now().Add(2 * time.Second)))
+	for {
+		frame, raw, err := conn.ReadMessage()
+		s.Require().NoError(err)
+		if frame == gorillaWs.BinaryMessage {
+			return raw
+		}
+	}
+}
+
+func (s *HandlerTestSuite) TestBinaryMessagesAreRelayed() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	alice, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"alice", nil)
+	s.Require().NoError(err)
+	defer alice.Close()
+	robert, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"robert", nil)
+	s.Require().NoError(err)
+	defer robert.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	chunk := []byte{0x00, 0x7b, 0xff, 0x10, 0x22}
+	s.NoError(alice.WriteMessage(gorillaWs.BinaryMessage, append([]byte{websocket.BinaryFileChunk}, chunk...)))
+	for _, conn := range []*gorillaWs.Conn{alice, robert} {
+		kind, from, payload, err := websocket.ParseBinaryEnvelope(s.readBinary(conn))
+		s.Require().NoError(err)
+		s.Equal(websocket.BinaryFileChunk, kind)
+		s.Equal("alice", from)
+		s.Equal(chunk, payload)
+	}
+
+	// Binary messages must start with a known kind
+	s.NoError(robert.WriteMessage(gorillaWs.BinaryMessage, []byte{0x7f, 0x01}))
+	var rejected websocket.ErrorNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(robert, "error").Data, &rejected))
+	s.Equal(websocket.ErrCodeInvalidBinary, rejected.Code)
+
+	// Muted members cannot send voice either
+	s.Require().NoError(room.SetMuted("robert", true, "alice"))
+	s.NoError(robert.WriteMessage(gorillaWs.BinaryMessage, []byte{websocket.BinaryAudio, 0x01}))
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(robert, "error").Data, &rejected))
+	s.Equal(websocket.ErrCodeMuted, rejected.Code)
+}
+
+func (s *HandlerTestSuite) TestFileTransferSignaling() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	alice, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"alice", nil)
+	s.Require().NoError(err)
+	defer alice.Close()
+	robert, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"robert", nil)
+	s.Require().NoError(err)
+	defer robert.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	s.NoError(alice.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"file-offer","data":{"to":"robert","name":"slides.pdf","size":2048}}`)))
+	var offer websocket.FileOfferNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(robert, "file-offer").Data, &offer))
+	s.Equal("alice", offer.From)
+	s.Equal(int64(2048), offer.Size)
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(alice, "file-offered").Data, &offer))
+	s.NotEmpty(offer.TransferID)
+
+	// Signaling is not relayed before the recipient consents
+	signal := `{"type":"file-signal","data":{"transfer_id":"` + offer.TransferID + `","signal":{"sdp":"v=0"}}}`
+	s.NoError(alice.WriteMessage(gorillaWs.TextMessage, []byte(signal)))
+	var rejected websocket.ErrorNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(alice, "error").Data, &rejected))
+	s.Equal(websocket.ErrCodeFileTransfer, rejected.Code)
+
+	s.NoError(robert.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"file-accept","data":{"transfer_id":"`+offer.TransferID+`"}}`)))
+	var accepted websocket.FileTransferMessage
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(alice, "file-accept").Data, &accepted))
+	s.Equal("robert", accepted.From)
+
+	s.NoError(alice.WriteMessage(gorillaWs.TextMessage, []byte(signal)))
+	var relayed websocket.FileTransferMessage
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(robert, "file-signal").Data, &relayed))
+	s.Equal(offer.TransferID, relayed.TransferID)
+	s.JSONEq(`{"sdp":"v=0"}`, string(relayed.Signal))
+}
+
+// lifecycleMetrics counts room and client lifecycle notifications
+type lifecycleMetrics struct {
+	reapMetrics
+	rooms, clients int
+}
+
+func (m *lifecycleMetrics) RoomCreated(string)          { m.add(&m.rooms, 1) }
+func (m *lifecycleMetrics) RoomDeleted(string)          { m.add(&m.rooms, -1) }
+func (m *lifecycleMetrics) ClientJoined(string, string) { m.add(&m.clients, 1) }
+func (m *lifecycleMetrics) ClientLeft(string, string)   { m.add(&m.clients, -1) }
+
+func (m *lifecycleMetrics) add(counter *int, delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	*counter += delta
+}
+
+func (m *lifecycleMetrics) counts() (rooms, clients int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rooms, m.clients
+}
+
+func (s *HandlerTestSuite) TestRoomLifecycleMetrics() {
+	metrics := &lifecycleMetrics{}
+	room, _ := s.hub.CreateRoom(1, metrics)
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	alice, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"alice", nil)
+	s.Require().NoError(err)
+	robert, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"robert", nil)
+	s.Require().NoError(err)
+	defer robert.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+	rooms, clients := metrics.counts()
+	s.Equal(1, rooms)
+	s.Equal(2, clients)
+
+	alice.Close()
+	s.Eventually(func() bool { _, clients := metrics.counts(); return clients == 1 }, time.Second, 10*time.Millisecond)
+
+	s.True(s.hub.DeleteRoom(1))
+	rooms, clients = metrics.counts()
+	s.Equal(0, rooms)
+	s.Equal(0, clients)
+}
+
+func (s *HandlerTestSuite) TestMediaStatePresence() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	alice, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"alice", nil)
+	s.Require().NoError(err)
+	defer alice.Close()
+	robert, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"robert", nil)
+	s.Require().NoError(err)
+	defer robert.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	s.NoError(alice.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"media_state","data":{"camera_on":true,"screen_sharing":true}}`)))
+	var notification websocket.MediaStateNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(robert, "media_state").Data, &notification))
+	s.Equal("alice", notification.Username)
+	s.Equal(websocket.MediaState{CameraOn: true, ScreenSharing: true}, notification.MediaState)
+
+	for _, member := range room.ListClients() {
+		if member.Username == "alice" {
+			s.True(member.Media.CameraOn)
+			s.True(member.Media.ScreenSharing)
+			s.False(member.Media.MicMuted)
+		} else {
+			s.Equal(websocket.MediaState{}, member.Media)
+		}
+	}
+}
+
+func (s *HandlerTestSuite) TestBreakoutMoveAndRecall() {
+	parent, _ := s.hub.CreateRoom(1, nil, websocket.WithPassword("not-a-real-hash"))
+	defer s.hub.DeleteRoom(1)
+	breakout, err := s.hub.CreateBreakout(parent, 2, nil)
+	s.Require().NoError(err)
+	s.True(breakout.HasPassword())
+	_, err = s.hub.CreateBreakout(breakout, 3, nil)
+	s.ErrorIs(err, websocket.ErrNestedBreakout)
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/"
+	ticket, _, err := s.handler.Tickets.Issue(1)
+	s.Require().NoError(err)
+	alice, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"1?username=alice&ticket="+ticket, nil)
+	s.Require().NoError(err)
+	defer alice.Close()
+	s.Eventually(func() bool { return parent.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	results := parent.MoveClients([]string{"alice", "robert"}, 2, websocket.MoveToBreakout, s.handler.Tickets)
+	s.Equal([]websocket.MoveResult{
+		{Username: "alice", Moved: true},
+		{Username: "robert", Reason: "user not found in room"},
+	}, results)
+
+	var move websocket.MoveNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(alice, "move").Data, &move))
+	s.Equal(websocket.ID(2), move.RoomID)
+	s.Equal(websocket.MoveToBreakout, move.Reason)
+	alice.Close()
+
+	moved, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"2?username=alice&ticket="+move.Ticket, nil)
+	s.Require().NoError(err)
+	defer moved.Close()
+	s.Eventually(func() bool { return breakout.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	s.Equal(1, s.hub.RecallBreakouts(1, s.handler.Tickets))
+	var recall websocket.MoveNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(moved, "move").Data, &recall))
+	s.Equal(websocket.ID(1), recall.RoomID)
+	s.Equal(websocket.MoveRecall, recall.Reason)
+
+	s.hub.DeleteRoom(1)
+	_, exists := s.hub.GetRoom(2)
+	s.False(exists)
+}
+
+func (s *HandlerTestSuite) TestConnectionSpan() {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username=alice"
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL, nil)
+	s.Require().NoError(err)
+	s.Require().NoError(conn.WriteJSON(websocket.Message{Type: "message", Data: json.RawMessage(`{"text":"hi"}`)}))
+	s.readMessageOfType(conn, "message")
+	conn.WriteMessage(gorillaWs.CloseMessage, gorillaWs.FormatCloseMessage(gorillaWs.CloseNormalClosure, ""))
+	conn.Close()
+
+	// ended returns the ended spans named name
+	ended := func(name string) []sdktrace.ReadOnlySpan {
+		var spans []sdktrace.ReadOnlySpan
+		for _, span := range recorder.Ended() {
+			if span.Name() == name {
+				spans = append(spans, span)
+			}
+		}
+		return spans
+	}
+	s.Eventually(func() bool { return len(ended("ws.connection")) == 1 }, time.Second, 10*time.Millisecond)
+	span := ended("ws.connection")[0]
+	// The broadcast of the message is traced within its connection, the join
+	// notification, sent by the server, is not traced
+	fanouts := ended("ws.fanout")
+	s.Require().Len(fanouts, 1)
+	s.Equal(span.SpanContext().SpanID(), fanouts[0].Parent().SpanID())
+	s.Equal(span.SpanContext().TraceID(), fanouts[0].SpanContext().TraceID())
+	s.Equal(codes.Unset, span.Status().Code)
+	s.Require().Len(span.Events(), 1)
+	s.Equal("message", span.Events()[0].Name)
+	s.Contains(span.Events()[0].Attributes, attribute.String("message.type", "message"))
+}
+
+func (s *HandlerTestSuite) TestAttachmentsShareRoomUploads() {
+	dir := s.T().TempDir()
+	storage, err := websocket.NewLocalUploadStorage(dir, "/uploads")
+	s.Require().NoError(err)
+	s.hub.Uploads = storage
+	room, _ := s.hub.CreateRoom(1, nil)
+
+	key, uploadID := websocket.NewUploadKey(room.ID, ".txt")
+	url, err := storage.Put(context.Background(), key, "text/plain", strings.NewReader("notes"), 5)
+	s.Require().NoError(err)
+	s.Equal("/uploads/"+key, url)
+	s.Require().NoError(room.AddUpload(websocket.Upload{ID: uploadID, URL: url, Name: "notes.txt", ContentType: "text/plain", Size: 5}))
+
+	// The storage serves the file below its base path, without directory listings
+	w := httptest.NewRecorder()
+	storage.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal("notes", w.Body.String())
+	w = httptest.NewRecorder()
+	storage.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/uploads/"+websocket.UploadPrefix(room.ID), nil))
+	s.Equal(http.StatusNotFound, w.Code)
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	alice, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"alice", nil)
+	s.Require().NoError(err)
+	defer alice.Close()
+	robert, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"robert", nil)
+	s.Require().NoError(err)
+	defer robert.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	s.NoError(alice.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"attachment","data":{"url":"`+url+`","caption":"minutes"}}`)))
+	var shared websocket.AttachmentNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(robert, "attachment").Data, &shared))
+	s.Equal("alice", shared.Username)
+	s.Equal("notes.txt", shared.Name)
+	s.Equal("minutes", shared.Caption)
+	s.Equal(int64(5), shared.Size)
+
+	// Attachments must refer to an upload of the room
+	s.NoError(robert.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"attachment","data":{"url":"https://example.com/x.png"}}`)))
+	var rejected websocket.ErrorNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(robert, "error").Data, &rejected))
+	s.Equal(websocket.ErrCodeInvalidAttachment, rejected.Code)
+
+	// Deleting the room removes its files
+	s.True(s.hub.DeleteRoom(room.ID))
+	s.Eventually(func() bool {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(key)))
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *HandlerTestSuite) TestLockedRoomKeepsMembers() {
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithHost("host-1"), websocket.WithReconnectGrace(time.Second))
+	defer room.StopRoom()
+
+	token, err := websocket.NewHMACKeys("test-secret").Sign(websocket.NewHostClaims(1, "host-1", time.Now()))
+	s.Require().NoError(err)
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+
+	alice, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"alice&host_token="+token, nil)
+	s.Require().NoError(err)
+	defer alice.Close()
+	robert, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"robert", nil)
+	s.Require().NoError(err)
+	welcome := s.readWelcome(robert)
+	s.Eventually(func() bool { return room.GetClientCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	s.NoError(alice.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"lock"}`)))
+	var locked websocket.LockNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(robert, "lock").Data, &locked))
+	s.True(locked.Locked)
+	s.True(room.IsLocked())
+
+	// New members are turned away, hosts are not
+	_, resp, err := gorillaWs.DefaultDialer.Dial(wsURL+"carolyn", nil)
+	s.Require().Error(err)
+	s.Equal(http.StatusLocked, resp.StatusCode)
+	host, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"hostess&host_token="+token, nil)
+	s.Require().NoError(err)
+	host.Close()
+
+	// Current members keep chatting and may resume their session
+	robert.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+	robert, _, err = gorillaWs.DefaultDialer.Dial(wsURL+"robert&resume="+welcome.ResumeToken, nil)
+	s.Require().NoError(err)
+	defer robert.Close()
+	s.True(s.readWelcome(robert).Resumed)
+	s.NoError(robert.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"still here"}}`)))
+	s.readMessageOfType(alice, "chat")
+
+	// A join that passed the handler before the lock is turned away by the room
+	late := &websocket.Client{Send: make(chan []byte, 8), Room: room, Username: "dave"}
+	room.Register <- late
+	var refused websocket.Message
+	s.Require().NoError(json.Unmarshal(<-late.Send, &refused))
+	var lockedOut websocket.ErrorNotification
+	s.Require().NoError(json.Unmarshal(refused.Data, &lockedOut))
+	s.Equal(websocket.ErrCodeRoomLocked, lockedOut.Code)
+	_, open := <-late.Send
+	s.False(open)
+	s.Equal(2, room.GetClientCount())
+
+	s.True(room.SetLocked(false))
+	s.False(room.SetLocked(false))
+	carol, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"carolyn", nil)
+	s.Require().NoError(err)
+	carol.Close()
+}
+
+func (s *HandlerTestSuite) TestEditAndDeleteMessages() {
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithHost("host-1"))
+	defer room.StopRoom()
+
+	token, err := websocket.NewHMACKeys("test-secret").Sign(websocket.NewHostClaims(1, "host-1", time.Now()))
+	s.Require().NoError(err)
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	host, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"hostess&host_token="+token, nil)
+	s.Require().NoError(err)
+	defer host.Close()
+	alice, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"alice", nil)
+	s.Require().NoError(err)
+	defer alice.Close()
+	robert, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"robert", nil)
+	s.Require().NoError(err)
+	defer robert.Close()
+	s.Eventually(func() bool { return room.GetClientCount() == 3 }, time.Second, 10*time.Millisecond)
+
+	s.NoError(alice.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"helo"}}`)))
+	var chat websocket.ChatMessage
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(robert, "chat").Data, &chat))
+	s.Require().NotZero(chat.ID)
+	id := strconv.FormatUint(chat.ID, 10)
+
+	// Only the author or a host may change a message
+	s.NoError(robert.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"edit","data":{"id":`+id+`,"text":"hacked"}}`)))
+	var rejected websocket.ErrorNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(robert, "error").Data, &rejected))
+	s.Equal(websocket.ErrCodeEditFailed, rejected.Code)
+	s.Equal(websocket.ErrNotMessageAuthor.Error(), rejected.Message)
+
+	// Usernames are not unique, so a second connection named alice is not the author
+	impostor, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"alice", nil)
+	s.Require().NoError(err)
+	defer impostor.Close()
+	for _, msg := range []string{`{"type":"edit","data":{"id":` + id + `,"text":"hacked"}}`, `{"type":"delete","data":{"id":` + id + `}}`} {
+		s.NoError(impostor.WriteMessage(gorillaWs.TextMessage, []byte(msg)))
+		s.Require().NoError(json.Unmarshal(s.readMessageOfType(impostor, "error").Data, &rejected))
+		s.Equal(websocket.ErrNotMessageAuthor.Error(), rejected.Message)
+	}
+
+	s.NoError(alice.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"edit","data":{"id":`+id+`,"text":"hello"}}`)))
+	var edited websocket.MessageEditedNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(robert, "edit").Data, &edited))
+	s.Equal(websocket.MessageEditedNotification{ID: chat.ID, Username: "alice", EditedBy: "alice", Text: "hello", EditedAt: edited.EditedAt}, edited)
+	history := room.History(time.Time{}, time.Time{})
+	s.Require().Len(history, 1)
+	s.Equal("hello", history[0].Text)
+	s.False(history[0].EditedAt.IsZero())
+
+	s.NoError(host.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"delete","data":{"id":`+id+`}}`)))
+	var deleted websocket.MessageDeletedNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(alice, "delete").Data, &deleted))
+	s.Equal(websocket.MessageDeletedNotification{ID: chat.ID, Username: "alice", DeletedBy: "hostess"}, deleted)
+	s.Empty(room.History(time.Time{}, time.Time{}))
+
+	s.ErrorIs(room.DeleteMessage(chat.ID, "alice", "", false), websocket.ErrMessageNotFound)
+}
+
+func (s *HandlerTestSuite) TestStateTokenResumesAfterRestart() {
+	s.handler.States = websocket.NewStateTokens([]byte("state-key"), websocket.ResumeStateTTL)
+	room, _ := s.hub.CreateRoom(1, nil)
+
+	server := httptest.NewServer(s.engine)
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username=alice"
+	conn, _, err := gorillaWs.DefaultDialer.Dial(wsURL, nil)
+	s.Require().NoError(err)
+	var state websocket.StateMessage
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "state").Data, &state))
+	s.Zero(state.Cursor)
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"one"}}`)))
+	s.readMessageOfType(conn, "chat")
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"state","data":{"cursor":1}}`)))
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "state").Data, &state))
+	s.Equal(uint64(1), state.Cursor)
+	conn.Close()
+	server.Close()
+	snapshot, ok := room.BeginMigration()
+	s.Require().True(ok)
+	room.StopRoom()
+
+	// A new instance sharing the key, with the room restored from its snapshot
+	newInstance := func() (*websocket.Hub, string, func()) {
+		hub := websocket.NewHub()
+		handler := websocket.NewHandler(hub, s.pool)
+		handler.States = websocket.NewStateTokens([]byte("state-key"), websocket.ResumeStateTTL)
+		engine := gin.New()
+		engine.GET("/api/ws/:room_id", handler.HandleWebSocketWithJWT("test-secret"))
+		server := httptest.NewServer(engine)
+		return hub, "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?state=", server.Close
+	}
+	rejected := func(wsURL string) {
+		_, resp, err := gorillaWs.DefaultDialer.Dial(wsURL, nil)
+		s.Require().Error(err)
+		s.Equal(http.StatusUnauthorized, resp.StatusCode)
+		var body websocket.ErrorResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&body))
+		s.Equal(websocket.CodeInvalidToken, body.ErrorCode)
+	}
+
+	// A brand-new room that reuses the room ID does not accept the token
+	hub, wsURL, closeServer := newInstance()
+	unrelated, _ := hub.CreateRoom(1, nil)
+	rejected(wsURL + state.StateToken)
+	unrelated.StopRoom()
+	closeServer()
+
+	hub, wsURL, closeServer = newInstance()
+	defer closeServer()
+	restored, _ := hub.CreateRoom(1, nil, websocket.WithSnapshot(snapshot))
+	defer restored.StopRoom()
+	_, _, err = restored.ImportMessages([]websocket.ImportedMessage{{Username: "robert", Text: "two", SentAt: time.Now()}})
+	s.Require().NoError(err)
+	rejected(wsURL + state.StateToken + "x")
+
+	conn, _, err = gorillaWs.DefaultDialer.Dial(wsURL+state.StateToken+"&username=mallory", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+	var replay websocket.HistoryReplay
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "history").Data, &replay))
+	s.Require().Len(replay.Messages, 1)
+	s.Equal("two", replay.Messages[0].Text)
+	members := restored.ListClients()
+	s.Require().Len(members, 1)
+	s.Equal("alice", members[0].Username)
+	conn.Close()
+	s.Eventually(func() bool { return restored.GetClientCount() == 0 }, time.Second, 10*time.Millisecond)
+
+	// Bans apply to resumed identities
+	_, err = restored.Ban("alice", "", time.Hour, "host")
+	s.Require().NoError(err)
+	_, resp, err := gorillaWs.DefaultDialer.Dial(wsURL+state.StateToken, nil)
+	s.Require().Error(err)
+	s.Equal(http.StatusForbidden, resp.StatusCode)
+	restored.Unban("alice", "")
+
+	// Changing the password revokes tokens issued before
+	restored.SetPassword("new-hash")
+	rejected(wsURL + state.StateToken)
+}
+
+func (s *HandlerTestSuite) TestPayloadSchemaValidation() {
+	dir := s.T().TempDir()
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "chat.json"), []byte(`{
+		"type": "object",
+		"required": ["text"],
+		"properties": {"text": {"type": "string", "minLength": 1, "maxLength": 20}},
+		"additionalProperties": false
+	}`), 0o600))
+	schemas, err := websocket.LoadPayloadSchemas(dir)
+	s.Require().NoError(err)
+	s.Equal([]string{"chat"}, schemas.Types())
+	_, err = websocket.ParseJSONSchema([]byte(`{"type":"object","properties":{"id":{"type":"uuid"}}}`))
+	s.ErrorContains(err, "$.id")
+	// Keywords outside the supported subset fail loading instead of being ignored
+	unsupported := s.T().TempDir()
+	s.Require().NoError(os.WriteFile(filepath.Join(unsupported, "chat.json"), []byte(`{
+		"type": "object",
+		"description": "chat payload",
+		"properties": {"id": {"type": "string", "format": "uuid"}}
+	}`), 0o600))
+	_, err = websocket.LoadPayloadSchemas(unsupported)
+	s.ErrorContains(err, `chat.json: $.id: unsupported keyword "format"`)
+	s.handler.SignalingHandler.UseSchemas(schemas)
+
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	conn, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws/1?username=alice", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"hello"}}`)))
+	s.readMessageOfType(conn, "chat")
+
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":5,"color":"red"}}`)))
+	var rejected websocket.ErrorNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "error").Data, &rejected))
+	s.Equal(websocket.ErrCodeInvalidPayload, rejected.Code)
+	s.Equal([]websocket.ValidationError{
+		{Field: "data.color", Message: "is not allowed"},
+		{Field: "data.text", Message: "must be of type string"},
+	}, rejected.Fields)
+
+	// Messages that are not JSON are no longer dropped silently
+	s.NoError(conn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":`)))
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(conn, "error").Data, &rejected))
+	s.Equal(websocket.ErrCodeInvalidPayload, rejected.Code)
+	s.Require().Len(rejected.Fields, 1)
+	s.Equal("$", rejected.Fields[0].Field)
+	s.Len(room.History(time.Time{}, time.Time{}), 1)
+}
+
+func (s *HandlerTestSuite) TestSuggestUsernames() {
+	registry := websocket.NewUsernameRegistry(websocket.UniqueRoom)
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+
+	names := websocket.SuggestUsernames(room, registry, nil, "", websocket.MaxUsernameSuggestions+5)
+	s.Require().Len(names, websocket.MaxUsernameSuggestions)
+	seen := make(map[string]bool)
+	for _, name := range names {
+		s.GreaterOrEqual(len(name), websocket.MinUsernameLength)
+		s.False(seen[strings.ToLower(name)], "duplicate suggestion %s", name)
+		seen[strings.ToLower(name)] = true
+	}
+
+	// Words the room blocks never show up in suggestions
+	blocked := strings.Split(names[0], "-")[1]
+	s.Require().NoError(room.SetContentFilter(websocket.RoomContentFilter{Words: []string{blocked}}))
+	for _, name := range websocket.SuggestUsernames(room, registry, nil, "", websocket.MaxUsernameSuggestions) {
+		s.NotContains(strings.Split(name, "-"), blocked)
+	}
+	filter := websocket.NewContentFilter([]string{blocked}, websocket.FilterReject)
+	s.True(filter.Blocks(room, "a "+blocked+" here"))
+
+	// Taken names are only available to their holder
+	release, err := registry.Claim(room, names[1], "session-1")
+	s.Require().NoError(err)
+	s.False(registry.Available(room, strings.ToUpper(names[1]), "session-2"))
+	s.True(registry.Available(room, names[1], "session-1"))
+	release()
+	s.True(registry.Available(room, names[1], "session-2"))
+}
+
+func (s *HandlerTestSuite) TestHubConnectionRegistry() {
+	first, _ := s.hub.CreateRoom(1, nil)
+	defer first.StopRoom()
+	second, _ := s.hub.CreateRoom(2, nil)
+	defer second.StopRoom()
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	baseURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/"
+	var conns []*gorillaWs.Conn
+	for _, path := range []string{"1?username=mallory", "2?username=Mallory", "2?username=alice"} {
+		conn, _, err := gorillaWs.DefaultDialer.Dial(baseURL+path, nil)
+		s.Require().NoError(err)
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+	registry := s.hub.Connections
+	s.Eventually(func() bool { return registry.Len() == 3 }, time.Second, 10*time.Millisecond)
+
+	found := registry.Find(websocket.ConnectionFilter{Username: "MALLORY"})
+	s.Require().Len(found, 2)
+	s.ElementsMatch([]websocket.ID{1, 2}, []websocket.ID{found[0].RoomID, found[1].RoomID})
+	s.Len(registry.Find(websocket.ConnectionFilter{RoomID: 2}), 2)
+	info, ok := registry.Lookup(found[0].ConnID)
+	s.Require().True(ok)
+	s.Equal(found[0], info)
+
+	s.Equal(0, registry.DisconnectMatching(websocket.ConnectionFilter{}))
+	s.Equal(2, registry.DisconnectMatching(websocket.ConnectionFilter{Username: "mallory"}))
+	for _, conn := range conns[:2] {
+		s.NoError(conn.SetReadDeadline(time.Now().Add(2 * time.Second)))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				s.True(gorillaWs.IsCloseError(err, websocket.ClosePolicyViolation), "unexpected close: %v", err)
+				break
+			}
+		}
+	}
+	s.Eventually(func() bool { return registry.Len() == 1 }, time.Second, 10*time.Millisecond)
+	_, ok = registry.Lookup(found[0].ConnID)
+	s.False(ok)
+	s.False(registry.Disconnect(found[0].ConnID))
+}
+
+func (s *HandlerTestSuite) TestHubBanKeepsUserOutOfEveryRoom() {
+	for _, id := range []websocket.ID{1, 2} {
+		room, _ := s.hub.CreateRoom(id, nil)
+		defer room.StopRoom()
+	}
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	baseURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/"
+	conn, _, err := gorillaWs.DefaultDialer.Dial(baseURL+"1?username=mallory", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+	s.Eventually(func() bool { return s.hub.Connections.Len() == 1 }, time.Second, 10*time.Millisecond)
+
+	_, err = s.hub.Ban("Mallory", "", 0, "")
+	s.Require().NoError(err)
+	s.Eventually(func() bool { return s.hub.Connections.Len() == 0 }, time.Second, 10*time.Millisecond)
+	for _, path := range []string{"1?username=mallory", "2?username=MALLORY"} {
+		_, resp, err := gorillaWs.DefaultDialer.Dial(baseURL+path, nil)
+		s.Require().Error(err)
+		s.Equal(http.StatusForbidden, resp.StatusCode)
+	}
+	s.Len(s.hub.Bans(), 1)
+
+	s.True(s.hub.Unban("mallory", ""))
+	s.False(s.hub.Unban("mallory", ""))
+	conn, _, err = gorillaWs.DefaultDialer.Dial(baseURL+"2?username=mallory", nil)
+	s.Require().NoError(err)
+	conn.Close()
+
+	_, err = s.hub.Ban("mallory", "203.0.113.7", 0, "")
+	s.ErrorIs(err, websocket.ErrBanTargetRequired)
+}
+
+func (s *HandlerTestSuite) TestReadReceipts() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	baseURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+	alice, _, err := gorillaWs.DefaultDialer.Dial(baseURL+"alice", nil)
+	s.Require().NoError(err)
+	defer alice.Close()
+	bobby, _, err := gorillaWs.DefaultDialer.Dial(baseURL+"bobby", nil)
+	s.Require().NoError(err)
+	defer bobby.Close()
+
+	s.Require().NoError(alice.WriteJSON(map[string]any{"type": "chat", "data": map[string]string{"text": "hello"}}))
+	chat := s.readMessageOfType(bobby, "chat")
+	s.Require().NotZero(chat.Seq)
+	s.Require().NoError(bobby.WriteJSON(map[string]any{"type": "read", "data": map[string]uint64{"seq": chat.Seq}}))
+	// Positions beyond the latest broadcast are clamped to it
+	s.Require().NoError(alice.WriteJSON(map[string]any{"type": "read", "data": map[string]uint64{"seq": chat.Seq + 1000}}))
+
+	var delta websocket.ReadStateNotification
+	deltaMsg := s.readMessageOfType(alice, "read")
+	s.Require().NoError(json.Unmarshal(deltaMsg.Data, &delta))
+	s.Equal(map[string]uint64{"alice": chat.Seq, "bobby": chat.Seq}, delta.Positions)
+
+	// Deltas are not sequenced, so reporting the latest seq after one produces no other
+	s.Zero(deltaMsg.Seq)
+	s.Equal(chat.Seq, room.LastSeq())
+	events, _, _ := room.EventsSince(0, 100, true)
+	for _, event := range events {
+		s.NotEqual("read", event.Type)
+	}
+	s.Require().NoError(alice.WriteJSON(map[string]any{"type": "read", "data": map[string]uint64{"seq": room.LastSeq()}}))
+	s.NoError(alice.SetReadDeadline(time.Now().Add(websocket.ReadStateInterval + 500*time.Millisecond)))
+	for {
+		_, raw, err := alice.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			s.True(errors.As(err, &netErr) && netErr.Timeout(), "expected no delta, got %v", err)
+			break
+		}
+		var msg websocket.Message
+		s.Require().NoError(json.Unmarshal(raw, &msg))
+		s.NotEqual("read", msg.Type)
+	}
+
+	// A stale position does not move the read state back
+	s.Require().NoError(bobby.WriteJSON(map[string]any{"type": "read", "data": map[string]uint64{"seq": 1}}))
+	s.Eventually(func() bool { return room.ReadPositions()["bobby"] == chat.Seq }, time.Second, 10*time.Millisecond)
+
+	carol, _, err := gorillaWs.DefaultDialer.Dial(baseURL+"carol", nil)
+	s.Require().NoError(err)
+	defer carol.Close()
+	var state websocket.ReadStateNotification
+	s.Require().NoError(json.Unmarshal(s.readMessageOfType(carol, "read").Data, &state))
+	s.Equal(delta.Positions, state.Positions)
+
+	s.Require().NoError(bobby.Close())
+	s.Eventually(func() bool { _, ok := room.ReadPositions()["bobby"]; return !ok }, time.Second, 10*time.Millisecond)
+}
+
+func (s *HandlerTestSuite) TestRTTIgnoresForgedPongs() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/1?username="
+
+	// A pong claiming a ping sent long ago does not count as a round trip
+	forger, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"forger", nil)
+	s.Require().NoError(err)
+	defer forger.Close()
+	pinged := make(chan struct{}, 1)
+	forger.SetPingHandler(func(string) error {
+		pinged <- struct{}{}
+		return forger.WriteControl(gorillaWs.PongMessage, []byte("1"), time.Now().Add(time.Second))
+	})
+	go func() {
+		for {
+			if _, _, err := forger.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case <-pinged:
+	case <-time.After(2 * time.Second):
+		s.FailNow("no ping received")
+	}
+
+	// Echoing the ping payload measures the round trip
+	honest, _, err := gorillaWs.DefaultDialer.Dial(wsURL+"honest", nil)
+	s.Require().NoError(err)
+	defer honest.Close()
+	go func() {
+		for {
+			if _, _, err := honest.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	s.Eventually(func() bool { return room.RTTStats().Samples == 1 }, 2*time.Second, 10*time.Millisecond)
+	s.Less(room.RTTStats().P99, time.Second)
+}
+
+func (s *HandlerTestSuite) TestSlowPongReportsQuality() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+
+	conn, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws/1?username=testuser", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+	// Answering the first ping 1.2s late measures that round-trip time
+	conn.SetPingHandler(func(appData string) error {
+		time.Sleep(1200 * time.Millisecond)
+		return conn.WriteControl(gorillaWs.PongMessage, []byte(appData), time.Now().Add(time.Second))
+	})
+
+	s.Require().NoError(conn.SetReadDeadline(time.Now().Add(3 * time.Second)))
+	for {
+		var msg websocket.Message
+		s.Require().NoError(conn.ReadJSON(&msg))
+		if msg.Type != "quality" {
+			continue
+		}
+		var quality websocket.QualityNotification
+		s.NoError(json.Unmarshal(msg.Data, &quality))
+		s.Equal(websocket.QualityFair, quality.Level)
+		s.Equal(50, quality.Score)
+		s.GreaterOrEqual(quality.RTTMs, int64(1200))
+		s.Empty(quality.Username)
+		return
+	}
+}
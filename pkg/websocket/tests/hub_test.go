@@ -1,7 +1,18 @@
 package websocket_test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/YuarenArt/chatters/pkg/websocket"
 	"github.com/stretchr/testify/suite"
@@ -38,6 +49,748 @@ func (s *HubTestSuite) TestDeleteRoom() {
 	s.False(exists)
 }
 
+func (s *HubTestSuite) TestListRoomsFiltersVisibilityAndTag() {
+	s.hub.CreateRoom(3, nil, websocket.WithVisibility(websocket.VisibilityPublic), websocket.WithTags("Golang", "chat"))
+	s.hub.CreateRoom(1, nil, websocket.WithVisibility(websocket.VisibilityPublic), websocket.WithTags("music"))
+	s.hub.CreateRoom(2, nil, websocket.WithTags("golang"))
+	defer func() {
+		for _, id := range []websocket.ID{1, 2, 3} {
+			s.hub.DeleteRoom(id)
+		}
+	}()
+
+	public := s.hub.ListRooms(websocket.RoomFilter{Visibility: websocket.VisibilityPublic})
+	s.Require().Len(public, 2)
+	s.Equal(websocket.ID(1), public[0].ID)
+	s.Equal(websocket.ID(3), public[1].ID)
+	s.Equal([]string{"golang", "chat"}, public[1].Tags)
+
+	tagged := s.hub.ListRooms(websocket.RoomFilter{Visibility: websocket.VisibilityPublic, Tag: "GOLANG"})
+	s.Require().Len(tagged, 1)
+	s.Equal(websocket.ID(3), tagged[0].ID)
+
+	s.Len(s.hub.ListRooms(websocket.RoomFilter{Tag: "golang"}), 2)
+}
+
 func TestHubTestSuite(t *testing.T) {
 	suite.Run(t, new(HubTestSuite))
 }
+
+// fakeBroker is an in-memory Broker shared by several hubs
+type fakeBroker struct {
+	subscribers map[websocket.ID][]func(uint64, []byte)
+	published   [][]byte
+	seqs        map[websocket.ID]uint64
+	mu          sync.Mutex
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{
+		subscribers: make(map[websocket.ID][]func(uint64, []byte)),
+		seqs:        make(map[websocket.ID]uint64),
+	}
+}
+
+func (b *fakeBroker) Publish(_ context.Context, roomID websocket.ID, msg []byte, sequenced bool) error {
+	b.mu.Lock()
+	b.published = append(b.published, msg)
+	var seq uint64
+	if sequenced {
+		b.seqs[roomID]++
+		seq = b.seqs[roomID]
+	}
+	subscribers := append([]func(uint64, []byte){}, b.subscribers[roomID]...)
+	b.mu.Unlock()
+	for _, deliver := range subscribers {
+		deliver(seq, msg)
+	}
+	return nil
+}
+
+func (b *fakeBroker) Subscribe(roomID websocket.ID, deliver func(uint64, []byte)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[roomID] = append(b.subscribers[roomID], deliver)
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, roomID)
+	}, nil
+}
+
+func (b *fakeBroker) Close() error { return nil }
+
+// publishedTypes returns the envelope types of the published messages
+func (b *fakeBroker) publishedTypes() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	types := make([]string, 0, len(b.published))
+	for _, msg := range b.published {
+		var envelope websocket.Message
+		_ = json.Unmarshal(msg, &envelope)
+		types = append(types, envelope.Type)
+	}
+	return types
+}
+
+// unavailableBroker is a Broker whose subscriptions fail
+type unavailableBroker struct{ *fakeBroker }
+
+func (b *unavailableBroker) Subscribe(websocket.ID, func(uint64, []byte)) (func(), error) {
+	return nil, errors.New("broker unavailable")
+}
+
+func (s *HubTestSuite) TestFailedSubscribeDeliversLocally() {
+	s.hub.Broker = &unavailableBroker{fakeBroker: newFakeBroker()}
+	// Rooms are used as soon as they are visible, while the hub falls back to local delivery
+	for id := websocket.ID(1); id <= 50; id++ {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for {
+				if room, ok := s.hub.GetRoom(id); ok {
+					room.SetLocked(true)
+					return
+				}
+				runtime.Gosched()
+			}
+		}()
+		room, created := s.hub.CreateRoom(id, nil)
+		s.Require().True(created)
+		defer room.StopRoom()
+		<-done
+		s.Eventually(func() bool { return room.LastSeq() == 1 }, time.Second, time.Millisecond, "broadcasts are delivered locally")
+	}
+}
+
+func (s *HubTestSuite) TestBroadcastThroughBroker() {
+	broker := newFakeBroker()
+	s.hub.Broker = broker
+	other := websocket.NewHub()
+	other.Broker = broker
+
+	room, created := s.hub.CreateRoom(1, nil)
+	s.Require().True(created)
+	remote, created := other.CreateRoom(1, nil)
+	s.Require().True(created)
+
+	client := &websocket.Client{Send: make(chan []byte, 8), Room: remote, Username: "remote"}
+	remote.Register <- client
+	s.Eventually(func() bool { return remote.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	room.Broadcast <- []byte(`{"type":"chat"}`)
+	s.Eventually(func() bool {
+		for {
+			select {
+			case msg := <-client.Send:
+				var delivered websocket.Message
+				if json.Unmarshal(msg, &delivered) == nil && delivered.Type == "chat" && delivered.Seq > 0 {
+					return true
+				}
+			default:
+				return false
+			}
+		}
+	}, time.Second, 10*time.Millisecond, "message was not delivered through the broker")
+	s.Equal([]string{"join", "chat"}, broker.publishedTypes())
+
+	remote.Unregister <- client
+	s.Eventually(func() bool { return remote.GetClientCount() == 0 }, time.Second, 10*time.Millisecond)
+	room.StopRoom()
+	remote.StopRoom()
+}
+
+func (s *HubTestSuite) TestBrokerNumbersBroadcastsAlikeOnEveryInstance() {
+	broker := newFakeBroker()
+	s.hub.Broker = broker
+	other := websocket.NewHub()
+	other.Broker = broker
+
+	room, _ := s.hub.CreateRoom(1, nil)
+	local := &websocket.Client{Send: make(chan []byte, 8), Room: room, Username: "local"}
+	room.Register <- local
+	s.Eventually(func() bool { return room.LastSeq() == 1 }, time.Second, 10*time.Millisecond)
+
+	// The second instance subscribes after the first broadcast
+	remote, _ := other.CreateRoom(1, nil)
+	room.Broadcast <- []byte(`{"type":"chat"}`)
+	client := &websocket.Client{Send: make(chan []byte, 8), Room: remote, Username: "remote"}
+	remote.Register <- client
+	s.Eventually(func() bool { return room.LastSeq() == 3 && remote.LastSeq() == 3 }, time.Second, 10*time.Millisecond,
+		"instances numbered broadcasts differently")
+
+	remote.Unregister <- client
+	room.Unregister <- local
+	s.Eventually(func() bool { return remote.GetClientCount() == 0 && room.GetClientCount() == 0 }, time.Second, 10*time.Millisecond)
+	room.StopRoom()
+	remote.StopRoom()
+}
+
+type fakeJanitorMetrics struct {
+	reaped []string
+	active int
+	mu     sync.Mutex
+}
+
+func (m *fakeJanitorMetrics) ActiveRooms(count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active = count
+}
+
+func (m *fakeJanitorMetrics) RoomReaped(roomID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reaped = append(m.reaped, roomID)
+}
+
+func (s *HubTestSuite) TestModerationStateIsDroppedWithTheRoom() {
+	store := websocket.NewMemoryModerationStore()
+	s.hub.Moderation = store
+	stored := func(id websocket.ID) bool {
+		_, ok, err := store.Load(context.Background(), id)
+		s.Require().NoError(err)
+		return ok
+	}
+	banned := func(id websocket.ID) *websocket.Room {
+		room, _ := s.hub.CreateRoom(id, nil, websocket.WithHost("host-1"))
+		_, err := room.Ban("spammer", "", 0, "host")
+		s.Require().NoError(err)
+		s.Require().True(stored(id))
+		return room
+	}
+
+	// Migrating hands the state to the target, a failed migration keeps it
+	migrated := banned(1)
+	_, ok := migrated.BeginMigration()
+	s.Require().True(ok)
+	s.False(stored(1))
+	migrated.CancelMigration()
+	s.True(stored(1))
+	_, ok = migrated.BeginMigration()
+	s.Require().True(ok)
+	s.True(s.hub.CompleteMigration(1, "wss://other.example.com"))
+	s.False(stored(1))
+
+	banned(2)
+	_, ok = s.hub.ScheduleClose(2, 50*time.Millisecond)
+	s.Require().True(ok)
+	s.Eventually(func() bool { return !stored(2) }, 3*time.Second, 20*time.Millisecond)
+
+	banned(3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.hub.RunJanitor(ctx, 100*time.Millisecond, nil)
+	s.Eventually(func() bool { return !stored(3) }, 3*time.Second, 50*time.Millisecond)
+
+	// A new room with the ID of a reaped one starts without its bans
+	room, _ := s.hub.CreateRoom(3, nil)
+	_, isBanned := room.IsBanned("spammer", "")
+	s.False(isBanned)
+	room.StopRoom()
+}
+
+// sharedStorage is an UploadStorage every instance reaches, recording deletions
+type sharedStorage struct {
+	mu      sync.Mutex
+	deleted []string
+}
+
+func (st *sharedStorage) Put(_ context.Context, key, _ string, _ io.Reader, _ int64) (string, error) {
+	return "https://bucket.example.com/" + key, nil
+}
+
+func (st *sharedStorage) DeletePrefix(_ context.Context, prefix string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.deleted = append(st.deleted, prefix)
+	return nil
+}
+
+func (st *sharedStorage) Shared() bool { return true }
+
+func (s *HubTestSuite) TestUploadReservationsHoldTheLimit() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	for i := range websocket.MaxRoomUploads - 1 {
+		s.Require().NoError(room.AddUpload(websocket.Upload{URL: "/uploads/" + strconv.Itoa(i)}))
+	}
+
+	// A file being stored holds the last slot
+	reservation, err := room.ReserveUpload()
+	s.Require().NoError(err)
+	_, err = room.ReserveUpload()
+	s.ErrorIs(err, websocket.ErrTooManyUploads)
+	s.ErrorIs(room.AddUpload(websocket.Upload{URL: "/uploads/other"}), websocket.ErrTooManyUploads)
+
+	reservation.Release()
+	reservation, err = room.ReserveUpload()
+	s.Require().NoError(err)
+	s.NoError(reservation.Commit(websocket.Upload{URL: "/uploads/last"}))
+	reservation.Release()
+	_, err = room.ReserveUpload()
+	s.ErrorIs(err, websocket.ErrTooManyUploads)
+
+	// A room closed while the file was stored doesn't take it
+	closed, _ := s.hub.CreateRoom(2, nil)
+	reservation, err = closed.ReserveUpload()
+	s.Require().NoError(err)
+	s.hub.DeleteRoom(2)
+	s.ErrorIs(reservation.Commit(websocket.Upload{URL: "/uploads/late"}), websocket.ErrRoomStopped)
+}
+
+func (s *HubTestSuite) TestUploadsFollowMigratedRooms() {
+	// Files of instance-local storage stay behind and are deleted
+	dir := s.T().TempDir()
+	local, err := websocket.NewLocalUploadStorage(dir, "/uploads")
+	s.Require().NoError(err)
+	s.hub.Uploads = local
+	room, _ := s.hub.CreateRoom(1, nil)
+	key, uploadID := websocket.NewUploadKey(room.ID, ".txt")
+	url, err := local.Put(context.Background(), key, "text/plain", strings.NewReader("notes"), 5)
+	s.Require().NoError(err)
+	s.Require().NoError(room.AddUpload(websocket.Upload{ID: uploadID, URL: url}))
+	snapshot, ok := room.BeginMigration()
+	s.Require().True(ok)
+	s.Empty(snapshot.Uploads)
+	s.True(s.hub.CompleteMigration(1, "wss://other.example.com"))
+	s.Eventually(func() bool {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(key)))
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+
+	// Uploads in shared storage move with the room
+	shared := &sharedStorage{}
+	s.hub.Uploads = shared
+	room, _ = s.hub.CreateRoom(2, nil)
+	upload := websocket.Upload{ID: "u1", URL: "https://bucket.example.com/rooms/2/u1.txt", Name: "notes.txt"}
+	s.Require().NoError(room.AddUpload(upload))
+	snapshot, ok = room.BeginMigration()
+	s.Require().True(ok)
+	s.Require().Len(snapshot.Uploads, 1)
+	s.Equal(upload.URL, snapshot.Uploads[0].URL)
+	s.True(s.hub.CompleteMigration(2, "wss://other.example.com"))
+
+	target := websocket.NewHub()
+	target.Uploads = shared
+	restored, created := target.RestoreRoom(snapshot, nil)
+	s.Require().True(created)
+	again, ok := restored.BeginMigration()
+	s.Require().True(ok)
+	s.Equal(snapshot.Uploads, again.Uploads)
+	restored.StopRoom()
+
+	time.Sleep(50 * time.Millisecond)
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+	s.Empty(shared.deleted)
+}
+
+func (s *HubTestSuite) TestJanitorReapsIdleRooms() {
+	idle, _ := s.hub.CreateRoom(1, nil)
+	busy, _ := s.hub.CreateRoom(2, nil)
+	client := &websocket.Client{Send: make(chan []byte, 8), Room: busy, Username: "user"}
+	busy.Register <- client
+	s.Eventually(func() bool { return busy.GetClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	metrics := &fakeJanitorMetrics{}
+	go s.hub.RunJanitor(ctx, 100*time.Millisecond, metrics)
+
+	s.Eventually(func() bool {
+		_, exists := s.hub.GetRoom(1)
+		return !exists
+	}, 3*time.Second, 50*time.Millisecond)
+	_, exists := s.hub.GetRoom(2)
+	s.True(exists)
+
+	metrics.mu.Lock()
+	s.Equal([]string{"1"}, metrics.reaped)
+	s.Equal(1, metrics.active)
+	metrics.mu.Unlock()
+
+	busy.Unregister <- client
+	s.Eventually(func() bool { return busy.GetClientCount() == 0 }, time.Second, 10*time.Millisecond)
+	idle.StopRoom()
+	busy.StopRoom()
+}
+
+type fakeCompactionMetrics struct {
+	messages int
+	bytes    int
+	mu       sync.Mutex
+}
+
+func (m *fakeCompactionMetrics) HistoryCompacted(roomID string, messages int, bytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages += messages
+	m.bytes += bytes
+}
+
+func (s *HubTestSuite) TestCompactorReclaimsExpiredHistory() {
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithSettings(websocket.RoomSettings{Retention: 50 * time.Millisecond}))
+	defer room.StopRoom()
+	kept, _ := s.hub.CreateRoom(2, nil)
+	defer kept.StopRoom()
+
+	room.PostBotMessage("bot", "expires soon")
+	kept.PostBotMessage("bot", "kept forever")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	metrics := &fakeCompactionMetrics{}
+	go s.hub.RunCompactor(ctx, 20*time.Millisecond, metrics)
+
+	s.Eventually(func() bool {
+		metrics.mu.Lock()
+		defer metrics.mu.Unlock()
+		return metrics.messages == 1
+	}, 2*time.Second, 20*time.Millisecond)
+
+	metrics.mu.Lock()
+	s.Positive(metrics.bytes)
+	metrics.mu.Unlock()
+	s.Len(kept.History(time.Time{}, time.Time{}), 1)
+}
+
+func (s *HubTestSuite) TestEventBusPublishesRoomLifecycle() {
+	s.hub.Events = websocket.NewEventBus()
+	var (
+		mu     sync.Mutex
+		events []websocket.ServerEvent
+	)
+	s.hub.Events.Subscribe(func(event websocket.ServerEvent) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	})
+
+	room, _ := s.hub.CreateRoom(7, nil)
+	client := &websocket.Client{Send: make(chan []byte, 16), Room: room, Username: "alice"}
+	room.Register <- client
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 5*time.Millisecond)
+	room.Unregister <- client
+	s.Eventually(func() bool { return room.GetClientCount() == 0 }, time.Second, 5*time.Millisecond)
+	s.hub.DeleteRoom(7)
+
+	mu.Lock()
+	defer mu.Unlock()
+	s.Require().Len(events, 3)
+	s.Equal(websocket.ServerEventRoomCreated, events[0].Type)
+	s.Equal(websocket.ServerEventUserJoined, events[1].Type)
+	s.Equal("alice", events[1].Username)
+	s.Equal(websocket.ServerEventRoomDeleted, events[2].Type)
+	for _, event := range events {
+		s.Equal(websocket.ID(7), event.RoomID)
+		s.False(event.At.IsZero())
+	}
+}
+
+func (s *HubTestSuite) TestWireNamingOfJoinNotifications() {
+	for naming, want := range map[websocket.WireNaming]string{
+		websocket.NamingLegacy: `{"username":"alice","onlineCount":1}`,
+		websocket.NamingCompat: `{"username":"alice","onlineCount":1,"online_count":1}`,
+		websocket.NamingSnake:  `{"username":"alice","online_count":1}`,
+	} {
+		s.hub.Naming = naming
+		room, _ := s.hub.CreateRoom(1, nil)
+		client := &websocket.Client{Send: make(chan []byte, 16), Room: room, Username: "alice"}
+		room.Register <- client
+
+		var msg websocket.Message
+		s.Require().NoError(json.Unmarshal(<-client.Send, &msg))
+		s.Equal("join", msg.Type)
+		s.JSONEq(want, string(msg.Data), naming)
+
+		var notification websocket.JoinNotification
+		s.Require().NoError(json.Unmarshal(msg.Data, &notification))
+		s.Equal(1, notification.OnlineCount)
+
+		room.Unregister <- client
+		s.Eventually(func() bool { return room.GetClientCount() == 0 }, time.Second, 5*time.Millisecond)
+		s.hub.DeleteRoom(1)
+	}
+}
+
+func (s *HubTestSuite) TestWelcomeMessageOnlyToJoiner() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer s.hub.DeleteRoom(1)
+	welcome := "Read the agenda first"
+	_, applied := room.UpdateSettings(websocket.SettingsUpdate{Welcome: &welcome}, room.SettingsVersion())
+	s.Require().True(applied)
+
+	types := func(client *websocket.Client) []string {
+		var types []string
+		for {
+			select {
+			case raw := <-client.Send:
+				var msg websocket.Message
+				s.Require().NoError(json.Unmarshal(raw, &msg))
+				types = append(types, msg.Type)
+				if msg.Type == "system" {
+					var notification websocket.SystemNotification
+					s.Require().NoError(json.Unmarshal(msg.Data, &notification))
+					s.Equal(websocket.SystemNotification{Text: welcome, Kind: "welcome"}, notification)
+				}
+			case <-time.After(100 * time.Millisecond):
+				return types
+			}
+		}
+	}
+
+	alice := &websocket.Client{Send: make(chan []byte, 16), Room: room, Username: "alice"}
+	room.Register <- alice
+	s.Contains(types(alice), "system")
+
+	bob := &websocket.Client{Send: make(chan []byte, 16), Room: room, Username: "bob1"}
+	room.Register <- bob
+	s.Contains(types(bob), "system")
+	aliceTypes := types(alice)
+	s.Contains(aliceTypes, "join")
+	s.NotContains(aliceTypes, "system")
+
+	for _, client := range []*websocket.Client{alice, bob} {
+		room.Unregister <- client
+	}
+	s.Eventually(func() bool { return room.GetClientCount() == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func (s *HubTestSuite) TestOccupancyHistoryOutlivesRoom() {
+	s.hub.Occupancy = websocket.NewMemoryOccupancyStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.hub.RunOccupancySampler(ctx, 10*time.Millisecond, time.Hour)
+
+	_, created := s.hub.CreateRoom(1, nil)
+	s.Require().True(created)
+	s.Eventually(func() bool { return len(s.hub.OccupancyHistory(1)) >= 3 }, time.Second, 5*time.Millisecond)
+	s.True(s.hub.DeleteRoom(1))
+	cancel()
+
+	history := s.hub.OccupancyHistory(1)
+	s.Equal(0, history[len(history)-1].Clients)
+	for i := 1; i < len(history); i++ {
+		s.False(history[i].At.Before(history[i-1].At))
+	}
+
+	store := websocket.NewMemoryOccupancyStore()
+	start := time.Now()
+	for i := 0; i < websocket.MaxOccupancySamples+10; i++ {
+		store.Record(2, websocket.OccupancySample{At: start.Add(time.Duration(i) * time.Second), Clients: i})
+	}
+	samples := store.History(2)
+	s.Len(samples, websocket.MaxOccupancySamples)
+	s.Equal(10, samples[0].Clients)
+	store.Prune(start.Add(time.Duration(websocket.MaxOccupancySamples+10) * time.Second))
+	s.Empty(store.History(2))
+}
+
+// fakeConn is a Conn that discards writes and blocks reads until it is closed
+type fakeConn struct {
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{closed: make(chan struct{})}
+}
+
+func (c *fakeConn) SetReadLimit(int64)                        {}
+func (c *fakeConn) SetPongHandler(func(string) error)         {}
+func (c *fakeConn) SetReadDeadline(time.Time) error           { return nil }
+func (c *fakeConn) SetWriteDeadline(time.Time) error          { return nil }
+func (c *fakeConn) WriteMessage(int, []byte) error            { return nil }
+func (c *fakeConn) WriteControl(int, []byte, time.Time) error { return nil }
+func (c *fakeConn) Close() error                              { c.once.Do(func() { close(c.closed) }); return nil }
+func (c *fakeConn) ReadMessage() (int, []byte, error)         { <-c.closed; return 0, nil, io.EOF }
+
+func (c *fakeConn) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *HubTestSuite) TestSignalingRelayRacesKicks() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	defer room.StopRoom()
+	signaling := websocket.NewSignalingHandler()
+	websocket.RegisterDefaultSignaling(signaling)
+	sender := &websocket.Client{Conn: newFakeConn(), Send: make(chan []byte, 256), Room: room, Username: "alice"}
+	go sender.Write()
+	room.Register <- sender
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 5*time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		offer := websocket.Message{Type: "offer", Data: json.RawMessage(`{"sdp":"v=0"}`)}
+		for i := 0; i < 2000; i++ {
+			signaling.Handle(sender, offer)
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		peer := &websocket.Client{Conn: newFakeConn(), Send: make(chan []byte, 256), Room: room, Username: "peer" + strconv.Itoa(i)}
+		go peer.Write()
+		room.Register <- peer
+		time.Sleep(time.Millisecond)
+		room.KickClients([]string{peer.Username}, "host")
+	}
+	<-done
+}
+
+func (s *HubTestSuite) TestSlowConsumerPolicies() {
+	// broadcast joins a client with a queue of two to room, sends count broadcasts
+	// while it does not read and returns the ones it then receives
+	broadcast := func(room *websocket.Room, count int) []int {
+		client := &websocket.Client{Send: make(chan []byte, 2), Room: room, Username: "alice"}
+		room.Register <- client
+		s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 5*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		for len(client.Send) > 0 {
+			<-client.Send
+		}
+
+		last := room.LastSeq()
+		for i := 1; i <= count; i++ {
+			msg, _ := json.Marshal(websocket.Message{Type: "test", Data: json.RawMessage(strconv.Itoa(i))})
+			room.Broadcast <- msg
+		}
+		s.Eventually(func() bool { return room.LastSeq() == last+uint64(count) }, time.Second, 5*time.Millisecond)
+		s.Equal(1, room.GetClientCount())
+
+		var received []int
+		for {
+			select {
+			case msg := <-client.Send:
+				var message websocket.Message
+				s.Require().NoError(json.Unmarshal(msg, &message))
+				n, _ := strconv.Atoi(string(message.Data))
+				received = append(received, n)
+			case <-time.After(100 * time.Millisecond):
+				return received
+			}
+		}
+	}
+
+	dropOldest, _ := s.hub.CreateRoom(1, nil, websocket.WithSlowConsumerPolicy(websocket.SlowConsumerDropOldest))
+	s.Equal([]int{4, 5}, broadcast(dropOldest, 5))
+
+	dir := s.T().TempDir()
+	spooled, _ := s.hub.CreateRoom(2, nil,
+		websocket.WithSlowConsumerPolicy(websocket.SlowConsumerBufferToDisk), websocket.WithSpoolDir(dir))
+	received := broadcast(spooled, 20)
+	s.Require().Len(received, 20)
+	for i, n := range received {
+		s.Equal(i+1, n)
+	}
+	s.Eventually(func() bool {
+		entries, _ := os.ReadDir(dir)
+		return len(entries) == 0
+	}, time.Second, 5*time.Millisecond)
+
+	// Spools of all rooms share a budget, clients over it are disconnected
+	budget := websocket.NewSpoolBudget(200)
+	options := []websocket.RoomOption{websocket.WithSlowConsumerPolicy(websocket.SlowConsumerBufferToDisk),
+		websocket.WithSpoolDir(dir), websocket.WithSpoolBudget(budget)}
+	within, _ := s.hub.CreateRoom(3, nil, options...)
+	s.Len(broadcast(within, 4), 4)
+	s.Eventually(func() bool { return budget.Used() == 0 }, time.Second, 5*time.Millisecond)
+
+	over, _ := s.hub.CreateRoom(4, nil, options...)
+	conn := newFakeConn()
+	client := &websocket.Client{Conn: conn, Send: make(chan []byte, 2), Room: over, Username: "alice"}
+	over.Register <- client
+	s.Eventually(func() bool { return over.GetClientCount() == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	for len(client.Send) > 0 {
+		<-client.Send
+	}
+	for i := 1; i <= 20; i++ {
+		msg, _ := json.Marshal(websocket.Message{Type: "test", Data: json.RawMessage(strconv.Itoa(i))})
+		over.Broadcast <- msg
+	}
+	s.Eventually(func() bool { return over.GetClientCount() == 0 }, time.Second, 5*time.Millisecond)
+	s.Eventually(conn.isClosed, time.Second, 5*time.Millisecond)
+	s.Eventually(func() bool { return budget.Used() == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func (s *HubTestSuite) TestPresenceShedForCongestedClients() {
+	room, _ := s.hub.CreateRoom(1, nil)
+	alice := &websocket.Client{Send: make(chan []byte, 4), Room: room, Username: "alice"}
+	room.Register <- alice
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	for len(alice.Send) > 0 {
+		<-alice.Send
+	}
+
+	// A half-full queue still takes chat and membership changes but no longer
+	// updates that later ones supersede
+	room.PostBotMessage("WeatherBot", "sunny")
+	room.PostBotMessage("WeatherBot", "windy")
+	s.Eventually(func() bool { return len(alice.Send) == 2 }, time.Second, 5*time.Millisecond)
+	robert := &websocket.Client{Send: make(chan []byte, 16), Room: room, Username: "robert"}
+	room.Register <- robert
+	s.Eventually(func() bool { return room.GetClientCount() == 2 }, time.Second, 5*time.Millisecond)
+	s.Eventually(func() bool { return len(alice.Send) == 3 }, time.Second, 5*time.Millisecond)
+	room.RaiseHand(robert)
+	room.PostBotMessage("WeatherBot", "rainy")
+	s.Eventually(func() bool { return len(alice.Send) == 4 }, time.Second, 5*time.Millisecond)
+
+	var types []string
+	for len(alice.Send) > 0 {
+		var message websocket.Message
+		s.Require().NoError(json.Unmarshal(<-alice.Send, &message))
+		types = append(types, message.Type)
+	}
+	s.Equal([]string{"chat", "chat", "join", "chat"}, types)
+
+	room.Unregister <- alice
+	room.Unregister <- robert
+	s.Eventually(func() bool { return room.GetClientCount() == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func (s *HubTestSuite) TestModerationStateSurvivesRestart() {
+	store, err := websocket.NewFileModerationStore(s.T().TempDir())
+	s.Require().NoError(err)
+	s.hub.Moderation = store
+
+	room, _ := s.hub.CreateRoom(1, nil, websocket.WithHost("host-1"))
+	alice := &websocket.Client{Send: make(chan []byte, 16), Room: room, Username: "alice"}
+	room.Register <- alice
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 5*time.Millisecond)
+	_, err = room.Ban("spammer", "", 0, "host")
+	s.Require().NoError(err)
+	s.Require().NoError(room.SetMuted("alice", true, "host"))
+	room.Unregister <- alice
+	s.Eventually(func() bool { return room.GetClientCount() == 0 }, time.Second, 5*time.Millisecond)
+	room.StopRoom()
+
+	// Another instance, or this one after a restart, resumes the state
+	restarted := websocket.NewHub()
+	restarted.Moderation = store
+	room, _ = restarted.CreateRoom(1, nil, websocket.WithHost("host-2"))
+	_, banned := room.IsBanned("spammer", "")
+	s.True(banned)
+	// Host tokens of the earlier room grant nothing in this one
+	s.Equal([]string{"host-2"}, room.HostIDs())
+	state, _, err := store.Load(context.Background(), 1)
+	s.Require().NoError(err)
+	s.Equal([]string{"host-2"}, state.HostIDs)
+	alice = &websocket.Client{Send: make(chan []byte, 16), Room: room, Username: "alice"}
+	room.Register <- alice
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, time.Second, 5*time.Millisecond)
+	s.True(alice.IsMuted())
+	room.Unregister <- alice
+	s.Eventually(func() bool { return room.GetClientCount() == 0 }, time.Second, 5*time.Millisecond)
+
+	s.True(restarted.DeleteRoom(1))
+	_, stored, err := store.Load(context.Background(), 1)
+	s.Require().NoError(err)
+	s.False(stored)
+}
@@ -0,0 +1,107 @@
+package websocket_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	gorillaWs "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/suite"
+)
+
+type OrderedDeliveryTestSuite struct {
+	suite.Suite
+	hub    *websocket.Hub
+	room   *websocket.Room
+	pool   *websocket.TaskPool
+	server *httptest.Server
+}
+
+func (s *OrderedDeliveryTestSuite) SetupTest() {
+	s.hub = websocket.NewHub()
+	var err error
+	s.pool, err = websocket.NewTaskPool(10)
+	s.Require().NoError(err)
+	handler := websocket.NewHandler(s.hub, s.pool)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/ws/:room_id", handler.HandleWebSocketWithJWT("test-secret"))
+	s.server = httptest.NewServer(engine)
+	s.room, _ = s.hub.CreateRoom(1, nil, websocket.WithOrderedDelivery(4))
+}
+
+func (s *OrderedDeliveryTestSuite) TearDownTest() {
+	s.room.StopRoom()
+	s.server.Close()
+	s.pool.Release()
+}
+
+func (s *OrderedDeliveryTestSuite) TestResumeReportsBroadcastsOutOfBuffer() {
+	for i := 0; i < 10; i++ {
+		s.Require().NoError(s.room.PostBotMessage("bot", "message"))
+	}
+	s.Require().Eventually(func() bool { return s.room.LastSeq() == 10 }, 2*time.Second, 10*time.Millisecond)
+
+	conn, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.server.URL, "http")+"/ws/1?username=alice&since=2", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	var seqs []uint64
+	var notification websocket.ErrorNotification
+	s.Require().NoError(conn.SetReadDeadline(time.Now().Add(2 * time.Second)))
+	for len(seqs) < 4 {
+		var msg websocket.Message
+		s.Require().NoError(conn.ReadJSON(&msg))
+		switch msg.Type {
+		case "error":
+			s.Empty(seqs, "the gap is reported before the replay")
+			s.NoError(json.Unmarshal(msg.Data, &notification))
+		case "chat":
+			seqs = append(seqs, msg.Seq)
+		}
+	}
+	s.Equal(websocket.ErrCodeReplayUnavailable, notification.Code)
+	s.Contains(notification.Message, "3..6")
+	s.Equal([]uint64{7, 8, 9, 10}, seqs)
+}
+
+func (s *OrderedDeliveryTestSuite) TestCompactedMessagesAreNotReplayed() {
+	room, _ := s.hub.CreateRoom(2, nil, websocket.WithOrderedDelivery(8),
+		websocket.WithSettings(websocket.RoomSettings{Retention: 50 * time.Millisecond}))
+	defer room.StopRoom()
+	s.Require().NoError(room.PostBotMessage("bot", "expired"))
+	s.Require().NoError(room.PostBotMessage("bot", "expired"))
+	time.Sleep(100 * time.Millisecond)
+	messages, _ := room.CompactHistory(time.Now())
+	s.Require().Equal(2, messages)
+	s.Require().NoError(room.PostBotMessage("bot", "fresh"))
+	s.Require().Eventually(func() bool { return room.LastSeq() == 3 }, 2*time.Second, 10*time.Millisecond)
+
+	conn, _, err := gorillaWs.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.server.URL, "http")+"/ws/2?username=alice&since=1", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	s.Require().NoError(conn.SetReadDeadline(time.Now().Add(2 * time.Second)))
+	for {
+		var msg websocket.Message
+		s.Require().NoError(conn.ReadJSON(&msg))
+		s.NotEqual("error", msg.Type)
+		if msg.Type != "chat" {
+			continue
+		}
+		var chat websocket.ChatMessage
+		s.NoError(json.Unmarshal(msg.Data, &chat))
+		s.Equal("fresh", chat.Text)
+		s.Equal(uint64(3), msg.Seq)
+		return
+	}
+}
+
+func TestOrderedDeliveryTestSuite(t *testing.T) {
+	suite.Run(t, new(OrderedDeliveryTestSuite))
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -111,6 +112,472 @@ func (s *RoomTestSuite) TestUnregisterClient() {
 	s.True(ok, "Timeout waiting for client to unregister")
 }
 
+func (s *RoomTestSuite) TestSetPasswordIfVersion() {
+	version := s.room.SettingsVersion()
+
+	newVersion, applied := s.room.SetPasswordIfVersion("hash", version)
+	s.True(applied)
+	s.Equal(version+1, newVersion)
+	s.True(s.room.HasPassword())
+
+	current, applied := s.room.SetPasswordIfVersion("", version)
+	s.False(applied)
+	s.Equal(newVersion, current)
+	s.True(s.room.HasPassword())
+}
+
+func (s *RoomTestSuite) TestUpdateSettings() {
+	topic := "Weekly sync"
+	maxClients := 10
+	version := s.room.SettingsVersion()
+
+	newVersion, applied := s.room.UpdateSettings(websocket.SettingsUpdate{
+		Topic:      &topic,
+		MaxClients: &maxClients,
+	}, version)
+	s.True(applied)
+	s.Equal(version+1, newVersion)
+
+	settings := s.room.Settings()
+	s.Equal(topic, settings.Topic)
+	s.Equal(maxClients, settings.MaxClients)
+	s.Equal(websocket.VisibilityPrivate, settings.Visibility)
+}
+
+func (s *RoomTestSuite) TestSettingsUpdateValidate() {
+	topic := strings.Repeat("a", websocket.MaxTopicLength+1)
+	visibility := websocket.Visibility("hidden")
+	maxClients := -1
+
+	errs := websocket.SettingsUpdate{
+		Topic:      &topic,
+		Visibility: &visibility,
+		MaxClients: &maxClients,
+	}.Validate()
+	s.Len(errs, 3)
+	s.Equal("topic", errs[0].Field)
+	s.Equal("visibility", errs[1].Field)
+	s.Equal("max_clients", errs[2].Field)
+}
+
+func (s *RoomTestSuite) TestKickClients() {
+	results := s.room.KickClients([]string{"testuser", "ghost"}, "host")
+	s.Require().Len(results, 2)
+	s.True(results[0].Kicked)
+	s.Equal("testuser", results[0].Username)
+	s.False(results[1].Kicked)
+	s.NotEmpty(results[1].Reason)
+	s.Equal(0, s.room.GetClientCount())
+	s.expectClose(websocket.CloseKicked)
+}
+
+func (s *RoomTestSuite) TestShutdownSendsCloseFrame() {
+	s.room.Shutdown()
+	s.expectClose(websocket.CloseServerShutdown)
+	s.Equal("server_shutdown", websocket.CloseReason(websocket.CloseServerShutdown))
+}
+
+func (s *RoomTestSuite) TestMigrationHandsOffRoom() {
+	s.room.PostBotMessage("bot", "before the move")
+	s.Eventually(func() bool {
+		return len(s.room.History(time.Time{}, time.Time{})) == 1
+	}, time.Second, 10*time.Millisecond)
+	_, err := s.room.Ban("spammer", "", 0, "host")
+	s.Require().NoError(err)
+	s.True(s.room.SetLocked(true))
+
+	snapshot, ok := s.room.BeginMigration()
+	s.Require().True(ok)
+	_, again := s.room.BeginMigration()
+	s.False(again)
+	s.True(s.room.IsMigrating())
+
+	// The snapshot travels between instances as JSON
+	data, err := json.Marshal(snapshot)
+	s.Require().NoError(err)
+	var received websocket.RoomSnapshot
+	s.Require().NoError(json.Unmarshal(data, &received))
+
+	target := websocket.NewHub()
+	restored, created := target.RestoreRoom(received, nil)
+	s.Require().True(created)
+	defer restored.StopRoom()
+	s.Equal(s.room.LastSeq(), restored.LastSeq())
+	history := restored.History(time.Time{}, time.Time{})
+	s.Require().Len(history, 1)
+	s.Equal("before the move", history[0].Text)
+	_, banned := restored.IsBanned("spammer", "")
+	s.True(banned)
+	s.True(restored.IsLocked())
+
+	source := websocket.NewHub()
+	source.Rooms.Store(s.room.ID, s.room)
+	s.True(source.CompleteMigration(s.room.ID, "https://chat-2.example.com"))
+
+	s.wsConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, msg, err := s.wsConn.ReadMessage()
+		s.Require().NoError(err)
+		var message websocket.Message
+		s.Require().NoError(json.Unmarshal(msg, &message))
+		if message.Type != "migrate" {
+			continue
+		}
+		var migrate websocket.MigrateNotification
+		s.Require().NoError(json.Unmarshal(message.Data, &migrate))
+		s.Equal("https://chat-2.example.com", migrate.URL)
+		s.Equal(restored.LastSeq(), migrate.LastSeq)
+		break
+	}
+	s.expectClose(websocket.CloseRoomMigrated)
+	_, exists := source.GetRoom(s.room.ID)
+	s.False(exists)
+}
+
+// expectClose reads from the test connection until it is closed and checks the close code
+func (s *RoomTestSuite) expectClose(code int) {
+	s.wsConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := s.wsConn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var closeErr *gorillaWs.CloseError
+		s.Require().ErrorAs(err, &closeErr)
+		s.Equal(code, closeErr.Code)
+		s.Equal(websocket.CloseReason(code), closeErr.Text)
+		return
+	}
+}
+
+func (s *RoomTestSuite) TestDisconnect() {
+	s.Equal(0, s.room.Disconnect([]string{"ghost"}))
+	s.Equal(1, s.room.Disconnect([]string{"testuser", "ghost"}))
+	s.True(s.waitForClientCount(0, time.Second))
+	s.expectClose(websocket.ClosePolicyViolation)
+}
+
+func (s *RoomTestSuite) TestPasswordAudit() {
+	var alerts []websocket.PasswordAudit
+	room := websocket.NewRoom(2, nil, websocket.WithPasswordAlert(3, func(_ *websocket.Room, audit websocket.PasswordAudit) {
+		alerts = append(alerts, audit)
+	}))
+
+	room.RecordFailedPassword("10.0.0.1")
+	room.RecordFailedPassword("10.0.0.2")
+	s.Empty(alerts)
+	room.RecordFailedPassword("10.0.0.1")
+	s.Require().Len(alerts, 1)
+	s.Equal(3, alerts[0].FailedAttempts)
+
+	audit := room.PasswordAudit()
+	s.Equal(3, audit.FailedAttempts)
+	s.Require().Len(audit.Sources, 2)
+	s.Equal(2, audit.Sources[0].Count)
+	s.NotContains(audit.Sources[0].SourceHash, "10.0.0.1")
+	s.False(audit.LastAttempt.IsZero())
+}
+
+func (s *RoomTestSuite) TestSearchMessages() {
+	for _, text := range []string{"Hello World", "deploy finished", "hello again"} {
+		msg := []byte(`{"type":"chat","data":{"text":"` + text + `"}}`)
+		s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msg))
+	}
+	s.Eventually(func() bool {
+		return len(s.room.History(time.Time{}, time.Time{})) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	results := s.room.SearchMessages("HELLO", time.Time{}, time.Time{})
+	s.Require().Len(results, 2)
+	s.Equal("Hello World", results[0].Text)
+	s.Equal("testuser", results[0].Username)
+	s.Less(results[0].ID, results[1].ID)
+
+	s.Len(s.room.SearchMessages("hello world", time.Time{}, time.Time{}), 1)
+	s.Empty(s.room.SearchMessages("hello", time.Now().Add(time.Minute), time.Time{}))
+}
+
+func (s *RoomTestSuite) TestImportMessages() {
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"live"}}`)))
+	s.Eventually(func() bool {
+		return len(s.room.History(time.Time{}, time.Time{})) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	now := time.Now()
+	invalid := []websocket.ImportedMessage{{Username: "", Text: "hi", SentAt: now}, {Username: "robert", Text: "later", SentAt: now.Add(time.Hour)}}
+	fieldErrs := websocket.ValidateImport(invalid, now)
+	s.Require().Len(fieldErrs, 2)
+	s.Equal("messages[0].username", fieldErrs[0].Field)
+	s.Equal("messages[1].sent_at", fieldErrs[1].Field)
+	_, _, err := s.room.ImportMessages(invalid)
+	s.ErrorIs(err, websocket.ErrInvalidImport)
+
+	// Imported messages are merged by sending time and numbered in sending order
+	result, _, err := s.room.ImportMessages([]websocket.ImportedMessage{
+		{Username: "robert", Text: "second", SentAt: now.Add(-time.Hour)},
+		{Username: "alice", Text: "first", SentAt: now.Add(-2 * time.Hour)},
+	})
+	s.Require().NoError(err)
+	s.Equal(2, result.Imported)
+	s.Zero(result.Dropped)
+	history := s.room.History(time.Time{}, time.Time{})
+	s.Require().Len(history, 3)
+	s.Equal([]string{"first", "second", "live"}, []string{history[0].Text, history[1].Text, history[2].Text})
+	s.Equal(result.FirstID, history[0].ID)
+	s.Equal(result.LastID, history[1].ID)
+	s.Greater(history[0].ID, history[2].ID)
+
+	s.True(history[0].Imported)
+	s.True(history[1].Imported)
+	s.False(history[2].Imported)
+
+	// Imports share a per-room budget
+	clock := now
+	room := websocket.NewRoom(2, nil, websocket.WithClock(func() time.Time { return clock }))
+	batch := make([]websocket.ImportedMessage, websocket.MaxImportBatch)
+	for i := range batch {
+		batch[i] = websocket.ImportedMessage{Username: "robert", Text: "old", SentAt: now.Add(-time.Hour)}
+	}
+	for range websocket.ImportRateLimit / websocket.MaxImportBatch {
+		_, _, err = room.ImportMessages(batch)
+		s.Require().NoError(err)
+	}
+	_, retryAfter, err := room.ImportMessages(batch)
+	s.ErrorIs(err, websocket.ErrImportRateLimited)
+	s.InDelta(time.Minute*websocket.MaxImportBatch/websocket.ImportRateLimit, retryAfter, float64(time.Millisecond))
+	clock = clock.Add(retryAfter - time.Second)
+	_, _, err = room.ImportMessages(batch)
+	s.ErrorIs(err, websocket.ErrImportRateLimited)
+	clock = clock.Add(time.Second + time.Millisecond)
+	_, _, err = room.ImportMessages(batch)
+	s.NoError(err)
+}
+
+func (s *RoomTestSuite) TestEventFeed() {
+	msg := []byte(`{"type":"chat","data":{"text":"hello"}}`)
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, msg))
+	s.Eventually(func() bool {
+		return len(s.room.History(time.Time{}, time.Time{})) == 1
+	}, time.Second, 10*time.Millisecond)
+	_, err := s.room.Ban("", "203.0.113.7", 0, "host")
+	s.Require().NoError(err)
+	s.room.Freeze(time.Minute)
+	// Presence updates stay out of the feed
+	s.room.RaiseHand(&websocket.Client{Room: s.room, Username: "robert", ConnID: "robert"})
+
+	events, next, truncated := s.room.EventsSince(0, 10, false)
+	s.False(truncated)
+	s.Require().Len(events, 3)
+	s.Equal("join", events[0].Type)
+	s.Equal(websocket.EventMessage, events[1].Type)
+	s.Contains(string(events[1].Data), `"text":"hello"`)
+	s.Equal("freeze", events[2].Type)
+	s.Equal(uint64(4), next)
+
+	hostEvents, _, _ := s.room.EventsSince(0, 10, true)
+	s.Require().Len(hostEvents, 4)
+	s.Equal(websocket.EventBan, hostEvents[2].Type)
+
+	events, next, _ = s.room.EventsSince(2, 1, false)
+	s.Require().Len(events, 1)
+	s.Equal("freeze", events[0].Type)
+	s.Equal(uint64(4), next, "cursor moves past the hidden ban event")
+}
+
+func (s *RoomTestSuite) TestPepperedPasswords() {
+	legacy, err := websocket.NewPasswordHasher("").Hash("secret")
+	s.Require().NoError(err)
+	s.room.SetPassword(legacy)
+	version := s.room.SettingsVersion()
+
+	hasher := websocket.NewPasswordHasher("pepper")
+	s.False(s.room.CheckPassword(hasher, "wrong"))
+	s.Equal(legacy, s.room.HashedPassword)
+
+	// A legacy hash is upgraded on the first successful check
+	s.True(s.room.CheckPassword(hasher, "secret"))
+	s.NotEqual(legacy, s.room.HashedPassword)
+	s.Equal(version, s.room.SettingsVersion())
+	ok, rehash := hasher.Verify(s.room.HashedPassword, "secret")
+	s.True(ok)
+	s.False(rehash)
+	s.True(s.room.CheckPassword(hasher, "secret"))
+
+	// Peppered hashes cannot be verified without the pepper
+	ok, _ = websocket.NewPasswordHasher("").Verify(s.room.HashedPassword, "secret")
+	s.False(ok)
+	ok, _ = websocket.NewPasswordHasher("other").Verify(s.room.HashedPassword, "secret")
+	s.False(ok)
+}
+
+func (s *RoomTestSuite) TestCreationOptions() {
+	room := websocket.NewRoom(2, nil,
+		websocket.WithSettings(websocket.RoomSettings{Visibility: websocket.VisibilityPublic, SlowMode: time.Second}),
+		websocket.WithHistoryLimit(0),
+	)
+	settings := room.Settings()
+	s.Equal(websocket.VisibilityPublic, settings.Visibility)
+	s.Equal(time.Second, settings.SlowMode)
+	s.Empty(room.History(time.Time{}, time.Time{}))
+}
+
+func (s *RoomTestSuite) TestListClientsAndMembersMessage() {
+	members := s.room.ListClients()
+	s.Require().Len(members, 1)
+	s.Equal("testuser", members[0].Username)
+	s.False(members[0].IsHost)
+	s.False(members[0].JoinedAt.IsZero())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		_ = s.wsConn.SetReadDeadline(deadline)
+		_, raw, err := s.wsConn.ReadMessage()
+		s.Require().NoError(err)
+
+		var message websocket.Message
+		s.Require().NoError(json.Unmarshal(raw, &message))
+		if message.Type != "members" {
+			continue
+		}
+		var list websocket.MembersMessage
+		s.NoError(json.Unmarshal(message.Data, &list))
+		s.Require().Len(list.Members, 1)
+		s.Equal("testuser", list.Members[0].Username)
+		return
+	}
+	s.Fail("Timeout waiting for members message")
+}
+
+func (s *RoomTestSuite) TestMetadata() {
+	s.NoError(s.room.SetMetadata("mode", "ranked"))
+	s.Equal(map[string]string{"mode": "ranked"}, s.room.Metadata())
+
+	s.ErrorIs(s.room.SetMetadata("", "x"), websocket.ErrMetadataKeyInvalid)
+	s.ErrorIs(s.room.SetMetadata("agenda", strings.Repeat("a", websocket.MaxMetadataValueLength+1)), websocket.ErrMetadataValueTooBig)
+	for i := 1; i < websocket.MaxMetadataKeys; i++ {
+		s.NoError(s.room.SetMetadata(strconv.Itoa(i), "v"))
+	}
+	s.ErrorIs(s.room.SetMetadata("overflow", "v"), websocket.ErrMetadataFull)
+	s.NoError(s.room.SetMetadata("mode", "casual"), "existing keys can be overwritten when full")
+
+	s.True(s.room.DeleteMetadata("mode"))
+	s.False(s.room.DeleteMetadata("mode"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		_ = s.wsConn.SetReadDeadline(deadline)
+		_, raw, err := s.wsConn.ReadMessage()
+		s.Require().NoError(err)
+
+		var message websocket.Message
+		s.Require().NoError(json.Unmarshal(raw, &message))
+		if message.Type != "metadata" {
+			continue
+		}
+		var notification websocket.MetadataNotification
+		s.NoError(json.Unmarshal(message.Data, &notification))
+		s.Equal("ranked", notification.Metadata["mode"])
+		return
+	}
+	s.Fail("Timeout waiting for metadata notification")
+}
+
+func (s *RoomTestSuite) TestRoomWebhookMirrorsMessages() {
+	events := make(chan websocket.RoomMessageEvent, 2*websocket.RoomWebhookRate)
+	websocket.WithRoomWebhookDispatcher(func(_ websocket.RoomWebhook, secret string, event websocket.RoomMessageEvent) {
+		s.NotEmpty(secret)
+		events <- event
+	})(s.room)
+
+	_, _, err := s.room.AddWebhook("http://archive.example.com/chatters")
+	s.ErrorIs(err, websocket.ErrInsecureWebhookURL)
+	hook, _, err := s.room.AddWebhook("https://archive.example.com/chatters")
+	s.Require().NoError(err)
+	s.Len(s.room.Webhooks(), 1)
+
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"hello"}}`)))
+	select {
+	case event := <-events:
+		s.Equal(hook.ID, event.WebhookID)
+		s.Equal("testuser", event.Username)
+		s.Equal("hello", event.Text)
+		s.Zero(event.Dropped)
+	case <-time.After(2 * time.Second):
+		s.Fail("message was not mirrored")
+	}
+
+	burst := websocket.RoomWebhookRate + 5
+	for i := 0; i < burst; i++ {
+		s.room.PostBotMessage("Bot", "burst")
+	}
+	delivered := len(events)
+	s.Less(delivered, burst)
+	for len(events) > 0 {
+		<-events
+	}
+	time.Sleep(100 * time.Millisecond)
+	s.room.PostBotMessage("Bot", "after")
+	event := <-events
+	s.Equal("after", event.Text)
+	s.EqualValues(burst-delivered, event.Dropped)
+
+	s.True(s.room.RemoveWebhook(hook.ID))
+	s.Empty(s.room.Webhooks())
+}
+
+func (s *RoomTestSuite) TestBotHooks() {
+	events := make(chan websocket.BotHookEvent, 1)
+	websocket.WithHookDispatcher(func(_ websocket.BotHook, event websocket.BotHookEvent) {
+		events <- event
+	})(s.room)
+
+	hook, token, err := s.room.AddHook("!weather", "https://bots.example.com/weather", "WeatherBot")
+	s.Require().NoError(err)
+	s.Len(s.room.Hooks(), 1)
+
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"hello"}}`)))
+	s.NoError(s.wsConn.WriteMessage(gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"!weather Berlin"}}`)))
+	select {
+	case event := <-events:
+		s.Equal(hook.ID, event.HookID)
+		s.Equal("testuser", event.Username)
+		s.Equal("Berlin", event.Args)
+	case <-time.After(2 * time.Second):
+		s.Fail("hook was not dispatched")
+	}
+
+	_, err = s.room.AuthorizeHook(hook.ID, "wrong")
+	s.ErrorIs(err, websocket.ErrHookNotFound)
+	authorized, err := s.room.AuthorizeHook(hook.ID, token)
+	s.Require().NoError(err)
+
+	s.NoError(s.room.PostBotMessage(authorized.BotName, "Berlin: sunny"))
+	history := s.room.SearchMessages("sunny", time.Time{}, time.Time{})
+	s.Require().Len(history, 1)
+	s.Equal("WeatherBot", history[0].Username)
+
+	s.True(s.room.RemoveHook(hook.ID))
+	s.Empty(s.room.Hooks())
+
+	_, _, err = s.room.AddHook("!weather", "http://bots.example.com/weather", "WeatherBot")
+	s.ErrorIs(err, websocket.ErrInsecureWebhookURL)
+}
+
+func (s *RoomTestSuite) TestBotMessageToStoppedRoomDoesNotBlock() {
+	room := websocket.NewRoom(99, nil)
+	room.StopRoom()
+
+	posted := make(chan error, 1)
+	go func() { posted <- room.PostBotMessage("WeatherBot", "Berlin: sunny") }()
+	select {
+	case err := <-posted:
+		s.ErrorIs(err, websocket.ErrRoomStopped)
+	case <-time.After(time.Second):
+		s.Fail("posting to a stopped room blocked")
+	}
+	s.Empty(room.SearchMessages("sunny", time.Time{}, time.Time{}))
+}
+
 func TestRoomTestSuite(t *testing.T) {
 	suite.Run(t, new(RoomTestSuite))
 }
@@ -0,0 +1,83 @@
+package websocket_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/stretchr/testify/suite"
+)
+
+type TenantTestSuite struct {
+	suite.Suite
+}
+
+func (s *TenantTestSuite) TestRoomQuota() {
+	tracker := websocket.NewTenantTracker(websocket.TenantQuota{MaxRooms: 1})
+
+	release, ok := tracker.AcquireRoom("key")
+	s.True(ok)
+	_, ok = tracker.AcquireRoom("key")
+	s.False(ok)
+	_, ok = tracker.AcquireRoom("other")
+	s.True(ok)
+
+	release()
+	release()
+	s.Equal(0, tracker.Usage("key").Rooms)
+	_, ok = tracker.AcquireRoom("key")
+	s.True(ok)
+}
+
+func (s *TenantTestSuite) TestRoomReleasedOnStop() {
+	tracker := websocket.NewTenantTracker(websocket.TenantQuota{MaxRooms: 1})
+	hub := websocket.NewHub()
+
+	release, ok := tracker.AcquireRoom("key")
+	s.Require().True(ok)
+	_, created := hub.CreateRoom(1, nil, websocket.WithTenant(tracker, "key", release))
+	s.Require().True(created)
+	s.Equal(1, tracker.Usage("key").Rooms)
+
+	s.True(hub.DeleteRoom(1))
+	s.Equal(0, tracker.Usage("key").Rooms)
+}
+
+func (s *TenantTestSuite) TestMessageQuotaWindow() {
+	tracker := websocket.NewTenantTracker(websocket.TenantQuota{MaxMessages: 2, MessageWindow: 50 * time.Millisecond})
+
+	s.True(tracker.AllowMessage("key"))
+	s.True(tracker.AllowMessage("key"))
+	s.False(tracker.AllowMessage("key"))
+	s.Equal(2, tracker.Usage("key").Messages)
+
+	time.Sleep(60 * time.Millisecond)
+	s.True(tracker.AllowMessage("key"))
+}
+
+func (s *TenantTestSuite) TestInjectedMessagesCountAgainstQuota() {
+	tracker := websocket.NewTenantTracker(websocket.TenantQuota{MaxMessages: 1})
+	room := websocket.NewRoom(1, nil, websocket.WithTenant(tracker, "key", nil))
+	go room.Run()
+	defer room.StopRoom()
+
+	s.Equal("key", room.Tenant())
+	s.NoError(room.InjectMessage("StatusBot", "deploy started"))
+	s.ErrorIs(room.InjectMessage("StatusBot", "deploy finished"), websocket.ErrTenantQuota)
+
+	history := room.History(time.Time{}, time.Time{})
+	s.Require().Len(history, 1)
+	s.Equal("StatusBot", history[0].Username)
+	s.Equal("deploy started", history[0].Text)
+}
+
+func (s *TenantTestSuite) TestNilTrackerAllowsEverything() {
+	var tracker *websocket.TenantTracker
+	_, ok := tracker.AcquireConnection("key")
+	s.True(ok)
+	s.True(tracker.AllowMessage("key"))
+}
+
+func TestTenantTestSuite(t *testing.T) {
+	suite.Run(t, new(TenantTestSuite))
+}
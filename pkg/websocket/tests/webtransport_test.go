@@ -0,0 +1,180 @@
+package websocket_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/YuarenArt/chatters/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	gorillaWs "github.com/gorilla/websocket"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebTransportTestSuite struct {
+	suite.Suite
+	hub    *websocket.Hub
+	pool   *websocket.TaskPool
+	server *webtransport.Server
+	port   int
+}
+
+// selfSignedCertificate returns a certificate for 127.0.0.1
+func selfSignedCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+func (s *WebTransportTestSuite) SetupTest() {
+	s.hub = websocket.NewHub()
+	var err error
+	s.pool, err = websocket.NewTaskPool(10)
+	s.NoError(err)
+	handler := websocket.NewHandler(s.hub, s.pool)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Handle(http.MethodConnect, "/wt/:room_id", handler.HandleWebTransportWithKeys(websocket.NewHMACKeys("test-secret")))
+	s.server = handler.EnableWebTransport(engine)
+
+	cert, err := selfSignedCertificate()
+	s.Require().NoError(err)
+	s.server.H3.TLSConfig = http3.ConfigureTLSConfig(&tls.Config{Certificates: []tls.Certificate{cert}})
+	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	s.Require().NoError(err)
+	s.port = udpConn.LocalAddr().(*net.UDPAddr).Port
+	go s.server.Serve(udpConn)
+	s.hub.CreateRoom(1, nil)
+}
+
+func (s *WebTransportTestSuite) TearDownTest() {
+	if room, ok := s.hub.GetRoom(1); ok {
+		room.StopRoom()
+	}
+	s.server.Close()
+	s.pool.Release()
+}
+
+// dial opens a session to room 1 and accepts its message stream
+func (s *WebTransportTestSuite) dial(query string) (*webtransport.Session, *webtransport.Stream) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	dialer := &webtransport.Dialer{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	s.T().Cleanup(func() { dialer.Close() })
+	resp, session, err := dialer.Dial(ctx, "https://127.0.0.1:"+strconv.Itoa(s.port)+"/wt/1?"+query, nil)
+	s.Require().NoError(err)
+	s.Equal(http.StatusOK, resp.StatusCode)
+	stream, err := session.AcceptStream(ctx)
+	s.Require().NoError(err)
+	return session, stream
+}
+
+// writeFrame writes a message framed as the server expects
+func writeFrame(w io.Writer, messageType int, payload []byte) error {
+	frame := make([]byte, 5+len(payload))
+	frame[0] = byte(messageType)
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	copy(frame[5:], payload)
+	_, err := w.Write(frame)
+	return err
+}
+
+// readFrame reads the next frame from the server
+func readFrame(r io.Reader) (int, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(header[1:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return int(header[0]), payload, nil
+}
+
+// readUntil reads frames until one of messageType whose payload satisfies match
+func (s *WebTransportTestSuite) readUntil(stream *webtransport.Stream, messageType int, match func([]byte) bool) []byte {
+	s.Require().NoError(stream.SetReadDeadline(time.Now().Add(2 * time.Second)))
+	for {
+		frameType, payload, err := readFrame(stream)
+		s.Require().NoError(err)
+		if frameType == messageType && match(payload) {
+			return payload
+		}
+	}
+}
+
+func (s *WebTransportTestSuite) TestJoinAndChat() {
+	_, stream := s.dial("username=alice")
+	room, _ := s.hub.GetRoom(1)
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	s.NoError(writeFrame(stream, gorillaWs.TextMessage, []byte(`{"type":"chat","data":{"text":"Hello"}}`)))
+	var chat websocket.ChatMessage
+	s.readUntil(stream, gorillaWs.TextMessage, func(payload []byte) bool {
+		var msg websocket.Message
+		return json.Unmarshal(payload, &msg) == nil && msg.Type == "chat" && json.Unmarshal(msg.Data, &chat) == nil
+	})
+	s.Equal("Hello", chat.Text)
+	s.Equal("alice", chat.Username)
+
+	s.NoError(writeFrame(stream, gorillaWs.PingMessage, []byte("probe")))
+	pong := s.readUntil(stream, gorillaWs.PongMessage, func([]byte) bool { return true })
+	s.Equal("probe", string(pong))
+}
+
+func (s *WebTransportTestSuite) TestCloseFrameLeavesRoom() {
+	session, stream := s.dial("username=bobby")
+	room, _ := s.hub.GetRoom(1)
+	s.Eventually(func() bool { return room.GetClientCount() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	s.NoError(writeFrame(stream, gorillaWs.CloseMessage, gorillaWs.FormatCloseMessage(gorillaWs.CloseNormalClosure, "")))
+	s.Eventually(func() bool { return room.GetClientCount() == 0 }, 2*time.Second, 10*time.Millisecond)
+	select {
+	case <-session.Context().Done():
+	case <-time.After(2 * time.Second):
+		s.Fail("session not closed")
+	}
+}
+
+func (s *WebTransportTestSuite) TestRejectsUnknownRoom() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	dialer := &webtransport.Dialer{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	defer dialer.Close()
+	resp, _, err := dialer.Dial(ctx, "https://127.0.0.1:"+strconv.Itoa(s.port)+"/wt/999", nil)
+	s.Error(err)
+	s.Require().NotNil(resp)
+	s.Equal(http.StatusNotFound, resp.StatusCode)
+}
+
+func TestWebTransportTestSuite(t *testing.T) {
+	suite.Run(t, new(WebTransportTestSuite))
+}
@@ -0,0 +1,103 @@
+package websocket
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JoinTicketTTL is how long a join ticket may be redeemed after it is issued
+const JoinTicketTTL = 30 * time.Second
+
+// ticketNonceSize is the random part of a ticket, also used to make it single-use
+const ticketNonceSize = 16
+
+// TicketStore issues short-lived single-use tickets that authorize a WebSocket join
+// in place of the room password. A ticket is "<payload>.<hmac>" where the payload
+// carries a nonce, the room ID and the expiry, so any node sharing the key can verify it.
+// Redeemed nonces are remembered until they expire to make tickets single-use.
+type TicketStore struct {
+	redeemed  map[string]time.Time
+	lastSweep time.Time
+	key       []byte
+	ttl       time.Duration
+	mu        sync.Mutex
+}
+
+// NewTicketStore creates a store whose tickets expire after ttl and are signed with key.
+// A nil key is replaced by a random one, so tickets only verify on this instance.
+func NewTicketStore(ttl time.Duration, key []byte) *TicketStore {
+	if key == nil {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	return &TicketStore{
+		redeemed:  make(map[string]time.Time),
+		key:       key,
+		ttl:       ttl,
+		lastSweep: time.Now(),
+	}
+}
+
+// Issue creates a ticket for roomID and returns it with its expiry
+func (s *TicketStore) Issue(roomID ID) (string, time.Time, error) {
+	payload := make([]byte, ticketNonceSize+4+8)
+	if _, err := rand.Read(payload[:ticketNonceSize]); err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(s.ttl)
+	binary.BigEndian.PutUint32(payload[ticketNonceSize:], uint32(roomID))
+	binary.BigEndian.PutUint64(payload[ticketNonceSize+4:], uint64(expiresAt.UnixMilli()))
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + s.sign(encoded), expiresAt.Truncate(time.Millisecond), nil
+}
+
+// Redeem consumes the ticket and reports whether it was valid for roomID
+func (s *TicketStore) Redeem(ticket string, roomID ID) bool {
+	encoded, signature, ok := strings.Cut(ticket, ".")
+	if !ok || !hmac.Equal([]byte(s.sign(encoded)), []byte(signature)) {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(payload) != ticketNonceSize+4+8 {
+		return false
+	}
+	nonce := string(payload[:ticketNonceSize])
+	ticketRoom := ID(binary.BigEndian.Uint32(payload[ticketNonceSize:]))
+	expiresAt := time.UnixMilli(int64(binary.BigEndian.Uint64(payload[ticketNonceSize+4:])))
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+	if _, used := s.redeemed[nonce]; used {
+		return false
+	}
+	s.redeemed[nonce] = expiresAt
+	return ticketRoom == roomID && now.Before(expiresAt)
+}
+
+func (s *TicketStore) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// sweep forgets redeemed nonces of expired tickets at most once per ttl. Caller must hold s.mu.
+func (s *TicketStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	s.lastSweep = now
+	for nonce, expiresAt := range s.redeemed {
+		if now.After(expiresAt) {
+			delete(s.redeemed, nonce)
+		}
+	}
+}
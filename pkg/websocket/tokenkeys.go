@@ -0,0 +1,119 @@
+package websocket
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var (
+	// ErrTokenSigningDisabled is returned when signing with keys that only hold a public key
+	ErrTokenSigningDisabled = errors.New("host token signing key is not configured")
+	// ErrTokenKeyMismatch is returned when the public key does not verify what the private key signs
+	ErrTokenKeyMismatch = errors.New("JWT public key does not match the private key")
+)
+
+// keyProbe is signed and verified to check that a configured key pair matches
+const keyProbe = "chatters-key-probe"
+
+// TokenKeys signs and verifies host tokens with an HMAC secret (HS256) or an
+// asymmetric key pair (RS256 or EdDSA). With a key pair, other services can verify
+// host tokens using only the public key.
+type TokenKeys struct {
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewHMACKeys returns keys that sign and verify HS256 tokens with secret
+func NewHMACKeys(secret string) *TokenKeys {
+	return &TokenKeys{
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+}
+
+// NewTokenKeys loads PEM encoded RSA or Ed25519 keys from privateKeyFile and
+// publicKeyFile. The public key is derived from the private one when its file is
+// empty; without a private key tokens can be verified but not issued. When both
+// files are given, a probe signed with the private key must verify with the public
+// one. When both files are empty the HMAC secret is used.
+func NewTokenKeys(secret, privateKeyFile, publicKeyFile string) (*TokenKeys, error) {
+	if privateKeyFile == "" && publicKeyFile == "" {
+		return NewHMACKeys(secret), nil
+	}
+
+	keys := &TokenKeys{}
+	if privateKeyFile != "" {
+		pem, err := os.ReadFile(privateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read JWT private key: %w", err)
+		}
+		if rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(pem); err == nil {
+			keys.method, keys.signKey, keys.verifyKey = jwt.SigningMethodRS256, rsaKey, &rsaKey.PublicKey
+		} else if edKey, err := jwt.ParseEdPrivateKeyFromPEM(pem); err == nil {
+			keys.method, keys.signKey, keys.verifyKey = jwt.SigningMethodEdDSA, edKey, edKey.(ed25519.PrivateKey).Public()
+		} else {
+			return nil, errors.New("JWT private key must be a PEM encoded RSA or Ed25519 key")
+		}
+	}
+
+	if publicKeyFile != "" {
+		pem, err := os.ReadFile(publicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read JWT public key: %w", err)
+		}
+		var method jwt.SigningMethod
+		var publicKey crypto.PublicKey
+		if rsaKey, err := jwt.ParseRSAPublicKeyFromPEM(pem); err == nil {
+			method, publicKey = jwt.SigningMethodRS256, rsaKey
+		} else if edKey, err := jwt.ParseEdPublicKeyFromPEM(pem); err == nil {
+			method, publicKey = jwt.SigningMethodEdDSA, edKey
+		} else {
+			return nil, errors.New("JWT public key must be a PEM encoded RSA or Ed25519 key")
+		}
+		if keys.method != nil && keys.method != method {
+			return nil, errors.New("JWT private and public keys are of different types")
+		}
+		keys.method, keys.verifyKey = method, publicKey
+	}
+	if keys.signKey != nil && publicKeyFile != "" {
+		signature, err := keys.method.Sign(keyProbe, keys.signKey)
+		if err != nil {
+			return nil, fmt.Errorf("sign with JWT private key: %w", err)
+		}
+		if keys.method.Verify(keyProbe, signature, keys.verifyKey) != nil {
+			return nil, ErrTokenKeyMismatch
+		}
+	}
+	return keys, nil
+}
+
+// Algorithm returns the JWT alg used for host tokens
+func (k *TokenKeys) Algorithm() string {
+	return k.method.Alg()
+}
+
+// Sign issues a token carrying claims
+func (k *TokenKeys) Sign(claims jwt.MapClaims) (string, error) {
+	if k.signKey == nil {
+		return "", ErrTokenSigningDisabled
+	}
+	return jwt.NewWithClaims(k.method, claims).SignedString(k.signKey)
+}
+
+// Parse verifies the token signature and expiry. Tokens signed with any other
+// algorithm than the configured one are rejected.
+func (k *TokenKeys) Parse(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return k.verifyKey, nil
+	})
+}
@@ -0,0 +1,72 @@
+package websocket
+
+import (
+	"context"
+
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans of this package. Connection spans use the globally
+// registered tracer provider, a no-op unless tracing is set up.
+const tracerName = "github.com/YuarenArt/chatters/pkg/websocket"
+
+// startConnectionSpan starts the span covering the connection's lifetime as a child
+// of the upgrade request in ctx. It ends when the client's read loop stops.
+func (c *Client) startConnectionSpan(ctx context.Context) {
+	_, c.span = otel.Tracer(tracerName).Start(ctx, "ws.connection",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.Int64("chatters.room_id", int64(c.Room.ID)),
+			attribute.String("chatters.username", c.Username),
+			attribute.String("chatters.connection_id", c.ConnID),
+			attribute.Bool("chatters.host", c.IsHost()),
+		),
+	)
+}
+
+// connectionSpan returns the connection span, a no-op span for clients created without one
+func (c *Client) connectionSpan() trace.Span {
+	if c.span == nil {
+		return trace.SpanFromContext(context.Background())
+	}
+	return c.span
+}
+
+// spanContext returns a context carrying the connection span
+func (c *Client) spanContext() context.Context {
+	return trace.ContextWithSpan(context.Background(), c.connectionSpan())
+}
+
+// startFanoutSpan starts the span of one room broadcast as a child of the span in
+// ctx, the connection the message came from. Broadcasts without one, such as the
+// ones relayed by the broker, are not traced, so the number of spans follows the
+// sampled connections rather than the message rate.
+func (r *Room) startFanoutSpan(ctx context.Context) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return otel.Tracer(tracerName).Start(ctx, "ws.fanout",
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attribute.Int64("chatters.room_id", int64(r.ID))),
+	)
+}
+
+// traceMessage adds a span event for a message read from the client
+func (c *Client) traceMessage(name, msgType string) {
+	c.connectionSpan().AddEvent(name, trace.WithAttributes(attribute.String("message.type", msgType)))
+}
+
+// endConnectionSpan ends the connection span with the error that stopped reading.
+// Closes initiated by the client are not errors.
+func (c *Client) endConnectionSpan(err error) {
+	span := c.connectionSpan()
+	if err != nil && !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "connection lost")
+	}
+	span.End()
+}
@@ -7,6 +7,7 @@ import "encoding/json"
 type Message struct {
 	Type string          `json:"type"` // chat, join, leave, kick etc.
 	Data json.RawMessage `json:"data"`
+	Seq  uint64          `json:"seq,omitempty"` // set on room broadcasts, increasing per room
 }
 
 // ChatMessage Chat message payload
@@ -14,6 +15,8 @@ type Message struct {
 type ChatMessage struct {
 	Text     string `json:"text" example:"Hello world!"`
 	Username string `json:"username" example:"JohnDoe"`
+	Bot      bool   `json:"bot,omitempty" example:"false"`
+	ID       uint64 `json:"id,omitempty" example:"42"` // history ID, referenced by edit and delete
 }
 
 // KickMessage Payload for kicking a user
@@ -35,21 +38,65 @@ type KickNotification struct {
 }
 
 // JoinNotification Sent to clients when a user joins
+// @Description online_count is also sent as the legacy onlineCount unless the server uses snake naming
 type JoinNotification struct {
-	Username    string `json:"username" example:"JohnDoe"`
-	OnlineCount int    `json:"onlineCount" example:"5"`
+	Username    string     `json:"username" example:"JohnDoe"`
+	OnlineCount int        `json:"online_count" example:"5"`
+	naming      WireNaming // set by the room, see WireNaming
 }
 
 // LeaveNotification Sent to clients when a user leaves
+// @Description online_count is also sent as the legacy onlineCount unless the server uses snake naming
 type LeaveNotification struct {
-	Username    string `json:"username" example:"JohnDoe"`
-	OnlineCount int    `json:"onlineCount" example:"4"`
+	Username    string     `json:"username" example:"JohnDoe"`
+	OnlineCount int        `json:"online_count" example:"4"`
+	naming      WireNaming // set by the room, see WireNaming
 }
 
-// ErrorResponse Standard error response
+// SystemNotification Sent by the server to a single client
+// @Description Kind is "welcome" for the room's welcome message shown after joining
+type SystemNotification struct {
+	Text string `json:"text" example:"Welcome! Please read the pinned agenda."`
+	Kind string `json:"kind" example:"welcome"`
+}
+
+// ErrorNotification Sent to a single client when its message was rejected
+// @Description Structured error delivered as a message of type "error"
+type ErrorNotification struct {
+	Code         string            `json:"code" example:"room_frozen"`
+	Message      string            `json:"message" example:"room is frozen by the host"`
+	RetryAfterMs int64             `json:"retry_after_ms,omitempty" example:"250"`
+	Fields       []ValidationError `json:"fields,omitempty"` // offending payload fields of invalid_payload errors
+}
+
+// FreezeNotification Sent to clients when the room is frozen or unfrozen
+type FreezeNotification struct {
+	Until  int64 `json:"until,omitempty" example:"1718000300000"` // unix ms, 0 when frozen indefinitely
+	Frozen bool  `json:"frozen" example:"true"`
+}
+
+// LockNotification Sent to clients when the host locks or unlocks the room
+type LockNotification struct {
+	Locked bool `json:"locked" example:"true"`
+}
+
+// ClosingNotification Sent to clients while a scheduled close counts down and once when the room closes
+type ClosingNotification struct {
+	ClosesAt    int64 `json:"closes_at" example:"1718000300000"` // unix ms
+	SecondsLeft int   `json:"seconds_left" example:"60"`
+}
+
+// FreezeMessage Payload for a host freezing the room over WebSocket
+type FreezeMessage struct {
+	DurationSeconds int `json:"duration_seconds,omitempty" example:"300"`
+}
+
+// ErrorResponse Standard error response of REST and WebSocket upgrade endpoints
+// @Description Code is the HTTP status, ErrorCode a stable machine-readable identifier
 type ErrorResponse struct {
-	Message string `json:"message" example:"Invalid request"`
-	Code    int    `json:"code" example:"400"`
+	Error     string    `json:"error" example:"room not found"`
+	ErrorCode ErrorCode `json:"error_code" example:"ROOM_NOT_FOUND"`
+	Code      int       `json:"code" example:"404"`
 }
 
 // ValidationError Field-specific validation error
@@ -0,0 +1,296 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrCodeInvalidAttachment is sent when an attachment does not refer to an upload of the room
+const ErrCodeInvalidAttachment = "invalid_attachment"
+
+// Upload limits
+const (
+	MaxRoomUploads       = 500 // uploads a room keeps track of for attachments
+	MaxCaptionLength     = MaxTextLength
+	uploadCleanupTimeout = 5 * time.Minute
+)
+
+var (
+	ErrTooManyUploads = errors.New("too many uploads in this room")
+	ErrUploadNotFound = errors.New("upload not found in this room")
+)
+
+// UploadStorage keeps uploaded files and serves them at public URLs.
+// Implementations must be safe for concurrent use.
+type UploadStorage interface {
+	// Put stores size bytes of body under key and returns the URL it is served at
+	Put(ctx context.Context, key, contentType string, body io.Reader, size int64) (string, error)
+	// DeletePrefix removes every file whose key starts with prefix
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// SharedUploadStorage is implemented by upload storages every instance reaches,
+// such as S3. The uploads of a migrating room stay in them and move along with
+// its snapshot; the files of other storages are deleted once the room has left.
+type SharedUploadStorage interface {
+	UploadStorage
+	Shared() bool
+}
+
+// WithUploadStorage tells the room where its uploads are kept
+func WithUploadStorage(storage UploadStorage) RoomOption {
+	return func(r *Room) {
+		shared, ok := storage.(SharedUploadStorage)
+		r.sharedUploads = ok && shared.Shared()
+	}
+}
+
+// Upload File uploaded to a room, embedded in chat with an attachment message
+type Upload struct {
+	UploadedAt  time.Time `json:"uploaded_at" example:"2024-01-01T12:00:00Z"`
+	ID          string    `json:"id" example:"3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e"`
+	URL         string    `json:"url" example:"/uploads/rooms/42/3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e.png"`
+	Name        string    `json:"name" example:"diagram.png"`
+	ContentType string    `json:"content_type" example:"image/png"`
+	Size        int64     `json:"size" example:"48213"`
+}
+
+// AttachmentMessage Payload of a member sharing an upload of the room
+type AttachmentMessage struct {
+	URL     string `json:"url" example:"/uploads/rooms/42/3f0c2b8d-9a10-4a55-9f59-0b8f5a5e5c1e.png"`
+	Caption string `json:"caption,omitempty" example:"The new architecture"`
+}
+
+// AttachmentNotification Broadcast for a shared upload, described by the server
+type AttachmentNotification struct {
+	Upload
+	Username string `json:"username" example:"JohnDoe"`
+	Caption  string `json:"caption,omitempty" example:"The new architecture"`
+}
+
+// UploadPrefix returns the key prefix of the uploads of room id
+func UploadPrefix(id ID) string {
+	return "rooms/" + strconv.FormatUint(uint64(id), 10) + "/"
+}
+
+// NewUploadKey returns a fresh key for an upload to room id with extension ext
+func NewUploadKey(id ID, ext string) (key, uploadID string) {
+	uploadID = uuid.New().String()
+	return UploadPrefix(id) + uploadID + ext, uploadID
+}
+
+// AddUpload makes upload available for attachment messages in the room
+func (r *Room) AddUpload(upload Upload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.uploads)+r.pendingUploads >= MaxRoomUploads {
+		return ErrTooManyUploads
+	}
+	r.addUploadLocked(upload)
+	return nil
+}
+
+// addUploadLocked records upload. Caller holds r.mu.
+func (r *Room) addUploadLocked(upload Upload) {
+	if r.uploads == nil {
+		r.uploads = make(map[string]Upload)
+	}
+	r.uploads[upload.URL] = upload
+}
+
+// UploadReservation is a slot of the room's uploads held while its file is stored
+type UploadReservation struct {
+	room *Room
+	done bool
+}
+
+// ReserveUpload claims a slot for an upload before its file is stored, so a room
+// at MaxRoomUploads doesn't get files it can't keep track of. The reservation
+// must be committed or released.
+func (r *Room) ReserveUpload() (*UploadReservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.uploads)+r.pendingUploads >= MaxRoomUploads {
+		return nil, ErrTooManyUploads
+	}
+	r.pendingUploads++
+	return &UploadReservation{room: r}, nil
+}
+
+// Commit makes the stored upload available for attachment messages. It returns
+// ErrRoomStopped if the room closed meanwhile, its files may already have been
+// deleted, so the caller deletes the one it stored.
+func (res *UploadReservation) Commit(upload Upload) error {
+	r := res.room
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if res.done {
+		return nil
+	}
+	res.done = true
+	r.pendingUploads--
+	select {
+	case <-r.Stop:
+		return ErrRoomStopped
+	default:
+	}
+	r.addUploadLocked(upload)
+	return nil
+}
+
+// Release gives the slot back if it wasn't committed
+func (res *UploadReservation) Release() {
+	r := res.room
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !res.done {
+		res.done = true
+		r.pendingUploads--
+	}
+}
+
+// uploadsSnapshot returns the uploads a migrating room hands off, none if its
+// files stay behind on this instance. Caller holds r.mu.
+func (r *Room) uploadsSnapshot() []Upload {
+	if !r.sharedUploads {
+		return nil
+	}
+	uploads := slices.Collect(maps.Values(r.uploads))
+	slices.SortFunc(uploads, func(a, b Upload) int { return a.UploadedAt.Compare(b.UploadedAt) })
+	return uploads
+}
+
+// upload returns the upload of the room served at url
+func (r *Room) upload(url string) (Upload, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	upload, ok := r.uploads[url]
+	return upload, ok
+}
+
+// handleAttachmentMessage shares an upload of the room. The name, type and size
+// are taken from the upload, not from the client.
+func (c *Client) handleAttachmentMessage(message Message) {
+	var attachment AttachmentMessage
+	if err := json.Unmarshal(message.Data, &attachment); err != nil || len(attachment.Caption) > MaxCaptionLength {
+		c.sendError(ErrCodeInvalidAttachment, "attachment needs the URL of an upload and a caption of at most 1000 characters")
+		return
+	}
+	upload, ok := c.Room.upload(attachment.URL)
+	if !ok {
+		c.sendError(ErrCodeInvalidAttachment, ErrUploadNotFound.Error())
+		return
+	}
+	if c.IsMuted() {
+		c.sendError(ErrCodeMuted, "you are muted by the host")
+		return
+	}
+	if !c.reserveBroadcast() {
+		return
+	}
+	c.Room.enqueue(c.spanContext(), mustMarshal(Message{Type: "attachment", Data: mustMarshal(AttachmentNotification{
+		Upload:   upload,
+		Username: c.Username,
+		Caption:  attachment.Caption,
+	})}))
+}
+
+// deleteUploads removes the uploads of a room that is gone for good from storage,
+// in the background
+func (h *Hub) deleteUploads(id ID) {
+	storage := h.Uploads
+	if storage == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), uploadCleanupTimeout)
+		defer cancel()
+		if err := storage.DeletePrefix(ctx, UploadPrefix(id)); err != nil {
+			log.Printf("Deleting uploads of room %d failed: %v", id, err)
+		}
+	}()
+}
+
+// LocalUploadStorage is an UploadStorage keeping files in a directory, served by
+// its ServeHTTP under baseURL
+type LocalUploadStorage struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalUploadStorage stores uploads in dir, creating it if needed. baseURL is
+// the URL path or absolute URL the storage is mounted at, such as "/uploads/".
+func NewLocalUploadStorage(dir, baseURL string) (*LocalUploadStorage, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	return &LocalUploadStorage{dir: dir, baseURL: baseURL}, nil
+}
+
+// BasePath returns the URL path the storage must be mounted at
+func (s *LocalUploadStorage) BasePath() string {
+	if i := strings.Index(s.baseURL, "://"); i >= 0 {
+		if j := strings.Index(s.baseURL[i+3:], "/"); j >= 0 {
+			return s.baseURL[i+3+j:]
+		}
+		return "/"
+	}
+	return s.baseURL
+}
+
+// Put writes the file to a temporary file and renames it, so a failed upload
+// never leaves a partial file behind
+func (s *LocalUploadStorage) Put(_ context.Context, key, _ string, body io.Reader, _ int64) (string, error) {
+	target := filepath.Join(s.dir, filepath.FromSlash(path.Clean("/"+key)))
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), "upload-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", err
+	}
+	return s.baseURL + key, nil
+}
+
+func (s *LocalUploadStorage) DeletePrefix(_ context.Context, prefix string) error {
+	return os.RemoveAll(filepath.Join(s.dir, filepath.FromSlash(path.Clean("/"+prefix))))
+}
+
+// ServeHTTP serves the stored files below BasePath, without directory listings.
+// Browsers must not sniff them into another type than the one they were accepted as.
+func (s *LocalUploadStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.StripPrefix(strings.TrimSuffix(s.BasePath(), "/"), http.FileServer(http.Dir(s.dir))).ServeHTTP(w, r)
+}
@@ -0,0 +1,228 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config locates the bucket of an S3UploadStorage. Any S3-compatible service
+// works; buckets are addressed path-style, endpoint/bucket/key.
+type S3Config struct {
+	Endpoint  string // e.g. https://s3.eu-central-1.amazonaws.com
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PublicURL string // optional, base URL the bucket is served at, endpoint/bucket/ if empty
+}
+
+// S3UploadStorage is an UploadStorage keeping files in an S3 bucket. Requests are
+// signed with AWS Signature Version 4.
+type S3UploadStorage struct {
+	cfg    S3Config
+	client *http.Client
+}
+
+// NewS3UploadStorage stores uploads in the bucket of cfg
+func NewS3UploadStorage(cfg S3Config) (*S3UploadStorage, error) {
+	if cfg.Endpoint == "" || cfg.Region == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("S3 upload storage needs an endpoint, region, bucket and credentials")
+	}
+	if _, err := url.ParseRequestURI(cfg.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = cfg.Endpoint + "/" + cfg.Bucket + "/"
+	} else if !strings.HasSuffix(cfg.PublicURL, "/") {
+		cfg.PublicURL += "/"
+	}
+	return &S3UploadStorage{cfg: cfg, client: &http.Client{Timeout: time.Minute}}, nil
+}
+
+// Put uploads the file with a single PUT request
+func (s *S3UploadStorage) Put(ctx context.Context, key, contentType string, body io.Reader, size int64) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(body, size))
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if err := s.do(req, data, nil); err != nil {
+		return "", err
+	}
+	return s.cfg.PublicURL + key, nil
+}
+
+// Shared reports that every instance reaches the bucket, so the uploads of a
+// migrating room stay where they are
+func (s *S3UploadStorage) Shared() bool { return true }
+
+// DeletePrefix lists the objects under prefix and deletes them one by one
+func (s *S3UploadStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.Endpoint+"/"+s.cfg.Bucket+"?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		var list struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := s.do(req, nil, &list); err != nil {
+			return err
+		}
+		for _, object := range list.Contents {
+			req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(object.Key), nil)
+			if err != nil {
+				return err
+			}
+			if err := s.do(req, nil, nil); err != nil {
+				return err
+			}
+		}
+		if !list.IsTruncated || list.NextContinuationToken == "" {
+			return nil
+		}
+		token = list.NextContinuationToken
+	}
+}
+
+func (s *S3UploadStorage) objectURL(key string) string {
+	return s.cfg.Endpoint + "/" + s.cfg.Bucket + "/" + escapePath(key)
+}
+
+// do signs and sends req, decoding an XML response into result if it is not nil
+func (s *S3UploadStorage) do(req *http.Request, payload []byte, result any) error {
+	s.sign(req, payload, time.Now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("S3 %s %s: status %d: %s", req.Method, req.URL.Path, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	if result == nil {
+		return nil
+	}
+	return xml.NewDecoder(resp.Body).Decode(result)
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req
+func (s *S3UploadStorage) sign(req *http.Request, payload []byte, now time.Time) {
+	payloadHash := sha256.Sum256(payload)
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), date)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.cfg.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escapePath URI-encodes every segment of p as Signature Version 4 requires
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery encodes query sorted by key with Signature Version 4 escaping
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode escapes everything but unreserved characters
+func uriEncode(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
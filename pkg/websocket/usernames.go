@@ -0,0 +1,211 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UniqueScope controls how widely a username must be unique
+type UniqueScope string
+
+const (
+	UniqueNone   UniqueScope = "none"   // duplicates are allowed
+	UniqueRoom   UniqueScope = "room"   // unique among the members of a room
+	UniqueTenant UniqueScope = "tenant" // unique across the rooms of an API key
+	UniqueGlobal UniqueScope = "global" // reserved server-wide for the session that claimed it
+)
+
+// GlobalReservationTTL is how long a globally reserved username stays with its
+// session after the session's last connection closed
+const GlobalReservationTTL = 24 * time.Hour
+
+// Error codes sent in reply to rename messages
+const (
+	ErrCodeInvalidUsername = "invalid_username"
+	ErrCodeUsernameTaken   = "username_taken"
+)
+
+var ErrUsernameTaken = errors.New("username is already taken")
+
+// RenameMessage Sent by a client to change its username
+type RenameMessage struct {
+	Username string `json:"username" example:"JaneDoe"`
+}
+
+// RenameNotification Sent to clients when a member changes its username
+type RenameNotification struct {
+	From string `json:"from" example:"JohnDoe"`
+	To   string `json:"to" example:"JaneDoe"`
+}
+
+// nameClaim is a username held by one holder, a session ID or a single connection
+type nameClaim struct {
+	releasedAt time.Time
+	holder     string
+	refs       int
+}
+
+// UsernameRegistry enforces username uniqueness within a scope. A nil
+// registry allows duplicates. The default name is never reserved.
+type UsernameRegistry struct {
+	claims    map[string]*nameClaim
+	lastPrune time.Time
+	scope     UniqueScope
+	mu        sync.Mutex
+}
+
+// ParseUniqueScope returns the scope named s
+func ParseUniqueScope(s string) (UniqueScope, error) {
+	switch scope := UniqueScope(strings.ToLower(strings.TrimSpace(s))); scope {
+	case UniqueNone, UniqueRoom, UniqueTenant, UniqueGlobal:
+		return scope, nil
+	}
+	return "", errors.New("username scope must be none, room, tenant or global")
+}
+
+// NewUsernameRegistry creates a registry enforcing scope
+func NewUsernameRegistry(scope UniqueScope) *UsernameRegistry {
+	return &UsernameRegistry{claims: make(map[string]*nameClaim), scope: scope}
+}
+
+// Scope returns the enforced scope
+func (u *UsernameRegistry) Scope() UniqueScope {
+	if u == nil {
+		return UniqueNone
+	}
+	return u.scope
+}
+
+// key returns the claim key of username in room, false if the name is not enforced.
+// Without tenancy every room belongs to the same tenant.
+func (u *UsernameRegistry) key(room *Room, username string) (string, bool) {
+	if u == nil || username == room.brand().DefaultName {
+		return "", false
+	}
+	name := strings.ToLower(username)
+	switch u.scope {
+	case UniqueRoom:
+		return "r:" + strconv.FormatUint(uint64(room.ID), 10) + ":" + name, true
+	case UniqueTenant:
+		return "t:" + room.tenant + ":" + name, true
+	case UniqueGlobal:
+		return "g:" + name, true
+	}
+	return "", false
+}
+
+// Claim reserves username in room for holder. The same holder may claim a name
+// several times; it is freed once every release was called, and in the global
+// scope only after GlobalReservationTTL. An empty holder stands for a single
+// connection. ErrUsernameTaken is returned if someone else holds the name.
+func (u *UsernameRegistry) Claim(room *Room, username, holder string) (release func(), err error) {
+	key, enforced := u.key(room, username)
+	if !enforced {
+		return func() {}, nil
+	}
+	if holder == "" {
+		holder = uuid.New().String()
+	}
+
+	now := time.Now()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.prune(now)
+	claim := u.claims[key]
+	if claim != nil && claim.holder != holder {
+		if claim.refs > 0 || !u.expired(claim, now) {
+			return nil, ErrUsernameTaken
+		}
+		claim = nil
+	}
+	if claim == nil {
+		claim = &nameClaim{holder: holder}
+		u.claims[key] = claim
+	}
+	claim.refs++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { u.release(key, claim) })
+	}, nil
+}
+
+// release drops one reference of claim
+func (u *UsernameRegistry) release(key string, claim *nameClaim) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	claim.refs--
+	if claim.refs > 0 || u.claims[key] != claim {
+		return
+	}
+	if u.scope == UniqueGlobal {
+		claim.releasedAt = time.Now()
+		return
+	}
+	delete(u.claims, key)
+}
+
+// expired reports whether an unreferenced claim may be taken over. Caller must hold u.mu.
+func (u *UsernameRegistry) expired(claim *nameClaim, now time.Time) bool {
+	return u.scope != UniqueGlobal || now.Sub(claim.releasedAt) >= GlobalReservationTTL
+}
+
+// prune drops expired global reservations at most once a minute. Caller must hold u.mu.
+func (u *UsernameRegistry) prune(now time.Time) {
+	if u.scope != UniqueGlobal || now.Sub(u.lastPrune) < time.Minute {
+		return
+	}
+	u.lastPrune = now
+	for key, claim := range u.claims {
+		if claim.refs == 0 && u.expired(claim, now) {
+			delete(u.claims, key)
+		}
+	}
+}
+
+// handleRenameMessage changes the client's username if it is valid, not banned
+// and free in the configured scope, then announces the change
+func (c *Client) handleRenameMessage(message Message) {
+	var rename RenameMessage
+	if err := json.Unmarshal(message.Data, &rename); err != nil {
+		return
+	}
+	username := strings.TrimSpace(rename.Username)
+	if err := validateUsername(username); err != nil {
+		c.sendError(ErrCodeInvalidUsername, err.Error())
+		return
+	}
+	from := c.Username
+	if username == from {
+		return
+	}
+	if _, banned := c.Room.IsBanned(username, ""); banned && !c.IsHost() {
+		c.sendError(ErrCodeUsernameTaken, "username is not available")
+		return
+	}
+	// A change of case keeps the claim, which is case-insensitive
+	if !strings.EqualFold(username, from) {
+		releaseName, err := c.usernames.Claim(c.Room, username, c.SessionID)
+		if err != nil {
+			c.sendError(ErrCodeUsernameTaken, err.Error())
+			return
+		}
+		if c.releaseName != nil {
+			c.releaseName()
+		}
+		c.releaseName = releaseName
+	}
+
+	c.Room.mu.Lock()
+	c.Username = username
+	c.Room.mu.Unlock()
+	c.Room.broadcastNotification("rename", RenameNotification{From: from, To: username})
+	c.Room.renameHand(c.authorID(), username)
+	c.Room.renameRead(from, username)
+}
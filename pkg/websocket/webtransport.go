@@ -0,0 +1,218 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
+)
+
+var errNotHTTP3 = errors.New("webtransport: request did not arrive over HTTP/3")
+
+// webTransportHeaderSize is the size of a frame header: the message type and the
+// big-endian payload length
+const webTransportHeaderSize = 5
+
+// webTransportConn adapts a WebTransport session to the Conn interface. Messages
+// travel on one bidirectional stream opened by the server, each framed as a byte
+// with the WebSocket opcode of the message (1 text, 2 binary, 8 close, 9 ping,
+// 10 pong), its length as a big-endian uint32 and the payload. Clients answer
+// pings with a pong carrying the same payload.
+type webTransportConn struct {
+	session     *webtransport.Session
+	stream      *webtransport.Stream
+	reader      *bufio.Reader
+	pongHandler func(string) error
+	readLimit   int64
+	closeCode   webtransport.SessionErrorCode
+	writeMu     sync.Mutex
+}
+
+// newWebTransportConn opens the message stream of session
+func newWebTransportConn(session *webtransport.Session) (*webTransportConn, error) {
+	stream, err := session.OpenStream()
+	if err != nil {
+		return nil, err
+	}
+	return &webTransportConn{
+		session: session,
+		stream:  stream,
+		reader:  bufio.NewReader(stream),
+	}, nil
+}
+
+func (c *webTransportConn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+func (c *webTransportConn) SetPongHandler(h func(appData string) error) {
+	c.pongHandler = h
+}
+
+func (c *webTransportConn) SetReadDeadline(t time.Time) error {
+	return c.stream.SetReadDeadline(t)
+}
+
+// SetWriteDeadline serializes with control frames, which set their own deadline
+func (c *webTransportConn) SetWriteDeadline(t time.Time) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.stream.SetWriteDeadline(t)
+}
+
+// ReadMessage returns the next text or binary message, answering pings and
+// passing pongs to the pong handler. A close frame ends the connection.
+func (c *webTransportConn) ReadMessage() (int, []byte, error) {
+	var header [webTransportHeaderSize]byte
+	for {
+		if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+			return 0, nil, err
+		}
+		size := binary.BigEndian.Uint32(header[1:])
+		if c.readLimit > 0 && int64(size) > c.readLimit {
+			return 0, nil, websocket.ErrReadLimit
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(c.reader, payload); err != nil {
+			return 0, nil, err
+		}
+
+		switch messageType := int(header[0]); messageType {
+		case websocket.TextMessage, websocket.BinaryMessage:
+			return messageType, payload, nil
+		case websocket.PingMessage:
+			if err := c.writeFrame(websocket.PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+		case websocket.PongMessage:
+			if c.pongHandler != nil {
+				if err := c.pongHandler(string(payload)); err != nil {
+					return 0, nil, err
+				}
+			}
+		case websocket.CloseMessage:
+			code := websocket.CloseNoStatusReceived
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			return 0, nil, &websocket.CloseError{Code: code}
+		}
+	}
+}
+
+func (c *webTransportConn) WriteMessage(messageType int, data []byte) error {
+	return c.writeFrame(messageType, data)
+}
+
+// WriteControl writes a ping or close frame. The code of a close frame also
+// closes the session, so browsers see it on WebTransport.closed.
+func (c *webTransportConn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if messageType == websocket.CloseMessage && len(data) >= 2 {
+		c.closeCode = webtransport.SessionErrorCode(binary.BigEndian.Uint16(data))
+	}
+	if err := c.stream.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+	return c.writeFrameLocked(messageType, data)
+}
+
+// Close closes the session with the code of the close frame sent, if any
+func (c *webTransportConn) Close() error {
+	c.writeMu.Lock()
+	code := c.closeCode
+	c.writeMu.Unlock()
+	return c.session.CloseWithError(code, "")
+}
+
+// writeFrame writes a frame, serializing concurrent writers
+func (c *webTransportConn) writeFrame(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.writeFrameLocked(messageType, data)
+}
+
+// writeFrameLocked writes a frame in a single stream write. Caller holds writeMu.
+func (c *webTransportConn) writeFrameLocked(messageType int, data []byte) error {
+	frame := make([]byte, webTransportHeaderSize+len(data))
+	frame[0] = byte(messageType)
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	copy(frame[webTransportHeaderSize:], data)
+	_, err := c.stream.Write(frame)
+	return err
+}
+
+// webTransportWriter exposes the HTTP/3 stream of the request, wrapped by gin and
+// the middlewares, to the WebTransport upgrade
+type webTransportWriter struct {
+	gin.ResponseWriter
+	http3.Settingser
+	http3.HTTPStreamer
+}
+
+// EnableWebTransport serves the WebTransport endpoint over HTTP/3. handler serves
+// the requests, and must route the endpoint to HandleWebTransportWithKeys. The
+// returned server is started with the TLS configuration and address of the HTTPS
+// server; sessions from other origins are checked against Origins.
+func (h *Handler) EnableWebTransport(handler http.Handler) *webtransport.Server {
+	h3 := &http3.Server{Handler: handler}
+	webtransport.ConfigureHTTP3Server(h3)
+	h.WebTransport = &webtransport.Server{
+		H3:          h3,
+		CheckOrigin: h.Origins.CheckOrigin,
+	}
+	return h.WebTransport
+}
+
+// HandleWebTransportWithKeys creates a handler accepting WebTransport sessions,
+// verifying host tokens with keys. Clients join rooms as over WebSocket.
+func (h *Handler) HandleWebTransportWithKeys(keys *TokenKeys) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.handleWebTransport(c, keys)
+	}
+}
+
+// handleWebTransport joins a room over a WebTransport session. Sessions are
+// opened with an extended CONNECT request to /wt/{room_id}, taking the query
+// parameters of the WebSocket endpoint. OpenAPI has no CONNECT operations, so
+// the endpoint is documented in the README rather than in Swagger.
+func (h *Handler) handleWebTransport(c *gin.Context, keys *TokenKeys) {
+	h.serveRoom(c, c.Param("room_id"), keys, h.upgradeWebTransport)
+}
+
+// upgradeWebTransport accepts the WebTransport session of the request
+func (h *Handler) upgradeWebTransport(c *gin.Context) (Conn, *countingConn, error) {
+	var w http.ResponseWriter = c.Writer
+	for {
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = unwrapper.Unwrap()
+	}
+	settingser, ok := w.(http3.Settingser)
+	streamer, isStream := w.(http3.HTTPStreamer)
+	if h.WebTransport == nil || !ok || !isStream {
+		return nil, nil, errNotHTTP3
+	}
+
+	session, err := h.WebTransport.Upgrade(webTransportWriter{c.Writer, settingser, streamer}, c.Request)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := newWebTransportConn(session)
+	if err != nil {
+		session.CloseWithError(0, "")
+		return nil, nil, err
+	}
+	return conn, nil, nil
+}
@@ -11,6 +11,8 @@ class ChatWidget {
         this.username = '';
         this.isConnected = false;
         this.reconnectAttempts = 0;
+        this.lastSeq = 0;
+        this.ackTimer = null;
         this.maxReconnectAttempts = window.ChattersApp?.config?.RECONNECT_ATTEMPTS || 5;
         this.reconnectDelayBase = window.ChattersApp?.config?.RECONNECT_DELAY || 1000;
         this.fileManager = null;
@@ -101,6 +103,8 @@ class ChatWidget {
             this.currentRoom = roomId;
             this.username = username;
             this.hostToken = hostToken;
+            this.resumeToken = null;
+            this.lastSeq = 0;
             await this.connectWebSocket(roomId, username, password, hostToken);
         } catch (error) {
             console.error('Join room error:', error);
@@ -115,11 +119,16 @@ class ChatWidget {
         try {
             let wsUrl = `${window.ChattersApp.config.WS_BASE_URL}/${roomId}?username=${encodeURIComponent(username)}`;
             if (password) {
-                wsUrl += `&password=${encodeURIComponent(password)}`;
+                const ticket = await this.fetchJoinTicket(roomId, password);
+                wsUrl += `&ticket=${encodeURIComponent(ticket)}`;
             }
             if (hostToken) {
                 wsUrl += `&host_token=${encodeURIComponent(hostToken)}`;
             }
+            if (this.resumeToken) {
+                wsUrl += `&resume=${encodeURIComponent(this.resumeToken)}`;
+                if (this.lastSeq) wsUrl += `&since=${this.lastSeq}`;
+            }
             this.ws = new WebSocket(wsUrl);
 
             this.ws.onopen = () => {
@@ -198,6 +207,19 @@ class ChatWidget {
         }
     }
 
+    async fetchJoinTicket(roomId, password) {
+        const response = await fetch(`${window.ChattersApp.config.API_BASE_URL}/rooms/${roomId}/join-ticket`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ password })
+        });
+        if (!response.ok) {
+            throw new Error('Invalid room password');
+        }
+        const data = await response.json();
+        return data.ticket;
+    }
+
     handleReconnect() {
         if (this.reconnectAttempts < this.maxReconnectAttempts) {
             this.reconnectAttempts++;
@@ -215,17 +237,49 @@ class ChatWidget {
 
     handleMessage(message) {
         try {
+            if (message.seq) {
+                if (message.seq <= this.lastSeq) return;
+                this.lastSeq = message.seq;
+                this.scheduleAck();
+            }
             switch (message.type) {
                 case 'chat':
                     this.addChatMessage(message.data);
                     break;
+                case 'system':
+                    this.addSystemMessage(message.data.text);
+                    break;
+                case 'action':
+                    this.addSystemMessage(`* ${message.data.username} ${message.data.text}`);
+                    break;
+                case 'commands':
+                    message.data.commands.forEach(command => {
+                        this.addSystemMessage(`${command.usage} - ${command.description}`);
+                    });
+                    break;
                 case 'join':
                     this.addSystemMessage(`${message.data.username} joined`);
-                    this.updateOnlineCount(message.data.onlineCount);
+                    this.updateOnlineCount(message.data.online_count ?? message.data.onlineCount);
                     break;
                 case 'leave':
                     this.addSystemMessage(`${message.data.username} left`);
-                    this.updateOnlineCount(message.data.onlineCount);
+                    this.updateOnlineCount(message.data.online_count ?? message.data.onlineCount);
+                    break;
+                case 'members':
+                    this.updateOnlineCount(message.data.members.length);
+                    break;
+                case 'welcome':
+                    this.resumeToken = message.data.resume_token;
+                    this.connectionId = message.data.connection_id;
+                    break;
+                case 'preferences':
+                    this.preferences = message.data;
+                    break;
+                case 'reconnecting':
+                    this.addSystemMessage(`${message.data.username} lost connection`);
+                    break;
+                case 'reconnected':
+                    this.addSystemMessage(`${message.data.username} reconnected`);
                     break;
                 case 'error':
                     this.showNotification('Error', message.data.message, 'error');
@@ -236,6 +290,16 @@ class ChatWidget {
         }
     }
 
+    scheduleAck() {
+        if (this.ackTimer) return;
+        this.ackTimer = setTimeout(() => {
+            this.ackTimer = null;
+            if (this.ws && this.ws.readyState === WebSocket.OPEN) {
+                this.ws.send(JSON.stringify({ type: 'ack', data: { seq: this.lastSeq } }));
+            }
+        }, 1000);
+    }
+
     addChatMessage(data) {
         const messagesContainer = document.getElementById('chatMessages');
         if (!messagesContainer) return;
@@ -365,6 +429,8 @@ class ChatWidget {
             this.currentRoom = null;
             this.hostToken = null;
             this.roomPassword = null;
+            this.resumeToken = null;
+            this.lastSeq = 0;
             this.reconnectAttempts = 0;
             
             // Hide host controls
@@ -497,12 +563,19 @@ class ChatWidget {
     async changeRoomPassword() {
         try {
             const newPassword = document.getElementById('newPassword')?.value || '';
-            
+
+            const roomResponse = await fetch(`${window.ChattersApp.config.API_BASE_URL}/rooms/${this.currentRoom}`);
+            if (!roomResponse.ok) {
+                throw new Error('Failed to load room settings');
+            }
+            const etag = roomResponse.headers.get('ETag');
+
             const response = await fetch(`${window.ChattersApp.config.API_BASE_URL}/rooms/${this.currentRoom}/password`, {
                 method: 'PUT',
                 headers: {
                     'Content-Type': 'application/json',
-                    'Authorization': this.hostToken
+                    'Authorization': this.hostToken,
+                    'If-Match': etag
                 },
                 body: JSON.stringify({ new_password: newPassword })
             });